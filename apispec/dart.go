@@ -0,0 +1,512 @@
+package apispec
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// GenerateDart returns a Dart library with a class per model and a client method per route.
+// It needs package:http; every model has fromJson and toJson.
+func GenerateDart(routes []Route) string {
+	g := &dartGenerator{names: make(map[reflect.Type]string), taken: make(map[string]reflect.Type)}
+	for _, route := range routes {
+		if route.NoClient {
+			continue
+		}
+		for _, model := range []interface{}{route.Request, route.Data, route.Body} {
+			if model != nil {
+				g.collect(reflect.TypeOf(model))
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(dartHeader)
+	b.WriteString("class SmlGoApiClient {\n")
+	b.WriteString(dartClientCore)
+	for _, route := range routes {
+		if !route.NoClient {
+			g.writeMethod(&b, route)
+		}
+	}
+	b.WriteString("}\n")
+	for _, t := range g.order {
+		b.WriteString("\n")
+		g.writeClass(&b, t)
+	}
+	return b.String()
+}
+
+const dartHeader = `// GENERATED CODE - DO NOT MODIFY BY HAND.
+// Generated by smlgoapi from its route registry; download a fresh copy from GET /v1/client/dart.
+
+import 'dart:convert';
+
+import 'package:http/http.dart' as http;
+
+/// Error response or failed request. [body] is the decoded response, if any.
+class ApiException implements Exception {
+  final int statusCode;
+  final String message;
+  final dynamic body;
+
+  ApiException(this.statusCode, this.message, [this.body]);
+
+  @override
+  String toString() => 'ApiException($statusCode): $message';
+}
+
+`
+
+const dartClientCore = `  final String baseUrl;
+
+  /// Sent with every request, e.g. {'Authorization': 'Bearer ...'} or {'X-API-Key': '...'}
+  final Map<String, String> headers;
+
+  final http.Client _client;
+
+  SmlGoApiClient(this.baseUrl, {Map<String, String>? headers, http.Client? client})
+      : headers = headers ?? {},
+        _client = client ?? http.Client();
+
+  void close() => _client.close();
+
+  Future<dynamic> _send(String method, String path, {Map<String, dynamic>? query, Object? body}) async {
+    final params = <String, dynamic>{};
+    query?.forEach((key, value) {
+      if (value == null) return;
+      params[key] = value is List ? value.map((v) => '$v').toList() : '$value';
+    });
+    final uri = Uri.parse('$baseUrl$path').replace(queryParameters: params.isEmpty ? null : params);
+
+    final request = http.Request(method, uri)
+      ..headers.addAll(headers)
+      ..headers['Accept'] = 'application/json';
+    if (body != null) {
+      request.headers['Content-Type'] = 'application/json';
+      request.body = jsonEncode(body);
+    }
+
+    final response = await http.Response.fromStream(await _client.send(request));
+    final text = utf8.decode(response.bodyBytes);
+    final decoded = text.isEmpty ? null : jsonDecode(text);
+    if (response.statusCode >= 400 || (decoded is Map && decoded['success'] == false)) {
+      final message = decoded is Map ? '${decoded['error'] ?? decoded['message'] ?? response.reasonPhrase}' : text;
+      throw ApiException(response.statusCode, message, decoded);
+    }
+    return decoded;
+  }
+`
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type dartGenerator struct {
+	names map[reflect.Type]string // Dart class name of each model struct
+	taken map[string]reflect.Type
+	order []reflect.Type
+}
+
+// dartField is a JSON field of a model struct, after flattening embedded structs
+type dartField struct {
+	json      string
+	name      string // Dart field name
+	t         reflect.Type
+	omitEmpty bool
+}
+
+// collect registers the structs reachable from t
+func (g *dartGenerator) collect(t reflect.Type) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		g.collect(t.Elem())
+	case reflect.Struct:
+		if t == timeType {
+			return
+		}
+		if _, ok := g.names[t]; ok {
+			return
+		}
+		name := t.Name()
+		if name == "" {
+			name = "Anonymous"
+		}
+		// Same name in two packages: qualify the later one, e.g. ServicesStatus
+		if _, ok := g.taken[name]; ok {
+			pkg := path.Base(t.PkgPath())
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		g.names[t] = name
+		g.taken[name] = t
+		g.order = append(g.order, t)
+		for _, field := range dartFields(t) {
+			g.collect(field.t)
+		}
+	}
+}
+
+// dartFields lists the fields encoding/json writes for t
+func dartFields(t reflect.Type) []dartField {
+	var fields []dartField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, dartFields(embedded)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, dartField{
+			json:      name,
+			name:      dartIdentifier(name),
+			t:         field.Type,
+			omitEmpty: strings.Contains(options, "omitempty"),
+		})
+	}
+	return fields
+}
+
+func (g *dartGenerator) writeClass(b *strings.Builder, t reflect.Type) {
+	name := g.names[t]
+	fields := dartFields(t)
+
+	fmt.Fprintf(b, "class %s {\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(b, "  final %s %s;\n", g.dartType(f.t), f.name)
+	}
+	if len(fields) > 0 {
+		b.WriteString("\n")
+	}
+
+	if len(fields) == 0 {
+		fmt.Fprintf(b, "  const %s();\n\n", name)
+	} else {
+		fmt.Fprintf(b, "  const %s({\n", name)
+		for _, f := range fields {
+			switch def := dartDefault(f); {
+			case isNullable(f.t):
+				fmt.Fprintf(b, "    this.%s,\n", f.name)
+			case def != "":
+				fmt.Fprintf(b, "    this.%s = %s,\n", f.name, def)
+			default:
+				fmt.Fprintf(b, "    required this.%s,\n", f.name)
+			}
+		}
+		b.WriteString("  });\n\n")
+	}
+
+	fmt.Fprintf(b, "  factory %s.fromJson(Map<String, dynamic> json) => %s(\n", name, name)
+	for _, f := range fields {
+		fmt.Fprintf(b, "        %s: %s,\n", f.name, g.decode(f.t, fmt.Sprintf("json['%s']", f.json), 0))
+	}
+	b.WriteString("      );\n\n")
+
+	b.WriteString("  Map<String, dynamic> toJson() => <String, dynamic>{\n")
+	for _, f := range fields {
+		switch {
+		case isNullable(f.t):
+			// Public fields are not promoted by the null check, so conversions need "!"
+			value := g.encode(f.t, f.name, 0)
+			if value != f.name {
+				value = g.encode(f.t, f.name+"!", 0)
+			}
+			fmt.Fprintf(b, "        if (%s != null) '%s': %s,\n", f.name, f.json, value)
+		case f.omitEmpty && dartNonEmpty(f) != "":
+			fmt.Fprintf(b, "        if (%s) '%s': %s,\n", dartNonEmpty(f), f.json, g.encode(f.t, f.name, 0))
+		default:
+			fmt.Fprintf(b, "        '%s': %s,\n", f.json, g.encode(f.t, f.name, 0))
+		}
+	}
+	b.WriteString("      };\n}\n")
+}
+
+func (g *dartGenerator) writeMethod(b *strings.Builder, route Route) {
+	var args, named []string
+	dartPath := route.Path
+	for _, param := range route.PathParams() {
+		name := dartIdentifier(param)
+		args = append(args, "String "+name)
+		dartPath = strings.Replace(dartPath, ":"+param, "${Uri.encodeComponent("+name+")}", 1)
+		dartPath = strings.Replace(dartPath, "*"+param, "${Uri.encodeComponent("+name+")}", 1)
+	}
+	if route.Request != nil {
+		args = append(args, g.names[structType(reflect.TypeOf(route.Request))]+" request")
+	}
+	for _, param := range route.Query {
+		named = append(named, dartParamType(param.Type)+"? "+dartIdentifier(param.Name))
+	}
+	if len(named) > 0 {
+		args = append(args, "{"+strings.Join(named, ", ")+"}")
+	}
+
+	var result reflect.Type
+	source := "body"
+	switch {
+	case route.Data != nil:
+		result = reflect.TypeOf(route.Data)
+		source = "(body as Map<String, dynamic>)['data']"
+	case route.Body != nil:
+		result = reflect.TypeOf(route.Body)
+	}
+	returnType := "dynamic"
+	if result != nil {
+		returnType = g.dartType(result)
+	}
+
+	b.WriteString("\n")
+	if route.Summary != "" {
+		fmt.Fprintf(b, "  /// %s\n  ///\n", route.Summary)
+	}
+	fmt.Fprintf(b, "  /// `%s %s`\n", route.Method, route.Path)
+	fmt.Fprintf(b, "  Future<%s> %s(%s) async {\n", returnType, route.Name, strings.Join(args, ", "))
+
+	call := fmt.Sprintf("'%s', '%s'", route.Method, dartPath)
+	var query []string
+	if route.Request != nil {
+		if route.Method == http.MethodGet {
+			query = append(query, "...request.toJson()")
+		} else {
+			call += ", body: request.toJson()"
+		}
+	}
+	for _, param := range route.Query {
+		name := dartIdentifier(param.Name)
+		query = append(query, fmt.Sprintf("if (%s != null) '%s': %s", name, param.Name, name))
+	}
+	if len(query) > 0 {
+		call += ", query: {" + strings.Join(query, ", ") + "}"
+	}
+
+	if result == nil {
+		fmt.Fprintf(b, "    return _send(%s);\n", call)
+	} else {
+		fmt.Fprintf(b, "    final body = await _send(%s);\n", call)
+		fmt.Fprintf(b, "    return %s;\n", g.decode(result, source, 0))
+	}
+	b.WriteString("  }\n")
+}
+
+func (g *dartGenerator) dartType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.dartType(t.Elem()) + "?"
+	case reflect.String:
+		return "String"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "double"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "String" // base64
+		}
+		return "List<" + g.dartType(t.Elem()) + ">"
+	case reflect.Map:
+		return "Map<String, " + g.dartType(t.Elem()) + ">"
+	case reflect.Struct:
+		if t == timeType {
+			return "DateTime"
+		}
+		return g.names[t]
+	}
+	return "dynamic"
+}
+
+// decode returns the Dart expression converting the JSON value src to t. Missing values become
+// the Go zero value, except for pointers, which stay null.
+func (g *dartGenerator) decode(t reflect.Type, src string, depth int) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return fmt.Sprintf("%s == null ? null : %s", src, g.decode(t.Elem(), src, depth))
+	case reflect.String:
+		return fmt.Sprintf("(%s as String?) ?? ''", src)
+	case reflect.Bool:
+		return fmt.Sprintf("(%s as bool?) ?? false", src)
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("(%s as num?)?.toDouble() ?? 0", src)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return fmt.Sprintf("(%s as String?) ?? ''", src)
+		}
+		e := fmt.Sprintf("e%d", depth)
+		return fmt.Sprintf("((%s as List<dynamic>?) ?? const []).map((%s) => %s).toList()", src, e, g.decode(t.Elem(), e, depth+1))
+	case reflect.Map:
+		k, v := fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth)
+		return fmt.Sprintf("((%s as Map<String, dynamic>?) ?? const {}).map((%s, %s) => MapEntry(%s, %s))", src, k, v, k, g.decode(t.Elem(), v, depth+1))
+	case reflect.Struct:
+		if t == timeType {
+			return fmt.Sprintf("DateTime.parse(%s as String)", src)
+		}
+		return fmt.Sprintf("%s.fromJson((%s as Map<String, dynamic>?) ?? const {})", g.names[t], src)
+	case reflect.Interface:
+		return src
+	}
+	if g.dartType(t) == "int" {
+		return fmt.Sprintf("(%s as num?)?.toInt() ?? 0", src)
+	}
+	return src
+}
+
+// encode returns the Dart expression converting the non-null value src of type t to JSON
+func (g *dartGenerator) encode(t reflect.Type, src string, depth int) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.encode(t.Elem(), src, depth)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 || !needsEncoding(t.Elem()) {
+			return src
+		}
+		e := fmt.Sprintf("e%d", depth)
+		return fmt.Sprintf("%s.map((%s) => %s).toList()", src, e, g.encodeElement(t.Elem(), e, depth+1))
+	case reflect.Map:
+		if !needsEncoding(t.Elem()) {
+			return src
+		}
+		k, v := fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth)
+		return fmt.Sprintf("%s.map((%s, %s) => MapEntry(%s, %s))", src, k, v, k, g.encodeElement(t.Elem(), v, depth+1))
+	case reflect.Struct:
+		if t == timeType {
+			return src + ".toUtc().toIso8601String()"
+		}
+		return src + ".toJson()"
+	}
+	return src
+}
+
+// encodeElement encodes a list or map element, which may be null when t is a pointer
+func (g *dartGenerator) encodeElement(t reflect.Type, src string, depth int) string {
+	if t.Kind() == reflect.Ptr {
+		return fmt.Sprintf("%s == null ? null : %s", src, g.encode(t.Elem(), src, depth))
+	}
+	return g.encode(t, src, depth)
+}
+
+// needsEncoding reports whether values of t differ between Dart and JSON
+func needsEncoding(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return needsEncoding(t.Elem())
+	case reflect.Struct:
+		return true
+	}
+	return false
+}
+
+func isNullable(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface
+}
+
+// dartDefault is the constructor default of an omitempty field, "" when it must be passed
+func dartDefault(f dartField) string {
+	if !f.omitEmpty {
+		return ""
+	}
+	switch f.t.Kind() {
+	case reflect.String:
+		return "''"
+	case reflect.Bool:
+		return "false"
+	case reflect.Float32, reflect.Float64:
+		return "0"
+	case reflect.Slice, reflect.Array:
+		if f.t.Elem().Kind() == reflect.Uint8 {
+			return "''"
+		}
+		return "const []"
+	case reflect.Map:
+		return "const {}"
+	case reflect.Struct:
+		return ""
+	}
+	if (&dartGenerator{}).dartType(f.t) == "int" {
+		return "0"
+	}
+	return ""
+}
+
+// dartNonEmpty is the condition under which encoding/json writes an omitempty field
+func dartNonEmpty(f dartField) string {
+	switch f.t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return f.name + ".isNotEmpty"
+	case reflect.Bool:
+		return f.name
+	case reflect.Struct:
+		return ""
+	}
+	return f.name + " != 0"
+}
+
+func dartParamType(goType string) string {
+	switch goType {
+	case "int":
+		return "int"
+	case "bool":
+		return "bool"
+	case "double":
+		return "double"
+	}
+	return "String"
+}
+
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+var dartReserved = map[string]bool{
+	"assert": true, "break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "default": true, "do": true, "else": true, "enum": true, "extends": true,
+	"false": true, "final": true, "finally": true, "for": true, "if": true, "in": true, "is": true,
+	"new": true, "null": true, "rethrow": true, "return": true, "super": true, "switch": true,
+	"this": true, "throw": true, "true": true, "try": true, "var": true, "void": true, "while": true,
+	"with": true, "hashCode": true, "runtimeType": true, "toString": true, "toJson": true,
+}
+
+// dartIdentifier turns a JSON or parameter name into a lowerCamelCase Dart identifier,
+// e.g. similarity_score -> similarityScore
+func dartIdentifier(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == '.' })
+	if len(parts) == 0 {
+		return "value"
+	}
+	var b strings.Builder
+	for i, part := range parts {
+		if i == 0 {
+			b.WriteString(strings.ToLower(part[:1]) + part[1:])
+		} else {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	id := b.String()
+	if id[0] >= '0' && id[0] <= '9' {
+		id = "n" + id
+	}
+	if dartReserved[id] {
+		id += "Value"
+	}
+	return id
+}
@@ -0,0 +1,169 @@
+package apispec
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Endpoint is a route as listed by the documentation endpoints
+type Endpoint struct {
+	Name       string   `json:"name"`
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Summary    string   `json:"summary,omitempty"`
+	PathParams []string `json:"path_params,omitempty"`
+	Query      []Param  `json:"query,omitempty"`
+	Request    string   `json:"request,omitempty"`    // model of the JSON body, or of the query of a GET route
+	RequestIn  string   `json:"request_in,omitempty"` // "body" or "query"
+	Response   string   `json:"response,omitempty"`   // model of the reply; "APIResponse" wraps Data
+	Data       string   `json:"data,omitempty"`       // type of APIResponse.data
+}
+
+// Section groups the endpoints under one path segment, e.g. "admin" for /v1/admin/...
+type Section struct {
+	Name      string     `json:"name"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// Model is a request or response struct as the JSON fields it carries
+type Model struct {
+	Name   string       `json:"name"`
+	Fields []ModelField `json:"fields"`
+}
+
+// ModelField is a JSON field of a model; Type is string, integer, number, boolean, datetime,
+// object or a model name, or a list (T[]) or map (map[string]T) of those
+type ModelField struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Optional bool   `json:"optional,omitempty"` // omitempty or a pointer
+}
+
+// Guide lists every served endpoint with the models they exchange
+type Guide struct {
+	Sections []Section `json:"sections"`
+	Models   []Model   `json:"models,omitempty"` // left out by /v1/help
+}
+
+// Sections groups routes by the first path segment after /v1, in registry order. Routes outside
+// /v1 are in the section "root".
+func Sections(routes []Route) []Section {
+	guide := newGuideBuilder(routes)
+	return guide.sections(routes)
+}
+
+// BuildGuide describes the routes and every model reachable from their requests and responses
+func BuildGuide(routes []Route) Guide {
+	guide := newGuideBuilder(routes)
+	models := make([]Model, 0, len(guide.order))
+	for _, t := range guide.order {
+		model := Model{Name: guide.names[t], Fields: []ModelField{}}
+		for _, field := range dartFields(t) {
+			model.Fields = append(model.Fields, ModelField{
+				Name:     field.json,
+				Type:     guide.typeName(field.t),
+				Optional: field.omitEmpty || field.t.Kind() == reflect.Ptr,
+			})
+		}
+		models = append(models, model)
+	}
+	return Guide{Sections: guide.sections(routes), Models: models}
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// guideBuilder names models the same way as the Dart client, so both agree on class names
+type guideBuilder struct {
+	*dartGenerator
+}
+
+func newGuideBuilder(routes []Route) guideBuilder {
+	g := guideBuilder{&dartGenerator{names: make(map[reflect.Type]string), taken: make(map[string]reflect.Type)}}
+	for _, route := range routes {
+		for _, model := range []interface{}{route.Request, route.Data, route.Body} {
+			if model != nil {
+				g.collect(reflect.TypeOf(model))
+			}
+		}
+	}
+	return g
+}
+
+func (g guideBuilder) sections(routes []Route) []Section {
+	var sections []Section
+	index := map[string]int{}
+	for _, route := range routes {
+		name := "root"
+		if rest, ok := strings.CutPrefix(route.Path, "/v1/"); ok {
+			name, _, _ = strings.Cut(rest, "/")
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(sections)
+			index[name] = i
+			sections = append(sections, Section{Name: name})
+		}
+		sections[i].Endpoints = append(sections[i].Endpoints, g.endpoint(route))
+	}
+	return sections
+}
+
+func (g guideBuilder) endpoint(route Route) Endpoint {
+	endpoint := Endpoint{
+		Name:       route.Name,
+		Method:     route.Method,
+		Path:       route.Path,
+		Summary:    route.Summary,
+		PathParams: route.PathParams(),
+		Query:      route.Query,
+	}
+	if route.Request != nil {
+		endpoint.Request = g.typeName(reflect.TypeOf(route.Request))
+		endpoint.RequestIn = "body"
+		if route.Method == http.MethodGet {
+			endpoint.RequestIn = "query"
+		}
+	}
+	switch {
+	case route.Body != nil:
+		endpoint.Response = g.typeName(reflect.TypeOf(route.Body))
+	case route.Data != nil:
+		endpoint.Response = "APIResponse"
+		endpoint.Data = g.typeName(reflect.TypeOf(route.Data))
+	}
+	return endpoint
+}
+
+func (g guideBuilder) typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.typeName(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		if t == rawMessageType {
+			return "object"
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string" // base64
+		}
+		return g.typeName(t.Elem()) + "[]"
+	case reflect.Map:
+		return "map[string]" + g.typeName(t.Elem())
+	case reflect.Struct:
+		if t == timeType {
+			return "datetime"
+		}
+		return g.names[t]
+	}
+	return "object"
+}
@@ -0,0 +1,164 @@
+package apispec
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// OpenAPI is the OpenAPI 3 document of the served routes. It is converted from the Swagger 2.0
+// document swag generates from the handler annotations into docs/ (see go generate in main.go),
+// then aligned with the registry so it never lists a route the router does not serve.
+type OpenAPI struct {
+	mu       sync.RWMutex
+	document []byte
+}
+
+// Load replaces the document. Annotated operations without a served route are dropped, and served
+// routes under the base path without annotations are added from their spec, with the summary and
+// parameters but no models; both are logged so the annotations can be fixed.
+func (o *OpenAPI) Load(swagger string, routes []Route) error {
+	var doc2 openapi2.T
+	if err := json.Unmarshal([]byte(swagger), &doc2); err != nil {
+		return fmt.Errorf("invalid generated Swagger document: %w", err)
+	}
+	doc, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return fmt.Errorf("failed to convert the Swagger document to OpenAPI 3: %w", err)
+	}
+
+	base := strings.TrimSuffix(doc2.BasePath, "/")
+	doc.Servers = openapi3.Servers{{URL: base}}
+	if base == "" {
+		doc.Servers = openapi3.Servers{{URL: "/"}}
+	}
+	if doc.Components != nil {
+		// Which routes need credentials depends on the auth and jwt settings, so the scheme is
+		// offered everywhere and Swagger UI sends it with every call once authorized
+		for name := range doc.Components.SecuritySchemes {
+			doc.Security = append(doc.Security, openapi3.SecurityRequirement{name: {}})
+		}
+	}
+
+	served := make(map[string]Route, len(routes))
+	for _, route := range routes {
+		if route.Method != http.MethodHead {
+			served[route.Method+" "+route.Path] = route
+		}
+	}
+
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			key := method + " " + base + ginPath(path)
+			if _, ok := served[key]; ok {
+				delete(served, key)
+				continue
+			}
+			// Annotated relative to the base path but served outside it, like /metrics
+			root := method + " " + ginPath(path)
+			if _, ok := served[root]; ok && base != "" {
+				delete(served, root)
+				item.Servers = openapi3.Servers{{URL: "/"}}
+				continue
+			}
+			log.Printf("⚠️ [openapi] %s %s is annotated but not served; left out of the document", method, base+path)
+			item.SetOperation(method, nil)
+		}
+		if len(item.Operations()) == 0 {
+			doc.Paths.Delete(path)
+		}
+	}
+
+	var added []string
+	for _, route := range routes {
+		if _, ok := served[route.Method+" "+route.Path]; !ok || !strings.HasPrefix(route.Path, base+"/") {
+			continue
+		}
+		path := openAPIPath(strings.TrimPrefix(route.Path, base))
+		item := doc.Paths.Value(path)
+		if item == nil {
+			item = &openapi3.PathItem{}
+			doc.Paths.Set(path, item)
+		}
+		item.SetOperation(route.Method, route.operation())
+		added = append(added, route.Method+" "+route.Path)
+	}
+	if len(added) > 0 {
+		sort.Strings(added)
+		log.Printf("⚠️ [openapi] %d routes have no annotations and are documented without models: %s", len(added), strings.Join(added, ", "))
+	}
+
+	document, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode the OpenAPI document: %w", err)
+	}
+	o.mu.Lock()
+	o.document = document
+	o.mu.Unlock()
+	return nil
+}
+
+// JSON returns the document, or nil before a successful Load
+func (o *OpenAPI) JSON() []byte {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.document
+}
+
+// operation describes a route that has no annotations from what its spec declares
+func (r Route) operation() *openapi3.Operation {
+	op := openapi3.NewOperation()
+	op.OperationID = r.Name
+	op.Summary = r.Summary
+	for _, name := range r.PathParams() {
+		op.AddParameter(openapi3.NewPathParameter(name).WithSchema(openapi3.NewStringSchema()))
+	}
+	for _, param := range r.Query {
+		op.AddParameter(openapi3.NewQueryParameter(param.Name).WithSchema(paramSchema(param.Type)))
+	}
+	op.AddResponse(http.StatusOK, openapi3.NewResponse().WithDescription("OK"))
+	return op
+}
+
+func paramSchema(kind string) *openapi3.Schema {
+	switch kind {
+	case "int":
+		return openapi3.NewIntegerSchema()
+	case "bool":
+		return openapi3.NewBoolSchema()
+	case "double":
+		return openapi3.NewFloat64Schema()
+	default:
+		return openapi3.NewStringSchema()
+	}
+}
+
+// ginPath turns /products/{code} into /products/:code
+func ginPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = ":" + segment[1:len(segment)-1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// openAPIPath turns /products/:code and /files/*path into /products/{code} and /files/{path}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,120 @@
+package apispec
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route describes one registered endpoint and the models it exchanges
+type Route struct {
+	Name    string // method name in generated clients, e.g. "searchByVector"
+	Method  string
+	Path    string // gin path, e.g. /v1/products/:code
+	Summary string
+
+	Request interface{} // JSON body, or the query parameters of a GET route; nil for none
+	Query   []Param     // query parameters not covered by Request
+
+	// Data is the type of APIResponse.data; Body replaces it for endpoints that do not reply
+	// with an APIResponse. Both nil means the response is untyped.
+	Data interface{}
+	Body interface{}
+
+	NoClient bool // left out of generated clients, e.g. file downloads
+}
+
+// Param is a query parameter
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // "string", "int", "bool" or "double"
+}
+
+// PathParams returns the names of the :params in the path, in order
+func (r Route) PathParams() []string {
+	var params []string
+	for _, segment := range strings.Split(r.Path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+		}
+	}
+	return params
+}
+
+// Registry is the list of routes the router actually serves, with the models declared for them
+type Registry struct {
+	mu     sync.RWMutex
+	routes []Route
+}
+
+// Load replaces the registry with the router's routes. Routes without a spec are listed with a
+// derived name and untyped models, and specs without a route are dropped; both are logged so
+// the spec table can be fixed.
+func (r *Registry) Load(served gin.RoutesInfo, specs []Route) {
+	byKey := make(map[string]Route, len(specs))
+	for _, spec := range specs {
+		byKey[spec.Method+" "+spec.Path] = spec
+	}
+
+	routes := make([]Route, 0, len(served))
+	names := make(map[string]bool, len(served))
+	for _, info := range served {
+		key := info.Method + " " + info.Path
+		route, ok := byKey[key]
+		if ok {
+			delete(byKey, key)
+		} else {
+			log.Printf("⚠️ [routes] %s has no spec, clients see it untyped", key)
+			route = Route{Method: info.Method, Path: info.Path, NoClient: info.Method == http.MethodHead}
+		}
+		if route.Name == "" {
+			route.Name = derivedName(route.Method, route.Path)
+		}
+		for base, i := route.Name, 2; names[route.Name]; i++ {
+			route.Name = fmt.Sprintf("%s%d", base, i)
+		}
+		names[route.Name] = true
+		routes = append(routes, route)
+	}
+	for key := range byKey {
+		log.Printf("⚠️ [routes] spec for %s does not match a registered route", key)
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	r.mu.Lock()
+	r.routes = routes
+	r.mu.Unlock()
+}
+
+// Routes returns the registered routes sorted by path and method
+func (r *Registry) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Route(nil), r.routes...)
+}
+
+// derivedName builds a camelCase name from the method and the path after /v1,
+// e.g. GET /v1/thai-admin/export -> getThaiAdminExport
+func derivedName(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/v1"), "/") {
+		segment = strings.TrimLeft(segment, ":*")
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
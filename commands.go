@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"smlgoapi/config"
+	"smlgoapi/services"
+)
+
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: smlgoapi [command] [flags]
+
+Commands:
+  serve     Run the API server (the default without a command)
+  sync      Copy products from PostgreSQL into the Weaviate index and exit
+  migrate   Create the tables and triggers the server uses and exit
+  help      Show this help
+
+Run "smlgoapi <command> -h" for the flags of a command.
+`)
+}
+
+// loadConfig loads the configuration after checking smlgoapi.json, since a typo in it would
+// silently fall back to defaults. With validateOnly it exits once the file is checked.
+func loadConfig(validateOnly bool) *config.Config {
+	if path := config.FindConfigFile(); path != "" {
+		problems := config.ValidateConfigFile(path)
+		for _, problem := range problems {
+			log.Printf("❌ %v", problem)
+		}
+		if len(problems) > 0 {
+			log.Fatalf("❌ %s has %d problem(s); fix them and restart (check with --validate-config)", path, len(problems))
+		}
+		if validateOnly {
+			log.Printf("✅ %s is valid", path)
+			os.Exit(0)
+		}
+	} else if validateOnly {
+		log.Println("📄 smlgoapi.json not found; configuration comes from environment variables")
+		os.Exit(0)
+	}
+	cfg := config.LoadConfig()
+	// Credentials never reach the logs, whatever prints them
+	secrets := cfg.SecretValues()
+	log.SetOutput(config.NewRedactingWriter(log.Writer(), secrets))
+	gin.DefaultWriter = config.NewRedactingWriter(gin.DefaultWriter, secrets)
+	gin.DefaultErrorWriter = config.NewRedactingWriter(gin.DefaultErrorWriter, secrets)
+	return cfg
+}
+
+// syncVectorIndex runs one Weaviate sync in the foreground, as POST /v1/admin/sync-weaviate does
+// in the background. SIGINT or SIGTERM stops it; the watermark is only recorded by a finished run.
+func syncVectorIndex(args []string) {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	mode := flags.String("mode", services.SyncModeFull, "full, or incremental for rows changed since the last sync")
+	dryRun := flags.Bool("dry-run", false, "count what would be written and deleted without changing Weaviate")
+	batchSize := flags.Int("batch-size", 0, "products per batch (default weaviate.sync.batch_size)")
+	flags.Parse(args)
+
+	cfg := loadConfig(false)
+	if *batchSize <= 0 {
+		*batchSize = cfg.Weaviate.Sync.BatchSize
+	}
+
+	postgreSQLService, err := services.NewPostgreSQLService(cfg)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize PostgreSQL service: %v", err)
+	}
+	defer postgreSQLService.Close()
+
+	weaviateService, err := services.NewWeaviateService(cfg)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize Weaviate service: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	syncService := services.NewWeaviateSyncService(weaviateService, postgreSQLService, cfg.Weaviate.Sync)
+	if err := syncService.EnsureSchema(ctx); err != nil {
+		log.Fatalf("❌ Failed to prepare sync_state table: %v", err)
+	}
+
+	log.Printf("🔄 Weaviate %s sync started (dry run: %t, batch size: %d)", *mode, *dryRun, *batchSize)
+	status, err := syncService.Run(ctx, services.WeaviateSyncOptions{
+		Mode:      *mode,
+		DryRun:    *dryRun,
+		BatchSize: *batchSize,
+	})
+	for _, message := range status.Errors {
+		log.Printf("⚠️ %s", message)
+	}
+	if err != nil {
+		log.Fatalf("❌ Weaviate sync failed: %v", err)
+	}
+	log.Printf("✅ Weaviate sync done: %d products read, %d objects upserted, %d failed, %d deleted",
+		status.ProductsRead, status.ObjectsUpserted, status.ObjectsFailed, status.ObjectsDeleted)
+}
+
+// schemaStep prepares the tables of one service
+type schemaStep struct {
+	name   string
+	ensure func(context.Context) error
+}
+
+// migrate creates the tables and triggers of the features enabled in the configuration, the same
+// ones the server prepares at startup. Run it before deploying, e.g. with a database user that may
+// change the schema when the server's user may not.
+func migrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	timeout := flags.Duration("timeout", time.Minute, "time allowed for each step")
+	flags.Parse(args)
+
+	cfg := loadConfig(false)
+
+	postgreSQLService, err := services.NewPostgreSQLService(cfg)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize PostgreSQL service: %v", err)
+	}
+	defer postgreSQLService.Close()
+
+	// ClickHouse only holds analytics; without it those tables are left out
+	clickHouseService, err := services.NewClickHouseService(cfg)
+	if err != nil {
+		log.Printf("⚠️ ClickHouse service unavailable, skipping its tables: %v", err)
+		clickHouseService = nil
+	} else {
+		defer clickHouseService.Close()
+	}
+
+	failed := 0
+	for _, step := range schemaSteps(cfg, clickHouseService, postgreSQLService) {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		err := step.ensure(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("❌ %s: %v", step.name, err)
+			failed++
+			continue
+		}
+		log.Printf("✅ %s", step.name)
+	}
+	if failed > 0 {
+		log.Fatalf("❌ %d migration step(s) failed", failed)
+	}
+	log.Println("✅ Schema is up to date")
+}
+
+// schemaSteps lists the EnsureSchema calls of NewAPIHandler under the same conditions; sync_state
+// is created even while Weaviate is down so the first sync finds it
+func schemaSteps(cfg *config.Config, ch *services.ClickHouseService, pg *services.PostgreSQLService) []schemaStep {
+	var steps []schemaStep
+	if ch != nil {
+		steps = append(steps, schemaStep{"product events", services.NewProductEventService(ch).EnsureSchema})
+		if !cfg.Search.Analytics.Disabled {
+			steps = append(steps, schemaStep{"search events", services.NewSearchAnalyticsService(ch, cfg.Search.Analytics).EnsureSchema})
+		}
+	}
+	if cfg.Auth.Enabled {
+		steps = append(steps, schemaStep{"api_keys", services.NewAPIKeyService(pg, 0).EnsureSchema})
+	}
+	if cfg.JWT.Enabled {
+		steps = append(steps, schemaStep{"api_users", services.NewUserService(pg).EnsureSchema})
+	}
+	steps = append(steps,
+		schemaStep{"sync_state", services.NewWeaviateSyncService(nil, pg, cfg.Weaviate.Sync).EnsureSchema},
+		schemaStep{"shared_results", services.NewShareService(pg, cfg.Search.Share).EnsureSchema},
+		schemaStep{"named_queries", services.NewNamedQueryService(pg).EnsureSchema},
+		schemaStep{"product_images", services.NewProductImageService(pg).EnsureSchema},
+	)
+	if !cfg.Audit.Disabled {
+		steps = append(steps, schemaStep{"audit_log", services.NewAuditService(pg, cfg.Audit).EnsureSchema})
+	}
+	if len(cfg.Undo.Tables) > 0 {
+		steps = append(steps, schemaStep{"undo tables", services.NewUndoService(pg, cfg.Undo).EnsureSchema})
+	}
+	if cfg.InventoryEvents.Enabled {
+		steps = append(steps, schemaStep{"inventory triggers", services.NewInventoryEventService(pg, cfg.InventoryEvents).EnsureSchema})
+	}
+	if !cfg.Webhooks.Disabled {
+		steps = append(steps, schemaStep{"webhook tables", services.NewWebhookService(pg, cfg.Webhooks).EnsureSchema})
+	}
+	return steps
+}
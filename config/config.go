@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,25 +20,602 @@ type Config struct {
 		Host string `json:"host"`
 	} `json:"server"`
 	ClickHouse struct {
-		Host     string `json:"host"`
-		Port     string `json:"port"`
-		User     string `json:"user"`
-		Password string `json:"password"`
-		Database string `json:"database"`
-		Secure   bool   `json:"secure"`
+		Host     string     `json:"host"`
+		Port     string     `json:"port"`
+		User     string     `json:"user"`
+		Password string     `json:"password"`
+		Database string     `json:"database"`
+		Secure   bool       `json:"secure"`
+		Pool     PoolConfig `json:"pool"`
 	} `json:"clickhouse"`
 	PostgreSQL struct {
-		Host     string `json:"host"`
-		Port     string `json:"port"`
-		User     string `json:"user"`
-		Password string `json:"password"`
-		Database string `json:"database"`
-		SSLMode  string `json:"sslmode"`
+		Host     string     `json:"host"`
+		Port     string     `json:"port"`
+		User     string     `json:"user"`
+		Password string     `json:"password"`
+		Database string     `json:"database"`
+		SSLMode  string     `json:"sslmode"`
+		Pool     PoolConfig `json:"pool"`
 	} `json:"postgresql"`
 	Weaviate struct {
-		URL    string `json:"url"`
-		Scheme string `json:"scheme"`
+		URL    string               `json:"url"`
+		Scheme string               `json:"scheme"`
+		Schema WeaviateSchemaConfig `json:"schema"`
+		Sync   WeaviateSyncConfig   `json:"sync"`
 	} `json:"weaviate"`
+	Auth            AuthConfig            `json:"auth"`
+	JWT             JWTConfig             `json:"jwt"`
+	Health          HealthConfig          `json:"health"`
+	RateLimit       RateLimitConfig       `json:"rate_limit"`
+	CORS            CORSConfig            `json:"cors"`
+	Limits          LimitsConfig          `json:"limits"`
+	SQLPolicy       SQLPolicyConfig       `json:"sql_policy"`
+	SQLLimits       SQLLimitsConfig       `json:"sql_limits"`
+	Secrets         SecretsConfig         `json:"secrets"`
+	Shutdown        ShutdownConfig        `json:"shutdown"`
+	SelectCache     SelectCacheConfig     `json:"select_cache"`
+	Redis           RedisConfig           `json:"redis"`
+	PageLimits      PageLimitsConfig      `json:"page_limits"`
+	Jobs            JobsConfig            `json:"jobs"`
+	Search          SearchConfig          `json:"search"`
+	Reports         ReportsConfig         `json:"reports"`
+	Export          ExportConfig          `json:"export"`
+	DataAPI         DataAPIConfig         `json:"data_api"`
+	Audit           AuditConfig           `json:"audit"`
+	Undo            UndoConfig            `json:"undo"`
+	Metrics         MetricsConfig         `json:"metrics"`
+	Tracing         TracingConfig         `json:"tracing"`
+	GRPC            GRPCConfig            `json:"grpc"`
+	InventoryEvents InventoryEventsConfig `json:"inventory_events"`
+	Webhooks        WebhooksConfig        `json:"webhooks"`
+	FieldMapping    FieldMappingConfig    `json:"field_mapping"`
+	ThaiAdmin       ThaiAdminConfig       `json:"thai_admin"`
+}
+
+// PoolConfig sizes a database connection pool and bounds how long one statement may run
+type PoolConfig struct {
+	MaxOpenConns            int `json:"max_open_conns"`             // default 25
+	MaxIdleConns            int `json:"max_idle_conns"`             // default 5
+	ConnMaxLifetimeSeconds  int `json:"conn_max_lifetime_seconds"`  // default 1800
+	ConnMaxIdleTimeSeconds  int `json:"conn_max_idle_time_seconds"` // default 300
+	StatementTimeoutSeconds int `json:"statement_timeout_seconds"`  // enforced by the server; 0 = no limit
+}
+
+func (p *PoolConfig) applyDefaults() {
+	if p.MaxOpenConns <= 0 {
+		p.MaxOpenConns = 25
+	}
+	if p.MaxIdleConns <= 0 {
+		p.MaxIdleConns = 5
+	}
+	p.MaxIdleConns = min(p.MaxIdleConns, p.MaxOpenConns)
+	if p.ConnMaxLifetimeSeconds <= 0 {
+		p.ConnMaxLifetimeSeconds = 1800
+	}
+	if p.ConnMaxIdleTimeSeconds <= 0 {
+		p.ConnMaxIdleTimeSeconds = 300
+	}
+}
+
+// AuthConfig holds API key authentication settings
+type AuthConfig struct {
+	Enabled         bool `json:"enabled"`           // require API keys on protected endpoints
+	CacheTTLSeconds int  `json:"cache_ttl_seconds"` // how long validated keys are cached in memory
+}
+
+// JWTConfig holds settings for user sessions issued by /v1/auth/login
+type JWTConfig struct {
+	Enabled          bool   `json:"enabled"`            // require a session or API key on every data endpoint
+	Secret           string `json:"secret"`             // signs access tokens
+	RefreshSecret    string `json:"refresh_secret"`     // signs refresh tokens
+	AccessTTLMinutes int    `json:"access_ttl_minutes"` // lifetime of access tokens
+	RefreshTTLHours  int    `json:"refresh_ttl_hours"`  // lifetime of refresh tokens
+}
+
+// RateLimitConfig holds token bucket limits applied per client IP, or per API key when one is sent
+type RateLimitConfig struct {
+	Enabled           bool                     `json:"enabled"`
+	RequestsPerMinute int                      `json:"requests_per_minute"` // default for routes without a rule
+	Burst             int                      `json:"burst"`
+	Routes            map[string]RateLimitRule `json:"routes"` // path prefix -> rule; the longest prefix wins
+}
+
+// RateLimitRule limits one route prefix; RequestsPerMinute 0 leaves the route unlimited
+type RateLimitRule struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	Burst             int `json:"burst"`
+}
+
+// CORSConfig lists the browser origins allowed to call the API. An origin is "*", an exact
+// origin such as "https://shop.example.com", or a subdomain wildcard such as "https://*.example.com".
+type CORSConfig struct {
+	AllowedOrigins []string            `json:"allowed_origins"` // default ["*"]
+	Routes         map[string]CORSRule `json:"routes"`          // path prefix -> rule; the longest prefix wins
+}
+
+// CORSRule replaces the allowed origins for one route prefix; an empty list allows no other origin
+type CORSRule struct {
+	AllowedOrigins []string `json:"allowed_origins"`
+}
+
+// OriginsFor returns the allowed origins of a request path
+func (c CORSConfig) OriginsFor(path string) []string {
+	origins, bestRoute := c.AllowedOrigins, ""
+	for route, rule := range c.Routes {
+		if strings.HasPrefix(path, route) && len(route) > len(bestRoute) {
+			origins, bestRoute = rule.AllowedOrigins, route
+		}
+	}
+	return origins
+}
+
+// LimitsConfig bounds request bodies and handler time, per route prefix, and sets the
+// connection timeouts of the HTTP server
+type LimitsConfig struct {
+	MaxBodyBytes             int64                 `json:"max_body_bytes"`              // default 1 MiB
+	TimeoutSeconds           int                   `json:"timeout_seconds"`             // handler deadline, default 30
+	Routes                   map[string]RouteLimit `json:"routes"`                      // path prefix -> limits; the longest prefix wins
+	ReadHeaderTimeoutSeconds int                   `json:"read_header_timeout_seconds"` // default 10
+	IdleTimeoutSeconds       int                   `json:"idle_timeout_seconds"`        // keep-alive connections, default 120
+
+	builtinRoutes map[string]RouteLimit // consulted when no entry of Routes matches
+}
+
+// RouteLimit replaces both limits for one route prefix; 0 leaves that limit off
+type RouteLimit struct {
+	MaxBodyBytes   int64 `json:"max_body_bytes"`
+	TimeoutSeconds int   `json:"timeout_seconds"`
+}
+
+// For returns the limits of a request path: the configured rule with the longest matching prefix,
+// otherwise the built-in one, otherwise the global limits
+func (l LimitsConfig) For(path string) RouteLimit {
+	if limit, ok := longestRouteLimit(l.Routes, path); ok {
+		return limit
+	}
+	if limit, ok := longestRouteLimit(l.builtinRoutes, path); ok {
+		return limit
+	}
+	return RouteLimit{MaxBodyBytes: l.MaxBodyBytes, TimeoutSeconds: l.TimeoutSeconds}
+}
+
+func longestRouteLimit(routes map[string]RouteLimit, path string) (RouteLimit, bool) {
+	var limit RouteLimit
+	bestRoute, found := "", false
+	for route, rule := range routes {
+		if strings.HasPrefix(path, route) && (!found || len(route) > len(bestRoute)) {
+			limit, bestRoute, found = rule, route, true
+		}
+	}
+	return limit, found
+}
+
+// SelectCacheConfig bounds the in-memory cache of /v1/select and /v1/pgselect results. Only
+// requests that set cache_ttl are cached.
+type SelectCacheConfig struct {
+	Disabled      bool `json:"disabled"`
+	MaxEntries    int  `json:"max_entries"`     // default 1000; the entry closest to expiring is dropped first
+	MaxTTLSeconds int  `json:"max_ttl_seconds"` // longer cache_ttl values are lowered to this; default 3600
+	MaxRows       int  `json:"max_rows"`        // larger results are not cached; default 10000
+}
+
+// RedisConfig points every instance at one Redis, so the SELECT cache and the rate limits are
+// shared behind a load balancer. Without a URL each instance keeps them in memory.
+type RedisConfig struct {
+	URL       string `json:"url"`        // redis://[:password@]host:6379/0 or rediss:// for TLS; empty disables Redis
+	KeyPrefix string `json:"key_prefix"` // prepended to every key; default "smlgoapi:"
+	TimeoutMs int    `json:"timeout_ms"` // per command; on errors the instance falls back to memory; default 200
+}
+
+// PageLimitsConfig sets the default and maximum page size of list endpoints, optionally per role
+type PageLimitsConfig struct {
+	Routes map[string]PageLimit            `json:"routes"` // route path, e.g. "/v1/search-by-vector"; "*" covers the rest
+	Roles  map[string]map[string]PageLimit `json:"roles"`  // "admin", "operator", "viewer" or "api_key" -> route -> limit
+}
+
+// PageLimit is the page size used when a request sets none, and the largest one it may ask for
+type PageLimit struct {
+	Default int `json:"default"`
+	Max     int `json:"max"`
+}
+
+// Resolve returns the limits for route and caller role. Role overrides replace only the fields they set.
+func (p PageLimitsConfig) Resolve(route, role string) PageLimit {
+	limit, ok := p.Routes[route]
+	if !ok {
+		limit = p.Routes["*"]
+	}
+
+	if overrides, ok := p.Roles[role]; ok {
+		override, ok := overrides[route]
+		if !ok {
+			override = overrides["*"]
+		}
+		if override.Default > 0 {
+			limit.Default = override.Default
+		}
+		if override.Max > 0 {
+			limit.Max = override.Max
+		}
+	}
+
+	if limit.Max <= 0 {
+		limit.Max = defaultPageLimit.Max
+	}
+	if limit.Default <= 0 {
+		limit.Default = defaultPageLimit.Default
+	}
+	if limit.Default > limit.Max {
+		limit.Default = limit.Max
+	}
+	return limit
+}
+
+// defaultPageLimit applies to routes without a configured limit
+var defaultPageLimit = PageLimit{Default: 20, Max: 100}
+
+// SQLPolicyConfig restricts what the raw SQL endpoints may run. It is re-read from smlgoapi.json
+// while the server runs, so changes apply without a restart.
+type SQLPolicyConfig struct {
+	Enabled                 bool     `json:"enabled"`
+	DeniedStatements        []string `json:"denied_statements"`         // statement keywords, e.g. DROP, TRUNCATE
+	AllowedTables           []string `json:"allowed_tables"`            // "table", "schema.table" or "schema.*"; empty allows all
+	DeniedTables            []string `json:"denied_tables"`             // checked before allowed_tables
+	MaxLimit                int      `json:"max_limit"`                 // SELECTs must have LIMIT <= max_limit; 0 disables
+	AllowMultipleStatements bool     `json:"allow_multiple_statements"` // allow "stmt1; stmt2" in one request
+	ReloadIntervalSeconds   int      `json:"reload_interval_seconds"`   // how often smlgoapi.json is checked for changes
+}
+
+// SQLLimitsConfig bounds the statements run through /v1/select, /v1/pgselect, /v1/command and
+// /v1/pgcommand
+type SQLLimitsConfig struct {
+	DefaultTimeoutMs int `json:"default_timeout_ms"` // for requests without timeout_ms; default 120000
+	MaxTimeoutMs     int `json:"max_timeout_ms"`     // longer timeout_ms values are lowered to this; default 600000
+	// JSON results of /v1/select and /v1/pgselect stop at whichever comes first and return a cursor
+	// to the rest; ?format= exports are streamed and not limited
+	MaxRows          int   `json:"max_rows"`           // larger max_rows values are lowered to this; default 50000
+	MaxResponseBytes int64 `json:"max_response_bytes"` // JSON size of the rows; default 32 MiB
+}
+
+// Timeout returns how long a statement may run when the request asks for requestedMs (0 for the default)
+func (l SQLLimitsConfig) Timeout(requestedMs int) time.Duration {
+	if requestedMs <= 0 {
+		requestedMs = l.DefaultTimeoutMs
+	}
+	return time.Duration(min(requestedMs, l.MaxTimeoutMs)) * time.Millisecond
+}
+
+// Rows returns how many rows a JSON result may hold when the request asks for requested (0 for the maximum)
+func (l SQLLimitsConfig) Rows(requested int) int {
+	if requested <= 0 {
+		return l.MaxRows
+	}
+	return min(requested, l.MaxRows)
+}
+
+// ShutdownConfig bounds how long SIGINT or SIGTERM waits for the work in progress. Orchestrators
+// kill the process after their own grace period (30 seconds in Kubernetes), so the two together
+// should stay below it.
+type ShutdownConfig struct {
+	// Running requests, imports, syncs and jobs may finish within this; default 20
+	DrainSeconds int `json:"drain_seconds"`
+	// Then whatever still runs is cancelled on the databases, and this is left for that and for
+	// writing the queued search events and webhooks; default 5
+	FlushSeconds int `json:"flush_seconds"`
+}
+
+// WeaviateSchemaConfig maps the fields the search reads to property names in the Weaviate class,
+// so a renamed property can be followed by editing the config instead of the code
+type WeaviateSchemaConfig struct {
+	Class string `json:"class"` // class searched with BM25
+	// Fields lists candidate property names per logical field (barcode, name, ic_code) in order
+	// of preference; the first one present in the class schema is used
+	Fields          map[string][]string `json:"fields"`
+	ValidateRetries int                 `json:"validate_retries"` // attempts to read the schema at startup
+	RetryDelayMs    int                 `json:"retry_delay_ms"`   // wait between attempts
+}
+
+// WeaviateSyncConfig controls the scheduled incremental sync from ic_inventory into Weaviate
+type WeaviateSyncConfig struct {
+	IntervalSeconds int `json:"interval_seconds"` // shorthand for jobs.weaviate_sync "@every <n>s"; 0 leaves it to the jobs config
+	// UpdatedAtColumn is an ic_inventory column holding the last change time. Without it only new
+	// rows (a higher row_order_ref) are picked up by incremental syncs.
+	UpdatedAtColumn string `json:"updated_at_column"`
+	BatchSize       int    `json:"batch_size"` // products per batch of scheduled syncs
+}
+
+// SearchConfig holds settings of /v1/search-by-vector
+type SearchConfig struct {
+	// PrioritySteps sets what happens when an exact-match step of the first page fails:
+	// "barcode", "code" and "like" (the LIKE fallback); "*" covers steps without their own entry
+	PrioritySteps map[string]SearchStepPolicy `json:"priority_steps"`
+
+	// Freshness sets when /v1/admin/index-freshness reports the search indexes as stale
+	Freshness IndexFreshnessConfig `json:"freshness"`
+
+	// Shadow mirrors a sample of searches to the hybrid pipeline and logs how the results differ
+	Shadow ShadowSearchConfig `json:"shadow"`
+
+	// LatencyBudget decides which optional stages still run under an X-Latency-Budget-Ms header
+	LatencyBudget LatencyBudgetConfig `json:"latency_budget"`
+
+	// Share controls the links created by /v1/share
+	Share ShareConfig `json:"share"`
+
+	// Synonyms is the dictionary used by expand_synonyms
+	Synonyms SynonymsConfig `json:"synonyms"`
+
+	// AIEnhance is the language model used by ai_enhance
+	AIEnhance AIEnhanceConfig `json:"ai_enhance"`
+
+	// Analytics records every search in ClickHouse for /v1/analytics
+	Analytics SearchAnalyticsConfig `json:"analytics"`
+}
+
+// SearchAnalyticsConfig controls the search_events log. Events are queued in memory and written
+// in batches; when the queue is full new events are dropped rather than slowing searches down.
+type SearchAnalyticsConfig struct {
+	Disabled        bool `json:"disabled"`
+	BufferSize      int  `json:"buffer_size"`       // events queued before new ones are dropped
+	BatchSize       int  `json:"batch_size"`        // events per INSERT
+	FlushIntervalMs int  `json:"flush_interval_ms"` // a partial batch is written after this
+	RetentionDays   int  `json:"retention_days"`    // TTL of search_events, applied when the table is created
+}
+
+// AIEnhanceConfig selects the language model that rewrites queries for the vector lookup when a
+// search sets ai_enhance. The API key is never written in this file: it is read from an environment
+// variable, or from the file or Vault secret api_key refers to.
+type AIEnhanceConfig struct {
+	Provider        string `json:"provider"`          // "deepseek", "openai" or "ollama"; empty disables ai_enhance
+	BaseURL         string `json:"base_url"`          // API root; empty = the provider's public endpoint
+	Model           string `json:"model"`             // empty = the provider's default model
+	APIKeyEnv       string `json:"api_key_env"`       // variable holding the key; empty = DEEPSEEK_API_KEY or OPENAI_API_KEY
+	APIKey          string `json:"api_key"`           // env:, file: or vault: reference; takes precedence over api_key_env
+	TimeoutMs       int    `json:"timeout_ms"`        // the search continues with the original query after this
+	CacheTTLSeconds int    `json:"cache_ttl_seconds"` // how long an answer is reused for the same query
+	CacheMaxEntries int    `json:"cache_max_entries"`
+}
+
+// SynonymsConfig points at a Thai/English synonym dictionary: one group per line, terms separated
+// by commas. Without a file the built-in dictionary is used.
+type SynonymsConfig struct {
+	File                  string `json:"file"`
+	ReloadIntervalSeconds int    `json:"reload_interval_seconds"` // how often the file is checked for changes
+}
+
+// ShareConfig holds the limits of shared result links
+type ShareConfig struct {
+	DefaultTTLMinutes int    `json:"default_ttl_minutes"` // lifetime of links created without ttl_minutes
+	MaxTTLMinutes     int    `json:"max_ttl_minutes"`     // longer requested lifetimes are lowered to this
+	MaxBytes          int    `json:"max_bytes"`           // largest stored params + result, as JSON
+	BaseURL           string `json:"base_url"`            // public URL of the API for links; empty = the request's host
+}
+
+// LatencyBudgetConfig controls searches run under a latency budget. An optional stage (Weaviate,
+// supplemental text results, the estimated total, facets) is skipped when less than its reserve
+// of the budget remains, and the response is marked partial.
+type LatencyBudgetConfig struct {
+	DefaultMs int            `json:"default_ms"` // budget of requests without the header; 0 = none
+	MaxMs     int            `json:"max_ms"`     // larger header values are lowered to this
+	ReserveMs map[string]int `json:"reserve_ms"` // stage -> budget needed to start it
+	FinishMs  int            `json:"finish_ms"`  // budget kept for building the page after an optional stage
+}
+
+// ShadowSearchConfig controls dark traffic to the hybrid search pipeline. Mirrored searches run
+// in the background after the response is sent; their results are only logged.
+type ShadowSearchConfig struct {
+	Enabled       bool    `json:"enabled"`
+	SamplePercent float64 `json:"sample_percent"` // share of first-page searches mirrored, 0-100
+	TimeoutMs     int     `json:"timeout_ms"`     // a mirrored search is cancelled after this
+	MaxConcurrent int     `json:"max_concurrent"` // mirrors beyond this many in flight are dropped
+}
+
+// ReportsConfig holds settings of the /v1/reports endpoints
+type ReportsConfig struct {
+	StockAging StockAgingConfig `json:"stock_aging"`
+}
+
+// ExportConfig controls the background exports of /v1/admin/export. Finished files are kept on
+// the local disk of the instance that ran the job.
+type ExportConfig struct {
+	Dir            string `json:"dir"`             // where export files are written; default "./exports"
+	RetentionHours int    `json:"retention_hours"` // finished jobs and their files are removed after this; default 24
+	MaxConcurrent  int    `json:"max_concurrent"`  // more jobs wait in the queue; default 2
+}
+
+// DataAPIConfig lists the PostgreSQL tables served by the /v1/data/{table} endpoints. Tables
+// that are not listed cannot be read or written through them.
+type DataAPIConfig struct {
+	Tables map[string]DataTableConfig `json:"tables"` // "table" (public schema) or "schema.table"
+}
+
+// DataTableConfig is the access granted to one table of the data API
+type DataTableConfig struct {
+	ReadOnly      bool     `json:"read_only"`      // only GET; POST, PUT and DELETE answer 403
+	HiddenColumns []string `json:"hidden_columns"` // never returned, filtered, sorted or written
+}
+
+// AuditConfig controls the audit_log table in PostgreSQL, where every write request to /command,
+// /pgcommand, /pgtransaction, the data API and the admin endpoints is recorded
+type AuditConfig struct {
+	Disabled      bool `json:"disabled"`
+	RetentionDays int  `json:"retention_days"` // older entries are removed by jobs.audit_prune; default 365
+	MaxSQLBytes   int  `json:"max_sql_bytes"`  // longer SQL text is cut; default 65536
+}
+
+// UndoConfig lists the PostgreSQL tables whose rows are kept before /pgcommand updates or deletes
+// them, so /v1/admin/undo/{id} can put them back
+type UndoConfig struct {
+	Tables        []string `json:"tables"`         // "table" (public schema) or "schema.table"; empty disables undo
+	MaxRows       int      `json:"max_rows"`       // statements changing more rows are rolled back; default 10000
+	RetentionDays int      `json:"retention_days"` // older operations are removed by jobs.undo_prune; default 7
+}
+
+// StockAgingConfig describes where goods receipts are found for /v1/reports/stock-aging and how
+// on-hand quantities are bucketed by receipt age
+type StockAgingConfig struct {
+	ReceiptTable      string `json:"receipt_table"`       // document lines with item_code, wh_code, doc_date, qty, trans_flag
+	ReceiptTransFlags []int  `json:"receipt_trans_flags"` // trans_flag values of goods receipts
+	BucketDays        []int  `json:"bucket_days"`         // upper bounds of the age buckets; older stock goes to a last bucket
+}
+
+// MetricsConfig controls the Prometheus endpoint
+type MetricsConfig struct {
+	Disabled bool   `json:"disabled"`
+	Path     string `json:"path"` // default /metrics
+}
+
+// GRPCConfig runs the gRPC service of proto/smlgoapi.proto next to the HTTP API, on server.host
+type GRPCConfig struct {
+	Enabled bool   `json:"enabled"`
+	Port    string `json:"port"` // default 9090
+}
+
+// InventoryEventsConfig streams stock balance and price changes on /v1/events
+type InventoryEventsConfig struct {
+	Enabled             bool   `json:"enabled"`
+	Mode                string `json:"mode"`                  // "poll" (default) diffs the tables, "notify" installs triggers and LISTENs
+	Channel             string `json:"channel"`               // NOTIFY channel of notify mode, default smlgoapi_inventory
+	PollIntervalSeconds int    `json:"poll_interval_seconds"` // poll mode, default 10
+	HeartbeatSeconds    int    `json:"heartbeat_seconds"`     // keep-alive comment on idle streams, default 15
+	Buffer              int    `json:"buffer"`                // events kept for Last-Event-ID resume, default 1000
+}
+
+// WebhooksConfig tunes the delivery of the callbacks registered on /v1/webhooks
+type WebhooksConfig struct {
+	Disabled          bool    `json:"disabled"`
+	TimeoutSeconds    int     `json:"timeout_seconds"`     // per call, default 10
+	MaxAttempts       int     `json:"max_attempts"`        // a delivery fails after this many attempts; default 8
+	RetryBaseSeconds  int     `json:"retry_base_seconds"`  // wait before the first retry, doubled for every later one; default 30
+	RetryMaxSeconds   int     `json:"retry_max_seconds"`   // longest wait between attempts, default 3600
+	LowStockThreshold float64 `json:"low_stock_threshold"` // stock.low fires when a balance falls to this or below; default 0
+	RetentionDays     int     `json:"retention_days"`      // older deliveries are removed by jobs.webhook_prune; default 30
+}
+
+// TracingConfig sends OpenTelemetry traces to an OTLP/HTTP collector
+type TracingConfig struct {
+	Enabled     bool              `json:"enabled"`
+	Endpoint    string            `json:"endpoint"`     // traces URL, default http://localhost:4318/v1/traces
+	Headers     map[string]string `json:"headers"`      // e.g. an API key header of a hosted collector
+	ServiceName string            `json:"service_name"` // default smlgoapi
+	SampleRatio float64           `json:"sample_ratio"` // share of new traces recorded, 0-1; default 1
+}
+
+// FieldMappingConfig maps logical product fields to column names per datasource, for ERP schemas
+// that name ic_inventory columns differently. Fields not listed keep their standard column.
+type FieldMappingConfig struct {
+	PostgreSQL map[string]string `json:"postgresql"` // e.g. "unit_standard_code": "unit_code"
+	ClickHouse map[string]string `json:"clickhouse"`
+}
+
+// ThaiAdminConfig locates the optional data of the Thai administrative endpoints
+type ThaiAdminConfig struct {
+	// BoundariesFile is a GeoJSON FeatureCollection of tambon polygons used for reverse geocoding;
+	// default provinces/tambon_boundaries.geojson. Without it reverse geocoding answers 503.
+	BoundariesFile string `json:"boundaries_file"`
+	// BoundariesIDProperty is the feature property holding the tambon ID, default tambon_id.
+	// Codes such as "TH100402" (ADM3_PCODE of the OCHA boundaries) are read by their digits.
+	BoundariesIDProperty string `json:"boundaries_id_property"`
+	// CacheMaxAgeSeconds is how long clients and CDNs may reuse the GET lookups before asking
+	// again with If-None-Match; default 3600
+	CacheMaxAgeSeconds int `json:"cache_max_age_seconds"`
+	// Upstream is where POST /v1/admin/thai-admin/refresh and the thai_admin_refresh job read
+	// new data from
+	Upstream ThaiAdminUpstreamConfig `json:"upstream"`
+}
+
+// ThaiAdminUpstreamConfig is a source publishing api_province.json, api_amphure.json and
+// api_tambon.json in the format of the files in provinces/
+type ThaiAdminUpstreamConfig struct {
+	URL            string `json:"url"`             // base URL the three file names are appended to; empty disables refreshes
+	TimeoutSeconds int    `json:"timeout_seconds"` // per file, default 60
+	// MaxRemovedPercent refuses a refresh removing more of any level's records, which is more
+	// likely a broken upstream than a reform; default 10
+	MaxRemovedPercent int `json:"max_removed_percent"`
+}
+
+// IndexFreshnessConfig holds the staleness thresholds of the search indexes
+type IndexFreshnessConfig struct {
+	MaxLagSeconds      int `json:"max_lag_seconds"`       // oldest change not yet in Weaviate
+	MaxPendingChanges  int `json:"max_pending_changes"`   // rows changed since the last sync; 0 = not checked
+	TFIDFMaxAgeSeconds int `json:"tfidf_max_age_seconds"` // time since the TF-IDF index was built
+}
+
+// SearchStepPolicy is the failure handling of one priority search step
+type SearchStepPolicy struct {
+	OnError      string `json:"on_error"`       // "skip", "retry" (then skip) or "fail" (fail the request)
+	Retries      int    `json:"retries"`        // extra attempts for "retry" and "fail"
+	RetryDelayMs int    `json:"retry_delay_ms"` // wait between attempts
+}
+
+// defaultSearchStepPolicy retries a failed step once before skipping it
+var defaultSearchStepPolicy = SearchStepPolicy{OnError: "retry", Retries: 1, RetryDelayMs: 100}
+
+// StepPolicy returns the policy of a priority search step
+func (s SearchConfig) StepPolicy(step string) SearchStepPolicy {
+	policy, ok := s.PrioritySteps[step]
+	if !ok {
+		if policy, ok = s.PrioritySteps["*"]; !ok {
+			return defaultSearchStepPolicy
+		}
+	}
+	if policy.OnError == "" {
+		policy.OnError = defaultSearchStepPolicy.OnError
+	}
+	if policy.OnError == "skip" || policy.Retries < 0 {
+		policy.Retries = 0
+	}
+	return policy
+}
+
+// defaultStageReserveMs is the budget each optional search stage needs to start, sized from
+// typical timings: the Weaviate lookup is the slowest, the count queries the cheapest
+var defaultStageReserveMs = map[string]int{
+	"vector":     150,
+	"supplement": 60,
+	"estimate":   40,
+	"facets":     60,
+}
+
+// JobsConfig schedules the background maintenance jobs listed at /v1/admin/jobs. Disabled jobs
+// can still be run by hand.
+type JobsConfig struct {
+	WeaviateSync      JobConfig             `json:"weaviate_sync"`       // incremental Weaviate sync
+	ImageCachePrune   ImageCachePruneConfig `json:"image_cache_prune"`   // removes old files from health.cache_dir
+	PriceCacheRefresh PriceCacheConfig      `json:"price_cache_refresh"` // reloads prices and balances into memory
+	HealthProbe       JobConfig             `json:"health_probe"`        // runs the dependency checks and logs failures
+	AuditPrune        JobConfig             `json:"audit_prune"`         // removes audit entries older than audit.retention_days
+	UndoPrune         JobConfig             `json:"undo_prune"`          // removes undo snapshots older than undo.retention_days
+	WebhookPrune      JobConfig             `json:"webhook_prune"`       // removes webhook deliveries older than webhooks.retention_days
+	ThaiAdminRefresh  JobConfig             `json:"thai_admin_refresh"`  // reloads the Thai administrative data from thai_admin.upstream
+}
+
+// JobConfig is the schedule of one job: "@every 5m", "@daily" or a cron expression such as "*/10 * * * *"
+type JobConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Schedule string `json:"schedule"`
+}
+
+// ImageCachePruneConfig limits the image cache by file age and total size
+type ImageCachePruneConfig struct {
+	JobConfig
+	MaxAgeHours int `json:"max_age_hours"` // files not modified for longer are removed
+	MaxSizeMB   int `json:"max_size_mb"`   // oldest files are removed until the cache fits; 0 disables
+}
+
+// PriceCacheConfig keeps prices and balances in memory for search results
+type PriceCacheConfig struct {
+	JobConfig
+	MaxAgeSeconds int `json:"max_age_seconds"` // older data is ignored and read from PostgreSQL again
+}
+
+// HealthConfig holds latency budgets and thresholds used by the health check
+type HealthConfig struct {
+	PostgreSQLBudgetMs int    `json:"postgresql_budget_ms"` // slower pings are reported as "slow"
+	ClickHouseBudgetMs int    `json:"clickhouse_budget_ms"`
+	WeaviateBudgetMs   int    `json:"weaviate_budget_ms"`
+	CacheDir           string `json:"cache_dir"`         // image cache directory to check for free space
+	MinCacheFreeMB     int    `json:"min_cache_free_mb"` // less free space marks the cache as degraded
 }
 
 // JSONConfig represents the structure of smlgoapi.json
@@ -44,20 +625,22 @@ type JSONConfig struct {
 		Port string `json:"port"`
 	} `json:"server"`
 	ClickHouse struct {
-		Host     string `json:"host"`
-		Port     string `json:"port"`
-		User     string `json:"user"`
-		Password string `json:"password"`
-		Database string `json:"database"`
-		Secure   bool   `json:"secure"`
+		Host     string     `json:"host"`
+		Port     string     `json:"port"`
+		User     string     `json:"user"`
+		Password string     `json:"password"`
+		Database string     `json:"database"`
+		Secure   bool       `json:"secure"`
+		Pool     PoolConfig `json:"pool"`
 	} `json:"clickhouse"`
 	PostgreSQL struct {
-		Host     string `json:"host"`
-		Port     string `json:"port"`
-		User     string `json:"user"`
-		Password string `json:"password"`
-		Database string `json:"database"`
-		SSLMode  string `json:"sslmode"`
+		Host     string     `json:"host"`
+		Port     string     `json:"port"`
+		User     string     `json:"user"`
+		Password string     `json:"password"`
+		Database string     `json:"database"`
+		SSLMode  string     `json:"sslmode"`
+		Pool     PoolConfig `json:"pool"`
 	} `json:"postgresql"`
 	// Alternative field name for backward compatibility
 	Postgres struct {
@@ -69,9 +652,38 @@ type JSONConfig struct {
 		Secure   bool   `json:"secure"`
 	} `json:"postgres"`
 	Weaviate struct {
-		URL    string `json:"url"`
-		Scheme string `json:"scheme"`
+		URL    string               `json:"url"`
+		Scheme string               `json:"scheme"`
+		Schema WeaviateSchemaConfig `json:"schema"`
+		Sync   WeaviateSyncConfig   `json:"sync"`
 	} `json:"weaviate"`
+	Auth            AuthConfig            `json:"auth"`
+	JWT             JWTConfig             `json:"jwt"`
+	Health          HealthConfig          `json:"health"`
+	RateLimit       RateLimitConfig       `json:"rate_limit"`
+	CORS            CORSConfig            `json:"cors"`
+	Limits          LimitsConfig          `json:"limits"`
+	SQLPolicy       SQLPolicyConfig       `json:"sql_policy"`
+	SQLLimits       SQLLimitsConfig       `json:"sql_limits"`
+	Secrets         SecretsConfig         `json:"secrets"`
+	Shutdown        ShutdownConfig        `json:"shutdown"`
+	SelectCache     SelectCacheConfig     `json:"select_cache"`
+	Redis           RedisConfig           `json:"redis"`
+	PageLimits      PageLimitsConfig      `json:"page_limits"`
+	Jobs            JobsConfig            `json:"jobs"`
+	Search          SearchConfig          `json:"search"`
+	Reports         ReportsConfig         `json:"reports"`
+	Export          ExportConfig          `json:"export"`
+	DataAPI         DataAPIConfig         `json:"data_api"`
+	Audit           AuditConfig           `json:"audit"`
+	Undo            UndoConfig            `json:"undo"`
+	Metrics         MetricsConfig         `json:"metrics"`
+	Tracing         TracingConfig         `json:"tracing"`
+	GRPC            GRPCConfig            `json:"grpc"`
+	InventoryEvents InventoryEventsConfig `json:"inventory_events"`
+	Webhooks        WebhooksConfig        `json:"webhooks"`
+	FieldMapping    FieldMappingConfig    `json:"field_mapping"`
+	ThaiAdmin       ThaiAdminConfig       `json:"thai_admin"`
 }
 
 func LoadConfig() *Config {
@@ -87,6 +699,7 @@ func LoadConfig() *Config {
 		config.ClickHouse.Password = jsonConfig.ClickHouse.Password
 		config.ClickHouse.Database = jsonConfig.ClickHouse.Database
 		config.ClickHouse.Secure = jsonConfig.ClickHouse.Secure
+		config.ClickHouse.Pool = jsonConfig.ClickHouse.Pool
 
 		// Support both "postgresql" and "postgres" field names
 		if jsonConfig.PostgreSQL.Host != "" {
@@ -96,6 +709,7 @@ func LoadConfig() *Config {
 			config.PostgreSQL.Password = jsonConfig.PostgreSQL.Password
 			config.PostgreSQL.Database = jsonConfig.PostgreSQL.Database
 			config.PostgreSQL.SSLMode = jsonConfig.PostgreSQL.SSLMode
+			config.PostgreSQL.Pool = jsonConfig.PostgreSQL.Pool
 		} else if jsonConfig.Postgres.Host != "" {
 			config.PostgreSQL.Host = jsonConfig.Postgres.Host
 			config.PostgreSQL.Port = jsonConfig.Postgres.Port
@@ -115,7 +729,39 @@ func LoadConfig() *Config {
 		if config.Weaviate.Scheme == "" {
 			config.Weaviate.Scheme = "http" // Default scheme
 		}
+		config.Weaviate.Schema = jsonConfig.Weaviate.Schema
+		config.Weaviate.Sync = jsonConfig.Weaviate.Sync
+
+		config.Auth = jsonConfig.Auth
+		config.JWT = jsonConfig.JWT
+		config.Health = jsonConfig.Health
+		config.RateLimit = jsonConfig.RateLimit
+		config.CORS = jsonConfig.CORS
+		config.Limits = jsonConfig.Limits
+		config.SQLPolicy = jsonConfig.SQLPolicy
+		config.SQLLimits = jsonConfig.SQLLimits
+		config.Secrets = jsonConfig.Secrets
+		config.Shutdown = jsonConfig.Shutdown
+		config.SelectCache = jsonConfig.SelectCache
+		config.Redis = jsonConfig.Redis
+		config.PageLimits = jsonConfig.PageLimits
+		config.Jobs = jsonConfig.Jobs
+		config.Search = jsonConfig.Search
+		config.Reports = jsonConfig.Reports
+		config.Export = jsonConfig.Export
+		config.DataAPI = jsonConfig.DataAPI
+		config.Audit = jsonConfig.Audit
+		config.Undo = jsonConfig.Undo
+		config.Metrics = jsonConfig.Metrics
+		config.Tracing = jsonConfig.Tracing
+		config.GRPC = jsonConfig.GRPC
+		config.InventoryEvents = jsonConfig.InventoryEvents
+		config.Webhooks = jsonConfig.Webhooks
+		config.FieldMapping = jsonConfig.FieldMapping
+		config.ThaiAdmin = jsonConfig.ThaiAdmin
 
+		config.applyDefaults()
+		config.resolveSecrets()
 		return config
 	}
 
@@ -133,35 +779,538 @@ func LoadConfig() *Config {
 	config.ClickHouse.Host = getEnv("CLICKHOUSE_HOST", "localhost")
 	config.ClickHouse.Port = getEnv("CLICKHOUSE_PORT", "9000")
 	config.ClickHouse.User = getEnv("CLICKHOUSE_USER", "default")
-	config.ClickHouse.Password = getEnv("CLICKHOUSE_PASSWORD", "")
+	config.ClickHouse.Password = getEnvSecret("CLICKHOUSE_PASSWORD")
 	config.ClickHouse.Database = getEnv("CLICKHOUSE_DATABASE", "default")
 	config.ClickHouse.Secure = getEnv("CLICKHOUSE_SECURE", "false") == "true"
+	config.ClickHouse.Pool.MaxOpenConns = getEnvInt("CLICKHOUSE_MAX_OPEN_CONNS", 0)
+	config.ClickHouse.Pool.StatementTimeoutSeconds = getEnvInt("CLICKHOUSE_STATEMENT_TIMEOUT_SECONDS", 0)
 
 	// PostgreSQL configuration
 	config.PostgreSQL.Host = getEnv("POSTGRESQL_HOST", "localhost")
 	config.PostgreSQL.Port = getEnv("POSTGRESQL_PORT", "5432")
 	config.PostgreSQL.User = getEnv("POSTGRESQL_USER", "postgres")
-	config.PostgreSQL.Password = getEnv("POSTGRESQL_PASSWORD", "")
+	config.PostgreSQL.Password = getEnvSecret("POSTGRESQL_PASSWORD")
 	config.PostgreSQL.Database = getEnv("POSTGRESQL_DATABASE", "postgres")
 	config.PostgreSQL.SSLMode = getEnv("POSTGRESQL_SSLMODE", "disable")
+	config.PostgreSQL.Pool.MaxOpenConns = getEnvInt("POSTGRESQL_MAX_OPEN_CONNS", 0)
+	config.PostgreSQL.Pool.StatementTimeoutSeconds = getEnvInt("POSTGRESQL_STATEMENT_TIMEOUT_SECONDS", 0)
 
 	// Weaviate configuration
 	config.Weaviate.URL = getEnv("WEAVIATE_URL", "goapi.dev.dedepos.com:18008")
 	config.Weaviate.Scheme = getEnv("WEAVIATE_SCHEME", "http")
+	config.Weaviate.Schema.Class = getEnv("WEAVIATE_CLASS", "")
+	config.Weaviate.Schema.ValidateRetries = getEnvInt("WEAVIATE_SCHEMA_RETRIES", 0)
+	config.Weaviate.Schema.RetryDelayMs = getEnvInt("WEAVIATE_SCHEMA_RETRY_DELAY_MS", 0)
+	config.Weaviate.Sync.IntervalSeconds = getEnvInt("WEAVIATE_SYNC_INTERVAL_SECONDS", 0)
+	config.Weaviate.Sync.UpdatedAtColumn = getEnv("WEAVIATE_SYNC_UPDATED_AT_COLUMN", "")
+	config.Weaviate.Sync.BatchSize = getEnvInt("WEAVIATE_SYNC_BATCH_SIZE", 0)
+	for _, field := range []string{"barcode", "name", "ic_code"} {
+		if value := getEnv("WEAVIATE_FIELD_"+strings.ToUpper(field), ""); value != "" {
+			if config.Weaviate.Schema.Fields == nil {
+				config.Weaviate.Schema.Fields = map[string][]string{}
+			}
+			config.Weaviate.Schema.Fields[field] = splitList(value)
+		}
+	}
 
+	// Auth configuration
+	config.Auth.Enabled = getEnv("AUTH_ENABLED", "false") == "true"
+	config.Auth.CacheTTLSeconds = getEnvInt("AUTH_CACHE_TTL_SECONDS", 0)
+
+	// JWT session configuration
+	config.JWT.Enabled = getEnv("JWT_ENABLED", "false") == "true"
+	config.JWT.Secret = getEnvSecret("JWT_SECRET")
+	config.JWT.RefreshSecret = getEnvSecret("JWT_REFRESH_SECRET")
+	config.JWT.AccessTTLMinutes = getEnvInt("JWT_ACCESS_TTL_MINUTES", 0)
+	config.JWT.RefreshTTLHours = getEnvInt("JWT_REFRESH_TTL_HOURS", 0)
+
+	// Health check configuration
+	config.Health.PostgreSQLBudgetMs = getEnvInt("HEALTH_POSTGRESQL_BUDGET_MS", 0)
+	config.Health.ClickHouseBudgetMs = getEnvInt("HEALTH_CLICKHOUSE_BUDGET_MS", 0)
+	config.Health.WeaviateBudgetMs = getEnvInt("HEALTH_WEAVIATE_BUDGET_MS", 0)
+	config.Health.CacheDir = getEnv("CACHE_DIR", "")
+	config.Health.MinCacheFreeMB = getEnvInt("HEALTH_MIN_CACHE_FREE_MB", 0)
+
+	// Rate limit configuration (per-route rules are only configurable in smlgoapi.json)
+	config.RateLimit.Enabled = getEnv("RATE_LIMIT_ENABLED", "false") == "true"
+	config.RateLimit.RequestsPerMinute = getEnvInt("RATE_LIMIT_PER_MINUTE", 0)
+	config.RateLimit.Burst = getEnvInt("RATE_LIMIT_BURST", 0)
+
+	// CORS configuration (per-route rules are only configurable in smlgoapi.json)
+	if origins := getEnv("CORS_ALLOWED_ORIGINS", ""); origins != "" {
+		for _, origin := range strings.Split(origins, ",") {
+			config.CORS.AllowedOrigins = append(config.CORS.AllowedOrigins, strings.TrimSpace(origin))
+		}
+	}
+
+	// Request limits (per-route rules are only configurable in smlgoapi.json)
+	config.Limits.MaxBodyBytes = int64(getEnvInt("MAX_BODY_BYTES", 0))
+	config.Limits.TimeoutSeconds = getEnvInt("REQUEST_TIMEOUT_SECONDS", 0)
+
+	// SQL policy configuration (table lists are only configurable in smlgoapi.json)
+	config.SQLPolicy.Enabled = getEnv("SQL_POLICY_ENABLED", "false") == "true"
+	config.SQLPolicy.MaxLimit = getEnvInt("SQL_POLICY_MAX_LIMIT", 0)
+	config.SQLLimits.DefaultTimeoutMs = getEnvInt("SQL_DEFAULT_TIMEOUT_MS", 0)
+	config.SQLLimits.MaxTimeoutMs = getEnvInt("SQL_MAX_TIMEOUT_MS", 0)
+	config.SQLLimits.MaxRows = getEnvInt("SQL_MAX_ROWS", 0)
+	config.SQLLimits.MaxResponseBytes = int64(getEnvInt("SQL_MAX_RESPONSE_BYTES", 0))
+
+	// Shutdown configuration
+	config.Shutdown.DrainSeconds = getEnvInt("SHUTDOWN_DRAIN_SECONDS", 0)
+	config.Shutdown.FlushSeconds = getEnvInt("SHUTDOWN_FLUSH_SECONDS", 0)
+
+	// SELECT result cache configuration
+	config.SelectCache.Disabled = getEnv("SELECT_CACHE_DISABLED", "false") == "true"
+	config.SelectCache.MaxEntries = getEnvInt("SELECT_CACHE_MAX_ENTRIES", 0)
+
+	// Redis configuration
+	config.Redis.URL = getEnvSecret("REDIS_URL")
+	config.Redis.KeyPrefix = getEnv("REDIS_KEY_PREFIX", "")
+
+	// Secret references (VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE are read when needed)
+	config.Secrets.Vault.TokenFile = getEnv("VAULT_TOKEN_FILE", "")
+
+	// Background export configuration
+	config.Export.Dir = getEnv("EXPORT_DIR", "")
+	config.Export.RetentionHours = getEnvInt("EXPORT_RETENTION_HOURS", 0)
+
+	// Audit log configuration
+	config.Audit.Disabled = getEnv("AUDIT_DISABLED", "false") == "true"
+	config.Audit.RetentionDays = getEnvInt("AUDIT_RETENTION_DAYS", 0)
+
+	// Metrics configuration
+	config.Metrics.Disabled = getEnv("METRICS_DISABLED", "false") == "true"
+	config.Metrics.Path = getEnv("METRICS_PATH", "")
+
+	// Tracing configuration
+	config.Tracing.Enabled = getEnv("TRACING_ENABLED", "false") == "true"
+	config.Tracing.Endpoint = getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	config.Tracing.ServiceName = getEnv("OTEL_SERVICE_NAME", "")
+
+	// gRPC configuration
+	config.GRPC.Enabled = getEnv("GRPC_ENABLED", "false") == "true"
+	config.GRPC.Port = getEnv("GRPC_PORT", "")
+
+	// Inventory change events
+	config.InventoryEvents.Enabled = getEnv("INVENTORY_EVENTS_ENABLED", "false") == "true"
+	config.InventoryEvents.Mode = getEnv("INVENTORY_EVENTS_MODE", "")
+	config.InventoryEvents.PollIntervalSeconds = getEnvInt("INVENTORY_EVENTS_POLL_INTERVAL_SECONDS", 0)
+
+	// Webhook delivery
+	config.Webhooks.Disabled = getEnv("WEBHOOKS_DISABLED", "false") == "true"
+
+	// Thai administrative data
+	config.ThaiAdmin.BoundariesFile = getEnv("THAI_ADMIN_BOUNDARIES_FILE", "")
+	config.ThaiAdmin.Upstream.URL = getEnv("THAI_ADMIN_UPSTREAM_URL", "")
+
+	// Jobs are only configurable in smlgoapi.json; WEAVIATE_SYNC_INTERVAL_SECONDS enables the sync job
+
+	config.applyDefaults()
+	config.resolveSecrets()
 	return config
 }
 
+// applyDefaults fills in defaults for optional settings left empty by the JSON file or environment
+func (c *Config) applyDefaults() {
+	if c.Auth.CacheTTLSeconds <= 0 {
+		c.Auth.CacheTTLSeconds = 60
+	}
+	if c.JWT.AccessTTLMinutes <= 0 {
+		c.JWT.AccessTTLMinutes = 15
+	}
+	if c.JWT.RefreshTTLHours <= 0 {
+		c.JWT.RefreshTTLHours = 168
+	}
+	if c.RateLimit.RequestsPerMinute <= 0 {
+		c.RateLimit.RequestsPerMinute = 300
+	}
+	if c.RateLimit.Burst <= 0 {
+		c.RateLimit.Burst = 60
+	}
+	if c.RateLimit.Routes == nil {
+		c.RateLimit.Routes = map[string]RateLimitRule{
+			"/v1/health":   {RequestsPerMinute: 0},
+			"/v1/search":   {RequestsPerMinute: 120, Burst: 20},
+			"/v1/select":   {RequestsPerMinute: 30, Burst: 5},
+			"/v1/pgselect": {RequestsPerMinute: 30, Burst: 5},
+			"/imgproxy":    {RequestsPerMinute: 600, Burst: 100},
+		}
+	}
+	if c.CORS.AllowedOrigins == nil {
+		c.CORS.AllowedOrigins = []string{"*"}
+	}
+	c.PostgreSQL.Pool.applyDefaults()
+	c.ClickHouse.Pool.applyDefaults()
+	c.Limits.applyDefaults()
+	c.SQLPolicy.applyDefaults()
+	c.SQLLimits.applyDefaults()
+	c.Secrets.Vault.applyDefaults()
+	if c.Shutdown.DrainSeconds <= 0 {
+		c.Shutdown.DrainSeconds = 20
+	}
+	if c.Shutdown.FlushSeconds <= 0 {
+		c.Shutdown.FlushSeconds = 5
+	}
+	if c.SelectCache.MaxEntries <= 0 {
+		c.SelectCache.MaxEntries = 1000
+	}
+	if c.SelectCache.MaxTTLSeconds <= 0 {
+		c.SelectCache.MaxTTLSeconds = 3600
+	}
+	if c.SelectCache.MaxRows <= 0 {
+		c.SelectCache.MaxRows = 10000
+	}
+	if c.Redis.KeyPrefix == "" {
+		c.Redis.KeyPrefix = "smlgoapi:"
+	}
+	if c.Redis.TimeoutMs <= 0 {
+		c.Redis.TimeoutMs = 200
+	}
+	c.Weaviate.Schema.applyDefaults()
+	if c.Weaviate.Sync.BatchSize <= 0 {
+		c.Weaviate.Sync.BatchSize = 200
+	}
+	if c.PageLimits.Routes == nil {
+		c.PageLimits.Routes = map[string]PageLimit{
+			"/v1/search-by-vector": {Default: 50, Max: 500},
+		}
+	}
+	if _, ok := c.PageLimits.Routes["*"]; !ok {
+		c.PageLimits.Routes["*"] = defaultPageLimit
+	}
+	c.Jobs.applyDefaults(c.Weaviate.Sync)
+	if c.Search.Freshness.MaxLagSeconds <= 0 {
+		c.Search.Freshness.MaxLagSeconds = 3600
+	}
+	if c.Search.Freshness.TFIDFMaxAgeSeconds <= 0 {
+		c.Search.Freshness.TFIDFMaxAgeSeconds = 86400
+	}
+	if c.Search.Shadow.TimeoutMs <= 0 {
+		c.Search.Shadow.TimeoutMs = 5000
+	}
+	if c.Search.Shadow.MaxConcurrent <= 0 {
+		c.Search.Shadow.MaxConcurrent = 4
+	}
+	if c.Search.LatencyBudget.MaxMs <= 0 {
+		c.Search.LatencyBudget.MaxMs = 10000
+	}
+	if c.Search.LatencyBudget.ReserveMs == nil {
+		c.Search.LatencyBudget.ReserveMs = map[string]int{}
+	}
+	for stage, ms := range defaultStageReserveMs {
+		if _, ok := c.Search.LatencyBudget.ReserveMs[stage]; !ok {
+			c.Search.LatencyBudget.ReserveMs[stage] = ms
+		}
+	}
+	if c.Search.LatencyBudget.FinishMs <= 0 {
+		c.Search.LatencyBudget.FinishMs = 50
+	}
+	if c.Search.Share.DefaultTTLMinutes <= 0 {
+		c.Search.Share.DefaultTTLMinutes = 60
+	}
+	if c.Search.Share.MaxTTLMinutes <= 0 {
+		c.Search.Share.MaxTTLMinutes = 7 * 24 * 60
+	}
+	if c.Search.Share.MaxBytes <= 0 {
+		c.Search.Share.MaxBytes = 1 << 20
+	}
+	if c.Search.Synonyms.ReloadIntervalSeconds <= 0 {
+		c.Search.Synonyms.ReloadIntervalSeconds = 30
+	}
+	if c.Search.AIEnhance.TimeoutMs <= 0 {
+		c.Search.AIEnhance.TimeoutMs = 3000
+	}
+	if c.Search.AIEnhance.CacheTTLSeconds <= 0 {
+		c.Search.AIEnhance.CacheTTLSeconds = 3600
+	}
+	if c.Search.AIEnhance.CacheMaxEntries <= 0 {
+		c.Search.AIEnhance.CacheMaxEntries = 1000
+	}
+	if c.Search.Analytics.BufferSize <= 0 {
+		c.Search.Analytics.BufferSize = 10000
+	}
+	if c.Search.Analytics.BatchSize <= 0 {
+		c.Search.Analytics.BatchSize = 500
+	}
+	if c.Search.Analytics.FlushIntervalMs <= 0 {
+		c.Search.Analytics.FlushIntervalMs = 2000
+	}
+	if c.Search.Analytics.RetentionDays <= 0 {
+		c.Search.Analytics.RetentionDays = 90
+	}
+	if c.Reports.StockAging.ReceiptTable == "" {
+		c.Reports.StockAging.ReceiptTable = "ic_trans_detail"
+	}
+	if len(c.Reports.StockAging.ReceiptTransFlags) == 0 {
+		c.Reports.StockAging.ReceiptTransFlags = []int{12}
+	}
+	if len(c.Reports.StockAging.BucketDays) == 0 {
+		c.Reports.StockAging.BucketDays = []int{30, 90, 180}
+	}
+	if c.Audit.RetentionDays <= 0 {
+		c.Audit.RetentionDays = 365
+	}
+	if c.Audit.MaxSQLBytes <= 0 {
+		c.Audit.MaxSQLBytes = 64 << 10
+	}
+	if c.Undo.MaxRows <= 0 {
+		c.Undo.MaxRows = 10000
+	}
+	if c.Undo.RetentionDays <= 0 {
+		c.Undo.RetentionDays = 7
+	}
+	if c.Export.Dir == "" {
+		c.Export.Dir = "./exports"
+	}
+	if c.Export.RetentionHours <= 0 {
+		c.Export.RetentionHours = 24
+	}
+	if c.Export.MaxConcurrent <= 0 {
+		c.Export.MaxConcurrent = 2
+	}
+	if c.Metrics.Path == "" {
+		c.Metrics.Path = "/metrics"
+	}
+	if c.Tracing.Endpoint == "" {
+		c.Tracing.Endpoint = "http://localhost:4318/v1/traces"
+	}
+	if c.Tracing.ServiceName == "" {
+		c.Tracing.ServiceName = "smlgoapi"
+	}
+	if c.Tracing.SampleRatio <= 0 || c.Tracing.SampleRatio > 1 {
+		c.Tracing.SampleRatio = 1
+	}
+	if c.GRPC.Port == "" {
+		c.GRPC.Port = "9090"
+	}
+	if c.InventoryEvents.Mode != "notify" {
+		c.InventoryEvents.Mode = "poll"
+	}
+	if c.InventoryEvents.Channel == "" {
+		c.InventoryEvents.Channel = "smlgoapi_inventory"
+	}
+	if c.InventoryEvents.PollIntervalSeconds <= 0 {
+		c.InventoryEvents.PollIntervalSeconds = 10
+	}
+	if c.InventoryEvents.HeartbeatSeconds <= 0 {
+		c.InventoryEvents.HeartbeatSeconds = 15
+	}
+	if c.InventoryEvents.Buffer <= 0 {
+		c.InventoryEvents.Buffer = 1000
+	}
+	if c.Webhooks.TimeoutSeconds <= 0 {
+		c.Webhooks.TimeoutSeconds = 10
+	}
+	if c.Webhooks.MaxAttempts <= 0 {
+		c.Webhooks.MaxAttempts = 8
+	}
+	if c.Webhooks.RetryBaseSeconds <= 0 {
+		c.Webhooks.RetryBaseSeconds = 30
+	}
+	if c.Webhooks.RetryMaxSeconds <= 0 {
+		c.Webhooks.RetryMaxSeconds = 3600
+	}
+	if c.Webhooks.RetentionDays <= 0 {
+		c.Webhooks.RetentionDays = 30
+	}
+	if c.Health.PostgreSQLBudgetMs <= 0 {
+		c.Health.PostgreSQLBudgetMs = 200
+	}
+	if c.Health.ClickHouseBudgetMs <= 0 {
+		c.Health.ClickHouseBudgetMs = 500
+	}
+	if c.Health.WeaviateBudgetMs <= 0 {
+		c.Health.WeaviateBudgetMs = 500
+	}
+	if c.Health.CacheDir == "" {
+		c.Health.CacheDir = "./image_cache"
+	}
+	if c.Health.MinCacheFreeMB <= 0 {
+		c.Health.MinCacheFreeMB = 500
+	}
+	if c.ThaiAdmin.BoundariesFile == "" {
+		c.ThaiAdmin.BoundariesFile = filepath.Join("provinces", "tambon_boundaries.geojson")
+	}
+	if c.ThaiAdmin.BoundariesIDProperty == "" {
+		c.ThaiAdmin.BoundariesIDProperty = "tambon_id"
+	}
+	if c.ThaiAdmin.CacheMaxAgeSeconds <= 0 {
+		c.ThaiAdmin.CacheMaxAgeSeconds = 3600
+	}
+	if c.ThaiAdmin.Upstream.TimeoutSeconds <= 0 {
+		c.ThaiAdmin.Upstream.TimeoutSeconds = 60
+	}
+	if c.ThaiAdmin.Upstream.MaxRemovedPercent <= 0 {
+		c.ThaiAdmin.Upstream.MaxRemovedPercent = 10
+	}
+}
+
+// applyDefaults fills in job schedules. weaviate.sync.interval_seconds still enables the
+// Weaviate sync job when the job itself is not configured.
+func (j *JobsConfig) applyDefaults(sync WeaviateSyncConfig) {
+	if j.WeaviateSync.Schedule == "" {
+		j.WeaviateSync.Schedule = "@every 5m"
+		if sync.IntervalSeconds > 0 {
+			j.WeaviateSync.Enabled = true
+			j.WeaviateSync.Schedule = fmt.Sprintf("@every %ds", sync.IntervalSeconds)
+		}
+	}
+	if j.ImageCachePrune.Schedule == "" {
+		j.ImageCachePrune.Schedule = "30 3 * * *"
+	}
+	if j.ImageCachePrune.MaxAgeHours <= 0 {
+		j.ImageCachePrune.MaxAgeHours = 24 * 30
+	}
+	if j.PriceCacheRefresh.Schedule == "" {
+		j.PriceCacheRefresh.Schedule = "@every 5m"
+	}
+	if j.PriceCacheRefresh.MaxAgeSeconds <= 0 {
+		j.PriceCacheRefresh.MaxAgeSeconds = 600
+	}
+	if j.HealthProbe.Schedule == "" {
+		j.HealthProbe.Schedule = "@every 1m"
+	}
+	if j.AuditPrune.Schedule == "" {
+		j.AuditPrune.Schedule = "15 4 * * *"
+	}
+	if j.UndoPrune.Schedule == "" {
+		j.UndoPrune.Schedule = "45 4 * * *"
+	}
+	if j.WebhookPrune.Schedule == "" {
+		j.WebhookPrune.Schedule = "0 5 * * *"
+	}
+	if j.ThaiAdminRefresh.Schedule == "" {
+		j.ThaiAdminRefresh.Schedule = "30 5 * * 0"
+	}
+}
+
+// applyDefaults keeps SQL endpoints free of a handler deadline, since their results may be streamed
+// for longer than any fixed timeout, and gives uploads room for whole datasets. The built-in routes
+// stay in force under the configured ones, so adding a rule does not lift the limits of WebSockets
+// and event streams.
+func (l *LimitsConfig) applyDefaults() {
+	if l.MaxBodyBytes <= 0 {
+		l.MaxBodyBytes = 1 << 20
+	}
+	if l.TimeoutSeconds <= 0 {
+		l.TimeoutSeconds = 30
+	}
+	if l.builtinRoutes == nil {
+		l.builtinRoutes = map[string]RouteLimit{
+			"/v1/select":                  {MaxBodyBytes: 1 << 20},
+			"/v1/pgselect":                {MaxBodyBytes: 1 << 20},
+			"/v1/command":                 {MaxBodyBytes: 10 << 20, TimeoutSeconds: 120},
+			"/v1/pgcommand":               {MaxBodyBytes: 10 << 20, TimeoutSeconds: 120},
+			"/v1/pgtransaction":           {MaxBodyBytes: 10 << 20, TimeoutSeconds: 120},
+			"/v1/admin/thai-admin/upload": {MaxBodyBytes: 50 << 20, TimeoutSeconds: 120},
+			"/v1/admin/import":            {MaxBodyBytes: 50 << 20, TimeoutSeconds: 300},                    // product files
+			"/v1/admin/export":            {MaxBodyBytes: 1 << 20},                                          // downloads of any size
+			"/v1/products":                {MaxBodyBytes: 10 << 20, TimeoutSeconds: 30},                     // image uploads
+			"/v1/barcode/scan":            {MaxBodyBytes: 10 << 20, TimeoutSeconds: 30},                     // base64 photos
+			"/v1/ws":                      {},                                                               // long-lived WebSocket connections
+			"/v1/events":                  {},                                                               // long-lived event streams
+			"/v1/events/view":             {MaxBodyBytes: l.MaxBodyBytes, TimeoutSeconds: l.TimeoutSeconds}, // product views keep the defaults
+		}
+	}
+	if l.ReadHeaderTimeoutSeconds <= 0 {
+		l.ReadHeaderTimeoutSeconds = 10
+	}
+	if l.IdleTimeoutSeconds <= 0 {
+		l.IdleTimeoutSeconds = 120
+	}
+}
+
+func (l *SQLLimitsConfig) applyDefaults() {
+	if l.MaxTimeoutMs <= 0 {
+		l.MaxTimeoutMs = 600000
+	}
+	if l.DefaultTimeoutMs <= 0 {
+		l.DefaultTimeoutMs = min(120000, l.MaxTimeoutMs)
+	}
+	if l.MaxRows <= 0 {
+		l.MaxRows = 50000
+	}
+	if l.MaxResponseBytes <= 0 {
+		l.MaxResponseBytes = 32 << 20
+	}
+}
+
+// applyDefaults denies DROP and TRUNCATE unless denied_statements is set explicitly (even to [])
+func (p *SQLPolicyConfig) applyDefaults() {
+	if p.DeniedStatements == nil {
+		p.DeniedStatements = []string{"DROP", "TRUNCATE"}
+	}
+	if p.ReloadIntervalSeconds <= 0 {
+		p.ReloadIntervalSeconds = 5
+	}
+}
+
+// defaultWeaviateFields are the property names of the Product class created by the import tools.
+// Older imports used snake_case, so those names are accepted as fallbacks.
+var defaultWeaviateFields = map[string][]string{
+	"barcode": {"barcode"},
+	"name":    {"name"},
+	"ic_code": {"icCode", "ic_code"},
+}
+
+// applyDefaults fills in the Product class and any logical field missing from fields
+func (s *WeaviateSchemaConfig) applyDefaults() {
+	if s.Class == "" {
+		s.Class = "Product"
+	}
+	if s.Fields == nil {
+		s.Fields = map[string][]string{}
+	}
+	for field, candidates := range defaultWeaviateFields {
+		if len(s.Fields[field]) == 0 {
+			s.Fields[field] = candidates
+		}
+	}
+	if s.ValidateRetries <= 0 {
+		s.ValidateRetries = 3
+	}
+	if s.RetryDelayMs <= 0 {
+		s.RetryDelayMs = 1000
+	}
+}
+
+// LoadSQLPolicy re-reads the sql_policy section from the JSON config file at path
+func LoadSQLPolicy(path string) (SQLPolicyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SQLPolicyConfig{}, err
+	}
+
+	var jsonConfig JSONConfig
+	if err := json.Unmarshal(data, &jsonConfig); err != nil {
+		return SQLPolicyConfig{}, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+
+	jsonConfig.SQLPolicy.applyDefaults()
+	return jsonConfig.SQLPolicy, nil
+}
+
+// FindConfigFile returns the path of smlgoapi.json, or "" when configuration comes from the environment
+func FindConfigFile() string {
+	for _, configPath := range configFilePaths {
+		if _, err := os.Stat(configPath); err == nil {
+			return configPath
+		}
+	}
+	return ""
+}
+
+// configFilePaths lists the locations searched for smlgoapi.json
+var configFilePaths = []string{
+	"smlgoapi.json",
+	"./smlgoapi.json",
+	filepath.Join(".", "smlgoapi.json"),
+}
+
 // loadJSONConfig attempts to load configuration from smlgoapi.json
 func loadJSONConfig() *JSONConfig {
 	// Try multiple possible locations for the config file
-	possiblePaths := []string{
-		"smlgoapi.json",
-		"./smlgoapi.json",
-		filepath.Join(".", "smlgoapi.json"),
-	}
-
-	for _, configPath := range possiblePaths {
+	for _, configPath := range configFilePaths {
 		data, err := os.ReadFile(configPath)
 		if err != nil {
 			continue // Try next path
@@ -182,31 +1331,44 @@ func loadJSONConfig() *JSONConfig {
 }
 
 func (c *Config) GetClickHouseDSN() string {
-	return fmt.Sprintf("clickhouse://%s:%s@%s:%s/%s?secure=%t",
-		c.ClickHouse.User,
-		c.ClickHouse.Password,
+	dsn := fmt.Sprintf("clickhouse://%s@%s:%s/%s?secure=%t",
+		url.UserPassword(c.ClickHouse.User, c.ClickHouse.Password),
 		c.ClickHouse.Host,
 		c.ClickHouse.Port,
 		c.ClickHouse.Database,
 		c.ClickHouse.Secure,
 	)
+	// Unknown DSN parameters become query settings
+	if timeout := c.ClickHouse.Pool.StatementTimeoutSeconds; timeout > 0 {
+		dsn += fmt.Sprintf("&max_execution_time=%d", timeout)
+	}
+	return dsn
 }
 
 func (c *Config) GetPostgreSQLDSN() string {
-	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		c.PostgreSQL.User,
-		c.PostgreSQL.Password,
+	dsn := fmt.Sprintf("postgres://%s@%s:%s/%s?sslmode=%s",
+		url.UserPassword(c.PostgreSQL.User, c.PostgreSQL.Password),
 		c.PostgreSQL.Host,
 		c.PostgreSQL.Port,
 		c.PostgreSQL.Database,
 		c.PostgreSQL.SSLMode,
 	)
+	// pq sends unknown DSN parameters to the server as session settings
+	if timeout := c.PostgreSQL.Pool.StatementTimeoutSeconds; timeout > 0 {
+		dsn += fmt.Sprintf("&statement_timeout=%d", timeout*1000)
+	}
+	return dsn
 }
 
 func (c *Config) GetServerAddress() string {
 	return fmt.Sprintf("%s:%s", c.Server.Host, c.Server.Port)
 }
 
+// GetGRPCAddress is the listen address of the gRPC server
+func (c *Config) GetGRPCAddress() string {
+	return fmt.Sprintf("%s:%s", c.Server.Host, c.GRPC.Port)
+}
+
 func (c *Config) GetWeaviateURL() string {
 	return c.Weaviate.URL
 }
@@ -221,3 +1383,33 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvSecret reads a credential from KEY, or from the file named by KEY_FILE as mounted by
+// Docker and Kubernetes secrets. KEY may also hold an env:, file: or vault: reference.
+func getEnvSecret(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	if path := os.Getenv(key + "_FILE"); path != "" {
+		return secretFilePrefix + path
+	}
+	return ""
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// splitList splits a comma-separated environment value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
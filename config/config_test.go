@@ -0,0 +1,36 @@
+package config
+
+import "testing"
+
+func TestLimitsConfigFor(t *testing.T) {
+	pgcommandOnly := map[string]RouteLimit{"/v1/pgcommand": {MaxBodyBytes: 20 << 20}}
+	withV1 := map[string]RouteLimit{
+		"/v1/":          {MaxBodyBytes: 2 << 20, TimeoutSeconds: 60},
+		"/v1/pgcommand": {MaxBodyBytes: 20 << 20, TimeoutSeconds: 600},
+	}
+	tests := []struct {
+		name   string
+		routes map[string]RouteLimit
+		path   string
+		want   RouteLimit
+	}{
+		{"configured rule", pgcommandOnly, "/v1/pgcommand", RouteLimit{MaxBodyBytes: 20 << 20}},
+		{"built-in WebSocket route kept", pgcommandOnly, "/v1/ws", RouteLimit{}},
+		{"built-in event stream route kept", pgcommandOnly, "/v1/events", RouteLimit{}},
+		{"longest built-in prefix", pgcommandOnly, "/v1/events/view", RouteLimit{MaxBodyBytes: 1 << 20, TimeoutSeconds: 30}},
+		{"built-in route", pgcommandOnly, "/v1/command", RouteLimit{MaxBodyBytes: 10 << 20, TimeoutSeconds: 120}},
+		{"no route", pgcommandOnly, "/health", RouteLimit{MaxBodyBytes: 1 << 20, TimeoutSeconds: 30}},
+		{"longest configured prefix", withV1, "/v1/pgcommand", RouteLimit{MaxBodyBytes: 20 << 20, TimeoutSeconds: 600}},
+		{"configured prefix before built-in route", withV1, "/v1/ws", RouteLimit{MaxBodyBytes: 2 << 20, TimeoutSeconds: 60}},
+		{"no routes configured", nil, "/v1/ws", RouteLimit{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := LimitsConfig{Routes: tt.routes}
+			limits.applyDefaults()
+			if got := limits.For(tt.path); got != tt.want {
+				t.Errorf("For(%q) = %+v, want %+v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A secret setting holds either the value itself or a reference to where it is kept, so
+// smlgoapi.json can be committed and shared without credentials in it
+const (
+	secretEnvPrefix   = "env:"   // env:NAME reads the environment variable NAME
+	secretFilePrefix  = "file:"  // file:/run/secrets/pg_password reads a mounted secret file
+	secretVaultPrefix = "vault:" // vault:secret/data/smlgoapi#pg_password reads a key of a Vault secret
+)
+
+// SecretsConfig says where vault: references are read from
+type SecretsConfig struct {
+	Vault VaultConfig `json:"vault"`
+}
+
+// VaultConfig is the HashiCorp Vault server of vault: references. The token is never read from
+// smlgoapi.json: it comes from token_file, e.g. written by the Vault agent, or VAULT_TOKEN.
+type VaultConfig struct {
+	Address        string `json:"address"`         // e.g. https://vault.example.com:8200; default VAULT_ADDR
+	TokenFile      string `json:"token_file"`      // file holding the token; default VAULT_TOKEN
+	Namespace      string `json:"namespace"`       // Vault Enterprise namespace; default VAULT_NAMESPACE
+	TimeoutSeconds int    `json:"timeout_seconds"` // per request, default 10
+}
+
+func (v *VaultConfig) applyDefaults() {
+	if v.Address == "" {
+		v.Address = os.Getenv("VAULT_ADDR")
+	}
+	if v.Namespace == "" {
+		v.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if v.TimeoutSeconds <= 0 {
+		v.TimeoutSeconds = 10
+	}
+}
+
+// IsSecretRef tells whether value is a reference to a secret rather than the secret itself
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, secretEnvPrefix) ||
+		strings.HasPrefix(value, secretFilePrefix) ||
+		strings.HasPrefix(value, secretVaultPrefix)
+}
+
+// secretRefProblem describes what is wrong with the form of a reference, or "" when it is fine
+func secretRefProblem(value string) string {
+	switch {
+	case value == secretEnvPrefix, value == secretFilePrefix:
+		return fmt.Sprintf("%q names no variable or file", value)
+	case strings.HasPrefix(value, secretVaultPrefix):
+		path, key, ok := strings.Cut(strings.TrimPrefix(value, secretVaultPrefix), "#")
+		if !ok || path == "" || key == "" {
+			return fmt.Sprintf("%q must be vault:<path>#<key>, e.g. vault:secret/data/smlgoapi#pg_password", value)
+		}
+	}
+	return ""
+}
+
+// secretFields lists the settings that may hold a reference, by their path in smlgoapi.json
+func (c *Config) secretFields() map[string]*string {
+	return map[string]*string{
+		"clickhouse.password":       &c.ClickHouse.Password,
+		"postgresql.password":       &c.PostgreSQL.Password,
+		"jwt.secret":                &c.JWT.Secret,
+		"jwt.refresh_secret":        &c.JWT.RefreshSecret,
+		"redis.url":                 &c.Redis.URL,
+		"search.ai_enhance.api_key": &c.Search.AIEnhance.APIKey,
+	}
+}
+
+// resolveSecrets replaces every reference with the secret it points to. A reference that cannot
+// be read stops the server, since running with an empty password or signing key is worse.
+func (c *Config) resolveSecrets() {
+	resolver := &secretResolver{vault: c.Secrets.Vault, cache: map[string]map[string]interface{}{}}
+	var failed []string
+
+	fields := c.secretFields()
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		secret, err := resolver.resolve(*fields[path])
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		*fields[path] = secret
+	}
+	for name, value := range c.Tracing.Headers {
+		secret, err := resolver.resolve(value)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("tracing.headers.%s: %v", name, err))
+			continue
+		}
+		c.Tracing.Headers[name] = secret
+	}
+
+	for _, message := range failed {
+		log.Printf("❌ Secret %s", message)
+	}
+	if len(failed) > 0 {
+		log.Fatalf("❌ %d secret(s) could not be read; fix them and restart", len(failed))
+	}
+}
+
+// SecretValues returns the resolved credentials, for NewRedactingWriter
+func (c *Config) SecretValues() []string {
+	var values []string
+	for _, value := range c.secretFields() {
+		values = append(values, *value)
+	}
+	if u, err := url.Parse(c.Redis.URL); err == nil && u.User != nil {
+		password, _ := u.User.Password()
+		values = append(values, password)
+	}
+	for _, value := range c.Tracing.Headers {
+		values = append(values, value)
+	}
+	// Keys query enhancement reads from the environment itself
+	for _, name := range []string{c.Search.AIEnhance.APIKeyEnv, "DEEPSEEK_API_KEY", "OPENAI_API_KEY"} {
+		if name != "" {
+			values = append(values, os.Getenv(name))
+		}
+	}
+	return values
+}
+
+type secretResolver struct {
+	vault VaultConfig
+	cache map[string]map[string]interface{} // Vault secrets by path, each read once
+}
+
+func (r *secretResolver) resolve(value string) (string, error) {
+	if problem := secretRefProblem(value); problem != "" {
+		return "", errors.New(problem)
+	}
+	switch {
+	case strings.HasPrefix(value, secretEnvPrefix):
+		name := strings.TrimPrefix(value, secretEnvPrefix)
+		secret := os.Getenv(name)
+		if secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, secretFilePrefix):
+		path := strings.TrimPrefix(value, secretFilePrefix)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		// Secret files are usually written with a trailing newline
+		secret := strings.TrimRight(string(data), "\r\n")
+		if secret == "" {
+			return "", fmt.Errorf("secret file %s is empty", path)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, secretVaultPrefix):
+		path, key, _ := strings.Cut(strings.TrimPrefix(value, secretVaultPrefix), "#")
+		return r.readVault(path, key)
+	}
+	return value, nil
+}
+
+// readVault reads key of the secret at path. Both KV version 1 and 2 secrets are understood;
+// for version 2 the path includes data/, e.g. secret/data/smlgoapi.
+func (r *secretResolver) readVault(path, key string) (string, error) {
+	data, ok := r.cache[path]
+	if !ok {
+		var err error
+		if data, err = r.fetchVault(path); err != nil {
+			return "", err
+		}
+		r.cache[path] = data
+	}
+	switch secret := data[key].(type) {
+	case string:
+		return secret, nil
+	case nil:
+		return "", fmt.Errorf("vault secret %s has no key %s", path, key)
+	default:
+		return "", fmt.Errorf("key %s of vault secret %s is not a string", key, path)
+	}
+}
+
+func (r *secretResolver) fetchVault(path string) (map[string]interface{}, error) {
+	if r.vault.Address == "" {
+		return nil, errors.New("vault references need secrets.vault.address or VAULT_ADDR")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if r.vault.TokenFile != "" {
+		data, err := os.ReadFile(r.vault.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		return nil, errors.New("vault references need secrets.vault.token_file or VAULT_TOKEN")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(r.vault.Address, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault address: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if r.vault.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", r.vault.Namespace)
+	}
+	client := &http.Client{Timeout: time.Duration(r.vault.TimeoutSeconds) * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault secret %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault answered %s for secret %s", resp.Status, path)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid vault response for secret %s: %w", path, err)
+	}
+	// KV version 2 nests the values under data.data, next to data.metadata
+	if inner, ok := body.Data["data"].(map[string]interface{}); ok {
+		if _, ok := body.Data["metadata"]; ok {
+			return inner, nil
+		}
+	}
+	return body.Data, nil
+}
+
+// redactingWriter masks credentials in everything written through it
+type redactingWriter struct {
+	w        io.Writer
+	replacer *strings.Replacer
+}
+
+// NewRedactingWriter returns w with every non-empty secret, also URL-escaped as it appears in a
+// DSN, replaced by "****". Set it as the log output once the configuration is loaded. A short
+// secret is masked too, even where it appears in unrelated text: leaking it is worse.
+func NewRedactingWriter(w io.Writer, secrets []string) io.Writer {
+	seen := map[string]bool{}
+	var forms []string
+	for _, secret := range secrets {
+		for _, form := range []string{secret, url.QueryEscape(secret), url.PathEscape(secret)} {
+			if form == "" || seen[form] {
+				continue
+			}
+			seen[form] = true
+			forms = append(forms, form)
+		}
+	}
+	if len(forms) == 0 {
+		return w
+	}
+	// The replacer takes the first match in argument order, so a secret inside a longer one
+	// must not cut the longer one short
+	sort.Slice(forms, func(i, k int) bool { return len(forms[i]) > len(forms[k]) })
+	pairs := make([]string, 0, 2*len(forms))
+	for _, form := range forms {
+		pairs = append(pairs, form, "****")
+	}
+	return &redactingWriter{w: w, replacer: strings.NewReplacer(pairs...)}
+}
+
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, r.replacer.Replace(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,456 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigProblem is one mistake found in smlgoapi.json
+type ConfigProblem struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string // e.g. "search.share.max_bytes"
+	Message string
+}
+
+func (p ConfigProblem) Error() string {
+	location := p.File
+	if p.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+	if p.Path == "" {
+		return fmt.Sprintf("%s: %s", location, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, p.Path, p.Message)
+}
+
+// ValidateConfigFile checks smlgoapi.json against the JSONConfig schema: syntax, unknown fields
+// (usually typos that would otherwise fall back to defaults), value types and required settings.
+// Keys starting with "_" are comments and always allowed.
+func ValidateConfigFile(path string) []ConfigProblem {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []ConfigProblem{{File: path, Message: err.Error()}}
+	}
+	return ValidateConfigJSON(path, data)
+}
+
+// ValidateConfigJSON checks the contents of a config file; name is used in the messages
+func ValidateConfigJSON(name string, data []byte) []ConfigProblem {
+	v := &configValidator{name: name, data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	v.dec.UseNumber()
+
+	if err := v.value("", reflect.TypeOf(JSONConfig{})); err != nil {
+		v.syntaxError(err)
+		return v.problems
+	}
+	if _, err := v.dec.Token(); err != io.EOF {
+		v.addAt(v.dec.InputOffset(), "", "unexpected content after the closing brace")
+		return v.problems
+	}
+
+	var cfg JSONConfig
+	if err := json.Unmarshal(data, &cfg); err == nil {
+		v.required(&cfg)
+		v.corsOrigins(&cfg.CORS)
+		v.redisURL(cfg.Redis.URL)
+		v.upstreamURL(cfg.ThaiAdmin.Upstream.URL)
+		v.secretRefs(&cfg)
+	}
+	return v.problems
+}
+
+type configValidator struct {
+	name     string
+	data     []byte
+	dec      *json.Decoder
+	problems []ConfigProblem
+}
+
+// value walks one JSON value, checking it against t. Only syntax errors are returned; schema
+// mistakes are collected so every one of them is reported at once.
+func (v *configValidator) value(path string, t reflect.Type) error {
+	offset := v.dec.InputOffset()
+	tok, err := v.dec.Token()
+	if err != nil {
+		return err
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '{' {
+			switch t.Kind() {
+			case reflect.Struct:
+				return v.object(path, t)
+			case reflect.Map:
+				return v.mapObject(path, t)
+			case reflect.Interface:
+				return v.skip()
+			}
+			v.addAt(offset, path, fmt.Sprintf("expected %s, got an object", jsonKind(t)))
+			return v.skip()
+		}
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i := 0; v.dec.More(); i++ {
+				if err := v.value(fmt.Sprintf("%s[%d]", path, i), t.Elem()); err != nil {
+					return err
+				}
+			}
+			_, err := v.dec.Token()
+			return err
+		}
+		if t.Kind() != reflect.Interface {
+			v.addAt(offset, path, fmt.Sprintf("expected %s, got an array", jsonKind(t)))
+		}
+		return v.skip()
+	case nil:
+		return nil
+	case string:
+		if t.Kind() != reflect.String && t.Kind() != reflect.Interface {
+			v.addAt(offset, path, fmt.Sprintf("expected %s, got the string %q", jsonKind(t), tok))
+		}
+	case bool:
+		if t.Kind() != reflect.Bool && t.Kind() != reflect.Interface {
+			v.addAt(offset, path, fmt.Sprintf("expected %s, got %t", jsonKind(t), tok))
+		}
+	case json.Number:
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if _, err := tok.Int64(); err != nil {
+				v.addAt(offset, path, fmt.Sprintf("expected a whole number, got %s", tok))
+			}
+		case reflect.Float32, reflect.Float64, reflect.Interface:
+		case reflect.String:
+			v.addAt(offset, path, fmt.Sprintf("expected a string, got the number %s (put it in quotes)", tok))
+		default:
+			v.addAt(offset, path, fmt.Sprintf("expected %s, got the number %s", jsonKind(t), tok))
+		}
+	}
+	return nil
+}
+
+// object checks the keys of a JSON object against the json tags of a struct
+func (v *configValidator) object(path string, t reflect.Type) error {
+	fields := structFields(t)
+	for v.dec.More() {
+		offset := v.dec.InputOffset()
+		tok, err := v.dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		keyPath := joinConfigPath(path, key)
+
+		field, ok := fields[key]
+		if !ok {
+			if !strings.HasPrefix(key, "_") {
+				v.addAt(offset, keyPath, unknownFieldMessage(key, fields))
+			}
+			if err := v.value(keyPath, reflect.TypeOf((*interface{})(nil)).Elem()); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := v.value(keyPath, field); err != nil {
+			return err
+		}
+	}
+	_, err := v.dec.Token()
+	return err
+}
+
+// mapObject checks the values of a JSON object decoded into a map; any key is allowed
+func (v *configValidator) mapObject(path string, t reflect.Type) error {
+	for v.dec.More() {
+		tok, err := v.dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := v.value(joinConfigPath(path, tok.(string)), t.Elem()); err != nil {
+			return err
+		}
+	}
+	_, err := v.dec.Token()
+	return err
+}
+
+// skip consumes the rest of an object or array whose opening delimiter was already read
+func (v *configValidator) skip() error {
+	for depth := 1; depth > 0; {
+		tok, err := v.dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
+// required reports settings the server cannot start without
+func (v *configValidator) required(cfg *JSONConfig) {
+	if cfg.Server.Port == "" {
+		v.add("server.port", "is required")
+	}
+	pg := cfg.PostgreSQL
+	section := "postgresql"
+	if pg.Host == "" && cfg.Postgres.Host != "" {
+		pg.Host, pg.Database, section = cfg.Postgres.Host, cfg.Postgres.Database, "postgres"
+	}
+	if pg.Host == "" {
+		v.add("postgresql.host", "is required")
+	}
+	if pg.Database == "" {
+		v.add(section+".database", "is required")
+	}
+	if cfg.JWT.Enabled && cfg.JWT.Secret == "" {
+		v.add("jwt.secret", "is required when jwt.enabled is true")
+	}
+}
+
+// corsOrigins reports origin patterns that can never match the Origin header of a browser
+func (v *configValidator) corsOrigins(cfg *CORSConfig) {
+	check := func(path string, origins []string) {
+		for i, origin := range origins {
+			if msg := corsOriginProblem(origin); msg != "" {
+				v.add(fmt.Sprintf("%s[%d]", path, i), msg)
+			}
+		}
+	}
+	check("cors.allowed_origins", cfg.AllowedOrigins)
+	routes := make([]string, 0, len(cfg.Routes))
+	for route := range cfg.Routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		check("cors.routes."+route+".allowed_origins", cfg.Routes[route].AllowedOrigins)
+	}
+}
+
+func corsOriginProblem(origin string) string {
+	if origin == "*" {
+		return ""
+	}
+	scheme, host, ok := strings.Cut(origin, "://")
+	switch {
+	case !ok || (scheme != "http" && scheme != "https"):
+		return fmt.Sprintf("%q must start with http:// or https://", origin)
+	case strings.Contains(host, "/"):
+		return fmt.Sprintf("%q must not contain a path (an origin is scheme://host[:port])", origin)
+	case strings.Contains(strings.TrimPrefix(host, "*."), "*"):
+		return fmt.Sprintf("%q: a wildcard is only allowed as the first label, e.g. https://*.example.com", origin)
+	}
+	return ""
+}
+
+// redisURL reports a redis.url the client could not connect with
+func (v *configValidator) redisURL(raw string) {
+	if raw == "" || IsSecretRef(raw) {
+		return
+	}
+	u, err := url.Parse(raw)
+	switch {
+	case err != nil:
+		v.add("redis.url", err.Error())
+	case u.Scheme != "redis" && u.Scheme != "rediss":
+		v.add("redis.url", fmt.Sprintf("%q must start with redis:// or rediss://", raw))
+	case u.Host == "":
+		v.add("redis.url", fmt.Sprintf("%q has no host", raw))
+	}
+}
+
+// upstreamURL reports a thai_admin.upstream.url the data could not be downloaded from
+func (v *configValidator) upstreamURL(raw string) {
+	if raw == "" {
+		return
+	}
+	u, err := url.Parse(raw)
+	switch {
+	case err != nil:
+		v.add("thai_admin.upstream.url", err.Error())
+	case u.Scheme != "http" && u.Scheme != "https":
+		v.add("thai_admin.upstream.url", fmt.Sprintf("%q must start with http:// or https://", raw))
+	case u.Host == "":
+		v.add("thai_admin.upstream.url", fmt.Sprintf("%q has no host", raw))
+	}
+}
+
+func (v *configValidator) syntaxError(err error) {
+	var syntax *json.SyntaxError
+	switch {
+	case errors.As(err, &syntax):
+		// The offset is just past the offending character
+		v.addLine(syntax.Offset-1, "", "invalid JSON: "+syntax.Error())
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		v.addLine(int64(len(v.data)), "", "invalid JSON: unexpected end of file")
+	default:
+		v.addLine(v.dec.InputOffset(), "", "invalid JSON: "+err.Error())
+	}
+}
+
+func (v *configValidator) add(path, message string) {
+	v.problems = append(v.problems, ConfigProblem{File: v.name, Path: path, Message: message})
+}
+
+// addAt records a problem with the token after offset. The decoder's offset is just past the
+// previous token, so whitespace and separators are skipped to point at the token itself.
+func (v *configValidator) addAt(offset int64, path, message string) {
+	for offset < int64(len(v.data)) && strings.ContainsRune(" \t\r\n,:", rune(v.data[offset])) {
+		offset++
+	}
+	v.addLine(offset, path, message)
+}
+
+// addLine records a problem at a byte offset of the file
+func (v *configValidator) addLine(offset int64, path, message string) {
+	offset = max(0, min(offset, int64(len(v.data))))
+	line, column := 1, 1
+	for _, b := range v.data[:offset] {
+		if b == '\n' {
+			line, column = line+1, 1
+		} else {
+			column++
+		}
+	}
+	v.problems = append(v.problems, ConfigProblem{File: v.name, Line: line, Column: column, Path: path, Message: message})
+}
+
+// structFields maps the json names of a struct's fields to their types
+func structFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			// Embedded structs such as JobConfig share the object of the outer struct
+			for embedded, ft := range structFields(f.Type) {
+				fields[embedded] = ft
+			}
+			continue
+		}
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// unknownFieldMessage names the closest known field, which is nearly always the intended one
+func unknownFieldMessage(key string, fields map[string]reflect.Type) string {
+	best, bestDistance := "", len(key)/2+2
+	known := make([]string, 0, len(fields))
+	for name := range fields {
+		known = append(known, name)
+		if d := editDistance(strings.ToLower(key), name); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf("unknown field (did you mean %q?)", best)
+	}
+	sort.Strings(known)
+	return fmt.Sprintf("unknown field (known: %s)", strings.Join(known, ", "))
+}
+
+func joinConfigPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// jsonKind describes the JSON value expected for a Go type
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// editDistance is the Levenshtein distance between two field names
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// secretRefs reports malformed env:, file: and vault: references and an api_key written in clear
+func (v *configValidator) secretRefs(cfg *JSONConfig) {
+	fields := map[string]string{
+		"clickhouse.password": cfg.ClickHouse.Password,
+		"postgresql.password": cfg.PostgreSQL.Password,
+		"postgres.password":   cfg.Postgres.Password,
+		"jwt.secret":          cfg.JWT.Secret,
+		"jwt.refresh_secret":  cfg.JWT.RefreshSecret,
+		"redis.url":           cfg.Redis.URL,
+	}
+	for name, value := range cfg.Tracing.Headers {
+		fields["tracing.headers."+name] = value
+	}
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if msg := secretRefProblem(fields[path]); msg != "" {
+			v.add(path, msg)
+		}
+	}
+
+	if key := cfg.Search.AIEnhance.APIKey; key != "" {
+		if !IsSecretRef(key) {
+			v.add("search.ai_enhance.api_key", "must be an env:, file: or vault: reference, not the key itself")
+		} else if msg := secretRefProblem(key); msg != "" {
+			v.add("search.ai_enhance.api_key", msg)
+		}
+	}
+}
@@ -0,0 +1,8818 @@
+// Package docs Code generated by swaggo/swag. DO NOT EDIT
+package docs
+
+import "github.com/swaggo/swag"
+
+const docTemplate = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "contact": {},
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/": {
+            "get": {
+                "description": "Where the documentation is and the served endpoints by section, from the route registry",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "documentation"
+                ],
+                "summary": "API overview",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/address/parse": {
+            "post": {
+                "description": "Read the house number, moo, soi, road, tambon, amphure, province, zip code and phone from an address typed as one line, and find its tambon in the Thai administrative data. Confidence tells how well the location matched; components can be passed to /thai-admin/format-address as they are.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Split a Thai address into its parts",
+                "parameters": [
+                    {
+                        "description": "Address text",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddressParseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.AddressParseResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/api-keys": {
+            "get": {
+                "description": "List all API keys without their secret values",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.APIKey"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create an API key with the given scopes (read, command, admin). The key is only returned once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Issue a new API key",
+                "parameters": [
+                    {
+                        "description": "Key name and scopes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.CreateAPIKeyResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/api-keys/{id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/audit": {
+            "get": {
+                "description": "Who sent which write request (/command, /pgcommand, /pgtransaction, the data API, imports and other admin actions), when and from which IP, with its SQL, rows affected, status and duration. Newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Audit log of write requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start, RFC 3339 time or YYYY-MM-DD (inclusive)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End, RFC 3339 time (exclusive) or YYYY-MM-DD (inclusive)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session username or api-key:\u003cname\u003e",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Route pattern or path prefix, e.g. /v1/pgcommand or /v1/admin",
+                        "name": "route",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "HTTP method, e.g. DELETE",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries per page (page_limits)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.AuditPage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/cache": {
+            "get": {
+                "description": "Hit and miss counts of the /select and /pgselect result cache and its live entries, the most used first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "SELECT cache entries",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.SelectCacheStats"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove one entry by key, the entries reading a table, or every entry of a database. Without parameters the whole cache is cleared.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Invalidate SELECT cache entries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Entry key from GET /admin/cache",
+                        "name": "key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "clickhouse or postgresql",
+                        "name": "database",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Table read by the cached queries",
+                        "name": "table",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.SelectCacheInvalidation"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/db-stats": {
+            "get": {
+                "description": "Pool settings and live connection counts of PostgreSQL and ClickHouse. A growing wait_count means max_open_conns is too low for the load.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Database connection pool statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.DBPoolStats"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/export": {
+            "get": {
+                "description": "Exports of this instance, newest first. Finished exports are removed after export.retention_hours.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Background exports",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.ExportJob"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Write every row of a table, or the result of a SELECT query, to a CSV, Parquet, NDJSON or XLSX file in the background. Poll GET /admin/export/{id} until the status is done, then download the file from its download_url.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Start a background export",
+                "parameters": [
+                    {
+                        "description": "Table or query to export",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ExportJob"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/export/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Status of a background export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ExportJob"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancels a queued or running export; removes a finished one together with its file",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Cancel or remove a background export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ExportJob"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/export/{id}/download": {
+            "get": {
+                "produces": [
+                    "text/csv",
+                    "application/vnd.apache.parquet",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Download a finished export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/import/products": {
+            "post": {
+                "description": "Upsert products, barcodes and prices from the multipart field \"file\" in one transaction. The header names ic_inventory columns (code is required), barcode, unit_code and price_0 to price_4; empty cells leave stored values unchanged.\nRows that fail are rolled back alone and reported; ?report=csv downloads them with an error column instead of the JSON summary.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Import products from CSV or XLSX",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV or XLSX file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Check every row against the database, then roll back",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv: respond with the rejected rows",
+                        "name": "report",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductImportResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/index-freshness": {
+            "get": {
+                "description": "Lag between ic_inventory in PostgreSQL and the Weaviate and TF-IDF indexes: last sync, pending changed rows and whether each index is stale under search.freshness",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Search index freshness",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Respond 503 when an index is stale, for uptime monitors",
+                        "name": "fail_on_stale",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.IndexFreshnessReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.IndexFreshnessReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs": {
+            "get": {
+                "description": "Schedule, next run and last-run outcome of every maintenance job",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List background jobs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/jobs.Status"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a background job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/jobs.Status"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs/{name}/run": {
+            "post": {
+                "description": "Starts the job in the background, also when it is disabled; poll GET /admin/jobs/{name} for the outcome",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Run a background job now",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/jobs.Status"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/jobs.Status"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/queries": {
+            "get": {
+                "description": "List every registered SQL template with its parameters",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List named queries",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.NamedQuery"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/queries/{name}": {
+            "put": {
+                "description": "Register or replace the SQL template under name. The query must be one SELECT with a placeholder per parameter: $1, $2, ... in parameter order for PostgreSQL, ? for ClickHouse.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Register a named query",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Named query",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Database, SQL template and typed parameters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NamedQueryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.NamedQuery"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete a named query",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Named query",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/running-queries": {
+            "get": {
+                "description": "Statements running on /select, /selectget, /pgselect, /command, /pgcommand and /pgtransaction of this instance, the longest running first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "SQL statements in progress",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.RunningQuery"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/running-queries/{id}": {
+            "delete": {
+                "description": "Cancels the statement on the database; its request answers 409",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Cancel a SQL statement in progress",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Running query id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.RunningQuery"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/sql-policy": {
+            "get": {
+                "description": "Statement, table and LIMIT rules applied to /command, /select, /pgcommand and /pgselect",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Show the active SQL policy",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/sql-policy/reload": {
+            "post": {
+                "description": "The policy is also reloaded automatically when the file changes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reload the SQL policy from smlgoapi.json",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/sync": {
+            "get": {
+                "description": "Rows copied by the running sync, or by the last one when none is running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Progress of the table sync",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.TableSyncStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Copy a table from one database into the other in batches, e.g. to mirror ic_inventory into ClickHouse for analytics. A missing target table is created with mapped column types. mode=full empties the target first, append adds every row, incremental adds rows whose key is above the largest key in the target. Runs in the background; poll GET /admin/sync for progress.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Copy a table between PostgreSQL and ClickHouse",
+                "parameters": [
+                    {
+                        "description": "Source, target, table and mode",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.TableSyncOptions"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.TableSyncStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.TableSyncStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/sync-weaviate": {
+            "get": {
+                "description": "Counters of the running sync, or of the last one when none is running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Progress of the Weaviate sync",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.WeaviateSyncStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Upsert every ic_inventory product (one object per barcode) into the Weaviate Product class and delete objects that no longer exist. mode=incremental only pushes rows changed since the last recorded watermark. Runs in the background; poll GET /admin/sync-weaviate for progress.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Sync products from PostgreSQL into Weaviate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "full (default) or incremental",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count what would change without writing",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Products per batch (default 200, max 1000)",
+                        "name": "batch_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.WeaviateSyncStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.WeaviateSyncStatus"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/thai-admin/refresh": {
+            "post": {
+                "description": "Download api_province.json, api_amphure.json and api_tambon.json from thai_admin.upstream.url, validate them like an upload and, when they differ from the data being served, swap them in and rewrite the files in provinces/. The thai_admin_refresh job does the same on a schedule.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reload the Thai administrative data from upstream",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only download, validate and diff",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ThaiAdminUploadResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ThaiAdminUploadResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/thai-admin/upload": {
+            "post": {
+                "description": "Validate uploaded provinces, amphures and/or tambons, report the differences and swap them in without a restart. Omitted levels keep their current records.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Replace the Thai administrative data",
+                "parameters": [
+                    {
+                        "description": "Replacement data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ThaiAdminUploadRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate and diff",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Rewrite the data files so the update survives a restart (default true)",
+                        "name": "persist",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ThaiAdminUploadResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ThaiAdminUploadResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/undo": {
+            "get": {
+                "description": "UPDATE and DELETE statements sent to /pgcommand on the tables of undo.tables, whose previous rows were kept. Newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Undoable /pgcommand operations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Operations per page (page_limits)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Operations to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UndoPage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/undo/{id}": {
+            "get": {
+                "description": "The operation with the rows it kept, as they were before the statement ran",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "An undoable /pgcommand operation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Undo operation id, returned by /pgcommand as result.undo_operation",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Kept rows to return (page_limits)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UndoOperation"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Put back the rows kept by an UPDATE or DELETE: deleted rows are inserted again and updated rows get their previous values, matched by primary key. Each operation can be restored once.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Undo a /pgcommand operation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Undo operation id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UndoOperation"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List API users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.User"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a user with role admin, operator or viewer",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Create an API user",
+                "parameters": [
+                    {
+                        "description": "User details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/amphures": {
+            "get": {
+                "description": "Retrieve all districts (amphures) in a specified province",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Get all amphures in a province",
+                "parameters": [
+                    {
+                        "description": "Province ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AmphureRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Province ID (GET only)",
+                        "name": "province_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Amphure"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (GET only)"
+                    }
+                }
+            },
+            "post": {
+                "description": "Retrieve all districts (amphures) in a specified province",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Get all amphures in a province",
+                "parameters": [
+                    {
+                        "description": "Province ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AmphureRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Province ID (GET only)",
+                        "name": "province_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Amphure"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (GET only)"
+                    }
+                }
+            }
+        },
+        "/analytics/searches/latency": {
+            "get": {
+                "description": "p50/p90/p95/p99 search latency over the last N days or between two dates, overall (\"all\") and per method",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Search latency percentiles",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Look-back window in days (default 1, max 90)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD (instead of days)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD (default today)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.SearchLatencyStats"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/analytics/searches/top": {
+            "get": {
+                "description": "Queries searched most often over the last N days or between two dates (first pages only, case-insensitive)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Most searched queries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Look-back window in days (default 7, max 90)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD (instead of days)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD (default today)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of queries (default 20, max 100 unless page_limits says otherwise)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.SearchQueryStats"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/analytics/zero-results": {
+            "get": {
+                "description": "Most frequent queries without any result over a date range, i.e. products customers look for but cannot find. Catalog managers use it to add missing synonyms or products.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Zero-result query report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD (default 30 days before to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD (default today)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Look-back window in days when from and to are not given (default 30, max 90)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of queries (default 20, max 100 unless page_limits says otherwise)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.SearchQueryStats"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Exchange a username and password for an access token and a refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log in and obtain a JWT session",
+                "parameters": [
+                    {
+                        "description": "Credentials",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.TokenResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh a JWT session",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.TokenResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/barcode/scan": {
+            "post": {
+                "description": "Decode an EAN-13/EAN-8/UPC, Code 128 or QR code from a base64 image and return the products with exactly that barcode, for clients without a native scanner",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Identify a product from a barcode photo",
+                "parameters": [
+                    {
+                        "description": "Base64 image",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BarcodeScanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.BarcodeScanResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/command": {
+            "post": {
+                "description": "Execute any SQL command (INSERT, UPDATE, DELETE, CREATE, etc.) via JSON request. The SQL may be sent base64 encoded as query_base64 instead of query; the response echoes the decoded SQL. timeout_ms bounds how long the statement runs (sql_limits); a statement that runs longer, or whose client disconnects, is cancelled on the database and answers 408.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "database"
+                ],
+                "summary": "Execute database command",
+                "parameters": [
+                    {
+                        "description": "Command to execute",
+                        "name": "command",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/data/{table}": {
+            "get": {
+                "description": "Page through a table listed in data_api.tables. Filter with column=value or column[op]=value (op: eq, ne, gt, gte, lt, lte, like, ilike, in with a comma-separated list, null with true or false); conditions are combined with AND.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "data"
+                ],
+                "summary": "Rows of a table",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name, optionally schema-qualified",
+                        "name": "table",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Rows per page (page_limits)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Rows to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns, descending with a leading -, e.g. -create_date_time_now,code",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns to return (default: all)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.DataPage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Insert a JSON object of column values; omitted columns get their defaults. Returns the stored row.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "data"
+                ],
+                "summary": "Insert a row",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name, optionally schema-qualified",
+                        "name": "table",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Column values",
+                        "name": "row",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/data/{table}/{id}": {
+            "get": {
+                "description": "The row whose primary key is id; tables with a composite or no primary key are read with filters on the list instead",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "data"
+                ],
+                "summary": "One row of a table",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name, optionally schema-qualified",
+                        "name": "table",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Primary key value",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Set the columns given in the JSON object on the row whose primary key is id; other columns keep their values. Returns the updated row.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "data"
+                ],
+                "summary": "Update a row",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name, optionally schema-qualified",
+                        "name": "table",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Primary key value",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Column values",
+                        "name": "row",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete the row whose primary key is id and return it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "data"
+                ],
+                "summary": "Delete a row",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name, optionally schema-qualified",
+                        "name": "table",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Primary key value",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "description": "Server-Sent Events stream of \"balance\" and \"price\" events as ic_balance and ic_inventory_price_formula change, so POS frontends can refresh availability without polling the search. Reconnecting with Last-Event-ID resumes after the last event received; a \"reset\" event means changes were missed and the client should reload what it shows.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Stream stock balance and price changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated product codes to follow; all products when omitted",
+                        "name": "ic_code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated event types: balance, price (default both)",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the last event received, sent by EventSource on reconnect",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.InventoryEvent"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events/view": {
+            "post": {
+                "description": "Track a product view posted by a frontend for trending and recently viewed lists",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Record a product view event",
+                "parameters": [
+                    {
+                        "description": "Product view event",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductViewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/findbyzipcode": {
+            "get": {
+                "description": "Find complete location information (province, amphure, tambon) by Thai postal code",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Find location by zip code",
+                "parameters": [
+                    {
+                        "description": "Zip code to search",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ZipCodeRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Zip code (GET only)",
+                        "name": "zip_code",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.CompleteLocationData"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (GET only)"
+                    }
+                }
+            },
+            "post": {
+                "description": "Find complete location information (province, amphure, tambon) by Thai postal code",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Find location by zip code",
+                "parameters": [
+                    {
+                        "description": "Zip code to search",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ZipCodeRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Zip code (GET only)",
+                        "name": "zip_code",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.CompleteLocationData"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (GET only)"
+                    }
+                }
+            }
+        },
+        "/graphql": {
+            "get": {
+                "description": "Product search, product detail with barcodes, price tiers and balances, and the Thai provinces, amphures and tambons in one schema. Fields are named as in the REST responses; GET /v1/graphql?sdl=1 returns the schema.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "graphql"
+                ],
+                "summary": "GraphQL API",
+                "parameters": [
+                    {
+                        "description": "GraphQL query and variables",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GraphQLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.GraphQLResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Product search, product detail with barcodes, price tiers and balances, and the Thai provinces, amphures and tambons in one schema. Fields are named as in the REST responses; GET /v1/graphql?sdl=1 returns the schema.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "graphql"
+                ],
+                "summary": "GraphQL API",
+                "parameters": [
+                    {
+                        "description": "GraphQL query and variables",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GraphQLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.GraphQLResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/guide": {
+            "get": {
+                "description": "Every served endpoint by section with its parameters and the request and response models, from the route registry",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "documentation"
+                ],
+                "summary": "Developer guide",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apispec.Guide"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Get the health status of the API and its dependencies with latency measurements.\nThe service is \"degraded\" (HTTP 200) when optional dependencies fail or exceed their latency budget,\nand \"unhealthy\" (HTTP 503) only when PostgreSQL is down.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/live": {
+            "get": {
+                "description": "Answers 200 as long as the process serves HTTP; no dependency is contacted, so a database outage never restarts the container",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LivenessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "description": "Checks each dependency (PostgreSQL, ClickHouse, Weaviate, Thai administrative data, image cache) with its latency.\nOptional dependencies that are slow or down make the service \"degraded\" but still ready (HTTP 200);\nonly a critical dependency (PostgreSQL) being down answers 503 so the instance is taken out of rotation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReadinessResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReadinessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/help": {
+            "get": {
+                "description": "Every served endpoint by section with its summary and parameters, from the route registry",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "documentation"
+                ],
+                "summary": "Endpoint list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apispec.Guide"
+                        }
+                    }
+                }
+            }
+        },
+        "/images/duplicates": {
+            "get": {
+                "description": "Group uploaded images of different products whose perceptual hashes (dHash) are at most max_distance bits apart, to find duplicated SKUs. Linked image URLs are not downloaded and take no part.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Find duplicate product images",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Largest Hamming distance between duplicates, 0-16 (default 6)",
+                        "name": "max_distance",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ImageDuplicatesResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Request counts and latency per route, connection pools, Weaviate availability, index freshness and search result counts in the Prometheus text format",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Prometheus metrics",
+                "responses": {
+                    "200": {
+                        "description": "Prometheus metrics",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/pgcommand": {
+            "post": {
+                "description": "Execute any PostgreSQL SQL command (INSERT, UPDATE, DELETE, CREATE, etc.) via JSON request. The SQL may be sent base64 encoded as query_base64 instead of query; the response echoes the decoded SQL. timeout_ms bounds how long the statement runs (sql_limits); a statement that runs longer, or whose client disconnects, is cancelled on the database and answers 408.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "database"
+                ],
+                "summary": "Execute PostgreSQL database command",
+                "parameters": [
+                    {
+                        "description": "Command to execute",
+                        "name": "command",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pgselect": {
+            "post": {
+                "description": "Execute PostgreSQL SELECT query and return data via JSON request. The SQL may be sent base64 encoded as query_base64 instead of query; the response echoes the decoded SQL. timeout_ms bounds how long the statement runs (sql_limits); a statement that runs longer, or whose client disconnects, is cancelled on the database and answers 408. With explain the plan is returned in plan instead of rows. Results stop at max_rows or sql_limits.max_response_bytes with truncated set and a next_cursor to the rest.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "database"
+                ],
+                "summary": "Execute PostgreSQL SELECT query",
+                "parameters": [
+                    {
+                        "description": "SELECT query to execute",
+                        "name": "select",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output format: json (default), ndjson (streamed, one row per line), csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Download file name for csv and xlsx",
+                        "name": "filename",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pgtransaction": {
+            "post": {
+                "description": "Run an ordered list of statements atomically: all are committed, or on the first failure the transaction is rolled back and nothing is written.\nEach statement may bind params to $1, $2, ...; SELECT and ... RETURNING statements report their rows.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "database"
+                ],
+                "summary": "Execute PostgreSQL statements in one transaction",
+                "parameters": [
+                    {
+                        "description": "Statements to execute",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/product-images/{id}": {
+            "get": {
+                "description": "Serves the file of an uploaded product image. No authentication, so the URL works in img tags.",
+                "produces": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif",
+                    "image/webp"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get an uploaded product image",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Image id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/batch": {
+            "post": {
+                "description": "Resolve up to 500 ic_codes or barcodes in one call, with price, stock and barcodes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Look up many products at once",
+                "parameters": [
+                    {
+                        "description": "Codes or barcodes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductBatchResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/recently-viewed": {
+            "get": {
+                "description": "Products most recently viewed by the client identified by client_id or the X-Client-ID header",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get recently viewed products for a client",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client identifier (or X-Client-ID header)",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of products (default 20, max 100 unless page_limits says otherwise)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.TrendingProduct"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/products/trending": {
+            "get": {
+                "description": "Most viewed products over the last N days, enriched with product data",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get trending products",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Look-back window in days (default 7, max 90)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of products (default 20, max 100 unless page_limits says otherwise)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.TrendingProduct"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{code}": {
+            "get": {
+                "description": "Full product record by ic_code or barcode: ic_inventory columns, barcodes, price tiers, per-warehouse balances and image URLs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get product detail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ic_code or barcode",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductDetail"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{code}/images": {
+            "get": {
+                "description": "Images linked to a product in product_images, first image first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "List product images",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ic_code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.ProductImage"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Link an image URL (JSON body) or upload a JPEG, PNG, GIF or WebP file (multipart field \"file\", optional \"sort_order\"). Uploaded files are served from /v1/product-images/{id}.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Add a product image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ic_code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Image URL",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductImageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductImage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{code}/images/{id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Delete a product image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ic_code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Image id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/provinces": {
+            "get": {
+                "description": "Retrieve all provinces in Thailand with Thai and English names",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Get all Thai provinces",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Province"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (GET only)"
+                    }
+                }
+            },
+            "post": {
+                "description": "Retrieve all provinces in Thailand with Thai and English names",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Get all Thai provinces",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Province"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (GET only)"
+                    }
+                }
+            }
+        },
+        "/query/{name}": {
+            "post": {
+                "description": "Run the SQL template an admin registered under name with typed arguments. Frontends get the flexibility of SQL without sending raw queries to /select. Supports ?format=ndjson|csv|xlsx like /select.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sql"
+                ],
+                "summary": "Run a named query",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Named query",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Arguments by parameter name",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.RunNamedQueryRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), ndjson, csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/stock-aging": {
+            "get": {
+                "description": "On-hand quantities per warehouse bucketed by goods-receipt age (0-30, 31-90, 91-180 and over 180 days), oldest stock assumed sold first. Streamed as CSV by default.",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Stock aging report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only this warehouse",
+                        "name": "wh_code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "warehouse (default) or item for one row per product and warehouse",
+                        "name": "level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv (default), ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Download file name",
+                        "name": "filename",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "CSV report",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/schema/{db}/tables": {
+            "get": {
+                "description": "Tables and views with their kind (or ClickHouse engine), estimated row count and size. Tables the SQL policy denies are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "database"
+                ],
+                "summary": "Tables of a database",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "postgresql or clickhouse",
+                        "name": "db",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "PostgreSQL schema or ClickHouse database (default: public, or the configured ClickHouse database)",
+                        "name": "schema",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.TableInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/schema/{db}/tables/{name}": {
+            "get": {
+                "description": "Columns with their types, nullability and defaults, indexes (ClickHouse: data skipping indexes), primary key and ClickHouse sorting key",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "database"
+                ],
+                "summary": "Columns and indexes of a table",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "postgresql or clickhouse",
+                        "name": "db",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Table name, optionally qualified with the schema or database",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.TableSchema"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "post": {
+                "description": "Earlier path of POST /search-by-vector, taking the same body and giving the same response. Answers carry a Deprecation header and a Link to /v1/search-by-vector; move clients there.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Product search (deprecated)",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "description": "Search parameters",
+                        "name": "search",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchParameters"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.VectorSearchResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/search-by-vector": {
+            "get": {
+                "description": "Search for products using Weaviate vector database to get IC codes (primary) or barcodes (fallback), then search PostgreSQL for detailed product information.\nOn the first page (offset 0 without a cursor) exact barcode and code matches, or LIKE matches when there are none, lead the results; search_method and priority in the response say how the page was found.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Search products using vector database first, then PostgreSQL",
+                "parameters": [
+                    {
+                        "description": "Search parameters",
+                        "name": "search",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchParameters"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search text (GET only)",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results (GET only)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination offset (GET only)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous page, replaces offset (GET only)",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.VectorSearchResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Search for products using Weaviate vector database to get IC codes (primary) or barcodes (fallback), then search PostgreSQL for detailed product information.\nOn the first page (offset 0 without a cursor) exact barcode and code matches, or LIKE matches when there are none, lead the results; search_method and priority in the response say how the page was found.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Search products using vector database first, then PostgreSQL",
+                "parameters": [
+                    {
+                        "description": "Search parameters",
+                        "name": "search",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchParameters"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search text (GET only)",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results (GET only)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination offset (GET only)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous page, replaces offset (GET only)",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.VectorSearchResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/search/explain": {
+            "get": {
+                "description": "Runs each search stage (exact barcode, exact code, LIKE, Weaviate BM25, PostgreSQL text search, TF-IDF) for the query and reports whether and at which rank it returns the product, with the tokens of the query and the product name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Explain why a product does or does not match a query",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search text as typed by the user",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ic_code of the product expected in the results",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size of the search being explained",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Results inspected per stage (default 300, max 1000)",
+                        "name": "depth",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.SearchExplanation"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/search/hybrid": {
+            "post": {
+                "description": "Runs Weaviate BM25, the TF-IDF index and PostgreSQL ILIKE in parallel and merges their rankings with reciprocal rank fusion. Each result lists its rank and score per source.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Hybrid product search with reciprocal rank fusion",
+                "parameters": [
+                    {
+                        "description": "Hybrid search parameters",
+                        "name": "search",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.HybridSearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.HybridSearchResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.HybridSearchResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/select": {
+            "post": {
+                "description": "Execute SELECT query and return data via JSON request. The SQL may be sent base64 encoded as query_base64 instead of query; the response echoes the decoded SQL. timeout_ms bounds how long the statement runs (sql_limits); a statement that runs longer, or whose client disconnects, is cancelled on the database and answers 408. With explain the plan is returned in plan instead of rows. Results stop at max_rows or sql_limits.max_response_bytes with truncated set and a next_cursor to the rest.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "database"
+                ],
+                "summary": "Execute SELECT query",
+                "parameters": [
+                    {
+                        "description": "SELECT query to execute",
+                        "name": "select",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output format: json (default), ndjson (streamed, one row per line), csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Download file name for csv and xlsx",
+                        "name": "filename",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/selectget": {
+            "get": {
+                "description": "For tools that can only issue GET requests. q is the SELECT in base64, standard or URL-safe, with or without padding. The response is the same as POST /select; its query field holds the decoded SQL so it can be checked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "database"
+                ],
+                "summary": "Execute a base64-encoded ClickHouse SELECT with GET",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SELECT query, base64 encoded",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seconds the result may be served from the cache",
+                        "name": "cache_ttl",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Milliseconds the query may run (sql_limits)",
+                        "name": "timeout_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Rows per page, lowered to sql_limits.max_rows",
+                        "name": "max_rows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output format: json (default), ndjson (streamed, one row per line), csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Download file name for csv and xlsx",
+                        "name": "filename",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.SelectResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/share": {
+            "post": {
+                "description": "Store a result set, or the query and parameters behind it, under a short token and return a read-only link that expires after ttl_minutes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "share"
+                ],
+                "summary": "Share a search result",
+                "parameters": [
+                    {
+                        "description": "Result or parameters to share",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ShareRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ShareLink"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/share/{token}": {
+            "get": {
+                "description": "Return the result or parameters stored under a share token. Links need no credentials and stop working when they expire.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "share"
+                ],
+                "summary": "Open a share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.SharedResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tables": {
+            "get": {
+                "description": "Retrieve a list of all tables in the ClickHouse database. GET /schema/{db}/tables also lists PostgreSQL tables with row estimates, and GET /schema/{db}/tables/{name} describes one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "database"
+                ],
+                "summary": "Get all database tables",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Table"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/tambons": {
+            "get": {
+                "description": "Retrieve all sub-districts (tambons) in a specified amphure and province",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Get all tambons in an amphure",
+                "parameters": [
+                    {
+                        "description": "Amphure and Province IDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TambonRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Amphure ID (GET only)",
+                        "name": "amphure_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Province ID (GET only)",
+                        "name": "province_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Tambon"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (GET only)"
+                    }
+                }
+            },
+            "post": {
+                "description": "Retrieve all sub-districts (tambons) in a specified amphure and province",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Get all tambons in an amphure",
+                "parameters": [
+                    {
+                        "description": "Amphure and Province IDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TambonRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Amphure ID (GET only)",
+                        "name": "amphure_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Province ID (GET only)",
+                        "name": "province_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Tambon"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (GET only)"
+                    }
+                }
+            }
+        },
+        "/thai-admin/export": {
+            "get": {
+                "description": "Full province \u003e amphure \u003e tambon hierarchy as JSON or CSV for offline use. The ETag is the SHA-256 of the file; send it in If-None-Match to get 304 when nothing changed.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Download the Thai administrative dataset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "json (default) or csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ThaiAdminDataset"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/thai-admin/format-address": {
+            "post": {
+                "description": "Order address components the way Thailand Post expects (แขวง/เขต for Bangkok, ตำบล/อำเภอ/จังหวัด elsewhere) and add an English block for international labels. The location is checked against the Thai administrative data.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Format a Thai postal address",
+                "parameters": [
+                    {
+                        "description": "Address components",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddressFormatRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.AddressFormatResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/thai-admin/reverse-geocode": {
+            "get": {
+                "description": "Return the tambon, amphure and province containing a WGS 84 point, e.g. the GPS position of a delivery address. Needs the tambon boundaries GeoJSON file set by thai_admin.boundaries_file.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Find the tambon at a coordinate",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "lng",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.CompleteLocationData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/thai-admin/search": {
+            "post": {
+                "description": "Find administrative areas from free text in Thai or English (\"บางรัก\", \"Chiang Mai\", \"อ.เมือง\") without knowing their IDs. Labels, spaces and tone marks are ignored and misspellings still match; results are scored from 1 (exact) down to 0.6, best first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thai-admin"
+                ],
+                "summary": "Search provinces, amphures and tambons by name",
+                "parameters": [
+                    {
+                        "description": "Search text and filters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ThaiAdminSearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.ThaiAdminSearchResult"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Every registered webhook, without its secret",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Webhook"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Register a URL to be POSTed the events it subscribes to: product.updated, stock.low, import.completed. Calls are signed with HMAC-SHA256; the secret is generated unless given and is only returned here.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register a webhook",
+                "parameters": [
+                    {
+                        "description": "URL, events and optional secret",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the URL, events and description of a webhook. active pauses or resumes it; a secret replaces the current one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Change a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "URL, events, active flag and optional new secret",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a webhook and its deliveries, including those not sent yet",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Latest deliveries first, with their attempts, last response status and error",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List the deliveries of a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, delivered or failed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum deliveries (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.WebhookDelivery"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{delivery}/retry": {
+            "post": {
+                "description": "Queue a delivery again with a fresh set of attempts, e.g. after a failed one once the receiver is fixed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Retry a delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Delivery id",
+                        "name": "delivery",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.WebhookDelivery"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/test": {
+            "post": {
+                "description": "Queue a \"ping\" event for the webhook, even while it is paused. Check its outcome in the deliveries.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Send a test event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.WebhookDelivery"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "description": "Upgrade to a WebSocket and send {\"id\", \"type\"} messages: \"search\" and \"select\" stream their results back in \"rows\" batches followed by \"done\"; \"jobs\" pushes the progress of the Weaviate sync, table sync, exports, product imports and scheduled jobs whenever it changes; \"cancel\" stops a subscription. Browsers can pass credentials as ?access_token=.",
+                "tags": [
+                    "websocket"
+                ],
+                "summary": "Live search, SELECT and job progress over a WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key or access token, for clients that cannot set headers",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apispec.Endpoint": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "type of APIResponse.data",
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "path_params": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "query": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apispec.Param"
+                    }
+                },
+                "request": {
+                    "description": "model of the JSON body, or of the query of a GET route",
+                    "type": "string"
+                },
+                "request_in": {
+                    "description": "\"body\" or \"query\"",
+                    "type": "string"
+                },
+                "response": {
+                    "description": "model of the reply; \"APIResponse\" wraps Data",
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                }
+            }
+        },
+        "apispec.Guide": {
+            "type": "object",
+            "properties": {
+                "models": {
+                    "description": "left out by /v1/help",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apispec.Model"
+                    }
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apispec.Section"
+                    }
+                }
+            }
+        },
+        "apispec.Model": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apispec.ModelField"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "apispec.ModelField": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "optional": {
+                    "description": "omitempty or a pointer",
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "apispec.Param": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "\"string\", \"int\", \"bool\" or \"double\"",
+                    "type": "string"
+                }
+            }
+        },
+        "apispec.Section": {
+            "type": "object",
+            "properties": {
+                "endpoints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apispec.Endpoint"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "jobs.RunInfo": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "trigger": {
+                    "type": "string"
+                }
+            }
+        },
+        "jobs.Status": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "failures": {
+                    "type": "integer"
+                },
+                "last_run": {
+                    "$ref": "#/definitions/jobs.RunInfo"
+                },
+                "last_success": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run": {
+                    "type": "string"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "runs": {
+                    "type": "integer"
+                },
+                "schedule": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key_prefix": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.APIResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "degraded_services": {
+                    "description": "Services that were unavailable, so the response may come from a fallback path",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.AddressFormatRequest": {
+            "type": "object",
+            "properties": {
+                "abbreviate": {
+                    "description": "ต. อ. จ. ม. ซ. ถ. instead of the full words",
+                    "type": "boolean"
+                },
+                "amphure": {
+                    "type": "string"
+                },
+                "amphure_id": {
+                    "type": "integer"
+                },
+                "building": {
+                    "type": "string"
+                },
+                "building_en": {
+                    "type": "string"
+                },
+                "company": {
+                    "type": "string"
+                },
+                "company_en": {
+                    "type": "string"
+                },
+                "floor": {
+                    "type": "string"
+                },
+                "house_no": {
+                    "type": "string"
+                },
+                "moo": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "name_en": {
+                    "description": "Latin spellings for the English block; without them the Thai text is printed as given",
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "province": {
+                    "type": "string"
+                },
+                "province_id": {
+                    "type": "integer"
+                },
+                "road": {
+                    "type": "string"
+                },
+                "road_en": {
+                    "type": "string"
+                },
+                "room": {
+                    "type": "string"
+                },
+                "soi": {
+                    "type": "string"
+                },
+                "soi_en": {
+                    "type": "string"
+                },
+                "tambon": {
+                    "type": "string"
+                },
+                "tambon_id": {
+                    "type": "integer"
+                },
+                "village": {
+                    "type": "string"
+                },
+                "village_en": {
+                    "type": "string"
+                },
+                "zip_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AddressFormatResult": {
+            "type": "object",
+            "properties": {
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "lines_en": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "location": {
+                    "$ref": "#/definitions/models.CompleteLocationData"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "text_en": {
+                    "type": "string"
+                },
+                "verified": {
+                    "description": "the location matched the Thai administrative data",
+                    "type": "boolean"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.AddressParseRequest": {
+            "type": "object",
+            "required": [
+                "address"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AddressParseResult": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "$ref": "#/definitions/models.AddressFormatRequest"
+                },
+                "confidence": {
+                    "description": "0 to 1: how well the location matches the text",
+                    "type": "number"
+                },
+                "location": {
+                    "description": "the tambon found, when one clearly matches",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CompleteLocationData"
+                        }
+                    ]
+                },
+                "unparsed": {
+                    "description": "text after the house number that no label explains",
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.Amphure": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name_en": {
+                    "type": "string"
+                },
+                "name_th": {
+                    "type": "string"
+                },
+                "province_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AmphureRequest": {
+            "type": "object",
+            "required": [
+                "province_id"
+            ],
+            "properties": {
+                "province_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AmphureTree": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name_en": {
+                    "type": "string"
+                },
+                "name_th": {
+                    "type": "string"
+                },
+                "tambons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TambonLeaf"
+                    }
+                }
+            }
+        },
+        "models.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "session username, \"api-key:\u003cname\u003e\", or empty when auth is off",
+                    "type": "string"
+                },
+                "client_ip": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "route": {
+                    "description": "route pattern, e.g. /v1/data/:table/:id",
+                    "type": "string"
+                },
+                "rows_affected": {
+                    "type": "integer"
+                },
+                "sql": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditPage": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEntry"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "entries matching the filters",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BarcodeScanRequest": {
+            "type": "object",
+            "required": [
+                "image"
+            ],
+            "properties": {
+                "image": {
+                    "description": "base64 JPEG, PNG, GIF or WebP; a data: URL is accepted",
+                    "type": "string"
+                }
+            }
+        },
+        "models.BatchProduct": {
+            "type": "object",
+            "properties": {
+                "barcodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ic_code": {
+                    "type": "string"
+                },
+                "matched_by": {
+                    "description": "\"code\" or \"barcode\"",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prices": {
+                    "description": "price_0..price_4",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "qty_available": {
+                    "type": "number"
+                },
+                "query": {
+                    "description": "the requested code or barcode",
+                    "type": "string"
+                },
+                "sale_price": {
+                    "description": "price_0",
+                    "type": "number"
+                },
+                "unit_standard_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ColumnInfo": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "default": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nullable": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "description": "as the database spells it, e.g. numeric(18,2) or Nullable(String)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommandRequest": {
+            "type": "object",
+            "properties": {
+                "query": {
+                    "description": "SQL command to execute",
+                    "type": "string"
+                },
+                "query_base64": {
+                    "description": "the command base64 encoded, instead of query",
+                    "type": "string"
+                },
+                "timeout_ms": {
+                    "description": "milliseconds the command may run; 0 for sql_limits.default_timeout_ms",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CommandResponse": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "number"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "result": {},
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.CompleteLocationData": {
+            "type": "object",
+            "properties": {
+                "amphure": {
+                    "$ref": "#/definitions/models.Amphure"
+                },
+                "province": {
+                    "$ref": "#/definitions/models.Province"
+                },
+                "tambon": {
+                    "$ref": "#/definitions/models.Tambon"
+                }
+            }
+        },
+        "models.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/models.APIKey"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "role",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DBPoolStats": {
+            "type": "object",
+            "properties": {
+                "conn_max_idle_time_seconds": {
+                    "type": "integer"
+                },
+                "conn_max_lifetime_seconds": {
+                    "type": "integer"
+                },
+                "database": {
+                    "type": "string"
+                },
+                "idle": {
+                    "type": "integer"
+                },
+                "in_use": {
+                    "type": "integer"
+                },
+                "max_idle_closed": {
+                    "type": "integer"
+                },
+                "max_idle_conns": {
+                    "type": "integer"
+                },
+                "max_idle_time_closed": {
+                    "type": "integer"
+                },
+                "max_lifetime_closed": {
+                    "type": "integer"
+                },
+                "max_open_conns": {
+                    "type": "integer"
+                },
+                "open_connections": {
+                    "type": "integer"
+                },
+                "statement_timeout_seconds": {
+                    "description": "0 = no limit",
+                    "type": "integer"
+                },
+                "wait_count": {
+                    "description": "queries that waited for a free connection",
+                    "type": "integer"
+                },
+                "wait_duration_ms": {
+                    "description": "total time spent waiting",
+                    "type": "number"
+                }
+            }
+        },
+        "models.DataPage": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "additionalProperties": true
+                    }
+                },
+                "table": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "rows matching the filters",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DependencyHealth": {
+            "type": "object",
+            "properties": {
+                "budget_ms": {
+                    "type": "integer"
+                },
+                "critical": {
+                    "type": "boolean"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "up, slow or down",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExportJob": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "database": {
+                    "type": "string"
+                },
+                "download_url": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "when the job and its file are removed",
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "rows": {
+                    "description": "rows written so far",
+                    "type": "integer"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "queued, running, done, failed or cancelled",
+                    "type": "string"
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExportRequest": {
+            "type": "object",
+            "properties": {
+                "database": {
+                    "description": "postgresql (default) or clickhouse",
+                    "type": "string"
+                },
+                "filename": {
+                    "description": "download name; default export-\u003cid\u003e.\u003cformat\u003e",
+                    "type": "string"
+                },
+                "format": {
+                    "description": "csv (default), parquet, ndjson or xlsx",
+                    "type": "string"
+                },
+                "params": {
+                    "description": "bound to the placeholders of Query",
+                    "type": "array",
+                    "items": {}
+                },
+                "query": {
+                    "type": "string"
+                },
+                "table": {
+                    "description": "exports every row of the table, optionally schema-qualified",
+                    "type": "string"
+                }
+            }
+        },
+        "models.GraphQLError": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {}
+                }
+            }
+        },
+        "models.GraphQLRequest": {
+            "type": "object",
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "models.GraphQLResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GraphQLError"
+                    }
+                }
+            }
+        },
+        "models.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "database": {
+                    "type": "string"
+                },
+                "dependencies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DependencyHealth"
+                    }
+                },
+                "rate_limits": {
+                    "$ref": "#/definitions/models.RateLimitInfo"
+                },
+                "status": {
+                    "description": "healthy, degraded or unhealthy",
+                    "type": "string"
+                },
+                "thai_admin": {
+                    "$ref": "#/definitions/models.ThaiAdminDatasetInfo"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HybridSearchRequest": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "candidates": {
+                    "description": "results taken from each source, max(limit*2, 50) when not given",
+                    "type": "integer"
+                },
+                "k": {
+                    "description": "RRF rank constant, 60 when not given",
+                    "type": "integer"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "sources": {
+                    "description": "\"bm25\", \"tfidf\", \"sql\"; all when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "weights": {
+                    "description": "per-source weight, 1 when not given",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                }
+            }
+        },
+        "models.ImageDuplicateGroup": {
+            "type": "object",
+            "properties": {
+                "distance": {
+                    "description": "largest Hamming distance between two images that joined the group",
+                    "type": "integer"
+                },
+                "ic_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductImage"
+                    }
+                }
+            }
+        },
+        "models.ImageDuplicatesResponse": {
+            "type": "object",
+            "properties": {
+                "compared": {
+                    "description": "hashed images",
+                    "type": "integer"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImageDuplicateGroup"
+                    }
+                },
+                "max_distance": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.IndexInfo": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "description": "PostgreSQL key columns or expressions",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expression": {
+                    "description": "PostgreSQL CREATE INDEX statement, ClickHouse index expression",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "primary": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "description": "btree, gin...; minmax, bloom_filter...",
+                    "type": "string"
+                },
+                "unique": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.InventoryEvent": {
+            "type": "object",
+            "properties": {
+                "balance_qty": {
+                    "type": "number"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "ic_code": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "previous_balance_qty": {
+                    "type": "number"
+                },
+                "previous_prices": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "prices": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LivenessResponse": {
+            "type": "object",
+            "properties": {
+                "goroutines": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "always \"alive\"",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "uptime_seconds": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.LoginRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NamedQuery": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "database": {
+                    "description": "postgresql or clickhouse",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "params": {
+                    "description": "in placeholder order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NamedQueryParam"
+                    }
+                },
+                "query": {
+                    "description": "one SELECT with $1, $2, ... (PostgreSQL) or ? (ClickHouse) placeholders",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NamedQueryParam": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "used when the argument is missing or null"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "required": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "description": "string, int, float, bool, date, timestamp, string[], int[] or float[]",
+                    "type": "string"
+                }
+            }
+        },
+        "models.NamedQueryRequest": {
+            "type": "object",
+            "required": [
+                "database",
+                "query"
+            ],
+            "properties": {
+                "database": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "params": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NamedQueryParam"
+                    }
+                },
+                "query": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProductBarcode": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string"
+                },
+                "unit_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProductBatchRequest": {
+            "type": "object",
+            "required": [
+                "codes"
+            ],
+            "properties": {
+                "codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.ProductBatchResponse": {
+            "type": "object",
+            "properties": {
+                "not_found": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BatchProduct"
+                    }
+                }
+            }
+        },
+        "models.ProductDetail": {
+            "type": "object",
+            "properties": {
+                "balances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WarehouseBalance"
+                    }
+                },
+                "barcodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductBarcode"
+                    }
+                },
+                "ic_code": {
+                    "type": "string"
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "inventory": {
+                    "description": "every ic_inventory column",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "matched_by": {
+                    "description": "\"code\" or \"barcode\"",
+                    "type": "string"
+                },
+                "prices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductPrice"
+                    }
+                },
+                "total_balance": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ProductImage": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "dHash of uploaded images, 16 hex digits",
+                    "type": "string"
+                },
+                "ic_code": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "sort_order": {
+                    "description": "lowest first; the first image is the img_url of search results",
+                    "type": "integer"
+                },
+                "uploaded": {
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProductImageRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "sort_order": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProductImportError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ProductImportResult": {
+            "type": "object",
+            "properties": {
+                "barcodes_upserted": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "description": "everything was rolled back",
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductImportError"
+                    }
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "prices_upserted": {
+                    "type": "integer"
+                },
+                "products_inserted": {
+                    "type": "integer"
+                },
+                "products_updated": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ProductPrice": {
+            "type": "object",
+            "properties": {
+                "tiers": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "unit_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProductViewRequest": {
+            "type": "object",
+            "required": [
+                "ic_code"
+            ],
+            "properties": {
+                "client_id": {
+                    "description": "falls back to the X-Client-ID header",
+                    "type": "string"
+                },
+                "ic_code": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "e.g. \"web\", \"pos\", \"mobile\"",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Province": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "geography_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name_en": {
+                    "type": "string"
+                },
+                "name_th": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProvinceTree": {
+            "type": "object",
+            "properties": {
+                "amphures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AmphureTree"
+                    }
+                },
+                "geography_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name_en": {
+                    "type": "string"
+                },
+                "name_th": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RateLimitInfo": {
+            "type": "object",
+            "properties": {
+                "backend": {
+                    "description": "memory, or redis when buckets are shared by every instance",
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RateLimitRuleInfo"
+                    }
+                },
+                "tracked_clients": {
+                    "description": "buckets kept in this instance's memory",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RateLimitRuleInfo": {
+            "type": "object",
+            "properties": {
+                "burst": {
+                    "type": "integer"
+                },
+                "requests_per_minute": {
+                    "description": "0 means unlimited",
+                    "type": "integer"
+                },
+                "route": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "dependencies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DependencyHealth"
+                    }
+                },
+                "ready": {
+                    "description": "false only when a critical dependency is down",
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "healthy, degraded or unhealthy",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RecordDiff": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "after": {
+                    "type": "integer"
+                },
+                "before": {
+                    "type": "integer"
+                },
+                "changed": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.RefreshRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RunNamedQueryRequest": {
+            "type": "object",
+            "properties": {
+                "args": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "models.RunningQuery": {
+            "type": "object",
+            "properties": {
+                "cancelled": {
+                    "description": "cancel requested; the statement is being stopped",
+                    "type": "boolean"
+                },
+                "database": {
+                    "description": "clickhouse or postgresql",
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "description": "running time so far",
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "route": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "timeout_ms": {
+                    "description": "when the statement is cancelled by itself",
+                    "type": "integer"
+                },
+                "user": {
+                    "description": "username, or api-key:\u003cname\u003e",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SearchLatencyStats": {
+            "type": "object",
+            "properties": {
+                "avg_ms": {
+                    "type": "number"
+                },
+                "max_ms": {
+                    "type": "number"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "p50_ms": {
+                    "type": "number"
+                },
+                "p90_ms": {
+                    "type": "number"
+                },
+                "p95_ms": {
+                    "type": "number"
+                },
+                "p99_ms": {
+                    "type": "number"
+                },
+                "searches": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SearchParameters": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "ai": {
+                    "description": "1 = same as expand_synonyms (kept for older clients)",
+                    "type": "integer"
+                },
+                "ai_enhance": {
+                    "description": "let the configured language model add terms to the vector lookup",
+                    "type": "boolean"
+                },
+                "boost_has_image": {
+                    "description": "added when the product has an image",
+                    "type": "number"
+                },
+                "boost_in_stock": {
+                    "description": "added when stock is above zero",
+                    "type": "number"
+                },
+                "cursor": {
+                    "description": "next_cursor from the previous page; replaces offset",
+                    "type": "string"
+                },
+                "expand_synonyms": {
+                    "description": "add Thai/English synonyms to the vector lookup",
+                    "type": "boolean"
+                },
+                "facets": {
+                    "description": "item_type, unit_standard_code, supplier, stock_status or all",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "fields": {
+                    "description": "result fields to return, e.g. [\"code\", \"name\", \"final_price\"]; all when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "group_by": {
+                    "description": "collapse variants: \"code_prefix\" or \"name\"",
+                    "type": "string"
+                },
+                "group_prefix_length": {
+                    "description": "fixed code prefix length for group_by=code_prefix",
+                    "type": "integer"
+                },
+                "in_stock_only": {
+                    "description": "only products with qty_available above zero",
+                    "type": "boolean"
+                },
+                "item_type": {
+                    "description": "ic_inventory.item_type",
+                    "type": "integer"
+                },
+                "limit": {
+                    "description": "number of results",
+                    "type": "integer"
+                },
+                "max_price": {
+                    "description": "final_price at most this",
+                    "type": "number"
+                },
+                "min_price": {
+                    "description": "Filters, applied in SQL so pages and counts only cover matching products",
+                    "type": "number"
+                },
+                "offset": {
+                    "description": "pagination offset",
+                    "type": "integer"
+                },
+                "query": {
+                    "description": "actual search text (not base64)",
+                    "type": "string"
+                },
+                "weight_barcode": {
+                    "description": "exact barcode matches",
+                    "type": "number"
+                },
+                "weight_exact_code": {
+                    "description": "Ranking knobs; weights default to 1 and boosts to 0, which is the standard ranking",
+                    "type": "number"
+                },
+                "weight_vector": {
+                    "description": "Weaviate relevance",
+                    "type": "number"
+                }
+            }
+        },
+        "models.SearchQueryStats": {
+            "type": "object",
+            "properties": {
+                "avg_latency_ms": {
+                    "type": "number"
+                },
+                "avg_results": {
+                    "type": "number"
+                },
+                "first_searched": {
+                    "type": "string"
+                },
+                "last_searched": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "searches": {
+                    "type": "integer"
+                },
+                "unique_clients": {
+                    "type": "integer"
+                },
+                "zero_results": {
+                    "description": "searches that found nothing",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SelectCacheEntryStats": {
+            "type": "object",
+            "properties": {
+                "cached_at": {
+                    "type": "string"
+                },
+                "database": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "hits": {
+                    "description": "memory backend only",
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "tables": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.SelectCacheInvalidation": {
+            "type": "object",
+            "properties": {
+                "removed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SelectCacheStats": {
+            "type": "object",
+            "properties": {
+                "backend": {
+                    "description": "memory, or redis when shared by every instance",
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "entries": {
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Redis could not be read completely",
+                    "type": "string"
+                },
+                "hits": {
+                    "description": "answered by this instance",
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SelectCacheEntryStats"
+                    }
+                },
+                "max_entries": {
+                    "description": "memory backend only",
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SelectRequest": {
+            "type": "object",
+            "properties": {
+                "analyze": {
+                    "description": "with explain on PostgreSQL: run the query and report actual times and rows",
+                    "type": "boolean"
+                },
+                "cache_ttl": {
+                    "description": "seconds the result may be served from the cache; 0 always runs the query",
+                    "type": "integer"
+                },
+                "cursor": {
+                    "description": "next_cursor of the previous page of the same query and params",
+                    "type": "string"
+                },
+                "explain": {
+                    "description": "return the plan instead of running the query",
+                    "type": "boolean"
+                },
+                "max_rows": {
+                    "description": "rows per page, lowered to sql_limits.max_rows; 0 for that maximum",
+                    "type": "integer"
+                },
+                "params": {
+                    "description": "values bound to \"?\" (ClickHouse) or $1, $2, ... (PostgreSQL) placeholders",
+                    "type": "array",
+                    "items": {}
+                },
+                "query": {
+                    "description": "SELECT query to execute",
+                    "type": "string"
+                },
+                "query_base64": {
+                    "description": "the query base64 encoded, instead of query",
+                    "type": "string"
+                },
+                "timeout_ms": {
+                    "description": "milliseconds the query may run; 0 for sql_limits.default_timeout_ms",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SelectResponse": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "description": "served from the SELECT cache",
+                    "type": "boolean"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {}
+                },
+                "duration_ms": {
+                    "type": "number"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "next_cursor": {
+                    "description": "pass as \"cursor\" with the same query and params to fetch the next page",
+                    "type": "string"
+                },
+                "plan": {
+                    "description": "EXPLAIN output, one line per element, when explain is set",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "query": {
+                    "type": "string"
+                },
+                "row_count": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "truncated": {
+                    "description": "rows stopped at max_rows or sql_limits.max_response_bytes",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ShareLink": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ShareRequest": {
+            "type": "object",
+            "properties": {
+                "endpoint": {
+                    "description": "API path the result came from, e.g. \"/v1/search-by-vector\"",
+                    "type": "string"
+                },
+                "params": {
+                    "description": "request parameters, so the search can be run again"
+                },
+                "result": {
+                    "description": "response data to show as-is"
+                },
+                "title": {
+                    "description": "e.g. \"Filters for Hino 500\"",
+                    "type": "string"
+                },
+                "ttl_minutes": {
+                    "description": "defaults to search.share.default_ttl_minutes",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SharedResult": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "params": {},
+                "result": {},
+                "title": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Table": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TableInfo": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "PostgreSQL: table, view, materialized view...; ClickHouse: the engine",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "row_estimate": {
+                    "type": "integer"
+                },
+                "schema": {
+                    "description": "PostgreSQL schema or ClickHouse database",
+                    "type": "string"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TableSchema": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ColumnInfo"
+                    }
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "indexes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.IndexInfo"
+                    }
+                },
+                "kind": {
+                    "description": "PostgreSQL: table, view, materialized view...; ClickHouse: the engine",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "primary_key": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "row_estimate": {
+                    "type": "integer"
+                },
+                "schema": {
+                    "description": "PostgreSQL schema or ClickHouse database",
+                    "type": "string"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "sorting_key": {
+                    "description": "ClickHouse ORDER BY expression",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Tambon": {
+            "type": "object",
+            "properties": {
+                "amphure_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name_en": {
+                    "type": "string"
+                },
+                "name_th": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "zip_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TambonLeaf": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name_en": {
+                    "type": "string"
+                },
+                "name_th": {
+                    "type": "string"
+                },
+                "zip_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TambonRequest": {
+            "type": "object",
+            "required": [
+                "amphure_id",
+                "province_id"
+            ],
+            "properties": {
+                "amphure_id": {
+                    "type": "integer"
+                },
+                "province_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ThaiAdminCounts": {
+            "type": "object",
+            "properties": {
+                "amphures": {
+                    "type": "integer"
+                },
+                "provinces": {
+                    "type": "integer"
+                },
+                "tambons": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ThaiAdminDataset": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "$ref": "#/definitions/models.ThaiAdminCounts"
+                },
+                "provinces": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProvinceTree"
+                    }
+                },
+                "updated_at": {
+                    "description": "latest updated_at of any record",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "changes whenever the data changes",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ThaiAdminDatasetInfo": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "$ref": "#/definitions/models.ThaiAdminCounts"
+                },
+                "replaced_at": {
+                    "description": "when an upload or refresh last swapped the data in",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "files, upload or upstream",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "latest updated_at of any record",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "the version of /v1/thai-admin/export",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ThaiAdminDiff": {
+            "type": "object",
+            "properties": {
+                "amphures": {
+                    "$ref": "#/definitions/models.RecordDiff"
+                },
+                "provinces": {
+                    "$ref": "#/definitions/models.RecordDiff"
+                },
+                "tambons": {
+                    "$ref": "#/definitions/models.RecordDiff"
+                }
+            }
+        },
+        "models.ThaiAdminSearchRequest": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "levels": {
+                    "description": "province, amphure and/or tambon; default all",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "limit": {
+                    "description": "default 20, max 100",
+                    "type": "integer"
+                },
+                "province_id": {
+                    "description": "only areas in this province",
+                    "type": "integer"
+                },
+                "query": {
+                    "description": "e.g. \"บางรัก\" or \"Chiang Mai\"",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ThaiAdminSearchResult": {
+            "type": "object",
+            "properties": {
+                "amphure": {
+                    "description": "of a tambon",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Amphure"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "level": {
+                    "description": "province, amphure or tambon",
+                    "type": "string"
+                },
+                "name_en": {
+                    "type": "string"
+                },
+                "name_th": {
+                    "type": "string"
+                },
+                "province": {
+                    "description": "of an amphure or tambon",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Province"
+                        }
+                    ]
+                },
+                "score": {
+                    "description": "1 for an exact match, down to 0.6",
+                    "type": "number"
+                },
+                "zip_code": {
+                    "description": "tambons only",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ThaiAdminUploadRequest": {
+            "type": "object",
+            "properties": {
+                "amphures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Amphure"
+                    }
+                },
+                "provinces": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Province"
+                    }
+                },
+                "tambons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tambon"
+                    }
+                }
+            }
+        },
+        "models.ThaiAdminUploadResult": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "false for dry runs and invalid data",
+                    "type": "boolean"
+                },
+                "diff": {
+                    "$ref": "#/definitions/models.ThaiAdminDiff"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "persisted": {
+                    "type": "boolean"
+                },
+                "up_to_date": {
+                    "description": "a refresh found nothing to change",
+                    "type": "boolean"
+                },
+                "version": {
+                    "description": "dataset version after the upload",
+                    "type": "string"
+                },
+                "warnings": {
+                    "description": "problems the current data already had",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "access token lifetime in seconds",
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "models.TransactionRequest": {
+            "type": "object",
+            "required": [
+                "statements"
+            ],
+            "properties": {
+                "statements": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.TransactionStatementRequest"
+                    }
+                }
+            }
+        },
+        "models.TransactionResponse": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean"
+                },
+                "duration_ms": {
+                    "type": "number"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed_statement": {
+                    "description": "index of the statement that failed",
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TransactionStatementResult"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.TransactionStatementRequest": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "params": {
+                    "description": "values bound to $1, $2, ... placeholders",
+                    "type": "array",
+                    "items": {}
+                },
+                "query": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TransactionStatementResult": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "number"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "description": "result of SELECT and ... RETURNING statements",
+                    "type": "array",
+                    "items": {}
+                },
+                "rows_affected": {
+                    "description": "rows changed, or returned by a query",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TrendingProduct": {
+            "type": "object",
+            "properties": {
+                "ic_code": {
+                    "type": "string"
+                },
+                "last_viewed": {
+                    "type": "string"
+                },
+                "product": {},
+                "unique_clients": {
+                    "type": "integer"
+                },
+                "views": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UndoOperation": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "restored_at": {
+                    "type": "string"
+                },
+                "restored_by": {
+                    "type": "string"
+                },
+                "restored_rows": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "description": "rows kept",
+                    "type": "integer"
+                },
+                "snapshot": {
+                    "description": "the rows as they were, on GET /v1/admin/undo/{id}",
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "sql": {
+                    "type": "string"
+                },
+                "statement": {
+                    "description": "UPDATE or DELETE",
+                    "type": "string"
+                },
+                "table": {
+                    "description": "schema.table",
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UndoPage": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UndoOperation"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WarehouseBalance": {
+            "type": "object",
+            "properties": {
+                "balance_qty": {
+                    "type": "number"
+                },
+                "wh_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Webhook": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.WebhookRequest": {
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ZipCodeRequest": {
+            "type": "object",
+            "required": [
+                "zip_code"
+            ],
+            "properties": {
+                "zip_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.BarcodeScanResult": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string"
+                },
+                "format": {
+                    "description": "EAN_13, EAN_8, UPC_A, UPC_E, CODE_128 or QR_CODE",
+                    "type": "string"
+                },
+                "products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.SearchResult"
+                    }
+                }
+            }
+        },
+        "services.FacetBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.HybridSearchResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.HybridSearchResult"
+                    }
+                },
+                "duration_ms": {
+                    "type": "number"
+                },
+                "k": {
+                    "type": "integer"
+                },
+                "partial": {
+                    "description": "a requested source failed",
+                    "type": "boolean"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.HybridSourceStatus"
+                    }
+                },
+                "suggested_query": {
+                    "description": "corrected query offered when nothing matched",
+                    "type": "string"
+                }
+            }
+        },
+        "services.HybridSearchResult": {
+            "type": "object",
+            "properties": {
+                "balance_qty": {
+                    "type": "number"
+                },
+                "barcode": {
+                    "description": "Individual barcode from Weaviate",
+                    "type": "string"
+                },
+                "barcodes": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "discount_percent": {
+                    "type": "number"
+                },
+                "discount_price": {
+                    "type": "number"
+                },
+                "final_price": {
+                    "type": "number"
+                },
+                "group_key": {
+                    "description": "Variant grouping fields (only populated when group_by is requested)",
+                    "type": "string"
+                },
+                "hybrid_score": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "img_url": {
+                    "type": "string"
+                },
+                "multi_packing": {
+                    "type": "integer"
+                },
+                "multi_packing_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "premium_word": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "qty_available": {
+                    "type": "number"
+                },
+                "sale_price": {
+                    "description": "New pricing and inventory fields",
+                    "type": "number"
+                },
+                "search_priority": {
+                    "type": "integer"
+                },
+                "similarity_score": {
+                    "type": "number"
+                },
+                "sold_qty": {
+                    "type": "number"
+                },
+                "sources": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/services.HybridSourceScore"
+                    }
+                },
+                "supplier_code": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "variant_count": {
+                    "type": "integer"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.SearchResult"
+                    }
+                }
+            }
+        },
+        "services.HybridSourceScore": {
+            "type": "object",
+            "properties": {
+                "rank": {
+                    "description": "1-based",
+                    "type": "integer"
+                },
+                "rrf": {
+                    "description": "weight / (k + rank)",
+                    "type": "number"
+                },
+                "score": {
+                    "description": "the source's own score",
+                    "type": "number"
+                }
+            }
+        },
+        "services.HybridSourceStatus": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "number"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "services.IndexFreshness": {
+            "type": "object",
+            "properties": {
+                "age_seconds": {
+                    "description": "AgeSeconds is the time since LastSync",
+                    "type": "number"
+                },
+                "available": {
+                    "type": "boolean"
+                },
+                "documents": {
+                    "description": "TF-IDF documents",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "string"
+                },
+                "lag_seconds": {
+                    "description": "how long the oldest pending change has waited",
+                    "type": "number"
+                },
+                "last_mode": {
+                    "type": "string"
+                },
+                "last_sync": {
+                    "description": "last successful sync, or when the TF-IDF index was built",
+                    "type": "string"
+                },
+                "oldest_pending": {
+                    "description": "OldestPending is the change time of the oldest pending row, known with weaviate.sync.updated_at_column",
+                    "type": "string"
+                },
+                "pending_changes": {
+                    "description": "PendingChanges counts ic_inventory rows changed since the last sync (Weaviate only)",
+                    "type": "integer"
+                },
+                "reasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "stale": {
+                    "type": "boolean"
+                },
+                "watermark": {
+                    "$ref": "#/definitions/services.WeaviateSyncWatermark"
+                }
+            }
+        },
+        "services.IndexFreshnessReport": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "indexes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.IndexFreshness"
+                    }
+                },
+                "stale": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "services.PrioritySearch": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "false on later pages",
+                    "type": "boolean"
+                },
+                "count": {
+                    "description": "rows of the page found by the steps",
+                    "type": "integer"
+                },
+                "filled": {
+                    "description": "the steps filled the page, so no ranked search ran",
+                    "type": "boolean"
+                },
+                "steps": {
+                    "description": "steps that ran, in order: barcode, code, like",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "services.SearchExplainStage": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "matched": {
+                    "description": "the product is among the stage's results",
+                    "type": "boolean"
+                },
+                "rank": {
+                    "description": "1-based position among distinct products",
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "runs": {
+                    "description": "whether /v1/search-by-vector runs this stage for the query",
+                    "type": "boolean"
+                },
+                "score": {
+                    "description": "the stage's own score",
+                    "type": "number"
+                },
+                "stage": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "products the stage returned",
+                    "type": "integer"
+                }
+            }
+        },
+        "services.SearchExplainTokens": {
+            "type": "object",
+            "properties": {
+                "product": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "query": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "shared": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "services.SearchExplanation": {
+            "type": "object",
+            "properties": {
+                "barcodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code": {
+                    "type": "string"
+                },
+                "depth": {
+                    "description": "results inspected per stage",
+                    "type": "integer"
+                },
+                "exists": {
+                    "description": "the code is in ic_inventory",
+                    "type": "boolean"
+                },
+                "indexed_barcodes": {
+                    "description": "barcodes of the product's Weaviate objects",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "stages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.SearchExplainStage"
+                    }
+                },
+                "tokens": {
+                    "$ref": "#/definitions/services.SearchExplainTokens"
+                },
+                "verdict": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.SearchResult": {
+            "type": "object",
+            "properties": {
+                "balance_qty": {
+                    "type": "number"
+                },
+                "barcode": {
+                    "description": "Individual barcode from Weaviate",
+                    "type": "string"
+                },
+                "barcodes": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "discount_percent": {
+                    "type": "number"
+                },
+                "discount_price": {
+                    "type": "number"
+                },
+                "final_price": {
+                    "type": "number"
+                },
+                "group_key": {
+                    "description": "Variant grouping fields (only populated when group_by is requested)",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "img_url": {
+                    "type": "string"
+                },
+                "multi_packing": {
+                    "type": "integer"
+                },
+                "multi_packing_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "premium_word": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "qty_available": {
+                    "type": "number"
+                },
+                "sale_price": {
+                    "description": "New pricing and inventory fields",
+                    "type": "number"
+                },
+                "search_priority": {
+                    "type": "integer"
+                },
+                "similarity_score": {
+                    "type": "number"
+                },
+                "sold_qty": {
+                    "type": "number"
+                },
+                "supplier_code": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "variant_count": {
+                    "type": "integer"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.SearchResult"
+                    }
+                }
+            }
+        },
+        "services.SearchStepFailure": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "step": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.TableSyncColumn": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "source_type": {
+                    "type": "string"
+                },
+                "target_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.TableSyncOptions": {
+            "type": "object",
+            "properties": {
+                "batch_size": {
+                    "description": "rows written per insert",
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "column whose values only grow; required by incremental",
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "full (default), append or incremental",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "postgresql or clickhouse",
+                    "type": "string"
+                },
+                "table": {
+                    "description": "source table, optionally schema- or database-qualified",
+                    "type": "string"
+                },
+                "target": {
+                    "description": "the other database",
+                    "type": "string"
+                },
+                "target_table": {
+                    "description": "default: the source table name",
+                    "type": "string"
+                }
+            }
+        },
+        "services.TableSyncStatus": {
+            "type": "object",
+            "properties": {
+                "batches": {
+                    "type": "integer"
+                },
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.TableSyncColumn"
+                    }
+                },
+                "created": {
+                    "description": "the target table did not exist and was created",
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "options": {
+                    "$ref": "#/definitions/services.TableSyncOptions"
+                },
+                "phase": {
+                    "type": "string"
+                },
+                "rows_copied": {
+                    "type": "integer"
+                },
+                "rows_per_second": {
+                    "type": "number"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "since": {
+                    "description": "incremental: largest key found in the target"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "total_rows": {
+                    "description": "rows to copy, counted before copying",
+                    "type": "integer"
+                }
+            }
+        },
+        "services.VectorSearchResponse": {
+            "type": "object",
+            "properties": {
+                "count_strategy": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.SearchResult"
+                    }
+                },
+                "duration_ms": {
+                    "type": "number"
+                },
+                "estimated_total": {
+                    "type": "integer"
+                },
+                "exact_count": {
+                    "description": "Pagination counts, see SearchCounts",
+                    "type": "integer"
+                },
+                "expanded_query": {
+                    "description": "The query sent to Weaviate when ai_enhance or expand_synonyms added terms to it",
+                    "type": "string"
+                },
+                "facets": {
+                    "description": "Counts per facet value over all matching products, when facets were requested",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/services.FacetBucket"
+                        }
+                    }
+                },
+                "failed_steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.SearchStepFailure"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "description": "pass as \"cursor\" to fetch the next page",
+                    "type": "string"
+                },
+                "partial": {
+                    "description": "Set when a priority search step failed and was skipped (exact matches may be missing) or\nwhen optional stages were left out to stay within X-Latency-Budget-Ms",
+                    "type": "boolean"
+                },
+                "priority": {
+                    "$ref": "#/definitions/services.PrioritySearch"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "search_method": {
+                    "description": "How /v1/search-by-vector found the page: priority, vector or text (see SearchMethodPriority),\nand what its priority steps contributed",
+                    "type": "string"
+                },
+                "skipped_stages": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "suggested_query": {
+                    "description": "A corrected query offered when nothing matched, e.g. \"coca cola\" for \"coca colla\"",
+                    "type": "string"
+                },
+                "total_count": {
+                    "description": "same as exact_count, kept for existing clients",
+                    "type": "integer"
+                }
+            }
+        },
+        "services.WeaviateSyncOptions": {
+            "type": "object",
+            "properties": {
+                "batch_size": {
+                    "description": "products read and objects written per batch",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "description": "count what would change without writing to Weaviate",
+                    "type": "boolean"
+                },
+                "mode": {
+                    "description": "SyncModeFull (default) or SyncModeIncremental",
+                    "type": "string"
+                }
+            }
+        },
+        "services.WeaviateSyncStatus": {
+            "type": "object",
+            "properties": {
+                "batches": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "objects_deleted": {
+                    "description": "in a dry run: stale objects that would be deleted",
+                    "type": "integer"
+                },
+                "objects_failed": {
+                    "type": "integer"
+                },
+                "objects_scanned": {
+                    "description": "existing Weaviate objects checked for deletion",
+                    "type": "integer"
+                },
+                "objects_upserted": {
+                    "description": "in a dry run: objects that would be written",
+                    "type": "integer"
+                },
+                "options": {
+                    "$ref": "#/definitions/services.WeaviateSyncOptions"
+                },
+                "phase": {
+                    "type": "string"
+                },
+                "products_read": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "since": {
+                    "description": "starting point of an incremental sync",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/services.WeaviateSyncWatermark"
+                        }
+                    ]
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "watermark": {
+                    "description": "recorded when the run succeeded",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/services.WeaviateSyncWatermark"
+                        }
+                    ]
+                }
+            }
+        },
+        "services.WeaviateSyncWatermark": {
+            "type": "object",
+            "properties": {
+                "row_order_ref": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
+    }
+}`
+
+// SwaggerInfo holds exported Swagger Info so clients can modify it
+var SwaggerInfo = &swag.Spec{
+	Version:          "1.0.0",
+	Host:             "",
+	BasePath:         "/v1",
+	Schemes:          []string{},
+	Title:            "SMLGOAPI",
+	Description:      "Product search, SQL and Thai administrative data over ClickHouse and PostgreSQL.\nEndpoints behind authentication take \"Authorization: Bearer <access token or API key>\".",
+	InfoInstanceName: "swagger",
+	SwaggerTemplate:  docTemplate,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
+}
@@ -1,140 +1,523 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"smlgoapi/config"
+	"smlgoapi/jobs"
+	"smlgoapi/metrics"
+	"smlgoapi/middleware"
 	"smlgoapi/models"
 	"smlgoapi/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
 )
 
-// COMMENTED OUT FOR SPEED TESTING - DeepSeek API structures
-/*
-// DeepSeek API structures
-type DeepSeekRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-}
-
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type DeepSeekResponse struct {
-	Choices []Choice `json:"choices"`
-}
-
-type Choice struct {
-	Message Message `json:"message"`
-}
-
-const (
-	DeepSeekAPIURL = "https://api.deepseek.com/v1/chat/completions"
-	DeepSeekAPIKey = "sk-f57e7b56ab3f4f1a8030b7ae57500b85"
-	DeepSeekModel  = "deepseek-chat"
-)
-*/
-
 type APIHandler struct {
+	config            *config.Config
 	clickHouseService *services.ClickHouseService
 	postgreSQLService *services.PostgreSQLService
 	vectorDB          *services.TFIDFVectorDatabase
 	thaiAdminService  *services.ThaiAdminService
 	weaviateService   *services.WeaviateService
+
+	productEventService *services.ProductEventService
+	searchAnalytics     *services.SearchAnalyticsService // nil without ClickHouse or when disabled
+	apiKeyService       *services.APIKeyService
+	sessionService      *services.SessionService
+	rateLimiter         *services.RateLimiter
+	sqlPolicyService    *services.SQLPolicyService
+	weaviateSyncService *services.WeaviateSyncService
+	tableSyncService    *services.TableSyncService // nil unless both databases are connected
+	dataService         *services.DataService      // nil without PostgreSQL
+	auditService        *services.AuditService     // nil without PostgreSQL or when audit.disabled is set
+	undoService         *services.UndoService      // nil without PostgreSQL or undo.tables
+	graphQLSchema       graphql.Schema
+	jobScheduler        *jobs.Scheduler
+	shadowMirror        *services.ShadowMirror // nil unless search.shadow is enabled
+	spelling            *services.SpellingIndex
+	synonyms            *services.SynonymService
+	queryEnhancer       *services.QueryEnhancer     // nil unless search.ai_enhance.provider is set
+	shareService        *services.ShareService      // nil without PostgreSQL
+	namedQueryService   *services.NamedQueryService // nil without PostgreSQL
+	productImageService *services.ProductImageService
+	exportJobService    *services.ExportJobService // nil when export.dir cannot be created
+	importProgress      *services.ImportProgress
+	inventoryEvents     *services.InventoryEventService // nil unless inventory_events.enabled
+	webhookService      *services.WebhookService        // nil without PostgreSQL or when webhooks.disabled is set
+	selectCache         *services.SelectCache
+	runningQueries      *services.RunningQueryRegistry
+	operations          *services.OperationTracker // what a shutdown drains
+	redis               *services.RedisStore       // nil without redis.url
+	serviceRegistry     *services.ServiceRegistry
+	metrics             *metrics.Registry
+	searchMetrics       searchMetrics
 }
 
-func NewAPIHandler(clickHouseService *services.ClickHouseService, postgreSQLService *services.PostgreSQLService) *APIHandler {
+func NewAPIHandler(cfg *config.Config, clickHouseService *services.ClickHouseService, postgreSQLService *services.PostgreSQLService) *APIHandler {
 	var vectorDB *services.TFIDFVectorDatabase
 	if clickHouseService != nil {
 		vectorDB = services.NewTFIDFVectorDatabase(clickHouseService)
 	}
-	thaiAdminService := services.NewThaiAdminService()
+	thaiAdminService := services.NewThaiAdminService(cfg.ThaiAdmin)
+
+	// Services missing from the start stay marked down; the health checks update the rest
+	serviceRegistry := services.NewServiceRegistry()
+	if postgreSQLService == nil {
+		serviceRegistry.MarkDown(services.ServicePostgreSQL, "not connected at startup")
+	}
+	if clickHouseService == nil {
+		serviceRegistry.MarkDown(services.ServiceClickHouse, "not connected at startup")
+	}
 
 	// Initialize Weaviate service with config
 	var weaviateService *services.WeaviateService
-	cfg := config.LoadConfig()
 	ws, err := services.NewWeaviateService(cfg)
 	if err != nil {
 		log.Printf("⚠️ Failed to initialize Weaviate service: %v", err)
 		weaviateService = nil
+		serviceRegistry.MarkDown(services.ServiceWeaviate, err.Error())
 	} else {
 		weaviateService = ws
 	}
 
-	return &APIHandler{
-		clickHouseService: clickHouseService,
-		postgreSQLService: postgreSQLService,
-		vectorDB:          vectorDB,
-		thaiAdminService:  thaiAdminService,
-		weaviateService:   weaviateService,
+	// Product view events live in ClickHouse (analytics store)
+	var productEventService *services.ProductEventService
+	if clickHouseService != nil {
+		productEventService = services.NewProductEventService(clickHouseService)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := productEventService.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare product events table: %v", err)
+		}
+		cancel()
+	}
+
+	// Every search is logged to ClickHouse too, unless search.analytics.disabled is set
+	var searchAnalytics *services.SearchAnalyticsService
+	if clickHouseService != nil && !cfg.Search.Analytics.Disabled {
+		searchAnalytics = services.NewSearchAnalyticsService(clickHouseService, cfg.Search.Analytics)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := searchAnalytics.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare search events table: %v", err)
+		}
+		cancel()
+		searchAnalytics.Start()
+	}
+
+	// API keys live in PostgreSQL; the table is only created when auth is enabled
+	apiKeyService := services.NewAPIKeyService(postgreSQLService, time.Duration(cfg.Auth.CacheTTLSeconds)*time.Second)
+	if cfg.Auth.Enabled {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := apiKeyService.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare api_keys table: %v", err)
+		}
+		cancel()
+	}
+
+	// User sessions are opt-in; a misconfigured secret must not silently leave endpoints open
+	var sessionService *services.SessionService
+	if cfg.JWT.Enabled {
+		userService := services.NewUserService(postgreSQLService)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := userService.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare api_users table: %v", err)
+		}
+		cancel()
+
+		sessionService, err = services.NewSessionService(cfg.JWT, userService)
+		if err != nil {
+			log.Fatalf("❌ JWT sessions enabled but misconfigured: %v", err)
+		}
+	}
+
+	// Populating the vector index needs both the source (PostgreSQL) and the index (Weaviate)
+	var weaviateSyncService *services.WeaviateSyncService
+	if weaviateService != nil && postgreSQLService != nil {
+		weaviateSyncService = services.NewWeaviateSyncService(weaviateService, postgreSQLService, cfg.Weaviate.Sync)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := weaviateSyncService.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare sync_state table: %v", err)
+		}
+		cancel()
+	}
+
+	// Shared result links live in PostgreSQL so every instance can open them
+	var shareService *services.ShareService
+	if postgreSQLService != nil {
+		shareService = services.NewShareService(postgreSQLService, cfg.Search.Share)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := shareService.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare shared_results table: %v", err)
+		}
+		cancel()
+	}
+
+	// Named query templates live in PostgreSQL next to the API keys that call them
+	var namedQueryService *services.NamedQueryService
+	if postgreSQLService != nil {
+		namedQueryService = services.NewNamedQueryService(postgreSQLService)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := namedQueryService.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare named_queries table: %v", err)
+		}
+		cancel()
+	}
+
+	var productImageService *services.ProductImageService
+	if postgreSQLService != nil {
+		productImageService = services.NewProductImageService(postgreSQLService)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := productImageService.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare product_images table: %v", err)
+		}
+		cancel()
+	}
+
+	// The data API serves the tables listed in data_api.tables
+	var dataService *services.DataService
+	if postgreSQLService != nil {
+		dataService = services.NewDataService(postgreSQLService, cfg.DataAPI)
+	}
+
+	// Write requests are audited in PostgreSQL unless audit.disabled is set
+	var auditService *services.AuditService
+	if postgreSQLService != nil && !cfg.Audit.Disabled {
+		auditService = services.NewAuditService(postgreSQLService, cfg.Audit)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := auditService.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare audit_log table: %v", err)
+		}
+		cancel()
+	}
+
+	// UPDATE and DELETE on the tables of undo.tables keep the previous rows
+	var undoService *services.UndoService
+	if postgreSQLService != nil && len(cfg.Undo.Tables) > 0 {
+		undoService = services.NewUndoService(postgreSQLService, cfg.Undo)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := undoService.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare undo tables: %v", err)
+		}
+		cancel()
+	}
+
+	// Stock and price changes are streamed on /v1/events when inventory_events.enabled is set
+	var inventoryEvents *services.InventoryEventService
+	if postgreSQLService != nil && cfg.InventoryEvents.Enabled {
+		inventoryEvents = services.NewInventoryEventService(postgreSQLService, cfg.InventoryEvents)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := inventoryEvents.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare inventory triggers: %v", err)
+		}
+		cancel()
+		inventoryEvents.Start()
+	}
+
+	// Registered webhooks are called back on product, stock and import events
+	var webhookService *services.WebhookService
+	if postgreSQLService != nil && !cfg.Webhooks.Disabled {
+		webhookService = services.NewWebhookService(postgreSQLService, cfg.Webhooks)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := webhookService.EnsureSchema(ctx); err != nil {
+			log.Printf("⚠️ Failed to prepare webhook tables: %v", err)
+		}
+		cancel()
+		webhookService.Start()
+	}
+
+	// Background exports are written to local disk
+	exportJobService, err := services.NewExportJobService(cfg.Export)
+	if err != nil {
+		log.Printf("⚠️ Background exports disabled: %v", err)
+	}
+
+	// The synonym dictionary file, when configured, is followed like the SQL policy
+	synonyms := services.NewSynonymService(cfg.Search.Synonyms)
+	go synonyms.Watch(context.Background(), time.Duration(cfg.Search.Synonyms.ReloadIntervalSeconds)*time.Second)
+
+	queryEnhancer, err := services.NewQueryEnhancer(cfg.Search.AIEnhance)
+	if err != nil {
+		log.Printf("⚠️ Query enhancement disabled: %v", err)
+	}
+
+	// Without Redis every instance keeps its own SELECT cache and rate limits
+	redisStore, err := services.NewRedisStore(cfg.Redis)
+	if err != nil {
+		log.Printf("⚠️ Redis disabled: %v", err)
+	}
+
+	// The SQL policy follows smlgoapi.json for the lifetime of the process
+	sqlPolicyService := services.NewSQLPolicyService(cfg.SQLPolicy)
+	go sqlPolicyService.Watch(context.Background())
+
+	h := &APIHandler{
+		config:              cfg,
+		clickHouseService:   clickHouseService,
+		postgreSQLService:   postgreSQLService,
+		vectorDB:            vectorDB,
+		thaiAdminService:    thaiAdminService,
+		weaviateService:     weaviateService,
+		productEventService: productEventService,
+		searchAnalytics:     searchAnalytics,
+		apiKeyService:       apiKeyService,
+		sessionService:      sessionService,
+		rateLimiter:         services.NewRateLimiter(cfg.RateLimit, redisStore),
+		sqlPolicyService:    sqlPolicyService,
+		weaviateSyncService: weaviateSyncService,
+		shadowMirror:        services.NewShadowMirror(cfg.Search.Shadow),
+		spelling:            services.NewSpellingIndex(postgreSQLService),
+		shareService:        shareService,
+		namedQueryService:   namedQueryService,
+		productImageService: productImageService,
+		exportJobService:    exportJobService,
+		importProgress:      &services.ImportProgress{},
+		inventoryEvents:     inventoryEvents,
+		webhookService:      webhookService,
+		dataService:         dataService,
+		auditService:        auditService,
+		undoService:         undoService,
+		selectCache:         services.NewSelectCache(cfg.SelectCache, redisStore),
+		runningQueries:      services.NewRunningQueryRegistry(),
+		operations:          services.NewOperationTracker(),
+		redis:               redisStore,
+		synonyms:            synonyms,
+		queryEnhancer:       queryEnhancer,
+		serviceRegistry:     serviceRegistry,
+		metrics:             metrics.NewRegistry(),
+	}
+	h.registerMetrics()
+	if inventoryEvents != nil && webhookService != nil {
+		inventoryEvents.OnEvent(h.inventoryWebhooks)
+	}
+
+	schema, err := h.newGraphQLSchema()
+	if err != nil {
+		log.Fatalf("❌ Invalid GraphQL schema: %v", err)
+	}
+	h.graphQLSchema = schema
+
+	// Copying tables between the databases needs both of them
+	if postgreSQLService != nil && clickHouseService != nil {
+		h.tableSyncService = services.NewTableSyncService(postgreSQLService, clickHouseService, h.selectCache)
+	}
+
+	// Maintenance jobs need the handler for the health checks, so they are registered last
+	h.jobScheduler = h.newJobScheduler()
+	h.jobScheduler.Start(h.operations.Context())
+	return h
+}
+
+// APIKeyService returns the API key service used by the authentication middleware
+func (h *APIHandler) APIKeyService() *services.APIKeyService {
+	return h.apiKeyService
+}
+
+// SessionService returns the JWT session service, or nil when sessions are disabled
+func (h *APIHandler) SessionService() *services.SessionService {
+	return h.sessionService
+}
+
+// RateLimiter returns the rate limiter shared by the middleware and the health report
+func (h *APIHandler) RateLimiter() *services.RateLimiter {
+	return h.rateLimiter
+}
+
+// AuditService returns the audit log written by the audit middleware, or nil when it is disabled
+func (h *APIHandler) AuditService() *services.AuditService {
+	return h.auditService
+}
+
+// ServiceRegistry returns the registry of unavailable services read by the degraded-services middleware
+func (h *APIHandler) ServiceRegistry() *services.ServiceRegistry {
+	return h.serviceRegistry
+}
+
+// Operations returns the tracker of long operations; its context is the base of every request,
+// so the server can drain and then cancel them on shutdown
+func (h *APIHandler) Operations() *services.OperationTracker {
+	return h.operations
+}
+
+// CloseEventStreams ends the open /v1/events streams, which would otherwise hold up the shutdown
+// of the HTTP server; register it with RegisterOnShutdown
+func (h *APIHandler) CloseEventStreams() {
+	h.inventoryEvents.Close()
+}
+
+// Close writes the search events and webhook events still queued; call it after the HTTP server
+// has stopped
+func (h *APIHandler) Close(ctx context.Context) {
+	h.searchAnalytics.Close(ctx)
+	h.webhookService.Close(ctx)
+	if h.exportJobService != nil {
+		h.exportJobService.Close(ctx)
+	}
+	if h.redis != nil {
+		h.redis.Close()
 	}
 }
 
 // HealthCheck godoc
 // @Summary Health check endpoint
-// @Description Get the health status of the API and database
+// @Description Get the health status of the API and its dependencies with latency measurements.
+// @Description The service is "degraded" (HTTP 200) when optional dependencies fail or exceed their latency budget,
+// @Description and "unhealthy" (HTTP 503) only when PostgreSQL is down.
 // @Tags health
 // @Produce json
 // @Success 200 {object} models.HealthResponse
+// @Failure 503 {object} models.HealthResponse
 // @Router /health [get]
 func (h *APIHandler) HealthCheck(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	var version string
-	var err error
+	var chVersion, pgVersion string
+	dependencies := h.checkDependencies(ctx, &chVersion, &pgVersion)
+	status := services.OverallHealthStatus(dependencies)
 
-	if h.clickHouseService != nil {
-		version, err = h.clickHouseService.GetVersion(ctx)
-		if err != nil {
-			c.JSON(http.StatusServiceUnavailable, models.APIResponse{
-				Success: false,
-				Error:   "ClickHouse connection failed: " + err.Error(),
-			})
-			return
-		}
-	} else {
-		version = "ClickHouse unavailable"
+	if chVersion == "" {
+		chVersion = "ClickHouse unavailable"
 	}
-
-	// Test PostgreSQL connection
-	var pgVersion string
-	if h.postgreSQLService != nil {
-		pgVersion, err = h.postgreSQLService.GetVersion(ctx)
-		if err != nil {
-			c.JSON(http.StatusServiceUnavailable, models.APIResponse{
-				Success: false,
-				Error:   "PostgreSQL connection failed: " + err.Error(),
-			})
-			return
-		}
-	} else {
+	if pgVersion == "" {
 		pgVersion = "PostgreSQL unavailable"
 	}
 
+	database := "connected"
+	if status == models.HealthStatusUnhealthy {
+		database = "disconnected"
+	}
+
 	response := models.HealthResponse{
-		Status:    "healthy",
-		Timestamp: time.Now(),
-		Version:   fmt.Sprintf("ClickHouse: %s, PostgreSQL: %s", version, pgVersion),
-		Database:  "connected",
+		Status:       status,
+		Timestamp:    time.Now(),
+		Version:      fmt.Sprintf("ClickHouse: %s, PostgreSQL: %s", chVersion, pgVersion),
+		Database:     database,
+		Dependencies: dependencies,
+		RateLimits:   h.rateLimiter.Info(),
+	}
+	if info, err := h.thaiAdminService.DatasetInfo(); err == nil {
+		response.ThaiAdmin = info
 	}
 
-	c.JSON(http.StatusOK, response)
+	httpStatus := http.StatusOK
+	if status == models.HealthStatusUnhealthy {
+		httpStatus = http.StatusServiceUnavailable
+	}
+	c.JSON(httpStatus, response)
+}
+
+// checkDependencies runs the health checks and records unavailable services in the service registry
+func (h *APIHandler) checkDependencies(ctx context.Context, chVersion, pgVersion *string) []models.DependencyHealth {
+	dependencies := services.RunHealthChecks(ctx, h.healthChecks(chVersion, pgVersion), 3*time.Second)
+	h.serviceRegistry.Update(dependencies)
+	return dependencies
+}
+
+// healthChecks builds the dependency probes; database versions are captured into the given pointers
+func (h *APIHandler) healthChecks(chVersion, pgVersion *string) []services.DependencyCheck {
+	cfg := h.config.Health
+
+	checks := []services.DependencyCheck{
+		{
+			Name:     services.ServicePostgreSQL,
+			Critical: true,
+			BudgetMs: cfg.PostgreSQLBudgetMs,
+			Check: func(ctx context.Context) (string, error) {
+				if h.postgreSQLService == nil {
+					return "", fmt.Errorf("PostgreSQL service not initialized")
+				}
+				version, err := h.postgreSQLService.GetVersion(ctx)
+				if err != nil {
+					return "", fmt.Errorf("PostgreSQL connection failed: %w", err)
+				}
+				*pgVersion = version
+				return version, nil
+			},
+		},
+		{
+			Name:     services.ServiceClickHouse,
+			BudgetMs: cfg.ClickHouseBudgetMs,
+			Check: func(ctx context.Context) (string, error) {
+				if h.clickHouseService == nil {
+					return "", fmt.Errorf("ClickHouse unavailable (running in PostgreSQL-only mode)")
+				}
+				version, err := h.clickHouseService.GetVersion(ctx)
+				if err != nil {
+					return "", fmt.Errorf("ClickHouse connection failed: %w", err)
+				}
+				*chVersion = version
+				return version, nil
+			},
+		},
+		{
+			Name:     services.ServiceWeaviate,
+			BudgetMs: cfg.WeaviateBudgetMs,
+			Check: func(ctx context.Context) (string, error) {
+				if h.weaviateService == nil {
+					return "", fmt.Errorf("Weaviate unavailable (search falls back to PostgreSQL)")
+				}
+				if err := h.weaviateService.Ping(ctx); err != nil {
+					return "", fmt.Errorf("Weaviate ping failed: %w", err)
+				}
+				return "ready", nil
+			},
+		},
+		{
+			Name: services.ServiceThaiAdmin,
+			Check: func(ctx context.Context) (string, error) {
+				return h.thaiAdminService.Check()
+			},
+		},
+		{
+			Name: services.ServiceImageCache,
+			Check: func(ctx context.Context) (string, error) {
+				path := cfg.CacheDir
+				if _, err := os.Stat(path); err != nil {
+					path = filepath.Dir(filepath.Clean(path)) // not created yet, measure the parent volume
+				}
+				freeBytes, err := services.DiskFreeBytes(path)
+				if err != nil {
+					return "", fmt.Errorf("failed to read free space for %s: %w", cfg.CacheDir, err)
+				}
+				freeMB := freeBytes / (1024 * 1024)
+				details := fmt.Sprintf("%s: %d MB free (minimum %d MB)", cfg.CacheDir, freeMB, cfg.MinCacheFreeMB)
+				if freeMB < uint64(cfg.MinCacheFreeMB) {
+					return details, fmt.Errorf("low disk space for image cache")
+				}
+				return details, nil
+			},
+		},
+	}
+	if h.redis != nil {
+		checks = append(checks, services.DependencyCheck{
+			Name: services.ServiceRedis,
+			Check: func(ctx context.Context) (string, error) {
+				if err := h.redis.Ping(ctx); err != nil {
+					return "", fmt.Errorf("Redis ping failed (cache and rate limits fall back to memory): %w", err)
+				}
+				return "ready", nil
+			},
+		})
+	}
+	return checks
 }
 
 // GetTables godoc
 // @Summary Get all database tables
-// @Description Retrieve a list of all tables in the database
+// @Description Retrieve a list of all tables in the ClickHouse database. GET /schema/{db}/tables also lists PostgreSQL tables with row estimates, and GET /schema/{db}/tables/{name} describes one.
 // @Tags database
 // @Produce json
 // @Success 200 {object} models.APIResponse{data=[]models.Table}
-// @Router /api/tables [get]
+// @Router /tables [get]
 func (h *APIHandler) GetTables(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -156,12 +539,15 @@ func (h *APIHandler) GetTables(c *gin.Context) {
 
 // CommandEndpoint godoc
 // @Summary Execute database command
-// @Description Execute any SQL command (INSERT, UPDATE, DELETE, CREATE, etc.) via JSON request
+// @Description Execute any SQL command (INSERT, UPDATE, DELETE, CREATE, etc.) via JSON request. The SQL may be sent base64 encoded as query_base64 instead of query; the response echoes the decoded SQL. timeout_ms bounds how long the statement runs (sql_limits); a statement that runs longer, or whose client disconnects, is cancelled on the database and answers 408.
 // @Tags database
 // @Accept json
 // @Produce json
 // @Param command body models.CommandRequest true "Command to execute"
 // @Success 200 {object} models.CommandResponse
+// @Failure 408 {object} models.CommandResponse
+// @Failure 409 {object} models.CommandResponse
+// @Failure 503 {object} models.CommandResponse
 // @Router /command [post]
 func (h *APIHandler) CommandEndpoint(c *gin.Context) {
 	startTime := time.Now()
@@ -176,8 +562,40 @@ func (h *APIHandler) CommandEndpoint(c *gin.Context) {
 		})
 		return
 	}
+	query, err := decodeSQL(commandReq.Query, commandReq.QueryBase64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.CommandResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	commandReq.Query, commandReq.QueryBase64 = query, ""
+
+	middleware.AuditSQL(c, commandReq.Query)
 
-	log.Printf("💻 [command] Executing command: %s", commandReq.Query)
+	if err := h.sqlPolicyService.Check(services.ServiceClickHouse, commandReq.Query, h.config.ClickHouse.Database); err != nil {
+		log.Printf("🛡️ [command] Rejected by SQL policy: %v", err)
+		c.JSON(http.StatusForbidden, models.CommandResponse{
+			Success: false,
+			Error:   err.Error(),
+			Command: commandReq.Query,
+		})
+		return
+	}
+
+	timeout, cancel, err := h.withQueryTimeout(c, services.ServiceClickHouse, commandReq.Query, commandReq.TimeoutMs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.CommandResponse{
+			Success: false,
+			Error:   err.Error(),
+			Command: commandReq.Query,
+		})
+		return
+	}
+	defer cancel()
+
+	log.Printf("💻 [command] Executing command: %s (timeout %s)", commandReq.Query, timeout)
 
 	ctx := c.Request.Context()
 
@@ -187,6 +605,15 @@ func (h *APIHandler) CommandEndpoint(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("❌ [command] Execution failed: %v", err)
+		if status, message, ok := queryInterrupted(c, "command", timeout); ok {
+			c.JSON(status, models.CommandResponse{
+				Success:  false,
+				Error:    message,
+				Command:  commandReq.Query,
+				Duration: duration,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.CommandResponse{
 			Success:  false,
 			Error:    fmt.Sprintf("Command execution failed: %s", err.Error()),
@@ -197,6 +624,8 @@ func (h *APIHandler) CommandEndpoint(c *gin.Context) {
 	}
 
 	log.Printf("✅ [command] Execution successful in %.2fms", duration)
+	auditCommandRows(c, result)
+	h.invalidateSelectCache("command", services.ServiceClickHouse, commandReq.Query)
 
 	c.JSON(http.StatusOK, models.CommandResponse{
 		Success:  true,
@@ -209,12 +638,17 @@ func (h *APIHandler) CommandEndpoint(c *gin.Context) {
 
 // SelectEndpoint godoc
 // @Summary Execute SELECT query
-// @Description Execute SELECT query and return data via JSON request
+// @Description Execute SELECT query and return data via JSON request. The SQL may be sent base64 encoded as query_base64 instead of query; the response echoes the decoded SQL. timeout_ms bounds how long the statement runs (sql_limits); a statement that runs longer, or whose client disconnects, is cancelled on the database and answers 408. With explain the plan is returned in plan instead of rows. Results stop at max_rows or sql_limits.max_response_bytes with truncated set and a next_cursor to the rest.
 // @Tags database
 // @Accept json
 // @Produce json
 // @Param select body models.SelectRequest true "SELECT query to execute"
+// @Param format query string false "Output format: json (default), ndjson (streamed, one row per line), csv or xlsx"
+// @Param filename query string false "Download file name for csv and xlsx"
 // @Success 200 {object} models.SelectResponse
+// @Failure 408 {object} models.SelectResponse
+// @Failure 409 {object} models.SelectResponse
+// @Failure 503 {object} models.SelectResponse
 // @Router /select [post]
 func (h *APIHandler) SelectEndpoint(c *gin.Context) {
 	startTime := time.Now()
@@ -230,16 +664,106 @@ func (h *APIHandler) SelectEndpoint(c *gin.Context) {
 		return
 	}
 
-	log.Printf("🔍 [select] Executing query: %s", selectReq.Query)
+	h.selectClickHouse(c, selectReq, startTime)
+}
+
+// selectClickHouse runs the SELECT of /v1/select and /v1/selectget
+func (h *APIHandler) selectClickHouse(c *gin.Context, selectReq models.SelectRequest, startTime time.Time) {
+	query, err := decodeSQL(selectReq.Query, selectReq.QueryBase64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	selectReq.Query, selectReq.QueryBase64 = query, ""
+
+	if err := h.checkSelectSQL(services.ServiceClickHouse, selectReq.Query, h.config.ClickHouse.Database); err != nil {
+		log.Printf("🛡️ [select] Rejected by SQL policy: %v", err)
+		c.JSON(http.StatusForbidden, models.SelectResponse{
+			Success: false,
+			Error:   err.Error(),
+			Query:   selectReq.Query,
+		})
+		return
+	}
+
+	params, err := services.NormalizeQueryParams(selectReq.Params, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   "Invalid params: " + err.Error(),
+			Query:   selectReq.Query,
+		})
+		return
+	}
+
+	format, err := selectFormat(c)
+	if err == nil {
+		err = explainRequestError(selectReq, format, false)
+	}
+	var page *selectPage
+	if err == nil {
+		page, err = h.newSelectPage(services.ServiceClickHouse, selectReq, format)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   err.Error(),
+			Query:   selectReq.Query,
+		})
+		return
+	}
+
+	timeout, cancel, err := h.withQueryTimeout(c, services.ServiceClickHouse, selectReq.Query, selectReq.TimeoutMs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   err.Error(),
+			Query:   selectReq.Query,
+		})
+		return
+	}
+	defer cancel()
+
+	if selectReq.Explain {
+		explainSelect(c, "select", selectReq, timeout, startTime, func(ctx context.Context) ([]string, error) {
+			return h.clickHouseService.Explain(ctx, selectReq.Query, params...)
+		})
+		return
+	}
+	if format != selectFormatJSON {
+		log.Printf("🔍 [select] Exporting query as %s: %s (%d params)", format, selectReq.Query, len(params))
+		exportRows(c, "select", format, selectReq.Query, params, h.clickHouseService.StreamSelect)
+		return
+	}
+
+	cacheKey, cacheTTL, served := h.serveCachedSelect(c, "select", services.ServiceClickHouse, selectReq, startTime)
+	if served {
+		return
+	}
+
+	log.Printf("🔍 [select] Executing query: %s (%d params, timeout %s)", selectReq.Query, len(params), timeout)
 
 	ctx := c.Request.Context()
 
-	// Execute select query using ClickHouse service
-	data, err := h.clickHouseService.ExecuteSelect(ctx, selectReq.Query)
+	// Read the page; a larger result stops at max_rows or max_response_bytes
+	err = page.run(ctx, h.clickHouseService.StreamSelect, params)
+	data := page.rows.Rows
 	duration := float64(time.Since(startTime).Nanoseconds()) / 1e6
 
 	if err != nil {
 		log.Printf("❌ [select] Query failed: %v", err)
+		if status, message, ok := queryInterrupted(c, "select", timeout); ok {
+			c.JSON(status, models.SelectResponse{
+				Success:  false,
+				Error:    message,
+				Query:    selectReq.Query,
+				Duration: duration,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.SelectResponse{
 			Success:  false,
 			Error:    fmt.Sprintf("Query execution failed: %s", err.Error()),
@@ -249,26 +773,39 @@ func (h *APIHandler) SelectEndpoint(c *gin.Context) {
 		return
 	}
 
+	if !page.rows.Truncated {
+		h.selectCache.Put(cacheKey, services.ServiceClickHouse, selectReq.Query, data, cacheTTL)
+	}
+
 	rowCount := len(data)
-	log.Printf("✅ [select] Query successful: %d rows returned in %.2fms", rowCount, duration)
-	c.JSON(http.StatusOK, models.SelectResponse{
-		Success:  true,
-		Message:  fmt.Sprintf("Query executed successfully, %d rows returned", rowCount),
-		Data:     data,
-		Query:    selectReq.Query,
-		RowCount: rowCount,
-		Duration: duration,
+	log.Printf("✅ [select] Query successful: %d rows returned in %.2fms (truncated: %t)", rowCount, duration, page.rows.Truncated)
+	message := fmt.Sprintf("Query executed successfully, %d rows returned", rowCount)
+	if page.rows.Truncated {
+		message += "; more rows remain, fetch them with next_cursor"
+	}
+	respond(c, http.StatusOK, models.SelectResponse{
+		Success:    true,
+		Message:    message,
+		Data:       data,
+		Query:      selectReq.Query,
+		RowCount:   rowCount,
+		Duration:   duration,
+		Truncated:  page.rows.Truncated,
+		NextCursor: page.nextCursor(),
 	})
 }
 
 // PgCommandEndpoint godoc
 // @Summary Execute PostgreSQL database command
-// @Description Execute any PostgreSQL SQL command (INSERT, UPDATE, DELETE, CREATE, etc.) via JSON request
+// @Description Execute any PostgreSQL SQL command (INSERT, UPDATE, DELETE, CREATE, etc.) via JSON request. The SQL may be sent base64 encoded as query_base64 instead of query; the response echoes the decoded SQL. timeout_ms bounds how long the statement runs (sql_limits); a statement that runs longer, or whose client disconnects, is cancelled on the database and answers 408.
 // @Tags database
 // @Accept json
 // @Produce json
 // @Param command body models.CommandRequest true "Command to execute"
 // @Success 200 {object} models.CommandResponse
+// @Failure 408 {object} models.CommandResponse
+// @Failure 409 {object} models.CommandResponse
+// @Failure 503 {object} models.CommandResponse
 // @Router /pgcommand [post]
 func (h *APIHandler) PgCommandEndpoint(c *gin.Context) {
 	startTime := time.Now()
@@ -283,17 +820,87 @@ func (h *APIHandler) PgCommandEndpoint(c *gin.Context) {
 		})
 		return
 	}
+	query, err := decodeSQL(commandReq.Query, commandReq.QueryBase64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.CommandResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	commandReq.Query, commandReq.QueryBase64 = query, ""
+
+	middleware.AuditSQL(c, commandReq.Query)
+
+	if err := h.sqlPolicyService.Check(services.ServicePostgreSQL, commandReq.Query, postgreSQLDefaultSchema); err != nil {
+		log.Printf("🛡️ [pgcommand] Rejected by SQL policy: %v", err)
+		c.JSON(http.StatusForbidden, models.CommandResponse{
+			Success: false,
+			Error:   err.Error(),
+			Command: commandReq.Query,
+		})
+		return
+	}
+
+	timeout, cancel, err := h.withQueryTimeout(c, services.ServicePostgreSQL, commandReq.Query, commandReq.TimeoutMs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.CommandResponse{
+			Success: false,
+			Error:   err.Error(),
+			Command: commandReq.Query,
+		})
+		return
+	}
+	defer cancel()
 
-	log.Printf("🐘 [pgcommand] Executing PostgreSQL command: %s", commandReq.Query)
+	log.Printf("🐘 [pgcommand] Executing PostgreSQL command: %s (timeout %s)", commandReq.Query, timeout)
 
 	ctx := c.Request.Context()
 
+	// UPDATE and DELETE on undo tables keep the rows they change
+	var undoPlan *services.UndoPlan
+	if h.undoService != nil {
+		if undoPlan, err = h.undoService.Plan(commandReq.Query); err != nil {
+			log.Printf("🛡️ [pgcommand] Rejected: %v", err)
+			c.JSON(http.StatusBadRequest, models.CommandResponse{
+				Success: false,
+				Error:   err.Error(),
+				Command: commandReq.Query,
+			})
+			return
+		}
+	}
+
 	// Execute command using PostgreSQL service
-	result, err := h.postgreSQLService.ExecuteCommand(ctx, commandReq.Query)
+	var result interface{}
+	if undoPlan != nil {
+		result, err = h.undoService.Execute(ctx, undoPlan, commandReq.Query, shareCreator(c))
+	} else {
+		result, err = h.postgreSQLService.ExecuteCommand(ctx, commandReq.Query)
+	}
 	duration := float64(time.Since(startTime).Nanoseconds()) / 1e6
 
+	if errors.Is(err, services.ErrUndoTooManyRows) {
+		log.Printf("🛡️ [pgcommand] Rolled back: %v", err)
+		c.JSON(http.StatusBadRequest, models.CommandResponse{
+			Success:  false,
+			Error:    err.Error(),
+			Command:  commandReq.Query,
+			Duration: duration,
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("❌ [pgcommand] Execution failed: %v", err)
+		if status, message, ok := queryInterrupted(c, "pgcommand", timeout); ok {
+			c.JSON(status, models.CommandResponse{
+				Success:  false,
+				Error:    message,
+				Command:  commandReq.Query,
+				Duration: duration,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.CommandResponse{
 			Success:  false,
 			Error:    fmt.Sprintf("PostgreSQL command execution failed: %s", err.Error()),
@@ -304,6 +911,13 @@ func (h *APIHandler) PgCommandEndpoint(c *gin.Context) {
 	}
 
 	log.Printf("✅ [pgcommand] Execution successful in %.2fms", duration)
+	auditCommandRows(c, result)
+	if undoPlan != nil {
+		operation := result.(map[string]interface{})["undo_operation"]
+		log.Printf("↩️ [pgcommand] Previous rows of %s kept as undo operation %s", undoPlan.Table, operation)
+		middleware.AuditDetail(c, fmt.Sprintf("undo operation %s", operation))
+	}
+	h.invalidateSelectCache("pgcommand", services.ServicePostgreSQL, commandReq.Query)
 
 	c.JSON(http.StatusOK, models.CommandResponse{
 		Success:  true,
@@ -316,12 +930,17 @@ func (h *APIHandler) PgCommandEndpoint(c *gin.Context) {
 
 // PgSelectEndpoint godoc
 // @Summary Execute PostgreSQL SELECT query
-// @Description Execute PostgreSQL SELECT query and return data via JSON request
+// @Description Execute PostgreSQL SELECT query and return data via JSON request. The SQL may be sent base64 encoded as query_base64 instead of query; the response echoes the decoded SQL. timeout_ms bounds how long the statement runs (sql_limits); a statement that runs longer, or whose client disconnects, is cancelled on the database and answers 408. With explain the plan is returned in plan instead of rows. Results stop at max_rows or sql_limits.max_response_bytes with truncated set and a next_cursor to the rest.
 // @Tags database
 // @Accept json
 // @Produce json
 // @Param select body models.SelectRequest true "SELECT query to execute"
+// @Param format query string false "Output format: json (default), ndjson (streamed, one row per line), csv or xlsx"
+// @Param filename query string false "Download file name for csv and xlsx"
 // @Success 200 {object} models.SelectResponse
+// @Failure 408 {object} models.SelectResponse
+// @Failure 409 {object} models.SelectResponse
+// @Failure 503 {object} models.SelectResponse
 // @Router /pgselect [post]
 func (h *APIHandler) PgSelectEndpoint(c *gin.Context) {
 	startTime := time.Now()
@@ -336,17 +955,101 @@ func (h *APIHandler) PgSelectEndpoint(c *gin.Context) {
 		})
 		return
 	}
+	query, err := decodeSQL(selectReq.Query, selectReq.QueryBase64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	selectReq.Query, selectReq.QueryBase64 = query, ""
 
-	log.Printf("🐘 [pgselect] Executing PostgreSQL query: %s", selectReq.Query)
+	if err := h.checkSelectSQL(services.ServicePostgreSQL, selectReq.Query, postgreSQLDefaultSchema); err != nil {
+		log.Printf("🛡️ [pgselect] Rejected by SQL policy: %v", err)
+		c.JSON(http.StatusForbidden, models.SelectResponse{
+			Success: false,
+			Error:   err.Error(),
+			Query:   selectReq.Query,
+		})
+		return
+	}
+
+	params, err := services.NormalizeQueryParams(selectReq.Params, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   "Invalid params: " + err.Error(),
+			Query:   selectReq.Query,
+		})
+		return
+	}
+
+	format, err := selectFormat(c)
+	if err == nil {
+		err = explainRequestError(selectReq, format, true)
+	}
+	var page *selectPage
+	if err == nil {
+		page, err = h.newSelectPage(services.ServicePostgreSQL, selectReq, format)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   err.Error(),
+			Query:   selectReq.Query,
+		})
+		return
+	}
+
+	timeout, cancel, err := h.withQueryTimeout(c, services.ServicePostgreSQL, selectReq.Query, selectReq.TimeoutMs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   err.Error(),
+			Query:   selectReq.Query,
+		})
+		return
+	}
+	defer cancel()
+
+	if selectReq.Explain {
+		explainSelect(c, "pgselect", selectReq, timeout, startTime, func(ctx context.Context) ([]string, error) {
+			return h.postgreSQLService.Explain(ctx, selectReq.Query, selectReq.Analyze, params...)
+		})
+		return
+	}
+	if format != selectFormatJSON {
+		log.Printf("🐘 [pgselect] Exporting query as %s: %s (%d params)", format, selectReq.Query, len(params))
+		exportRows(c, "pgselect", format, selectReq.Query, params, h.postgreSQLService.StreamSelect)
+		return
+	}
+
+	cacheKey, cacheTTL, served := h.serveCachedSelect(c, "pgselect", services.ServicePostgreSQL, selectReq, startTime)
+	if served {
+		return
+	}
+
+	log.Printf("🐘 [pgselect] Executing PostgreSQL query: %s (%d params, timeout %s)", selectReq.Query, len(params), timeout)
 
 	ctx := c.Request.Context()
 
-	// Execute select query using PostgreSQL service
-	data, err := h.postgreSQLService.ExecuteSelect(ctx, selectReq.Query)
+	// Read the page; a larger result stops at max_rows or max_response_bytes
+	err = page.run(ctx, h.postgreSQLService.StreamSelect, params)
+	data := page.rows.Rows
 	duration := float64(time.Since(startTime).Nanoseconds()) / 1e6
 
 	if err != nil {
 		log.Printf("❌ [pgselect] Query failed: %v", err)
+		if status, message, ok := queryInterrupted(c, "pgselect", timeout); ok {
+			c.JSON(status, models.SelectResponse{
+				Success:  false,
+				Error:    message,
+				Query:    selectReq.Query,
+				Duration: duration,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.SelectResponse{
 			Success:  false,
 			Error:    fmt.Sprintf("PostgreSQL query execution failed: %s", err.Error()),
@@ -356,361 +1059,27 @@ func (h *APIHandler) PgSelectEndpoint(c *gin.Context) {
 		return
 	}
 
-	rowCount := len(data)
-	log.Printf("✅ [pgselect] Query successful: %d rows returned in %.2fms", rowCount, duration)
-
-	c.JSON(http.StatusOK, models.SelectResponse{
-		Success:  true,
-		Message:  fmt.Sprintf("PostgreSQL query executed successfully, %d rows returned", rowCount),
-		Data:     data,
-		Query:    selectReq.Query,
-		RowCount: rowCount,
-		Duration: duration,
-	})
-}
-
-// GuideEndpoint godoc
-// @Summary API Guide for AI Agents
-// @Description Complete API documentation and usage guide for AI agents and developers
-// @Tags documentation
-// @Produce json
-// @Success 200 {object} map[string]interface{}
-// @Router /guide [get]
-func (h *APIHandler) GuideEndpoint(c *gin.Context) {
-	guide := map[string]interface{}{
-		"api_name":      "SMLGOAPI",
-		"version":       "2.0.0",
-		"description":   "Advanced Auto Parts API with AI-powered search, multi-language support, and PostgreSQL backend",
-		"base_url":      "http://localhost:8008/v1",
-		"documentation": "Complete API guide for AI agents, developers, and frontend applications",
-		"last_updated":  "2025-06-20",
-
-		"concepts": map[string]interface{}{
-			"overview": "SMLGOAPI is a modern REST API providing intelligent auto parts search with AI translation assistance, multi-language support (Thai/English), and comprehensive database operations.",
-			"core_features": []string{
-				"🤖 AI-powered query enhancement with DeepSeek API integration",
-				"🌐 Multi-language search support (Thai ↔ English translation)",
-				"🔍 Full-text search in part codes and names with OR logic",
-				"🎯 Smart typo correction and query optimization",
-				"🗄️ PostgreSQL and ClickHouse dual database support",
-				"📷 Image proxy with intelligent caching",
-				"⚡ Real-time health monitoring and performance metrics",
-				"🌍 CORS-enabled for seamless frontend integration",
-			},
-			"new_features_v2": []string{
-				"AI translation assistant for automotive terms",
-				"Enhanced search with both original and translated terms",
-				"Fallback enhancement for offline AI scenarios",
-				"SQL query logging for debugging",
-				"Improved error handling without mock data",
-				"Search limited to code and name fields for better accuracy",
-			},
-			"data_flow": "Frontend → AI Translation → Enhanced Query → PostgreSQL → Structured Results → Frontend",
-			"security":  "Open API (implement authentication for production use)",
-		},
-
-		"endpoints": map[string]interface{}{
-			"health_check": map[string]interface{}{
-				"method":     "GET",
-				"url":        "/health",
-				"purpose":    "Check API and database connectivity",
-				"parameters": "None",
-				"response_example": map[string]interface{}{
-					"status":    "healthy",
-					"timestamp": "2025-06-17T05:10:16.7356603+07:00",
-					"version":   "25.5.1.2782",
-					"database":  "connected",
-				},
-				"use_cases": []string{"Health monitoring", "Deployment verification", "Database connectivity check"},
-			},
-
-			"universal_command": map[string]interface{}{
-				"method":       "POST",
-				"url":          "/command",
-				"purpose":      "Execute any SQL command (CREATE, INSERT, UPDATE, DELETE, ALTER, DROP, etc.)",
-				"content_type": "application/json",
-				"request_format": map[string]interface{}{
-					"query": "string (required) - Any SQL command",
-				},
-				"request_example": map[string]interface{}{
-					"query": "CREATE TABLE IF NOT EXISTS test_api_guide (id UInt32, name String, price Float64, created_at DateTime DEFAULT now()) ENGINE = MergeTree() ORDER BY id",
-				},
-				"response_format": map[string]interface{}{
-					"success":     "boolean - Execution status",
-					"message":     "string - Result message",
-					"result":      "object - Command execution result",
-					"command":     "string - The executed command",
-					"duration_ms": "number - Execution time in milliseconds",
-				},
-				"response_example": map[string]interface{}{
-					"success":     true,
-					"message":     "Command executed successfully",
-					"result":      map[string]interface{}{"query": "CREATE TABLE IF NOT EXISTS test_api_guide (id UInt32, name String, price Float64, created_at DateTime DEFAULT now()) ENGINE = MergeTree() ORDER BY id", "rows_affected": 0, "status": "success"},
-					"command":     "CREATE TABLE IF NOT EXISTS test_api_guide (id UInt32, name String, price Float64, created_at DateTime DEFAULT now()) ENGINE = MergeTree() ORDER BY id",
-					"duration_ms": 550.0,
-				},
-				"example_queries": []string{
-					"CREATE TABLE IF NOT EXISTS test_api_guide (id UInt32, name String, price Float64, created_at DateTime DEFAULT now()) ENGINE = MergeTree() ORDER BY id",
-					"INSERT INTO test_api_guide (id, name, price) VALUES (1, 'Test Product', 99.99)",
-					"ALTER TABLE test_api_guide UPDATE price = 199.99 WHERE id = 1",
-					"ALTER TABLE test_api_guide DELETE WHERE id = 1",
-				},
-			},
-
-			"universal_select": map[string]interface{}{
-				"method":       "POST",
-				"url":          "/select",
-				"purpose":      "Execute SELECT queries and retrieve data",
-				"content_type": "application/json",
-				"request_format": map[string]interface{}{
-					"query": "string (required) - Any SELECT query",
-				},
-				"request_example": map[string]interface{}{
-					"query": "SELECT * FROM test_api_guide ORDER BY id",
-				},
-				"response_format": map[string]interface{}{
-					"success":     "boolean - Query status",
-					"message":     "string - Result message",
-					"data":        "array - Query result data",
-					"query":       "string - The executed query",
-					"row_count":   "number - Number of rows returned",
-					"duration_ms": "number - Execution time in milliseconds",
-				},
-				"response_example": map[string]interface{}{
-					"success": true,
-					"message": "Query executed successfully, 1 rows returned",
-					"data": []map[string]interface{}{
-						{"id": 1, "name": "Test Product", "price": 99.99, "created_at": "2025-06-16T23:40:33Z"},
-					},
-					"query":       "SELECT * FROM test_api_guide ORDER BY id",
-					"row_count":   1,
-					"duration_ms": 550.0,
-				}, "example_queries": []string{
-					"SELECT * FROM ic_inventory ORDER BY name LIMIT 10",
-					"SELECT table_name FROM information_schema.tables WHERE table_schema = 'public'",
-					"SELECT 1 as test, now() as timestamp",
-				},
-			},
-
-			"database_tables": map[string]interface{}{
-				"method":     "GET",
-				"url":        "/api/tables",
-				"purpose":    "List all available database tables",
-				"parameters": "None",
-				"response_example": []map[string]interface{}{
-					{"name": "products", "engine": "MergeTree", "rows": 1500},
-					{"name": "categories", "engine": "MergeTree", "rows": 25},
-				},
-				"use_cases": []string{
-					"Database exploration",
-					"Schema discovery",
-					"Table listing for admin interfaces",
-				},
-			},
-
-			"thai_provinces": map[string]interface{}{
-				"method":       "POST",
-				"url":          "/get/provinces",
-				"purpose":      "Get all Thai provinces with Thai and English names",
-				"content_type": "application/json",
-				"request_body": map[string]interface{}{}, // Empty object
-				"response_format": map[string]interface{}{
-					"success": "boolean - Request status",
-					"message": "string - Success message with count",
-					"data":    "array - List of provinces",
-				},
-				"response_example": map[string]interface{}{
-					"success": true,
-					"message": "Retrieved 77 provinces successfully",
-					"data": []map[string]interface{}{
-						{"id": 1, "name_th": "กรุงเทพมหานคร", "name_en": "Bangkok"},
-						{"id": 2, "name_th": "สมุทรปราการ", "name_en": "Samut Prakan"},
-					},
-				},
-				"use_cases": []string{
-					"Address form population",
-					"Location-based filtering",
-					"Administrative boundary lookup",
-				},
-			},
-
-			"thai_amphures": map[string]interface{}{
-				"method":       "POST",
-				"url":          "/get/amphures",
-				"purpose":      "Get all districts (amphures) in a specified province",
-				"content_type": "application/json",
-				"request_format": map[string]interface{}{
-					"province_id": "integer (required) - Province ID from /get/provinces",
-				},
-				"request_example": map[string]interface{}{
-					"province_id": 1,
-				},
-				"response_format": map[string]interface{}{
-					"success": "boolean - Request status",
-					"message": "string - Success message with count",
-					"data":    "array - List of amphures in the province",
-				},
-				"response_example": map[string]interface{}{
-					"success": true,
-					"message": "Retrieved 50 amphures for province_id 1",
-					"data": []map[string]interface{}{
-						{"id": 1001, "name_th": "เขตพระนคร", "name_en": "Khet Phra Nakhon"},
-						{"id": 1002, "name_th": "เขตดุสิต", "name_en": "Khet Dusit"},
-					},
-				},
-				"use_cases": []string{
-					"Two-level address selection",
-					"District-based operations",
-					"Administrative subdivision lookup",
-				},
-			},
-
-			"thai_tambons": map[string]interface{}{
-				"method":       "POST",
-				"url":          "/get/tambons",
-				"purpose":      "Get all sub-districts (tambons) in a specified amphure and province",
-				"content_type": "application/json",
-				"request_format": map[string]interface{}{
-					"amphure_id":  "integer (required) - Amphure ID from /get/amphures",
-					"province_id": "integer (required) - Province ID for validation",
-				},
-				"request_example": map[string]interface{}{
-					"amphure_id":  1001,
-					"province_id": 1,
-				},
-				"response_format": map[string]interface{}{
-					"success": "boolean - Request status",
-					"message": "string - Success message with count",
-					"data":    "array - List of tambons in the amphure",
-				},
-				"response_example": map[string]interface{}{
-					"success": true,
-					"message": "Retrieved 12 tambons for amphure_id 1001 in province_id 1",
-					"data": []map[string]interface{}{
-						{"id": 100101, "name_th": "พระบรมมหาราชวัง", "name_en": "Phra Borom Maha Ratchawang"},
-						{"id": 100102, "name_th": "วังบูรพาภิรมย์", "name_en": "Wang Burapha Phirom"},
-					},
-				},
-				"use_cases": []string{
-					"Complete address hierarchy",
-					"Fine-grained location services",
-					"Full administrative address validation",
-				},
-			},
-
-			"api_documentation": map[string]interface{}{
-				"method":   "GET",
-				"url":      "/",
-				"purpose":  "Basic API information and endpoint list",
-				"response": "JSON object with API metadata and available endpoints",
-			},
-		},
-
-		"ai_agent_instructions": map[string]interface{}{
-			"overview": "This API is designed to be AI-friendly with comprehensive JSON responses and clear error messages.",
-			"best_practices": []string{
-				"Always check /health before executing operations",
-				"Use /command for data modification (INSERT, UPDATE, DELETE, CREATE)",
-				"Use /select for data retrieval and analytics",
-				"Handle both success and error responses appropriately",
-				"Check duration_ms for performance monitoring",
-				"Use proper JSON formatting in requests",
-			},
-			"error_handling": map[string]interface{}{
-				"all_endpoints_return": "Consistent JSON structure with success boolean",
-				"error_format": map[string]interface{}{
-					"success": false,
-					"error":   "Detailed error message",
-					"query":   "The query that failed (for SQL endpoints)",
-				},
-				"common_errors": []string{
-					"Invalid JSON syntax",
-					"SQL syntax errors",
-					"Database connection issues",
-					"Missing required parameters",
-				},
-			},
-			"data_types": map[string]interface{}{
-				"clickhouse_types": []string{"UInt32", "String", "Float64", "DateTime", "Array", "Nullable"},
-				"json_mapping":     "ClickHouse types automatically mapped to JSON equivalents",
-			},
-		},
-
-		"integration_examples": map[string]interface{}{
-			"curl_examples": map[string]interface{}{
-				"health_check": "curl http://localhost:8008/health",
-				"create_table": "curl -X POST http://localhost:8008/command -H 'Content-Type: application/json' -d '{\"query\": \"CREATE TABLE test (id UInt32, name String) ENGINE = MergeTree() ORDER BY id\"}'",
-				"insert_data":  "curl -X POST http://localhost:8008/command -H 'Content-Type: application/json' -d '{\"query\": \"INSERT INTO test VALUES (1, 'hello')\"}'",
-				"select_data":  "curl -X POST http://localhost:8008/select -H 'Content-Type: application/json' -d '{\"query\": \"SELECT * FROM test\"}'",
-			},
-			"javascript_fetch": map[string]interface{}{
-				"async_example": `
-async function executeSQL(endpoint, query) {
-  const response = await fetch('http://localhost:8008/' + endpoint, {
-    method: 'POST',
-    headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ query: query })
-  });
-  return await response.json();
-}
-
-// Usage
-const result = await executeSQL('select', 'SELECT * FROM products LIMIT 5');
-`,
-			},
-		},
-
-		"production_considerations": map[string]interface{}{
-			"security": []string{
-				"Add authentication (JWT/API keys)",
-				"Implement query validation/whitelisting",
-				"Configure CORS for specific domains",
-				"Add rate limiting",
-				"Enable HTTPS",
-			},
-			"performance": []string{
-				"Monitor query execution times",
-				"Implement query caching",
-				"Use connection pooling (already implemented)",
-				"Add query timeout limits",
-				"Monitor memory usage",
-			},
-			"monitoring": []string{
-				"Log all SQL executions",
-				"Monitor error rates",
-				"Track response times",
-				"Set up health check alerts",
-			},
-		},
-
-		"database_schema": map[string]interface{}{
-			"note": "Use /api/tables to discover available tables",
-			"common_tables": []string{
-				"products - Product catalog data",
-				"categories - Product categories",
-				"system.tables - ClickHouse system table for metadata",
-			},
-			"query_tips": []string{
-				"Always use LIMIT for large result sets",
-				"Use proper WHERE clauses for filtering",
-				"Leverage ClickHouse's columnar storage with SELECT specific columns",
-				"Use ORDER BY for consistent result ordering",
-			},
-		},
+	if !page.rows.Truncated {
+		h.selectCache.Put(cacheKey, services.ServicePostgreSQL, selectReq.Query, data, cacheTTL)
+	}
 
-		"support_information": map[string]interface{}{
-			"troubleshooting": map[string]interface{}{
-				"connection_issues":  "Check /health endpoint and ClickHouse server status",
-				"query_errors":       "Validate SQL syntax and table/column names",
-				"cors_issues":        "API includes CORS headers for localhost and * origins",
-				"performance_issues": "Monitor duration_ms in responses",
-			},
-			"logs":    "Check server console for detailed error information",
-			"testing": "Use included Postman collection or test frontend HTML",
-		},
+	rowCount := len(data)
+	log.Printf("✅ [pgselect] Query successful: %d rows returned in %.2fms (truncated: %t)", rowCount, duration, page.rows.Truncated)
+	message := fmt.Sprintf("PostgreSQL query executed successfully, %d rows returned", rowCount)
+	if page.rows.Truncated {
+		message += "; more rows remain, fetch them with next_cursor"
 	}
 
-	c.JSON(http.StatusOK, guide)
+	respond(c, http.StatusOK, models.SelectResponse{
+		Success:    true,
+		Message:    message,
+		Data:       data,
+		Query:      selectReq.Query,
+		RowCount:   rowCount,
+		Duration:   duration,
+		Truncated:  page.rows.Truncated,
+		NextCursor: page.nextCursor(),
+	})
 }
 
 // Thai Administrative Data Endpoints
@@ -722,7 +1091,9 @@ const result = await executeSQL('select', 'SELECT * FROM products LIMIT 5');
 // @Accept json
 // @Produce json
 // @Success 200 {object} models.APIResponse{data=[]models.Province}
-// @Router /get/provinces [post]
+// @Success 304 "Not modified (GET only)"
+// @Router /provinces [post]
+// @Router /provinces [get]
 func (h *APIHandler) GetProvinces(c *gin.Context) {
 	provinces, err := h.thaiAdminService.GetProvinces()
 	if err != nil {
@@ -747,11 +1118,14 @@ func (h *APIHandler) GetProvinces(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body models.AmphureRequest true "Province ID"
+// @Param province_id query int false "Province ID (GET only)"
 // @Success 200 {object} models.APIResponse{data=[]models.Amphure}
-// @Router /get/amphures [post]
+// @Success 304 "Not modified (GET only)"
+// @Router /amphures [post]
+// @Router /amphures [get]
 func (h *APIHandler) GetAmphures(c *gin.Context) {
 	var req models.AmphureRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := bindRequest(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
 			Error:   "Invalid request format: " + err.Error(),
@@ -782,11 +1156,15 @@ func (h *APIHandler) GetAmphures(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body models.TambonRequest true "Amphure and Province IDs"
+// @Param amphure_id query int false "Amphure ID (GET only)"
+// @Param province_id query int false "Province ID (GET only)"
 // @Success 200 {object} models.APIResponse{data=[]models.Tambon}
-// @Router /get/tambons [post]
+// @Success 304 "Not modified (GET only)"
+// @Router /tambons [post]
+// @Router /tambons [get]
 func (h *APIHandler) GetTambons(c *gin.Context) {
 	var req models.TambonRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := bindRequest(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
 			Error:   "Invalid request format: " + err.Error(),
@@ -817,100 +1195,236 @@ func (h *APIHandler) GetTambons(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body models.ZipCodeRequest true "Zip code to search"
+// @Param zip_code query int false "Zip code (GET only)"
 // @Success 200 {object} models.APIResponse{data=[]models.CompleteLocationData}
-// @Router /get/findbyzipcode [post]
+// @Success 304 "Not modified (GET only)"
+// @Router /findbyzipcode [post]
+// @Router /findbyzipcode [get]
 func (h *APIHandler) FindByZipCode(c *gin.Context) {
 	var req models.ZipCodeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := bindRequest(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	locations, err := h.thaiAdminService.FindByZipCode(req.ZipCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to find locations: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    locations,
+		Message: fmt.Sprintf("Found %d locations for zip code %d", len(locations), req.ZipCode),
+	})
+}
+
+// SearchProductsLegacy godoc
+// @Summary Product search (deprecated)
+// @Description Earlier path of POST /search-by-vector, taking the same body and giving the same response. Answers carry a Deprecation header and a Link to /v1/search-by-vector; move clients there.
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param search body models.SearchParameters true "Search parameters"
+// @Success 200 {object} models.APIResponse{data=services.VectorSearchResponse}
+// @Failure 400 {object} models.APIResponse
+// @Deprecated
+// @Router /search [post]
+func (h *APIHandler) SearchProductsLegacy(c *gin.Context) {
+	log.Printf("⚠️ [VECTOR-SEARCH] Deprecated POST /v1/search from %s (%s); use /v1/search-by-vector", c.ClientIP(), c.Request.UserAgent())
+	c.Header("Deprecation", "true")
+	c.Header("Link", `</v1/search-by-vector>; rel="successor-version"`)
+	c.Set(pageLimitRouteKey, searchLimitRoute)
+	h.SearchProductsByVector(c)
+}
+
+// SearchProductsByVector godoc
+// @Summary Search products using vector database first, then PostgreSQL
+// @Description Search for products using Weaviate vector database to get IC codes (primary) or barcodes (fallback), then search PostgreSQL for detailed product information.
+// @Description On the first page (offset 0 without a cursor) exact barcode and code matches, or LIKE matches when there are none, lead the results; search_method and priority in the response say how the page was found.
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param search body models.SearchParameters true "Search parameters"
+// @Param query query string false "Search text (GET only)"
+// @Param limit query int false "Number of results (GET only)"
+// @Param offset query int false "Pagination offset (GET only)"
+// @Param cursor query string false "next_cursor from the previous page, replaces offset (GET only)"
+// @Success 200 {object} models.APIResponse{data=services.VectorSearchResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /search-by-vector [post]
+// @Router /search-by-vector [get]
+func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
+	startTime := time.Now()
+
+	var params models.SearchParameters
+
+	// POST requests use the JSON body, GET requests use query parameters
+	if err := bindRequest(c, &params); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid JSON format: " + err.Error(),
+		})
+		return
+	}
+
+	log.Printf("🔍 [VECTOR-SEARCH] Parsed parameters: query='%s', limit=%d, offset=%d", params.Query, params.Limit, params.Offset)
+
+	// Validate query
+	if strings.TrimSpace(params.Query) == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Query parameter is required",
+		})
+		return
+	}
+
+	if message := searchPageError(params); message != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	if !services.IsValidGroupBy(params.GroupBy) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid group_by '%s': supported values are '%s' and '%s'", params.GroupBy, services.GroupByCodePrefix, services.GroupByName),
+		})
+		return
+	}
+
+	weights := searchRankingWeights(params)
+	if err := weights.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid ranking parameters: " + err.Error(),
+		})
+		return
+	}
+
+	filters := searchFilters(params)
+	if err := filters.Validate(); err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Error:   "Invalid request format: " + err.Error(),
+			Message: "Invalid filters: " + err.Error(),
 		})
 		return
 	}
 
-	locations, err := h.thaiAdminService.FindByZipCode(req.ZipCode)
+	fields, err := services.ParseResultFields(params.Fields)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Error:   "Failed to find locations: " + err.Error(),
+			Message: "Invalid fields: " + err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
-		Success: true,
-		Data:    locations,
-		Message: fmt.Sprintf("Found %d locations for zip code %d", len(locations), req.ZipCode),
-	})
-}
-
-// SearchProductsByVector godoc
-// @Summary Search products using vector database first, then PostgreSQL
-// @Description Search for products using Weaviate vector database to get IC codes (primary) or barcodes (fallback), then search PostgreSQL for detailed product information
-// @Tags search
-// @Accept json
-// @Produce json
-// @Param search body models.SearchParameters true "Search parameters"
-// @Success 200 {object} models.APIResponse
-// @Router /search-by-vector [post]
-func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
-	startTime := time.Now()
-
-	var params models.SearchParameters
-
-	// Only support POST requests - parse JSON body
-	if err := c.ShouldBindJSON(&params); err != nil {
+	facets, err := services.ParseFacets(params.Facets)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Invalid JSON format: " + err.Error(),
+			Message: "Invalid facets: " + err.Error(),
 		})
 		return
 	}
 
-	log.Printf("🔍 [VECTOR-SEARCH] Parsed parameters: query='%s', limit=%d, offset=%d", params.Query, params.Limit, params.Offset)
-
-	// Validate query
-	if params.Query == "" {
+	// Under a latency budget, optional stages are skipped once too little of it remains
+	budgetDuration, err := services.ParseLatencyBudget(c.GetHeader(services.LatencyBudgetHeader), h.config.Search.LatencyBudget)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Query parameter is required",
+			Message: "Invalid latency budget: " + err.Error(),
 		})
 		return
 	}
+	budget := services.NewLatencyBudget(startTime, budgetDuration, h.config.Search.LatencyBudget)
 
 	query := params.Query
 
-	// AI Enhancement for Vector Search - DISABLED FOR SPEED TESTING
-	// enhancedQuery, err := h.enhanceQueryForVectorSearch(query)
-	// if err != nil {
-	// 	log.Printf("⚠️ [VECTOR-SEARCH] DeepSeek enhancement failed, using original query: %v", err)
-	// 	enhancedQuery = query // ใช้คำค้นหาเดิม
-	// }
-
-	// ใช้ original query โดยตรงเพื่อการทดสอบความเร็ว
+	// The exact-match and text steps use the query as typed. With ai_enhance the Weaviate lookup
+	// gets the terms added by the language model, and with expand_synonyms (or the older ai=1)
+	// the Thai/English synonyms of its terms.
 	searchQuery := query
-	log.Printf("🔍 [VECTOR-SEARCH] Using original query directly (AI enhancement disabled): '%s'", searchQuery)
-
-	// Set default values
-	limit := params.Limit
-	if limit <= 0 {
-		limit = 50 // Increased default limit
+	vectorQuery := query
+	aiEnhanced := false
+	if params.AIEnhance {
+		if h.queryEnhancer == nil {
+			log.Printf("⚠️ [VECTOR-SEARCH] ai_enhance requested but search.ai_enhance.provider is not configured")
+		} else {
+			enhanceCtx, cancel := budget.Stage(c.Request.Context())
+			enhanced, err := h.queryEnhancer.Enhance(enhanceCtx, query)
+			cancel()
+			if err != nil {
+				log.Printf("⚠️ [VECTOR-SEARCH] Using the original query: %v", err)
+			} else {
+				log.Printf("🤖 [VECTOR-SEARCH] %s enhancement: '%s' -> '%s'", h.queryEnhancer.Provider(), query, enhanced)
+				vectorQuery = enhanced
+				aiEnhanced = true
+			}
+		}
 	}
-	if limit > 500 {
-		limit = 500 // Increased max limit
+	expandSynonyms := params.ExpandSynonyms || params.AI == 1
+	if expandSynonyms {
+		expanded := h.synonyms.Expand(vectorQuery)
+		log.Printf("📖 [VECTOR-SEARCH] Synonym expansion: '%s' -> '%s'", vectorQuery, expanded)
+		vectorQuery = expanded
 	}
 
+	// Default and maximum page size come from page_limits (50/500 unless configured)
+	limit := h.clampLimit(c, params.Limit)
+
 	offset := params.Offset
-	if offset < 0 {
-		offset = 0
+
+	// A cursor replaces offset: the page continues after the last row of the previous page
+	// Cursors are tied to the ranking and filters they were issued under
+	fingerprintParts := []string{query}
+	if key := weights.Key(); key != "" {
+		fingerprintParts = append(fingerprintParts, key)
+	}
+	if key := filters.Key(); key != "" {
+		fingerprintParts = append(fingerprintParts, key)
+	}
+	if expandSynonyms && vectorQuery != query {
+		fingerprintParts = append(fingerprintParts, "synonyms")
+	}
+	if aiEnhanced {
+		fingerprintParts = append(fingerprintParts, "ai")
 	}
+	pager := &searchPager{pg: h.postgreSQLService, fingerprint: services.SearchFingerprint(fingerprintParts...), weights: weights, filters: filters}
+	if params.Cursor != "" {
+		cursor, err := services.DecodeSearchCursor(params.Cursor, pager.fingerprint)
+		if err == nil && cursor.Mode == services.SearchCursorVector && h.weaviateService == nil {
+			err = fmt.Errorf("%w: vector search is unavailable", services.ErrInvalidCursor)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: err.Error() + "; restart from the first page",
+			})
+			return
+		}
+		pager.cursor = cursor
+		offset = cursor.Seen
+	}
+	firstPage := pager.cursor == nil && offset == 0
 
 	// Enhanced logging
 	fmt.Printf("\n🚀 [VECTOR-SEARCH] === STARTING SEARCH ===\n")
 	fmt.Printf("   📝 Query: '%s'\n", query)
 	fmt.Printf("   📊 Limit: %d, Offset: %d\n", limit, offset)
-	fmt.Printf("   � AI Enhancement: DISABLED\n")
+	fmt.Printf("   📖 Synonym Expansion: %t\n", expandSynonyms)
+	fmt.Printf("   🤖 AI Enhancement: %t\n", aiEnhanced)
 	fmt.Printf("   =====================================\n")
 	ctx := c.Request.Context()
 
@@ -918,16 +1432,53 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 	var priorityResults []map[string]interface{}
 	var totalPriorityCount int
 	var remainingLimit = limit
+	// Priority steps that failed and were skipped under search.priority_steps
+	var failedSteps []services.SearchStepFailure
+	priority := &services.PrioritySearch{Applied: firstPage}
+
+	// describe reports how the page was found; rows is the page before grouping, which starts
+	// with the priority matches it includes
+	describe := func(results *services.VectorSearchResponse, method string, rows int) {
+		results.SearchMethod = method
+		if firstPage {
+			priority.Count = min(len(priorityResults), rows)
+		}
+		results.Priority = priority
+	}
+
+	// runStep applies the step's failure policy; false means the request has already failed
+	runStep := func(step string, run services.SearchStepFunc) ([]map[string]interface{}, int, bool) {
+		priority.Steps = append(priority.Steps, step)
+		rows, count, failure, err := services.RunSearchStep(ctx, step, h.config.Search.StepPolicy(step), run)
+		if err != nil {
+			log.Printf("❌ [PRIORITY-SEARCH] %v", err)
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Search failed",
+				Error:   err.Error(),
+			})
+			return nil, 0, false
+		}
+		if failure != nil {
+			failedSteps = append(failedSteps, *failure)
+		}
+		// The priority lookups return few rows, so filters are applied to them here
+		rows, removed := filters.Filter(rows)
+		return rows, count - removed, true
+	}
 
-	if offset == 0 {
+	if firstPage {
 		log.Printf("🎯 [PRIORITY-SEARCH] offset=0 detected, implementing priority search logic")
 
 		// Step 1: Search in ic_inventory_barcode.barcode first
 		log.Printf("🔍 [PRIORITY-SEARCH] Step 1: Searching in ic_inventory_barcode.barcode for '%s'", query)
-		barcodeResults, barcodeCount, err := h.postgreSQLService.SearchProductsByExactBarcode(ctx, query, limit, 0)
-		if err != nil {
-			log.Printf("⚠️ [PRIORITY-SEARCH] Barcode search failed: %v", err)
-		} else if barcodeCount > 0 {
+		barcodeResults, barcodeCount, ok := runStep(services.SearchStepBarcode, func() ([]map[string]interface{}, int, error) {
+			return h.postgreSQLService.SearchProductsByExactBarcode(ctx, query, limit, 0)
+		})
+		if !ok {
+			return
+		}
+		if barcodeCount > 0 {
 			log.Printf("✅ [PRIORITY-SEARCH] Found %d results in barcode search", barcodeCount)
 			priorityResults = append(priorityResults, barcodeResults...)
 			totalPriorityCount += barcodeCount
@@ -942,10 +1493,13 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 		// Step 2: If no barcode results or still have remaining limit, search in ic_inventory.code
 		if remainingLimit > 0 {
 			log.Printf("🔍 [PRIORITY-SEARCH] Step 2: Searching in ic_inventory.code for '%s' (remaining limit: %d)", query, remainingLimit)
-			codeResults, codeCount, err := h.postgreSQLService.SearchProductsByExactCode(ctx, query, remainingLimit, 0)
-			if err != nil {
-				log.Printf("⚠️ [PRIORITY-SEARCH] Code search failed: %v", err)
-			} else if codeCount > 0 {
+			codeResults, codeCount, ok := runStep(services.SearchStepCode, func() ([]map[string]interface{}, int, error) {
+				return h.postgreSQLService.SearchProductsByExactCode(ctx, query, remainingLimit, 0)
+			})
+			if !ok {
+				return
+			}
+			if codeCount > 0 {
 				log.Printf("✅ [PRIORITY-SEARCH] Found %d results in code search", codeCount)
 				priorityResults = append(priorityResults, codeResults...)
 				totalPriorityCount += codeCount
@@ -964,10 +1518,13 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 
 			// Step 3: Try simple LIKE search in both barcode and code fields
 			log.Printf("🔍 [PRIORITY-SEARCH] Step 3: Simple LIKE searching for '%s'", searchQuery)
-			simpleLikeResults, simpleLikeCount, err := h.postgreSQLService.SearchProductsSimpleLike(ctx, searchQuery, remainingLimit, 0)
-			if err != nil {
-				log.Printf("⚠️ [PRIORITY-SEARCH] Simple LIKE search failed: %v", err)
-			} else if simpleLikeCount > 0 {
+			simpleLikeResults, simpleLikeCount, ok := runStep(services.SearchStepLike, func() ([]map[string]interface{}, int, error) {
+				return h.postgreSQLService.SearchProductsSimpleLike(ctx, searchQuery, remainingLimit, 0)
+			})
+			if !ok {
+				return
+			}
+			if simpleLikeCount > 0 {
 				log.Printf("✅ [PRIORITY-SEARCH] Found %d results in simple LIKE search", simpleLikeCount)
 				priorityResults = append(priorityResults, simpleLikeResults...)
 				totalPriorityCount += simpleLikeCount
@@ -980,76 +1537,109 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 			}
 		}
 
+		// The exact barcode and code lookups can both hit the same product
+		var dropped int
+		priorityResults, dropped = services.MergeResultsByCode(priorityResults, nil)
+		totalPriorityCount -= dropped
+
 		log.Printf("🎯 [PRIORITY-SEARCH] Priority search completed: %d total results, remaining limit: %d", len(priorityResults), remainingLimit)
 
 		// If we have enough results from priority search, return them
 		if len(priorityResults) >= limit {
 			log.Printf("🎉 [PRIORITY-SEARCH] Priority search satisfied the limit, returning %d results", len(priorityResults))
 
-			// Convert to expected format
-			var convertedResults []services.SearchResult
-			for _, result := range priorityResults[:limit] {
-				convertedResult := services.SearchResult{
-					ID:               getStringValue(result, "id"),
-					Code:             getStringValue(result, "code"),
-					Name:             getStringValue(result, "name"),
-					Price:            getFloat64Value(result, "price"),
-					Unit:             getStringValue(result, "unit"),
-					SupplierCode:     getStringValue(result, "supplier_code"),
-					ImgURL:           getStringValue(result, "img_url"),
-					SimilarityScore:  getFloat64Value(result, "similarity_score"),
-					SalePrice:        getFloat64Value(result, "sale_price"),
-					PremiumWord:      getStringValue(result, "premium_word"),
-					DiscountPrice:    getFloat64Value(result, "discount_price"),
-					DiscountPercent:  getFloat64Value(result, "discount_percent"),
-					FinalPrice:       getFloat64Value(result, "final_price"),
-					SoldQty:          getFloat64Value(result, "sold_qty"),
-					MultiPacking:     int(getFloat64Value(result, "multi_packing")),
-					MultiPackingName: getStringValue(result, "multi_packing_name"),
-					Barcodes:         getStringValue(result, "barcodes"),
-					QtyAvailable:     getFloat64Value(result, "qty_available"),
-					BalanceQty:       getFloat64Value(result, "balance_qty"),
-					SearchPriority:   int(getFloat64Value(result, "search_priority")),
-				}
-				convertedResults = append(convertedResults, convertedResult)
+			if !weights.IsDefault() {
+				weights.SortByRank(priorityResults)
 			}
 
+			// Convert to expected format
+			convertedResults := services.GroupSearchResults(convertSearchResults(priorityResults[:limit]), params.GroupBy, params.GroupPrefixLength)
+
 			results := &services.VectorSearchResponse{
-				Data:       convertedResults,
-				TotalCount: totalPriorityCount,
-				Query:      searchQuery + " (priority search: exact barcode + exact code + like barcode + like code)",
-				Duration:   time.Since(startTime).Seconds() * 1000,
+				Data:     convertedResults,
+				Query:    searchQuery + " (priority search: exact barcode + exact code + like barcode + like code)",
+				Duration: time.Since(startTime).Seconds() * 1000,
+			}
+			services.NewSearchCounts(0, limit, totalPriorityCount, -1).Apply(results)
+			priority.Filled = true
+			describe(results, services.SearchMethodPriority, limit)
+			results.MarkPartial(failedSteps)
+			results.Project(fields)
+			h.applySearchFacets(ctx, budget, results, searchQuery, nil, filters, facets)
+			results.MarkBudget(budget)
+			if firstPage && filters.IsZero() {
+				h.mirrorSearch(ctx, searchQuery, limit, results)
 			}
+			if results.HasMore {
+				results.NextCursor = pager.nextCursor(limit, h.weaviateService != nil)
+			}
+
+			h.applyProductImages(ctx, results.Data)
 
-			c.JSON(http.StatusOK, models.APIResponse{
+			h.recordSearch(c, services.SearchMethodPriority, searchQuery, offset, startTime, results)
+			respond(c, http.StatusOK, typed(models.APIResponse{
 				Success: true,
-				Data:    results,
 				Message: "Priority search completed successfully (exact/like match in barcode + code)",
-			})
+			}, results))
 			return
 		}
 	}
 
-	// Step 1: Search Weaviate vector database first to get IC codes and barcodes
-	if h.weaviateService == nil {
-		// Fallback to regular search when Weaviate is not available
-		log.Printf("⚠️ [VECTOR-SEARCH] Weaviate service not available, falling back to regular search")
+	// Step 1: Search Weaviate vector database first to get IC codes and barcodes. Under a latency
+	// budget the lookup is skipped, or dropped when it runs too long, and the text search is used.
+	var vectorProducts []services.Product
+	var weaviateFailed bool
+	useVector := h.weaviateService != nil && budget.Allow(services.SearchStageVector)
+	if useVector {
+		// Search vector database with higher limit to get more barcodes for better matching
+		vectorLimit := limit * 3 // Get more results from vector DB to compensate for potential mismatches
+		if vectorLimit > 300 {
+			vectorLimit = 300
+		}
+
+		vectorCtx, cancel := budget.Stage(ctx)
+		vectorProducts, err = h.weaviateService.SearchProducts(vectorCtx, vectorQuery, vectorLimit)
+		cancel()
+		if budget.Overran(services.SearchStageVector, vectorCtx, ctx, err) {
+			useVector = false
+		} else if err != nil {
+			// A failing Weaviate degrades the search to the PostgreSQL text search instead of failing it
+			log.Printf("❌ [VECTOR-SEARCH] Weaviate vector search failed, falling back to regular search: %v", err)
+			h.serviceRegistry.MarkDown(services.ServiceWeaviate, err.Error())
+			useVector = false
+			weaviateFailed = true
+		} else {
+			h.serviceRegistry.MarkUp(services.ServiceWeaviate)
+		}
+	}
+
+	if !useVector {
+		fallbackMessage := "Search completed successfully using fallback method (Weaviate unavailable)"
+		if h.weaviateService == nil || weaviateFailed {
+			// Fallback to regular search when Weaviate is not available
+			log.Printf("⚠️ [VECTOR-SEARCH] Weaviate service not available, falling back to regular search")
+			middleware.MarkDegraded(c, services.ServiceWeaviate)
+		} else {
+			log.Printf("⏱️ [VECTOR-SEARCH] Vector search skipped for the latency budget, using regular search")
+			fallbackMessage = "Search completed within the latency budget using regular search (vector search skipped)"
+		}
 
 		// For offset=0, we may already have priority results
 		var searchResults []map[string]interface{}
 		var totalCount int
 
-		if offset == 0 && len(priorityResults) > 0 {
+		if firstPage && len(priorityResults) > 0 {
 			// We have priority results, now get normal search results to fill remaining limit
 			if remainingLimit > 0 {
 				log.Printf("🔍 [VECTOR-SEARCH] Getting additional regular search results (remaining limit: %d)", remainingLimit)
-				normalResults, normalCount, err := h.postgreSQLService.SearchProducts(ctx, searchQuery, remainingLimit, 0)
+				normalResults, normalCount, err := pager.textPage(ctx, searchQuery, remainingLimit, 0)
 				if err != nil {
 					log.Printf("❌ [VECTOR-SEARCH] PostgreSQL regular search failed: %v", err)
 				} else {
 					// Combine priority results with normal results
-					searchResults = append(priorityResults, normalResults...)
-					totalCount = totalPriorityCount + normalCount
+					var dropped int
+					searchResults, dropped = services.MergeResultsByCode(priorityResults, normalResults)
+					totalCount = totalPriorityCount + normalCount - dropped
 					log.Printf("🎯 [VECTOR-SEARCH] Combined results: %d priority + %d normal = %d total", len(priorityResults), len(normalResults), len(searchResults))
 				}
 			} else {
@@ -1060,7 +1650,7 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 			}
 		} else {
 			// No priority results or offset > 0, use regular search
-			regularResults, regularCount, err := h.postgreSQLService.SearchProducts(ctx, searchQuery, limit, offset)
+			regularResults, regularCount, err := pager.textPage(ctx, searchQuery, limit, offset)
 			if err != nil {
 				log.Printf("❌ [VECTOR-SEARCH] PostgreSQL fallback search failed: %v", err)
 				c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -1073,63 +1663,41 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 			totalCount = regularCount
 		}
 
-		// Convert PostgreSQL results to the expected format
-		var convertedResults []services.SearchResult
-		for _, result := range searchResults {
-			convertedResult := services.SearchResult{
-				ID:               getStringValue(result, "id"),
-				Code:             getStringValue(result, "code"),
-				Name:             getStringValue(result, "name"),
-				Price:            getFloat64Value(result, "price"),
-				Unit:             getStringValue(result, "unit"),
-				SupplierCode:     getStringValue(result, "supplier_code"),
-				ImgURL:           getStringValue(result, "img_url"),
-				SimilarityScore:  getFloat64Value(result, "similarity_score"),
-				SalePrice:        getFloat64Value(result, "sale_price"),
-				PremiumWord:      getStringValue(result, "premium_word"),
-				DiscountPrice:    getFloat64Value(result, "discount_price"),
-				DiscountPercent:  getFloat64Value(result, "discount_percent"),
-				FinalPrice:       getFloat64Value(result, "final_price"),
-				SoldQty:          getFloat64Value(result, "sold_qty"),
-				MultiPacking:     int(getFloat64Value(result, "multi_packing")),
-				MultiPackingName: getStringValue(result, "multi_packing_name"),
-				Barcodes:         getStringValue(result, "barcodes"),
-				QtyAvailable:     getFloat64Value(result, "qty_available"),
-				BalanceQty:       getFloat64Value(result, "balance_qty"),
-				SearchPriority:   int(getFloat64Value(result, "search_priority")),
-			}
-			convertedResults = append(convertedResults, convertedResult)
+		if !weights.IsDefault() {
+			weights.SortByRank(searchResults)
 		}
 
+		// Convert PostgreSQL results to the expected format
+		convertedResults := services.GroupSearchResults(convertSearchResults(searchResults), params.GroupBy, params.GroupPrefixLength)
+
 		// Create response in the expected format
 		results := &services.VectorSearchResponse{
-			Data:       convertedResults,
-			TotalCount: totalCount,
-			Query:      searchQuery + " (fallback to regular search)",
-			Duration:   time.Since(startTime).Seconds() * 1000,
+			Data:     convertedResults,
+			Query:    searchQuery + " (fallback to regular search)",
+			Duration: time.Since(startTime).Seconds() * 1000,
+		}
+		// Without Weaviate the text search is the whole result set, so the count is exact
+		services.NewSearchCounts(offset, len(searchResults), totalCount, -1).Apply(results)
+		describe(results, services.SearchMethodText, len(searchResults))
+		results.MarkPartial(failedSteps)
+		results.Project(fields)
+		h.applySearchFacets(ctx, budget, results, searchQuery, nil, filters, facets)
+		results.MarkBudget(budget)
+		h.applySpellingSuggestion(results, searchQuery)
+		if firstPage && filters.IsZero() {
+			h.mirrorSearch(ctx, searchQuery, limit, results)
+		}
+		if results.HasMore {
+			results.NextCursor = pager.nextCursor(offset+len(searchResults), false)
 		}
 
-		c.JSON(http.StatusOK, models.APIResponse{
-			Success: true,
-			Data:    results,
-			Message: "Search completed successfully using fallback method (Weaviate unavailable)",
-		})
-		return
-	}
-
-	// Search vector database with higher limit to get more barcodes for better matching
-	vectorLimit := limit * 3 // Get more results from vector DB to compensate for potential mismatches
-	if vectorLimit > 300 {
-		vectorLimit = 300
-	}
+		h.applyProductImages(ctx, results.Data)
 
-	vectorProducts, err := h.weaviateService.SearchProducts(ctx, searchQuery, vectorLimit)
-	if err != nil {
-		log.Printf("❌ [VECTOR-SEARCH] Weaviate vector search failed: %v", err)
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Message: "Vector search failed: " + err.Error(),
-		})
+		h.recordSearch(c, services.SearchMethodText, searchQuery, offset, startTime, results)
+		respond(c, http.StatusOK, typed(models.APIResponse{
+			Success: true,
+			Message: fallbackMessage,
+		}, results))
 		return
 	}
 
@@ -1142,6 +1710,7 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 		if limit > 200 { // Cap at reasonable maximum
 			limit = 200
 		}
+		limit = h.clampLimit(c, limit)
 		log.Printf("🔼 [VECTOR-SEARCH] Auto-increasing limit from %d to %d due to many vector matches", originalLimit, limit)
 	}
 
@@ -1149,17 +1718,31 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 		log.Printf("ℹ️ [VECTOR-SEARCH] No products found in Weaviate vector database")
 		// Return empty results instead of error
 		results := &services.VectorSearchResponse{
-			Data:       []services.SearchResult{},
-			TotalCount: 0,
-			Query:      query,
-			Duration:   time.Since(startTime).Seconds() * 1000,
+			Data:     []services.SearchResult{},
+			Query:    query,
+			Duration: time.Since(startTime).Seconds() * 1000,
+		}
+		if vectorQuery != query {
+			results.ExpandedQuery = vectorQuery
+		}
+		services.NewSearchCounts(offset, 0, 0, -1).Apply(results)
+		describe(results, services.SearchMethodVector, 0)
+		results.MarkPartial(failedSteps)
+		results.Project(fields)
+		h.applySearchFacets(ctx, budget, results, searchQuery, nil, filters, facets)
+		results.MarkBudget(budget)
+		h.applySpellingSuggestion(results, searchQuery)
+		if firstPage && filters.IsZero() {
+			h.mirrorSearch(ctx, searchQuery, limit, results)
 		}
 
-		c.JSON(http.StatusOK, models.APIResponse{
+		h.applyProductImages(ctx, results.Data)
+
+		h.recordSearch(c, services.SearchMethodVector, searchQuery, offset, startTime, results)
+		respond(c, http.StatusOK, typed(models.APIResponse{
 			Success: true,
-			Data:    results,
 			Message: "No products found matching the query",
-		})
+		}, results))
 		return
 	} // Step 2: Extract IC codes from vector search results (preferred) or fallback to barcodes
 	icCodes, relevanceMap := h.weaviateService.GetICCodesWithRelevance(vectorProducts)
@@ -1176,11 +1759,11 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 		barcodeMapping := h.weaviateService.GetICCodeToBarcodeMap(vectorProducts)
 
 		// For offset=0, we may already have priority results
-		if offset == 0 && len(priorityResults) > 0 {
+		if firstPage && len(priorityResults) > 0 {
 			log.Printf("🎯 [VECTOR-SEARCH] Combining priority results with vector search (remaining limit: %d)", remainingLimit)
 			if remainingLimit > 0 {
 				// Get vector search results for remaining limit
-				vectorResults, vectorCount, err := h.postgreSQLService.SearchProductsByBarcodesWithRelevanceAndBarcodeMap(ctx, icCodes, relevanceMap, barcodeMapping, remainingLimit, 0)
+				vectorResults, vectorCount, err := pager.relevancePage(ctx, icCodes, relevanceMap, barcodeMapping, remainingLimit, 0)
 				if err != nil {
 					log.Printf("❌ [VECTOR-SEARCH] PostgreSQL search by IC codes failed: %v", err)
 					c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -1190,8 +1773,9 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 					return
 				}
 				// Combine priority results with vector results
-				searchResults = append(priorityResults, vectorResults...)
-				totalCount = totalPriorityCount + vectorCount
+				var dropped int
+				searchResults, dropped = services.MergeResultsByCode(priorityResults, vectorResults)
+				totalCount = totalPriorityCount + vectorCount - dropped
 				log.Printf("🎯 [VECTOR-SEARCH] Combined results: %d priority + %d vector = %d total", len(priorityResults), len(vectorResults), len(searchResults))
 			} else {
 				// Use only priority results
@@ -1201,7 +1785,7 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 			}
 		} else {
 			// Step 3: Search PostgreSQL using the IC codes with relevance scores and barcode mapping (normal flow)
-			searchResults, totalCount, err = h.postgreSQLService.SearchProductsByBarcodesWithRelevanceAndBarcodeMap(ctx, icCodes, relevanceMap, barcodeMapping, limit, offset)
+			searchResults, totalCount, err = pager.relevancePage(ctx, icCodes, relevanceMap, barcodeMapping, limit, offset)
 			if err != nil {
 				log.Printf("❌ [VECTOR-SEARCH] PostgreSQL search by IC codes failed: %v", err)
 				c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -1226,11 +1810,11 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 				barcodeMappingFallback := h.weaviateService.GetBarcodeToBarcodeMap(vectorProducts)
 
 				// For offset=0, we may already have priority results
-				if offset == 0 && len(priorityResults) > 0 {
+				if firstPage && len(priorityResults) > 0 {
 					log.Printf("🎯 [VECTOR-SEARCH] Combining priority results with barcode fallback (remaining limit: %d)", remainingLimit)
 					if remainingLimit > 0 {
 						// Get barcode fallback results for remaining limit
-						barcodeResults, barcodeCount, err := h.postgreSQLService.SearchProductsByBarcodesWithRelevanceAndBarcodeMap(ctx, barcodes, barcodeRelevanceMap, barcodeMappingFallback, remainingLimit, 0)
+						barcodeResults, barcodeCount, err := pager.relevancePage(ctx, barcodes, barcodeRelevanceMap, barcodeMappingFallback, remainingLimit, 0)
 						if err != nil {
 							log.Printf("❌ [VECTOR-SEARCH] PostgreSQL fallback search by barcodes failed: %v", err)
 							c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -1240,8 +1824,9 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 							return
 						}
 						// Combine priority results with barcode results
-						searchResults = append(priorityResults, barcodeResults...)
-						totalCount = totalPriorityCount + barcodeCount
+						var dropped int
+						searchResults, dropped = services.MergeResultsByCode(priorityResults, barcodeResults)
+						totalCount = totalPriorityCount + barcodeCount - dropped
 						log.Printf("🎯 [VECTOR-SEARCH] Combined results: %d priority + %d barcode = %d total", len(priorityResults), len(barcodeResults), len(searchResults))
 					} else {
 						// Use only priority results
@@ -1251,7 +1836,7 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 					}
 				} else {
 					// Step 3: Search PostgreSQL using the barcodes with relevance scores and barcode mapping (normal flow)
-					searchResults, totalCount, err = h.postgreSQLService.SearchProductsByBarcodesWithRelevanceAndBarcodeMap(ctx, barcodes, barcodeRelevanceMap, barcodeMappingFallback, limit, offset)
+					searchResults, totalCount, err = pager.relevancePage(ctx, barcodes, barcodeRelevanceMap, barcodeMappingFallback, limit, offset)
 					if err != nil {
 						log.Printf("❌ [VECTOR-SEARCH] PostgreSQL fallback search by barcodes failed: %v", err)
 						c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -1277,11 +1862,11 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 		barcodeMappingPrimary := h.weaviateService.GetBarcodeToBarcodeMap(vectorProducts)
 
 		// For offset=0, we may already have priority results
-		if offset == 0 && len(priorityResults) > 0 {
+		if firstPage && len(priorityResults) > 0 {
 			log.Printf("🎯 [VECTOR-SEARCH] Combining priority results with primary barcode search (remaining limit: %d)", remainingLimit)
 			if remainingLimit > 0 {
 				// Get primary barcode results for remaining limit
-				primaryBarcodeResults, primaryBarcodeCount, err := h.postgreSQLService.SearchProductsByBarcodesWithRelevanceAndBarcodeMap(ctx, barcodes, barcodeRelevanceMap, barcodeMappingPrimary, remainingLimit, 0)
+				primaryBarcodeResults, primaryBarcodeCount, err := pager.relevancePage(ctx, barcodes, barcodeRelevanceMap, barcodeMappingPrimary, remainingLimit, 0)
 				if err != nil {
 					log.Printf("❌ [VECTOR-SEARCH] PostgreSQL search by barcodes failed: %v", err)
 					c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -1291,8 +1876,9 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 					return
 				}
 				// Combine priority results with primary barcode results
-				searchResults = append(priorityResults, primaryBarcodeResults...)
-				totalCount = totalPriorityCount + primaryBarcodeCount
+				var dropped int
+				searchResults, dropped = services.MergeResultsByCode(priorityResults, primaryBarcodeResults)
+				totalCount = totalPriorityCount + primaryBarcodeCount - dropped
 				log.Printf("🎯 [VECTOR-SEARCH] Combined results: %d priority + %d primary barcode = %d total", len(priorityResults), len(primaryBarcodeResults), len(searchResults))
 			} else {
 				// Use only priority results
@@ -1302,7 +1888,7 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 			}
 		} else {
 			// Step 3: Search PostgreSQL using the barcodes with relevance scores and barcode mapping (normal flow)
-			searchResults, totalCount, err = h.postgreSQLService.SearchProductsByBarcodesWithRelevanceAndBarcodeMap(ctx, barcodes, barcodeRelevanceMap, barcodeMappingPrimary, limit, offset)
+			searchResults, totalCount, err = pager.relevancePage(ctx, barcodes, barcodeRelevanceMap, barcodeMappingPrimary, limit, offset)
 			if err != nil {
 				log.Printf("❌ [VECTOR-SEARCH] PostgreSQL search by barcodes failed: %v", err)
 				c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -1319,16 +1905,20 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 	}
 
 	// If user requested more results than what vector database returned, supplement with PostgreSQL results
-	if len(searchResults) < limit && len(vectorProducts) < limit {
+	if len(searchResults) < limit && len(vectorProducts) < limit && budget.Allow(services.SearchStageSupplement) {
 		log.Printf("🔍 [SUPPLEMENT-SEARCH] User requested %d results, but vector DB only returned %d. Supplementing with PostgreSQL results...", limit, len(vectorProducts))
 
 		// Calculate how many additional results we need
 		additionalNeeded := limit - len(searchResults)
 
 		// Get additional results from PostgreSQL general search (excluding already found results)
-		additionalResults, _, err := h.postgreSQLService.SearchProducts(ctx, searchQuery, additionalNeeded*2, len(searchResults)) // Get more to account for potential duplicates
+		supplementCtx, cancel := budget.Stage(ctx)
+		additionalResults, _, err := pager.textPage(supplementCtx, searchQuery, additionalNeeded*2, len(searchResults)) // Get more to account for potential duplicates
+		cancel()
 		if err != nil {
-			log.Printf("⚠️ [SUPPLEMENT-SEARCH] Failed to get additional PostgreSQL results: %v", err)
+			if !budget.Overran(services.SearchStageSupplement, supplementCtx, ctx, err) {
+				log.Printf("⚠️ [SUPPLEMENT-SEARCH] Failed to get additional PostgreSQL results: %v", err)
+			}
 		} else if len(additionalResults) > 0 {
 			log.Printf("✅ [SUPPLEMENT-SEARCH] Found %d additional results from PostgreSQL", len(additionalResults))
 
@@ -1352,6 +1942,7 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 				if code, ok := additionalResult["code"]; ok {
 					if codeStr, ok := code.(string); ok {
 						if !existingCodes[codeStr] {
+							pager.mark(services.SearchCursorText, additionalResult)
 							// Add with lower relevance score to indicate it's supplemental
 							additionalResult["similarity_score"] = 25.0 // Lower than vector results
 							additionalResult["search_priority"] = 7     // Lower priority than vector results
@@ -1365,70 +1956,69 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 
 			if addedCount > 0 {
 				log.Printf("🎯 [SUPPLEMENT-SEARCH] Added %d unique supplemental results (total now: %d)", addedCount, len(searchResults))
-				// Update total count to reflect combined results
-				totalCount = len(searchResults)
+				// Supplemental rows join the merged set; the text search total is reported as the estimate
+				totalCount += addedCount
 			}
 		}
 	}
 
+	// Exact matches lead the page unless ranking weights say otherwise
+	if !weights.IsDefault() {
+		weights.SortByRank(searchResults)
+	}
+
 	// Convert PostgreSQL results to the expected format
-	var convertedResults []services.SearchResult
-	for _, result := range searchResults {
-		convertedResult := services.SearchResult{
-			ID:               getStringValue(result, "id"),
-			Code:             getStringValue(result, "code"),
-			Name:             getStringValue(result, "name"),
-			Price:            getFloat64Value(result, "price"),
-			Unit:             getStringValue(result, "unit"),
-			SupplierCode:     getStringValue(result, "supplier_code"),
-			ImgURL:           getStringValue(result, "img_url"),
-			SimilarityScore:  getFloat64Value(result, "similarity_score"),
-			SalePrice:        getFloat64Value(result, "sale_price"),
-			PremiumWord:      getStringValue(result, "premium_word"),
-			DiscountPrice:    getFloat64Value(result, "discount_price"),
-			DiscountPercent:  getFloat64Value(result, "discount_percent"),
-			FinalPrice:       getFloat64Value(result, "final_price"),
-			SoldQty:          getFloat64Value(result, "sold_qty"),
-			MultiPacking:     int(getFloat64Value(result, "multi_packing")),
-			MultiPackingName: getStringValue(result, "multi_packing_name"),
-			Barcodes:         getStringValue(result, "barcodes"),
-			Barcode:          getStringValue(result, "barcode"), // Add the barcode field from Weaviate
-			QtyAvailable:     getFloat64Value(result, "qty_available"),
-			BalanceQty:       getFloat64Value(result, "balance_qty"),
-			SearchPriority:   int(getFloat64Value(result, "search_priority")),
+	convertedResults := services.GroupSearchResults(convertSearchResults(searchResults), params.GroupBy, params.GroupPrefixLength)
+
+	// Get total available products count from regular PostgreSQL search; it becomes estimated_total
+	// when the text match reaches further than the merged vector set
+	totalAvailableInPostgreSQL := -1
+	if h.postgreSQLService != nil && budget.Allow(services.SearchStageEstimate) {
+		estimateCtx, cancel := budget.Stage(ctx)
+		_, totalAvailableInPostgreSQL, err = h.postgreSQLService.SearchProductsRanked(estimateCtx, searchQuery, 1, 0, nil, services.DefaultRankingWeights(), filters)
+		cancel()
+		if err != nil {
+			if !budget.Overran(services.SearchStageEstimate, estimateCtx, ctx, err) {
+				log.Printf("⚠️ [VECTOR-SEARCH] Failed to get total count from PostgreSQL: %v", err)
+			}
+			totalAvailableInPostgreSQL = -1
 		}
-		convertedResults = append(convertedResults, convertedResult)
 	}
 
 	// Create response in the expected format
 	results := &services.VectorSearchResponse{
-		Data:       convertedResults,
-		TotalCount: totalCount,
-		Query:      searchQuery,
-		Duration:   time.Since(startTime).Seconds() * 1000,
+		Data:     convertedResults,
+		Query:    searchQuery,
+		Duration: time.Since(startTime).Seconds() * 1000,
 	}
-	duration := time.Since(startTime).Seconds() * 1000
-
-	// Get total available products count from regular PostgreSQL search for better reporting
-	var totalAvailableInPostgreSQL int
-	if h.postgreSQLService != nil {
-		_, totalAvailableInPostgreSQL, err = h.postgreSQLService.SearchProducts(ctx, searchQuery, 1, 0)
-		if err != nil {
-			log.Printf("⚠️ [VECTOR-SEARCH] Failed to get total count from PostgreSQL: %v", err)
-			totalAvailableInPostgreSQL = results.TotalCount // fallback to current count
-		}
-	} else {
-		totalAvailableInPostgreSQL = results.TotalCount
+	if vectorQuery != query {
+		results.ExpandedQuery = vectorQuery
 	}
+	services.NewSearchCounts(offset, len(searchResults), totalCount, totalAvailableInPostgreSQL).Apply(results)
+	describe(results, services.SearchMethodVector, len(searchResults))
+	results.MarkPartial(failedSteps)
+	results.Project(fields)
+	h.applySearchFacets(ctx, budget, results, searchQuery, icCodes, filters, facets)
+	results.MarkBudget(budget)
+	h.applySpellingSuggestion(results, searchQuery)
+	if firstPage && filters.IsZero() {
+		h.mirrorSearch(ctx, searchQuery, limit, results)
+	}
+	if results.HasMore {
+		results.NextCursor = pager.nextCursor(offset+len(searchResults), true)
+	}
+	duration := time.Since(startTime).Seconds() * 1000
 
 	// Enhanced search results logging
 	fmt.Printf("\n🎯 [VECTOR-SEARCH] === SEARCH RESULTS SUMMARY ===\n")
 	fmt.Printf("   📝 Query: '%s'\n", query)
-	fmt.Printf("   🚫 AI Enhancement: DISABLED\n")
+	if vectorQuery != query {
+		fmt.Printf("   📖 Expanded Query: '%s'\n", vectorQuery)
+	}
 	fmt.Printf("   🔗 Search Method: %s\n", searchMethod)
 	fmt.Printf("   🎲 Vector Database: %d products found\n", len(vectorProducts))
 	fmt.Printf("   📊 Vector-Matched Products: %d records (from %d vector results)\n", results.TotalCount, len(vectorProducts))
-	fmt.Printf("   📚 Total PostgreSQL Available: %d records (all matching products)\n", totalAvailableInPostgreSQL)
+	fmt.Printf("   📚 Estimated Total: %d records (%s)\n", results.EstimatedTotal, results.CountStrategy)
 	fmt.Printf("   📋 Returned Results: %d products (limit: %d)\n", len(results.Data), limit)
 	fmt.Printf("   📄 Page Info: page %d (offset: %d, limit: %d)\n", (offset/limit)+1, offset, limit)
 	fmt.Printf("   ⏱️  Processing Time: %.1fms\n", duration)
@@ -1440,8 +2030,8 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 			}
 			fmt.Printf("     %d. [%s] %s (Relevance: %.1f%%)\n", i+1, product.Code, product.Name, product.SimilarityScore)
 		}
-		if len(results.Data) < totalAvailableInPostgreSQL {
-			fmt.Printf("   📄 ... and %d more results available in PostgreSQL\n", totalAvailableInPostgreSQL-len(results.Data))
+		if len(results.Data) < results.EstimatedTotal {
+			fmt.Printf("   📄 ... and %d more results available in PostgreSQL\n", results.EstimatedTotal-len(results.Data))
 		}
 		if len(results.Data) < results.TotalCount {
 			fmt.Printf("   📄 ... and %d more vector-matched results available\n", results.TotalCount-len(results.Data))
@@ -1452,11 +2042,127 @@ func (h *APIHandler) SearchProductsByVector(c *gin.Context) {
 
 	fmt.Printf("   ===============================\n")
 	fmt.Printf("✅ [VECTOR-SEARCH] COMPLETED (%.1fms)\n\n", duration)
-	c.JSON(http.StatusOK, models.APIResponse{
+
+	h.applyProductImages(ctx, results.Data)
+
+	h.recordSearch(c, services.SearchMethodVector, searchQuery, offset, startTime, results)
+	respond(c, http.StatusOK, typed(models.APIResponse{
 		Success: true,
-		Data:    results,
 		Message: "Vector search completed successfully",
-	})
+	}, results))
+}
+
+// applySearchFacets adds the requested facets to a search response. Facets are optional, so a
+// failure is logged and the results are returned without them.
+func (h *APIHandler) applySearchFacets(ctx context.Context, budget *services.LatencyBudget, results *services.VectorSearchResponse, query string, codes []string, filters services.SearchFilters, facets []string) {
+	if len(facets) == 0 || !budget.Allow(services.SearchStageFacets) {
+		return
+	}
+	facetCtx, cancel := budget.Stage(ctx)
+	counts, err := h.postgreSQLService.SearchFacets(facetCtx, query, codes, filters, facets)
+	cancel()
+	if err != nil {
+		if !budget.Overran(services.SearchStageFacets, facetCtx, ctx, err) {
+			log.Printf("⚠️ [FACETS] Failed to compute facets for '%s': %v", query, err)
+		}
+		return
+	}
+	results.Facets = counts
+}
+
+// applySpellingSuggestion offers a corrected query when the search matched no product at all
+func (h *APIHandler) applySpellingSuggestion(results *services.VectorSearchResponse, query string) {
+	if results.ExactCount > 0 || len(results.Data) > 0 {
+		return
+	}
+	if suggestion := h.spelling.Suggest(query); suggestion != "" {
+		log.Printf("🔤 [SPELLING] No results for '%s', suggesting '%s'", query, suggestion)
+		results.SuggestedQuery = suggestion
+	}
+}
+
+// convertSearchResults maps raw PostgreSQL search rows to the SearchResult response format
+func convertSearchResults(rows []map[string]interface{}) []services.SearchResult {
+	var convertedResults []services.SearchResult
+	for _, result := range rows {
+		convertedResult := services.SearchResult{
+			ID:               getStringValue(result, "id"),
+			Code:             getStringValue(result, "code"),
+			Name:             getStringValue(result, "name"),
+			Price:            getFloat64Value(result, "price"),
+			Unit:             getStringValue(result, "unit"),
+			SupplierCode:     getStringValue(result, "supplier_code"),
+			ImgURL:           getStringValue(result, "img_url"),
+			SimilarityScore:  getFloat64Value(result, "similarity_score"),
+			SalePrice:        getFloat64Value(result, "sale_price"),
+			PremiumWord:      getStringValue(result, "premium_word"),
+			DiscountPrice:    getFloat64Value(result, "discount_price"),
+			DiscountPercent:  getFloat64Value(result, "discount_percent"),
+			FinalPrice:       getFloat64Value(result, "final_price"),
+			SoldQty:          getFloat64Value(result, "sold_qty"),
+			MultiPacking:     int(getFloat64Value(result, "multi_packing")),
+			MultiPackingName: getStringValue(result, "multi_packing_name"),
+			Barcodes:         getStringValue(result, "barcodes"),
+			Barcode:          getStringValue(result, "barcode"), // Add the barcode field from Weaviate
+			QtyAvailable:     getFloat64Value(result, "qty_available"),
+			BalanceQty:       getFloat64Value(result, "balance_qty"),
+			SearchPriority:   int(getFloat64Value(result, "search_priority")),
+		}
+		convertedResults = append(convertedResults, convertedResult)
+	}
+	return convertedResults
+}
+
+// searchPageError explains why the paging parameters of a search are rejected, or returns ""
+func searchPageError(params models.SearchParameters) string {
+	switch {
+	case params.Limit < 0:
+		return fmt.Sprintf("Invalid limit %d: must not be negative", params.Limit)
+	case params.Offset < 0:
+		return fmt.Sprintf("Invalid offset %d: must not be negative", params.Offset)
+	case params.Cursor != "" && params.Offset > 0:
+		return "Pass either cursor or offset, not both: the cursor already says where the page starts"
+	case params.GroupPrefixLength < 0:
+		return fmt.Sprintf("Invalid group_prefix_length %d: must not be negative", params.GroupPrefixLength)
+	case params.GroupPrefixLength > 0 && params.GroupBy != services.GroupByCodePrefix:
+		return fmt.Sprintf("group_prefix_length only applies to group_by=%s", services.GroupByCodePrefix)
+	}
+	return ""
+}
+
+// searchRankingWeights reads the ranking knobs of a search request
+func searchRankingWeights(params models.SearchParameters) services.RankingWeights {
+	weights := services.DefaultRankingWeights()
+	if params.WeightExactCode != nil {
+		weights.ExactCode = *params.WeightExactCode
+	}
+	if params.WeightBarcode != nil {
+		weights.Barcode = *params.WeightBarcode
+	}
+	if params.WeightVector != nil {
+		weights.Vector = *params.WeightVector
+	}
+	weights.InStock = params.BoostInStock
+	weights.HasImage = params.BoostHasImage
+	return weights
+}
+
+// searchFilters reads the price, stock and item type filters of a search request
+func searchFilters(params models.SearchParameters) services.SearchFilters {
+	return services.SearchFilters{
+		MinPrice:    params.MinPrice,
+		MaxPrice:    params.MaxPrice,
+		InStockOnly: params.InStockOnly,
+		ItemType:    params.ItemType,
+	}
+}
+
+// bindRequest binds query parameters for GET requests and the JSON body otherwise
+func bindRequest(c *gin.Context, obj interface{}) error {
+	if c.Request.Method == http.MethodGet {
+		return c.ShouldBindQuery(obj)
+	}
+	return c.ShouldBindJSON(obj)
 }
 
 // Helper functions for type conversion from map[string]interface{}
@@ -1492,145 +2198,3 @@ func getFloat64Value(data map[string]interface{}, key string) float64 {
 	}
 	return 0.0
 }
-
-// COMMENTED OUT FOR SPEED TESTING - DeepSeek AI Enhancement Functions
-/*
-// enhanceQueryForVectorSearch enhances search query specifically for vector search
-// ปรับปรุงคำค้นหาสำหรับ vector search โดยเฉพาะ พร้อม fallback เมื่อ API ล้มเหลว
-func (h *APIHandler) enhanceQueryForVectorSearch(originalQuery string) (string, error) {
-	log.Printf("🤖 [vector-enhance] Processing query for vector search: '%s'", originalQuery)
-
-	// ลอง DeepSeek API ก่อน แต่ถ้าล้มเหลวจะใช้ fallback ทันที
-	enhancedQuery, err := h.callDeepSeekAPIForVector(originalQuery)
-	if err != nil {
-		log.Printf("⚠️ [vector-enhance] DeepSeek API failed (%v), using fallback enhancement", err)
-		return originalQuery, nil
-	}
-
-	// ลบคำซ้ำและทำความสะอาด
-	cleanedQuery := h.removeDuplicateWords(enhancedQuery)
-
-	if cleanedQuery != originalQuery {
-		log.Printf("🤖 [vector-enhance] DeepSeek enhanced & cleaned: '%s' -> '%s'", originalQuery, cleanedQuery)
-		return cleanedQuery, nil
-	}
-
-	log.Printf("🤖 [vector-enhance] DeepSeek returned same query, using fallback")
-
-	return originalQuery, nil
-}
-
-// callDeepSeekAPIForVector เรียก DeepSeek API สำหรับ vector search โดยเฉพาะ
-func (h *APIHandler) callDeepSeekAPIForVector(originalQuery string) (string, error) {
-	// สร้าง prompt ที่ครอบคลุมสำหรับ vector search
-	prompt := fmt.Sprintf(`
-ตัวอย่างคำพ้องเสียง ถ้าข้อความเป็นไทย ใช้คำพร้องเสียงเป็นภาษาอังกฤษ ถ้าเป็นภาษาอังกฤษ ใช้คำพ้องเสียงเป็นภาษาไทย:
-- toyota = โตโยต้า
-- honda = ฮอนด้า
-- nissan = นิสสัน
-- mazda = มาสด้า
-- brake = เบรค
-- oil = น้ำมัน
-- light = ไฟ
-- wheel = ล้อ
-- tire = ยาง
-- battery = แบตเตอรี่
-- coil = คอยล์
-- shock = โช๊ค
-- filter = กรอง
-- engine = เครื่องยนต์
-ถ้าเป็นชื่อรุ่น ใช้คำพ้องเสียงเป็นภาษาอังกฤษ และภาษาไทย
-
-ใช้คำพ้องเสียงเหล่านี้เพื่อปรับปรุงการค้นหา vector โดยเฉพาะ
-result=ข้อความต้นฉบับ + space + ข้อความพ้องเสียงที่เกี่ยวข้อง
-แบ่งคำให้ด้วย space และลบคำซ้ำ
-ข้อความต้นฉบับ: "%s"
-
-return เฉพาะ result
-`, originalQuery)
-
-	// เรียกใช้ DeepSeek API พร้อม timeout ที่เพิ่มขึ้น
-	reqBody := DeepSeekRequest{
-		Model: DeepSeekModel,
-		Messages: []Message{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", DeepSeekAPIURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+DeepSeekAPIKey)
-
-	client := &http.Client{Timeout: 30 * time.Second} // เพิ่ม timeout เป็น 30 วินาที
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("❌ [vector-enhance] DeepSeek API timeout/error: %v", err)
-		return "", fmt.Errorf("failed to call DeepSeek API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		log.Printf("❌ [vector-enhance] DeepSeek API status error: %d", resp.StatusCode)
-		return "", fmt.Errorf("DeepSeek API error: status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("❌ [vector-enhance] Failed to read DeepSeek response: %v", err)
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var deepSeekResp DeepSeekResponse
-	if err := json.Unmarshal(body, &deepSeekResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	if len(deepSeekResp.Choices) == 0 {
-		return "", fmt.Errorf("no response from DeepSeek")
-	}
-
-	enhancedQuery := strings.TrimSpace(deepSeekResp.Choices[0].Message.Content)
-	log.Printf("🤖 [vector-enhance] DeepSeek API success: '%s' -> '%s'", originalQuery, enhancedQuery)
-	return enhancedQuery, nil
-}
-
-// removeDuplicateWords ลบคำซ้ำจาก query string
-func (h *APIHandler) removeDuplicateWords(query string) string {
-	// ลบเครื่องหมายคำพูดออก
-	query = strings.Trim(query, `"'`)
-
-	// แยกคำด้วย space
-	words := strings.Fields(query)
-
-	// ใช้ map เพื่อเก็บคำที่ไม่ซ้ำ (case insensitive)
-	seen := make(map[string]bool)
-	var result []string
-
-	for _, word := range words {
-		// ลบ special characters และทำให้เป็น lowercase สำหรับเปรียบเทียบ
-		normalizedWord := strings.ToLower(strings.Trim(word, ".,!?;:()[]{}"))
-
-		// ถ้ายังไม่เคยเห็นคำนี้
-		if !seen[normalizedWord] && normalizedWord != "" {
-			seen[normalizedWord] = true
-			result = append(result, word) // เก็บคำเดิม (ไม่ใช่ normalized)
-		}
-	}
-
-	finalResult := strings.Join(result, " ")
-	log.Printf("🧹 [vector-enhance] Removed duplicates: '%s' -> '%s'", query, finalResult)
-	return finalResult
-}
-*/
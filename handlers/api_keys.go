@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"smlgoapi/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKey godoc
+// @Summary Issue a new API key
+// @Description Create an API key with the given scopes (read, command, admin). The key is only returned once.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.CreateAPIKeyRequest true "Key name and scopes"
+// @Success 200 {object} models.APIResponse{data=models.CreateAPIKeyResponse}
+// @Router /admin/api-keys [post]
+func (h *APIHandler) CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	rawKey, key, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), req.Name, req.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.CreateAPIKeyResponse{Key: rawKey, APIKey: *key},
+		Message: "API key created - store it now, it will not be shown again",
+	})
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description List all API keys without their secret values
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.APIKey}
+// @Router /admin/api-keys [get]
+func (h *APIHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    keys,
+		Message: fmt.Sprintf("Retrieved %d API keys", len(keys)),
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Tags admin
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} models.APIResponse
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid API key id",
+		})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("API key %d revoked", id),
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"smlgoapi/middleware"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditLog godoc
+// @Summary Audit log of write requests
+// @Description Who sent which write request (/command, /pgcommand, /pgtransaction, the data API, imports and other admin actions), when and from which IP, with its SQL, rows affected, status and duration. Newest first.
+// @Tags admin
+// @Produce json
+// @Param from query string false "Start, RFC 3339 time or YYYY-MM-DD (inclusive)"
+// @Param to query string false "End, RFC 3339 time (exclusive) or YYYY-MM-DD (inclusive)"
+// @Param user query string false "Session username or api-key:<name>"
+// @Param route query string false "Route pattern or path prefix, e.g. /v1/pgcommand or /v1/admin"
+// @Param method query string false "HTTP method, e.g. DELETE"
+// @Param limit query int false "Entries per page (page_limits)"
+// @Param offset query int false "Entries to skip"
+// @Success 200 {object} models.APIResponse{data=models.AuditPage}
+// @Failure 400 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /admin/audit [get]
+func (h *APIHandler) GetAuditLog(c *gin.Context) {
+	if h.auditService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "The audit log requires PostgreSQL and audit enabled",
+		})
+		return
+	}
+
+	filter := services.AuditFilter{
+		Actor:  c.Query("user"),
+		Route:  c.Query("route"),
+		Method: c.Query("method"),
+		Limit:  h.queryLimit(c),
+		Offset: queryIntBounded(c, "offset", 0, 0, math.MaxInt32),
+	}
+	var err error
+	if filter.From, err = auditTime(c.Query("from"), false); err == nil {
+		filter.To, err = auditTime(c.Query("to"), true)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	entries, total, err := h.auditService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: models.AuditPage{
+			Entries: entries,
+			Total:   total,
+			Limit:   filter.Limit,
+			Offset:  filter.Offset,
+		},
+		Message: fmt.Sprintf("%d of %d entries", len(entries), total),
+	})
+}
+
+// auditTime reads a from or to parameter. A date covers the whole day in local time, so as the
+// end of a range it stands for the following midnight.
+func auditTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s': use RFC 3339, e.g. 2026-01-31T08:00:00+07:00, or a date like 2026-01-31", value)
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// auditCommandRows records the rows_affected of an ExecuteCommand result
+func auditCommandRows(c *gin.Context, result interface{}) {
+	if fields, ok := result.(map[string]interface{}); ok {
+		if rows, ok := fields["rows_affected"].(int64); ok {
+			middleware.AuditRows(c, rows)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Login godoc
+// @Summary Log in and obtain a JWT session
+// @Description Exchange a username and password for an access token and a refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.LoginRequest true "Credentials"
+// @Success 200 {object} models.APIResponse{data=models.TokenResponse}
+// @Failure 401 {object} models.APIResponse
+// @Router /auth/login [post]
+func (h *APIHandler) Login(c *gin.Context) {
+	if !h.requireSessions(c) {
+		return
+	}
+
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	tokens, err := h.sessionService.Login(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		h.respondAuthError(c, "login", req.Username, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    tokens,
+		Message: "Logged in as " + tokens.User.Username,
+	})
+}
+
+// RefreshToken godoc
+// @Summary Refresh a JWT session
+// @Description Exchange a refresh token for a new access token and refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.APIResponse{data=models.TokenResponse}
+// @Failure 401 {object} models.APIResponse
+// @Router /auth/refresh [post]
+func (h *APIHandler) RefreshToken(c *gin.Context) {
+	if !h.requireSessions(c) {
+		return
+	}
+
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	tokens, err := h.sessionService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		h.respondAuthError(c, "refresh", "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    tokens,
+		Message: "Session refreshed",
+	})
+}
+
+// CreateUser godoc
+// @Summary Create an API user
+// @Description Create a user with role admin, operator or viewer
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.CreateUserRequest true "User details"
+// @Success 200 {object} models.APIResponse{data=models.User}
+// @Router /admin/users [post]
+func (h *APIHandler) CreateUser(c *gin.Context) {
+	if !h.requireSessions(c) {
+		return
+	}
+
+	var req models.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	user, err := h.sessionService.Users().CreateUser(c.Request.Context(), req.Username, req.Password, req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    user,
+		Message: fmt.Sprintf("User %s created with role %s", user.Username, user.Role),
+	})
+}
+
+// ListUsers godoc
+// @Summary List API users
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.User}
+// @Router /admin/users [get]
+func (h *APIHandler) ListUsers(c *gin.Context) {
+	if !h.requireSessions(c) {
+		return
+	}
+
+	users, err := h.sessionService.Users().ListUsers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    users,
+		Message: fmt.Sprintf("Retrieved %d users", len(users)),
+	})
+}
+
+// requireSessions writes a 503 and returns false when JWT sessions are disabled
+func (h *APIHandler) requireSessions(c *gin.Context) bool {
+	if h.sessionService != nil {
+		return true
+	}
+	c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+		Success: false,
+		Error:   "User sessions are disabled; set jwt.enabled in the configuration",
+	})
+	return false
+}
+
+// respondAuthError maps credential failures to 401 and everything else to 500
+func (h *APIHandler) respondAuthError(c *gin.Context, action, username string, err error) {
+	if errors.Is(err, services.ErrInvalidCredentials) || errors.Is(err, services.ErrInvalidToken) {
+		log.Printf("🔒 [auth] Failed %s for '%s': %v", action, username, err)
+		c.Header("WWW-Authenticate", `Bearer realm="smlgoapi"`)
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("❌ [auth] %s error: %v", action, err)
+	c.JSON(http.StatusInternalServerError, models.APIResponse{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
+
+// Failures of authorizeCredential
+var (
+	errUnauthenticated = errors.New("unauthenticated")
+	errForbidden       = errors.New("forbidden")
+)
+
+// authorizeCredential checks an API key or JWT access token the way the authentication
+// middleware does, for callers outside the gin route groups (gRPC, WebSocket subscriptions).
+// API keys must carry scope and sessions at least minRole. It returns the page_limits role of the
+// caller; errors wrap errUnauthenticated or errForbidden.
+func (h *APIHandler) authorizeCredential(ctx context.Context, credential, scope, minRole string) (string, error) {
+	if credential == "" {
+		return "", fmt.Errorf("%w: credentials required", errUnauthenticated)
+	}
+
+	// JWTs are three dot-separated segments; API keys never contain dots
+	if h.sessionService != nil && strings.Count(credential, ".") == 2 {
+		claims, err := h.sessionService.ParseAccessToken(credential)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid or expired access token", errUnauthenticated)
+		}
+		if !models.RoleAtLeast(claims.Role, minRole) {
+			return "", fmt.Errorf("%w: user '%s' with role '%s' requires role: %s", errForbidden, claims.Username, claims.Role, minRole)
+		}
+		return claims.Role, nil
+	}
+
+	key, err := h.apiKeyService.ValidateAPIKey(ctx, credential)
+	if err != nil {
+		log.Printf("🔒 [auth] Rejected API key: %v", err)
+		return "", fmt.Errorf("%w: invalid or revoked API key", errUnauthenticated)
+	}
+	if !key.HasScope(scope) {
+		return "", fmt.Errorf("%w: API key '%s' lacks required scope: %s", errForbidden, key.Name, scope)
+	}
+	return apiKeyLimitRole, nil
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// barcodeScanLimit is the most products returned for one scanned barcode
+const barcodeScanLimit = 20
+
+// ScanBarcode godoc
+// @Summary Identify a product from a barcode photo
+// @Description Decode an EAN-13/EAN-8/UPC, Code 128 or QR code from a base64 image and return the products with exactly that barcode, for clients without a native scanner
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param request body models.BarcodeScanRequest true "Base64 image"
+// @Success 200 {object} models.APIResponse{data=services.BarcodeScanResult}
+// @Failure 400 {object} models.APIResponse
+// @Failure 422 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /barcode/scan [post]
+func (h *APIHandler) ScanBarcode(c *gin.Context) {
+	if h.postgreSQLService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Barcode scanning requires PostgreSQL, which is unavailable",
+		})
+		return
+	}
+
+	var req models.BarcodeScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	barcode, format, err := services.DecodeBarcodeImage(req.Image)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrNoBarcode) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	rows, _, err := h.postgreSQLService.SearchProductsByExactBarcode(ctx, barcode, barcodeScanLimit, 0)
+	if err != nil {
+		log.Printf("❌ [barcode] Lookup of %s failed: %v", barcode, err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result := services.BarcodeScanResult{
+		Barcode:  barcode,
+		Format:   format,
+		Products: convertSearchResults(rows),
+	}
+	if result.Products == nil {
+		result.Products = []services.SearchResult{}
+	}
+	h.applyProductImages(ctx, result.Products)
+
+	log.Printf("📷 [barcode] Scanned %s %s: %d products", format, barcode, len(result.Products))
+	message := "Barcode decoded"
+	if len(result.Products) == 0 {
+		message = "Barcode decoded, but no product has it"
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+		Message: message,
+	})
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+
+	"smlgoapi/middleware"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListDataRows godoc
+// @Summary Rows of a table
+// @Description Page through a table listed in data_api.tables. Filter with column=value or column[op]=value (op: eq, ne, gt, gte, lt, lte, like, ilike, in with a comma-separated list, null with true or false); conditions are combined with AND.
+// @Tags data
+// @Produce json
+// @Param table path string true "Table name, optionally schema-qualified"
+// @Param limit query int false "Rows per page (page_limits)"
+// @Param offset query int false "Rows to skip"
+// @Param sort query string false "Comma-separated columns, descending with a leading -, e.g. -create_date_time_now,code"
+// @Param fields query string false "Comma-separated columns to return (default: all)"
+// @Success 200 {object} models.APIResponse{data=models.DataPage}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /data/{table} [get]
+func (h *APIHandler) ListDataRows(c *gin.Context) {
+	if !h.requireDataAPI(c) {
+		return
+	}
+
+	query, err := services.ParseDataQuery(c.Request.URL.Query())
+	if err != nil {
+		h.dataError(c, err)
+		return
+	}
+	query.Limit = h.queryLimit(c)
+	query.Offset = queryIntBounded(c, "offset", 0, 0, math.MaxInt32)
+
+	table := c.Param("table")
+	rows, total, err := h.dataService.List(c.Request.Context(), table, query)
+	if err != nil {
+		h.dataError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: models.DataPage{
+			Table:  table,
+			Rows:   rows,
+			Total:  total,
+			Limit:  query.Limit,
+			Offset: query.Offset,
+		},
+		Message: fmt.Sprintf("%d of %d rows", len(rows), total),
+	})
+}
+
+// GetDataRow godoc
+// @Summary One row of a table
+// @Description The row whose primary key is id; tables with a composite or no primary key are read with filters on the list instead
+// @Tags data
+// @Produce json
+// @Param table path string true "Table name, optionally schema-qualified"
+// @Param id path string true "Primary key value"
+// @Success 200 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /data/{table}/{id} [get]
+func (h *APIHandler) GetDataRow(c *gin.Context) {
+	if !h.requireDataAPI(c) {
+		return
+	}
+
+	row, err := h.dataService.Get(c.Request.Context(), c.Param("table"), c.Param("id"))
+	if err != nil {
+		h.dataError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    row,
+	})
+}
+
+// CreateDataRow godoc
+// @Summary Insert a row
+// @Description Insert a JSON object of column values; omitted columns get their defaults. Returns the stored row.
+// @Tags data
+// @Accept json
+// @Produce json
+// @Param table path string true "Table name, optionally schema-qualified"
+// @Param row body object true "Column values"
+// @Success 201 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /data/{table} [post]
+func (h *APIHandler) CreateDataRow(c *gin.Context) {
+	values, ok := h.bindDataRow(c)
+	if !ok {
+		return
+	}
+
+	table := c.Param("table")
+	row, err := h.dataService.Create(c.Request.Context(), table, values)
+	if err != nil {
+		h.dataError(c, err)
+		return
+	}
+	h.dataWritten(c, "Inserted into", table, row)
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    row,
+		Message: "Row created",
+	})
+}
+
+// UpdateDataRow godoc
+// @Summary Update a row
+// @Description Set the columns given in the JSON object on the row whose primary key is id; other columns keep their values. Returns the updated row.
+// @Tags data
+// @Accept json
+// @Produce json
+// @Param table path string true "Table name, optionally schema-qualified"
+// @Param id path string true "Primary key value"
+// @Param row body object true "Column values"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /data/{table}/{id} [put]
+func (h *APIHandler) UpdateDataRow(c *gin.Context) {
+	values, ok := h.bindDataRow(c)
+	if !ok {
+		return
+	}
+
+	table := c.Param("table")
+	row, err := h.dataService.Update(c.Request.Context(), table, c.Param("id"), values)
+	if err != nil {
+		h.dataError(c, err)
+		return
+	}
+	h.dataWritten(c, "Updated", table, row)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    row,
+		Message: "Row updated",
+	})
+}
+
+// DeleteDataRow godoc
+// @Summary Delete a row
+// @Description Delete the row whose primary key is id and return it
+// @Tags data
+// @Produce json
+// @Param table path string true "Table name, optionally schema-qualified"
+// @Param id path string true "Primary key value"
+// @Success 200 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /data/{table}/{id} [delete]
+func (h *APIHandler) DeleteDataRow(c *gin.Context) {
+	if !h.requireDataAPI(c) {
+		return
+	}
+
+	table := c.Param("table")
+	row, err := h.dataService.Delete(c.Request.Context(), table, c.Param("id"))
+	if err != nil {
+		h.dataError(c, err)
+		return
+	}
+	h.dataWritten(c, "Deleted from", table, row)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    row,
+		Message: "Row deleted",
+	})
+}
+
+func (h *APIHandler) bindDataRow(c *gin.Context) (map[string]interface{}, bool) {
+	if !h.requireDataAPI(c) {
+		return nil, false
+	}
+	var values map[string]interface{}
+	if err := c.ShouldBindJSON(&values); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: the body must be a JSON object of column values: " + err.Error(),
+		})
+		return nil, false
+	}
+	return values, true
+}
+
+// dataWritten logs and audits a write and drops the cached SELECT results that read the table.
+// Writes to ic_inventory are announced to webhooks as product.updated.
+func (h *APIHandler) dataWritten(c *gin.Context, action, table string, row map[string]interface{}) {
+	by := shareCreator(c)
+	if by == "" {
+		by = "anonymous"
+	}
+	log.Printf("✏️ [data] %s %s by %s", action, table, by)
+	middleware.AuditRows(c, 1)
+	if removed := h.selectCache.Invalidate(services.ServicePostgreSQL, table); removed > 0 {
+		log.Printf("🧹 [data] Dropped %d cached SELECT results", removed)
+	}
+	if strings.TrimPrefix(strings.ToLower(table), "public.") == "ic_inventory" {
+		if code, ok := row[h.postgreSQLService.FieldColumn("code")]; ok {
+			h.productUpdated(fmt.Sprint(code), "data", map[string]interface{}{"action": strings.ToLower(strings.Fields(action)[0])})
+		}
+	}
+}
+
+func (h *APIHandler) dataError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrDataTableNotFound), errors.Is(err, services.ErrDataRowNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrDataReadOnly):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidDataRequest):
+		status = http.StatusBadRequest
+	default:
+		log.Printf("❌ [data] %s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
+
+func (h *APIHandler) requireDataAPI(c *gin.Context) bool {
+	if h.dataService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "The data API requires PostgreSQL, which is unavailable",
+		})
+		return false
+	}
+	return true
+}
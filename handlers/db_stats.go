@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDBStats godoc
+// @Summary Database connection pool statistics
+// @Description Pool settings and live connection counts of PostgreSQL and ClickHouse. A growing wait_count means max_open_conns is too low for the load.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.DBPoolStats}
+// @Router /admin/db-stats [get]
+func (h *APIHandler) GetDBStats(c *gin.Context) {
+	pools := []models.DBPoolStats{}
+	if h.postgreSQLService != nil {
+		pools = append(pools, services.NewDBPoolStats(services.ServicePostgreSQL, h.config.PostgreSQL.Pool, h.postgreSQLService.Stats()))
+	}
+	if h.clickHouseService != nil {
+		pools = append(pools, services.NewDBPoolStats(services.ServiceClickHouse, h.config.ClickHouse.Pool, h.clickHouseService.Stats()))
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    pools,
+	})
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Background export formats; parquet is only offered here since it suits files better than responses
+const exportFormatParquet = "parquet"
+
+// exportTablePattern accepts a table name, optionally schema-qualified
+var exportTablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// StartExport godoc
+// @Summary Start a background export
+// @Description Write every row of a table, or the result of a SELECT query, to a CSV, Parquet, NDJSON or XLSX file in the background. Poll GET /admin/export/{id} until the status is done, then download the file from its download_url.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.ExportRequest true "Table or query to export"
+// @Success 202 {object} models.APIResponse{data=models.ExportJob}
+// @Failure 400 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /admin/export [post]
+func (h *APIHandler) StartExport(c *gin.Context) {
+	if !h.requireExports(c) {
+		return
+	}
+
+	var req models.ExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	job, stream, params, status, err := h.prepareExport(req)
+	if err != nil {
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	job.CreatedBy = shareCreator(c)
+
+	job, err = h.exportJobService.Start(job, exportJobRunner(job.Format, job.Query, params, stream))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("📤 [export] Queued %s as %s: %s (%d params)", job.ID, job.Format, job.Query, len(params))
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    job,
+		Message: "Export queued",
+	})
+}
+
+// prepareExport validates an export request and picks the database to stream from. On error it
+// also returns the status to answer with.
+func (h *APIHandler) prepareExport(req models.ExportRequest) (models.ExportJob, rowStreamer, []interface{}, int, error) {
+	job := models.ExportJob{
+		Database: strings.ToLower(req.Database),
+		Table:    strings.TrimSpace(req.Table),
+		Query:    strings.TrimSpace(req.Query),
+		Format:   strings.ToLower(req.Format),
+	}
+	if job.Database == "" {
+		job.Database = services.ServicePostgreSQL
+	}
+	if job.Format == "" {
+		job.Format = selectFormatCSV
+	}
+	switch job.Format {
+	case selectFormatCSV, exportFormatParquet, selectFormatNDJSON, selectFormatXLSX:
+	default:
+		return job, nil, nil, http.StatusBadRequest, fmt.Errorf("unsupported format '%s': use csv, parquet, ndjson or xlsx", job.Format)
+	}
+	if name := exportFilenamePattern.ReplaceAllString(req.Filename, "_"); strings.Trim(name, "._") != "" {
+		job.Filename = strings.Trim(strings.TrimSuffix(name, "."+job.Format), "._") + "." + job.Format
+	}
+
+	switch {
+	case (job.Table == "") == (job.Query == ""):
+		return job, nil, nil, http.StatusBadRequest, fmt.Errorf("set either table or query")
+	case job.Table != "" && !exportTablePattern.MatchString(job.Table):
+		return job, nil, nil, http.StatusBadRequest, fmt.Errorf("invalid table name '%s'", job.Table)
+	case job.Table != "":
+		job.Query = "SELECT * FROM " + job.Table
+	}
+
+	var stream rowStreamer
+	var defaultSchema string
+	switch job.Database {
+	case services.ServicePostgreSQL:
+		if h.postgreSQLService == nil {
+			return job, nil, nil, http.StatusServiceUnavailable, fmt.Errorf("PostgreSQL is not connected")
+		}
+		stream, defaultSchema = h.postgreSQLService.StreamSelect, postgreSQLDefaultSchema
+	case services.ServiceClickHouse:
+		if h.clickHouseService == nil {
+			return job, nil, nil, http.StatusServiceUnavailable, fmt.Errorf("ClickHouse is not connected")
+		}
+		stream, defaultSchema = h.clickHouseService.StreamSelect, h.config.ClickHouse.Database
+	default:
+		return job, nil, nil, http.StatusBadRequest, fmt.Errorf("invalid database: %s (use %s or %s)",
+			job.Database, services.ServicePostgreSQL, services.ServiceClickHouse)
+	}
+
+	if err := h.checkSelectSQL(job.Database, job.Query, defaultSchema); err != nil {
+		log.Printf("🛡️ [export] Rejected by SQL policy: %v", err)
+		return job, nil, nil, http.StatusForbidden, err
+	}
+	params, err := services.NormalizeQueryParams(req.Params, job.Database == services.ServicePostgreSQL)
+	if err != nil {
+		return job, nil, nil, http.StatusBadRequest, fmt.Errorf("invalid params: %w", err)
+	}
+	return job, stream, params, 0, nil
+}
+
+// exportJobRunner streams a query into the file of an export job
+func exportJobRunner(format, query string, params []interface{}, stream rowStreamer) services.ExportFunc {
+	return func(ctx context.Context, w *bufio.Writer, progress func(rows int)) (int, error) {
+		var enc rowEncoder
+		switch format {
+		case selectFormatCSV:
+			enc = newCSVEncoder(w)
+		case exportFormatParquet:
+			enc = newParquetEncoder(w)
+		case selectFormatXLSX:
+			enc = newXLSXEncoder(w)
+		default:
+			enc = &ndjsonEncoder{w: w}
+		}
+
+		rowCount := 0
+		_, err := stream(ctx, query, func(columns []string, values []interface{}) error {
+			if err := enc.Row(columns, values); err != nil {
+				return err
+			}
+			rowCount++
+			if rowCount%exportFlushRows == 0 {
+				progress(rowCount)
+			}
+			return nil
+		}, params...)
+		if err != nil {
+			enc.Abort(err)
+			return rowCount, err
+		}
+		return rowCount, enc.Close()
+	}
+}
+
+// ListExports godoc
+// @Summary Background exports
+// @Description Exports of this instance, newest first. Finished exports are removed after export.retention_hours.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.ExportJob}
+// @Router /admin/export [get]
+func (h *APIHandler) ListExports(c *gin.Context) {
+	if !h.requireExports(c) {
+		return
+	}
+
+	exports := h.exportJobService.List()
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    exports,
+		Message: fmt.Sprintf("%d exports", len(exports)),
+	})
+}
+
+// GetExport godoc
+// @Summary Status of a background export
+// @Tags admin
+// @Produce json
+// @Param id path string true "Export id"
+// @Success 200 {object} models.APIResponse{data=models.ExportJob}
+// @Failure 404 {object} models.APIResponse
+// @Router /admin/export/{id} [get]
+func (h *APIHandler) GetExport(c *gin.Context) {
+	if !h.requireExports(c) {
+		return
+	}
+
+	job, err := h.exportJobService.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// DownloadExport godoc
+// @Summary Download a finished export
+// @Tags admin
+// @Produce text/csv,application/vnd.apache.parquet,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path string true "Export id"
+// @Success 200 {file} binary
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Router /admin/export/{id}/download [get]
+func (h *APIHandler) DownloadExport(c *gin.Context) {
+	if !h.requireExports(c) {
+		return
+	}
+
+	path, filename, err := h.exportJobService.File(c.Param("id"))
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, services.ErrExportNotReady) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// FileAttachment serves Range requests, so interrupted downloads of large files can resume
+	c.FileAttachment(path, filename)
+}
+
+// DeleteExport godoc
+// @Summary Cancel or remove a background export
+// @Description Cancels a queued or running export; removes a finished one together with its file
+// @Tags admin
+// @Produce json
+// @Param id path string true "Export id"
+// @Success 200 {object} models.APIResponse{data=models.ExportJob}
+// @Failure 404 {object} models.APIResponse
+// @Router /admin/export/{id} [delete]
+func (h *APIHandler) DeleteExport(c *gin.Context) {
+	if !h.requireExports(c) {
+		return
+	}
+
+	job, err := h.exportJobService.Delete(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	message := fmt.Sprintf("Export %s removed", job.ID)
+	if job.FinishedAt == nil {
+		message = fmt.Sprintf("Export %s is being cancelled", job.ID)
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    job,
+		Message: message,
+	})
+}
+
+// requireExports answers 503 when the export directory could not be prepared
+func (h *APIHandler) requireExports(c *gin.Context) bool {
+	if h.exportJobService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Background exports are unavailable; check export.dir in the logs",
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+)
+
+// graphQLGinKey holds the gin context in the context of a GraphQL request, for page limits
+type graphQLGinKey struct{}
+
+// GraphQL godoc
+// @Summary GraphQL API
+// @Description Product search, product detail with barcodes, price tiers and balances, and the Thai provinces, amphures and tambons in one schema. Fields are named as in the REST responses; GET /v1/graphql?sdl=1 returns the schema.
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param request body models.GraphQLRequest true "GraphQL query and variables"
+// @Success 200 {object} models.GraphQLResponse
+// @Router /graphql [post]
+// @Router /graphql [get]
+func (h *APIHandler) GraphQL(c *gin.Context) {
+	if c.Request.Method == http.MethodGet && c.Query("sdl") != "" {
+		c.String(http.StatusOK, graphQLSchemaSDL)
+		return
+	}
+
+	var req models.GraphQLRequest
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				c.JSON(http.StatusBadRequest, models.GraphQLResponse{
+					Errors: []models.GraphQLError{{Message: "variables must be a JSON object: " + err.Error()}},
+				})
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.GraphQLResponse{
+			Errors: []models.GraphQLError{{Message: "Invalid JSON body: " + err.Error()}},
+		})
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		c.JSON(http.StatusBadRequest, models.GraphQLResponse{
+			Errors: []models.GraphQLError{{Message: "query is required"}},
+		})
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.graphQLSchema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        context.WithValue(c.Request.Context(), graphQLGinKey{}, c),
+	})
+
+	response := models.GraphQLResponse{Data: result.Data}
+	for _, err := range result.Errors {
+		response.Errors = append(response.Errors, models.GraphQLError{Message: err.Message, Path: err.Path})
+	}
+	if len(response.Errors) > 0 {
+		log.Printf("⚠️ [graphql] %d errors, first: %s", len(response.Errors), response.Errors[0].Message)
+	}
+
+	// Errors of single fields still answer 200 with the rest of the data, as GraphQL clients expect
+	status := http.StatusOK
+	if result.Data == nil && len(response.Errors) > 0 {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, response)
+}
+
+// graphQLSchemaSDL documents the schema built by newGraphQLSchema
+const graphQLSchemaSDL = `type Query {
+  search_products(query: String!, limit: Int, offset: Int): ProductSearch
+  product(code: String!): Product
+  provinces: [Province!]!
+  amphures(province_id: Int!): [Amphure!]!
+  tambons(province_id: Int!, amphure_id: Int!): [Tambon!]!
+  find_by_zip_code(zip_code: Int!): [Location!]!
+}
+
+type ProductSearch { total: Int! products: [ProductHit!]! }
+
+type ProductHit {
+  code: String! name: String! unit_standard_code: String item_type: Int
+  sale_price: Float final_price: Float discount_price: Float discount_percent: Float
+  qty_available: Float sold_qty: Float has_image: Boolean barcodes: String rank_score: Float
+  detail: Product
+}
+
+type Product {
+  ic_code: String! matched_by: String! name: String inventory: JSON
+  barcodes: [Barcode!]! prices: [Price!]! balances: [Balance!]! total_balance: Float! images: [String!]!
+}
+
+type Barcode { barcode: String! unit_code: String }
+type Price { unit_code: String tiers: [Float!]! }
+type Balance { wh_code: String! balance_qty: Float! }
+
+type Province { id: Int! name_th: String! name_en: String! amphures: [Amphure!]! }
+type Amphure { id: Int! name_th: String! name_en: String! province_id: Int! tambons: [Tambon!]! }
+type Tambon { id: Int! name_th: String! name_en: String! amphure_id: Int! zip_code: Int }
+type Location { province: Province! amphure: Amphure! tambon: Tambon! }
+
+scalar JSON
+`
+
+// newGraphQLSchema builds the schema served by /v1/graphql on the same services as the REST
+// endpoints. Nested fields (a hit's detail, a province's amphures, an amphure's tambons) are only
+// loaded when the query asks for them.
+func (h *APIHandler) newGraphQLSchema() (graphql.Schema, error) {
+	jsonScalar := graphql.NewScalar(graphql.ScalarConfig{
+		Name:        "JSON",
+		Description: "Any JSON value",
+		Serialize:   func(value interface{}) interface{} { return value },
+	})
+
+	tambonType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tambon",
+		Fields: graphql.Fields{
+			"id":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name_th":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name_en":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"amphure_id": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"zip_code": &graphql.Field{
+				Type: graphql.Int,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if tambon := p.Source.(models.Tambon); tambon.ZipCode != 0 {
+						return tambon.ZipCode, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
+	amphureType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Amphure",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name_th":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name_en":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"province_id": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"tambons": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tambonType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					amphure := p.Source.(models.Amphure)
+					return h.tambonsWithAmphure(amphure.ProvinceID, amphure.ID)
+				},
+			},
+		},
+	})
+
+	provinceType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Province",
+		Fields: graphql.Fields{
+			"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name_th": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name_en": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"amphures": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(amphureType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return h.amphuresWithProvince(p.Source.(models.Province).ID)
+				},
+			},
+		},
+	})
+
+	locationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Location",
+		Fields: graphql.Fields{
+			"province": &graphql.Field{Type: graphql.NewNonNull(provinceType)},
+			"amphure":  &graphql.Field{Type: graphql.NewNonNull(amphureType)},
+			"tambon":   &graphql.Field{Type: graphql.NewNonNull(tambonType)},
+		},
+	})
+
+	barcodeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Barcode",
+		Fields: graphql.Fields{
+			"barcode":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"unit_code": &graphql.Field{Type: graphql.String},
+		},
+	})
+	priceType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Price",
+		Fields: graphql.Fields{
+			"unit_code": &graphql.Field{Type: graphql.String},
+			"tiers":     &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Float)))},
+		},
+	})
+	balanceType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Balance",
+		Fields: graphql.Fields{
+			"wh_code":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"balance_qty": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		},
+	})
+
+	productType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Product",
+		Fields: graphql.Fields{
+			"ic_code":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"matched_by": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*models.ProductDetail).Inventory[h.postgreSQLService.FieldColumn(services.FieldName)], nil
+				},
+			},
+			"inventory":     &graphql.Field{Type: jsonScalar, Description: "Every ic_inventory column"},
+			"barcodes":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(barcodeType)))},
+			"prices":        &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(priceType)))},
+			"balances":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(balanceType)))},
+			"total_balance": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"images":        &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+		},
+	})
+
+	productHitType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductHit",
+		Fields: graphql.Fields{
+			"code":               &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name":               &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"unit_standard_code": &graphql.Field{Type: graphql.String},
+			"item_type":          &graphql.Field{Type: graphql.Int},
+			"sale_price":         &graphql.Field{Type: graphql.Float},
+			"final_price":        &graphql.Field{Type: graphql.Float},
+			"discount_price":     &graphql.Field{Type: graphql.Float},
+			"discount_percent":   &graphql.Field{Type: graphql.Float},
+			"qty_available":      &graphql.Field{Type: graphql.Float},
+			"sold_qty":           &graphql.Field{Type: graphql.Float},
+			"has_image":          &graphql.Field{Type: graphql.Boolean},
+			"barcodes":           &graphql.Field{Type: graphql.String},
+			"rank_score":         &graphql.Field{Type: graphql.Float},
+			"detail": &graphql.Field{
+				Type: productType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					code, _ := p.Source.(map[string]interface{})["code"].(string)
+					return h.graphQLProduct(p.Context, code)
+				},
+			},
+		},
+	})
+
+	productSearchType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductSearch",
+		Fields: graphql.Fields{
+			"total":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"products": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(productHitType)))},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"search_products": &graphql.Field{
+				Type:        productSearchType,
+				Description: "The PostgreSQL text search step of /v1/search-by-vector, without the vector lookup",
+				Args: graphql.FieldConfigArgument{
+					"query":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: h.graphQLSearchProducts,
+			},
+			"product": &graphql.Field{
+				Type:        productType,
+				Description: "A product by ic_code or barcode, null when none matches",
+				Args: graphql.FieldConfigArgument{
+					"code": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return h.graphQLProduct(p.Context, strings.TrimSpace(p.Args["code"].(string)))
+				},
+			},
+			"provinces": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(provinceType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return h.thaiAdminService.GetProvinces()
+				},
+			},
+			"amphures": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(amphureType))),
+				Args: graphql.FieldConfigArgument{
+					"province_id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return h.amphuresWithProvince(p.Args["province_id"].(int))
+				},
+			},
+			"tambons": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tambonType))),
+				Args: graphql.FieldConfigArgument{
+					"province_id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"amphure_id":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return h.tambonsWithAmphure(p.Args["province_id"].(int), p.Args["amphure_id"].(int))
+				},
+			},
+			"find_by_zip_code": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(locationType))),
+				Args: graphql.FieldConfigArgument{
+					"zip_code": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					locations, err := h.thaiAdminService.FindByZipCode(p.Args["zip_code"].(int))
+					for i := range locations {
+						locations[i].Amphure.ProvinceID = locations[i].Province.ID
+						locations[i].Tambon.AmphureID = locations[i].Amphure.ID
+					}
+					return locations, err
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+func (h *APIHandler) graphQLSearchProducts(p graphql.ResolveParams) (interface{}, error) {
+	if h.postgreSQLService == nil {
+		return nil, errors.New("product search requires PostgreSQL, which is unavailable")
+	}
+	query := strings.TrimSpace(p.Args["query"].(string))
+	if query == "" {
+		return nil, errors.New("query must not be empty")
+	}
+
+	// Default and maximum page size come from page_limits, as on the REST search
+	requested, _ := p.Args["limit"].(int)
+	limit := requested
+	if c, ok := p.Context.Value(graphQLGinKey{}).(*gin.Context); ok {
+		limit = h.clampLimit(c, requested)
+	}
+	offset, _ := p.Args["offset"].(int)
+	if offset < 0 {
+		offset = 0
+	}
+
+	products, total, err := h.postgreSQLService.SearchProducts(p.Context, query, limit, offset)
+	if err != nil {
+		log.Printf("❌ [graphql] Search for '%s' failed: %v", query, err)
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	if products == nil {
+		products = []map[string]interface{}{}
+	}
+	return map[string]interface{}{"total": total, "products": products}, nil
+}
+
+// graphQLProduct resolves to null rather than an error for codes no product has
+func (h *APIHandler) graphQLProduct(ctx context.Context, code string) (interface{}, error) {
+	if h.postgreSQLService == nil {
+		return nil, errors.New("product detail requires PostgreSQL, which is unavailable")
+	}
+	detail, err := h.productDetail(ctx, code)
+	if errors.Is(err, services.ErrProductNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// amphuresWithProvince fills in province_id, which the REST listing leaves out, for GraphQL nested
+// tambons and gRPC
+func (h *APIHandler) amphuresWithProvince(provinceID int) ([]models.Amphure, error) {
+	amphures, err := h.thaiAdminService.GetAmphuresByProvinceID(provinceID)
+	for i := range amphures {
+		amphures[i].ProvinceID = provinceID
+	}
+	if amphures == nil {
+		amphures = []models.Amphure{}
+	}
+	return amphures, err
+}
+
+func (h *APIHandler) tambonsWithAmphure(provinceID, amphureID int) ([]models.Tambon, error) {
+	tambons, err := h.thaiAdminService.GetTambonsByAmphureAndProvince(amphureID, provinceID)
+	for i := range tambons {
+		tambons[i].AmphureID = amphureID
+	}
+	if tambons == nil {
+		tambons = []models.Tambon{}
+	}
+	return tambons, err
+}
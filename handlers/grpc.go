@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServiceName is the full name of service SmlGoAPI in proto/smlgoapi.proto
+const grpcServiceName = "smlgoapi.SmlGoAPI"
+
+// grpcLimitRoleKey holds the page_limits role of an authenticated gRPC caller
+type grpcLimitRoleKey struct{}
+
+// grpcMethod is one unary method of the SmlGoAPI service
+type grpcMethod struct {
+	name       string
+	sql        bool // raw SQL, protected like /v1/pgselect instead of like the viewer endpoints
+	newRequest func() grpcRequest
+	call       func(h *APIHandler, ctx context.Context, req grpcRequest) (protoMessage, error)
+}
+
+var grpcMethods = []grpcMethod{
+	{
+		name:       "SearchProducts",
+		newRequest: func() grpcRequest { return &grpcSearchRequest{} },
+		call: func(h *APIHandler, ctx context.Context, req grpcRequest) (protoMessage, error) {
+			return h.grpcSearchProducts(ctx, req.(*grpcSearchRequest))
+		},
+	},
+	{
+		name:       "GetProduct",
+		newRequest: func() grpcRequest { return &grpcProductRequest{} },
+		call: func(h *APIHandler, ctx context.Context, req grpcRequest) (protoMessage, error) {
+			return h.grpcGetProduct(ctx, req.(*grpcProductRequest))
+		},
+	},
+	{
+		name:       "ExecuteSelect",
+		sql:        true,
+		newRequest: func() grpcRequest { return &grpcSelectRequest{} },
+		call: func(h *APIHandler, ctx context.Context, req grpcRequest) (protoMessage, error) {
+			return h.grpcExecuteSelect(ctx, req.(*grpcSelectRequest))
+		},
+	},
+	{
+		name:       "ListProvinces",
+		newRequest: func() grpcRequest { return &grpcProvincesRequest{} },
+		call: func(h *APIHandler, ctx context.Context, req grpcRequest) (protoMessage, error) {
+			provinces, err := h.thaiAdminService.GetProvinces()
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to load provinces: %v", err)
+			}
+			return grpcProvinces(provinces), nil
+		},
+	},
+	{
+		name:       "ListAmphures",
+		newRequest: func() grpcRequest { return &grpcAmphuresRequest{} },
+		call: func(h *APIHandler, ctx context.Context, req grpcRequest) (protoMessage, error) {
+			amphures, err := h.amphuresWithProvince(req.(*grpcAmphuresRequest).ProvinceID)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to load amphures: %v", err)
+			}
+			return grpcAmphures(amphures), nil
+		},
+	},
+	{
+		name:       "ListTambons",
+		newRequest: func() grpcRequest { return &grpcTambonsRequest{} },
+		call: func(h *APIHandler, ctx context.Context, req grpcRequest) (protoMessage, error) {
+			r := req.(*grpcTambonsRequest)
+			tambons, err := h.tambonsWithAmphure(r.ProvinceID, r.AmphureID)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to load tambons: %v", err)
+			}
+			return grpcTambons(tambons), nil
+		},
+	},
+	{
+		name:       "FindByZipCode",
+		newRequest: func() grpcRequest { return &grpcZipCodeRequest{} },
+		call: func(h *APIHandler, ctx context.Context, req grpcRequest) (protoMessage, error) {
+			locations, err := h.thaiAdminService.FindByZipCode(req.(*grpcZipCodeRequest).ZipCode)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to find locations: %v", err)
+			}
+			return grpcLocations(locations), nil
+		},
+	},
+}
+
+// NewGRPCServer returns a gRPC server for the SmlGoAPI service of proto/smlgoapi.proto, calling
+// the same services as the HTTP endpoints. Access follows the HTTP routes: lookups are open unless
+// JWT sessions are enabled, ExecuteSelect needs a read API key or admin session when any auth is.
+func (h *APIHandler) NewGRPCServer() *grpc.Server {
+	desc := grpc.ServiceDesc{
+		ServiceName: grpcServiceName,
+		HandlerType: (*interface{})(nil),
+		Metadata:    "proto/smlgoapi.proto",
+	}
+	for _, method := range grpcMethods {
+		desc.Methods = append(desc.Methods, method.desc())
+	}
+
+	server := grpc.NewServer(
+		grpc.ForceServerCodec(grpcCodec{}),
+		grpc.UnaryInterceptor(grpcLogging),
+	)
+	server.RegisterService(&desc, h)
+	return server
+}
+
+func (m grpcMethod) desc() grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: m.name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := m.newRequest()
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			h := srv.(*APIHandler)
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				ctx, err := h.grpcAuthorize(ctx, m)
+				if err != nil {
+					return nil, err
+				}
+				return m.call(h, ctx, req.(grpcRequest))
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + grpcServiceName + "/" + m.name}
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
+
+// grpcLogging logs every call and turns panics into Internal errors, as gin.Recovery does for HTTP
+func grpcLogging(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ [grpc] %s panicked: %v\n%s", info.FullMethod, r, debug.Stack())
+			resp, err = nil, status.Error(codes.Internal, "internal error")
+		}
+		log.Printf("📡 [grpc] %s %s in %.2fms", info.FullMethod, status.Code(err), float64(time.Since(start).Nanoseconds())/1e6)
+	}()
+	return handler(ctx, req)
+}
+
+// grpcAuthorize checks the credentials in the call metadata with the rules of the viewer and
+// sqlRead route groups, and records the caller's page_limits role in the context
+func (h *APIHandler) grpcAuthorize(ctx context.Context, m grpcMethod) (context.Context, error) {
+	enforce, minRole := h.config.JWT.Enabled, models.RoleViewer
+	if m.sql {
+		enforce, minRole = h.config.Auth.Enabled || h.config.JWT.Enabled, models.RoleAdmin
+	}
+	if !enforce {
+		return ctx, nil
+	}
+
+	role, err := h.authorizeCredential(ctx, grpcCredential(ctx), models.ScopeRead, minRole)
+	if errors.Is(err, errForbidden) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error()+"; send 'authorization: Bearer <token or API key>' metadata")
+	}
+	return context.WithValue(ctx, grpcLimitRoleKey{}, role), nil
+}
+
+// grpcCredential reads the "x-api-key" metadata, or "authorization" in the Bearer or ApiKey form
+func grpcCredential(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("x-api-key"); len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+		return strings.TrimSpace(values[0])
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ""
+	}
+	scheme, value, found := strings.Cut(strings.TrimSpace(values[0]), " ")
+	if !found || (!strings.EqualFold(scheme, "Bearer") && !strings.EqualFold(scheme, "ApiKey")) {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+func (h *APIHandler) grpcSearchProducts(ctx context.Context, req *grpcSearchRequest) (protoMessage, error) {
+	if h.postgreSQLService == nil {
+		return nil, status.Error(codes.Unavailable, "product search requires PostgreSQL, which is unavailable")
+	}
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, status.Error(codes.InvalidArgument, "query must not be empty")
+	}
+
+	role, _ := ctx.Value(grpcLimitRoleKey{}).(string)
+	limit := clampToPageLimit(h.config.PageLimits.Resolve(searchLimitRoute, role), req.Limit)
+	offset := max(req.Offset, 0)
+
+	start := time.Now()
+	products, total, err := h.postgreSQLService.SearchProducts(ctx, query, limit, offset)
+	if err != nil {
+		log.Printf("❌ [grpc] Search for '%s' failed: %v", query, err)
+		return nil, grpcError(err, "search failed")
+	}
+
+	results := &services.VectorSearchResponse{
+		Data:     convertSearchResults(products),
+		Query:    query,
+		Duration: float64(time.Since(start).Nanoseconds()) / 1e6,
+	}
+	services.NewSearchCounts(offset, len(products), total, total).Apply(results)
+	return results, nil
+}
+
+func (h *APIHandler) grpcGetProduct(ctx context.Context, req *grpcProductRequest) (protoMessage, error) {
+	if h.postgreSQLService == nil {
+		return nil, status.Error(codes.Unavailable, "product detail requires PostgreSQL, which is unavailable")
+	}
+	code := strings.TrimSpace(req.Code)
+	if code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code must not be empty")
+	}
+
+	detail, err := h.productDetail(ctx, code)
+	if errors.Is(err, services.ErrProductNotFound) {
+		return nil, status.Errorf(codes.NotFound, "no product with ic_code or barcode '%s'", code)
+	}
+	if err != nil {
+		log.Printf("❌ [grpc] Failed to load detail for %s: %v", code, err)
+		return nil, grpcError(err, "failed to load product")
+	}
+	return grpcProduct{detail}, nil
+}
+
+func (h *APIHandler) grpcExecuteSelect(ctx context.Context, req *grpcSelectRequest) (protoMessage, error) {
+	var stream rowStreamer
+	database, defaultSchema := services.ServicePostgreSQL, postgreSQLDefaultSchema
+	switch strings.ToLower(strings.TrimSpace(req.Database)) {
+	case "", services.ServicePostgreSQL:
+		if h.postgreSQLService == nil {
+			return nil, status.Error(codes.Unavailable, "PostgreSQL is unavailable")
+		}
+		stream = h.postgreSQLService.StreamSelect
+	case services.ServiceClickHouse:
+		if h.clickHouseService == nil {
+			return nil, status.Error(codes.Unavailable, "ClickHouse is unavailable")
+		}
+		stream = h.clickHouseService.StreamSelect
+		database, defaultSchema = services.ServiceClickHouse, h.config.ClickHouse.Database
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "database must be %s or %s", services.ServicePostgreSQL, services.ServiceClickHouse)
+	}
+
+	if err := h.checkSelectSQL(database, req.Query, defaultSchema); err != nil {
+		log.Printf("🛡️ [grpc] Rejected by SQL policy: %v", err)
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	page, err := h.newSelectPage(database, models.SelectRequest{Query: req.Query}, selectFormatJSON)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	ctx, _, cancel, err := h.queryTimeoutContext(ctx, "/"+grpcServiceName+"/ExecuteSelect", database, req.Query, "", 0)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	defer cancel()
+
+	start := time.Now()
+	// A larger result stops at max_rows or max_response_bytes, as on /pgselect
+	err = page.run(ctx, stream, req.Params)
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	if err != nil {
+		switch cause := context.Cause(ctx); {
+		case errors.Is(cause, services.ErrQueryCancelled):
+			return nil, status.Error(codes.Aborted, cause.Error())
+		case errors.Is(cause, services.ErrShuttingDown):
+			return nil, status.Error(codes.Unavailable, cause.Error())
+		}
+		log.Printf("❌ [grpc] Query failed: %v", err)
+		return nil, grpcError(err, "query execution failed")
+	}
+
+	log.Printf("✅ [grpc] Query successful: %d rows returned in %.2fms (truncated: %t)", len(page.rows.Rows), duration, page.rows.Truncated)
+	return grpcSelectResult{Rows: page.rows.Rows, Duration: duration, Truncated: page.rows.Truncated}, nil
+}
+
+// grpcError reports a cancelled or timed out call with its context status, anything else as Internal
+func grpcError(err error, message string) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, fmt.Sprintf("%s: %v", message, err))
+}
@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"smlgoapi/models"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Messages of the gRPC service in proto/smlgoapi.proto. Like the search messages in
+// services/search_proto.go they are written and read by hand with protowire, so the build does
+// not need protoc; keep the field numbers in sync with the .proto file.
+
+// grpcCodec replaces the generated-code codec of grpc-go: responses are protoMessage values and
+// requests decode themselves with unmarshalProto
+type grpcCodec struct{}
+
+// grpcRequest is a request message of the gRPC service
+type grpcRequest interface {
+	unmarshalProto(b []byte) error
+}
+
+func (grpcCodec) Name() string {
+	return "proto"
+}
+
+func (grpcCodec) Marshal(v interface{}) ([]byte, error) {
+	message, ok := v.(protoMessage)
+	if !ok {
+		return nil, fmt.Errorf("cannot encode %T as protobuf", v)
+	}
+	return message.AppendProto(nil), nil
+}
+
+func (grpcCodec) Unmarshal(data []byte, v interface{}) error {
+	request, ok := v.(grpcRequest)
+	if !ok {
+		return fmt.Errorf("cannot decode protobuf into %T", v)
+	}
+	return request.unmarshalProto(data)
+}
+
+// protoValue is one decoded field
+type protoValue struct {
+	typ    protowire.Type
+	number uint64 // varint and fixed64 fields
+	bytes  []byte // length-delimited fields
+}
+
+func (v protoValue) int() int {
+	if v.typ != protowire.VarintType {
+		return 0
+	}
+	return int(int64(v.number))
+}
+
+func (v protoValue) double() float64 {
+	if v.typ != protowire.Fixed64Type {
+		return 0
+	}
+	return math.Float64frombits(v.number)
+}
+
+func (v protoValue) string() string {
+	return string(v.bytes)
+}
+
+// readProto calls field for every field of a message. Fields of other wire types (e.g. fixed32)
+// are skipped, as are unknown field numbers in field itself.
+func readProto(b []byte, field func(num protowire.Number, v protoValue) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		v := protoValue{typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			v.number, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			v.number, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			v.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ == protowire.VarintType || typ == protowire.Fixed64Type || typ == protowire.BytesType {
+			if err := field(num, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// grpcSearchRequest is SearchProductsRequest
+type grpcSearchRequest struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+func (r *grpcSearchRequest) unmarshalProto(b []byte) error {
+	return readProto(b, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			r.Query = v.string()
+		case 2:
+			r.Limit = v.int()
+		case 3:
+			r.Offset = v.int()
+		}
+		return nil
+	})
+}
+
+// grpcProductRequest is GetProductRequest
+type grpcProductRequest struct {
+	Code string
+}
+
+func (r *grpcProductRequest) unmarshalProto(b []byte) error {
+	return readProto(b, func(num protowire.Number, v protoValue) error {
+		if num == 1 {
+			r.Code = v.string()
+		}
+		return nil
+	})
+}
+
+// grpcSelectRequest is ExecuteSelectRequest; Params hold the decoded Value messages
+type grpcSelectRequest struct {
+	Database string
+	Query    string
+	Params   []interface{}
+}
+
+func (r *grpcSelectRequest) unmarshalProto(b []byte) error {
+	return readProto(b, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			r.Database = v.string()
+		case 2:
+			r.Query = v.string()
+		case 3:
+			param, err := readProtoScalar(v.bytes)
+			if err != nil {
+				return fmt.Errorf("params[%d]: %w", len(r.Params), err)
+			}
+			r.Params = append(r.Params, param)
+		}
+		return nil
+	})
+}
+
+// readProtoScalar decodes a Value message: string, float64, int64, bool or nil
+func readProtoScalar(b []byte) (interface{}, error) {
+	var value interface{}
+	err := readProto(b, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			value = v.string()
+		case 2:
+			value = v.double()
+		case 3:
+			value = int64(v.number)
+		case 4:
+			value = v.number != 0
+		}
+		return nil
+	})
+	return value, err
+}
+
+// grpcProvincesRequest is ListProvincesRequest, which has no fields
+type grpcProvincesRequest struct{}
+
+func (r *grpcProvincesRequest) unmarshalProto(b []byte) error {
+	return readProto(b, func(protowire.Number, protoValue) error { return nil })
+}
+
+// grpcAmphuresRequest is ListAmphuresRequest
+type grpcAmphuresRequest struct {
+	ProvinceID int
+}
+
+func (r *grpcAmphuresRequest) unmarshalProto(b []byte) error {
+	return readProto(b, func(num protowire.Number, v protoValue) error {
+		if num == 1 {
+			r.ProvinceID = v.int()
+		}
+		return nil
+	})
+}
+
+// grpcTambonsRequest is ListTambonsRequest
+type grpcTambonsRequest struct {
+	ProvinceID int
+	AmphureID  int
+}
+
+func (r *grpcTambonsRequest) unmarshalProto(b []byte) error {
+	return readProto(b, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			r.ProvinceID = v.int()
+		case 2:
+			r.AmphureID = v.int()
+		}
+		return nil
+	})
+}
+
+// grpcZipCodeRequest is FindByZipCodeRequest
+type grpcZipCodeRequest struct {
+	ZipCode int
+}
+
+func (r *grpcZipCodeRequest) unmarshalProto(b []byte) error {
+	return readProto(b, func(num protowire.Number, v protoValue) error {
+		if num == 1 {
+			r.ZipCode = v.int()
+		}
+		return nil
+	})
+}
+
+// grpcProduct is the Product message
+type grpcProduct struct {
+	*models.ProductDetail
+}
+
+func (p grpcProduct) AppendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, p.ICCode)
+	b = appendProtoString(b, 2, p.MatchedBy)
+	b = appendProtoValueMap(b, 3, p.Inventory)
+	for _, barcode := range p.Barcodes {
+		var m []byte
+		m = appendProtoString(m, 1, barcode.Barcode)
+		m = appendProtoString(m, 2, barcode.UnitCode)
+		b = appendProtoMessage(b, 4, m)
+	}
+	for _, price := range p.Prices {
+		var m []byte
+		m = appendProtoString(m, 1, price.UnitCode)
+		if len(price.Tiers) > 0 {
+			var tiers []byte
+			for _, tier := range price.Tiers {
+				tiers = protowire.AppendFixed64(tiers, math.Float64bits(tier))
+			}
+			m = appendProtoMessage(m, 2, tiers) // packed repeated double
+		}
+		b = appendProtoMessage(b, 5, m)
+	}
+	for _, balance := range p.Balances {
+		var m []byte
+		m = appendProtoString(m, 1, balance.WarehouseCode)
+		m = appendProtoDouble(m, 2, balance.Quantity)
+		b = appendProtoMessage(b, 6, m)
+	}
+	b = appendProtoDouble(b, 7, p.TotalBalance)
+	for _, image := range p.Images {
+		b = protowire.AppendTag(b, 8, protowire.BytesType)
+		b = protowire.AppendString(b, image)
+	}
+	return b
+}
+
+// grpcSelectResult is ExecuteSelectResponse
+type grpcSelectResult struct {
+	Rows      []interface{}
+	Duration  float64
+	Truncated bool
+}
+
+func (r grpcSelectResult) AppendProto(b []byte) []byte {
+	for _, row := range r.Rows {
+		columns, _ := row.(map[string]interface{})
+		b = appendProtoMessage(b, 1, appendProtoValueMap(nil, 1, columns))
+	}
+	b = appendProtoInt(b, 2, len(r.Rows))
+	b = appendProtoDouble(b, 3, r.Duration)
+	b = appendProtoBool(b, 4, r.Truncated)
+	return b
+}
+
+// grpcProvinces is ProvinceList
+type grpcProvinces []models.Province
+
+func (l grpcProvinces) AppendProto(b []byte) []byte {
+	for i := range l {
+		b = appendProtoMessage(b, 1, appendProvinceProto(nil, &l[i]))
+	}
+	return b
+}
+
+// grpcAmphures is AmphureList
+type grpcAmphures []models.Amphure
+
+func (l grpcAmphures) AppendProto(b []byte) []byte {
+	for i := range l {
+		b = appendProtoMessage(b, 1, appendAmphureProto(nil, &l[i]))
+	}
+	return b
+}
+
+// grpcTambons is TambonList
+type grpcTambons []models.Tambon
+
+func (l grpcTambons) AppendProto(b []byte) []byte {
+	for i := range l {
+		b = appendProtoMessage(b, 1, appendTambonProto(nil, &l[i]))
+	}
+	return b
+}
+
+// grpcLocations is LocationList
+type grpcLocations []models.CompleteLocationData
+
+func (l grpcLocations) AppendProto(b []byte) []byte {
+	for i := range l {
+		var m []byte
+		m = appendProtoMessage(m, 1, appendProvinceProto(nil, &l[i].Province))
+		m = appendProtoMessage(m, 2, appendAmphureProto(nil, &l[i].Amphure))
+		m = appendProtoMessage(m, 3, appendTambonProto(nil, &l[i].Tambon))
+		b = appendProtoMessage(b, 1, m)
+	}
+	return b
+}
+
+func appendProvinceProto(b []byte, p *models.Province) []byte {
+	b = appendProtoInt(b, 1, p.ID)
+	b = appendProtoString(b, 2, p.NameTh)
+	b = appendProtoString(b, 3, p.NameEn)
+	return b
+}
+
+func appendAmphureProto(b []byte, a *models.Amphure) []byte {
+	b = appendProtoInt(b, 1, a.ID)
+	b = appendProtoString(b, 2, a.NameTh)
+	b = appendProtoString(b, 3, a.NameEn)
+	b = appendProtoInt(b, 4, a.ProvinceID)
+	return b
+}
+
+func appendTambonProto(b []byte, t *models.Tambon) []byte {
+	b = appendProtoInt(b, 1, t.ID)
+	b = appendProtoString(b, 2, t.NameTh)
+	b = appendProtoString(b, 3, t.NameEn)
+	b = appendProtoInt(b, 4, t.AmphureID)
+	b = appendProtoInt(b, 5, t.ZipCode)
+	return b
+}
+
+// appendProtoValueMap appends a map<string, Value> field, entries in key order so equal rows
+// encode identically
+func appendProtoValueMap(b []byte, num protowire.Number, values map[string]interface{}) []byte {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var entry []byte
+		entry = appendProtoString(entry, 1, key)
+		entry = appendProtoMessage(entry, 2, appendProtoScalar(nil, values[key]))
+		b = appendProtoMessage(b, num, entry)
+	}
+	return b
+}
+
+// appendProtoScalar writes the fields of a Value message. Oneof fields are written even when
+// zero, so 0 and "" stay distinguishable from NULL.
+func appendProtoScalar(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return b
+	case string:
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		return protowire.AppendString(b, v)
+	case []byte:
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		return protowire.AppendBytes(b, v)
+	case float64:
+		b = protowire.AppendTag(b, 2, protowire.Fixed64Type)
+		return protowire.AppendFixed64(b, math.Float64bits(v))
+	case float32:
+		return appendProtoScalar(b, float64(v))
+	case int64:
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		return protowire.AppendVarint(b, uint64(v))
+	case int:
+		return appendProtoScalar(b, int64(v))
+	case int32:
+		return appendProtoScalar(b, int64(v))
+	case int16:
+		return appendProtoScalar(b, int64(v))
+	case int8:
+		return appendProtoScalar(b, int64(v))
+	case uint32:
+		return appendProtoScalar(b, int64(v))
+	case uint16:
+		return appendProtoScalar(b, int64(v))
+	case uint8:
+		return appendProtoScalar(b, int64(v))
+	case bool:
+		b = protowire.AppendTag(b, 4, protowire.VarintType)
+		return protowire.AppendVarint(b, protowire.EncodeBool(v))
+	case time.Time:
+		return appendProtoScalar(b, v.Format(time.RFC3339Nano))
+	}
+
+	// Arrays, objects, decimals and 64-bit unsigned values travel as their JSON text
+	text, err := json.Marshal(v)
+	if err != nil {
+		return appendProtoScalar(b, fmt.Sprint(v))
+	}
+	return appendProtoScalar(b, string(text))
+}
+
+func appendProtoString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendProtoDouble(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+func appendProtoInt(b []byte, num protowire.Number, v int) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(int64(v)))
+}
+
+func appendProtoBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, protowire.EncodeBool(v))
+}
+
+// appendProtoMessage appends an embedded message; an empty one is still written so repeated
+// fields keep their length
+func appendProtoMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// processStarted is reported as the uptime of the liveness probe
+var processStarted = time.Now()
+
+// HealthLive godoc
+// @Summary Liveness probe
+// @Description Answers 200 as long as the process serves HTTP; no dependency is contacted, so a database outage never restarts the container
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.LivenessResponse
+// @Router /health/live [get]
+func (h *APIHandler) HealthLive(c *gin.Context) {
+	c.JSON(http.StatusOK, models.LivenessResponse{
+		Status:        "alive",
+		Timestamp:     time.Now(),
+		UptimeSeconds: time.Since(processStarted).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+	})
+}
+
+// HealthReady godoc
+// @Summary Readiness probe
+// @Description Checks each dependency (PostgreSQL, ClickHouse, Weaviate, Thai administrative data, image cache) with its latency.
+// @Description Optional dependencies that are slow or down make the service "degraded" but still ready (HTTP 200);
+// @Description only a critical dependency (PostgreSQL) being down answers 503 so the instance is taken out of rotation.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.ReadinessResponse
+// @Failure 503 {object} models.ReadinessResponse
+// @Router /health/ready [get]
+func (h *APIHandler) HealthReady(c *gin.Context) {
+	var chVersion, pgVersion string
+	dependencies := h.checkDependencies(c.Request.Context(), &chVersion, &pgVersion)
+	status := services.OverallHealthStatus(dependencies)
+
+	response := models.ReadinessResponse{
+		Status:       status,
+		Ready:        status != models.HealthStatusUnhealthy,
+		Timestamp:    time.Now(),
+		Dependencies: dependencies,
+	}
+	httpStatus := http.StatusOK
+	if !response.Ready {
+		httpStatus = http.StatusServiceUnavailable
+	}
+	c.JSON(httpStatus, response)
+}
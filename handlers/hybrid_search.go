@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"smlgoapi/middleware"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxHybridCandidates caps how many results are taken from each source
+const maxHybridCandidates = 1000
+
+// HybridSearch godoc
+// @Summary Hybrid product search with reciprocal rank fusion
+// @Description Runs Weaviate BM25, the TF-IDF index and PostgreSQL ILIKE in parallel and merges their rankings with reciprocal rank fusion. Each result lists its rank and score per source.
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param search body models.HybridSearchRequest true "Hybrid search parameters"
+// @Success 200 {object} models.APIResponse{data=services.HybridSearchResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 502 {object} models.APIResponse{data=services.HybridSearchResponse}
+// @Failure 503 {object} models.APIResponse
+// @Router /search/hybrid [post]
+func (h *APIHandler) HybridSearch(c *gin.Context) {
+	startTime := time.Now()
+
+	var req models.HybridSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid JSON format: " + err.Error(),
+		})
+		return
+	}
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Query parameter is required",
+		})
+		return
+	}
+
+	sources := req.Sources
+	if len(sources) == 0 {
+		sources = services.HybridSources
+	}
+	weights := make(map[string]float64, len(sources))
+	for _, source := range sources {
+		if !services.IsValidHybridSource(source) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: fmt.Sprintf("Unknown source '%s': supported sources are %s", source, strings.Join(services.HybridSources, ", ")),
+			})
+			return
+		}
+		weights[source] = 1
+	}
+	for source, weight := range req.Weights {
+		if !services.IsValidHybridSource(source) || weight < 0 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: fmt.Sprintf("Invalid weight for '%s': weights must be >= 0 and keyed by source", source),
+			})
+			return
+		}
+		if _, requested := weights[source]; requested {
+			weights[source] = weight
+		}
+	}
+
+	// Product details always come from PostgreSQL
+	if h.postgreSQLService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "PostgreSQL service not initialized",
+		})
+		return
+	}
+
+	limit := h.clampLimit(c, req.Limit)
+	k := req.K
+	if k <= 0 {
+		k = services.DefaultRRFK
+	}
+	candidates := req.Candidates
+	if candidates <= 0 {
+		candidates = max(limit*2, 50)
+	}
+	candidates = min(max(candidates, limit), maxHybridCandidates)
+
+	rankers := make(map[string]services.HybridRanker, len(weights))
+	for source := range weights {
+		rankers[source] = h.hybridRanker(source)
+	}
+
+	ctx := c.Request.Context()
+	lists, statuses := services.RunHybridSources(ctx, req.Query, candidates, rankers, weights)
+	for _, status := range statuses {
+		if status.Error != "" {
+			middleware.MarkDegraded(c, hybridSourceServices[status.Source])
+		}
+	}
+
+	response := &services.HybridSearchResponse{
+		Data:    []services.HybridSearchResult{},
+		Query:   req.Query,
+		K:       k,
+		Sources: statuses,
+		Partial: len(lists) < len(statuses),
+	}
+	if len(lists) == 0 {
+		log.Printf("❌ [HYBRID-SEARCH] every source failed for '%s'", req.Query)
+		response.Duration = time.Since(startTime).Seconds() * 1000
+		c.JSON(http.StatusBadGateway, models.APIResponse{
+			Success:          false,
+			Data:             response,
+			Error:            "All search sources failed",
+			DegradedServices: middleware.DegradedServicesFromContext(c),
+		})
+		return
+	}
+
+	fused := services.FuseRRF(lists, weights, k)
+	if len(fused) > limit {
+		fused = fused[:limit]
+	}
+
+	if len(fused) > 0 {
+		codes := make([]string, len(fused))
+		relevance := make(map[string]float64, len(fused))
+		for i, match := range fused {
+			codes[i] = match.Code
+			relevance[match.Code] = match.Score
+		}
+		rows, _, err := h.postgreSQLService.SearchProductsByBarcodesWithRelevanceAndBarcodeMap(ctx, codes, relevance, nil, len(codes), 0)
+		if err != nil {
+			log.Printf("❌ [HYBRID-SEARCH] loading product details failed: %v", err)
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to load product details",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		// Keep the fused order; codes no longer in ic_inventory are dropped
+		converted := convertSearchResults(rows)
+		h.applyProductImages(ctx, converted)
+		products := make(map[string]services.SearchResult, len(rows))
+		for _, product := range converted {
+			products[product.Code] = product
+		}
+		for _, match := range fused {
+			product, ok := products[match.Code]
+			if !ok {
+				continue
+			}
+			response.Data = append(response.Data, services.HybridSearchResult{
+				SearchResult: product,
+				HybridScore:  match.Score,
+				Sources:      match.Sources,
+			})
+		}
+	}
+
+	if len(response.Data) == 0 {
+		response.SuggestedQuery = h.spelling.Suggest(req.Query)
+	}
+
+	response.Duration = time.Since(startTime).Seconds() * 1000
+	log.Printf("✅ [HYBRID-SEARCH] '%s': %d results from %d sources in %.1fms", req.Query, len(response.Data), len(lists), response.Duration)
+	h.recordSearchEvent(services.SearchEvent{
+		Time:        startTime,
+		Endpoint:    c.FullPath(),
+		Query:       req.Query,
+		Method:      services.SearchMethodHybrid,
+		ResultCount: len(response.Data),
+		Returned:    len(response.Data),
+		LatencyMs:   response.Duration,
+		ClientID:    c.GetHeader("X-Client-ID"),
+	})
+
+	respond(c, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    response,
+		Message: fmt.Sprintf("Hybrid search returned %d products", len(response.Data)),
+	})
+}
+
+// mirrorSearch runs a sampled first-page search again through the hybrid pipeline (search.shadow)
+// and logs how its ranking and latency differ from the response. The response is not affected.
+func (h *APIHandler) mirrorSearch(ctx context.Context, query string, limit int, primary *services.VectorSearchResponse) {
+	if !h.shadowMirror.Sample() {
+		return
+	}
+	primaryCodes := make([]string, 0, len(primary.Data))
+	for _, result := range primary.Data {
+		primaryCodes = append(primaryCodes, result.Code)
+	}
+	primaryMs := primary.Duration
+
+	started := h.shadowMirror.Go(ctx, func(ctx context.Context) {
+		startTime := time.Now()
+		rankers := make(map[string]services.HybridRanker, len(services.HybridSources))
+		weights := make(map[string]float64, len(services.HybridSources))
+		for _, source := range services.HybridSources {
+			rankers[source] = h.hybridRanker(source)
+			weights[source] = 1
+		}
+
+		lists, statuses := services.RunHybridSources(ctx, query, max(limit*2, 50), rankers, weights)
+		if len(lists) == 0 {
+			log.Printf("⚠️ [SHADOW] '%s': every hybrid source failed", query)
+			return
+		}
+		fused := services.FuseRRF(lists, weights, services.DefaultRRFK)
+		if len(fused) > limit {
+			fused = fused[:limit]
+		}
+		shadowCodes := make([]string, len(fused))
+		for i, match := range fused {
+			shadowCodes[i] = match.Code
+		}
+		shadowMs := time.Since(startTime).Seconds() * 1000
+
+		diff := services.CompareSearchResults(primaryCodes, shadowCodes)
+		log.Printf("🌗 [SHADOW] '%s': overlap %d/%d, same top: %t, latency %.1fms vs %.1fms (%+.1fms), %d/%d sources, only primary %v, only shadow %v",
+			query, diff.Overlap, diff.Compared, diff.SameTop, primaryMs, shadowMs, shadowMs-primaryMs,
+			len(lists), len(statuses), diff.OnlyPrimary, diff.OnlyShadow)
+	})
+	if !started {
+		log.Printf("⏭️ [SHADOW] '%s' not mirrored: too many shadow searches in flight", query)
+	}
+}
+
+// hybridSourceServices names the dependency behind each hybrid source
+var hybridSourceServices = map[string]string{
+	services.HybridSourceBM25:  services.ServiceWeaviate,
+	services.HybridSourceTFIDF: services.ServiceClickHouse,
+	services.HybridSourceSQL:   services.ServicePostgreSQL,
+}
+
+// hybridRanker returns the ranker of a source, or nil when its backing service is unavailable
+func (h *APIHandler) hybridRanker(source string) services.HybridRanker {
+	switch source {
+	case services.HybridSourceBM25:
+		if h.weaviateService == nil {
+			return nil
+		}
+		return func(ctx context.Context, query string, limit int) ([]services.RankedCode, error) {
+			products, err := h.weaviateService.SearchProducts(ctx, query, limit)
+			if err != nil {
+				return nil, err
+			}
+			// Weaviate holds one object per barcode; FuseRRF keeps each code at its best rank
+			ranked := make([]services.RankedCode, 0, len(products))
+			for _, product := range products {
+				ranked = append(ranked, services.RankedCode{Code: product.ICCode, Score: product.Relevance})
+			}
+			return ranked, nil
+		}
+	case services.HybridSourceTFIDF:
+		if h.vectorDB == nil {
+			return nil
+		}
+		return h.vectorDB.RankProducts
+	case services.HybridSourceSQL:
+		return func(ctx context.Context, query string, limit int) ([]services.RankedCode, error) {
+			rows, _, err := h.postgreSQLService.SearchProducts(ctx, query, limit, 0)
+			if err != nil {
+				return nil, err
+			}
+			ranked := make([]services.RankedCode, 0, len(rows))
+			for _, row := range rows {
+				ranked = append(ranked, services.RankedCode{Code: getStringValue(row, "code"), Score: getFloat64Value(row, "search_priority")})
+			}
+			return ranked, nil
+		}
+	}
+	return nil
+}
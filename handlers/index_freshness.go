@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetIndexFreshness godoc
+// @Summary Search index freshness
+// @Description Lag between ic_inventory in PostgreSQL and the Weaviate and TF-IDF indexes: last sync, pending changed rows and whether each index is stale under search.freshness
+// @Tags admin
+// @Produce json
+// @Param fail_on_stale query bool false "Respond 503 when an index is stale, for uptime monitors"
+// @Success 200 {object} models.APIResponse{data=services.IndexFreshnessReport}
+// @Failure 503 {object} models.APIResponse{data=services.IndexFreshnessReport}
+// @Router /admin/index-freshness [get]
+func (h *APIHandler) GetIndexFreshness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	report := h.indexFreshness(ctx)
+
+	status := http.StatusOK
+	message := "Search indexes are up to date"
+	if report.Stale {
+		message = "A search index is stale"
+		if c.Query("fail_on_stale") == "true" {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(status, models.APIResponse{
+		Success: status == http.StatusOK,
+		Data:    report,
+		Message: message,
+	})
+}
+
+func (h *APIHandler) indexFreshness(ctx context.Context) services.IndexFreshnessReport {
+	cfg := h.config.Search.Freshness
+
+	weaviate := services.IndexFreshness{Index: services.IndexWeaviate, Error: "Weaviate sync requires both Weaviate and PostgreSQL"}
+	if h.weaviateSyncService != nil {
+		weaviate = h.weaviateSyncService.Freshness(ctx, cfg)
+	}
+
+	report := services.IndexFreshnessReport{
+		CheckedAt: time.Now(),
+		Indexes:   []services.IndexFreshness{weaviate, services.TFIDFFreshness(h.vectorDB, cfg)},
+	}
+	for _, index := range report.Indexes {
+		report.Stale = report.Stale || index.Stale
+	}
+	return report
+}
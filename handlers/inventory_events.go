@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"smlgoapi/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InventoryEvents godoc
+// @Summary Stream stock balance and price changes
+// @Description Server-Sent Events stream of "balance" and "price" events as ic_balance and ic_inventory_price_formula change, so POS frontends can refresh availability without polling the search. Reconnecting with Last-Event-ID resumes after the last event received; a "reset" event means changes were missed and the client should reload what it shows.
+// @Tags products
+// @Produce text/event-stream
+// @Param ic_code query string false "Comma-separated product codes to follow; all products when omitted"
+// @Param types query string false "Comma-separated event types: balance, price (default both)"
+// @Param Last-Event-ID header string false "Id of the last event received, sent by EventSource on reconnect"
+// @Success 200 {object} models.InventoryEvent
+// @Failure 503 {object} models.APIResponse
+// @Router /events [get]
+func (h *APIHandler) InventoryEvents(c *gin.Context) {
+	if h.inventoryEvents == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Inventory events are disabled; set inventory_events.enabled",
+		})
+		return
+	}
+
+	codes := queryList(c.Query("ic_code"))
+	types := queryList(c.DefaultQuery("types", models.InventoryEventBalance+","+models.InventoryEventPrice))
+	for eventType := range types {
+		if eventType != models.InventoryEventBalance && eventType != models.InventoryEventPrice {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Unknown event type '%s': use balance or price", eventType),
+			})
+			return
+		}
+	}
+	wanted := func(event models.InventoryEvent) bool {
+		if _, ok := types[event.Type]; !ok {
+			return false
+		}
+		if len(codes) == 0 {
+			return true
+		}
+		_, ok := codes[event.ICCode]
+		return ok
+	}
+
+	lastID, _ := strconv.ParseInt(c.GetHeader("Last-Event-ID"), 10, 64)
+	sub, replay, complete := h.inventoryEvents.Subscribe(lastID)
+	defer h.inventoryEvents.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // nginx would otherwise hold events back
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: 5000\n\n")
+	if !complete {
+		writeSSE(w, 0, "reset", gin.H{"reason": "events after the Last-Event-ID are no longer kept; reload stock and prices"})
+	}
+	for _, event := range replay {
+		if wanted(event) {
+			writeSSE(w, event.ID, event.Type, event)
+		}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(time.Duration(h.config.InventoryEvents.HeartbeatSeconds) * time.Second)
+	defer heartbeat.Stop()
+	ctx := c.Request.Context()
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Too far behind or shutting down; EventSource reconnects with Last-Event-ID
+				return
+			}
+			if !wanted(event) {
+				continue
+			}
+			writeSSE(w, event.ID, event.Type, event)
+			w.Flush()
+		case <-heartbeat.C:
+			fmt.Fprintf(w, ": heartbeat\n\n")
+			w.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// writeSSE writes one Server-Sent Event; id 0 leaves the client's last event id unchanged
+func writeSSE(w io.Writer, id int64, event string, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("❌ [events] Failed to encode %s event: %v", event, err)
+		return
+	}
+	if id > 0 {
+		fmt.Fprintf(w, "id: %d\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+}
+
+// queryList splits a comma-separated query parameter into a set, ignoring empty items
+func queryList(value string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			set[item] = struct{}{}
+		}
+	}
+	return set
+}
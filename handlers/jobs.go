@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"smlgoapi/jobs"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Registered job names
+const (
+	jobWeaviateSync      = "weaviate_sync"
+	jobImageCachePrune   = "image_cache_prune"
+	jobPriceCacheRefresh = "price_cache_refresh"
+	jobHealthProbe       = "health_probe"
+	jobAuditPrune        = "audit_prune"
+	jobUndoPrune         = "undo_prune"
+	jobWebhookPrune      = "webhook_prune"
+	jobThaiAdminRefresh  = "thai_admin_refresh"
+)
+
+// newJobScheduler registers the maintenance jobs configured under "jobs". Jobs whose service is
+// unavailable are still listed and fail with a clear error when run.
+func (h *APIHandler) newJobScheduler() *jobs.Scheduler {
+	cfg := h.config.Jobs
+	scheduler := jobs.NewScheduler()
+
+	register := func(job jobs.Job) {
+		// A shutdown waits for a running job, and none starts once it began
+		run := job.Run
+		job.Run = func(ctx context.Context) (string, error) {
+			done, err := h.operations.Begin("job", job.Name)
+			if err != nil {
+				return "", err
+			}
+			defer done()
+			return run(ctx)
+		}
+		if err := scheduler.Register(job); err != nil {
+			log.Printf("⚠️ [jobs] %v; job not registered", err)
+		}
+	}
+
+	register(jobs.Job{
+		Name:        jobWeaviateSync,
+		Description: "Incremental sync of changed ic_inventory rows into Weaviate",
+		Schedule:    cfg.WeaviateSync.Schedule,
+		Enabled:     cfg.WeaviateSync.Enabled,
+		Timeout:     time.Hour,
+		Run: func(ctx context.Context) (string, error) {
+			if h.weaviateSyncService == nil {
+				return "", fmt.Errorf("Weaviate sync requires both Weaviate and PostgreSQL")
+			}
+			status, err := h.weaviateSyncService.Run(ctx, services.WeaviateSyncOptions{
+				Mode:      services.SyncModeIncremental,
+				BatchSize: h.config.Weaviate.Sync.BatchSize,
+			})
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d products read, %d objects upserted, %d deleted",
+				status.ProductsRead, status.ObjectsUpserted, status.ObjectsDeleted), nil
+		},
+	})
+
+	register(jobs.Job{
+		Name:        jobImageCachePrune,
+		Description: fmt.Sprintf("Remove image cache files older than %dh or beyond %d MB", cfg.ImageCachePrune.MaxAgeHours, cfg.ImageCachePrune.MaxSizeMB),
+		Schedule:    cfg.ImageCachePrune.Schedule,
+		Enabled:     cfg.ImageCachePrune.Enabled,
+		Run: func(ctx context.Context) (string, error) {
+			return services.PruneCacheDir(h.config.Health.CacheDir,
+				time.Duration(cfg.ImageCachePrune.MaxAgeHours)*time.Hour,
+				int64(cfg.ImageCachePrune.MaxSizeMB)*1024*1024)
+		},
+	})
+
+	register(jobs.Job{
+		Name:        jobPriceCacheRefresh,
+		Description: "Reload prices and balances into memory for search results",
+		Schedule:    cfg.PriceCacheRefresh.Schedule,
+		Enabled:     cfg.PriceCacheRefresh.Enabled,
+		Timeout:     5 * time.Minute,
+		Run: func(ctx context.Context) (string, error) {
+			if h.postgreSQLService == nil {
+				return "", fmt.Errorf("PostgreSQL service not initialized")
+			}
+			return h.postgreSQLService.RefreshPriceBalanceCache(ctx, time.Duration(cfg.PriceCacheRefresh.MaxAgeSeconds)*time.Second)
+		},
+	})
+
+	register(jobs.Job{
+		Name:        jobHealthProbe,
+		Description: "Probe dependencies and log the ones that are down or slow",
+		Schedule:    cfg.HealthProbe.Schedule,
+		Enabled:     cfg.HealthProbe.Enabled,
+		Run: func(ctx context.Context) (string, error) {
+			var chVersion, pgVersion string
+			dependencies := h.checkDependencies(ctx, &chVersion, &pgVersion)
+			status := services.OverallHealthStatus(dependencies)
+
+			var problems []string
+			for _, dep := range dependencies {
+				if dep.Status != "up" {
+					problems = append(problems, fmt.Sprintf("%s %s", dep.Name, dep.Status))
+				}
+			}
+			summary := status
+			if len(problems) > 0 {
+				summary += ": " + strings.Join(problems, ", ")
+			}
+			if status == models.HealthStatusUnhealthy {
+				return summary, fmt.Errorf("service is unhealthy")
+			}
+			return summary, nil
+		},
+	})
+
+	register(jobs.Job{
+		Name:        jobAuditPrune,
+		Description: fmt.Sprintf("Remove audit log entries older than %d days", h.config.Audit.RetentionDays),
+		Schedule:    cfg.AuditPrune.Schedule,
+		Enabled:     cfg.AuditPrune.Enabled,
+		Run: func(ctx context.Context) (string, error) {
+			if h.auditService == nil {
+				return "", fmt.Errorf("Audit log requires PostgreSQL and audit enabled")
+			}
+			return h.auditService.Prune(ctx)
+		},
+	})
+
+	register(jobs.Job{
+		Name:        jobUndoPrune,
+		Description: fmt.Sprintf("Remove undo snapshots older than %d days", h.config.Undo.RetentionDays),
+		Schedule:    cfg.UndoPrune.Schedule,
+		Enabled:     cfg.UndoPrune.Enabled,
+		Run: func(ctx context.Context) (string, error) {
+			if h.undoService == nil {
+				return "", fmt.Errorf("Undo requires PostgreSQL and tables listed in undo.tables")
+			}
+			return h.undoService.Prune(ctx)
+		},
+	})
+
+	register(jobs.Job{
+		Name:        jobWebhookPrune,
+		Description: fmt.Sprintf("Remove webhook deliveries older than %d days", h.config.Webhooks.RetentionDays),
+		Schedule:    cfg.WebhookPrune.Schedule,
+		Enabled:     cfg.WebhookPrune.Enabled,
+		Run: func(ctx context.Context) (string, error) {
+			if h.webhookService == nil {
+				return "", fmt.Errorf("Webhooks require PostgreSQL and webhooks enabled")
+			}
+			return h.webhookService.Prune(ctx)
+		},
+	})
+
+	register(jobs.Job{
+		Name:        jobThaiAdminRefresh,
+		Description: "Reload the Thai administrative data from thai_admin.upstream",
+		Schedule:    cfg.ThaiAdminRefresh.Schedule,
+		Enabled:     cfg.ThaiAdminRefresh.Enabled,
+		Timeout:     10 * time.Minute,
+		Run: func(ctx context.Context) (string, error) {
+			result, err := h.thaiAdminService.RefreshFromUpstream(ctx, false)
+			switch {
+			case err != nil:
+				return "", err
+			case len(result.Errors) > 0 && !result.Applied:
+				return "", fmt.Errorf("upstream data rejected: %s", strings.Join(result.Errors, "; "))
+			case result.UpToDate:
+				return "up to date at version " + result.Version, nil
+			}
+			d := result.Diff.Tambons
+			summary := fmt.Sprintf("refreshed to version %s (tambons: %d added, %d removed, %d changed)",
+				result.Version, len(d.Added), len(d.Removed), len(d.Changed))
+			if !result.Persisted {
+				return summary, fmt.Errorf("%s", strings.Join(result.Errors, "; "))
+			}
+			return summary, nil
+		},
+	})
+
+	return scheduler
+}
+
+// ListJobs godoc
+// @Summary List background jobs
+// @Description Schedule, next run and last-run outcome of every maintenance job
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]jobs.Status}
+// @Router /admin/jobs [get]
+func (h *APIHandler) ListJobs(c *gin.Context) {
+	statuses := h.jobScheduler.List()
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    statuses,
+		Message: fmt.Sprintf("%d jobs", len(statuses)),
+	})
+}
+
+// GetJob godoc
+// @Summary Get a background job
+// @Tags admin
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 200 {object} models.APIResponse{data=jobs.Status}
+// @Failure 404 {object} models.APIResponse
+// @Router /admin/jobs/{name} [get]
+func (h *APIHandler) GetJob(c *gin.Context) {
+	status, err := h.jobScheduler.Get(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("%s: %s", err.Error(), c.Param("name")),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    status,
+	})
+}
+
+// RunJob godoc
+// @Summary Run a background job now
+// @Description Starts the job in the background, also when it is disabled; poll GET /admin/jobs/{name} for the outcome
+// @Tags admin
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 202 {object} models.APIResponse{data=jobs.Status}
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse{data=jobs.Status}
+// @Router /admin/jobs/{name}/run [post]
+func (h *APIHandler) RunJob(c *gin.Context) {
+	name := c.Param("name")
+	status, err := h.jobScheduler.Trigger(name)
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("%s: %s", err.Error(), name),
+		})
+		return
+	case errors.Is(err, jobs.ErrJobRunning):
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Data:    status,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    status,
+		Message: fmt.Sprintf("Job %s started", name),
+	})
+}
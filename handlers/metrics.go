@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"smlgoapi/metrics"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchResultBuckets bound the histogram of matches per search
+var searchResultBuckets = []float64{0, 1, 5, 10, 25, 50, 100, 250, 500, 1000}
+
+// searchMetrics count searches as they are answered
+type searchMetrics struct {
+	searches *metrics.CounterVec
+	results  *metrics.HistogramVec
+	duration *metrics.HistogramVec
+}
+
+// Metrics returns the registry served on metrics.path, which the request middleware also uses
+func (h *APIHandler) Metrics() *metrics.Registry {
+	return h.metrics
+}
+
+// registerMetrics adds the search counters and the gauges read on every scrape: connection pools,
+// Weaviate availability, index freshness and the image cache volume
+func (h *APIHandler) registerMetrics() {
+	reg := h.metrics
+	h.searchMetrics = searchMetrics{
+		searches: reg.NewCounterVec("smlgoapi_searches_total",
+			"Searches answered, by method and whether anything was found (outcome hit or zero_results)", "method", "outcome"),
+		results: reg.NewHistogramVec("smlgoapi_search_results",
+			"Matches per search, all pages", searchResultBuckets, "method"),
+		duration: reg.NewHistogramVec("smlgoapi_search_duration_seconds",
+			"Time until a search response was ready, by method", metrics.DefaultDurationBuckets, "method"),
+	}
+
+	reg.CounterFunc("smlgoapi_search_events_dropped_total",
+		"Search analytics events dropped because the ClickHouse writer fell behind", nil,
+		func(_ context.Context, emit func(float64, ...string)) {
+			if h.searchAnalytics != nil {
+				emit(float64(h.searchAnalytics.Dropped()))
+			}
+		})
+
+	pools := func(each func(emit func(float64, ...string), db string, stats sql.DBStats)) metrics.CollectFunc {
+		return func(_ context.Context, emit func(float64, ...string)) {
+			if h.postgreSQLService != nil {
+				each(emit, "postgresql", h.postgreSQLService.Stats())
+			}
+			if h.clickHouseService != nil {
+				each(emit, "clickhouse", h.clickHouseService.Stats())
+			}
+		}
+	}
+	reg.GaugeFunc("smlgoapi_db_max_open_connections", "Connection limit of the pool (0 = unlimited)", []string{"db"},
+		pools(func(emit func(float64, ...string), db string, stats sql.DBStats) {
+			emit(float64(stats.MaxOpenConnections), db)
+		}))
+	reg.GaugeFunc("smlgoapi_db_connections", "Open connections by state", []string{"db", "state"},
+		pools(func(emit func(float64, ...string), db string, stats sql.DBStats) {
+			emit(float64(stats.InUse), db, "in_use")
+			emit(float64(stats.Idle), db, "idle")
+		}))
+	reg.CounterFunc("smlgoapi_db_wait_count_total", "Queries that waited for a free connection", []string{"db"},
+		pools(func(emit func(float64, ...string), db string, stats sql.DBStats) {
+			emit(float64(stats.WaitCount), db)
+		}))
+	reg.CounterFunc("smlgoapi_db_wait_duration_seconds_total", "Time spent waiting for a free connection", []string{"db"},
+		pools(func(emit func(float64, ...string), db string, stats sql.DBStats) {
+			emit(stats.WaitDuration.Seconds(), db)
+		}))
+
+	reg.GaugeFunc("smlgoapi_weaviate_up", "1 when Weaviate answers a ping, 0 when it is down or not configured", nil,
+		func(ctx context.Context, emit func(float64, ...string)) {
+			up := 0.0
+			if h.weaviateService != nil {
+				ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+				defer cancel()
+				if h.weaviateService.Ping(ctx) == nil {
+					up = 1
+				}
+			}
+			emit(up)
+		})
+
+	// The freshness report is computed once per scrape and shared by its gauges
+	var freshness struct {
+		sync.Mutex
+		at     time.Time
+		report services.IndexFreshnessReport
+	}
+	indexes := func(ctx context.Context) []services.IndexFreshness {
+		freshness.Lock()
+		defer freshness.Unlock()
+		if time.Since(freshness.at) > 5*time.Second {
+			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			freshness.report, freshness.at = h.indexFreshness(ctx), time.Now()
+		}
+		return freshness.report.Indexes
+	}
+	reg.GaugeFunc("smlgoapi_index_age_seconds", "Seconds since the search index was last synced or built", []string{"index"},
+		func(ctx context.Context, emit func(float64, ...string)) {
+			for _, index := range indexes(ctx) {
+				if index.LastSync != nil {
+					emit(index.AgeSeconds, index.Index)
+				}
+			}
+		})
+	reg.GaugeFunc("smlgoapi_index_lag_seconds", "How long the oldest change not yet in the index has waited", []string{"index"},
+		func(ctx context.Context, emit func(float64, ...string)) {
+			for _, index := range indexes(ctx) {
+				if index.LastSync != nil {
+					emit(index.LagSeconds, index.Index)
+				}
+			}
+		})
+	reg.GaugeFunc("smlgoapi_index_pending_changes", "ic_inventory rows changed since the last sync", []string{"index"},
+		func(ctx context.Context, emit func(float64, ...string)) {
+			for _, index := range indexes(ctx) {
+				if index.PendingChanges != nil {
+					emit(float64(*index.PendingChanges), index.Index)
+				}
+			}
+		})
+	reg.GaugeFunc("smlgoapi_index_stale", "1 when the index is stale under search.freshness", []string{"index"},
+		func(ctx context.Context, emit func(float64, ...string)) {
+			for _, index := range indexes(ctx) {
+				stale := 0.0
+				if index.Stale {
+					stale = 1
+				}
+				emit(stale, index.Index)
+			}
+		})
+
+	reg.GaugeFunc("smlgoapi_image_cache_free_bytes", "Free space on the volume of health.cache_dir", nil,
+		func(_ context.Context, emit func(float64, ...string)) {
+			path := h.config.Health.CacheDir
+			if _, err := os.Stat(path); err != nil {
+				path = filepath.Dir(filepath.Clean(path))
+			}
+			if free, err := services.DiskFreeBytes(path); err == nil {
+				emit(float64(free))
+			}
+		})
+
+	reg.GaugeFunc("go_goroutines", "Number of goroutines", nil,
+		func(_ context.Context, emit func(float64, ...string)) {
+			emit(float64(runtime.NumGoroutine()))
+		})
+}
+
+// observeSearch counts an answered search for the metrics endpoint
+func (m searchMetrics) observeSearch(event services.SearchEvent) {
+	outcome := "hit"
+	if event.ResultCount == 0 {
+		outcome = "zero_results"
+	}
+	m.searches.Inc(event.Method, outcome)
+	m.results.Observe(float64(event.ResultCount), event.Method)
+	m.duration.Observe(event.LatencyMs/1000, event.Method)
+}
+
+// GetMetrics godoc
+// @Summary Prometheus metrics
+// @Description Request counts and latency per route, connection pools, Weaviate availability, index freshness and search result counts in the Prometheus text format
+// @Tags health
+// @Produce plain
+// @Success 200 {string} string "Prometheus metrics"
+// @Router /metrics [get]
+func (h *APIHandler) GetMetrics(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := h.metrics.Write(c.Request.Context(), &buf); err != nil {
+		log.Printf("❌ [metrics] Failed to write metrics: %v", err)
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Data(http.StatusOK, metrics.ContentType, buf.Bytes())
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"smlgoapi/middleware"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RunNamedQuery godoc
+// @Summary Run a named query
+// @Description Run the SQL template an admin registered under name with typed arguments. Frontends get the flexibility of SQL without sending raw queries to /select. Supports ?format=ndjson|csv|xlsx like /select.
+// @Tags sql
+// @Accept json
+// @Produce json
+// @Param name path string true "Named query"
+// @Param request body models.RunNamedQueryRequest false "Arguments by parameter name"
+// @Param format query string false "json (default), ndjson, csv or xlsx"
+// @Success 200 {object} models.SelectResponse
+// @Failure 400 {object} models.SelectResponse
+// @Failure 404 {object} models.SelectResponse
+// @Failure 503 {object} models.SelectResponse
+// @Router /query/{name} [post]
+func (h *APIHandler) RunNamedQuery(c *gin.Context) {
+	startTime := time.Now()
+	name := c.Param("name")
+
+	if h.namedQueryService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.SelectResponse{
+			Success: false,
+			Error:   "Named queries require PostgreSQL",
+		})
+		return
+	}
+
+	var req models.RunNamedQueryRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.SelectResponse{
+				Success: false,
+				Error:   "Invalid JSON body: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	namedQuery, err := h.namedQueryService.Get(ctx, name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrNamedQueryNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.SelectResponse{
+			Success: false,
+			Error:   fmt.Sprintf("%s: %s", name, err.Error()),
+		})
+		return
+	}
+
+	params, err := services.BindNamedQuery(namedQuery, req.Args)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var stream rowStreamer
+	switch {
+	case namedQuery.Database == services.NamedQueryClickHouse && h.clickHouseService != nil:
+		stream = h.clickHouseService.StreamSelect
+	case namedQuery.Database == services.NamedQueryPostgreSQL:
+		stream = h.postgreSQLService.StreamSelect
+	default:
+		c.JSON(http.StatusServiceUnavailable, models.SelectResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Named query %s requires ClickHouse", name),
+		})
+		return
+	}
+
+	format, err := selectFormat(c)
+	var page *selectPage
+	if err == nil {
+		page, err = h.newSelectPage(namedQuery.Database, models.SelectRequest{Query: namedQuery.Query}, format)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	timeout, cancel, err := h.withQueryTimeout(c, namedQuery.Database, namedQuery.Query, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	defer cancel()
+	if format != selectFormatJSON {
+		log.Printf("📜 [query] Exporting %s as %s (%d params)", name, format, len(params))
+		exportRows(c, "query", format, namedQuery.Query, params, stream)
+		return
+	}
+
+	log.Printf("📜 [query] Running %s on %s (%d params)", name, namedQuery.Database, len(params))
+
+	// A larger result stops at max_rows or max_response_bytes, as on /pgselect
+	err = page.run(c.Request.Context(), stream, params)
+	data := page.rows.Rows
+	duration := float64(time.Since(startTime).Nanoseconds()) / 1e6
+
+	if err != nil {
+		if status, message, ok := queryInterrupted(c, "query", timeout); ok {
+			c.JSON(status, models.SelectResponse{
+				Success:  false,
+				Error:    message,
+				Duration: duration,
+			})
+			return
+		}
+		log.Printf("❌ [query] %s failed: %v", name, err)
+		c.JSON(http.StatusInternalServerError, models.SelectResponse{
+			Success:  false,
+			Error:    fmt.Sprintf("Named query %s failed: %s", name, err.Error()),
+			Duration: duration,
+		})
+		return
+	}
+
+	rowCount := len(data)
+	log.Printf("✅ [query] %s returned %d rows in %.2fms (truncated: %t)", name, rowCount, duration, page.rows.Truncated)
+	message := fmt.Sprintf("Named query %s returned %d rows", name, rowCount)
+	if page.rows.Truncated {
+		message += "; more rows remain, narrow the query with its arguments"
+	}
+	respond(c, http.StatusOK, models.SelectResponse{
+		Success:   true,
+		Message:   message,
+		Data:      data,
+		RowCount:  rowCount,
+		Duration:  duration,
+		Truncated: page.rows.Truncated,
+	})
+}
+
+// ListNamedQueries godoc
+// @Summary List named queries
+// @Description List every registered SQL template with its parameters
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.NamedQuery}
+// @Failure 503 {object} models.APIResponse
+// @Router /admin/queries [get]
+func (h *APIHandler) ListNamedQueries(c *gin.Context) {
+	if h.namedQueryService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Named queries require PostgreSQL",
+		})
+		return
+	}
+
+	queries, err := h.namedQueryService.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    queries,
+		Message: fmt.Sprintf("Retrieved %d named queries", len(queries)),
+	})
+}
+
+// SaveNamedQuery godoc
+// @Summary Register a named query
+// @Description Register or replace the SQL template under name. The query must be one SELECT with a placeholder per parameter: $1, $2, ... in parameter order for PostgreSQL, ? for ClickHouse.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Named query"
+// @Param request body models.NamedQueryRequest true "Database, SQL template and typed parameters"
+// @Success 200 {object} models.APIResponse{data=models.NamedQuery}
+// @Failure 400 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /admin/queries/{name} [put]
+func (h *APIHandler) SaveNamedQuery(c *gin.Context) {
+	if h.namedQueryService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Named queries require PostgreSQL",
+		})
+		return
+	}
+
+	var req models.NamedQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	middleware.AuditSQL(c, req.Query)
+	namedQuery, err := h.namedQueryService.Save(c.Request.Context(), c.Param("name"), req, shareCreator(c))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidNamedQuery) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Message: "Failed to save named query",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("📜 [query] Registered %s on %s with %d params", namedQuery.Name, namedQuery.Database, len(namedQuery.Params))
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    namedQuery,
+		Message: fmt.Sprintf("Named query %s saved", namedQuery.Name),
+	})
+}
+
+// DeleteNamedQuery godoc
+// @Summary Delete a named query
+// @Tags admin
+// @Produce json
+// @Param name path string true "Named query"
+// @Success 200 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /admin/queries/{name} [delete]
+func (h *APIHandler) DeleteNamedQuery(c *gin.Context) {
+	if h.namedQueryService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Named queries require PostgreSQL",
+		})
+		return
+	}
+
+	name := c.Param("name")
+	if err := h.namedQueryService.Delete(c.Request.Context(), name); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrNamedQueryNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("%s: %s", name, err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Named query %s deleted", name),
+	})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"log"
+
+	"smlgoapi/middleware"
+	"smlgoapi/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// msgpackHandle writes the current MessagePack spec (str8 and bin types, timestamp extension),
+// which the Dart and Go msgpack libraries expect
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// protoMessage is response data with a protobuf encoding, see proto/search.proto
+type protoMessage interface {
+	AppendProto(b []byte) []byte
+}
+
+// typedResponse is an APIResponse whose data can also be sent as protobuf. It serializes to JSON
+// and MessagePack exactly like the embedded APIResponse.
+type typedResponse struct {
+	models.APIResponse
+	proto protoMessage
+}
+
+// typed wraps a response whose Data has a protobuf encoding
+func typed(response models.APIResponse, data protoMessage) typedResponse {
+	response.Data = data
+	return typedResponse{APIResponse: response, proto: data}
+}
+
+// AppendProto writes the envelope (e.g. SearchAPIResponse): success = 1, data = 2, message = 3, error = 4
+func (r typedResponse) AppendProto(b []byte) []byte {
+	if r.Success {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendBytes(b, r.proto.AppendProto(nil))
+	if r.Message != "" {
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendString(b, r.Message)
+	}
+	if r.Error != "" {
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendString(b, r.Error)
+	}
+	for _, service := range r.DegradedServices {
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendString(b, service)
+	}
+	return b
+}
+
+// respond writes obj in the encoding asked for by the Accept header: JSON (the default),
+// MessagePack, or protobuf for responses built with typed. Clients that accept none of the
+// offered types get JSON.
+func respond(c *gin.Context, status int, obj interface{}) {
+	obj = withDegradedServices(c, obj)
+	offered := []string{binding.MIMEJSON, binding.MIMEMSGPACK, binding.MIMEMSGPACK2}
+	message, isProto := obj.(protoMessage)
+	if isProto {
+		offered = append(offered, binding.MIMEPROTOBUF)
+	}
+	c.Header("Vary", "Accept")
+
+	switch format := c.NegotiateFormat(offered...); format {
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		var body []byte
+		if err := codec.NewEncoderBytes(&body, msgpackHandle).Encode(obj); err != nil {
+			log.Printf("❌ [respond] MessagePack encoding failed, sending JSON: %v", err)
+			c.JSON(status, obj)
+			return
+		}
+		c.Data(status, format, body)
+	case binding.MIMEPROTOBUF:
+		c.Data(status, binding.MIMEPROTOBUF, message.AppendProto(nil))
+	default:
+		c.JSON(status, obj)
+	}
+}
+
+// withDegradedServices copies the services the request went without into an API envelope
+func withDegradedServices(c *gin.Context, obj interface{}) interface{} {
+	degraded := middleware.DegradedServicesFromContext(c)
+	if len(degraded) == 0 {
+		return obj
+	}
+	switch response := obj.(type) {
+	case models.APIResponse:
+		response.DegradedServices = degraded
+		return response
+	case typedResponse:
+		response.DegradedServices = degraded
+		return response
+	}
+	return obj
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"strconv"
+
+	"smlgoapi/config"
+	"smlgoapi/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyLimitRole is the page_limits role used for requests authenticated with an API key
+const apiKeyLimitRole = "api_key"
+
+// searchLimitRoute is the route whose page_limits also apply to gRPC and WebSocket searches
+const searchLimitRoute = "/v1/search-by-vector"
+
+// pageLimitRouteKey holds the route whose page_limits apply to a request served by an alias route
+const pageLimitRouteKey = "page_limit_route"
+
+// pageLimit returns the configured page size limits for the current route and caller
+func (h *APIHandler) pageLimit(c *gin.Context) config.PageLimit {
+	route := c.FullPath()
+	if alias := c.GetString(pageLimitRouteKey); alias != "" {
+		route = alias
+	}
+	return h.config.PageLimits.Resolve(route, limitRole(c))
+}
+
+// clampLimit replaces a missing or invalid limit with the route default and caps it at the route maximum
+func (h *APIHandler) clampLimit(c *gin.Context, requested int) int {
+	return clampToPageLimit(h.pageLimit(c), requested)
+}
+
+func clampToPageLimit(limit config.PageLimit, requested int) int {
+	if requested <= 0 {
+		return limit.Default
+	}
+	if requested > limit.Max {
+		return limit.Max
+	}
+	return requested
+}
+
+// queryLimit reads the "limit" query parameter and clamps it with clampLimit
+func (h *APIHandler) queryLimit(c *gin.Context) int {
+	requested, _ := strconv.Atoi(c.Query("limit"))
+	return h.clampLimit(c, requested)
+}
+
+// limitRole identifies the caller for role-specific page limits. Callers are only known on routes
+// where authentication is enforced; everyone else gets the route limits.
+func limitRole(c *gin.Context) string {
+	if claims, ok := middleware.SessionFromContext(c); ok {
+		return claims.Role
+	}
+	if _, ok := middleware.APIKeyFromContext(c); ok {
+		return apiKeyLimitRole
+	}
+	return ""
+}
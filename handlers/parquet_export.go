@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetSchemaRows is how many rows are read before the Parquet schema is fixed. A column whose
+// values are all NULL in these rows is written as text.
+const parquetSchemaRows = 500
+
+// Parquet physical types of the exported columns
+const (
+	parquetText = iota
+	parquetBool
+	parquetInt
+	parquetDouble
+	parquetTimestamp
+)
+
+// parquetEncoder writes a Snappy-compressed Parquet file with one optional column per result
+// column. Parquet needs the schema before the first row, so the first rows are held back and
+// their values decide the column types.
+type parquetEncoder struct {
+	out     io.Writer
+	w       *parquet.Writer
+	columns []string
+	kinds   []int
+	index   []int // result column -> Parquet column; Parquet orders columns by name
+	pending [][]interface{}
+	row     parquet.Row
+}
+
+func newParquetEncoder(w io.Writer) *parquetEncoder {
+	return &parquetEncoder{out: w}
+}
+
+func (e *parquetEncoder) Row(columns []string, values []interface{}) error {
+	if e.w != nil {
+		return e.writeRow(values)
+	}
+	if e.columns == nil {
+		e.columns = append([]string{}, columns...)
+	}
+	// values is reused by the scan, so the held back rows are copied
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		row[i] = exportValue(v)
+	}
+	e.pending = append(e.pending, row)
+	if len(e.pending) < parquetSchemaRows {
+		return nil
+	}
+	return e.start()
+}
+
+// start fixes the schema from the held back rows and writes them
+func (e *parquetEncoder) start() error {
+	e.kinds = make([]int, len(e.columns))
+	for i := range e.columns {
+		for _, row := range e.pending {
+			if row[i] != nil {
+				e.kinds[i] = parquetKind(row[i])
+				break
+			}
+		}
+	}
+
+	// Parquet column names must be unique; a repeated name gets a _2, _3... suffix
+	names := make([]string, len(e.columns))
+	group := parquet.Group{}
+	for i, column := range e.columns {
+		name := column
+		for n := 2; group[name] != nil; n++ {
+			name = column + "_" + strconv.Itoa(n)
+		}
+		names[i] = name
+		group[name] = parquet.Optional(parquetNode(e.kinds[i]))
+	}
+	schema := parquet.NewSchema("export", group)
+	e.index = make([]int, len(e.columns))
+	for i, name := range names {
+		leaf, _ := schema.Lookup(name)
+		e.index[i] = leaf.ColumnIndex
+	}
+	e.w = parquet.NewWriter(e.out, schema, parquet.Compression(&parquet.Snappy))
+	e.row = make(parquet.Row, len(e.columns))
+
+	for _, row := range e.pending {
+		if err := e.writeRow(row); err != nil {
+			return err
+		}
+	}
+	e.pending = nil
+	return nil
+}
+
+func (e *parquetEncoder) writeRow(values []interface{}) error {
+	for i, v := range values {
+		value, err := parquetValue(e.kinds[i], exportValue(v))
+		if err != nil {
+			return fmt.Errorf("column %s: %w", e.columns[i], err)
+		}
+		definition := 1
+		if value.IsNull() {
+			definition = 0
+		}
+		e.row[e.index[i]] = value.Level(0, definition, e.index[i])
+	}
+	_, err := e.w.WriteRows([]parquet.Row{e.row})
+	return err
+}
+
+// Abort leaves the file unfinished; a failed export is discarded
+func (e *parquetEncoder) Abort(err error) {}
+
+func (e *parquetEncoder) Close() error {
+	if e.w == nil {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	return e.w.Close()
+}
+
+func parquetKind(v interface{}) int {
+	switch v.(type) {
+	case bool:
+		return parquetBool
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return parquetInt
+	case float32, float64:
+		return parquetDouble
+	case time.Time:
+		return parquetTimestamp
+	}
+	return parquetText
+}
+
+func parquetNode(kind int) parquet.Node {
+	switch kind {
+	case parquetBool:
+		return parquet.Leaf(parquet.BooleanType)
+	case parquetInt:
+		return parquet.Int(64)
+	case parquetDouble:
+		return parquet.Leaf(parquet.DoubleType)
+	case parquetTimestamp:
+		return parquet.Timestamp(parquet.Millisecond)
+	}
+	return parquet.String()
+}
+
+// parquetValue converts a value to the type of its column. Database columns have one type, so
+// a mismatch only happens when the held back rows were not representative.
+func parquetValue(kind int, v interface{}) (parquet.Value, error) {
+	if v == nil {
+		return parquet.NullValue(), nil
+	}
+	switch kind {
+	case parquetBool:
+		if b, ok := v.(bool); ok {
+			return parquet.BooleanValue(b), nil
+		}
+	case parquetInt:
+		switch n := v.(type) {
+		case int:
+			return parquet.Int64Value(int64(n)), nil
+		case int8:
+			return parquet.Int64Value(int64(n)), nil
+		case int16:
+			return parquet.Int64Value(int64(n)), nil
+		case int32:
+			return parquet.Int64Value(int64(n)), nil
+		case int64:
+			return parquet.Int64Value(n), nil
+		case uint:
+			return parquet.Int64Value(int64(n)), nil
+		case uint8:
+			return parquet.Int64Value(int64(n)), nil
+		case uint16:
+			return parquet.Int64Value(int64(n)), nil
+		case uint32:
+			return parquet.Int64Value(int64(n)), nil
+		case uint64:
+			return parquet.Int64Value(int64(n)), nil
+		}
+	case parquetDouble:
+		switch n := v.(type) {
+		case float32:
+			return parquet.DoubleValue(float64(n)), nil
+		case float64:
+			return parquet.DoubleValue(n), nil
+		}
+	case parquetTimestamp:
+		if t, ok := v.(time.Time); ok {
+			return parquet.Int64Value(t.UnixMilli()), nil
+		}
+	default:
+		return parquet.ByteArrayValue([]byte(exportString(v))), nil
+	}
+	return parquet.Value{}, fmt.Errorf("%T value in a column of another type", v)
+}
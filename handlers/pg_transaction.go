@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"smlgoapi/middleware"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PgTransactionEndpoint godoc
+// @Summary Execute PostgreSQL statements in one transaction
+// @Description Run an ordered list of statements atomically: all are committed, or on the first failure the transaction is rolled back and nothing is written.
+// @Description Each statement may bind params to $1, $2, ...; SELECT and ... RETURNING statements report their rows.
+// @Tags database
+// @Accept json
+// @Produce json
+// @Param transaction body models.TransactionRequest true "Statements to execute"
+// @Success 200 {object} models.TransactionResponse
+// @Failure 400 {object} models.TransactionResponse
+// @Failure 403 {object} models.TransactionResponse
+// @Failure 409 {object} models.TransactionResponse
+// @Failure 500 {object} models.TransactionResponse
+// @Failure 503 {object} models.TransactionResponse
+// @Router /pgtransaction [post]
+func (h *APIHandler) PgTransactionEndpoint(c *gin.Context) {
+	startTime := time.Now()
+
+	var req models.TransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("❌ [pgtransaction] JSON bind error: %v", err)
+		c.JSON(http.StatusBadRequest, models.TransactionResponse{
+			Success: false,
+			Error:   "Invalid JSON body: " + err.Error(),
+		})
+		return
+	}
+	if len(req.Statements) > services.MaxTransactionStatements {
+		c.JSON(http.StatusBadRequest, models.TransactionResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Too many statements: %d (maximum %d)", len(req.Statements), services.MaxTransactionStatements),
+		})
+		return
+	}
+
+	queries := make([]string, len(req.Statements))
+	for i, statement := range req.Statements {
+		queries[i] = strings.TrimRight(strings.TrimSpace(statement.Query), ";") + ";"
+	}
+	middleware.AuditSQL(c, strings.Join(queries, "\n"))
+
+	statements := make([]services.TransactionStatement, len(req.Statements))
+	for i, statement := range req.Statements {
+		index := i
+		if err := services.CheckTransactionStatement(statement.Query); err != nil {
+			c.JSON(http.StatusBadRequest, models.TransactionResponse{
+				Success:         false,
+				FailedStatement: &index,
+				Error:           fmt.Sprintf("Invalid statement %d: %s", i, err.Error()),
+			})
+			return
+		}
+		if err := h.sqlPolicyService.Check(services.ServicePostgreSQL, statement.Query, postgreSQLDefaultSchema); err != nil {
+			log.Printf("🛡️ [pgtransaction] Statement %d rejected by SQL policy: %v", i, err)
+			c.JSON(http.StatusForbidden, models.TransactionResponse{
+				Success:         false,
+				FailedStatement: &index,
+				Error:           err.Error(),
+			})
+			return
+		}
+		params, err := services.NormalizeQueryParams(statement.Params, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.TransactionResponse{
+				Success:         false,
+				FailedStatement: &index,
+				Error:           fmt.Sprintf("Invalid params of statement %d: %s", i, err.Error()),
+			})
+			return
+		}
+		statements[i] = services.TransactionStatement{Query: statement.Query, Params: params}
+	}
+
+	log.Printf("🐘 [pgtransaction] Executing %d statements in one transaction", len(statements))
+
+	done := h.trackQuery(c, services.ServicePostgreSQL, strings.Join(queries, "\n"), 0)
+	defer done()
+
+	results, err := h.postgreSQLService.ExecuteTransaction(c.Request.Context(), statements)
+	duration := float64(time.Since(startTime).Nanoseconds()) / 1e6
+
+	if err != nil {
+		log.Printf("❌ [pgtransaction] Rolled back: %v", err)
+		if status, message, ok := queryInterrupted(c, "pgtransaction", 0); ok {
+			c.JSON(status, models.TransactionResponse{
+				Success:  false,
+				Message:  "Transaction rolled back, no changes were written",
+				Results:  results,
+				Duration: duration,
+				Error:    message,
+			})
+			return
+		}
+		response := models.TransactionResponse{
+			Success:  false,
+			Message:  "Transaction rolled back, no changes were written",
+			Results:  results,
+			Duration: duration,
+			Error:    err.Error(),
+		}
+		if n := len(results); n > 0 && results[n-1].Error != "" {
+			failed := n - 1
+			response.FailedStatement = &failed
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	log.Printf("✅ [pgtransaction] Committed %d statements in %.2fms", len(results), duration)
+	var affected int64
+	for _, result := range results {
+		if result.RowsAffected != nil {
+			affected += *result.RowsAffected
+		}
+	}
+	middleware.AuditRows(c, affected)
+	for _, statement := range statements {
+		h.invalidateSelectCache("pgtransaction", services.ServicePostgreSQL, statement.Query)
+	}
+
+	c.JSON(http.StatusOK, models.TransactionResponse{
+		Success:   true,
+		Message:   fmt.Sprintf("Transaction committed: %d statements", len(results)),
+		Committed: true,
+		Results:   results,
+		Duration:  duration,
+	})
+}
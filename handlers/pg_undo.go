@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+
+	"smlgoapi/middleware"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListUndoOperations godoc
+// @Summary Undoable /pgcommand operations
+// @Description UPDATE and DELETE statements sent to /pgcommand on the tables of undo.tables, whose previous rows were kept. Newest first.
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Operations per page (page_limits)"
+// @Param offset query int false "Operations to skip"
+// @Success 200 {object} models.APIResponse{data=models.UndoPage}
+// @Failure 503 {object} models.APIResponse
+// @Router /admin/undo [get]
+func (h *APIHandler) ListUndoOperations(c *gin.Context) {
+	if !h.requireUndo(c) {
+		return
+	}
+
+	limit := h.queryLimit(c)
+	offset := queryIntBounded(c, "offset", 0, 0, math.MaxInt32)
+	operations, total, err := h.undoService.List(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.undoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: models.UndoPage{
+			Operations: operations,
+			Total:      total,
+			Limit:      limit,
+			Offset:     offset,
+		},
+		Message: fmt.Sprintf("%d of %d operations", len(operations), total),
+	})
+}
+
+// GetUndoOperation godoc
+// @Summary An undoable /pgcommand operation
+// @Description The operation with the rows it kept, as they were before the statement ran
+// @Tags admin
+// @Produce json
+// @Param id path string true "Undo operation id, returned by /pgcommand as result.undo_operation"
+// @Param limit query int false "Kept rows to return (page_limits)"
+// @Success 200 {object} models.APIResponse{data=models.UndoOperation}
+// @Failure 404 {object} models.APIResponse
+// @Router /admin/undo/{id} [get]
+func (h *APIHandler) GetUndoOperation(c *gin.Context) {
+	if !h.requireUndo(c) {
+		return
+	}
+
+	operation, err := h.undoService.Get(c.Request.Context(), c.Param("id"), h.queryLimit(c))
+	if err != nil {
+		h.undoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    operation,
+	})
+}
+
+// RestoreUndoOperation godoc
+// @Summary Undo a /pgcommand operation
+// @Description Put back the rows kept by an UPDATE or DELETE: deleted rows are inserted again and updated rows get their previous values, matched by primary key. Each operation can be restored once.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Undo operation id"
+// @Success 200 {object} models.APIResponse{data=models.UndoOperation}
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Router /admin/undo/{id} [post]
+func (h *APIHandler) RestoreUndoOperation(c *gin.Context) {
+	if !h.requireUndo(c) {
+		return
+	}
+
+	operation, err := h.undoService.Restore(c.Request.Context(), c.Param("id"), shareCreator(c))
+	if err != nil {
+		h.undoError(c, err)
+		return
+	}
+
+	log.Printf("↩️ [undo] Restored %d of %d rows of %s (operation %s)", *operation.RestoredRows, operation.Rows, operation.Table, operation.ID)
+	middleware.AuditRows(c, *operation.RestoredRows)
+	if removed := h.selectCache.Invalidate(services.ServicePostgreSQL, operation.Table); removed > 0 {
+		log.Printf("🧹 [undo] Dropped %d cached SELECT results", removed)
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    operation,
+		Message: fmt.Sprintf("Restored %d of %d rows of %s", *operation.RestoredRows, operation.Rows, operation.Table),
+	})
+}
+
+func (h *APIHandler) undoError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrUndoNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrUndoRestored):
+		status = http.StatusConflict
+	default:
+		log.Printf("❌ [undo] %s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
+
+func (h *APIHandler) requireUndo(c *gin.Context) bool {
+	if h.undoService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Undo requires PostgreSQL and tables listed in undo.tables",
+		})
+		return false
+	}
+	return true
+}
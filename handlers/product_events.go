@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"smlgoapi/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordProductView godoc
+// @Summary Record a product view event
+// @Description Track a product view posted by a frontend for trending and recently viewed lists
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param event body models.ProductViewRequest true "Product view event"
+// @Success 200 {object} models.APIResponse
+// @Router /events/view [post]
+func (h *APIHandler) RecordProductView(c *gin.Context) {
+	if h.productEventService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Product events require ClickHouse, which is unavailable",
+		})
+		return
+	}
+
+	var req models.ProductViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	req.ICCode = strings.TrimSpace(req.ICCode)
+	if req.ClientID == "" {
+		req.ClientID = c.GetHeader("X-Client-ID")
+	}
+
+	if err := h.productEventService.RecordView(c.Request.Context(), req); err != nil {
+		log.Printf("❌ [events] Failed to record view for %s: %v", req.ICCode, err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Product view recorded",
+	})
+}
+
+// GetTrendingProducts godoc
+// @Summary Get trending products
+// @Description Most viewed products over the last N days, enriched with product data
+// @Tags products
+// @Produce json
+// @Param days query int false "Look-back window in days (default 7, max 90)"
+// @Param limit query int false "Number of products (default 20, max 100 unless page_limits says otherwise)"
+// @Success 200 {object} models.APIResponse{data=[]models.TrendingProduct}
+// @Router /products/trending [get]
+func (h *APIHandler) GetTrendingProducts(c *gin.Context) {
+	if h.productEventService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Product events require ClickHouse, which is unavailable",
+		})
+		return
+	}
+
+	days := queryIntBounded(c, "days", 7, 1, 90)
+	limit := h.queryLimit(c)
+	ctx := c.Request.Context()
+
+	trending, err := h.productEventService.GetTrending(ctx, days, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	h.attachProductDetails(c, trending)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    trending,
+		Message: "Retrieved " + strconv.Itoa(len(trending)) + " trending products",
+	})
+}
+
+// GetRecentlyViewedProducts godoc
+// @Summary Get recently viewed products for a client
+// @Description Products most recently viewed by the client identified by client_id or the X-Client-ID header
+// @Tags products
+// @Produce json
+// @Param client_id query string false "Client identifier (or X-Client-ID header)"
+// @Param limit query int false "Number of products (default 20, max 100 unless page_limits says otherwise)"
+// @Success 200 {object} models.APIResponse{data=[]models.TrendingProduct}
+// @Router /products/recently-viewed [get]
+func (h *APIHandler) GetRecentlyViewedProducts(c *gin.Context) {
+	if h.productEventService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Product events require ClickHouse, which is unavailable",
+		})
+		return
+	}
+
+	clientID := c.Query("client_id")
+	if clientID == "" {
+		clientID = c.GetHeader("X-Client-ID")
+	}
+	if clientID == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "client_id query parameter or X-Client-ID header is required",
+		})
+		return
+	}
+
+	limit := h.queryLimit(c)
+
+	recent, err := h.productEventService.GetRecentlyViewed(c.Request.Context(), clientID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	h.attachProductDetails(c, recent)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    recent,
+		Message: "Retrieved " + strconv.Itoa(len(recent)) + " recently viewed products",
+	})
+}
+
+// attachProductDetails enriches view statistics with product data from PostgreSQL.
+// Enrichment is best effort: failures are logged and the statistics are returned as-is.
+func (h *APIHandler) attachProductDetails(c *gin.Context, items []models.TrendingProduct) {
+	if h.postgreSQLService == nil || len(items) == 0 {
+		return
+	}
+
+	codes := make([]string, len(items))
+	for i, item := range items {
+		codes[i] = item.ICCode
+	}
+
+	rows, _, err := h.postgreSQLService.SearchProductsByBarcodesWithRelevanceAndBarcodeMap(c.Request.Context(), codes, nil, nil, len(codes), 0)
+	if err != nil {
+		log.Printf("⚠️ [events] Failed to load product details: %v", err)
+		return
+	}
+
+	products := convertSearchResults(rows)
+	h.applyProductImages(c.Request.Context(), products)
+	productsByCode := make(map[string]interface{}, len(rows))
+	for _, product := range products {
+		productsByCode[product.Code] = product
+	}
+	for i := range items {
+		if product, ok := productsByCode[items[i].ICCode]; ok {
+			items[i].Product = product
+		}
+	}
+}
+
+// queryIntBounded reads an integer query parameter, falling back to def and clamping to [min, max]
+func queryIntBounded(c *gin.Context, key string, def, min, max int) int {
+	value, err := strconv.Atoi(c.Query(key))
+	if err != nil {
+		return def
+	}
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListProductImages godoc
+// @Summary List product images
+// @Description Images linked to a product in product_images, first image first
+// @Tags products
+// @Produce json
+// @Param code path string true "ic_code"
+// @Success 200 {object} models.APIResponse{data=[]models.ProductImage}
+// @Failure 503 {object} models.APIResponse
+// @Router /products/{code}/images [get]
+func (h *APIHandler) ListProductImages(c *gin.Context) {
+	if !h.requireProductImages(c) {
+		return
+	}
+
+	code := strings.TrimSpace(c.Param("code"))
+	images, err := h.productImageService.List(c.Request.Context(), code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    images,
+		Message: fmt.Sprintf("Retrieved %d images of %s", len(images), code),
+	})
+}
+
+// AddProductImage godoc
+// @Summary Add a product image
+// @Description Link an image URL (JSON body) or upload a JPEG, PNG, GIF or WebP file (multipart field "file", optional "sort_order"). Uploaded files are served from /v1/product-images/{id}.
+// @Tags products
+// @Accept json,mpfd
+// @Produce json
+// @Param code path string true "ic_code"
+// @Param request body models.ProductImageRequest false "Image URL"
+// @Success 201 {object} models.APIResponse{data=models.ProductImage}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 413 {object} models.APIResponse
+// @Router /products/{code}/images [post]
+func (h *APIHandler) AddProductImage(c *gin.Context) {
+	if !h.requireProductImages(c) {
+		return
+	}
+
+	code := strings.TrimSpace(c.Param("code"))
+	ctx := c.Request.Context()
+	var image *models.ProductImage
+	var err error
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		image, err = h.uploadProductImage(c, code)
+	} else {
+		var req models.ProductImageRequest
+		if bindErr := c.ShouldBindJSON(&req); bindErr != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Invalid request format: " + bindErr.Error(),
+			})
+			return
+		}
+		image, err = h.productImageService.AddURL(ctx, code, req, shareCreator(c))
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrInvalidProductImage):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrProductNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Message: "Failed to add product image",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("🖼️ [products] Added image %d to %s (uploaded: %t)", image.ID, code, image.Uploaded)
+	h.productUpdated(code, "images", map[string]interface{}{"action": "image_added", "image_id": image.ID})
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    image,
+		Message: fmt.Sprintf("Image added to %s", code),
+	})
+}
+
+// uploadProductImage stores the "file" field of a multipart request
+func (h *APIHandler) uploadProductImage(c *gin.Context, code string) (*models.ProductImage, error) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("%w: multipart field \"file\": %v", services.ErrInvalidProductImage, err)
+	}
+	sortOrder := 0
+	if value := c.PostForm("sort_order"); value != "" {
+		if sortOrder, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("%w: sort_order must be an integer", services.ErrInvalidProductImage)
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return h.productImageService.Upload(c.Request.Context(), code, data, sortOrder, shareCreator(c))
+}
+
+// DeleteProductImage godoc
+// @Summary Delete a product image
+// @Tags products
+// @Produce json
+// @Param code path string true "ic_code"
+// @Param id path int true "Image id"
+// @Success 200 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /products/{code}/images/{id} [delete]
+func (h *APIHandler) DeleteProductImage(c *gin.Context) {
+	if !h.requireProductImages(c) {
+		return
+	}
+
+	code := strings.TrimSpace(c.Param("code"))
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err == nil {
+		err = h.productImageService.Delete(c.Request.Context(), code, id)
+	} else {
+		err = services.ErrProductImageNotFound
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrProductImageNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("image %s of %s: %s", c.Param("id"), code, err.Error()),
+		})
+		return
+	}
+
+	log.Printf("🖼️ [products] Deleted image %d of %s", id, code)
+	h.productUpdated(code, "images", map[string]interface{}{"action": "image_deleted", "image_id": id})
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Image %d of %s deleted", id, code),
+	})
+}
+
+// GetProductImageFile godoc
+// @Summary Get an uploaded product image
+// @Description Serves the file of an uploaded product image. No authentication, so the URL works in img tags.
+// @Tags products
+// @Produce image/jpeg,image/png,image/gif,image/webp
+// @Param id path int true "Image id"
+// @Success 200 {file} binary
+// @Failure 404 {object} models.APIResponse
+// @Router /product-images/{id} [get]
+func (h *APIHandler) GetProductImageFile(c *gin.Context) {
+	if !h.requireProductImages(c) {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	var contentType string
+	var data []byte
+	if err == nil {
+		contentType, data, err = h.productImageService.File(c.Request.Context(), id)
+	} else {
+		err = services.ErrProductImageNotFound
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrProductImageNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// An id always names the same bytes; replacing an image creates a new id
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// FindDuplicateImages godoc
+// @Summary Find duplicate product images
+// @Description Group uploaded images of different products whose perceptual hashes (dHash) are at most max_distance bits apart, to find duplicated SKUs. Linked image URLs are not downloaded and take no part.
+// @Tags products
+// @Produce json
+// @Param max_distance query int false "Largest Hamming distance between duplicates, 0-16 (default 6)"
+// @Success 200 {object} models.APIResponse{data=models.ImageDuplicatesResponse}
+// @Failure 503 {object} models.APIResponse
+// @Router /images/duplicates [get]
+func (h *APIHandler) FindDuplicateImages(c *gin.Context) {
+	if !h.requireProductImages(c) {
+		return
+	}
+
+	maxDistance := queryIntBounded(c, "max_distance", 6, 0, 16)
+	result, err := h.productImageService.Duplicates(c.Request.Context(), maxDistance)
+	if err != nil {
+		log.Printf("❌ [products] Duplicate image search failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+		Message: fmt.Sprintf("Found %d duplicate groups among %d images", len(result.Groups), result.Compared),
+	})
+}
+
+// requireProductImages answers 503 when product images are unavailable
+func (h *APIHandler) requireProductImages(c *gin.Context) bool {
+	if h.productImageService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Product images require PostgreSQL",
+		})
+		return false
+	}
+	return true
+}
+
+// applyProductImages sets the img_url of results without an image to the first image in
+// product_images. Images are optional, so a failure is logged and the results are left as they were.
+func (h *APIHandler) applyProductImages(ctx context.Context, results []services.SearchResult) {
+	if h.productImageService == nil || len(results) == 0 {
+		return
+	}
+
+	var codes []string
+	var collect func(results []services.SearchResult)
+	collect = func(results []services.SearchResult) {
+		for _, result := range results {
+			codes = append(codes, result.Code)
+			collect(result.Variants)
+		}
+	}
+	collect(results)
+
+	images, err := h.productImageService.FirstImages(ctx, codes)
+	if err != nil {
+		log.Printf("⚠️ [products] Failed to load images for %d results: %v", len(codes), err)
+		return
+	}
+
+	var apply func(results []services.SearchResult)
+	apply = func(results []services.SearchResult) {
+		for i := range results {
+			if url, ok := images[results[i].Code]; ok && (results[i].ImgURL == "" || results[i].ImgURL == "N/A") {
+				results[i].ImgURL = url
+			}
+			apply(results[i].Variants)
+		}
+	}
+	apply(results)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"smlgoapi/middleware"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportProducts godoc
+// @Summary Import products from CSV or XLSX
+// @Description Upsert products, barcodes and prices from the multipart field "file" in one transaction. The header names ic_inventory columns (code is required), barcode, unit_code and price_0 to price_4; empty cells leave stored values unchanged.
+// @Description Rows that fail are rolled back alone and reported; ?report=csv downloads them with an error column instead of the JSON summary.
+// @Tags admin
+// @Accept mpfd
+// @Produce json,text/csv
+// @Param file formData file true "CSV or XLSX file"
+// @Param dry_run query bool false "Check every row against the database, then roll back"
+// @Param report query string false "csv: respond with the rejected rows"
+// @Success 200 {object} models.APIResponse{data=models.ProductImportResult}
+// @Failure 400 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /admin/import/products [post]
+func (h *APIHandler) ImportProducts(c *gin.Context) {
+	if h.postgreSQLService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Product import requires PostgreSQL",
+		})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Upload the CSV or XLSX file in the multipart field \"file\": " + err.Error(),
+		})
+		return
+	}
+	file, err := header.Open()
+	if err == nil {
+		defer file.Close()
+	}
+	var data []byte
+	if err == nil {
+		data, err = io.ReadAll(file)
+	}
+	var table *services.ImportTable
+	if err == nil {
+		table, err = services.ParseImportFile(header.Filename, data)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	middleware.AuditDetail(c, fmt.Sprintf("%s: %d rows (dry run: %t)", header.Filename, len(table.Rows), dryRun))
+	log.Printf("📥 [import] %s: %d rows (dry run: %t)", header.Filename, len(table.Rows), dryRun)
+	done := h.operations.Track("import", header.Filename)
+	defer done()
+	result, err := h.postgreSQLService.ImportProducts(c.Request.Context(), header.Filename, table, dryRun, h.importProgress)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidImport) {
+			status = http.StatusBadRequest
+		}
+		log.Printf("❌ [import] %s failed: %v", header.Filename, err)
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Message: "Import failed; nothing was written",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("✅ [import] %s: %d imported (%d new, %d updated products), %d rejected",
+		header.Filename, result.Imported, result.ProductsInserted, result.ProductsUpdated, result.Rejected)
+	if !dryRun {
+		middleware.AuditRows(c, int64(result.Imported))
+	}
+	if !dryRun && result.Imported > 0 {
+		for _, table := range []string{"ic_inventory", "ic_inventory_barcode", "ic_inventory_price_formula"} {
+			if removed := h.selectCache.Invalidate(services.ServicePostgreSQL, table); removed > 0 {
+				log.Printf("🧹 [import] Dropped %d cached SELECT results of %s", removed, table)
+			}
+		}
+	}
+	if !dryRun {
+		h.webhookService.Emit(models.WebhookImportCompleted, gin.H{
+			"file":              header.Filename,
+			"rows":              result.Rows,
+			"imported":          result.Imported,
+			"rejected":          result.Rejected,
+			"products_inserted": result.ProductsInserted,
+			"products_updated":  result.ProductsUpdated,
+			"by":                shareCreator(c),
+		})
+	}
+
+	if c.Query("report") == "csv" {
+		writeImportReport(c, table, result)
+		return
+	}
+
+	message := fmt.Sprintf("Imported %d of %d rows, %d rejected", result.Imported, result.Rows, result.Rejected)
+	if dryRun {
+		message = fmt.Sprintf("Dry run: %d of %d rows would be imported, %d rejected; nothing was written",
+			result.Imported, result.Rows, result.Rejected)
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+		Message: message,
+	})
+}
+
+// writeImportReport responds with the rejected rows as they were uploaded plus their error
+func writeImportReport(c *gin.Context, table *services.ImportTable, result *models.ProductImportResult) {
+	c.Header("Content-Disposition", `attachment; filename="`+exportFilename(c, "import-errors", "csv")+`"`)
+	c.Header("X-Import-Rows", strconv.Itoa(result.Rows))
+	c.Header("X-Import-Imported", strconv.Itoa(result.Imported))
+	c.Header("X-Import-Rejected", strconv.Itoa(result.Rejected))
+	c.Status(http.StatusOK)
+	c.Writer.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	io.WriteString(c.Writer, "\uFEFF") // so Excel shows Thai text correctly
+
+	records := make(map[int][]string, len(table.Rows))
+	for i, line := range table.Lines {
+		records[line] = table.Rows[i]
+	}
+	w := csv.NewWriter(c.Writer)
+	w.Write(append(append([]string{"row"}, table.Header...), "error"))
+	for _, rejected := range result.Errors {
+		record := make([]string, len(table.Header))
+		copy(record, records[rejected.Row])
+		w.Write(append(append([]string{strconv.Itoa(rejected.Row)}, record...), rejected.Error))
+	}
+	w.Flush()
+}
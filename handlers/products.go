@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProductDetail godoc
+// @Summary Get product detail
+// @Description Full product record by ic_code or barcode: ic_inventory columns, barcodes, price tiers, per-warehouse balances and image URLs
+// @Tags products
+// @Produce json
+// @Param code path string true "ic_code or barcode"
+// @Success 200 {object} models.APIResponse{data=models.ProductDetail}
+// @Failure 404 {object} models.APIResponse
+// @Router /products/{code} [get]
+func (h *APIHandler) GetProductDetail(c *gin.Context) {
+	if h.postgreSQLService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Product detail requires PostgreSQL, which is unavailable",
+		})
+		return
+	}
+
+	code := strings.TrimSpace(c.Param("code"))
+	detail, err := h.productDetail(c.Request.Context(), code)
+	if errors.Is(err, services.ErrProductNotFound) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "No product with ic_code or barcode '" + code + "'",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ [products] Failed to load detail for %s: %v", code, err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    detail,
+	})
+}
+
+// productDetail loads a product by ic_code or barcode. Images managed through
+// /v1/products/{code}/images come before those in ic_inventory.
+func (h *APIHandler) productDetail(ctx context.Context, code string) (*models.ProductDetail, error) {
+	detail, err := h.postgreSQLService.GetProductDetail(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if h.productImageService != nil {
+		images, err := h.productImageService.List(ctx, detail.ICCode)
+		if err != nil {
+			log.Printf("⚠️ [products] Failed to load images of %s: %v", detail.ICCode, err)
+		}
+		urls := make([]string, 0, len(images)+len(detail.Images))
+		for _, image := range images {
+			urls = append(urls, image.URL)
+		}
+		detail.Images = append(urls, detail.Images...)
+	}
+	return detail, nil
+}
+
+// GetProductsBatch godoc
+// @Summary Look up many products at once
+// @Description Resolve up to 500 ic_codes or barcodes in one call, with price, stock and barcodes
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param request body models.ProductBatchRequest true "Codes or barcodes"
+// @Success 200 {object} models.APIResponse{data=models.ProductBatchResponse}
+// @Failure 400 {object} models.APIResponse
+// @Router /products/batch [post]
+func (h *APIHandler) GetProductsBatch(c *gin.Context) {
+	if h.postgreSQLService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Product lookup requires PostgreSQL, which is unavailable",
+		})
+		return
+	}
+
+	var req models.ProductBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	// Trim and drop duplicates; the response keeps the order of first appearance
+	seen := make(map[string]bool, len(req.Codes))
+	codes := make([]string, 0, len(req.Codes))
+	for _, code := range req.Codes {
+		code = strings.TrimSpace(code)
+		if code != "" && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 || len(codes) > models.MaxProductBatchSize {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("codes must contain between 1 and %d distinct values, got %d", models.MaxProductBatchSize, len(codes)),
+		})
+		return
+	}
+
+	result, err := h.postgreSQLService.GetProductsBatch(c.Request.Context(), codes)
+	if err != nil {
+		log.Printf("❌ [products] Batch lookup of %d codes failed: %v", len(codes), err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("📦 [products] Batch lookup: %d requested, %d found", len(codes), len(result.Products))
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusClientClosedRequest records a request whose client went away before the answer, as nginx does
+const statusClientClosedRequest = 499
+
+// withQueryTimeout bounds the statement of a SQL request by its timeout_ms, or by
+// sql_limits.default_timeout_ms without it, and lists it in the running-query registry. The
+// request context is replaced, so exports and the statement itself run under the deadline;
+// ClickHouse and PostgreSQL cancel the statement on the server when it passes, the client
+// disconnects or an admin cancels it.
+func (h *APIHandler) withQueryTimeout(c *gin.Context, database, query string, timeoutMs int) (time.Duration, context.CancelFunc, error) {
+	ctx, timeout, cancel, err := h.queryTimeoutContext(c.Request.Context(), c.FullPath(), database, query, shareCreator(c), timeoutMs)
+	if err != nil {
+		return 0, nil, err
+	}
+	c.Request = c.Request.WithContext(ctx)
+	return timeout, cancel, nil
+}
+
+// queryTimeoutContext is withQueryTimeout for statements run outside a gin handler, by gRPC
+// calls and WebSocket subscriptions; the statement must run under the returned context
+func (h *APIHandler) queryTimeoutContext(ctx context.Context, route, database, query, user string, timeoutMs int) (context.Context, time.Duration, context.CancelFunc, error) {
+	if timeoutMs < 0 {
+		return nil, 0, nil, errors.New("timeout_ms must not be negative")
+	}
+	timeout := h.config.SQLLimits.Timeout(timeoutMs)
+	// The route's handler deadline (limits.routes) or the gRPC deadline still applies when it is sooner
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline).Truncate(time.Millisecond)
+	}
+	ctx, done := h.trackQueryContext(ctx, route, database, query, user, timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, timeout, func() {
+		cancel()
+		done()
+	}, nil
+}
+
+// trackQuery lists the statement of the request in the running-query registry until done is
+// called, and replaces the request context with one an admin can cancel
+func (h *APIHandler) trackQuery(c *gin.Context, database, query string, timeout time.Duration) (done func()) {
+	ctx, done := h.trackQueryContext(c.Request.Context(), c.FullPath(), database, query, shareCreator(c), timeout)
+	c.Request = c.Request.WithContext(ctx)
+	return done
+}
+
+// trackQueryContext lists a statement in the running-query registry until done is called. The
+// statement must run under the returned context, which an admin can cancel.
+func (h *APIHandler) trackQueryContext(ctx context.Context, route, database, query, user string, timeout time.Duration) (context.Context, func()) {
+	drained := h.operations.Track("query", route)
+	ctx, done := h.runningQueries.Track(ctx, models.RunningQuery{
+		Database:  database,
+		Route:     route,
+		Query:     query,
+		User:      user,
+		TimeoutMs: timeout.Milliseconds(),
+	})
+	return ctx, func() {
+		done()
+		drained()
+	}
+}
+
+// queryInterrupted tells whether a statement failed because the request context ended, and the
+// status and error to answer with: 409 when an admin cancelled it, 503 when a shutdown did, 408
+// after the timeout, 499 when the client disconnected
+func queryInterrupted(c *gin.Context, logTag string, timeout time.Duration) (int, string, bool) {
+	ctx := c.Request.Context()
+	switch err := ctx.Err(); {
+	case errors.Is(context.Cause(ctx), services.ErrQueryCancelled):
+		log.Printf("🛑 [%s] Query cancelled by an administrator", logTag)
+		return http.StatusConflict, "Query cancelled by an administrator", true
+	case errors.Is(context.Cause(ctx), services.ErrShuttingDown):
+		log.Printf("🛑 [%s] Query cancelled: the server is shutting down", logTag)
+		return http.StatusServiceUnavailable, "Query cancelled: the server is shutting down", true
+	case errors.Is(err, context.DeadlineExceeded) && timeout <= 0:
+		return http.StatusRequestTimeout, "Query cancelled: the request timed out", true
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("⏱️ [%s] Query cancelled after %s", logTag, timeout)
+		return http.StatusRequestTimeout, fmt.Sprintf("Query cancelled: it did not finish within %s", timeout), true
+	case errors.Is(err, context.Canceled):
+		log.Printf("🔌 [%s] Query cancelled: the client disconnected", logTag)
+		return statusClientClosedRequest, "Query cancelled: the client disconnected", true
+	}
+	return 0, "", false
+}
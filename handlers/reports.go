@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStockAging godoc
+// @Summary Stock aging report
+// @Description On-hand quantities per warehouse bucketed by goods-receipt age (0-30, 31-90, 91-180 and over 180 days), oldest stock assumed sold first. Streamed as CSV by default.
+// @Tags reports
+// @Produce text/csv
+// @Param wh_code query string false "Only this warehouse"
+// @Param level query string false "warehouse (default) or item for one row per product and warehouse"
+// @Param format query string false "csv (default), ndjson or xlsx"
+// @Param filename query string false "Download file name"
+// @Success 200 {string} string "CSV report"
+// @Failure 400 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /reports/stock-aging [get]
+func (h *APIHandler) GetStockAging(c *gin.Context) {
+	if h.postgreSQLService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Stock aging requires PostgreSQL, which is unavailable",
+		})
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", selectFormatCSV))
+	if format != selectFormatCSV && format != selectFormatNDJSON && format != selectFormatXLSX {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid format: use csv, ndjson or xlsx",
+		})
+		return
+	}
+	opts := services.StockAgingOptions{Warehouse: strings.TrimSpace(c.Query("wh_code"))}
+	switch level := c.DefaultQuery("level", "warehouse"); level {
+	case "warehouse":
+	case "item":
+		opts.ByItem = true
+	default:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid level: use warehouse or item, got '" + level + "'",
+		})
+		return
+	}
+
+	query, params, err := h.postgreSQLService.StockAgingQuery(c.Request.Context(), h.config.Reports.StockAging, opts)
+	if errors.Is(err, services.ErrStockAgingUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ [stock-aging] Failed to build report: %v", err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("📦 [stock-aging] Exporting %s report (warehouse: %q, by item: %t)", format, opts.Warehouse, opts.ByItem)
+	exportRows(c, "stock-aging", format, query, params, h.postgreSQLService.StreamSelect)
+}
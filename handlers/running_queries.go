@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"smlgoapi/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListRunningQueries godoc
+// @Summary SQL statements in progress
+// @Description Statements running on /select, /selectget, /pgselect, /command, /pgcommand and /pgtransaction of this instance, the longest running first
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.RunningQuery}
+// @Router /admin/running-queries [get]
+func (h *APIHandler) ListRunningQueries(c *gin.Context) {
+	queries := h.runningQueries.List()
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    queries,
+		Message: fmt.Sprintf("%d running queries", len(queries)),
+	})
+}
+
+// CancelRunningQuery godoc
+// @Summary Cancel a SQL statement in progress
+// @Description Cancels the statement on the database; its request answers 409
+// @Tags admin
+// @Produce json
+// @Param id path string true "Running query id"
+// @Success 200 {object} models.APIResponse{data=models.RunningQuery}
+// @Failure 404 {object} models.APIResponse
+// @Router /admin/running-queries/{id} [delete]
+func (h *APIHandler) CancelRunningQuery(c *gin.Context) {
+	query, err := h.runningQueries.Cancel(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("🛑 [admin] Cancelling query %s of %s (request %s): %s", query.ID, query.Route, query.RequestID, query.Query)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    query,
+		Message: fmt.Sprintf("Query %s is being cancelled", query.ID),
+	})
+}
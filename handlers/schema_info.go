@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// schemaDescriber is implemented by the PostgreSQL and ClickHouse services
+type schemaDescriber interface {
+	DescribeTables(ctx context.Context, schema string) ([]models.TableInfo, error)
+	DescribeTable(ctx context.Context, schema, name string) (*models.TableSchema, error)
+}
+
+// ListSchemaTables godoc
+// @Summary Tables of a database
+// @Description Tables and views with their kind (or ClickHouse engine), estimated row count and size. Tables the SQL policy denies are left out.
+// @Tags database
+// @Produce json
+// @Param db path string true "postgresql or clickhouse"
+// @Param schema query string false "PostgreSQL schema or ClickHouse database (default: public, or the configured ClickHouse database)"
+// @Success 200 {object} models.APIResponse{data=[]models.TableInfo}
+// @Failure 400 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /schema/{db}/tables [get]
+func (h *APIHandler) ListSchemaTables(c *gin.Context) {
+	describer, defaultSchema, ok := h.schemaDescriber(c)
+	if !ok {
+		return
+	}
+	schema := c.DefaultQuery("schema", defaultSchema)
+
+	tables, err := describer.DescribeTables(c.Request.Context(), schema)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	allowed := tables[:0]
+	for _, table := range tables {
+		if h.sqlPolicyService.AllowsTable(table.Schema+"."+table.Name, defaultSchema) {
+			allowed = append(allowed, table)
+		}
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    allowed,
+		Message: fmt.Sprintf("%d tables in %s", len(allowed), schema),
+	})
+}
+
+// GetSchemaTable godoc
+// @Summary Columns and indexes of a table
+// @Description Columns with their types, nullability and defaults, indexes (ClickHouse: data skipping indexes), primary key and ClickHouse sorting key
+// @Tags database
+// @Produce json
+// @Param db path string true "postgresql or clickhouse"
+// @Param name path string true "Table name, optionally qualified with the schema or database"
+// @Success 200 {object} models.APIResponse{data=models.TableSchema}
+// @Failure 400 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /schema/{db}/tables/{name} [get]
+func (h *APIHandler) GetSchemaTable(c *gin.Context) {
+	describer, defaultSchema, ok := h.schemaDescriber(c)
+	if !ok {
+		return
+	}
+	schema, name := defaultSchema, c.Param("name")
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		schema, name = name[:i], name[i+1:]
+	}
+
+	if !h.sqlPolicyService.AllowsTable(schema+"."+name, defaultSchema) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("access to table '%s.%s' is denied by the SQL policy", schema, name),
+		})
+		return
+	}
+
+	table, err := describer.DescribeTable(c.Request.Context(), schema, name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrTableNotFound) {
+			status = http.StatusNotFound
+			err = fmt.Errorf("table '%s.%s' not found", schema, name)
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    table,
+	})
+}
+
+// schemaDescriber picks the database named by the :db parameter and its default schema
+func (h *APIHandler) schemaDescriber(c *gin.Context) (schemaDescriber, string, bool) {
+	var describer schemaDescriber
+	var defaultSchema string
+	switch db := strings.ToLower(c.Param("db")); db {
+	case services.ServicePostgreSQL:
+		if h.postgreSQLService != nil {
+			describer, defaultSchema = h.postgreSQLService, postgreSQLDefaultSchema
+		}
+	case services.ServiceClickHouse:
+		if h.clickHouseService != nil {
+			describer, defaultSchema = h.clickHouseService, h.config.ClickHouse.Database
+		}
+	default:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("invalid database: %s (use %s or %s)", db, services.ServicePostgreSQL, services.ServiceClickHouse),
+		})
+		return nil, "", false
+	}
+	if describer == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("%s is not connected", c.Param("db")),
+		})
+		return nil, "", false
+	}
+	return describer, defaultSchema, true
+}
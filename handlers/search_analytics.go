@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordSearch counts a /search-by-vector response in the metrics and queues it for search analytics
+func (h *APIHandler) recordSearch(c *gin.Context, method, query string, offset int, startTime time.Time, results *services.VectorSearchResponse) {
+	h.recordSearchEvent(services.SearchEvent{
+		Time:        startTime,
+		Endpoint:    c.FullPath(),
+		Query:       query,
+		Method:      method,
+		ResultCount: results.EstimatedTotal,
+		Returned:    len(results.Data),
+		Offset:      offset,
+		LatencyMs:   time.Since(startTime).Seconds() * 1000,
+		ClientID:    c.GetHeader("X-Client-ID"),
+		Partial:     results.Partial,
+	})
+}
+
+func (h *APIHandler) recordSearchEvent(event services.SearchEvent) {
+	h.searchMetrics.observeSearch(event)
+	h.searchAnalytics.Record(event)
+}
+
+// searchAnalyticsUnavailable answers analytics requests when searches are not being recorded
+func (h *APIHandler) searchAnalyticsUnavailable(c *gin.Context) bool {
+	if h.searchAnalytics != nil {
+		return false
+	}
+	c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+		Success: false,
+		Error:   "Search analytics require ClickHouse and search.analytics enabled",
+	})
+	return true
+}
+
+// analyticsRange reads the reporting window: from/to dates (YYYY-MM-DD, both inclusive) or the last
+// days days up to now. It answers 400 and returns false when the dates are invalid.
+func analyticsRange(c *gin.Context, defaultDays int) (time.Time, time.Time, bool) {
+	now := time.Now()
+	fromParam, toParam := c.Query("from"), c.Query("to")
+	if fromParam == "" && toParam == "" {
+		return now.AddDate(0, 0, -queryIntBounded(c, "days", defaultDays, 1, 90)), now, true
+	}
+
+	invalid := func(message string) (time.Time, time.Time, bool) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid date range: " + message,
+		})
+		return time.Time{}, time.Time{}, false
+	}
+	to := now
+	if toParam != "" {
+		day, err := time.ParseInLocation(time.DateOnly, toParam, time.Local)
+		if err != nil {
+			return invalid("to must be a date like 2026-01-31, got '" + toParam + "'")
+		}
+		to = day.AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -defaultDays)
+	if fromParam != "" {
+		day, err := time.ParseInLocation(time.DateOnly, fromParam, time.Local)
+		if err != nil {
+			return invalid("from must be a date like 2026-01-01, got '" + fromParam + "'")
+		}
+		from = day
+	}
+	if !from.Before(to) {
+		return invalid("from must not be after to")
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		return invalid("at most 366 days")
+	}
+	return from, to, true
+}
+
+// GetTopSearches godoc
+// @Summary Most searched queries
+// @Description Queries searched most often over the last N days or between two dates (first pages only, case-insensitive)
+// @Tags analytics
+// @Produce json
+// @Param days query int false "Look-back window in days (default 7, max 90)"
+// @Param from query string false "First day, YYYY-MM-DD (instead of days)"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Param limit query int false "Number of queries (default 20, max 100 unless page_limits says otherwise)"
+// @Success 200 {object} models.APIResponse{data=[]models.SearchQueryStats}
+// @Failure 400 {object} models.APIResponse
+// @Router /analytics/searches/top [get]
+func (h *APIHandler) GetTopSearches(c *gin.Context) {
+	if h.searchAnalyticsUnavailable(c) {
+		return
+	}
+	from, to, ok := analyticsRange(c, 7)
+	if !ok {
+		return
+	}
+
+	stats, err := h.searchAnalytics.TopQueries(c.Request.Context(), from, to, h.queryLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    stats,
+		Message: "Retrieved " + strconv.Itoa(len(stats)) + " queries",
+	})
+}
+
+// GetZeroResultSearches godoc
+// @Summary Zero-result query report
+// @Description Most frequent queries without any result over a date range, i.e. products customers look for but cannot find. Catalog managers use it to add missing synonyms or products.
+// @Tags analytics
+// @Produce json
+// @Param from query string false "First day, YYYY-MM-DD (default 30 days before to)"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Param days query int false "Look-back window in days when from and to are not given (default 30, max 90)"
+// @Param limit query int false "Number of queries (default 20, max 100 unless page_limits says otherwise)"
+// @Success 200 {object} models.APIResponse{data=[]models.SearchQueryStats}
+// @Failure 400 {object} models.APIResponse
+// @Router /analytics/zero-results [get]
+func (h *APIHandler) GetZeroResultSearches(c *gin.Context) {
+	if h.searchAnalyticsUnavailable(c) {
+		return
+	}
+	from, to, ok := analyticsRange(c, 30)
+	if !ok {
+		return
+	}
+
+	stats, err := h.searchAnalytics.ZeroResultQueries(c.Request.Context(), from, to, h.queryLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    stats,
+		Message: fmt.Sprintf("Retrieved %d zero-result queries from %s to %s", len(stats),
+			from.Format(time.DateOnly), to.Add(-time.Nanosecond).Format(time.DateOnly)),
+	})
+}
+
+// GetSearchLatency godoc
+// @Summary Search latency percentiles
+// @Description p50/p90/p95/p99 search latency over the last N days or between two dates, overall ("all") and per method
+// @Tags analytics
+// @Produce json
+// @Param days query int false "Look-back window in days (default 1, max 90)"
+// @Param from query string false "First day, YYYY-MM-DD (instead of days)"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Success 200 {object} models.APIResponse{data=[]models.SearchLatencyStats}
+// @Failure 400 {object} models.APIResponse
+// @Router /analytics/searches/latency [get]
+func (h *APIHandler) GetSearchLatency(c *gin.Context) {
+	if h.searchAnalyticsUnavailable(c) {
+		return
+	}
+	from, to, ok := analyticsRange(c, 1)
+	if !ok {
+		return
+	}
+
+	stats, err := h.searchAnalytics.LatencyPercentiles(c.Request.Context(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    stats,
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+
+	"smlgoapi/services"
+)
+
+// searchPager fetches search pages either by offset or after the request's cursor, and remembers
+// the position after the last row handed out so the response can carry next_cursor
+type searchPager struct {
+	pg          *services.PostgreSQLService
+	fingerprint string
+	cursor      *services.SearchCursor // cursor the request resumes from; nil for offset paging
+	next        *services.SearchCursor
+	weights     services.RankingWeights
+	filters     services.SearchFilters
+}
+
+// textPage returns a page of the PostgreSQL text search. A text cursor resumes after its row;
+// any other cursor means the text results start from the beginning.
+func (p *searchPager) textPage(ctx context.Context, query string, limit, offset int) ([]map[string]interface{}, int, error) {
+	var rows []map[string]interface{}
+	var total int
+	var err error
+
+	switch {
+	case p.cursor == nil:
+		rows, total, err = p.pg.SearchProductsRanked(ctx, query, limit, offset, nil, p.weights, p.filters)
+	case p.cursor.Mode == services.SearchCursorText && p.cursor.Code != "":
+		rows, total, err = p.pg.SearchProductsRanked(ctx, query, limit, 0, p.cursor, p.weights, p.filters)
+	default:
+		rows, total, err = p.pg.SearchProductsRanked(ctx, query, limit, 0, nil, p.weights, p.filters)
+	}
+	if err == nil && len(rows) > 0 {
+		p.mark(services.SearchCursorText, rows[len(rows)-1])
+	}
+	return rows, total, err
+}
+
+// relevancePage returns a page of Weaviate-ranked products. A text cursor means the vector results
+// were already exhausted by earlier pages, so nothing is returned.
+func (p *searchPager) relevancePage(ctx context.Context, codes []string, relevanceMap map[string]float64, barcodeMap map[string]string, limit, offset int) ([]map[string]interface{}, int, error) {
+	var rows []map[string]interface{}
+	var total int
+	var err error
+
+	switch {
+	case p.cursor == nil:
+		rows, total, err = p.pg.SearchProductsByRelevanceRanked(ctx, codes, relevanceMap, barcodeMap, limit, offset, nil, p.weights, p.filters)
+	case p.cursor.Mode == services.SearchCursorText:
+		return []map[string]interface{}{}, 0, nil
+	case p.cursor.Code != "":
+		rows, total, err = p.pg.SearchProductsByRelevanceRanked(ctx, codes, relevanceMap, barcodeMap, limit, 0, p.cursor, p.weights, p.filters)
+	default:
+		rows, total, err = p.pg.SearchProductsByRelevanceRanked(ctx, codes, relevanceMap, barcodeMap, limit, 0, nil, p.weights, p.filters)
+	}
+	if err == nil && len(rows) > 0 {
+		p.mark(services.SearchCursorVector, rows[len(rows)-1])
+	}
+	return rows, total, err
+}
+
+// mark records row as the last row returned; call it before the row is modified
+func (p *searchPager) mark(mode string, row map[string]interface{}) {
+	p.next = services.NextSearchCursor(mode, p.fingerprint, 0, row)
+}
+
+// nextCursor returns the encoded cursor for the page after seen rows. Without a recorded row
+// (e.g. a page of priority matches only) it points at the start of the ranked results.
+func (p *searchPager) nextCursor(seen int, vectorAvailable bool) string {
+	next := p.next
+	if next == nil {
+		next = &services.SearchCursor{Mode: services.SearchCursorText, Query: p.fingerprint}
+		if vectorAvailable {
+			next.Mode = services.SearchCursorVector
+		}
+	}
+	next.Seen = seen
+	return next.Encode()
+}
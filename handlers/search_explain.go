@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Results inspected per stage when explaining a search
+const (
+	defaultExplainDepth = 300
+	maxExplainDepth     = 1000
+)
+
+// ExplainSearch godoc
+// @Summary Explain why a product does or does not match a query
+// @Description Runs each search stage (exact barcode, exact code, LIKE, Weaviate BM25, PostgreSQL text search, TF-IDF) for the query and reports whether and at which rank it returns the product, with the tokens of the query and the product name
+// @Tags search
+// @Produce json
+// @Param query query string true "Search text as typed by the user"
+// @Param code query string true "ic_code of the product expected in the results"
+// @Param limit query int false "Page size of the search being explained"
+// @Param depth query int false "Results inspected per stage (default 300, max 1000)"
+// @Success 200 {object} models.APIResponse{data=services.SearchExplanation}
+// @Failure 400 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /search/explain [get]
+func (h *APIHandler) ExplainSearch(c *gin.Context) {
+	query := c.Query("query")
+	code := strings.TrimSpace(c.Query("code"))
+	if strings.TrimSpace(query) == "" || code == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "query and code parameters are required",
+		})
+		return
+	}
+	if h.postgreSQLService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "PostgreSQL service not initialized",
+		})
+		return
+	}
+
+	limit := h.queryLimit(c)
+	depth, _ := strconv.Atoi(c.Query("depth"))
+	if depth <= 0 {
+		depth = defaultExplainDepth
+	}
+	depth = min(max(depth, limit), maxExplainDepth)
+
+	ctx := c.Request.Context()
+	explanation := &services.SearchExplanation{
+		Query:    query,
+		Code:     code,
+		Barcodes: []string{},
+		Depth:    depth,
+	}
+
+	detail, err := h.postgreSQLService.GetProductDetail(ctx, code)
+	switch {
+	case err == nil && detail.ICCode == code:
+		explanation.Exists = true
+		explanation.Name = getStringValue(detail.Inventory, "name")
+		for _, barcode := range detail.Barcodes {
+			explanation.Barcodes = append(explanation.Barcodes, barcode.Barcode)
+		}
+	case err != nil && !errors.Is(err, services.ErrProductNotFound):
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to load product",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if h.vectorDB != nil {
+		queryTokens := h.vectorDB.Tokenize(query)
+		productTokens := h.vectorDB.Tokenize(explanation.Name + " " + code)
+		explanation.Tokens = &services.SearchExplainTokens{
+			Query:   append([]string{}, queryTokens...),
+			Product: append([]string{}, productTokens...),
+			Shared:  services.SharedTokens(queryTokens, productTokens),
+		}
+	}
+
+	// Priority steps, as run on the first page of /v1/search-by-vector
+	barcodeStage := explainRowsStage(services.ExplainStageExactBarcode, code, func() ([]map[string]interface{}, int, error) {
+		return h.postgreSQLService.SearchProductsByExactBarcode(ctx, query, depth, 0)
+	})
+	barcodeStage.Runs = true
+	barcodeStage.Reason = explainExactBarcode(barcodeStage, query, explanation.Barcodes)
+
+	codeStage := explainRowsStage(services.ExplainStageExactCode, code, func() ([]map[string]interface{}, int, error) {
+		return h.postgreSQLService.SearchProductsByExactCode(ctx, query, depth, 0)
+	})
+	codeStage.Runs = barcodeStage.Total < limit
+	codeStage.Reason = explainExactCode(codeStage, query, code)
+
+	likeStage := explainRowsStage(services.ExplainStageLike, code, func() ([]map[string]interface{}, int, error) {
+		return h.postgreSQLService.SearchProductsSimpleLike(ctx, query, depth, 0)
+	})
+	likeStage.Runs = barcodeStage.Total+codeStage.Total == 0
+	switch {
+	case likeStage.Error != "":
+		likeStage.Reason = "the LIKE query failed"
+	case !likeStage.Runs:
+		likeStage.Reason = "runs only when the exact barcode and code steps find nothing"
+	case likeStage.Matched:
+		likeStage.Reason = fmt.Sprintf("the code or a barcode contains '%s'", query)
+	default:
+		likeStage.Reason = fmt.Sprintf("neither the code nor any barcode contains '%s'", query)
+	}
+
+	// Vector stage: /v1/search-by-vector reads up to three pages of BM25 hits
+	bm25Stage := services.SearchExplainStage{Stage: services.ExplainStageBM25, Runs: h.weaviateService != nil}
+	vectorLimit := min(limit*3, 300)
+	if h.weaviateService == nil {
+		bm25Stage.Reason = "Weaviate is not available; the text search is used instead"
+	} else {
+		products, err := h.weaviateService.SearchProducts(ctx, query, depth)
+		if err != nil {
+			bm25Stage.Error = err.Error()
+			bm25Stage.Reason = "the BM25 query failed"
+		} else {
+			codes := h.weaviateService.GetICCodes(products)
+			bm25Stage.Total = services.CountDistinct(codes)
+			bm25Stage.Rank = services.RankOfCode(codes, code)
+			for _, product := range products {
+				if product.ICCode == code {
+					bm25Stage.Score = product.Relevance
+					break
+				}
+			}
+			bm25Stage.Matched = bm25Stage.Rank > 0 && bm25Stage.Rank <= vectorLimit
+			if indexed, err := h.weaviateService.IndexedBarcodes(ctx, code); err == nil {
+				explanation.IndexedBarcodes = indexed
+			}
+			switch {
+			case bm25Stage.Matched:
+				bm25Stage.Reason = fmt.Sprintf("BM25 rank %d is within the %d hits the search reads", bm25Stage.Rank, vectorLimit)
+			case bm25Stage.Rank > 0:
+				bm25Stage.Reason = fmt.Sprintf("BM25 rank %d is below the %d hits the search reads", bm25Stage.Rank, vectorLimit)
+			case explanation.IndexedBarcodes != nil && len(explanation.IndexedBarcodes) == 0:
+				bm25Stage.Reason = "the product is not indexed in Weaviate; run a sync (POST /v1/admin/sync-weaviate)"
+			default:
+				bm25Stage.Reason = fmt.Sprintf("not among the top %d BM25 hits; compare the query and product tokens", depth)
+			}
+		}
+	}
+
+	textStage := explainRowsStage(services.ExplainStageTextSearch, code, func() ([]map[string]interface{}, int, error) {
+		return h.postgreSQLService.SearchProducts(ctx, query, depth, 0)
+	})
+	textStage.Runs = true
+	switch {
+	case textStage.Error != "":
+		textStage.Reason = "the text search failed"
+	case textStage.Matched:
+		textStage.Reason = "a word of the query appears in the name or code; used when Weaviate is unavailable and to fill short pages"
+	default:
+		textStage.Reason = "no word of the query appears in the name or code"
+	}
+
+	explanation.Stages = []services.SearchExplainStage{barcodeStage, codeStage, likeStage, bm25Stage, textStage}
+
+	if h.vectorDB != nil {
+		tfidfStage := services.SearchExplainStage{Stage: services.ExplainStageTFIDF, Reason: "used only by /v1/search/hybrid"}
+		ranked, err := h.vectorDB.RankProducts(ctx, query, depth)
+		if err != nil {
+			tfidfStage.Error = err.Error()
+		} else {
+			codes := make([]string, len(ranked))
+			for i, r := range ranked {
+				codes[i] = r.Code
+				if r.Code == code && tfidfStage.Score == 0 {
+					tfidfStage.Score = r.Score
+				}
+			}
+			tfidfStage.Total = services.CountDistinct(codes)
+			tfidfStage.Rank = services.RankOfCode(codes, code)
+			tfidfStage.Matched = tfidfStage.Rank > 0
+		}
+		explanation.Stages = append(explanation.Stages, tfidfStage)
+	}
+
+	explanation.Verdict = services.ExplainVerdict(explanation)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    explanation,
+		Message: explanation.Verdict,
+	})
+}
+
+// explainRowsStage runs a PostgreSQL search step and locates the product in its rows
+func explainRowsStage(stage, code string, run func() ([]map[string]interface{}, int, error)) services.SearchExplainStage {
+	result := services.SearchExplainStage{Stage: stage}
+	rows, total, err := run()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	codes := make([]string, 0, len(rows))
+	for _, row := range rows {
+		rowCode := getStringValue(row, "code")
+		codes = append(codes, rowCode)
+		if rowCode == code && result.Score == 0 {
+			result.Score = getFloat64Value(row, "search_priority")
+		}
+	}
+	result.Total = total
+	result.Rank = services.RankOfCode(codes, code)
+	result.Matched = result.Rank > 0
+	return result
+}
+
+func explainExactBarcode(stage services.SearchExplainStage, query string, barcodes []string) string {
+	if stage.Error != "" {
+		return "the barcode lookup failed"
+	}
+	if stage.Matched {
+		return fmt.Sprintf("'%s' is a barcode of the product", query)
+	}
+	for _, barcode := range barcodes {
+		if strings.TrimSpace(barcode) == strings.TrimSpace(query) {
+			return "the query matches a barcode only after trimming spaces; the lookup compares exactly"
+		}
+		if strings.Contains(barcode, query) {
+			return fmt.Sprintf("'%s' is part of barcode %s but not the whole barcode", query, barcode)
+		}
+	}
+	return "the query is not a barcode of the product"
+}
+
+func explainExactCode(stage services.SearchExplainStage, query, code string) string {
+	switch {
+	case stage.Error != "":
+		return "the code lookup failed"
+	case stage.Matched:
+		return "the query equals the product code"
+	case !stage.Runs:
+		return "skipped: exact barcode matches already fill the page"
+	case strings.EqualFold(strings.TrimSpace(query), code):
+		return "the query differs from the code only in case or spaces; the lookup is exact"
+	case strings.Contains(strings.ToLower(code), strings.ToLower(query)):
+		return "the query is part of the code; only the LIKE step can match it"
+	default:
+		return "the query is not the product code"
+	}
+}
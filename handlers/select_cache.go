@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveCachedSelect answers a select from the cache when the request set cache_ttl and a fresh
+// result is cached. Otherwise it returns the key and lifetime to cache the result under; a zero
+// lifetime means the result is not cached.
+func (h *APIHandler) serveCachedSelect(c *gin.Context, logTag, database string, req models.SelectRequest, startTime time.Time) (string, time.Duration, bool) {
+	ttl := h.selectCache.TTL(req.CacheTTL)
+	// Cached results are whole results; pages of one are always read from the database
+	if ttl == 0 || req.Cursor != "" || req.MaxRows > 0 {
+		return "", 0, false
+	}
+	key := services.SelectCacheKey(database, req.Query, req.Params)
+	data, ok := h.selectCache.Get(key)
+	if !ok {
+		return key, ttl, false
+	}
+
+	rowCount := len(data)
+	duration := float64(time.Since(startTime).Nanoseconds()) / 1e6
+	log.Printf("⚡ [%s] Served %d cached rows in %.2fms", logTag, rowCount, duration)
+	respond(c, http.StatusOK, models.SelectResponse{
+		Success:  true,
+		Message:  fmt.Sprintf("Query served from cache, %d rows returned", rowCount),
+		Data:     data,
+		Query:    req.Query,
+		RowCount: rowCount,
+		Duration: duration,
+		Cached:   true,
+	})
+	return key, ttl, true
+}
+
+// invalidateSelectCache drops cached results that read a table the command wrote
+func (h *APIHandler) invalidateSelectCache(logTag, database, query string) {
+	if removed := h.selectCache.InvalidateQuery(database, query); removed > 0 {
+		log.Printf("🧹 [%s] Dropped %d cached SELECT results", logTag, removed)
+	}
+}
+
+// GetSelectCache godoc
+// @Summary SELECT cache entries
+// @Description Hit and miss counts of the /select and /pgselect result cache and its live entries, the most used first
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.SelectCacheStats}
+// @Router /admin/cache [get]
+func (h *APIHandler) GetSelectCache(c *gin.Context) {
+	stats := h.selectCache.Stats()
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    stats,
+		Message: fmt.Sprintf("%d cached results, %d hits, %d misses", stats.Entries, stats.Hits, stats.Misses),
+	})
+}
+
+// InvalidateSelectCache godoc
+// @Summary Invalidate SELECT cache entries
+// @Description Remove one entry by key, the entries reading a table, or every entry of a database. Without parameters the whole cache is cleared.
+// @Tags admin
+// @Produce json
+// @Param key query string false "Entry key from GET /admin/cache"
+// @Param database query string false "clickhouse or postgresql"
+// @Param table query string false "Table read by the cached queries"
+// @Success 200 {object} models.APIResponse{data=models.SelectCacheInvalidation}
+// @Failure 400 {object} models.APIResponse
+// @Router /admin/cache [delete]
+func (h *APIHandler) InvalidateSelectCache(c *gin.Context) {
+	database := c.Query("database")
+	if database != "" && database != services.ServiceClickHouse && database != services.ServicePostgreSQL {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid database: %s (use %s or %s)", database, services.ServiceClickHouse, services.ServicePostgreSQL),
+		})
+		return
+	}
+
+	var removed int
+	if key := c.Query("key"); key != "" {
+		removed = h.selectCache.Remove(key)
+	} else {
+		removed = h.selectCache.Invalidate(database, c.Query("table"))
+	}
+
+	log.Printf("🧹 [cache] Removed %d cached SELECT results", removed)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.SelectCacheInvalidation{Removed: removed},
+		Message: fmt.Sprintf("Removed %d cached results", removed),
+	})
+}
@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// Output formats for /select and /pgselect, chosen with ?format=
+const (
+	selectFormatJSON   = "json"
+	selectFormatNDJSON = "ndjson"
+	selectFormatCSV    = "csv"
+	selectFormatXLSX   = "xlsx"
+)
+
+// exportFlushRows is how many rows are buffered before they are flushed to the client
+const exportFlushRows = 500
+
+// exportTimeLayout is how timestamps are written to CSV; Excel and most BI tools parse it directly
+const exportTimeLayout = "2006-01-02 15:04:05"
+
+var exportFilenamePattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// rowStreamer is a streaming select such as ClickHouseService.StreamSelect
+type rowStreamer func(ctx context.Context, query string, fn services.RowFunc, params ...interface{}) (int, error)
+
+// selectFormat returns the requested output format, or an error for an unknown one
+func selectFormat(c *gin.Context) (string, error) {
+	format := strings.ToLower(c.DefaultQuery("format", selectFormatJSON))
+	switch format {
+	case selectFormatJSON, selectFormatNDJSON, selectFormatCSV, selectFormatXLSX:
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported format '%s': use json, ndjson, csv or xlsx", format)
+}
+
+// rowEncoder writes a query result in one export format
+type rowEncoder interface {
+	Row(columns []string, values []interface{}) error
+	Abort(err error) // reports a failure after part of the output was sent
+	Close() error    // writes any remaining output
+}
+
+// lazyHeaderWriter sets the response headers on the first write, so a query that fails before
+// producing output can still be answered with a normal JSON error
+type lazyHeaderWriter struct {
+	c           *gin.Context
+	contentType string
+	filename    string
+	started     bool
+	discard     bool // set once a JSON error response replaced the export
+}
+
+func (w *lazyHeaderWriter) Write(p []byte) (int, error) {
+	if w.discard {
+		return len(p), nil
+	}
+	if !w.started {
+		w.writeHeaders()
+	}
+	return w.c.Writer.Write(p)
+}
+
+func (w *lazyHeaderWriter) writeHeaders() {
+	w.started = true
+	w.c.Header("Content-Type", w.contentType)
+	w.c.Header("X-Content-Type-Options", "nosniff")
+	if w.filename != "" {
+		w.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, w.filename))
+	}
+	w.c.Status(http.StatusOK)
+}
+
+// exportRows runs a streaming select and writes the rows in the given format (anything but json).
+// NDJSON and CSV are written as rows arrive; XLSX is assembled first and sent when the query ends.
+func exportRows(c *gin.Context, logTag, format, query string, params []interface{}, stream rowStreamer) {
+	startTime := time.Now()
+
+	out := &lazyHeaderWriter{c: c}
+	w := bufio.NewWriter(out)
+
+	var enc rowEncoder
+	switch format {
+	case selectFormatCSV:
+		out.contentType = "text/csv; charset=utf-8"
+		out.filename = exportFilename(c, logTag, format)
+		enc = newCSVEncoder(w)
+	case selectFormatXLSX:
+		out.contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		out.filename = exportFilename(c, logTag, format)
+		enc = newXLSXEncoder(w)
+	default:
+		out.contentType = "application/x-ndjson"
+		enc = &ndjsonEncoder{w: w}
+	}
+
+	rowCount := 0
+	_, err := stream(c.Request.Context(), query, func(columns []string, values []interface{}) error {
+		if err := enc.Row(columns, values); err != nil {
+			return err
+		}
+		rowCount++
+		if rowCount%exportFlushRows == 0 && out.started {
+			// A failed flush means the client went away; stop reading from the database
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	}, params...)
+	if err == nil {
+		err = enc.Close()
+	}
+	duration := float64(time.Since(startTime).Nanoseconds()) / 1e6
+
+	if err != nil && !out.started {
+		log.Printf("❌ [%s] Export query failed: %v", logTag, err)
+		// Nothing was sent yet: drop whatever the encoder still writes and let it release its resources
+		out.discard = true
+		enc.Abort(err)
+		c.JSON(http.StatusInternalServerError, models.SelectResponse{
+			Success:  false,
+			Error:    fmt.Sprintf("Query execution failed: %s", err.Error()),
+			Query:    query,
+			Duration: duration,
+		})
+		return
+	}
+
+	if err != nil {
+		log.Printf("❌ [%s] %s export aborted after %d rows: %v", logTag, format, rowCount, err)
+		enc.Abort(err)
+	} else {
+		log.Printf("✅ [%s] Exported %d rows as %s in %.2fms", logTag, rowCount, format, duration)
+	}
+
+	if !out.started {
+		// Empty result: send the headers with an empty body
+		out.writeHeaders()
+	}
+	w.Flush()
+	c.Writer.Flush()
+}
+
+// exportFilename builds the download name from ?filename= or the endpoint and current time
+func exportFilename(c *gin.Context, logTag, ext string) string {
+	name := exportFilenamePattern.ReplaceAllString(c.Query("filename"), "_")
+	name = strings.Trim(strings.TrimSuffix(name, "."+ext), "._")
+	if name == "" {
+		name = logTag + "-" + time.Now().Format("20060102-150405")
+	}
+	return name + "." + ext
+}
+
+// exportValue unwraps nullable pointers returned by the drivers
+func exportValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+// exportString formats a value for text formats
+func exportString(v interface{}) string {
+	switch val := exportValue(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case time.Time:
+		return val.Format(exportTimeLayout)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// ndjsonEncoder writes one JSON object per line with the columns in query order
+type ndjsonEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *ndjsonEncoder) Row(columns []string, values []interface{}) error {
+	e.w.WriteByte('{')
+	for i, col := range columns {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(col)
+		val, err := json.Marshal(values[i])
+		if err != nil {
+			val, _ = json.Marshal(exportString(values[i]))
+		}
+		e.w.Write(key)
+		e.w.WriteByte(':')
+		e.w.Write(val)
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+// Abort ends the stream with an {"error": "..."} line
+func (e *ndjsonEncoder) Abort(err error) {
+	line, _ := json.Marshal(map[string]string{"error": err.Error()})
+	e.w.Write(line)
+	e.w.WriteByte('\n')
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// csvEncoder writes a header row followed by one record per row. The output starts with a UTF-8
+// byte order mark so Excel shows Thai text correctly.
+type csvEncoder struct {
+	out    io.Writer
+	w      *csv.Writer
+	record []string
+}
+
+// newCSVEncoder expects the export's *bufio.Writer, which csv.NewWriter reuses as its own
+// buffer, so records are flushed together with the rest of the export
+func newCSVEncoder(w *bufio.Writer) *csvEncoder {
+	return &csvEncoder{out: w, w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) Row(columns []string, values []interface{}) error {
+	if e.record == nil {
+		if _, err := io.WriteString(e.out, "\uFEFF"); err != nil {
+			return err
+		}
+		if err := e.w.Write(columns); err != nil {
+			return err
+		}
+		e.record = make([]string, len(columns))
+	}
+	for i, v := range values {
+		e.record[i] = exportString(v)
+	}
+	return e.w.Write(e.record)
+}
+
+// Abort appends a "#error" record; CSV has no other way to signal a truncated export
+func (e *csvEncoder) Abort(err error) {
+	e.w.Write([]string{"#error", err.Error()})
+	e.w.Flush()
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// xlsxEncoder writes a single worksheet through excelize's stream writer, which spills large
+// sheets to a temporary file instead of keeping every cell in memory
+type xlsxEncoder struct {
+	out  io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+	err  error
+}
+
+func newXLSXEncoder(w io.Writer) *xlsxEncoder {
+	file := excelize.NewFile()
+	sw, err := file.NewStreamWriter("Sheet1")
+	return &xlsxEncoder{out: w, file: file, sw: sw, err: err}
+}
+
+func (e *xlsxEncoder) Row(columns []string, values []interface{}) error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.row == 0 {
+		header := make([]interface{}, len(columns))
+		for i, col := range columns {
+			header[i] = col
+		}
+		if err := e.writeRow(header); err != nil {
+			return err
+		}
+	}
+	if e.row >= excelize.TotalRows {
+		return fmt.Errorf("result exceeds the %d row limit of an xlsx sheet; use format=csv", excelize.TotalRows-1)
+	}
+
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		switch val := exportValue(v).(type) {
+		case nil:
+			cells[i] = nil
+		case string, bool, time.Time,
+			int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			cells[i] = val
+		default:
+			cells[i] = exportString(val)
+		}
+	}
+	return e.writeRow(cells)
+}
+
+func (e *xlsxEncoder) writeRow(cells []interface{}) error {
+	e.row++
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	return e.sw.SetRow(cell, cells)
+}
+
+// Abort only releases the workbook: it is written on Close, so a failed export never starts the response
+func (e *xlsxEncoder) Abort(err error) {
+	e.file.Close()
+}
+
+func (e *xlsxEncoder) Close() error {
+	defer e.file.Close()
+	if e.err != nil {
+		return e.err
+	}
+	if err := e.sw.Flush(); err != nil {
+		return err
+	}
+	_, err := e.file.WriteTo(e.out)
+	return err
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+)
+
+// selectPage is the part of a query's result one /select or /pgselect request returns: at most
+// max_rows rows and sql_limits.max_response_bytes of JSON, starting at the request's cursor
+type selectPage struct {
+	query       string // wrapped to start at the cursor's offset
+	offset      int
+	fingerprint string
+	rows        *services.LimitedRows
+}
+
+// newSelectPage checks the max_rows and cursor of a request
+func (h *APIHandler) newSelectPage(database string, req models.SelectRequest, format string) (*selectPage, error) {
+	if req.MaxRows < 0 {
+		return nil, errors.New("max_rows must not be negative")
+	}
+	if req.Cursor != "" && format != selectFormatJSON {
+		return nil, errors.New("cursor continues JSON results; leave format out")
+	}
+
+	maxRows := h.config.SQLLimits.Rows(req.MaxRows)
+	page := &selectPage{
+		query:       req.Query,
+		fingerprint: services.SelectFingerprint(database, req.Query, req.Params),
+		rows:        &services.LimitedRows{MaxRows: maxRows, MaxBytes: h.config.SQLLimits.MaxResponseBytes},
+	}
+	if req.Cursor != "" {
+		cursor, err := services.DecodeSelectCursor(req.Cursor, page.fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		page.offset = cursor.Offset
+		// One row more than fits tells whether the result goes on
+		page.query = services.SelectPageQuery(req.Query, cursor.Offset, maxRows+1)
+	}
+	return page, nil
+}
+
+// run reads the rows of the page. Once it is full the statement is cancelled on the database
+// instead of reading the rest of the result.
+func (p *selectPage) run(ctx context.Context, stream rowStreamer, params []interface{}) error {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	_, err := stream(ctx, p.query, func(columns []string, values []interface{}) error {
+		if err := p.rows.Collect(columns, values); err != nil {
+			stop()
+			return err
+		}
+		return nil
+	}, params...)
+	if errors.Is(err, services.ErrResultFull) {
+		return nil
+	}
+	return err
+}
+
+// nextCursor returns the cursor to the rows after the page, or "" when the page holds the rest
+func (p *selectPage) nextCursor() string {
+	if !p.rows.Truncated {
+		return ""
+	}
+	cursor := services.SelectCursor{Query: p.fingerprint, Offset: p.offset + len(p.rows.Rows)}
+	return cursor.Encode()
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"smlgoapi/middleware"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateShare godoc
+// @Summary Share a search result
+// @Description Store a result set, or the query and parameters behind it, under a short token and return a read-only link that expires after ttl_minutes
+// @Tags share
+// @Accept json
+// @Produce json
+// @Param request body models.ShareRequest true "Result or parameters to share"
+// @Success 200 {object} models.APIResponse{data=models.ShareLink}
+// @Failure 400 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /share [post]
+func (h *APIHandler) CreateShare(c *gin.Context) {
+	if h.shareService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "PostgreSQL service not initialized",
+		})
+		return
+	}
+
+	var req models.ShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid JSON format: " + err.Error(),
+		})
+		return
+	}
+
+	token, expiresAt, err := h.shareService.Create(c.Request.Context(), req, shareCreator(c))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidShare) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Message: "Failed to share result",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: models.ShareLink{
+			Token:     token,
+			URL:       h.shareURL(c, token),
+			ExpiresAt: expiresAt,
+		},
+		Message: fmt.Sprintf("Share link valid until %s", expiresAt.Format("2006-01-02 15:04 MST")),
+	})
+}
+
+// GetShare godoc
+// @Summary Open a share link
+// @Description Return the result or parameters stored under a share token. Links need no credentials and stop working when they expire.
+// @Tags share
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} models.APIResponse{data=models.SharedResult}
+// @Failure 404 {object} models.APIResponse
+// @Failure 410 {object} models.APIResponse
+// @Router /share/{token} [get]
+func (h *APIHandler) GetShare(c *gin.Context) {
+	if h.shareService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "PostgreSQL service not initialized",
+		})
+		return
+	}
+
+	shared, err := h.shareService.Get(c.Request.Context(), c.Param("token"))
+	switch {
+	case errors.Is(err, services.ErrShareNotFound):
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Error: err.Error()})
+		return
+	case errors.Is(err, services.ErrShareExpired):
+		c.JSON(http.StatusGone, models.APIResponse{Success: false, Error: err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+
+	// Links are read-only snapshots; shared caches must not keep them past expiry
+	c.Header("Cache-Control", "private, no-store")
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    shared,
+	})
+}
+
+// shareURL builds the link for a token from search.share.base_url, or from the request's host
+func (h *APIHandler) shareURL(c *gin.Context, token string) string {
+	base := strings.TrimRight(h.config.Search.Share.BaseURL, "/")
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+			scheme = proto
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + "/v1/share/" + token
+}
+
+// shareCreator names who created a link: the session user or the API key, when authenticated
+func shareCreator(c *gin.Context) string {
+	if claims, ok := middleware.SessionFromContext(c); ok {
+		return claims.Username
+	}
+	if key, ok := middleware.APIKeyFromContext(c); ok {
+		return "api-key:" + key.Name
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"smlgoapi/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// base64Encodings are tried in order; tools differ in the alphabet and whether they pad
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.RawStdEncoding,
+	base64.URLEncoding,
+	base64.RawURLEncoding,
+}
+
+// SelectGetEndpoint godoc
+// @Summary Execute a base64-encoded ClickHouse SELECT with GET
+// @Description For tools that can only issue GET requests. q is the SELECT in base64, standard or URL-safe, with or without padding. The response is the same as POST /select; its query field holds the decoded SQL so it can be checked.
+// @Tags database
+// @Produce json
+// @Param q query string true "SELECT query, base64 encoded"
+// @Param cache_ttl query int false "Seconds the result may be served from the cache"
+// @Param timeout_ms query int false "Milliseconds the query may run (sql_limits)"
+// @Param max_rows query int false "Rows per page, lowered to sql_limits.max_rows"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param format query string false "Output format: json (default), ndjson (streamed, one row per line), csv or xlsx"
+// @Param filename query string false "Download file name for csv and xlsx"
+// @Success 200 {object} models.SelectResponse
+// @Failure 400 {object} models.SelectResponse
+// @Failure 403 {object} models.SelectResponse
+// @Failure 408 {object} models.SelectResponse
+// @Failure 409 {object} models.SelectResponse
+// @Failure 503 {object} models.SelectResponse
+// @Router /selectget [get]
+func (h *APIHandler) SelectGetEndpoint(c *gin.Context) {
+	startTime := time.Now()
+
+	q := c.Query("q")
+	if q == "" {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   "Query parameter q is required: the SELECT query, base64 encoded",
+		})
+		return
+	}
+	cacheTTL, err := strconv.Atoi(c.DefaultQuery("cache_ttl", "0"))
+	if err != nil || cacheTTL < 0 {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   "cache_ttl must be a number of seconds",
+		})
+		return
+	}
+	timeoutMs, err := strconv.Atoi(c.DefaultQuery("timeout_ms", "0"))
+	if err != nil || timeoutMs < 0 {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   "timeout_ms must be a number of milliseconds",
+		})
+		return
+	}
+
+	maxRows, err := strconv.Atoi(c.DefaultQuery("max_rows", "0"))
+	if err != nil || maxRows < 0 {
+		c.JSON(http.StatusBadRequest, models.SelectResponse{
+			Success: false,
+			Error:   "max_rows must be a number of rows",
+		})
+		return
+	}
+
+	h.selectClickHouse(c, models.SelectRequest{
+		QueryBase64: q,
+		CacheTTL:    cacheTTL,
+		TimeoutMs:   timeoutMs,
+		MaxRows:     maxRows,
+		Cursor:      c.Query("cursor"),
+	}, startTime)
+}
+
+// decodeSQL returns the SQL of a request, given either as query or base64 encoded as query_base64
+func decodeSQL(query, queryBase64 string) (string, error) {
+	switch {
+	case query != "" && queryBase64 != "":
+		return "", errors.New("send either query or query_base64, not both")
+	case queryBase64 != "":
+		return decodeBase64SQL(queryBase64)
+	case strings.TrimSpace(query) == "":
+		return "", errors.New("query or query_base64 is required")
+	}
+	return query, nil
+}
+
+func decodeBase64SQL(encoded string) (string, error) {
+	// An unescaped "+" in a URL arrives as a space
+	encoded = strings.ReplaceAll(strings.TrimSpace(encoded), " ", "+")
+	for _, encoding := range base64Encodings {
+		decoded, err := encoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		if !utf8.Valid(decoded) {
+			return "", errors.New("query_base64 does not decode to UTF-8 text")
+		}
+		if strings.TrimSpace(string(decoded)) == "" {
+			return "", errors.New("query_base64 decodes to an empty query")
+		}
+		return string(decoded), nil
+	}
+	return "", errors.New("query_base64 is not valid base64")
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"smlgoapi/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// explainRequestError checks the explain and analyze flags of a SELECT request
+func explainRequestError(req models.SelectRequest, format string, analyzeSupported bool) error {
+	switch {
+	case req.Analyze && !req.Explain:
+		return errors.New("analyze requires explain")
+	case req.Analyze && !analyzeSupported:
+		return errors.New("analyze is only supported by /pgselect; ClickHouse has no EXPLAIN ANALYZE")
+	case req.Explain && format != selectFormatJSON:
+		return errors.New("explain returns JSON; leave format out")
+	}
+	return nil
+}
+
+// explainSelect answers the plan of the request's query instead of its rows
+func explainSelect(c *gin.Context, logTag string, req models.SelectRequest, timeout time.Duration, startTime time.Time, explain func(ctx context.Context) ([]string, error)) {
+	log.Printf("🧭 [%s] Explaining query (analyze: %t): %s", logTag, req.Analyze, req.Query)
+
+	plan, err := explain(c.Request.Context())
+	duration := float64(time.Since(startTime).Nanoseconds()) / 1e6
+
+	if err != nil {
+		log.Printf("❌ [%s] Explain failed: %v", logTag, err)
+		status, message, ok := queryInterrupted(c, logTag, timeout)
+		if !ok {
+			status, message = http.StatusInternalServerError, fmt.Sprintf("Explain failed: %s", err.Error())
+		}
+		c.JSON(status, models.SelectResponse{
+			Success:  false,
+			Error:    message,
+			Query:    req.Query,
+			Duration: duration,
+		})
+		return
+	}
+
+	message := "Query plan; the query was not run"
+	if req.Analyze {
+		message = "Query plan with actual times; the query ran in a read-only transaction that was rolled back"
+	}
+	c.JSON(http.StatusOK, models.SelectResponse{
+		Success:  true,
+		Message:  message,
+		Query:    req.Query,
+		Plan:     plan,
+		Duration: duration,
+	})
+}
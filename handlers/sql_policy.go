@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// postgreSQLDefaultSchema is the schema unqualified PostgreSQL table names resolve to
+const postgreSQLDefaultSchema = "public"
+
+// checkSelectSQL applies the SQL policy to the query of an endpoint that only reads, which may
+// not change data through a WITH query or SELECT ... INTO either
+func (h *APIHandler) checkSelectSQL(database, query, defaultSchema string) error {
+	if err := h.sqlPolicyService.Check(database, query, defaultSchema); err != nil {
+		return err
+	}
+	return services.CheckReadOnly(database, query)
+}
+
+// GetSQLPolicy godoc
+// @Summary Show the active SQL policy
+// @Description Statement, table and LIMIT rules applied to /command, /select, /pgcommand and /pgselect
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse
+// @Router /admin/sql-policy [get]
+func (h *APIHandler) GetSQLPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    h.sqlPolicyService.Policy(),
+	})
+}
+
+// ReloadSQLPolicy godoc
+// @Summary Reload the SQL policy from smlgoapi.json
+// @Description The policy is also reloaded automatically when the file changes
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse
+// @Router /admin/sql-policy/reload [post]
+func (h *APIHandler) ReloadSQLPolicy(c *gin.Context) {
+	if err := h.sqlPolicyService.Reload(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    h.sqlPolicyService.Policy(),
+		Message: "SQL policy reloaded",
+	})
+}
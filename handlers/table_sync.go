@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartTableSync godoc
+// @Summary Copy a table between PostgreSQL and ClickHouse
+// @Description Copy a table from one database into the other in batches, e.g. to mirror ic_inventory into ClickHouse for analytics. A missing target table is created with mapped column types. mode=full empties the target first, append adds every row, incremental adds rows whose key is above the largest key in the target. Runs in the background; poll GET /admin/sync for progress.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body services.TableSyncOptions true "Source, target, table and mode"
+// @Success 202 {object} models.APIResponse{data=services.TableSyncStatus}
+// @Failure 400 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse{data=services.TableSyncStatus}
+// @Failure 503 {object} models.APIResponse
+// @Router /admin/sync [post]
+func (h *APIHandler) StartTableSync(c *gin.Context) {
+	if !h.requireTableSync(c) {
+		return
+	}
+
+	var opts services.TableSyncOptions
+	if err := c.ShouldBindJSON(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	// Reading the source is held to the SQL policy like any other SELECT
+	defaultSchema := postgreSQLDefaultSchema
+	if opts.Source == services.ServiceClickHouse {
+		defaultSchema = h.config.ClickHouse.Database
+	}
+	if exportTablePattern.MatchString(opts.Table) {
+		if err := h.sqlPolicyService.Check(opts.Source, "SELECT * FROM "+opts.Table, defaultSchema); err != nil {
+			log.Printf("🛡️ [table-sync] Rejected by SQL policy: %v", err)
+			c.JSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	status, err := h.tableSyncService.Start(h.operations, opts)
+	if errors.Is(err, services.ErrTableSyncRunning) {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Data:    status,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrShuttingDown) {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "The server is shutting down; start the sync again once it is back",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    status,
+		Message: "Table sync started",
+	})
+}
+
+// GetTableSyncStatus godoc
+// @Summary Progress of the table sync
+// @Description Rows copied by the running sync, or by the last one when none is running
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=services.TableSyncStatus}
+// @Router /admin/sync [get]
+func (h *APIHandler) GetTableSyncStatus(c *gin.Context) {
+	if !h.requireTableSync(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    h.tableSyncService.Status(),
+	})
+}
+
+func (h *APIHandler) requireTableSync(c *gin.Context) bool {
+	if h.tableSyncService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Table sync requires both PostgreSQL and ClickHouse, and at least one is unavailable",
+		})
+		return false
+	}
+	return true
+}
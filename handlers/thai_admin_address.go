@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FormatAddress godoc
+// @Summary Format a Thai postal address
+// @Description Order address components the way Thailand Post expects (แขวง/เขต for Bangkok, ตำบล/อำเภอ/จังหวัด elsewhere) and add an English block for international labels. The location is checked against the Thai administrative data.
+// @Tags thai-admin
+// @Accept json
+// @Produce json
+// @Param request body models.AddressFormatRequest true "Address components"
+// @Success 200 {object} models.APIResponse{data=models.AddressFormatResult}
+// @Failure 400 {object} models.APIResponse
+// @Router /thai-admin/format-address [post]
+func (h *APIHandler) FormatAddress(c *gin.Context) {
+	var req models.AddressFormatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.thaiAdminService.FormatAddress(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrAddressIncomplete) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   "Failed to format address: " + err.Error(),
+		})
+		return
+	}
+
+	message := "Address formatted"
+	if !result.Verified {
+		message = "Address formatted from the names given; location not verified"
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+		Message: message,
+	})
+}
+
+// ParseAddress godoc
+// @Summary Split a Thai address into its parts
+// @Description Read the house number, moo, soi, road, tambon, amphure, province, zip code and phone from an address typed as one line, and find its tambon in the Thai administrative data. Confidence tells how well the location matched; components can be passed to /thai-admin/format-address as they are.
+// @Tags thai-admin
+// @Accept json
+// @Produce json
+// @Param request body models.AddressParseRequest true "Address text"
+// @Success 200 {object} models.APIResponse{data=models.AddressParseResult}
+// @Failure 400 {object} models.APIResponse
+// @Router /address/parse [post]
+func (h *APIHandler) ParseAddress(c *gin.Context) {
+	var req models.AddressParseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.thaiAdminService.ParseAddress(req.Address)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrAddressEmpty) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   "Failed to parse address: " + err.Error(),
+		})
+		return
+	}
+
+	message := "Address parsed"
+	if result.Location == nil {
+		message = "Address parsed; location not found"
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+		Message: message,
+	})
+}
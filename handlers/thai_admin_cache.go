@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheThaiAdmin runs before the GET lookups of the Thai administrative data. It sends an ETag
+// made of the dataset version and the query, Last-Modified and Cache-Control, and answers 304
+// when the client already has the response, so apps keep their province lists until an upload
+// or refresh changes the data.
+func (h *APIHandler) CacheThaiAdmin(c *gin.Context) {
+	export, err := h.thaiAdminService.Export(services.ThaiAdminExportJSON)
+	if err != nil {
+		// The lookup reports the failure to load the data
+		c.Next()
+		return
+	}
+
+	query := sha256.Sum256([]byte(c.Request.URL.Query().Encode()))
+	etag := fmt.Sprintf(`"%s-%s"`, export.Version, hex.EncodeToString(query[:])[:8])
+	modified := h.thaiAdminService.LastModified().UTC().Truncate(time.Second)
+
+	// Behind JWT the lists are still the same for everyone, but shared caches must not answer
+	// requests they cannot authorize
+	visibility := "public"
+	if h.config.JWT.Enabled {
+		visibility = "private"
+	}
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("%s, max-age=%d", visibility, h.config.ThaiAdmin.CacheMaxAgeSeconds))
+	c.Header("X-Dataset-Version", export.Version)
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request, etag, modified) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+	c.Writer = &thaiAdminCacheWriter{ResponseWriter: c.Writer}
+	c.Next()
+}
+
+// notModified applies If-None-Match, or If-Modified-Since when there is no If-None-Match
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if modified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modified.After(since)
+}
+
+// thaiAdminCacheWriter drops the cache headers from error responses, so a 400 for a bad
+// province_id or a 500 while the files are missing is not kept by the client
+type thaiAdminCacheWriter struct {
+	gin.ResponseWriter
+}
+
+func (w *thaiAdminCacheWriter) WriteHeader(code int) {
+	if code != http.StatusOK {
+		header := w.Header()
+		header.Del("ETag")
+		header.Del("Last-Modified")
+		header.Set("Cache-Control", "no-store")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the connection
+func (w *thaiAdminCacheWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
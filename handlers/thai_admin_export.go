@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportThaiAdminData godoc
+// @Summary Download the Thai administrative dataset
+// @Description Full province > amphure > tambon hierarchy as JSON or CSV for offline use. The ETag is the SHA-256 of the file; send it in If-None-Match to get 304 when nothing changed.
+// @Tags thai-admin
+// @Produce json
+// @Produce text/csv
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} models.ThaiAdminDataset
+// @Success 304 "Not modified"
+// @Failure 400 {object} models.APIResponse
+// @Router /thai-admin/export [get]
+func (h *APIHandler) ExportThaiAdminData(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", services.ThaiAdminExportJSON))
+	export, err := h.thaiAdminService.Export(format)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if format != services.ThaiAdminExportJSON && format != services.ThaiAdminExportCSV {
+			status = http.StatusBadRequest
+		} else {
+			log.Printf("❌ [thai-admin] Export as %s failed: %v", format, err)
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	contentType := "application/json; charset=utf-8"
+	if format == services.ThaiAdminExportCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	filename := fmt.Sprintf("thai-admin-%s.%s", export.Version, format)
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("ETag", `"`+export.Checksum+`"`)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Dataset-Version", export.Version)
+	c.Header("X-Checksum-SHA256", export.Checksum)
+
+	// ServeContent answers If-None-Match with 304 and handles HEAD and Range requests
+	modTime, _ := time.Parse(time.RFC3339, export.UpdatedAt)
+	http.ServeContent(c.Writer, c.Request, filename, modTime, bytes.NewReader(export.Body))
+}
+
+// UploadThaiAdminData godoc
+// @Summary Replace the Thai administrative data
+// @Description Validate uploaded provinces, amphures and/or tambons, report the differences and swap them in without a restart. Omitted levels keep their current records.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.ThaiAdminUploadRequest true "Replacement data"
+// @Param dry_run query bool false "Only validate and diff"
+// @Param persist query bool false "Rewrite the data files so the update survives a restart (default true)"
+// @Success 200 {object} models.APIResponse{data=models.ThaiAdminUploadResult}
+// @Failure 422 {object} models.APIResponse{data=models.ThaiAdminUploadResult}
+// @Router /admin/thai-admin/upload [post]
+func (h *APIHandler) UploadThaiAdminData(c *gin.Context) {
+	var req models.ThaiAdminUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+	if req.Provinces == nil && req.Amphures == nil && req.Tambons == nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Upload at least one of provinces, amphures or tambons",
+		})
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	persist := c.DefaultQuery("persist", "true") == "true"
+
+	result, err := h.thaiAdminService.UpdateData(req, dryRun, persist)
+	if err != nil {
+		log.Printf("❌ [thai-admin] Upload failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !result.Applied && !dryRun {
+		c.JSON(http.StatusUnprocessableEntity, models.APIResponse{
+			Success: false,
+			Data:    result,
+			Error:   fmt.Sprintf("Validation failed with %d errors; nothing was changed", len(result.Errors)),
+		})
+		return
+	}
+
+	message := "Validation passed; dry run, nothing was changed"
+	if result.Applied {
+		message = "Thai administrative data updated to version " + result.Version
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: len(result.Errors) == 0,
+		Data:    result,
+		Message: message,
+	})
+}
+
+// RefreshThaiAdminData godoc
+// @Summary Reload the Thai administrative data from upstream
+// @Description Download api_province.json, api_amphure.json and api_tambon.json from thai_admin.upstream.url, validate them like an upload and, when they differ from the data being served, swap them in and rewrite the files in provinces/. The thai_admin_refresh job does the same on a schedule.
+// @Tags admin
+// @Produce json
+// @Param dry_run query bool false "Only download, validate and diff"
+// @Success 200 {object} models.APIResponse{data=models.ThaiAdminUploadResult}
+// @Failure 422 {object} models.APIResponse{data=models.ThaiAdminUploadResult}
+// @Failure 502 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /admin/thai-admin/refresh [post]
+func (h *APIHandler) RefreshThaiAdminData(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+
+	result, err := h.thaiAdminService.RefreshFromUpstream(c.Request.Context(), dryRun)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrThaiAdminUpstreamMissing):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, services.ErrThaiAdminUpstream):
+			status = http.StatusBadGateway
+		}
+		log.Printf("❌ [thai-admin] Refresh failed: %v", err)
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if len(result.Errors) > 0 && !result.Applied {
+		c.JSON(http.StatusUnprocessableEntity, models.APIResponse{
+			Success: false,
+			Data:    result,
+			Error:   fmt.Sprintf("Upstream data failed validation with %d errors; nothing was changed", len(result.Errors)),
+		})
+		return
+	}
+
+	message := "Upstream data differs and passed validation; dry run, nothing was changed"
+	switch {
+	case result.UpToDate:
+		message = "Thai administrative data is up to date at version " + result.Version
+	case result.Applied:
+		message = "Thai administrative data refreshed to version " + result.Version
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: len(result.Errors) == 0,
+		Data:    result,
+		Message: message,
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReverseGeocode godoc
+// @Summary Find the tambon at a coordinate
+// @Description Return the tambon, amphure and province containing a WGS 84 point, e.g. the GPS position of a delivery address. Needs the tambon boundaries GeoJSON file set by thai_admin.boundaries_file.
+// @Tags thai-admin
+// @Produce json
+// @Param lat query number true "Latitude"
+// @Param lng query number true "Longitude"
+// @Success 200 {object} models.APIResponse{data=models.CompleteLocationData}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /thai-admin/reverse-geocode [get]
+func (h *APIHandler) ReverseGeocode(c *gin.Context) {
+	var req models.ReverseGeocodeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	location, err := h.thaiAdminService.ReverseGeocode(req.Lat, req.Lng)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrBoundariesUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   "Failed to reverse geocode: " + err.Error(),
+		})
+		return
+	}
+	if location == nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("No tambon contains %g, %g", req.Lat, req.Lng),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    location,
+		Message: fmt.Sprintf("%g, %g is in %s, %s, %s", req.Lat, req.Lng, location.Tambon.NameTh, location.Amphure.NameTh, location.Province.NameTh),
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchThaiAdmin godoc
+// @Summary Search provinces, amphures and tambons by name
+// @Description Find administrative areas from free text in Thai or English ("บางรัก", "Chiang Mai", "อ.เมือง") without knowing their IDs. Labels, spaces and tone marks are ignored and misspellings still match; results are scored from 1 (exact) down to 0.6, best first.
+// @Tags thai-admin
+// @Accept json
+// @Produce json
+// @Param request body models.ThaiAdminSearchRequest true "Search text and filters"
+// @Success 200 {object} models.APIResponse{data=[]models.ThaiAdminSearchResult}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /thai-admin/search [post]
+func (h *APIHandler) SearchThaiAdmin(c *gin.Context) {
+	var req models.ThaiAdminSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	results, err := h.thaiAdminService.SearchAdminAreas(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidAdminSearch) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   "Failed to search administrative areas: " + err.Error(),
+		})
+		return
+	}
+	if results == nil {
+		results = []models.ThaiAdminSearchResult{}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    results,
+		Message: fmt.Sprintf("Found %d areas matching %q", len(results), req.Query),
+	})
+}
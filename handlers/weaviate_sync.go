@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartWeaviateSync godoc
+// @Summary Sync products from PostgreSQL into Weaviate
+// @Description Upsert every ic_inventory product (one object per barcode) into the Weaviate Product class and delete objects that no longer exist. mode=incremental only pushes rows changed since the last recorded watermark. Runs in the background; poll GET /admin/sync-weaviate for progress.
+// @Tags admin
+// @Produce json
+// @Param mode query string false "full (default) or incremental"
+// @Param dry_run query bool false "Count what would change without writing"
+// @Param batch_size query int false "Products per batch (default 200, max 1000)"
+// @Success 202 {object} models.APIResponse{data=services.WeaviateSyncStatus}
+// @Failure 400 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse{data=services.WeaviateSyncStatus}
+// @Failure 503 {object} models.APIResponse
+// @Router /admin/sync-weaviate [post]
+func (h *APIHandler) StartWeaviateSync(c *gin.Context) {
+	if h.weaviateSyncService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Weaviate sync requires both Weaviate and PostgreSQL, and at least one is unavailable",
+		})
+		return
+	}
+
+	batchSize, _ := strconv.Atoi(c.Query("batch_size"))
+	status, err := h.weaviateSyncService.Start(h.operations, services.WeaviateSyncOptions{
+		Mode:      c.Query("mode"),
+		DryRun:    c.Query("dry_run") == "true",
+		BatchSize: batchSize,
+	})
+	if errors.Is(err, services.ErrSyncRunning) {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Data:    status,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrShuttingDown) {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "The server is shutting down; start the sync again once it is back",
+		})
+		return
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		if !errors.Is(err, services.ErrNoSyncWatermark) && !errors.Is(err, services.ErrInvalidSyncMode) {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    status,
+		Message: "Weaviate sync started",
+	})
+}
+
+// GetWeaviateSyncStatus godoc
+// @Summary Progress of the Weaviate sync
+// @Description Counters of the running sync, or of the last one when none is running
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=services.WeaviateSyncStatus}
+// @Router /admin/sync-weaviate [get]
+func (h *APIHandler) GetWeaviateSyncStatus(c *gin.Context) {
+	if h.weaviateSyncService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Weaviate sync requires both Weaviate and PostgreSQL, and at least one is unavailable",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    h.weaviateSyncService.Status(),
+	})
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListWebhooks godoc
+// @Summary List webhooks
+// @Description Every registered webhook, without its secret
+// @Tags webhooks
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.Webhook}
+// @Failure 503 {object} models.APIResponse
+// @Router /webhooks [get]
+func (h *APIHandler) ListWebhooks(c *gin.Context) {
+	if !h.requireWebhooks(c) {
+		return
+	}
+
+	webhooks, err := h.webhookService.List(c.Request.Context())
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    webhooks,
+		Message: fmt.Sprintf("Retrieved %d webhooks", len(webhooks)),
+	})
+}
+
+// CreateWebhook godoc
+// @Summary Register a webhook
+// @Description Register a URL to be POSTed the events it subscribes to: product.updated, stock.low, import.completed. Calls are signed with HMAC-SHA256; the secret is generated unless given and is only returned here.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body models.WebhookRequest true "URL, events and optional secret"
+// @Success 201 {object} models.APIResponse{data=models.Webhook}
+// @Failure 400 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
+// @Router /webhooks [post]
+func (h *APIHandler) CreateWebhook(c *gin.Context) {
+	req, ok := h.bindWebhook(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.Create(c.Request.Context(), req, shareCreator(c))
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+	log.Printf("🪝 [webhooks] Registered %d for %v at %s", webhook.ID, webhook.Events, webhook.URL)
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    webhook,
+		Message: fmt.Sprintf("Webhook %d created; store its secret, it is not shown again", webhook.ID),
+	})
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook id"
+// @Success 200 {object} models.APIResponse{data=models.Webhook}
+// @Failure 404 {object} models.APIResponse
+// @Router /webhooks/{id} [get]
+func (h *APIHandler) GetWebhook(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.Get(c.Request.Context(), id)
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    webhook,
+	})
+}
+
+// UpdateWebhook godoc
+// @Summary Change a webhook
+// @Description Replace the URL, events and description of a webhook. active pauses or resumes it; a secret replaces the current one.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook id"
+// @Param request body models.WebhookRequest true "URL, events, active flag and optional new secret"
+// @Success 200 {object} models.APIResponse{data=models.Webhook}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /webhooks/{id} [put]
+func (h *APIHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+	req, ok := h.bindWebhook(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+	log.Printf("🪝 [webhooks] Updated %d (active: %t)", webhook.ID, webhook.Active)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    webhook,
+		Message: fmt.Sprintf("Webhook %d updated", webhook.ID),
+	})
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Remove a webhook and its deliveries, including those not sent yet
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook id"
+// @Success 200 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /webhooks/{id} [delete]
+func (h *APIHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.Delete(c.Request.Context(), id); err != nil {
+		h.webhookError(c, err)
+		return
+	}
+	log.Printf("🪝 [webhooks] Deleted %d", id)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Webhook %d deleted", id),
+	})
+}
+
+// TestWebhook godoc
+// @Summary Send a test event
+// @Description Queue a "ping" event for the webhook, even while it is paused. Check its outcome in the deliveries.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook id"
+// @Success 202 {object} models.APIResponse{data=models.WebhookDelivery}
+// @Failure 404 {object} models.APIResponse
+// @Router /webhooks/{id}/test [post]
+func (h *APIHandler) TestWebhook(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Test(c.Request.Context(), id)
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    delivery,
+		Message: fmt.Sprintf("Ping queued as delivery %d", delivery.ID),
+	})
+}
+
+// ListWebhookDeliveries godoc
+// @Summary List the deliveries of a webhook
+// @Description Latest deliveries first, with their attempts, last response status and error
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook id"
+// @Param status query string false "pending, delivered or failed"
+// @Param limit query int false "Maximum deliveries (default 50, max 500)"
+// @Success 200 {object} models.APIResponse{data=[]models.WebhookDelivery}
+// @Failure 404 {object} models.APIResponse
+// @Router /webhooks/{id}/deliveries [get]
+func (h *APIHandler) ListWebhookDeliveries(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryFailed:
+	default:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Unknown status '%s': use pending, delivered or failed", status),
+		})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	deliveries, err := h.webhookService.Deliveries(c.Request.Context(), id, status, min(limit, 500))
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    deliveries,
+		Message: fmt.Sprintf("Retrieved %d deliveries", len(deliveries)),
+	})
+}
+
+// RetryWebhookDelivery godoc
+// @Summary Retry a delivery
+// @Description Queue a delivery again with a fresh set of attempts, e.g. after a failed one once the receiver is fixed
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook id"
+// @Param delivery path int true "Delivery id"
+// @Success 202 {object} models.APIResponse{data=models.WebhookDelivery}
+// @Failure 404 {object} models.APIResponse
+// @Router /webhooks/{id}/deliveries/{delivery}/retry [post]
+func (h *APIHandler) RetryWebhookDelivery(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("delivery"), 10, 64)
+	if err != nil {
+		h.webhookError(c, services.ErrWebhookNotFound)
+		return
+	}
+
+	delivery, err := h.webhookService.Retry(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    delivery,
+		Message: fmt.Sprintf("Delivery %d queued again", delivery.ID),
+	})
+}
+
+func (h *APIHandler) bindWebhook(c *gin.Context) (models.WebhookRequest, bool) {
+	var req models.WebhookRequest
+	if !h.requireWebhooks(c) {
+		return req, false
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format: " + err.Error(),
+		})
+		return req, false
+	}
+	req.URL = strings.TrimSpace(req.URL)
+	return req, true
+}
+
+func (h *APIHandler) webhookID(c *gin.Context) (int64, bool) {
+	if !h.requireWebhooks(c) {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.webhookError(c, services.ErrWebhookNotFound)
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *APIHandler) webhookError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidWebhook):
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
+
+// requireWebhooks answers 503 when webhooks are disabled or PostgreSQL is unavailable
+func (h *APIHandler) requireWebhooks(c *gin.Context) bool {
+	if h.webhookService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Error:   "Webhooks require PostgreSQL and are off when webhooks.disabled is set",
+		})
+		return false
+	}
+	return true
+}
+
+// productUpdated emits product.updated for code; source says what changed
+func (h *APIHandler) productUpdated(code, source string, details map[string]interface{}) {
+	data := map[string]interface{}{"ic_code": code, "source": source}
+	for key, value := range details {
+		data[key] = value
+	}
+	h.webhookService.Emit(models.WebhookProductUpdated, data)
+}
+
+// inventoryWebhooks turns inventory changes into webhook events: price changes are product
+// updates, and a balance falling to webhooks.low_stock_threshold or below is stock.low
+func (h *APIHandler) inventoryWebhooks(event models.InventoryEvent) {
+	switch event.Type {
+	case models.InventoryEventPrice:
+		h.productUpdated(event.ICCode, "price", map[string]interface{}{
+			"prices":          event.Prices,
+			"previous_prices": event.PreviousPrices,
+		})
+	case models.InventoryEventBalance:
+		threshold := h.config.Webhooks.LowStockThreshold
+		if event.BalanceQty == nil || event.PreviousBalanceQty == nil {
+			return
+		}
+		if *event.PreviousBalanceQty > threshold && *event.BalanceQty <= threshold {
+			h.webhookService.Emit(models.WebhookStockLow, map[string]interface{}{
+				"ic_code":              event.ICCode,
+				"balance_qty":          *event.BalanceQty,
+				"previous_balance_qty": *event.PreviousBalanceQty,
+				"threshold":            threshold,
+			})
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"smlgoapi/middleware"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsMaxMessageBytes   = 1 << 20
+	wsMaxSubscriptions  = 8 // per connection
+	wsWriteTimeout      = 10 * time.Second
+	wsPongTimeout       = 60 * time.Second
+	wsPingInterval      = 25 * time.Second
+	wsSearchBatch       = 20  // products per "rows" message of a search
+	wsSelectBatch       = 100 // rows per "rows" message of a select
+	wsProgressInterval  = time.Second
+	wsSendQueueMessages = 64
+)
+
+// Cross-origin upgrades have already been checked against the cors section by the CORS middleware
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// WebSocket godoc
+// @Summary Live search, SELECT and job progress over a WebSocket
+// @Description Upgrade to a WebSocket and send {"id", "type"} messages: "search" and "select" stream their results back in "rows" batches followed by "done"; "jobs" pushes the progress of the Weaviate sync, table sync, exports, product imports and scheduled jobs whenever it changes; "cancel" stops a subscription. Browsers can pass credentials as ?access_token=.
+// @Tags websocket
+// @Param access_token query string false "API key or access token, for clients that cannot set headers"
+// @Success 101
+// @Router /ws [get]
+func (h *APIHandler) WebSocket(c *gin.Context) {
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already answered the request
+		log.Printf("⚠️ [ws] Upgrade failed: %v", err)
+		return
+	}
+
+	credential, _ := middleware.Credential(c.Request)
+	session := &wsSession{
+		h:          h,
+		conn:       conn,
+		credential: credential,
+		role:       limitRole(c),
+		route:      c.FullPath(),
+		user:       shareCreator(c),
+		send:       make(chan models.WSMessage, wsSendQueueMessages),
+		subs:       map[string]context.CancelFunc{},
+	}
+	log.Printf("🔌 [ws] %s connected", c.ClientIP())
+	session.serve(c.Request.Context())
+	log.Printf("🔌 [ws] %s disconnected", c.ClientIP())
+}
+
+// wsSession is one WebSocket connection and its running subscriptions
+type wsSession struct {
+	h          *APIHandler
+	conn       *websocket.Conn
+	credential string // checked again by subscriptions that need more than the route allows
+	role       string // page_limits role
+	route      string // listed with the statements of "select" in the running-query registry
+	user       string
+	send       chan models.WSMessage
+
+	mu   sync.Mutex
+	subs map[string]context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// serve reads client messages until the connection closes, then stops every subscription
+func (s *wsSession) serve(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	writerDone := make(chan struct{})
+	go func() {
+		s.writeLoop(ctx)
+		close(writerDone)
+	}()
+
+	s.conn.SetReadLimit(wsMaxMessageBytes)
+	_ = s.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	for {
+		var req models.WSRequest
+		if err := s.conn.ReadJSON(&req); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				s.push(ctx, models.WSMessage{Type: "error", Error: "Invalid JSON message: " + err.Error()})
+				continue
+			}
+			break
+		}
+		_ = s.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		s.handle(ctx, req)
+	}
+
+	cancel()
+	s.wg.Wait()
+	<-writerDone
+	s.conn.Close()
+}
+
+// writeLoop is the only writer of the connection: queued messages and keep-alive pings
+func (s *wsSession) writeLoop(ctx context.Context) {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case msg := <-s.send:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := s.conn.WriteJSON(msg); err != nil {
+				s.conn.Close() // ends the read loop
+				return
+			}
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				s.conn.Close()
+				return
+			}
+		case <-ctx.Done():
+			_ = s.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteTimeout))
+			return
+		}
+	}
+}
+
+// push queues a message, waiting while the client is slow to read; false once the connection is gone
+func (s *wsSession) push(ctx context.Context, msg models.WSMessage) bool {
+	select {
+	case s.send <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *wsSession) handle(ctx context.Context, req models.WSRequest) {
+	var run func(ctx context.Context, req models.WSRequest) error
+	switch req.Type {
+	case "cancel":
+		s.mu.Lock()
+		cancel, ok := s.subs[req.ID]
+		s.mu.Unlock()
+		if ok {
+			cancel()
+		}
+		return
+	case "search":
+		run = s.search
+	case "select":
+		run = s.selectRows
+	case "jobs":
+		run = s.jobs
+	default:
+		s.push(ctx, models.WSMessage{ID: req.ID, Type: "error", Error: fmt.Sprintf("Unknown message type '%s': use search, select, jobs or cancel", req.Type)})
+		return
+	}
+
+	s.mu.Lock()
+	_, taken := s.subs[req.ID]
+	full := len(s.subs) >= wsMaxSubscriptions
+	var subCtx context.Context
+	var cancel context.CancelFunc
+	if !taken && !full {
+		subCtx, cancel = context.WithCancel(ctx)
+		s.subs[req.ID] = cancel
+	}
+	s.mu.Unlock()
+	if taken {
+		s.push(ctx, models.WSMessage{ID: req.ID, Type: "error", Error: fmt.Sprintf("Subscription '%s' is already running", req.ID)})
+		return
+	}
+	if full {
+		s.push(ctx, models.WSMessage{ID: req.ID, Type: "error", Error: fmt.Sprintf("At most %d subscriptions may run on one connection", wsMaxSubscriptions)})
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.subs, req.ID)
+			s.mu.Unlock()
+			cancel()
+		}()
+		if err := run(subCtx, req); err != nil && subCtx.Err() == nil {
+			s.push(ctx, models.WSMessage{ID: req.ID, Type: "error", Error: err.Error()})
+		}
+	}()
+}
+
+// search sends the PostgreSQL text search results batch by batch, so the first products arrive
+// before the whole result is ranked
+func (s *wsSession) search(ctx context.Context, req models.WSRequest) error {
+	if s.h.postgreSQLService == nil {
+		return errors.New("product search requires PostgreSQL, which is unavailable")
+	}
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return errors.New("query must not be empty")
+	}
+
+	limit := clampToPageLimit(s.h.config.PageLimits.Resolve(searchLimitRoute, s.role), req.Limit)
+	start := time.Now()
+	sent := 0
+	for sent < limit {
+		batch := min(wsSearchBatch, limit-sent)
+		products, total, err := s.h.postgreSQLService.SearchProducts(ctx, query, batch, sent)
+		if err != nil {
+			return fmt.Errorf("search failed: %w", err)
+		}
+		if len(products) > 0 && !s.push(ctx, models.WSMessage{ID: req.ID, Type: "rows", Data: convertSearchResults(products), Total: total}) {
+			return nil
+		}
+		sent += len(products)
+		if len(products) < batch || sent >= total {
+			break
+		}
+	}
+
+	s.push(ctx, models.WSMessage{ID: req.ID, Type: "done", RowCount: sent, Duration: float64(time.Since(start).Nanoseconds()) / 1e6})
+	return nil
+}
+
+// selectRows streams a SELECT in batches. Raw SQL needs what /v1/pgselect needs: a read API key
+// or an admin session whenever auth or JWT is enabled.
+func (s *wsSession) selectRows(ctx context.Context, req models.WSRequest) error {
+	if s.h.config.Auth.Enabled || s.h.config.JWT.Enabled {
+		if _, err := s.h.authorizeCredential(ctx, s.credential, models.ScopeRead, models.RoleAdmin); err != nil {
+			return err
+		}
+	}
+
+	var stream rowStreamer
+	database, defaultSchema := services.ServicePostgreSQL, postgreSQLDefaultSchema
+	forPostgres := true
+	switch strings.ToLower(strings.TrimSpace(req.Database)) {
+	case "", services.ServicePostgreSQL:
+		if s.h.postgreSQLService == nil {
+			return errors.New("PostgreSQL is unavailable")
+		}
+		stream = s.h.postgreSQLService.StreamSelect
+	case services.ServiceClickHouse:
+		if s.h.clickHouseService == nil {
+			return errors.New("ClickHouse is unavailable")
+		}
+		stream = s.h.clickHouseService.StreamSelect
+		database, defaultSchema = services.ServiceClickHouse, s.h.config.ClickHouse.Database
+		forPostgres = false
+	default:
+		return fmt.Errorf("database must be %s or %s", services.ServicePostgreSQL, services.ServiceClickHouse)
+	}
+
+	if err := s.h.checkSelectSQL(database, req.Query, defaultSchema); err != nil {
+		log.Printf("🛡️ [ws] Rejected by SQL policy: %v", err)
+		return err
+	}
+	params, err := services.NormalizeQueryParams(req.Params, forPostgres)
+	if err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+
+	ctx, timeout, cancel, err := s.h.queryTimeoutContext(ctx, s.route, database, req.Query, s.user, 0)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	start := time.Now()
+	batch := make([]map[string]interface{}, 0, wsSelectBatch)
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		ok := s.push(ctx, models.WSMessage{ID: req.ID, Type: "rows", Data: batch})
+		batch = make([]map[string]interface{}, 0, wsSelectBatch)
+		return ok
+	}
+	count, err := stream(ctx, req.Query, func(columns []string, values []interface{}) error {
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+		batch = append(batch, row)
+		if len(batch) == wsSelectBatch && !flush() {
+			return ctx.Err()
+		}
+		return nil
+	}, params...)
+	if err != nil {
+		// The timeout, an admin cancel or a shutdown ends the statement's context, not the subscription
+		switch cause := context.Cause(ctx); {
+		case errors.Is(cause, context.DeadlineExceeded):
+			return fmt.Errorf("query cancelled: it did not finish within %s", timeout)
+		case ctx.Err() != nil && cause != nil:
+			return cause
+		}
+		return fmt.Errorf("query execution failed: %w", err)
+	}
+	if !flush() {
+		return nil
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	log.Printf("✅ [ws] Streamed %d rows in %.2fms", count, duration)
+	s.push(ctx, models.WSMessage{ID: req.ID, Type: "done", RowCount: count, Duration: duration})
+	return nil
+}
+
+// jobs pushes the status of every background job when it subscribes, then each status that
+// changed, until cancelled. Job status is admin information, as on /v1/admin.
+func (s *wsSession) jobs(ctx context.Context, req models.WSRequest) error {
+	if _, err := s.h.authorizeCredential(ctx, s.credential, models.ScopeAdmin, models.RoleAdmin); err != nil {
+		return err
+	}
+
+	sent := map[string][]byte{}
+	ticker := time.NewTicker(wsProgressInterval)
+	defer ticker.Stop()
+	for {
+		for job, status := range s.h.jobProgress() {
+			encoded, err := json.Marshal(status)
+			if err != nil || bytes.Equal(sent[job], encoded) {
+				continue
+			}
+			sent[job] = encoded
+			if !s.push(ctx, models.WSMessage{ID: req.ID, Type: "progress", Job: job, Data: json.RawMessage(encoded)}) {
+				return nil
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// jobProgress collects the status of the long-running work of the server by job name
+func (h *APIHandler) jobProgress() map[string]interface{} {
+	progress := map[string]interface{}{
+		"import": h.importProgress.Status(),
+	}
+	if h.weaviateSyncService != nil {
+		progress["weaviate_sync"] = h.weaviateSyncService.Status()
+	}
+	if h.tableSyncService != nil {
+		progress["table_sync"] = h.tableSyncService.Status()
+	}
+	if h.exportJobService != nil {
+		for _, job := range h.exportJobService.List() {
+			progress["export:"+job.ID] = job
+		}
+	}
+	for _, job := range h.jobScheduler.List() {
+		progress["job:"+job.Name] = job
+	}
+	return progress
+}
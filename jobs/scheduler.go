@@ -0,0 +1,252 @@
+// Package jobs runs periodic maintenance tasks on cron-style schedules and keeps the outcome of
+// their last run for /v1/admin/jobs.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrJobNotFound is returned for a job name that was not registered
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is returned when a job is triggered while it is still running
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Func performs a job and returns a short summary of what it did
+type Func func(ctx context.Context) (string, error)
+
+// Job describes a task. Disabled jobs are not run on their schedule but can still be triggered.
+type Job struct {
+	Name        string
+	Description string
+	Schedule    string        // see ParseSchedule
+	Enabled     bool          // run on the schedule
+	Timeout     time.Duration // 0 means no timeout
+	Run         Func
+}
+
+// RunInfo is the outcome of one run
+type RunInfo struct {
+	Trigger    string     `json:"trigger"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+	Success    bool       `json:"success"`
+	Result     string     `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Status is a job with its schedule and run history
+type Status struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Schedule    string     `json:"schedule"`
+	Enabled     bool       `json:"enabled"`
+	Running     bool       `json:"running"`
+	NextRun     *time.Time `json:"next_run,omitempty"`
+	Runs        int        `json:"runs"`
+	Failures    int        `json:"failures"`
+	LastRun     *RunInfo   `json:"last_run,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Scheduler runs registered jobs. Each job runs at most once at a time; a scheduled run that
+// finds the previous one still running is skipped.
+type Scheduler struct {
+	mu      sync.RWMutex
+	entries map[string]*entry
+	ctx     context.Context
+}
+
+// NewScheduler creates an empty scheduler
+func NewScheduler() *Scheduler {
+	return &Scheduler{entries: make(map[string]*entry), ctx: context.Background()}
+}
+
+// Register adds a job; it must be called before Start
+func (s *Scheduler) Register(job Job) error {
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.entries[job.Name]; exists {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+	s.entries[job.Name] = &entry{
+		job:      job,
+		schedule: schedule,
+		status: Status{
+			Name:        job.Name,
+			Description: job.Description,
+			Schedule:    job.Schedule,
+			Enabled:     job.Enabled,
+		},
+	}
+	return nil
+}
+
+// Start runs enabled jobs on their schedules until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	entries := make([]*entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	s.mu.Unlock()
+
+	for _, e := range entries {
+		if e.job.Enabled {
+			log.Printf("🕒 [jobs] %s scheduled (%s)", e.job.Name, e.job.Schedule)
+			go s.loop(ctx, e)
+		}
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	for {
+		next := e.schedule.Next(time.Now())
+		e.mu.Lock()
+		e.status.NextRun = &next
+		e.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := s.run(ctx, e, TriggerSchedule, false); errors.Is(err, ErrJobRunning) {
+				log.Printf("⏭️ [jobs] %s skipped: previous run still in progress", e.job.Name)
+			}
+		}
+	}
+}
+
+// Trigger starts a job now in the background
+func (s *Scheduler) Trigger(name string) (Status, error) {
+	s.mu.RLock()
+	e, ok := s.entries[name]
+	ctx := s.ctx
+	s.mu.RUnlock()
+	if !ok {
+		return Status{}, ErrJobNotFound
+	}
+
+	if err := s.run(ctx, e, TriggerManual, true); err != nil {
+		return e.snapshot(), err
+	}
+	return e.snapshot(), nil
+}
+
+// run marks the job running and executes it, in a new goroutine when async is set
+func (s *Scheduler) run(ctx context.Context, e *entry, trigger string, async bool) error {
+	e.mu.Lock()
+	if e.status.Running {
+		e.mu.Unlock()
+		return ErrJobRunning
+	}
+	info := &RunInfo{Trigger: trigger, StartedAt: time.Now()}
+	e.status.Running = true
+	e.status.LastRun = info
+	e.mu.Unlock()
+
+	execute := func() {
+		runCtx := ctx
+		if e.job.Timeout > 0 {
+			var cancel context.CancelFunc
+			runCtx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+			defer cancel()
+		}
+
+		result, err := e.job.Run(runCtx)
+		finished := time.Now()
+
+		e.mu.Lock()
+		e.status.Running = false
+		e.status.Runs++
+		info.FinishedAt = &finished
+		info.DurationMs = finished.Sub(info.StartedAt).Milliseconds()
+		info.Result = result
+		if err != nil {
+			e.status.Failures++
+			info.Error = err.Error()
+		} else {
+			info.Success = true
+			e.status.LastSuccess = &finished
+		}
+		e.mu.Unlock()
+
+		if err != nil {
+			log.Printf("❌ [jobs] %s failed after %dms (%s): %v", e.job.Name, info.DurationMs, trigger, err)
+		} else {
+			log.Printf("✅ [jobs] %s finished in %dms (%s): %s", e.job.Name, info.DurationMs, trigger, result)
+		}
+	}
+
+	if async {
+		go execute()
+	} else {
+		execute()
+	}
+	return nil
+}
+
+// List returns the status of every job, ordered by name
+func (s *Scheduler) List() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(s.entries))
+	for _, e := range s.entries {
+		statuses = append(statuses, e.snapshot())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Get returns the status of one job
+func (s *Scheduler) Get(name string) (Status, error) {
+	s.mu.RLock()
+	e, ok := s.entries[name]
+	s.mu.RUnlock()
+	if !ok {
+		return Status{}, ErrJobNotFound
+	}
+	return e.snapshot(), nil
+}
+
+func (e *entry) snapshot() Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	status := e.status
+	if e.status.LastRun != nil {
+		lastRun := *e.status.LastRun
+		status.LastRun = &lastRun
+	}
+	return status
+}
@@ -2,23 +2,73 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"smlgoapi/config"
 	"smlgoapi/handlers"
 	"smlgoapi/services"
+	"smlgoapi/tracing"
+	"strings"
 	"syscall"
 	"time"
+
+	"google.golang.org/grpc"
 )
 
+//go:generate go run github.com/swaggo/swag/cmd/swag@v1.16.4 init --generalInfo main.go --output docs --outputTypes go,json --parseInternal
+
+// @title SMLGOAPI
+// @version 1.0.0
+// @description Product search, SQL and Thai administrative data over ClickHouse and PostgreSQL.
+// @description Endpoints behind authentication take "Authorization: Bearer <access token or API key>".
+// @BasePath /v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
-	// Load configuration
-	cfg := config.LoadConfig()
+	// Without a subcommand the server starts, so existing deployments keep working
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		serve(args)
+	case "sync":
+		syncVectorIndex(args)
+	case "migrate":
+		migrate(args)
+	case "help":
+		usage()
+	default:
+		log.Printf("❌ Unknown command %q", command)
+		usage()
+		os.Exit(2)
+	}
+}
+
+// serve runs the HTTP API, and the gRPC API when grpc.enabled is set, until SIGINT or SIGTERM
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	validateOnly := flags.Bool("validate-config", false, "check smlgoapi.json and exit")
+	flags.Parse(args)
+
+	cfg := loadConfig(*validateOnly)
+
+	// Spans are exported from here on; database connections opened below are traced
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Printf("⚠️ Tracing disabled: %v", err)
+		shutdownTracing = func(context.Context) error { return nil }
+	}
+
 	// Initialize ClickHouse service
 	var clickHouseService *services.ClickHouseService
-	clickHouseService, err := services.NewClickHouseService(cfg)
+	clickHouseService, err = services.NewClickHouseService(cfg)
 	if err != nil {
 		log.Printf("⚠️ ClickHouse service unavailable: %v", err)
 		log.Println("🔄 Continuing with PostgreSQL-only mode...")
@@ -35,15 +85,21 @@ func main() {
 	defer postgreSQLService.Close()
 
 	// Initialize API handlers
-	apiHandler := handlers.NewAPIHandler(clickHouseService, postgreSQLService)
+	apiHandler := handlers.NewAPIHandler(cfg, clickHouseService, postgreSQLService)
 
 	// Setup Gin router
-	router := setupRouter(apiHandler)
+	router := setupRouter(cfg, apiHandler)
 	// Create HTTP server
+	// Read and write deadlines are set per request by the limits middleware
 	srv := &http.Server{
-		Addr:    cfg.GetServerAddress(),
-		Handler: router,
+		Addr:              cfg.GetServerAddress(),
+		Handler:           router,
+		ReadHeaderTimeout: time.Duration(cfg.Limits.ReadHeaderTimeoutSeconds) * time.Second,
+		IdleTimeout:       time.Duration(cfg.Limits.IdleTimeoutSeconds) * time.Second,
 	}
+	// Requests run under the operation tracker's context, so a shutdown can cancel their statements
+	srv.BaseContext = func(net.Listener) context.Context { return apiHandler.Operations().Context() }
+	srv.RegisterOnShutdown(apiHandler.CloseEventStreams)
 
 	// Start server in a goroutine
 	go func() {
@@ -64,24 +120,93 @@ func main() {
 		log.Printf("  - API v1 Base: http://%s/v1", displayURL)
 		log.Printf("  - API Legacy: http://%s/api", displayURL)
 		log.Printf("  - Documentation: http://%s/", displayURL)
+		if cfg.Auth.Enabled {
+			log.Printf("🔒 API key authentication enabled for database and admin endpoints")
+		}
+		if cfg.RateLimit.Enabled {
+			log.Printf("🚦 Rate limiting enabled: %d requests/minute default, %d route rules", cfg.RateLimit.RequestsPerMinute, len(cfg.RateLimit.Routes))
+		}
+		if cfg.JWT.Enabled {
+			log.Printf("🔑 JWT sessions enabled: login at http://%s/v1/auth/login", displayURL)
+		}
 
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("❌ Failed to start server: %v", err)
 		}
 	}()
 
+	// gRPC on its own port, sharing the handlers and services of the HTTP API
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled {
+		listener, err := net.Listen("tcp", cfg.GetGRPCAddress())
+		if err != nil {
+			log.Fatalf("❌ Failed to listen for gRPC on %s: %v", cfg.GetGRPCAddress(), err)
+		}
+		grpcServer = apiHandler.NewGRPCServer()
+		go func() {
+			log.Printf("📡 gRPC server starting on %s (proto/smlgoapi.proto)", cfg.GetGRPCAddress())
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatalf("❌ Failed to start gRPC server: %v", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("🛑 Shutting down server...")
 
-	// Give a 5 second timeout for shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// Requests, imports, syncs and jobs in progress get drain_seconds to finish
+	operations := apiHandler.Operations()
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), time.Duration(cfg.Shutdown.DrainSeconds)*time.Second)
+	defer cancelDrain()
+	if running := operations.Running(); len(running) > 0 {
+		log.Printf("⏳ Waiting up to %ds for %d operation(s): %s", cfg.Shutdown.DrainSeconds, len(running), strings.Join(running, ", "))
+	}
+	drainErr := srv.Shutdown(drainCtx)
+	if grpcServer != nil {
+		stopGRPC(drainCtx, grpcServer)
+	}
+	remaining := operations.Drain(drainCtx)
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("❌ Server forced to shutdown: %v", err)
+	// Then what still runs is cancelled, which cancels its statements on the databases; requests
+	// answer 503 and write their audit entries before the connections close. This also stops the
+	// job schedules.
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), time.Duration(cfg.Shutdown.FlushSeconds)*time.Second)
+	defer cancelFlush()
+	operations.Cancel()
+	if drainErr != nil || len(remaining) > 0 {
+		log.Printf("⚠️ Cancelled what did not finish within %ds", cfg.Shutdown.DrainSeconds)
+		for _, op := range remaining {
+			log.Printf("  - %s", op)
+		}
+		if err := srv.Shutdown(flushCtx); err != nil {
+			log.Printf("⚠️ Server forced to shutdown: %v", err)
+		}
+		if left := operations.Drain(flushCtx); len(left) > 0 {
+			log.Printf("⚠️ Still running at exit: %s", strings.Join(left, ", "))
+		}
+	}
+
+	// Last, the queued search events, webhooks and traces are written
+	apiHandler.Close(flushCtx)
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Printf("⚠️ Failed to flush traces: %v", err)
 	}
 	log.Println("✅ Server exited")
 }
+
+// stopGRPC lets running calls finish, cancelling those still running when ctx ends
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
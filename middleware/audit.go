@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditContextKey is the gin context key holding the audit entry of a write request
+const AuditContextKey = "audit_entry"
+
+// auditWriteTimeout bounds the INSERT into audit_log after the handler has finished
+const auditWriteTimeout = 5 * time.Second
+
+// Audit records every request that may change data, i.e. any method but GET, HEAD and OPTIONS,
+// in the audit log once the handler has finished. Handlers add the SQL they ran and the rows it
+// affected with AuditSQL and AuditRows. Place it after Authenticate so the entry names the caller.
+func Audit(audit *services.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		entry := &models.AuditEntry{}
+		c.Set(AuditContextKey, entry)
+
+		c.Next()
+
+		entry.Time = start
+		entry.Actor = auditActor(c)
+		entry.Method = c.Request.Method
+		entry.Route = c.FullPath()
+		entry.Path = c.Request.URL.Path
+		entry.Status = c.Writer.Status()
+		entry.DurationMs = float64(time.Since(start).Nanoseconds()) / 1e6
+		entry.ClientIP = c.ClientIP()
+		entry.RequestID = c.GetString(RequestIDContextKey)
+
+		// The client may already be gone; the entry is written regardless
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), auditWriteTimeout)
+		defer cancel()
+		if err := audit.Record(ctx, entry); err != nil {
+			log.Printf("⚠️ [audit] %s %s by %q not recorded: %v", entry.Method, entry.Path, entry.Actor, err)
+		}
+	}
+}
+
+// AuditSQL records the SQL a write request ran; it does nothing on routes without Audit
+func AuditSQL(c *gin.Context, sql string) {
+	if entry := auditEntryFromContext(c); entry != nil {
+		entry.SQL = sql
+	}
+}
+
+// AuditRows records how many rows a write request changed
+func AuditRows(c *gin.Context, rows int64) {
+	if entry := auditEntryFromContext(c); entry != nil {
+		entry.RowsAffected = &rows
+	}
+}
+
+// AuditDetail records a short description of what a request did beyond its SQL, e.g. the file
+// an import read
+func AuditDetail(c *gin.Context, detail string) {
+	if entry := auditEntryFromContext(c); entry != nil {
+		entry.Detail = detail
+	}
+}
+
+func auditEntryFromContext(c *gin.Context) *models.AuditEntry {
+	value, exists := c.Get(AuditContextKey)
+	if !exists {
+		return nil
+	}
+	entry, _ := value.(*models.AuditEntry)
+	return entry
+}
+
+// auditActor names the caller the way share links do: the session user, else the API key
+func auditActor(c *gin.Context) string {
+	if claims, ok := SessionFromContext(c); ok {
+		return claims.Username
+	}
+	if key, ok := APIKeyFromContext(c); ok {
+		return "api-key:" + key.Name
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"smlgoapi/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyContextKey is the gin context key holding the authenticated *models.APIKey
+const APIKeyContextKey = "api_key"
+
+// SessionContextKey is the gin context key holding the authenticated *models.SessionClaims
+const SessionContextKey = "session"
+
+// ErrMissingCredentials is returned when the request carries no usable credentials
+var ErrMissingCredentials = errors.New("missing credentials")
+
+// APIKeyValidator validates a raw API key and returns the stored key record
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, error)
+}
+
+// AccessTokenParser validates a JWT access token and returns the session identity
+type AccessTokenParser interface {
+	ParseAccessToken(token string) (*models.SessionClaims, error)
+}
+
+// Authenticate accepts either an API key or a JWT access token from the Authorization header.
+// "Bearer <credential>" and "ApiKey <key>" forms are accepted, as well as the X-API-Key header
+// (see Credential).
+// Either validator may be nil, in which case that kind of credential is rejected.
+func Authenticate(apiKeys APIKeyValidator, sessions AccessTokenParser) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credential, err := Credential(c.Request)
+		if err != nil {
+			abortUnauthorized(c, "Credentials required: send 'Authorization: Bearer <token or API key>'")
+			return
+		}
+		if message := identify(c, credential, apiKeys, sessions); message != "" {
+			abortUnauthorized(c, message)
+			return
+		}
+		c.Next()
+	}
+}
+
+// rejectedCredential remembers a credential that failed validation during the request
+type rejectedCredential struct {
+	credential string
+	message    string
+}
+
+const rejectedCredentialContextKey = "rejected_credential"
+
+// identify validates credential and stores the API key or session in the context, returning the
+// reason it was rejected otherwise. RateLimit and Authenticate both call it; a credential already
+// checked during the request is not looked up again.
+func identify(c *gin.Context, credential string, apiKeys APIKeyValidator, sessions AccessTokenParser) string {
+	if _, ok := APIKeyFromContext(c); ok {
+		return ""
+	}
+	if _, ok := SessionFromContext(c); ok {
+		return ""
+	}
+	if value, ok := c.Get(rejectedCredentialContextKey); ok {
+		if rejected := value.(rejectedCredential); rejected.credential == credential {
+			return rejected.message
+		}
+	}
+	reject := func(message string) string {
+		c.Set(rejectedCredentialContextKey, rejectedCredential{credential: credential, message: message})
+		return message
+	}
+
+	// JWTs are three dot-separated segments; API keys never contain dots
+	if sessions != nil && strings.Count(credential, ".") == 2 {
+		claims, err := sessions.ParseAccessToken(credential)
+		if err != nil {
+			return reject("Invalid or expired access token")
+		}
+		c.Set(SessionContextKey, claims)
+		return ""
+	}
+
+	if apiKeys == nil {
+		return "API keys are not accepted; log in via /v1/auth/login"
+	}
+
+	key, err := apiKeys.ValidateAPIKey(c.Request.Context(), credential)
+	if err != nil {
+		log.Printf("🔒 [auth] Rejected API key for %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		return reject("Invalid or revoked API key")
+	}
+
+	c.Set(APIKeyContextKey, key)
+	return ""
+}
+
+// Authorize rejects requests that are not allowed by either an API key scope or a user role.
+// API keys must carry scope; user sessions must hold minRole or a more privileged role.
+// It must run after Authenticate.
+func Authorize(scope, minRole string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key, ok := APIKeyFromContext(c); ok {
+			if !key.HasScope(scope) {
+				abortForbidden(c, "API key '"+key.Name+"' lacks required scope: "+scope)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if session, ok := SessionFromContext(c); ok {
+			if !models.RoleAtLeast(session.Role, minRole) {
+				abortForbidden(c, "User '"+session.Username+"' with role '"+session.Role+"' requires role: "+minRole)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		abortUnauthorized(c, "Authentication required")
+	}
+}
+
+// APIKeyFromContext returns the authenticated API key, if any
+func APIKeyFromContext(c *gin.Context) (*models.APIKey, bool) {
+	value, exists := c.Get(APIKeyContextKey)
+	if !exists {
+		return nil, false
+	}
+	key, ok := value.(*models.APIKey)
+	return key, ok
+}
+
+// SessionFromContext returns the authenticated user session, if any
+func SessionFromContext(c *gin.Context) (*models.SessionClaims, bool) {
+	value, exists := c.Get(SessionContextKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*models.SessionClaims)
+	return claims, ok
+}
+
+// Credential returns the API key or access token sent with a request. Browsers cannot set headers
+// on WebSocket connections or EventSource streams, so those requests may pass it as the
+// access_token query parameter.
+func Credential(r *http.Request) (string, error) {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key, nil
+	}
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		if token := strings.TrimSpace(r.URL.Query().Get("access_token")); token != "" {
+			return token, nil
+		}
+	}
+
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	scheme, value, found := strings.Cut(header, " ")
+	if !found {
+		return "", ErrMissingCredentials
+	}
+	if !strings.EqualFold(scheme, "Bearer") && !strings.EqualFold(scheme, "ApiKey") {
+		return "", ErrMissingCredentials
+	}
+	if value = strings.TrimSpace(value); value == "" {
+		return "", ErrMissingCredentials
+	}
+	return value, nil
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="smlgoapi"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
+
+func abortForbidden(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/services"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS answers cross-origin requests from the origins the cors section allows for the request
+// path. Other origins get 403; requests without an Origin header are not affected.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	return cors.New(cors.Config{
+		AllowOriginWithContextFunc: func(c *gin.Context, origin string) bool {
+			return originAllowed(cfg.OriginsFor(c.Request.URL.Path), origin)
+		},
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "HEAD"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key", RequestIDHeader, "traceparent", services.LatencyBudgetHeader},
+		ExposeHeaders:    []string{"Content-Length", RequestIDHeader, DegradedServicesHeader},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	})
+}
+
+// originAllowed matches origin against "*", exact origins and "scheme://*.domain" patterns. A
+// wildcard covers every subdomain depth but not the domain itself.
+func originAllowed(patterns []string, origin string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || strings.EqualFold(pattern, origin) {
+			return true
+		}
+		scheme, domain, ok := strings.Cut(pattern, "*.")
+		if !ok || !strings.HasPrefix(strings.ToLower(origin), strings.ToLower(scheme)) {
+			continue
+		}
+		host := strings.ToLower(origin[len(scheme):])
+		if len(host) > len(domain)+1 && strings.HasSuffix(host, "."+strings.ToLower(domain)) && !strings.Contains(host, "/") {
+			return true
+		}
+	}
+	return false
+}
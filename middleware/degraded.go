@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"strings"
+
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DegradedServicesContextKey is the gin context key holding the services a request goes without
+const DegradedServicesContextKey = "degraded_services"
+
+// DegradedServicesHeader lists those services, comma separated, on every response
+const DegradedServicesHeader = "X-Degraded-Services"
+
+// DegradedServices takes the services currently marked down in the registry as the request
+// starts. Handlers add the fallbacks they take with MarkDegraded; API envelopes copy the list into
+// degraded_services and every response carries it in the X-Degraded-Services header.
+func DegradedServices(registry *services.ServiceRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if degraded := registry.Degraded(); len(degraded) > 0 {
+			c.Set(DegradedServicesContextKey, degraded)
+			c.Header(DegradedServicesHeader, strings.Join(degraded, ","))
+		}
+		c.Next()
+	}
+}
+
+// MarkDegraded records that the request was answered without service; call it before writing
+// the response
+func MarkDegraded(c *gin.Context, service string) {
+	degraded := DegradedServicesFromContext(c)
+	for _, name := range degraded {
+		if name == service {
+			return
+		}
+	}
+	degraded = append(append([]string(nil), degraded...), service)
+	c.Set(DegradedServicesContextKey, degraded)
+	c.Header(DegradedServicesHeader, strings.Join(degraded, ","))
+}
+
+// DegradedServicesFromContext returns the services the request goes without, if any
+func DegradedServicesFromContext(c *gin.Context) []string {
+	value, exists := c.Get(DegradedServicesContextKey)
+	if !exists {
+		return nil
+	}
+	degraded, _ := value.([]string)
+	return degraded
+}
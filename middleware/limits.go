@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeGrace is how long after the handler deadline the response may still be written
+const writeGrace = 10 * time.Second
+
+// Limits applies the body size and handler deadline of the request's route. A body over the
+// limit answers 413, a handler still running at the deadline sees its context cancelled and the
+// request answers 408. The connection's read and write deadlines follow the route, so routes
+// without a deadline (streamed SQL results) are not cut off by the server.
+func Limits(cfg config.LimitsConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := cfg.For(c.Request.URL.Path)
+
+		var body *limitedBody
+		if limit.MaxBodyBytes > 0 {
+			if c.Request.ContentLength > limit.MaxBodyBytes {
+				abortBodyTooLarge(c, limit.MaxBodyBytes)
+				return
+			}
+			body = &limitedBody{ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, limit.MaxBodyBytes)}
+			c.Request.Body = body
+		}
+
+		ctx := c.Request.Context()
+		controller := http.NewResponseController(c.Writer)
+		var deadline time.Time
+		if limit.TimeoutSeconds > 0 {
+			timeout := time.Duration(limit.TimeoutSeconds) * time.Second
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+			deadline = time.Now().Add(timeout + writeGrace)
+		}
+		// Errors only mean the connection does not support deadlines (e.g. HTTP/2 without them)
+		_ = controller.SetReadDeadline(deadline)
+		_ = controller.SetWriteDeadline(deadline)
+
+		c.Writer = &limitsWriter{ResponseWriter: c.Writer, ctx: ctx, body: body, limit: limit}
+		c.Next()
+
+		if !c.Writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			abortTimeout(c, limit.TimeoutSeconds)
+		}
+	}
+}
+
+// limitedBody remembers that the body went over its limit
+type limitedBody struct {
+	io.ReadCloser
+	tooLarge bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		b.tooLarge = true
+	}
+	return n, err
+}
+
+// limitsWriter turns the error status a handler answers after hitting a limit into 413 or 408,
+// since handlers report a failed read or a cancelled query as 400 or 500
+type limitsWriter struct {
+	gin.ResponseWriter
+	ctx   context.Context
+	body  *limitedBody
+	limit config.RouteLimit
+}
+
+func (w *limitsWriter) WriteHeader(code int) {
+	switch {
+	case code < http.StatusBadRequest:
+	case w.body != nil && w.body.tooLarge:
+		code = http.StatusRequestEntityTooLarge
+	case code >= http.StatusInternalServerError && errors.Is(w.ctx.Err(), context.DeadlineExceeded):
+		code = http.StatusRequestTimeout
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the connection
+func (w *limitsWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func abortBodyTooLarge(c *gin.Context, maxBytes int64) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, models.APIResponse{
+		Success: false,
+		Message: "Request body too large",
+		Error:   fmt.Sprintf("the body of %s may be at most %d bytes", c.Request.URL.Path, maxBytes),
+	})
+}
+
+func abortTimeout(c *gin.Context, seconds int) {
+	c.AbortWithStatusJSON(http.StatusRequestTimeout, models.APIResponse{
+		Success: false,
+		Message: "Request timed out",
+		Error:   fmt.Sprintf("%s did not finish within %d seconds", c.Request.URL.Path, seconds),
+	})
+}
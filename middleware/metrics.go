@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"smlgoapi/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics counts requests per route and status and records their latency in reg. Requests that
+// match no route are counted as "unmatched" so scanners cannot create a series per URL.
+func Metrics(reg *metrics.Registry) gin.HandlerFunc {
+	requests := reg.NewCounterVec("smlgoapi_http_requests_total",
+		"HTTP requests by method, route and status code", "method", "route", "status")
+	duration := reg.NewHistogramVec("smlgoapi_http_request_duration_seconds",
+		"HTTP request latency by method and route", metrics.DefaultDurationBuckets, "method", "route")
+
+	var inFlight atomic.Int64
+	reg.GaugeFunc("smlgoapi_http_requests_in_flight", "HTTP requests being served", nil,
+		func(_ context.Context, emit func(float64, ...string)) {
+			emit(float64(inFlight.Load()))
+		})
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		requests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		duration.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit rejects requests over the limit with 429 and a Retry-After header.
+// Clients are identified by their API key or user when the credential they send is valid,
+// otherwise by IP, so made-up keys cannot open a fresh bucket per request. Either validator may
+// be nil, in which case that kind of credential is not used to identify clients.
+func RateLimit(limiter *services.RateLimiter, apiKeys APIKeyValidator, sessions AccessTokenParser) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		decision := limiter.Allow(c.Request.URL.Path, rateLimitClientKey(c, apiKeys, sessions))
+		if decision.Limit == 0 {
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+
+		if !decision.Allowed {
+			retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, models.APIResponse{
+				Success: false,
+				Error:   "Rate limit exceeded, retry after " + strconv.Itoa(retryAfter) + " seconds",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitClientKey names the bucket of the request: the validated API key or user, or the IP
+func rateLimitClientKey(c *gin.Context, apiKeys APIKeyValidator, sessions AccessTokenParser) string {
+	if credential, err := Credential(c.Request); err == nil && (apiKeys != nil || sessions != nil) {
+		identify(c, credential, apiKeys, sessions)
+	}
+	if key, ok := APIKeyFromContext(c); ok {
+		return "key:" + strconv.Itoa(key.ID)
+	}
+	if session, ok := SessionFromContext(c); ok {
+		return "user:" + strconv.Itoa(session.UserID)
+	}
+	return "ip:" + c.ClientIP()
+}
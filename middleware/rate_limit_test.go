@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testAPIKeys accepts only "valid-key"
+type testAPIKeys struct{ lookups int }
+
+func (k *testAPIKeys) ValidateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	k.lookups++
+	if rawKey != "valid-key" {
+		return nil, errors.New("unknown key")
+	}
+	return &models.APIKey{ID: 7, Name: "reporting", Scopes: []string{models.ScopeRead}}, nil
+}
+
+func TestRateLimitClientKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	apiKeys := &testAPIKeys{}
+	limiter := services.NewRateLimiter(config.RateLimitConfig{Enabled: true, RequestsPerMinute: 60, Burst: 1}, nil)
+	router := gin.New()
+	router.Use(RateLimit(limiter, apiKeys, nil))
+	router.GET("/v1/data", Authenticate(apiKeys, nil), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/data", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Made-up keys share the bucket of the IP
+	if code := request("made-up-1"); code != http.StatusUnauthorized {
+		t.Fatalf("first made-up key answered %d, want 401", code)
+	}
+	for i := 2; i <= 3; i++ {
+		if code := request("made-up-" + strconv.Itoa(i)); code != http.StatusTooManyRequests {
+			t.Errorf("made-up key %d answered %d, want 429", i, code)
+		}
+	}
+	if code := request(""); code != http.StatusTooManyRequests {
+		t.Errorf("request without a key answered %d, want 429", code)
+	}
+
+	// A valid key has its own bucket and is looked up once per request
+	before := apiKeys.lookups
+	if code := request("valid-key"); code != http.StatusOK {
+		t.Errorf("valid key answered %d, want 200", code)
+	}
+	if lookups := apiKeys.lookups - before; lookups != 1 {
+		t.Errorf("valid key was looked up %d times, want 1", lookups)
+	}
+}
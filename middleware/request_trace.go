@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDContextKey is the gin context key holding the request id
+const RequestIDContextKey = "request_id"
+
+// RequestIDHeader carries the request id in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength keeps client-supplied ids short enough for PostgreSQL's application_name
+const maxRequestIDLength = 36
+
+// RequestTrace assigns every request an id and attaches it, with the route, to the request context
+// so database queries are tagged with it. The id is taken from X-Request-ID, else it is the trace id
+// of the request's span or W3C traceparent header, so logs and traces can be matched. It is echoed
+// back in the X-Request-ID response header.
+func RequestTrace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := sanitizeRequestID(c.GetHeader(RequestIDHeader))
+		if spanContext := trace.SpanContextFromContext(c.Request.Context()); requestID == "" && spanContext.HasTraceID() {
+			requestID = spanContext.TraceID().String()
+		}
+		if requestID == "" {
+			requestID = traceIDFromTraceparent(c.GetHeader("traceparent"))
+		}
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		c.Set(RequestIDContextKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(services.WithQueryTag(c.Request.Context(), services.QueryTag{
+			RequestID: requestID,
+			Route:     route,
+		}))
+
+		c.Next()
+	}
+}
+
+// traceIDFromTraceparent extracts the trace id from a "version-traceid-parentid-flags" header
+func traceIDFromTraceparent(header string) string {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || strings.Trim(parts[1], "0") == "" {
+		return ""
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return ""
+	}
+	return strings.ToLower(parts[1])
+}
+
+// sanitizeRequestID keeps only characters that are safe in logs, headers and database settings
+func sanitizeRequestID(id string) string {
+	var b strings.Builder
+	for _, r := range id {
+		if b.Len() >= maxRequestIDLength {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "req-unknown"
+	}
+	return "req-" + hex.EncodeToString(buf)
+}
@@ -1,18 +1,133 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Table represents a database table
 type Table struct {
 	Name string `json:"name" db:"name"`
 }
 
+// TableInfo is a table in a schema listing
+type TableInfo struct {
+	Name        string `json:"name"`
+	Schema      string `json:"schema"` // PostgreSQL schema or ClickHouse database
+	Kind        string `json:"kind"`   // PostgreSQL: table, view, materialized view...; ClickHouse: the engine
+	RowEstimate int64  `json:"row_estimate"`
+	SizeBytes   int64  `json:"size_bytes"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+// TableSchema describes the columns and indexes of one table
+type TableSchema struct {
+	TableInfo
+	Columns    []ColumnInfo `json:"columns"`
+	Indexes    []IndexInfo  `json:"indexes"`
+	PrimaryKey []string     `json:"primary_key,omitempty"`
+	SortingKey string       `json:"sorting_key,omitempty"` // ClickHouse ORDER BY expression
+}
+
+// ColumnInfo is a column of a TableSchema
+type ColumnInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"` // as the database spells it, e.g. numeric(18,2) or Nullable(String)
+	Nullable bool   `json:"nullable"`
+	Default  string `json:"default,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// IndexInfo is a PostgreSQL index or a ClickHouse data skipping index
+type IndexInfo struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`              // btree, gin...; minmax, bloom_filter...
+	Columns    []string `json:"columns,omitempty"` // PostgreSQL key columns or expressions
+	Expression string   `json:"expression"`        // PostgreSQL CREATE INDEX statement, ClickHouse index expression
+	Unique     bool     `json:"unique"`
+	Primary    bool     `json:"primary"`
+}
+
+// Health status values
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusDegraded  = "degraded"
+	HealthStatusUnhealthy = "unhealthy"
+
+	DependencyStatusUp   = "up"
+	DependencyStatusSlow = "slow"
+	DependencyStatusDown = "down"
+)
+
 // HealthResponse represents the health check response
 type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Version   string    `json:"version,omitempty"`
-	Database  string    `json:"database"`
+	Status       string                `json:"status"` // healthy, degraded or unhealthy
+	Timestamp    time.Time             `json:"timestamp"`
+	Version      string                `json:"version,omitempty"`
+	Database     string                `json:"database"`
+	Dependencies []DependencyHealth    `json:"dependencies,omitempty"`
+	RateLimits   *RateLimitInfo        `json:"rate_limits,omitempty"`
+	ThaiAdmin    *ThaiAdminDatasetInfo `json:"thai_admin,omitempty"`
+}
+
+// LivenessResponse is the answer of /v1/health/live
+type LivenessResponse struct {
+	Status        string    `json:"status"` // always "alive"
+	Timestamp     time.Time `json:"timestamp"`
+	UptimeSeconds float64   `json:"uptime_seconds"`
+	Goroutines    int       `json:"goroutines"`
+}
+
+// ReadinessResponse is the answer of /v1/health/ready
+type ReadinessResponse struct {
+	Status       string             `json:"status"` // healthy, degraded or unhealthy
+	Ready        bool               `json:"ready"`  // false only when a critical dependency is down
+	Timestamp    time.Time          `json:"timestamp"`
+	Dependencies []DependencyHealth `json:"dependencies"`
+}
+
+// RateLimitInfo reports the active rate limits
+type RateLimitInfo struct {
+	Enabled        bool                `json:"enabled"`
+	Backend        string              `json:"backend"` // memory, or redis when buckets are shared by every instance
+	Rules          []RateLimitRuleInfo `json:"rules,omitempty"`
+	TrackedClients int                 `json:"tracked_clients"` // buckets kept in this instance's memory
+}
+
+// RateLimitRuleInfo describes the limit applied to a route prefix ("*" is the default rule)
+type RateLimitRuleInfo struct {
+	Route             string `json:"route"`
+	RequestsPerMinute int    `json:"requests_per_minute"` // 0 means unlimited
+	Burst             int    `json:"burst"`
+}
+
+// DependencyHealth represents the health of a single dependency
+type DependencyHealth struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"` // up, slow or down
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	BudgetMs  int     `json:"budget_ms,omitempty"`
+	Details   string  `json:"details,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// DBPoolStats is the configuration and live state of a database connection pool
+type DBPoolStats struct {
+	Database                string  `json:"database"`
+	MaxOpenConns            int     `json:"max_open_conns"`
+	MaxIdleConns            int     `json:"max_idle_conns"`
+	ConnMaxLifetimeSeconds  int     `json:"conn_max_lifetime_seconds"`
+	ConnMaxIdleTimeSeconds  int     `json:"conn_max_idle_time_seconds"`
+	StatementTimeoutSeconds int     `json:"statement_timeout_seconds"` // 0 = no limit
+	OpenConnections         int     `json:"open_connections"`
+	InUse                   int     `json:"in_use"`
+	Idle                    int     `json:"idle"`
+	WaitCount               int64   `json:"wait_count"`       // queries that waited for a free connection
+	WaitDurationMs          float64 `json:"wait_duration_ms"` // total time spent waiting
+	MaxIdleClosed           int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed       int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed       int64   `json:"max_lifetime_closed"`
 }
 
 // APIResponse represents a generic API response
@@ -21,14 +136,50 @@ type APIResponse struct {
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	// Services that were unavailable, so the response may come from a fallback path
+	DegradedServices []string `json:"degraded_services,omitempty"`
 }
 
 // SearchParameters represents all search parameters in JSON format
 type SearchParameters struct {
-	Query  string `json:"query" binding:"required"` // actual search text (not base64)
-	Limit  int    `json:"limit,omitempty"`          // number of results
-	Offset int    `json:"offset,omitempty"`         // pagination offset
-	AI     int    `json:"ai,omitempty"`             // AI mode: 0=no AI, 1=use AI to enhance query
+	Query  string `json:"query" form:"query" binding:"required"` // actual search text (not base64)
+	Limit  int    `json:"limit,omitempty" form:"limit"`          // number of results
+	Offset int    `json:"offset,omitempty" form:"offset"`        // pagination offset
+	AI     int    `json:"ai,omitempty" form:"ai"`                // 1 = same as expand_synonyms (kept for older clients)
+
+	ExpandSynonyms bool `json:"expand_synonyms,omitempty" form:"expand_synonyms"` // add Thai/English synonyms to the vector lookup
+	AIEnhance      bool `json:"ai_enhance,omitempty" form:"ai_enhance"`           // let the configured language model add terms to the vector lookup
+
+	GroupBy           string `json:"group_by,omitempty" form:"group_by"`                       // collapse variants: "code_prefix" or "name"
+	GroupPrefixLength int    `json:"group_prefix_length,omitempty" form:"group_prefix_length"` // fixed code prefix length for group_by=code_prefix
+
+	Cursor string `json:"cursor,omitempty" form:"cursor"` // next_cursor from the previous page; replaces offset
+
+	// Ranking knobs; weights default to 1 and boosts to 0, which is the standard ranking
+	WeightExactCode *float64 `json:"weight_exact_code,omitempty" form:"weight_exact_code"` // exact code matches
+	WeightBarcode   *float64 `json:"weight_barcode,omitempty" form:"weight_barcode"`       // exact barcode matches
+	WeightVector    *float64 `json:"weight_vector,omitempty" form:"weight_vector"`         // Weaviate relevance
+	BoostInStock    float64  `json:"boost_in_stock,omitempty" form:"boost_in_stock"`       // added when stock is above zero
+	BoostHasImage   float64  `json:"boost_has_image,omitempty" form:"boost_has_image"`     // added when the product has an image
+
+	Fields []string `json:"fields,omitempty" form:"fields"` // result fields to return, e.g. ["code", "name", "final_price"]; all when empty
+	Facets []string `json:"facets,omitempty" form:"facets"` // item_type, unit_standard_code, supplier, stock_status or all
+
+	// Filters, applied in SQL so pages and counts only cover matching products
+	MinPrice    *float64 `json:"min_price,omitempty" form:"min_price"`         // final_price at least this
+	MaxPrice    *float64 `json:"max_price,omitempty" form:"max_price"`         // final_price at most this
+	InStockOnly bool     `json:"in_stock_only,omitempty" form:"in_stock_only"` // only products with qty_available above zero
+	ItemType    *int     `json:"item_type,omitempty" form:"item_type"`         // ic_inventory.item_type
+}
+
+// HybridSearchRequest is the body of /v1/search/hybrid
+type HybridSearchRequest struct {
+	Query      string             `json:"query" binding:"required"`
+	Limit      int                `json:"limit,omitempty"`
+	Sources    []string           `json:"sources,omitempty"`    // "bm25", "tfidf", "sql"; all when empty
+	Weights    map[string]float64 `json:"weights,omitempty"`    // per-source weight, 1 when not given
+	K          int                `json:"k,omitempty"`          // RRF rank constant, 60 when not given
+	Candidates int                `json:"candidates,omitempty"` // results taken from each source, max(limit*2, 50) when not given
 }
 
 // SearchRequest represents a vector search request (for backward compatibility)
@@ -40,7 +191,9 @@ type SearchRequest struct {
 
 // CommandRequest represents a command request for executing SQL commands
 type CommandRequest struct {
-	Query string `json:"query" binding:"required"` // SQL command to execute
+	Query       string `json:"query,omitempty"`        // SQL command to execute
+	QueryBase64 string `json:"query_base64,omitempty"` // the command base64 encoded, instead of query
+	TimeoutMs   int    `json:"timeout_ms,omitempty"`   // milliseconds the command may run; 0 for sql_limits.default_timeout_ms
 }
 
 // CommandResponse represents the response from command execution
@@ -53,20 +206,373 @@ type CommandResponse struct {
 	Error    string      `json:"error,omitempty"`
 }
 
+// TransactionRequest is an ordered list of PostgreSQL statements run atomically by /v1/pgtransaction
+type TransactionRequest struct {
+	Statements []TransactionStatementRequest `json:"statements" binding:"required,min=1,dive"`
+}
+
+// TransactionStatementRequest is one statement of a transaction
+type TransactionStatementRequest struct {
+	Query  string        `json:"query" binding:"required"`
+	Params []interface{} `json:"params,omitempty"` // values bound to $1, $2, ... placeholders
+}
+
+// TransactionStatementResult reports one statement of a transaction
+type TransactionStatementResult struct {
+	Index        int           `json:"index"`
+	RowsAffected *int64        `json:"rows_affected,omitempty"` // rows changed, or returned by a query
+	Rows         []interface{} `json:"rows,omitempty"`          // result of SELECT and ... RETURNING statements
+	Duration     float64       `json:"duration_ms"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// TransactionResponse reports a /v1/pgtransaction request
+type TransactionResponse struct {
+	Success         bool                         `json:"success"`
+	Message         string                       `json:"message,omitempty"`
+	Committed       bool                         `json:"committed"`
+	Results         []TransactionStatementResult `json:"results,omitempty"`
+	FailedStatement *int                         `json:"failed_statement,omitempty"` // index of the statement that failed
+	Duration        float64                      `json:"duration_ms"`
+	Error           string                       `json:"error,omitempty"`
+}
+
 // SelectRequest represents a select query request
 type SelectRequest struct {
-	Query string `json:"query" binding:"required"` // SELECT query to execute
+	Query       string        `json:"query,omitempty"`        // SELECT query to execute
+	QueryBase64 string        `json:"query_base64,omitempty"` // the query base64 encoded, instead of query
+	Params      []interface{} `json:"params,omitempty"`       // values bound to "?" (ClickHouse) or $1, $2, ... (PostgreSQL) placeholders
+	CacheTTL    int           `json:"cache_ttl,omitempty"`    // seconds the result may be served from the cache; 0 always runs the query
+	TimeoutMs   int           `json:"timeout_ms,omitempty"`   // milliseconds the query may run; 0 for sql_limits.default_timeout_ms
+	Explain     bool          `json:"explain,omitempty"`      // return the plan instead of running the query
+	Analyze     bool          `json:"analyze,omitempty"`      // with explain on PostgreSQL: run the query and report actual times and rows
+	MaxRows     int           `json:"max_rows,omitempty"`     // rows per page, lowered to sql_limits.max_rows; 0 for that maximum
+	Cursor      string        `json:"cursor,omitempty"`       // next_cursor of the previous page of the same query and params
+}
+
+// ExportRequest starts a background export of a table or a SELECT query; set one of Table and Query
+type ExportRequest struct {
+	Database string        `json:"database,omitempty"` // postgresql (default) or clickhouse
+	Table    string        `json:"table,omitempty"`    // exports every row of the table, optionally schema-qualified
+	Query    string        `json:"query,omitempty"`
+	Params   []interface{} `json:"params,omitempty"`   // bound to the placeholders of Query
+	Format   string        `json:"format,omitempty"`   // csv (default), parquet, ndjson or xlsx
+	Filename string        `json:"filename,omitempty"` // download name; default export-<id>.<format>
+}
+
+// ExportJob is the state of a background export. DownloadURL is set once the file is ready.
+type ExportJob struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"` // queued, running, done, failed or cancelled
+	Database    string     `json:"database"`
+	Table       string     `json:"table,omitempty"`
+	Query       string     `json:"query"`
+	Format      string     `json:"format"`
+	Filename    string     `json:"filename"`
+	Rows        int        `json:"rows"` // rows written so far
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // when the job and its file are removed
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+// RunningQuery is a statement in progress on /v1/select, /v1/selectget, /v1/pgselect, /v1/command,
+// /v1/pgcommand or /v1/pgtransaction of this instance
+type RunningQuery struct {
+	ID         string    `json:"id"`
+	Database   string    `json:"database"` // clickhouse or postgresql
+	Route      string    `json:"route"`
+	Query      string    `json:"query"`
+	User       string    `json:"user,omitempty"` // username, or api-key:<name>
+	RequestID  string    `json:"request_id,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs float64   `json:"duration_ms"`          // running time so far
+	TimeoutMs  int64     `json:"timeout_ms,omitempty"` // when the statement is cancelled by itself
+	Cancelled  bool      `json:"cancelled,omitempty"`  // cancel requested; the statement is being stopped
+}
+
+// DataPage is a page of rows returned by GET /v1/data/{table}
+type DataPage struct {
+	Table  string                   `json:"table"`
+	Rows   []map[string]interface{} `json:"rows"`
+	Total  int                      `json:"total"` // rows matching the filters
+	Limit  int                      `json:"limit"`
+	Offset int                      `json:"offset"`
+}
+
+// AuditEntry is one write request in the audit log: who sent it, when and from where, the SQL it
+// ran and what came of it
+type AuditEntry struct {
+	ID           int64     `json:"id"`
+	Time         time.Time `json:"time"`
+	Actor        string    `json:"actor"` // session username, "api-key:<name>", or empty when auth is off
+	Method       string    `json:"method"`
+	Route        string    `json:"route"` // route pattern, e.g. /v1/data/:table/:id
+	Path         string    `json:"path"`
+	Status       int       `json:"status"`
+	SQL          string    `json:"sql,omitempty"`
+	RowsAffected *int64    `json:"rows_affected,omitempty"`
+	Detail       string    `json:"detail,omitempty"`
+	DurationMs   float64   `json:"duration_ms"`
+	ClientIP     string    `json:"client_ip"`
+	RequestID    string    `json:"request_id"`
+}
+
+// AuditPage is a page of entries returned by GET /v1/admin/audit, newest first
+type AuditPage struct {
+	Entries []AuditEntry `json:"entries"`
+	Total   int          `json:"total"` // entries matching the filters
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+}
+
+// UndoOperation is an UPDATE or DELETE sent to /v1/pgcommand whose previous rows were kept
+type UndoOperation struct {
+	ID           string            `json:"id"`
+	Time         time.Time         `json:"time"`
+	Actor        string            `json:"actor"`
+	Table        string            `json:"table"`     // schema.table
+	Statement    string            `json:"statement"` // UPDATE or DELETE
+	SQL          string            `json:"sql"`
+	Rows         int64             `json:"rows"` // rows kept
+	RestoredAt   *time.Time        `json:"restored_at,omitempty"`
+	RestoredBy   string            `json:"restored_by,omitempty"`
+	RestoredRows *int64            `json:"restored_rows,omitempty"`
+	Snapshot     []json.RawMessage `json:"snapshot,omitempty" swaggertype:"array,object"` // the rows as they were, on GET /v1/admin/undo/{id}
+}
+
+// UndoPage is a page of operations returned by GET /v1/admin/undo, newest first
+type UndoPage struct {
+	Operations []UndoOperation `json:"operations"`
+	Total      int             `json:"total"`
+	Limit      int             `json:"limit"`
+	Offset     int             `json:"offset"`
+}
+
+// GraphQLRequest is a query to /v1/graphql; GET requests pass the same fields as query parameters,
+// with variables as a JSON object
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GraphQLResponse is the standard GraphQL response: the requested data and the errors of the
+// fields that could not be resolved
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLError is an error of a GraphQL request, with the path of the field it belongs to
+type GraphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// WSRequest is a message from a /v1/ws client. Type "search", "select" or "jobs" starts a
+// subscription under ID; "cancel" stops the subscription with that ID.
+type WSRequest struct {
+	ID       string        `json:"id"`
+	Type     string        `json:"type"`
+	Query    string        `json:"query,omitempty"`    // search text or SELECT statement
+	Limit    int           `json:"limit,omitempty"`    // search: results in total (page_limits)
+	Database string        `json:"database,omitempty"` // select: postgresql (default) or clickhouse
+	Params   []interface{} `json:"params,omitempty"`   // select: query parameters
+}
+
+// WSMessage is a message pushed to a /v1/ws client: "rows" with a batch of results, "done" when
+// a search or select has sent everything, "progress" with the status of a background job, and
+// "error" when a subscription failed or a request was invalid
+type WSMessage struct {
+	ID       string      `json:"id,omitempty"`
+	Type     string      `json:"type"`
+	Job      string      `json:"job,omitempty"` // progress: weaviate_sync, table_sync, import, export:<id> or job:<name>
+	Data     interface{} `json:"data,omitempty"`
+	Total    int         `json:"total,omitempty"`     // search: matching products
+	RowCount int         `json:"row_count,omitempty"` // done: rows sent
+	Duration float64     `json:"duration_ms,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// Webhook events
+const (
+	WebhookProductUpdated  = "product.updated"  // a product row, its images or its prices changed
+	WebhookStockLow        = "stock.low"        // a balance fell to webhooks.low_stock_threshold or below
+	WebhookImportCompleted = "import.completed" // a product import was written
+	WebhookPing            = "ping"             // sent by POST /v1/webhooks/{id}/test only
+)
+
+// WebhookEvents lists the events a webhook can subscribe to
+var WebhookEvents = []string{WebhookProductUpdated, WebhookStockLow, WebhookImportCompleted}
+
+// Webhook is an external URL called with the events it subscribed to. Secret signs every call
+// and is only returned when the webhook is created or its secret replaced.
+type Webhook struct {
+	ID          int64     `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookRequest registers or changes a webhook. An empty secret is generated on create and
+// left unchanged on update; Active defaults to true on create.
+type WebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events" binding:"required"`
+	Description string   `json:"description,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+	Secret      string   `json:"secret,omitempty"`
+}
+
+// Webhook delivery states
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // every attempt failed
+)
+
+// WebhookDelivery is one event queued for one webhook, with the outcome of its attempts
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// WebhookPayload is the JSON body POSTed to a webhook. ID is the same for every retry of it.
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Types of InventoryEvent
+const (
+	InventoryEventBalance = "balance"
+	InventoryEventPrice   = "price"
+)
+
+// InventoryEvent is a stock balance or price change streamed by /v1/events. A balance event
+// carries the total ic_balance quantity of the product, a price event its price_0..price_4; a
+// product removed from the table has a balance of 0 or no prices.
+type InventoryEvent struct {
+	ID                 int64     `json:"id"`
+	Type               string    `json:"type"`
+	ICCode             string    `json:"ic_code"`
+	BalanceQty         *float64  `json:"balance_qty,omitempty"`
+	PreviousBalanceQty *float64  `json:"previous_balance_qty,omitempty"`
+	Prices             []float64 `json:"prices,omitempty"`
+	PreviousPrices     []float64 `json:"previous_prices,omitempty"`
+	ChangedAt          time.Time `json:"changed_at"`
 }
 
 // SelectResponse represents the response from select query
 type SelectResponse struct {
-	Success  bool          `json:"success"`
-	Message  string        `json:"message,omitempty"`
-	Data     []interface{} `json:"data,omitempty"`
-	Query    string        `json:"query,omitempty"`
-	RowCount int           `json:"row_count"`
-	Duration float64       `json:"duration_ms"`
-	Error    string        `json:"error,omitempty"`
+	Success    bool          `json:"success"`
+	Message    string        `json:"message,omitempty"`
+	Data       []interface{} `json:"data,omitempty"`
+	Query      string        `json:"query,omitempty"`
+	RowCount   int           `json:"row_count"`
+	Duration   float64       `json:"duration_ms"`
+	Cached     bool          `json:"cached,omitempty"`      // served from the SELECT cache
+	Plan       []string      `json:"plan,omitempty"`        // EXPLAIN output, one line per element, when explain is set
+	Truncated  bool          `json:"truncated,omitempty"`   // rows stopped at max_rows or sql_limits.max_response_bytes
+	NextCursor string        `json:"next_cursor,omitempty"` // pass as "cursor" with the same query and params to fetch the next page
+	Error      string        `json:"error,omitempty"`
+}
+
+// SelectCacheStats reports the SELECT result cache
+type SelectCacheStats struct {
+	Enabled    bool                    `json:"enabled"`
+	Backend    string                  `json:"backend"` // memory, or redis when shared by every instance
+	Entries    int                     `json:"entries"`
+	MaxEntries int                     `json:"max_entries,omitempty"` // memory backend only
+	Hits       int64                   `json:"hits"`                  // answered by this instance
+	Misses     int64                   `json:"misses"`
+	Items      []SelectCacheEntryStats `json:"items"`
+	Error      string                  `json:"error,omitempty"` // Redis could not be read completely
+}
+
+// SelectCacheEntryStats describes one cached result
+type SelectCacheEntryStats struct {
+	Key       string    `json:"key"`
+	Database  string    `json:"database"`
+	Query     string    `json:"query"`
+	Tables    []string  `json:"tables"`
+	Rows      int       `json:"rows"`
+	Hits      int64     `json:"hits"` // memory backend only
+	CachedAt  time.Time `json:"cached_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SelectCacheInvalidation reports the entries removed from the SELECT cache
+type SelectCacheInvalidation struct {
+	Removed int `json:"removed"`
+}
+
+// Named query parameter types
+const (
+	NamedQueryString      = "string"
+	NamedQueryInt         = "int"
+	NamedQueryFloat       = "float"
+	NamedQueryBool        = "bool"
+	NamedQueryDate        = "date"      // "2006-01-02"
+	NamedQueryTimestamp   = "timestamp" // RFC 3339
+	NamedQueryStringArray = "string[]"
+	NamedQueryIntArray    = "int[]"
+	NamedQueryFloatArray  = "float[]"
+)
+
+// NamedQuery is a SQL template registered by an admin and run by name through /v1/query/{name}
+type NamedQuery struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Database    string            `json:"database"` // postgresql or clickhouse
+	Query       string            `json:"query"`    // one SELECT with $1, $2, ... (PostgreSQL) or ? (ClickHouse) placeholders
+	Params      []NamedQueryParam `json:"params"`   // in placeholder order
+	CreatedBy   string            `json:"created_by,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// NamedQueryParam is a typed argument of a named query
+type NamedQueryParam struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"` // string, int, float, bool, date, timestamp, string[], int[] or float[]
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"` // used when the argument is missing or null
+	Description string      `json:"description,omitempty"`
+}
+
+// NamedQueryRequest registers or replaces the named query in the path
+type NamedQueryRequest struct {
+	Description string            `json:"description"`
+	Database    string            `json:"database" binding:"required"`
+	Query       string            `json:"query" binding:"required"`
+	Params      []NamedQueryParam `json:"params"`
+}
+
+// RunNamedQueryRequest holds the arguments of a named query by parameter name
+type RunNamedQueryRequest struct {
+	Args map[string]interface{} `json:"args"`
 }
 
 // Thai Administrative Data Models
@@ -112,18 +618,24 @@ type ProvinceRequest struct {
 
 // AmphureRequest represents a request for amphure data
 type AmphureRequest struct {
-	ProvinceID int `json:"province_id" binding:"required"`
+	ProvinceID int `json:"province_id" form:"province_id" binding:"required"`
 }
 
 // TambonRequest represents a request for tambon data
 type TambonRequest struct {
-	AmphureID  int `json:"amphure_id" binding:"required"`
-	ProvinceID int `json:"province_id" binding:"required"`
+	AmphureID  int `json:"amphure_id" form:"amphure_id" binding:"required"`
+	ProvinceID int `json:"province_id" form:"province_id" binding:"required"`
 }
 
 // ZipCodeRequest represents a request to find location by zip code
 type ZipCodeRequest struct {
-	ZipCode int `json:"zip_code" binding:"required"`
+	ZipCode int `json:"zip_code" form:"zip_code" binding:"required"`
+}
+
+// ReverseGeocodeRequest is a WGS 84 coordinate, as given by GPS or a map
+type ReverseGeocodeRequest struct {
+	Lat float64 `json:"lat" form:"lat" binding:"required,min=-90,max=90"`
+	Lng float64 `json:"lng" form:"lng" binding:"required,min=-180,max=180"`
 }
 
 // CompleteLocationData represents complete location information with nested structure
@@ -168,3 +680,469 @@ type ProvinceNested struct {
 	UpdatedAt   string  `json:"updated_at"`
 	DeletedAt   *string `json:"deleted_at"`
 }
+
+// ThaiAdminDataset is the full province > amphure > tambon hierarchy served by /v1/thai-admin/export
+type ThaiAdminDataset struct {
+	Version   string          `json:"version"`    // changes whenever the data changes
+	UpdatedAt string          `json:"updated_at"` // latest updated_at of any record
+	Counts    ThaiAdminCounts `json:"counts"`
+	Provinces []ProvinceTree  `json:"provinces"`
+}
+
+// ThaiAdminDatasetInfo identifies the Thai administrative data being served
+type ThaiAdminDatasetInfo struct {
+	Version    string          `json:"version"`              // the version of /v1/thai-admin/export
+	UpdatedAt  string          `json:"updated_at,omitempty"` // latest updated_at of any record
+	Counts     ThaiAdminCounts `json:"counts"`
+	Source     string          `json:"source"`                // files, upload or upstream
+	ReplacedAt *time.Time      `json:"replaced_at,omitempty"` // when an upload or refresh last swapped the data in
+}
+
+// ThaiAdminCounts holds the number of records in a dataset
+type ThaiAdminCounts struct {
+	Provinces int `json:"provinces"`
+	Amphures  int `json:"amphures"`
+	Tambons   int `json:"tambons"`
+}
+
+// ProvinceTree is a province with its amphures
+type ProvinceTree struct {
+	ID          int           `json:"id"`
+	NameTh      string        `json:"name_th"`
+	NameEn      string        `json:"name_en"`
+	GeographyID int           `json:"geography_id,omitempty"`
+	Amphures    []AmphureTree `json:"amphures"`
+}
+
+// AmphureTree is an amphure with its tambons
+type AmphureTree struct {
+	ID      int          `json:"id"`
+	NameTh  string       `json:"name_th"`
+	NameEn  string       `json:"name_en"`
+	Tambons []TambonLeaf `json:"tambons"`
+}
+
+// TambonLeaf is a tambon inside an AmphureTree
+type TambonLeaf struct {
+	ID      int    `json:"id"`
+	NameTh  string `json:"name_th"`
+	NameEn  string `json:"name_en"`
+	ZipCode int    `json:"zip_code"`
+}
+
+// ThaiAdminUploadRequest carries replacement Thai administrative data. Levels that are omitted
+// keep their current records.
+type ThaiAdminUploadRequest struct {
+	Provinces []Province `json:"provinces,omitempty"`
+	Amphures  []Amphure  `json:"amphures,omitempty"`
+	Tambons   []Tambon   `json:"tambons,omitempty"`
+}
+
+// ThaiAdminUploadResult reports the validation and diff of an upload
+type ThaiAdminUploadResult struct {
+	Applied   bool          `json:"applied"` // false for dry runs and invalid data
+	Persisted bool          `json:"persisted"`
+	Errors    []string      `json:"errors,omitempty"`
+	Warnings  []string      `json:"warnings,omitempty"`   // problems the current data already had
+	Version   string        `json:"version,omitempty"`    // dataset version after the upload
+	UpToDate  bool          `json:"up_to_date,omitempty"` // a refresh found nothing to change
+	Diff      ThaiAdminDiff `json:"diff"`
+}
+
+// ThaiAdminDiff compares uploaded data with the data being served
+type ThaiAdminDiff struct {
+	Provinces RecordDiff `json:"provinces"`
+	Amphures  RecordDiff `json:"amphures"`
+	Tambons   RecordDiff `json:"tambons"`
+}
+
+// RecordDiff lists the ids added, removed and changed at one level
+type RecordDiff struct {
+	Before  int   `json:"before"`
+	After   int   `json:"after"`
+	Added   []int `json:"added"`
+	Removed []int `json:"removed"`
+	Changed []int `json:"changed"`
+}
+
+// ThaiAdminSearchRequest looks up provinces, amphures and tambons by a name that may be partial,
+// misspelled or in English
+type ThaiAdminSearchRequest struct {
+	Query      string   `json:"query" binding:"required"` // e.g. "บางรัก" or "Chiang Mai"
+	Levels     []string `json:"levels,omitempty"`         // province, amphure and/or tambon; default all
+	ProvinceID int      `json:"province_id,omitempty"`    // only areas in this province
+	Limit      int      `json:"limit,omitempty"`          // default 20, max 100
+}
+
+// ThaiAdminSearchResult is one area matching a search, with the areas it belongs to
+type ThaiAdminSearchResult struct {
+	Level    string    `json:"level"` // province, amphure or tambon
+	ID       int       `json:"id"`
+	NameTh   string    `json:"name_th"`
+	NameEn   string    `json:"name_en"`
+	ZipCode  int       `json:"zip_code,omitempty"` // tambons only
+	Score    float64   `json:"score"`              // 1 for an exact match, down to 0.6
+	Province *Province `json:"province,omitempty"` // of an amphure or tambon
+	Amphure  *Amphure  `json:"amphure,omitempty"`  // of a tambon
+}
+
+// AddressFormatRequest holds the parts of a delivery address. The location is taken from
+// tambon_id when given, otherwise it is looked up from the names and zip code.
+type AddressFormatRequest struct {
+	Name       string `json:"name,omitempty"`
+	Company    string `json:"company,omitempty"`
+	Phone      string `json:"phone,omitempty"`
+	HouseNo    string `json:"house_no,omitempty"`
+	Room       string `json:"room,omitempty"`
+	Floor      string `json:"floor,omitempty"`
+	Building   string `json:"building,omitempty"`
+	Village    string `json:"village,omitempty"`
+	Moo        string `json:"moo,omitempty"`
+	Soi        string `json:"soi,omitempty"`
+	Road       string `json:"road,omitempty"`
+	TambonID   int    `json:"tambon_id,omitempty"`
+	AmphureID  int    `json:"amphure_id,omitempty"`
+	ProvinceID int    `json:"province_id,omitempty"`
+	Tambon     string `json:"tambon,omitempty"`
+	Amphure    string `json:"amphure,omitempty"`
+	Province   string `json:"province,omitempty"`
+	ZipCode    int    `json:"zip_code,omitempty"`
+
+	// Latin spellings for the English block; without them the Thai text is printed as given
+	NameEn     string `json:"name_en,omitempty"`
+	CompanyEn  string `json:"company_en,omitempty"`
+	BuildingEn string `json:"building_en,omitempty"`
+	VillageEn  string `json:"village_en,omitempty"`
+	SoiEn      string `json:"soi_en,omitempty"`
+	RoadEn     string `json:"road_en,omitempty"`
+
+	Abbreviate bool `json:"abbreviate,omitempty"` // ต. อ. จ. ม. ซ. ถ. instead of the full words
+}
+
+// AddressFormatResult is a formatted address block in Thai and English
+type AddressFormatResult struct {
+	Lines    []string              `json:"lines"`
+	Text     string                `json:"text"`
+	LinesEn  []string              `json:"lines_en"`
+	TextEn   string                `json:"text_en"`
+	Verified bool                  `json:"verified"` // the location matched the Thai administrative data
+	Location *CompleteLocationData `json:"location,omitempty"`
+	Warnings []string              `json:"warnings,omitempty"`
+}
+
+// AddressParseRequest carries an address as typed, e.g. on an order form
+type AddressParseRequest struct {
+	Address string `json:"address" binding:"required"`
+}
+
+// AddressParseResult is an address split into its parts. Components can be sent to
+// /v1/thai-admin/format-address as they are.
+type AddressParseResult struct {
+	Components AddressFormatRequest  `json:"components"`
+	Location   *CompleteLocationData `json:"location,omitempty"` // the tambon found, when one clearly matches
+	Confidence float64               `json:"confidence"`         // 0 to 1: how well the location matches the text
+	Unparsed   string                `json:"unparsed,omitempty"` // text after the house number that no label explains
+	Warnings   []string              `json:"warnings,omitempty"`
+}
+
+// Product Event Models
+
+// ProductViewRequest represents a product view event posted by a frontend
+type ProductViewRequest struct {
+	ICCode   string `json:"ic_code" binding:"required"`
+	ClientID string `json:"client_id,omitempty"` // falls back to the X-Client-ID header
+	Source   string `json:"source,omitempty"`    // e.g. "web", "pos", "mobile"
+}
+
+// TrendingProduct represents aggregated view statistics for a product
+type TrendingProduct struct {
+	ICCode        string      `json:"ic_code"`
+	Views         int64       `json:"views"`
+	UniqueClients int64       `json:"unique_clients,omitempty"`
+	LastViewed    time.Time   `json:"last_viewed"`
+	Product       interface{} `json:"product,omitempty"`
+}
+
+// SearchQueryStats is how often a normalized query was searched, from search_events
+type SearchQueryStats struct {
+	Query         string    `json:"query"`
+	Searches      int64     `json:"searches"`
+	UniqueClients int64     `json:"unique_clients"`
+	ZeroResults   int64     `json:"zero_results"` // searches that found nothing
+	AvgResults    float64   `json:"avg_results"`
+	AvgLatencyMs  float64   `json:"avg_latency_ms"`
+	FirstSearched time.Time `json:"first_searched"`
+	LastSearched  time.Time `json:"last_searched"`
+}
+
+// SearchLatencyStats holds latency percentiles of one search method, or "all"
+type SearchLatencyStats struct {
+	Method   string  `json:"method"`
+	Searches int64   `json:"searches"`
+	AvgMs    float64 `json:"avg_ms"`
+	P50Ms    float64 `json:"p50_ms"`
+	P90Ms    float64 `json:"p90_ms"`
+	P95Ms    float64 `json:"p95_ms"`
+	P99Ms    float64 `json:"p99_ms"`
+	MaxMs    float64 `json:"max_ms"`
+}
+
+// ProductDetail is the merged record returned by /v1/products/:code
+type ProductDetail struct {
+	ICCode       string                 `json:"ic_code"`
+	MatchedBy    string                 `json:"matched_by"` // "code" or "barcode"
+	Inventory    map[string]interface{} `json:"inventory"`  // every ic_inventory column
+	Barcodes     []ProductBarcode       `json:"barcodes"`
+	Prices       []ProductPrice         `json:"prices"`
+	Balances     []WarehouseBalance     `json:"balances"`
+	TotalBalance float64                `json:"total_balance"`
+	Images       []string               `json:"images"`
+}
+
+// ProductBarcode is a row of ic_inventory_barcode
+type ProductBarcode struct {
+	Barcode  string `json:"barcode"`
+	UnitCode string `json:"unit_code,omitempty"`
+}
+
+// ProductPrice is a row of ic_inventory_price_formula; Tiers holds price_0..price_4 in order
+type ProductPrice struct {
+	UnitCode string    `json:"unit_code,omitempty"`
+	Tiers    []float64 `json:"tiers"`
+}
+
+// WarehouseBalance is the ic_balance quantity of one warehouse
+type WarehouseBalance struct {
+	WarehouseCode string  `json:"wh_code"`
+	Quantity      float64 `json:"balance_qty"`
+}
+
+// ProductImage is an image linked to a product in the product_images table, either by URL or
+// uploaded and served from /v1/product-images/{id}
+type ProductImage struct {
+	ID          int64     `json:"id"`
+	ICCode      string    `json:"ic_code"`
+	URL         string    `json:"url"`
+	Uploaded    bool      `json:"uploaded"`
+	ContentType string    `json:"content_type,omitempty"`
+	SizeBytes   int64     `json:"size_bytes,omitempty"`
+	SortOrder   int       `json:"sort_order"`     // lowest first; the first image is the img_url of search results
+	Hash        string    `json:"hash,omitempty"` // dHash of uploaded images, 16 hex digits
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ImageDuplicateGroup is a set of near-identical uploaded images linked to different products
+type ImageDuplicateGroup struct {
+	ICCodes  []string       `json:"ic_codes"`
+	Distance int            `json:"distance"` // largest Hamming distance between two images that joined the group
+	Images   []ProductImage `json:"images"`
+}
+
+// ImageDuplicatesResponse lists the duplicate groups among all hashed product images
+type ImageDuplicatesResponse struct {
+	MaxDistance int                   `json:"max_distance"`
+	Compared    int                   `json:"compared"` // hashed images
+	Groups      []ImageDuplicateGroup `json:"groups"`
+}
+
+// BarcodeScanRequest carries a photo of a barcode for /v1/barcode/scan
+type BarcodeScanRequest struct {
+	Image string `json:"image" binding:"required"` // base64 JPEG, PNG, GIF or WebP; a data: URL is accepted
+}
+
+// ProductImageRequest links an image URL to a product
+type ProductImageRequest struct {
+	URL       string `json:"url" binding:"required"`
+	SortOrder int    `json:"sort_order"`
+}
+
+// ProductImportResult summarizes a /v1/admin/import/products run
+type ProductImportResult struct {
+	DryRun           bool                 `json:"dry_run"` // everything was rolled back
+	Rows             int                  `json:"rows"`
+	Imported         int                  `json:"imported"`
+	Rejected         int                  `json:"rejected"`
+	ProductsInserted int                  `json:"products_inserted"`
+	ProductsUpdated  int                  `json:"products_updated"`
+	BarcodesUpserted int                  `json:"barcodes_upserted"`
+	PricesUpserted   int                  `json:"prices_upserted"`
+	Errors           []ProductImportError `json:"errors"`
+}
+
+// ProductImportError is a rejected row; Row is its line in the file, the header being line 1
+type ProductImportError struct {
+	Row   int    `json:"row"`
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error"`
+}
+
+// MaxProductBatchSize is the most codes accepted by /v1/products/batch
+const MaxProductBatchSize = 500
+
+// ProductBatchRequest lists ic_codes and/or barcodes to look up in one call
+type ProductBatchRequest struct {
+	Codes []string `json:"codes" binding:"required"`
+}
+
+// ProductBatchResponse holds the found products in request order and the codes that matched nothing
+type ProductBatchResponse struct {
+	Products []BatchProduct `json:"products"`
+	NotFound []string       `json:"not_found"`
+}
+
+// BatchProduct is a product record enriched with price and stock, as returned by /v1/products/batch
+type BatchProduct struct {
+	Query            string    `json:"query"` // the requested code or barcode
+	ICCode           string    `json:"ic_code"`
+	MatchedBy        string    `json:"matched_by"` // "code" or "barcode"
+	Name             string    `json:"name"`
+	UnitStandardCode string    `json:"unit_standard_code"`
+	Barcodes         []string  `json:"barcodes"`
+	SalePrice        float64   `json:"sale_price"` // price_0
+	Prices           []float64 `json:"prices"`     // price_0..price_4
+	QtyAvailable     float64   `json:"qty_available"`
+}
+
+// API Key Authentication Models
+
+// API key scopes. "command" implies "read"; "admin" implies every scope.
+const (
+	ScopeRead    = "read"
+	ScopeCommand = "command"
+	ScopeAdmin   = "admin"
+)
+
+// APIKey represents an API key record (the plaintext key is never stored)
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	Scopes     []string   `json:"scopes"`
+	Active     bool       `json:"active"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// HasScope reports whether the key grants the given scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin || (s == ScopeCommand && scope == ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKeyRequest represents a request to issue a new API key
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+// CreateAPIKeyResponse returns the newly issued key; the plaintext key is only shown once
+type CreateAPIKeyResponse struct {
+	Key    string `json:"key"`
+	APIKey APIKey `json:"api_key"`
+}
+
+// User Session Models
+
+// User roles, from most to least privileged. Each role includes the rights of the roles below it.
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleViewer   = "viewer"
+)
+
+var roleRank = map[string]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// IsValidRole reports whether role is one of the known user roles
+func IsValidRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// RoleAtLeast reports whether role grants at least the rights of minRole
+func RoleAtLeast(role, minRole string) bool {
+	rank, ok := roleRank[role]
+	return ok && rank >= roleRank[minRole]
+}
+
+// User represents an API user account (the password hash is never serialized)
+type User struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SessionClaims is the identity carried by a validated access token
+type SessionClaims struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// LoginRequest represents a username/password login
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest exchanges a refresh token for a new token pair
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenResponse is returned by login and refresh
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // access token lifetime in seconds
+	User         User   `json:"user"`
+}
+
+// CreateUserRequest represents a request to create an API user
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// Result Sharing Models
+
+// ShareRequest stores a search result, or the query and parameters that produced it, behind a
+// short-lived link. At least one of Params and Result is required.
+type ShareRequest struct {
+	Endpoint   string      `json:"endpoint,omitempty"`    // API path the result came from, e.g. "/v1/search-by-vector"
+	Params     interface{} `json:"params,omitempty"`      // request parameters, so the search can be run again
+	Result     interface{} `json:"result,omitempty"`      // response data to show as-is
+	Title      string      `json:"title,omitempty"`       // e.g. "Filters for Hino 500"
+	TTLMinutes int         `json:"ttl_minutes,omitempty"` // defaults to search.share.default_ttl_minutes
+}
+
+// ShareLink is returned when a result is shared
+type ShareLink struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SharedResult is the read-only content behind a share link
+type SharedResult struct {
+	Token     string      `json:"token"`
+	Endpoint  string      `json:"endpoint,omitempty"`
+	Params    interface{} `json:"params,omitempty"`
+	Result    interface{} `json:"result,omitempty"`
+	Title     string      `json:"title,omitempty"`
+	CreatedBy string      `json:"created_by,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
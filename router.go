@@ -1,15 +1,22 @@
 package main
 
 import (
+	"log"
+	"net/http"
+	"smlgoapi/apispec"
+	"smlgoapi/config"
+	"smlgoapi/docs"
 	"smlgoapi/handlers"
-	"time"
+	"smlgoapi/middleware"
+	"smlgoapi/models"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 // setupRouter configures and returns the main Gin router with all endpoints
-func setupRouter(apiHandler *handlers.APIHandler) *gin.Engine {
+func setupRouter(cfg *config.Config, apiHandler *handlers.APIHandler) *gin.Engine {
 	// Set Gin mode
 	gin.SetMode(gin.ReleaseMode)
 
@@ -17,45 +24,264 @@ func setupRouter(apiHandler *handlers.APIHandler) *gin.Engine {
 
 	// Middleware
 	router.Use(gin.Logger())
-	router.Use(gin.Recovery()) // CORS middleware
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"}, // In production, specify your frontend domain
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "HEAD"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
-
-	// API documentation endpoint (root)
-	router.GET("/", RootHandler)
+	router.Use(gin.Recovery())
+	router.Use(middleware.Tracing())      // OpenTelemetry server span, a no-op unless tracing is enabled
+	router.Use(middleware.RequestTrace()) // tags database queries with the request id
+	router.Use(middleware.DegradedServices(apiHandler.ServiceRegistry()))
+	// CORS comes before the limits so browsers can read a 413 or 429 instead of a CORS error
+	router.Use(middleware.CORS(cfg.CORS))
+	router.Use(middleware.Limits(cfg.Limits)) // body size and handler deadline per route
+	if !cfg.Metrics.Disabled {
+		router.Use(middleware.Metrics(apiHandler.Metrics()))
+	}
+	if cfg.RateLimit.Enabled {
+		// Only validated credentials get their own bucket; API keys are looked up when auth is on
+		var apiKeys middleware.APIKeyValidator
+		if cfg.Auth.Enabled {
+			apiKeys = apiHandler.APIKeyService()
+		}
+		router.Use(middleware.RateLimit(apiHandler.RateLimiter(), apiKeys, sessionParser(apiHandler)))
+	}
+
+	// Filled from router.Routes() once every route is registered
+	registry := &apispec.Registry{}
+	openAPI := &apispec.OpenAPI{}
+
+	// API documentation endpoint (root); it and /v1/help and /v1/guide list the registry, so they
+	// cannot mention routes that are not served
+	router.GET("/", RootHandler(registry))
+
+	// OpenAPI 3 document of the annotated handlers, browsable in Swagger UI at /swagger/index.html
+	router.GET("/openapi.json", OpenAPIHandler(openAPI))
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/openapi.json")))
+
+	// Prometheus scrapes the conventional path outside /v1, without credentials
+	if !cfg.Metrics.Disabled {
+		router.GET(cfg.Metrics.Path, apiHandler.GetMetrics)
+	}
 
 	// All API endpoints under /v1
 	v1 := router.Group("/v1")
 	{
 		// Health check endpoint
 		v1.GET("/health", apiHandler.HealthCheck)
+		v1.GET("/health/live", apiHandler.HealthLive)
+		v1.GET("/health/ready", apiHandler.HealthReady)
 
 		// API documentation endpoints
-		v1.GET("/docs", DocsHandler)
-		v1.GET("/guide", apiHandler.GuideEndpoint)
+		v1.GET("/docs", HelpHandler(registry)) // earlier name of /v1/help
+		v1.GET("/help", HelpHandler(registry))
+		v1.GET("/guide", GuideHandler(registry))
+		v1.GET("/client/dart", DartClientHandler(registry))
+
+		// Session endpoints
+		v1.POST("/auth/login", apiHandler.Login)
+		v1.POST("/auth/refresh", apiHandler.RefreshToken)
+
+		// Share links are opened without credentials; the token is the access right
+		v1.GET("/share/:token", apiHandler.GetShare)
+
+		// Uploaded product images are public so their URLs work in img tags
+		v1.GET("/product-images/:id", apiHandler.GetProductImageFile)
+
+		// Viewer endpoints: open unless JWT sessions are enabled
+		viewer := v1.Group("", authMiddleware(cfg.JWT.Enabled, apiHandler, models.ScopeRead, models.RoleViewer)...)
+		{
+			// Search endpoints
+			viewer.POST("/search-by-vector", apiHandler.SearchProductsByVector)
+			viewer.GET("/search-by-vector", apiHandler.SearchProductsByVector)
+			// The README has always documented POST /v1/search, but only /v1/search-by-vector was
+			// served, so clients written against it got 404. The alias serves them, deprecated, until they move.
+			viewer.POST("/search", apiHandler.SearchProductsLegacy)
+			viewer.POST("/search/hybrid", apiHandler.HybridSearch)
+			viewer.POST("/share", apiHandler.CreateShare)
+			viewer.POST("/query/:name", apiHandler.RunNamedQuery)
+
+			// Product event and homepage module endpoints
+			viewer.POST("/events/view", apiHandler.RecordProductView)
+			viewer.GET("/events", apiHandler.InventoryEvents)
+			viewer.GET("/products/trending", apiHandler.GetTrendingProducts)
+			viewer.GET("/products/recently-viewed", apiHandler.GetRecentlyViewedProducts)
+			viewer.POST("/products/batch", apiHandler.GetProductsBatch)
+			viewer.GET("/products/:code", apiHandler.GetProductDetail)
+			viewer.GET("/products/:code/images", apiHandler.ListProductImages)
+			viewer.POST("/barcode/scan", apiHandler.ScanBarcode)
+
+			// GraphQL over products and the Thai administrative data
+			viewer.POST("/graphql", apiHandler.GraphQL)
+			viewer.GET("/graphql", apiHandler.GraphQL)
+
+			// Streamed search and SELECT results and job progress over a WebSocket
+			viewer.GET("/ws", apiHandler.WebSocket)
+
+			// Thai Administrative Data endpoints
+			viewer.POST("/provinces", apiHandler.GetProvinces)
+			viewer.POST("/amphures", apiHandler.GetAmphures)
+			viewer.POST("/tambons", apiHandler.GetTambons)
+			viewer.POST("/findbyzipcode", apiHandler.FindByZipCode)
+
+			// GET variants with query parameters (linkable and CDN cacheable, answered
+			// with 304 while the dataset version is unchanged)
+			viewer.GET("/provinces", apiHandler.CacheThaiAdmin, apiHandler.GetProvinces)
+			viewer.GET("/amphures", apiHandler.CacheThaiAdmin, apiHandler.GetAmphures)
+			viewer.GET("/tambons", apiHandler.CacheThaiAdmin, apiHandler.GetTambons)
+			viewer.GET("/findbyzipcode", apiHandler.CacheThaiAdmin, apiHandler.FindByZipCode)
+
+			// Offline copy of the Thai administrative data
+			viewer.GET("/thai-admin/export", apiHandler.ExportThaiAdminData)
+			viewer.HEAD("/thai-admin/export", apiHandler.ExportThaiAdminData)
+
+			// Areas by name, for address forms that do not know the IDs
+			viewer.POST("/thai-admin/search", apiHandler.SearchThaiAdmin)
+
+			// Area at a GPS position, for delivery zones
+			viewer.GET("/thai-admin/reverse-geocode", apiHandler.ReverseGeocode)
+
+			// Shipping label address blocks
+			viewer.POST("/thai-admin/format-address", apiHandler.FormatAddress)
+			viewer.POST("/address/parse", apiHandler.ParseAddress)
+		}
+
+		// Database endpoints: API key scopes or user roles apply when any auth is enabled.
+		// Raw SQL is admin-only for user sessions. Write requests of the catalog, SQL command
+		// and admin groups are recorded in the audit log.
+		protected := cfg.Auth.Enabled || cfg.JWT.Enabled
+		operator := v1.Group("", authMiddleware(protected, apiHandler, models.ScopeRead, models.RoleOperator)...)
+		{
+			operator.GET("/tables", apiHandler.GetTables)
+			operator.GET("/schema/:db/tables", apiHandler.ListSchemaTables)
+			operator.GET("/schema/:db/tables/:name", apiHandler.GetSchemaTable)
+			operator.GET("/search/explain", apiHandler.ExplainSearch)
+			operator.GET("/reports/stock-aging", apiHandler.GetStockAging)
+			operator.GET("/images/duplicates", apiHandler.FindDuplicateImages)
+			operator.GET("/analytics/searches/top", apiHandler.GetTopSearches)
+			operator.GET("/analytics/zero-results", apiHandler.GetZeroResultSearches)
+			operator.GET("/analytics/searches/latency", apiHandler.GetSearchLatency)
+			operator.GET("/data/:table", apiHandler.ListDataRows)
+			operator.GET("/data/:table/:id", apiHandler.GetDataRow)
+		}
+		catalog := v1.Group("", append(authMiddleware(protected, apiHandler, models.ScopeCommand, models.RoleOperator), auditMiddleware(apiHandler)...)...)
+		{
+			catalog.POST("/products/:code/images", apiHandler.AddProductImage)
+			catalog.DELETE("/products/:code/images/:id", apiHandler.DeleteProductImage)
+			catalog.POST("/data/:table", apiHandler.CreateDataRow)
+			catalog.PUT("/data/:table/:id", apiHandler.UpdateDataRow)
+			catalog.DELETE("/data/:table/:id", apiHandler.DeleteDataRow)
+		}
+		sqlRead := v1.Group("", authMiddleware(protected, apiHandler, models.ScopeRead, models.RoleAdmin)...)
+		{
+			sqlRead.POST("/select", apiHandler.SelectEndpoint)
+			sqlRead.POST("/pgselect", apiHandler.PgSelectEndpoint)
+			sqlRead.GET("/selectget", apiHandler.SelectGetEndpoint)
+		}
+		sqlCommand := v1.Group("", append(authMiddleware(protected, apiHandler, models.ScopeCommand, models.RoleAdmin), auditMiddleware(apiHandler)...)...)
+		{
+			sqlCommand.POST("/command", apiHandler.CommandEndpoint)
+			sqlCommand.POST("/pgcommand", apiHandler.PgCommandEndpoint)
+			sqlCommand.POST("/pgtransaction", apiHandler.PgTransactionEndpoint)
+		}
 
-		// Search endpoints
-		v1.POST("/search-by-vector", apiHandler.SearchProductsByVector)
+		// Admin endpoints (always require an admin API key or admin session)
+		admin := v1.Group("/admin", append(authMiddleware(true, apiHandler, models.ScopeAdmin, models.RoleAdmin), auditMiddleware(apiHandler)...)...)
+		{
+			admin.GET("/api-keys", apiHandler.ListAPIKeys)
+			admin.POST("/api-keys", apiHandler.CreateAPIKey)
+			admin.DELETE("/api-keys/:id", apiHandler.RevokeAPIKey)
 
-		// Database endpoints
-		v1.GET("/tables", apiHandler.GetTables)
-		v1.POST("/command", apiHandler.CommandEndpoint)
-		v1.POST("/select", apiHandler.SelectEndpoint)
-		v1.POST("/pgcommand", apiHandler.PgCommandEndpoint)
-		v1.POST("/pgselect", apiHandler.PgSelectEndpoint)
+			admin.GET("/users", apiHandler.ListUsers)
+			admin.POST("/users", apiHandler.CreateUser)
 
-		// Thai Administrative Data endpoints
-		v1.POST("/provinces", apiHandler.GetProvinces)
-		v1.POST("/amphures", apiHandler.GetAmphures)
-		v1.POST("/tambons", apiHandler.GetTambons)
-		v1.POST("/findbyzipcode", apiHandler.FindByZipCode)
+			admin.GET("/queries", apiHandler.ListNamedQueries)
+			admin.PUT("/queries/:name", apiHandler.SaveNamedQuery)
+			admin.DELETE("/queries/:name", apiHandler.DeleteNamedQuery)
+			admin.GET("/running-queries", apiHandler.ListRunningQueries)
+			admin.DELETE("/running-queries/:id", apiHandler.CancelRunningQuery)
+
+			admin.GET("/sql-policy", apiHandler.GetSQLPolicy)
+			admin.POST("/sql-policy/reload", apiHandler.ReloadSQLPolicy)
+
+			admin.POST("/thai-admin/upload", apiHandler.UploadThaiAdminData)
+			admin.POST("/thai-admin/refresh", apiHandler.RefreshThaiAdminData)
+			admin.POST("/import/products", apiHandler.ImportProducts)
+
+			admin.POST("/export", apiHandler.StartExport)
+			admin.GET("/export", apiHandler.ListExports)
+			admin.GET("/export/:id", apiHandler.GetExport)
+			admin.GET("/export/:id/download", apiHandler.DownloadExport)
+			admin.DELETE("/export/:id", apiHandler.DeleteExport)
+
+			admin.POST("/sync-weaviate", apiHandler.StartWeaviateSync)
+			admin.GET("/sync-weaviate", apiHandler.GetWeaviateSyncStatus)
+			admin.POST("/sync", apiHandler.StartTableSync)
+			admin.GET("/sync", apiHandler.GetTableSyncStatus)
+			admin.GET("/index-freshness", apiHandler.GetIndexFreshness)
+			admin.GET("/db-stats", apiHandler.GetDBStats)
+			admin.GET("/cache", apiHandler.GetSelectCache)
+			admin.DELETE("/cache", apiHandler.InvalidateSelectCache)
+
+			admin.GET("/audit", apiHandler.GetAuditLog)
+
+			admin.GET("/undo", apiHandler.ListUndoOperations)
+			admin.GET("/undo/:id", apiHandler.GetUndoOperation)
+			admin.POST("/undo/:id", apiHandler.RestoreUndoOperation)
+
+			admin.GET("/jobs", apiHandler.ListJobs)
+			admin.GET("/jobs/:name", apiHandler.GetJob)
+			admin.POST("/jobs/:name/run", apiHandler.RunJob)
+		}
+
+		// Webhooks receive product, stock and import events, so only admins may register them
+		webhooks := v1.Group("/webhooks", append(authMiddleware(true, apiHandler, models.ScopeAdmin, models.RoleAdmin), auditMiddleware(apiHandler)...)...)
+		{
+			webhooks.GET("", apiHandler.ListWebhooks)
+			webhooks.POST("", apiHandler.CreateWebhook)
+			webhooks.GET("/:id", apiHandler.GetWebhook)
+			webhooks.PUT("/:id", apiHandler.UpdateWebhook)
+			webhooks.DELETE("/:id", apiHandler.DeleteWebhook)
+			webhooks.POST("/:id/test", apiHandler.TestWebhook)
+			webhooks.GET("/:id/deliveries", apiHandler.ListWebhookDeliveries)
+			webhooks.POST("/:id/deliveries/:delivery/retry", apiHandler.RetryWebhookDelivery)
+		}
 	}
 
+	specs := routeSpecs()
+	if !cfg.Metrics.Disabled {
+		specs = append(specs, apispec.Route{Method: http.MethodGet, Path: cfg.Metrics.Path, Summary: "Prometheus metrics", NoClient: true})
+	}
+	registry.Load(router.Routes(), specs)
+	if err := openAPI.Load(docs.SwaggerInfo.ReadDoc(), registry.Routes()); err != nil {
+		log.Printf("❌ [openapi] %v", err)
+	}
 	return router
 }
+
+// authMiddleware returns the authentication chain for a route group, or nothing when enforce is false.
+// API keys must carry scope; user sessions must hold at least minRole.
+func authMiddleware(enforce bool, apiHandler *handlers.APIHandler, scope, minRole string) []gin.HandlerFunc {
+	if !enforce {
+		return nil
+	}
+
+	return []gin.HandlerFunc{
+		middleware.Authenticate(apiHandler.APIKeyService(), sessionParser(apiHandler)),
+		middleware.Authorize(scope, minRole),
+	}
+}
+
+// sessionParser returns the access token parser, or nil when sessions are disabled. It avoids
+// handing a typed nil to the interface.
+func sessionParser(apiHandler *handlers.APIHandler) middleware.AccessTokenParser {
+	if sessionService := apiHandler.SessionService(); sessionService != nil {
+		return sessionService
+	}
+	return nil
+}
+
+// auditMiddleware records the write requests of a route group, or nothing when the audit log is off
+func auditMiddleware(apiHandler *handlers.APIHandler) []gin.HandlerFunc {
+	auditService := apiHandler.AuditService()
+	if auditService == nil {
+		return nil
+	}
+	return []gin.HandlerFunc{middleware.Audit(auditService)}
+}
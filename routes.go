@@ -0,0 +1,190 @@
+package main
+
+import (
+	"net/http"
+
+	"smlgoapi/apispec"
+	"smlgoapi/config"
+	"smlgoapi/jobs"
+	"smlgoapi/models"
+	"smlgoapi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeSpecs declares the models of each route for the registry. Routes added to setupRouter
+// without an entry here still appear in generated clients, untyped, and are logged at startup.
+func routeSpecs() []apispec.Route {
+	return []apispec.Route{
+		{Name: "overview", Method: http.MethodGet, Path: "/", Summary: "API overview"},
+		{Name: "openAPI", Method: http.MethodGet, Path: "/openapi.json", Summary: "OpenAPI 3 document", NoClient: true},
+		{Method: http.MethodGet, Path: "/swagger/*any", Summary: "Swagger UI", NoClient: true},
+		{Name: "health", Method: http.MethodGet, Path: "/v1/health", Summary: "API and dependency health", Body: models.HealthResponse{}},
+		{Name: "healthLive", Method: http.MethodGet, Path: "/v1/health/live", Summary: "Liveness probe", Body: models.LivenessResponse{}},
+		{Name: "healthReady", Method: http.MethodGet, Path: "/v1/health/ready", Summary: "Readiness probe with per-dependency status", Body: models.ReadinessResponse{}},
+		{Name: "docs", Method: http.MethodGet, Path: "/v1/docs", Summary: "Endpoint list (same as /v1/help)", Body: apispec.Guide{}},
+		{Name: "help", Method: http.MethodGet, Path: "/v1/help", Summary: "Endpoint list", Body: apispec.Guide{}},
+		{Name: "guide", Method: http.MethodGet, Path: "/v1/guide", Summary: "Endpoints with their request and response models", Body: apispec.Guide{}},
+		{Method: http.MethodGet, Path: "/v1/client/dart", Summary: "Generated Dart client", NoClient: true},
+
+		{Name: "login", Method: http.MethodPost, Path: "/v1/auth/login", Summary: "Sign in with a username and password", Request: models.LoginRequest{}, Data: models.TokenResponse{}},
+		{Name: "refreshToken", Method: http.MethodPost, Path: "/v1/auth/refresh", Summary: "Exchange a refresh token for new tokens", Request: models.RefreshRequest{}, Data: models.TokenResponse{}},
+
+		{Name: "searchByVector", Method: http.MethodPost, Path: "/v1/search-by-vector", Summary: "Product search", Request: models.SearchParameters{}, Data: services.VectorSearchResponse{}},
+		{Name: "searchByVectorQuery", Method: http.MethodGet, Path: "/v1/search-by-vector", Summary: "Product search with query parameters", Request: models.SearchParameters{}, Data: services.VectorSearchResponse{}},
+		{Name: "searchLegacy", Method: http.MethodPost, Path: "/v1/search", Summary: "Deprecated alias of POST /v1/search-by-vector", Request: models.SearchParameters{}, Data: services.VectorSearchResponse{}, NoClient: true},
+		{Name: "hybridSearch", Method: http.MethodPost, Path: "/v1/search/hybrid", Summary: "Search fusing BM25, TF-IDF and SQL rankings", Request: models.HybridSearchRequest{}, Data: services.HybridSearchResponse{}},
+		{Name: "shareResult", Method: http.MethodPost, Path: "/v1/share", Summary: "Share a search result behind a short-lived link", Request: models.ShareRequest{}, Data: models.ShareLink{}},
+		{Name: "openShare", Method: http.MethodGet, Path: "/v1/share/:token", Summary: "Read-only content of a share link", Data: models.SharedResult{}},
+		{Name: "runNamedQuery", Method: http.MethodPost, Path: "/v1/query/:name", Summary: "Run a named query registered by an admin",
+			Request: models.RunNamedQueryRequest{}, Query: []apispec.Param{{Name: "format", Type: "string"}}, Body: models.SelectResponse{}},
+		{Name: "explainSearch", Method: http.MethodGet, Path: "/v1/search/explain", Summary: "Why a product does or does not match a query",
+			Query: []apispec.Param{{Name: "query", Type: "string"}, {Name: "code", Type: "string"}, {Name: "limit", Type: "int"}, {Name: "depth", Type: "int"}},
+			Data:  services.SearchExplanation{}},
+
+		{Name: "recordProductView", Method: http.MethodPost, Path: "/v1/events/view", Summary: "Record a product view", Request: models.ProductViewRequest{}},
+		{Method: http.MethodGet, Path: "/v1/events", Summary: "Server-Sent Events stream of stock balance and price changes",
+			Query: []apispec.Param{{Name: "ic_code", Type: "string"}, {Name: "types", Type: "string"}}, NoClient: true},
+		{Name: "trendingProducts", Method: http.MethodGet, Path: "/v1/products/trending", Summary: "Most viewed products",
+			Query: []apispec.Param{{Name: "days", Type: "int"}, {Name: "limit", Type: "int"}}, Data: []models.TrendingProduct{}},
+		{Name: "recentlyViewedProducts", Method: http.MethodGet, Path: "/v1/products/recently-viewed", Summary: "Products recently viewed by a client",
+			Query: []apispec.Param{{Name: "client_id", Type: "string"}, {Name: "limit", Type: "int"}}, Data: []models.TrendingProduct{}},
+		{Name: "productsBatch", Method: http.MethodPost, Path: "/v1/products/batch", Summary: "Products by codes or barcodes", Request: models.ProductBatchRequest{}, Data: models.ProductBatchResponse{}},
+		{Name: "productDetail", Method: http.MethodGet, Path: "/v1/products/:code", Summary: "Product detail by ic_code or barcode", Data: models.ProductDetail{}},
+		{Name: "productImages", Method: http.MethodGet, Path: "/v1/products/:code/images", Summary: "Images linked to a product", Data: []models.ProductImage{}},
+		{Name: "addProductImage", Method: http.MethodPost, Path: "/v1/products/:code/images", Summary: "Link an image URL to a product (multipart uploads are not generated)",
+			Request: models.ProductImageRequest{}, Data: models.ProductImage{}},
+		{Name: "deleteProductImage", Method: http.MethodDelete, Path: "/v1/products/:code/images/:id", Summary: "Delete a product image"},
+		{Name: "duplicateImages", Method: http.MethodGet, Path: "/v1/images/duplicates", Summary: "Near-identical images of different products",
+			Query: []apispec.Param{{Name: "max_distance", Type: "int"}}, Data: models.ImageDuplicatesResponse{}},
+		{Name: "scanBarcode", Method: http.MethodPost, Path: "/v1/barcode/scan", Summary: "Identify a product from a barcode photo",
+			Request: models.BarcodeScanRequest{}, Data: services.BarcodeScanResult{}},
+		{Name: "graphQL", Method: http.MethodPost, Path: "/v1/graphql", Summary: "GraphQL query over products and Thai admin data", Request: models.GraphQLRequest{}, Body: models.GraphQLResponse{}},
+		{Name: "graphQLQuery", Method: http.MethodGet, Path: "/v1/graphql", Summary: "GraphQL query in query parameters, or the schema with sdl=1",
+			Query: []apispec.Param{{Name: "query", Type: "string"}, {Name: "operationName", Type: "string"}, {Name: "variables", Type: "string"}, {Name: "sdl", Type: "string"}}, Body: models.GraphQLResponse{}},
+		{Method: http.MethodGet, Path: "/v1/ws", Summary: "WebSocket for streamed search and SELECT results and job progress", NoClient: true},
+		{Name: "productImageFile", Method: http.MethodGet, Path: "/v1/product-images/:id", Summary: "Uploaded product image file", NoClient: true},
+
+		{Name: "stockAging", Method: http.MethodGet, Path: "/v1/reports/stock-aging", Summary: "Stock aging report (CSV)",
+			Query: []apispec.Param{{Name: "wh_code", Type: "string"}, {Name: "level", Type: "string"}, {Name: "format", Type: "string"}, {Name: "filename", Type: "string"}}, NoClient: true},
+
+		{Name: "topSearches", Method: http.MethodGet, Path: "/v1/analytics/searches/top", Summary: "Most searched queries",
+			Query: []apispec.Param{{Name: "days", Type: "int"}, {Name: "from", Type: "string"}, {Name: "to", Type: "string"}, {Name: "limit", Type: "int"}}, Data: []models.SearchQueryStats{}},
+		{Name: "zeroResultSearches", Method: http.MethodGet, Path: "/v1/analytics/zero-results", Summary: "Zero-result query report",
+			Query: []apispec.Param{{Name: "from", Type: "string"}, {Name: "to", Type: "string"}, {Name: "days", Type: "int"}, {Name: "limit", Type: "int"}}, Data: []models.SearchQueryStats{}},
+		{Name: "searchLatency", Method: http.MethodGet, Path: "/v1/analytics/searches/latency", Summary: "Search latency percentiles",
+			Query: []apispec.Param{{Name: "days", Type: "int"}, {Name: "from", Type: "string"}, {Name: "to", Type: "string"}}, Data: []models.SearchLatencyStats{}},
+
+		{Name: "listDataRows", Method: http.MethodGet, Path: "/v1/data/:table", Summary: "Rows of a data_api table (column filters are not generated)",
+			Query: []apispec.Param{{Name: "limit", Type: "int"}, {Name: "offset", Type: "int"}, {Name: "sort", Type: "string"}, {Name: "fields", Type: "string"}}, Data: models.DataPage{}},
+		{Name: "getDataRow", Method: http.MethodGet, Path: "/v1/data/:table/:id", Summary: "One row of a data_api table by primary key", Data: map[string]interface{}{}},
+		{Name: "createDataRow", Method: http.MethodPost, Path: "/v1/data/:table", Summary: "Insert a row", NoClient: true},
+		{Name: "updateDataRow", Method: http.MethodPut, Path: "/v1/data/:table/:id", Summary: "Update a row", NoClient: true},
+		{Name: "deleteDataRow", Method: http.MethodDelete, Path: "/v1/data/:table/:id", Summary: "Delete a row", Data: map[string]interface{}{}},
+
+		{Name: "provinces", Method: http.MethodPost, Path: "/v1/provinces", Summary: "All provinces", Data: []models.Province{}},
+		{Name: "amphures", Method: http.MethodPost, Path: "/v1/amphures", Summary: "Amphures of a province", Request: models.AmphureRequest{}, Data: []models.Amphure{}},
+		{Name: "tambons", Method: http.MethodPost, Path: "/v1/tambons", Summary: "Tambons of an amphure", Request: models.TambonRequest{}, Data: []models.Tambon{}},
+		{Name: "findByZipCode", Method: http.MethodPost, Path: "/v1/findbyzipcode", Summary: "Locations with a postal code", Request: models.ZipCodeRequest{}, Data: []models.CompleteLocationData{}},
+		{Name: "provincesQuery", Method: http.MethodGet, Path: "/v1/provinces", Summary: "All provinces (cacheable GET)", Data: []models.Province{}},
+		{Name: "amphuresQuery", Method: http.MethodGet, Path: "/v1/amphures", Summary: "Amphures of a province (cacheable GET)", Request: models.AmphureRequest{}, Data: []models.Amphure{}},
+		{Name: "tambonsQuery", Method: http.MethodGet, Path: "/v1/tambons", Summary: "Tambons of an amphure (cacheable GET)", Request: models.TambonRequest{}, Data: []models.Tambon{}},
+		{Name: "findByZipCodeQuery", Method: http.MethodGet, Path: "/v1/findbyzipcode", Summary: "Locations with a postal code (cacheable GET)", Request: models.ZipCodeRequest{}, Data: []models.CompleteLocationData{}},
+		{Name: "thaiAdminExport", Method: http.MethodGet, Path: "/v1/thai-admin/export", Summary: "Offline copy of the Thai administrative data", Body: models.ThaiAdminDataset{}},
+		{Method: http.MethodHead, Path: "/v1/thai-admin/export", NoClient: true},
+		{Name: "searchThaiAdmin", Method: http.MethodPost, Path: "/v1/thai-admin/search", Summary: "Provinces, amphures and tambons by name", Request: models.ThaiAdminSearchRequest{}, Data: []models.ThaiAdminSearchResult{}},
+		{Name: "reverseGeocode", Method: http.MethodGet, Path: "/v1/thai-admin/reverse-geocode", Summary: "Tambon, amphure and province at a coordinate", Request: models.ReverseGeocodeRequest{}, Data: models.CompleteLocationData{}},
+		{Name: "formatAddress", Method: http.MethodPost, Path: "/v1/thai-admin/format-address", Summary: "Shipping label address block", Request: models.AddressFormatRequest{}, Data: models.AddressFormatResult{}},
+		{Name: "parseAddress", Method: http.MethodPost, Path: "/v1/address/parse", Summary: "Parts and location of a typed address", Request: models.AddressParseRequest{}, Data: models.AddressParseResult{}},
+
+		{Name: "tables", Method: http.MethodGet, Path: "/v1/tables", Summary: "ClickHouse tables", Data: []models.Table{}},
+		{Name: "schemaTables", Method: http.MethodGet, Path: "/v1/schema/:db/tables", Summary: "Tables of a database with row estimates",
+			Query: []apispec.Param{{Name: "schema", Type: "string"}}, Data: []models.TableInfo{}},
+		{Name: "schemaTable", Method: http.MethodGet, Path: "/v1/schema/:db/tables/:name", Summary: "Columns and indexes of a table", Data: models.TableSchema{}},
+		{Name: "clickHouseSelect", Method: http.MethodPost, Path: "/v1/select", Summary: "ClickHouse SELECT", Request: models.SelectRequest{}, Body: models.SelectResponse{}},
+		{Name: "postgresSelect", Method: http.MethodPost, Path: "/v1/pgselect", Summary: "PostgreSQL SELECT", Request: models.SelectRequest{}, Body: models.SelectResponse{}},
+		{Name: "clickHouseSelectGet", Method: http.MethodGet, Path: "/v1/selectget", Summary: "ClickHouse SELECT sent base64 encoded in q", Query: []apispec.Param{{Name: "q", Type: "string"}, {Name: "cache_ttl", Type: "int"}, {Name: "timeout_ms", Type: "int"}, {Name: "max_rows", Type: "int"}, {Name: "cursor", Type: "string"}}, Body: models.SelectResponse{}},
+		{Name: "clickHouseCommand", Method: http.MethodPost, Path: "/v1/command", Summary: "ClickHouse command", Request: models.CommandRequest{}, Body: models.CommandResponse{}},
+		{Name: "postgresCommand", Method: http.MethodPost, Path: "/v1/pgcommand", Summary: "PostgreSQL command", Request: models.CommandRequest{}, Body: models.CommandResponse{}},
+		{Name: "postgresTransaction", Method: http.MethodPost, Path: "/v1/pgtransaction", Summary: "PostgreSQL statements in one transaction", Request: models.TransactionRequest{}, Body: models.TransactionResponse{}},
+
+		{Name: "listApiKeys", Method: http.MethodGet, Path: "/v1/admin/api-keys", Summary: "API keys", Data: []models.APIKey{}},
+		{Name: "createApiKey", Method: http.MethodPost, Path: "/v1/admin/api-keys", Summary: "Create an API key", Request: models.CreateAPIKeyRequest{}, Data: models.CreateAPIKeyResponse{}},
+		{Name: "revokeApiKey", Method: http.MethodDelete, Path: "/v1/admin/api-keys/:id", Summary: "Revoke an API key"},
+		{Name: "listUsers", Method: http.MethodGet, Path: "/v1/admin/users", Summary: "Users", Data: []models.User{}},
+		{Name: "createUser", Method: http.MethodPost, Path: "/v1/admin/users", Summary: "Create a user", Request: models.CreateUserRequest{}, Data: models.User{}},
+		{Name: "listNamedQueries", Method: http.MethodGet, Path: "/v1/admin/queries", Summary: "Named queries", Data: []models.NamedQuery{}},
+		{Name: "saveNamedQuery", Method: http.MethodPut, Path: "/v1/admin/queries/:name", Summary: "Register or replace a named query", Request: models.NamedQueryRequest{}, Data: models.NamedQuery{}},
+		{Name: "deleteNamedQuery", Method: http.MethodDelete, Path: "/v1/admin/queries/:name", Summary: "Delete a named query"},
+		{Name: "listRunningQueries", Method: http.MethodGet, Path: "/v1/admin/running-queries", Summary: "SQL statements in progress", Data: []models.RunningQuery{}},
+		{Name: "cancelRunningQuery", Method: http.MethodDelete, Path: "/v1/admin/running-queries/:id", Summary: "Cancel a SQL statement in progress", Data: models.RunningQuery{}},
+		{Name: "sqlPolicy", Method: http.MethodGet, Path: "/v1/admin/sql-policy", Summary: "SQL policy in effect", Data: config.SQLPolicyConfig{}},
+		{Name: "reloadSqlPolicy", Method: http.MethodPost, Path: "/v1/admin/sql-policy/reload", Summary: "Reload the SQL policy file", Data: config.SQLPolicyConfig{}},
+		{Name: "uploadThaiAdminData", Method: http.MethodPost, Path: "/v1/admin/thai-admin/upload", Summary: "Replace the Thai administrative data",
+			Request: models.ThaiAdminUploadRequest{}, Query: []apispec.Param{{Name: "dry_run", Type: "bool"}, {Name: "persist", Type: "bool"}}, Data: models.ThaiAdminUploadResult{}},
+		{Name: "refreshThaiAdminData", Method: http.MethodPost, Path: "/v1/admin/thai-admin/refresh", Summary: "Reload the Thai administrative data from upstream",
+			Query: []apispec.Param{{Name: "dry_run", Type: "bool"}}, Data: models.ThaiAdminUploadResult{}},
+		{Name: "importProducts", Method: http.MethodPost, Path: "/v1/admin/import/products", Summary: "Import products from a CSV or XLSX file",
+			Query: []apispec.Param{{Name: "dry_run", Type: "bool"}, {Name: "report", Type: "string"}, {Name: "filename", Type: "string"}}, Data: models.ProductImportResult{}, NoClient: true},
+		{Name: "startExport", Method: http.MethodPost, Path: "/v1/admin/export", Summary: "Start a background export", Request: models.ExportRequest{}, Data: models.ExportJob{}},
+		{Name: "listExports", Method: http.MethodGet, Path: "/v1/admin/export", Summary: "Background exports", Data: []models.ExportJob{}},
+		{Name: "getExport", Method: http.MethodGet, Path: "/v1/admin/export/:id", Summary: "Status of a background export", Data: models.ExportJob{}},
+		{Name: "downloadExport", Method: http.MethodGet, Path: "/v1/admin/export/:id/download", Summary: "Download a finished export", NoClient: true},
+		{Name: "deleteExport", Method: http.MethodDelete, Path: "/v1/admin/export/:id", Summary: "Cancel or remove a background export", Data: models.ExportJob{}},
+		{Name: "startWeaviateSync", Method: http.MethodPost, Path: "/v1/admin/sync-weaviate", Summary: "Start a Weaviate sync",
+			Query: []apispec.Param{{Name: "mode", Type: "string"}, {Name: "dry_run", Type: "bool"}, {Name: "batch_size", Type: "int"}}, Data: services.WeaviateSyncStatus{}},
+		{Name: "weaviateSyncStatus", Method: http.MethodGet, Path: "/v1/admin/sync-weaviate", Summary: "Progress of the Weaviate sync", Data: services.WeaviateSyncStatus{}},
+		{Name: "startTableSync", Method: http.MethodPost, Path: "/v1/admin/sync", Summary: "Copy a table between PostgreSQL and ClickHouse", Request: services.TableSyncOptions{}, Data: services.TableSyncStatus{}},
+		{Name: "tableSyncStatus", Method: http.MethodGet, Path: "/v1/admin/sync", Summary: "Progress of the table sync", Data: services.TableSyncStatus{}},
+		{Name: "indexFreshness", Method: http.MethodGet, Path: "/v1/admin/index-freshness", Summary: "Age of the search indexes",
+			Query: []apispec.Param{{Name: "fail_on_stale", Type: "bool"}}, Data: services.IndexFreshnessReport{}},
+		{Name: "dbStats", Method: http.MethodGet, Path: "/v1/admin/db-stats", Summary: "Database connection pool statistics", Data: []models.DBPoolStats{}},
+		{Name: "selectCache", Method: http.MethodGet, Path: "/v1/admin/cache", Summary: "SELECT result cache entries", Data: models.SelectCacheStats{}},
+		{Name: "invalidateSelectCache", Method: http.MethodDelete, Path: "/v1/admin/cache", Summary: "Remove SELECT cache entries",
+			Query: []apispec.Param{{Name: "key", Type: "string"}, {Name: "database", Type: "string"}, {Name: "table", Type: "string"}}, Data: models.SelectCacheInvalidation{}},
+		{Name: "auditLog", Method: http.MethodGet, Path: "/v1/admin/audit", Summary: "Audit log of write requests",
+			Query: []apispec.Param{{Name: "from", Type: "string"}, {Name: "to", Type: "string"}, {Name: "user", Type: "string"}, {Name: "route", Type: "string"},
+				{Name: "method", Type: "string"}, {Name: "limit", Type: "int"}, {Name: "offset", Type: "int"}}, Data: models.AuditPage{}},
+		{Name: "undoOperations", Method: http.MethodGet, Path: "/v1/admin/undo", Summary: "Undoable /pgcommand operations",
+			Query: []apispec.Param{{Name: "limit", Type: "int"}, {Name: "offset", Type: "int"}}, Data: models.UndoPage{}},
+		{Name: "undoOperation", Method: http.MethodGet, Path: "/v1/admin/undo/:id", Summary: "An undoable /pgcommand operation with its kept rows",
+			Query: []apispec.Param{{Name: "limit", Type: "int"}}, Data: models.UndoOperation{}},
+		{Name: "restoreUndoOperation", Method: http.MethodPost, Path: "/v1/admin/undo/:id", Summary: "Undo a /pgcommand operation", Data: models.UndoOperation{}},
+		{Name: "listJobs", Method: http.MethodGet, Path: "/v1/admin/jobs", Summary: "Background jobs", Data: []jobs.Status{}},
+		{Name: "getJob", Method: http.MethodGet, Path: "/v1/admin/jobs/:name", Summary: "Status of a background job", Data: jobs.Status{}},
+		{Name: "runJob", Method: http.MethodPost, Path: "/v1/admin/jobs/:name/run", Summary: "Run a background job now", Data: jobs.Status{}},
+
+		{Name: "listWebhooks", Method: http.MethodGet, Path: "/v1/webhooks", Summary: "Registered webhooks", Data: []models.Webhook{}},
+		{Name: "createWebhook", Method: http.MethodPost, Path: "/v1/webhooks", Summary: "Register a webhook", Request: models.WebhookRequest{}, Data: models.Webhook{}},
+		{Name: "getWebhook", Method: http.MethodGet, Path: "/v1/webhooks/:id", Summary: "A webhook", Data: models.Webhook{}},
+		{Name: "updateWebhook", Method: http.MethodPut, Path: "/v1/webhooks/:id", Summary: "Change a webhook", Request: models.WebhookRequest{}, Data: models.Webhook{}},
+		{Name: "deleteWebhook", Method: http.MethodDelete, Path: "/v1/webhooks/:id", Summary: "Delete a webhook and its deliveries"},
+		{Name: "testWebhook", Method: http.MethodPost, Path: "/v1/webhooks/:id/test", Summary: "Send a ping event to a webhook", Data: models.WebhookDelivery{}},
+		{Name: "webhookDeliveries", Method: http.MethodGet, Path: "/v1/webhooks/:id/deliveries", Summary: "Deliveries of a webhook",
+			Query: []apispec.Param{{Name: "status", Type: "string"}, {Name: "limit", Type: "int"}}, Data: []models.WebhookDelivery{}},
+		{Name: "retryWebhookDelivery", Method: http.MethodPost, Path: "/v1/webhooks/:id/deliveries/:delivery/retry", Summary: "Queue a webhook delivery again", Data: models.WebhookDelivery{}},
+	}
+}
+
+// OpenAPIHandler serves the OpenAPI 3 document of the served routes
+func OpenAPIHandler(document *apispec.OpenAPI) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body := document.JSON()
+		if body == nil {
+			c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+				Success: false,
+				Error:   "The OpenAPI document could not be built; see the server log",
+			})
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+}
+
+// DartClientHandler serves the Dart client generated from the route registry
+func DartClientHandler(registry *apispec.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Disposition", `attachment; filename="smlgoapi_client.dart"`)
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(apispec.GenerateDart(registry.Routes())))
+	}
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smlgoapi/models"
+)
+
+// ErrAddressEmpty is returned when there is no address text to parse
+var ErrAddressEmpty = errors.New("address is empty")
+
+// addressLabels name the parts of a Thai address; a label starts the part's value, which runs to
+// the next label. Longer labels come first so "หมู่บ้าน" is not read as "หมู่".
+var addressLabels = []struct {
+	label string
+	part  string
+}{
+	{"เลขที่", "house_no"}, {"หมู่บ้าน", "village"}, {"หมู่ที่", "moo"}, {"หมู่", "moo"}, {"ม.", "moo"},
+	{"อาคาร", "building"}, {"ซอย", "soi"}, {"ซ.", "soi"}, {"ถนน", "road"}, {"ถ.", "road"},
+	{"ตำบล", "tambon"}, {"แขวง", "tambon"}, {"ต.", "tambon"},
+	{"อำเภอ", "amphure"}, {"เขต", "amphure"}, {"อ.", "amphure"},
+	{"จังหวัด", "province"}, {"จ.", "province"},
+}
+
+// addressLabelPattern finds the labels at the start of a word or right after a number ("99/1ม.4"),
+// so "บจ." in a company name is not taken for จ.
+var addressLabelPattern = func() *regexp.Regexp {
+	labels := make([]string, len(addressLabels))
+	for i, l := range addressLabels {
+		labels[i] = regexp.QuoteMeta(l.label)
+	}
+	return regexp.MustCompile(`(?:^|[\s,\d])(` + strings.Join(labels, "|") + `)`)
+}()
+
+var (
+	addressPhonePattern   = regexp.MustCompile(`(?i)(?:โทร\.?|โทรศัพท์|tel\.?)?\s*(0\d{1,2}[-\s]?\d{3}[-\s]?\d{3,4})(?:\D|$)`)
+	addressZipPattern     = regexp.MustCompile(`(?:^|\D)(\d{5})(?:\D|$)`)
+	addressHouseNoPattern = regexp.MustCompile(`\d+(?:/\d+)*`)
+)
+
+// Weights of the evidence for a location; they add up to 1
+const (
+	addressTambonWeight   = 0.4
+	addressAmphureWeight  = 0.25
+	addressProvinceWeight = 0.2
+	addressZipWeight      = 0.15
+	// minAddressConfidence is the least a location must score to be reported
+	minAddressConfidence = 0.45
+)
+
+// ParseAddress splits a free-form Thai address into its parts and finds its tambon, amphure and
+// province in the administrative data. Labelled parts are compared with adminNameScore; names
+// written without a label count when they appear anywhere in the text. The zip code narrows the
+// tambons considered. Confidence is the weighted evidence for the location found.
+func (s *ThaiAdminService) ParseAddress(address string) (*models.AddressParseResult, error) {
+	text := strings.Join(strings.Fields(strings.ReplaceAll(address, ",", " ")), " ")
+	if text == "" {
+		return nil, ErrAddressEmpty
+	}
+	index, err := s.lookups()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.AddressParseResult{}
+	parts := &result.Components
+
+	// The phone number goes first, so its digits are not taken for a zip code
+	if m := addressPhonePattern.FindStringSubmatchIndex(text); m != nil {
+		parts.Phone = text[m[2]:m[3]]
+		text = strings.TrimSpace(text[:m[0]] + " " + text[m[3]:])
+	}
+	if all := addressZipPattern.FindAllStringSubmatchIndex(text, -1); len(all) > 0 {
+		m := all[len(all)-1]
+		parts.ZipCode, _ = strconv.Atoi(text[m[2]:m[3]])
+		text = strings.TrimSpace(text[:m[2]] + text[m[3]:])
+	}
+
+	result.Unparsed = splitAddressLabels(text, parts)
+
+	location, confidence, warnings := s.matchAddressLocation(index, text, parts)
+	result.Confidence = math.Round(confidence*1000) / 1000
+	result.Warnings = warnings
+	if location != nil {
+		result.Location = location
+		parts.TambonID = location.Tambon.ID
+		parts.Tambon = location.Tambon.NameTh
+		parts.Amphure = location.Amphure.NameTh
+		parts.Province = location.Province.NameTh
+		if parts.ZipCode == 0 {
+			parts.ZipCode = location.Tambon.ZipCode
+		}
+		// A missing label leaves the location in the part before it: "ซอย 3 บางรัก กรุงเทพฯ"
+		for _, part := range []*string{&parts.Village, &parts.Building, &parts.Soi, &parts.Road, &result.Unparsed} {
+			*part = trimAddressLocation(*part, location)
+		}
+	}
+	return result, nil
+}
+
+// splitAddressLabels fills the labelled parts of text, the house number and, from the text before
+// it, the recipient or company as Name. It returns the text between the house number and the first
+// label, which has no label to tell what it is.
+func splitAddressLabels(text string, parts *models.AddressFormatRequest) string {
+	matches := addressLabelPattern.FindAllStringSubmatchIndex(text, -1)
+	end := len(text)
+	if len(matches) > 0 {
+		end = matches[0][2]
+	}
+	for i, m := range matches {
+		next := len(text)
+		if i+1 < len(matches) {
+			next = matches[i+1][2]
+		}
+		value := strings.Trim(text[m[3]:next], " .")
+		setAddressPart(parts, labelPart(text[m[2]:m[3]]), value)
+	}
+
+	// Before the first label: [name] house number [anything else]
+	head := strings.TrimSpace(text[:end])
+	rest := ""
+	if parts.HouseNo == "" {
+		if loc := addressHouseNoPattern.FindStringIndex(head); loc != nil {
+			parts.HouseNo = head[loc[0]:loc[1]]
+			rest = strings.TrimSpace(head[loc[1]:])
+			head = strings.TrimSpace(head[:loc[0]])
+		}
+	}
+	parts.Name = head
+	return rest
+}
+
+func labelPart(label string) string {
+	for _, l := range addressLabels {
+		if l.label == label {
+			return l.part
+		}
+	}
+	return ""
+}
+
+func setAddressPart(parts *models.AddressFormatRequest, part, value string) {
+	switch part {
+	case "house_no":
+		parts.HouseNo = value
+	case "village":
+		parts.Village = value
+	case "moo":
+		parts.Moo = value
+	case "building":
+		parts.Building = value
+	case "soi":
+		parts.Soi = value
+	case "road":
+		parts.Road = value
+	case "tambon":
+		parts.Tambon = value
+	case "amphure":
+		parts.Amphure = value
+	case "province":
+		parts.Province = value
+	}
+}
+
+// matchAddressLocation scores the tambons the address may be in and returns the best one, unless
+// another one scores the same
+func (s *ThaiAdminService) matchAddressLocation(index *thaiAdminIndex, text string, parts *models.AddressFormatRequest) (*models.CompleteLocationData, float64, []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	provinceByID, amphureByID := index.provinceByID, index.amphureByID
+
+	var warnings []string
+	candidates := s.tambonsData
+	if parts.ZipCode != 0 {
+		if inZip := index.tambonsByZip[parts.ZipCode]; len(inZip) > 0 {
+			candidates = inZip
+		} else {
+			warnings = append(warnings, fmt.Sprintf("zip code %05d does not exist; matched by names only", parts.ZipCode))
+		}
+	}
+
+	// Names written without a label are looked for in the whole text
+	folded := thaiMarkFolder.Replace(normalizeAdminName(text))
+	if strings.Contains(folded, "กทม") || strings.Contains(folded, "กรุงเทพ") || strings.Contains(folded, "bangkok") {
+		folded += bangkokNameTh
+	}
+	score := func(value, nameTh, nameEn string) float64 {
+		if value != "" {
+			value = normalizeAdminName(value)
+			best := 0.0
+			for _, name := range []string{nameTh, nameEn} {
+				// A missing label leaves the next part in the value: "บางรัก กรุงเทพฯ"
+				if n := normalizeAdminName(name); n != "" && strings.HasPrefix(value, n) {
+					best = math.Max(best, 0.99)
+				}
+				best = math.Max(best, adminNameScore(value, name))
+			}
+			return best
+		}
+		for _, name := range []string{nameTh, nameEn} {
+			if n := thaiMarkFolder.Replace(normalizeAdminName(name)); n != "" && strings.Contains(folded, n) {
+				return 1
+			}
+		}
+		return 0
+	}
+
+	provinceScores := make(map[int]float64, len(s.provincesData))
+	for _, p := range s.provincesData {
+		provinceScores[p.ID] = score(parts.Province, p.NameTh, p.NameEn)
+	}
+	amphureScores := make(map[int]float64, len(s.amphuresData))
+
+	var best *models.Tambon
+	bestScore, ties := 0.0, 0
+	for i := range candidates {
+		t := &candidates[i]
+		a := amphureByID[t.AmphureID]
+		amphureScore, ok := amphureScores[a.ID]
+		if !ok {
+			amphureScore = score(parts.Amphure, a.NameTh, a.NameEn)
+			amphureScores[a.ID] = amphureScore
+		}
+		tambonScore := score(parts.Tambon, t.NameTh, t.NameEn)
+		// Without a label, "บางรัก" in the text is more likely the amphure than its tambon of the same name
+		if parts.Tambon == "" && normalizeAdminName(t.NameTh) == normalizeAdminName(a.NameTh) {
+			tambonScore *= 0.9
+		}
+		total := addressTambonWeight*tambonScore +
+			addressAmphureWeight*amphureScore +
+			addressProvinceWeight*provinceScores[a.ProvinceID]
+		if parts.ZipCode != 0 && t.ZipCode == parts.ZipCode {
+			total += addressZipWeight
+		}
+		switch {
+		case total > bestScore+1e-9:
+			best, bestScore, ties = t, total, 1
+		case math.Abs(total-bestScore) <= 1e-9:
+			ties++
+		}
+	}
+
+	if best == nil || bestScore < minAddressConfidence {
+		return nil, bestScore, append(warnings, "location not found in the Thai administrative data")
+	}
+	if ties > 1 {
+		return nil, bestScore / 2, append(warnings, fmt.Sprintf("location matches %d tambons equally; add the tambon, amphure or zip code", ties))
+	}
+	a := amphureByID[best.AmphureID]
+	p := provinceByID[a.ProvinceID]
+	location := &models.CompleteLocationData{
+		Province: models.Province{ID: p.ID, NameTh: p.NameTh, NameEn: p.NameEn},
+		Amphure:  models.Amphure{ID: a.ID, NameTh: a.NameTh, NameEn: a.NameEn, ProvinceID: a.ProvinceID},
+		Tambon:   models.Tambon{ID: best.ID, NameTh: best.NameTh, NameEn: best.NameEn, ZipCode: best.ZipCode, AmphureID: best.AmphureID},
+	}
+	if parts.ZipCode != 0 && parts.ZipCode != best.ZipCode {
+		warnings = append(warnings, fmt.Sprintf("zip code %05d differs from %05d on record for tambon %s", parts.ZipCode, best.ZipCode, best.NameTh))
+	}
+	return location, bestScore, warnings
+}
+
+// trimAddressLocation cuts value where the name of the tambon, amphure or province of location starts
+func trimAddressLocation(value string, location *models.CompleteLocationData) string {
+	names := []string{location.Tambon.NameTh, location.Amphure.NameTh, normalizeAdminName(location.Amphure.NameTh), location.Province.NameTh}
+	if location.Province.ID == bangkokProvinceID {
+		names = append(names, "กรุงเทพ", "กทม")
+	}
+	for _, name := range names {
+		if i := strings.Index(" "+value, " "+name); name != "" && i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+	}
+	return value
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"smlgoapi/models"
+)
+
+// ErrInvalidAPIKey is returned when a key is unknown or revoked
+var ErrInvalidAPIKey = errors.New("invalid or revoked API key")
+
+// apiKeyPrefix marks keys issued by this service so they are easy to recognise in configs and logs
+const apiKeyPrefix = "sml_"
+
+type cachedAPIKey struct {
+	key       *models.APIKey
+	expiresAt time.Time
+}
+
+// APIKeyService validates and manages API keys stored in the PostgreSQL api_keys table
+type APIKeyService struct {
+	postgreSQLService *PostgreSQLService
+	cacheTTL          time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]cachedAPIKey
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(postgreSQLService *PostgreSQLService, cacheTTL time.Duration) *APIKeyService {
+	return &APIKeyService{
+		postgreSQLService: postgreSQLService,
+		cacheTTL:          cacheTTL,
+		cache:             make(map[string]cachedAPIKey),
+	}
+}
+
+// EnsureSchema creates the api_keys table if it does not exist
+func (s *APIKeyService) EnsureSchema(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id           SERIAL PRIMARY KEY,
+			name         TEXT NOT NULL,
+			key_prefix   TEXT NOT NULL,
+			key_hash     TEXT NOT NULL UNIQUE,
+			scopes       TEXT NOT NULL DEFAULT 'read',
+			active       BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+			last_used_at TIMESTAMPTZ
+		)`
+
+	if _, err := s.postgreSQLService.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create api_keys table: %w", err)
+	}
+	return nil
+}
+
+// ValidateAPIKey looks up an active key by its SHA-256 hash, using a short-lived in-memory cache
+func (s *APIKeyService) ValidateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	hash := hashAPIKey(rawKey)
+
+	s.mu.RLock()
+	cached, ok := s.cache[hash]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.key, nil
+	}
+
+	query := `
+		SELECT id, name, key_prefix, scopes, active, created_at, last_used_at
+		FROM api_keys
+		WHERE key_hash = $1 AND active = TRUE`
+
+	key, err := scanAPIKey(s.postgreSQLService.db.QueryRowContext(ctx, query, hash))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate API key: %w", err)
+	}
+
+	// Best effort usage tracking; a failure here must not block the request
+	if _, err := s.postgreSQLService.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = now() WHERE id = $1`, key.ID); err != nil {
+		log.Printf("⚠️ [auth] Failed to update last_used_at for key %s: %v", key.KeyPrefix, err)
+	}
+
+	s.mu.Lock()
+	s.cache[hash] = cachedAPIKey{key: key, expiresAt: time.Now().Add(s.cacheTTL)}
+	s.mu.Unlock()
+
+	return key, nil
+}
+
+// CreateAPIKey issues a new key and returns its plaintext value together with the stored record
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, name string, scopes []string) (string, *models.APIKey, error) {
+	for _, scope := range scopes {
+		if scope != models.ScopeRead && scope != models.ScopeCommand && scope != models.ScopeAdmin {
+			return "", nil, fmt.Errorf("unknown scope '%s'", scope)
+		}
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	rawKey := apiKeyPrefix + hex.EncodeToString(buf)
+
+	query := `
+		INSERT INTO api_keys (name, key_prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, name, key_prefix, scopes, active, created_at, last_used_at`
+
+	key, err := scanAPIKey(s.postgreSQLService.db.QueryRowContext(ctx, query,
+		name, rawKey[:len(apiKeyPrefix)+8], hashAPIKey(rawKey), strings.Join(scopes, ",")))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return rawKey, key, nil
+}
+
+// ListAPIKeys returns all keys, newest first
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	query := `
+		SELECT id, name, key_prefix, scopes, active, created_at, last_used_at
+		FROM api_keys
+		ORDER BY created_at DESC`
+
+	rows, err := s.postgreSQLService.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey deactivates a key and drops every cached validation
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	result, err := s.postgreSQLService.db.ExecContext(ctx, `UPDATE api_keys SET active = FALSE WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("API key %d not found", id)
+	}
+
+	s.mu.Lock()
+	s.cache = make(map[string]cachedAPIKey)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopes string
+	var lastUsed sql.NullTime
+
+	if err := row.Scan(&key.ID, &key.Name, &key.KeyPrefix, &scopes, &key.Active, &key.CreatedAt, &lastUsed); err != nil {
+		return nil, err
+	}
+
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			key.Scopes = append(key.Scopes, scope)
+		}
+	}
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+
+	return &key, nil
+}
+
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
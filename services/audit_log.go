@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+)
+
+// AuditFilter selects entries of the audit log; zero fields do not filter
+type AuditFilter struct {
+	From   time.Time // inclusive
+	To     time.Time // exclusive
+	Actor  string
+	Route  string // route pattern or path prefix, e.g. /v1/pgcommand or /v1/admin
+	Method string
+	Limit  int
+	Offset int
+}
+
+// AuditService records write requests in the PostgreSQL audit_log table. An entry is written
+// before the request returns, so an answered write always leaves a trace.
+type AuditService struct {
+	postgreSQLService *PostgreSQLService
+	cfg               config.AuditConfig
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(postgreSQLService *PostgreSQLService, cfg config.AuditConfig) *AuditService {
+	return &AuditService{
+		postgreSQLService: postgreSQLService,
+		cfg:               cfg,
+	}
+}
+
+// EnsureSchema creates the audit_log table and the indexes behind its filters if they do not exist
+func (s *AuditService) EnsureSchema(ctx context.Context) error {
+	statements := []string{`
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id            BIGSERIAL PRIMARY KEY,
+			created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+			actor         TEXT NOT NULL DEFAULT '',
+			method        TEXT NOT NULL,
+			route         TEXT NOT NULL DEFAULT '',
+			path          TEXT NOT NULL,
+			status        INTEGER NOT NULL,
+			sql_text      TEXT NOT NULL DEFAULT '',
+			rows_affected BIGINT,
+			detail        TEXT NOT NULL DEFAULT '',
+			duration_ms   DOUBLE PRECISION NOT NULL DEFAULT 0,
+			client_ip     TEXT NOT NULL DEFAULT '',
+			request_id    TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor, created_at)`,
+	}
+	for _, statement := range statements {
+		if _, err := s.postgreSQLService.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to create audit_log table: %w", err)
+		}
+	}
+	return nil
+}
+
+// Record stores an entry. SQL longer than audit.max_sql_bytes is cut at a character boundary.
+func (s *AuditService) Record(ctx context.Context, entry *models.AuditEntry) error {
+	sqlText := entry.SQL
+	if len(sqlText) > s.cfg.MaxSQLBytes {
+		sqlText = strings.ToValidUTF8(sqlText[:s.cfg.MaxSQLBytes], "") + " …"
+	}
+
+	query := `
+		INSERT INTO audit_log (created_at, actor, method, route, path, status, sql_text, rows_affected,
+			detail, duration_ms, client_ip, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	if _, err := s.postgreSQLService.db.ExecContext(ctx, query,
+		entry.Time, entry.Actor, entry.Method, entry.Route, entry.Path, entry.Status, sqlText,
+		entry.RowsAffected, entry.Detail, entry.DurationMs, entry.ClientIP, entry.RequestID); err != nil {
+		return fmt.Errorf("failed to store audit entry: %w", err)
+	}
+	return nil
+}
+
+// List returns a page of the entries matching filter, newest first, and how many match in total
+func (s *AuditService) List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, int, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if !filter.From.IsZero() {
+		where("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("created_at < $%d", filter.To)
+	}
+	if filter.Actor != "" {
+		where("actor = $%d", filter.Actor)
+	}
+	if filter.Route != "" {
+		where("(route = $%[1]d OR left(path, length($%[1]d)) = $%[1]d)", filter.Route)
+	}
+	if filter.Method != "" {
+		where("method = $%d", strings.ToUpper(filter.Method))
+	}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	db := s.postgreSQLService.db
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT count(*) FROM audit_log "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, created_at, actor, method, route, path, status, sql_text, rows_affected, detail,
+			duration_ms, client_ip, request_id
+		FROM audit_log
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, whereClause, len(args)+1, len(args)+2)
+	rows, err := db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		var rowsAffected sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.Time, &e.Actor, &e.Method, &e.Route, &e.Path, &e.Status, &e.SQL,
+			&rowsAffected, &e.Detail, &e.DurationMs, &e.ClientIP, &e.RequestID); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if rowsAffected.Valid {
+			e.RowsAffected = &rowsAffected.Int64
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+// Prune removes the entries older than audit.retention_days
+func (s *AuditService) Prune(ctx context.Context) (string, error) {
+	cutoff := time.Now().AddDate(0, 0, -s.cfg.RetentionDays)
+	res, err := s.postgreSQLService.db.ExecContext(ctx, `DELETE FROM audit_log WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return "", fmt.Errorf("failed to prune audit_log: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return fmt.Sprintf("removed %d entries older than %d days", n, s.cfg.RetentionDays), nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/oned"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// ErrNoBarcode is returned when an image decodes but holds no readable barcode
+var ErrNoBarcode = errors.New("no barcode found in the image")
+
+// ErrInvalidBarcodeImage is returned when the scanned image cannot be decoded
+var ErrInvalidBarcodeImage = errors.New("invalid barcode image")
+
+// BarcodeScanResult is a barcode read from an image and the products it matches exactly
+type BarcodeScanResult struct {
+	Barcode  string         `json:"barcode"`
+	Format   string         `json:"format"` // EAN_13, EAN_8, UPC_A, UPC_E, CODE_128 or QR_CODE
+	Products []SearchResult `json:"products"`
+}
+
+// barcodeReaders are tried in order; retail barcodes first since most scans are of products
+var barcodeReaders = []func() gozxing.Reader{
+	func() gozxing.Reader { return oned.NewMultiFormatUPCEANReader(nil) },
+	oned.NewCode128Reader,
+	qrcode.NewQRCodeReader,
+}
+
+// DecodeBarcodeImage reads the first EAN/UPC, Code 128 or QR code from a base64 JPEG, PNG, GIF
+// or WebP image. A "data:image/...;base64," prefix is accepted, as produced by browsers.
+func DecodeBarcodeImage(encoded string) (text, format string, err error) {
+	if i := strings.Index(encoded, ";base64,"); i >= 0 && strings.HasPrefix(encoded, "data:") {
+		encoded = encoded[i+len(";base64,"):]
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: image is not valid base64: %v", ErrInvalidBarcodeImage, err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidBarcodeImage, err)
+	}
+
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidBarcodeImage, err)
+	}
+	// TRY_HARDER also scans the image rotated, for barcodes photographed upright
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	for _, newReader := range barcodeReaders {
+		result, err := newReader().Decode(bitmap, hints)
+		if err == nil {
+			return result.GetText(), result.GetBarcodeFormat().String(), nil
+		}
+	}
+	return "", "", ErrNoBarcode
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// PruneCacheDir removes files under dir that were not modified within maxAge, then the oldest
+// remaining files until the total size is at most maxBytes (0 disables the size limit).
+// A missing directory is not an error: there is nothing to prune yet.
+func PruneCacheDir(dir string, maxAge time.Duration, maxBytes int64) (string, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return fmt.Sprintf("%s does not exist", dir), nil
+	}
+
+	type cachedFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []cachedFile
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // removed while walking
+		}
+		files = append(files, cachedFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	cutoff := time.Now().Add(-maxAge)
+	removed, freed := 0, int64(0)
+	for _, file := range files {
+		expired := maxAge > 0 && file.modTime.Before(cutoff)
+		oversize := maxBytes > 0 && total > maxBytes
+		if !expired && !oversize {
+			break // sorted oldest first, so nothing later is expired either
+		}
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to remove %s: %w", file.path, err)
+		}
+		removed++
+		freed += file.size
+		total -= file.size
+	}
+
+	return fmt.Sprintf("removed %d of %d files (%d MB freed, %d MB kept)",
+		removed, len(files), freed/(1024*1024), total/(1024*1024)), nil
+}
@@ -8,19 +8,27 @@ import (
 	"smlgoapi/config"
 	"smlgoapi/models"
 
-	_ "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2"
 )
 
 type ClickHouseService struct {
 	db     *sql.DB
 	config *config.Config
+	fields FieldMap // ic_inventory columns, see field_mapping.clickhouse
 }
 
 func NewClickHouseService(config *config.Config) (*ClickHouseService, error) {
-	db, err := sql.Open("clickhouse", config.GetClickHouseDSN())
+	fields, err := NewClickHouseFieldMap(config.FieldMapping.ClickHouse)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := clickhouse.ParseDSN(config.GetClickHouseDSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open ClickHouse connection: %w", err)
 	}
+	db := sql.OpenDB(newTracingConnector(clickhouse.Connector(options), "clickhouse"))
+	configurePool(db, config.ClickHouse.Pool)
 
 	// Test connection
 	if err := db.Ping(); err != nil {
@@ -30,21 +38,43 @@ func NewClickHouseService(config *config.Config) (*ClickHouseService, error) {
 	return &ClickHouseService{
 		db:     db,
 		config: config,
+		fields: fields,
 	}, nil
 }
 
+// tagContext attaches the request's query tag as query_id and log_comment, which ClickHouse
+// records in system.query_log and system.processes. The driver sends the deadline of a tagged
+// context as max_execution_time, so the server also stops a query whose cancel request is lost.
+func tagContext(ctx context.Context) context.Context {
+	tag, ok := QueryTagFromContext(ctx)
+	if !ok {
+		if _, hasDeadline := ctx.Deadline(); hasDeadline {
+			return clickhouse.Context(ctx)
+		}
+		return ctx
+	}
+	return clickhouse.Context(ctx,
+		clickhouse.WithQueryID(tag.QueryID()),
+		clickhouse.WithSettings(clickhouse.Settings{"log_comment": tag.String()}))
+}
+
 func (s *ClickHouseService) Close() error {
 	return s.db.Close()
 }
 
+// Stats returns the connection pool statistics
+func (s *ClickHouseService) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
 func (s *ClickHouseService) GetVersion(ctx context.Context) (string, error) {
 	var version string
-	err := s.db.QueryRowContext(ctx, "SELECT version()").Scan(&version)
+	err := s.db.QueryRowContext(tagContext(ctx), "SELECT version()").Scan(&version)
 	return version, err
 }
 
 func (s *ClickHouseService) GetTables(ctx context.Context) ([]models.Table, error) {
-	rows, err := s.db.QueryContext(ctx, "SHOW TABLES")
+	rows, err := s.db.QueryContext(tagContext(ctx), "SHOW TABLES")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tables: %w", err)
 	}
@@ -65,7 +95,7 @@ func (s *ClickHouseService) GetTables(ctx context.Context) ([]models.Table, erro
 // ExecuteCommand executes a SQL command (INSERT, UPDATE, DELETE, CREATE, etc.)
 func (s *ClickHouseService) ExecuteCommand(ctx context.Context, query string) (interface{}, error) {
 	// Execute the command
-	result, err := s.db.ExecContext(ctx, query)
+	result, err := s.db.ExecContext(tagContext(ctx), query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute command: %w", err)
 	}
@@ -87,55 +117,24 @@ func (s *ClickHouseService) ExecuteCommand(ctx context.Context, query string) (i
 	}, nil
 }
 
-// ExecuteSelect executes a SELECT query and returns the result data
-func (s *ClickHouseService) ExecuteSelect(ctx context.Context, query string) ([]interface{}, error) {
-	rows, err := s.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute select query: %w", err)
-	}
-	defer rows.Close()
-
-	// Get column information
-	columns, err := rows.Columns()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get columns: %w", err)
-	}
-
+// ExecuteSelect executes a SELECT query and returns the result data.
+// Optional params are bound to "?" placeholders.
+func (s *ClickHouseService) ExecuteSelect(ctx context.Context, query string, params ...interface{}) ([]interface{}, error) {
 	var results []interface{}
-
-	for rows.Next() {
-		// Create a slice of interface{} to hold the values
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-
-		for i := range columns {
-			valuePtrs[i] = &values[i]
-		}
-
-		// Scan the row into the value pointers
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		// Create a map for this row
-		rowMap := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
-
-			// Convert []uint8 to string if needed
-			if b, ok := val.([]uint8); ok {
-				val = string(b)
-			}
-
-			rowMap[col] = val
-		}
-
-		results = append(results, rowMap)
+	if _, err := s.StreamSelect(ctx, query, collectRows(&results), params...); err != nil {
+		return nil, err
 	}
+	return results, nil
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %w", err)
+// StreamSelect executes a SELECT query and hands each row to fn as it is read,
+// so large results never have to fit in memory. It returns the number of rows streamed.
+func (s *ClickHouseService) StreamSelect(ctx context.Context, query string, fn RowFunc, params ...interface{}) (int, error) {
+	rows, err := s.db.QueryContext(tagContext(ctx), query, params...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute select query: %w", err)
 	}
+	defer rows.Close()
 
-	return results, nil
+	return scanRows(rows, fn)
 }
@@ -0,0 +1,541 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"smlgoapi/config"
+
+	"github.com/lib/pq"
+)
+
+// ErrDataTableNotFound is returned for tables that are not listed in data_api.tables or do not exist
+var ErrDataTableNotFound = errors.New("table not found")
+
+// ErrDataRowNotFound is returned when no row has the requested primary key
+var ErrDataRowNotFound = errors.New("row not found")
+
+// ErrDataReadOnly is returned for writes to a table listed as read_only
+var ErrDataReadOnly = errors.New("table is read-only")
+
+// ErrInvalidDataRequest is returned for filters, sorts and values that do not fit the table
+var ErrInvalidDataRequest = errors.New("invalid request")
+
+// dataSchemaTTL bounds how long a column change goes unnoticed by the data API
+const dataSchemaTTL = time.Minute
+
+// Query parameters of a data API list that are not column filters
+var dataReservedParams = map[string]bool{"limit": true, "offset": true, "sort": true, "fields": true}
+
+// dataFilterOps maps the operator of a "column[op]=value" filter to its SQL
+var dataFilterOps = map[string]string{
+	"eq": "=", "ne": "<>", "gt": ">", "gte": ">=", "lt": "<", "lte": "<=",
+	"like": "LIKE", "ilike": "ILIKE", "in": "= ANY", "null": "IS NULL",
+}
+
+// DataService implements the generic CRUD endpoints of /v1/data/{table} on the PostgreSQL tables
+// listed in data_api.tables. Columns and primary keys are read from information_schema, so the
+// statements are built from known identifiers and every value is bound as a parameter.
+type DataService struct {
+	pg     *PostgreSQLService
+	tables map[string]config.DataTableConfig // by schema.table
+
+	mu     sync.Mutex
+	schema map[string]*dataTable
+}
+
+// dataTable is the introspected shape of an allowed table
+type dataTable struct {
+	schema, name string
+	columns      []string // visible columns in table order
+	types        map[string]string
+	key          []string // primary key columns
+	readOnly     bool
+	loadedAt     time.Time
+}
+
+// DataQuery is a parsed list request: filters, sort order, selected fields and the page
+type DataQuery struct {
+	Filters []DataFilter
+	Sort    []DataSort
+	Fields  []string
+	Limit   int
+	Offset  int
+}
+
+// DataFilter is one "column[op]=value" condition; conditions are combined with AND
+type DataFilter struct {
+	Column string
+	Op     string
+	Value  string
+}
+
+// DataSort orders a list by a column
+type DataSort struct {
+	Column string
+	Desc   bool
+}
+
+// NewDataService creates the data API service. Unqualified table names are in the public schema;
+// invalid names are logged and skipped.
+func NewDataService(pg *PostgreSQLService, cfg config.DataAPIConfig) *DataService {
+	tables := make(map[string]config.DataTableConfig, len(cfg.Tables))
+	for name, table := range cfg.Tables {
+		qualified, ok := dataTableName(name)
+		if !ok {
+			log.Printf("⚠️ [data] Ignoring invalid table name in data_api.tables: %s", name)
+			continue
+		}
+		tables[qualified] = table
+	}
+	return &DataService{pg: pg, tables: tables, schema: make(map[string]*dataTable)}
+}
+
+// Tables returns the served tables, schema-qualified and sorted
+func (s *DataService) Tables() []string {
+	names := make([]string, 0, len(s.tables))
+	for name := range s.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dataTableName qualifies a table name with the public schema
+func dataTableName(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !validTableName(name) {
+		return "", false
+	}
+	if !strings.Contains(name, ".") {
+		name = postgresDefaultSchema + "." + name
+	}
+	return name, true
+}
+
+// ParseDataQuery reads the filters, sort and fields of a list request. Filters are "column=value"
+// or "column[op]=value"; "in" takes a comma-separated list and "null" takes true or false.
+// sort is a comma-separated list of columns, descending with a leading "-".
+func ParseDataQuery(values url.Values) (DataQuery, error) {
+	var q DataQuery
+	for param, list := range values {
+		if dataReservedParams[param] {
+			continue
+		}
+		column, op := param, "eq"
+		if i := strings.IndexByte(param, '['); i > 0 && strings.HasSuffix(param, "]") {
+			column, op = param[:i], param[i+1:len(param)-1]
+		}
+		if _, ok := dataFilterOps[op]; !ok {
+			return q, fmt.Errorf("%w: unknown filter operator '%s' in %s", ErrInvalidDataRequest, op, param)
+		}
+		for _, value := range list {
+			q.Filters = append(q.Filters, DataFilter{Column: column, Op: op, Value: value})
+		}
+	}
+	// Map iteration order is random; a stable WHERE clause keeps query plans and logs comparable
+	sort.SliceStable(q.Filters, func(i, j int) bool { return q.Filters[i].Column < q.Filters[j].Column })
+
+	for _, field := range splitList(values.Get("sort")) {
+		desc := strings.HasPrefix(field, "-")
+		q.Sort = append(q.Sort, DataSort{Column: strings.TrimPrefix(field, "-"), Desc: desc})
+	}
+	q.Fields = splitList(values.Get("fields"))
+	return q, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// table returns the introspected table, reading information_schema when the cached copy is stale
+func (s *DataService) table(ctx context.Context, name string) (*dataTable, error) {
+	qualified, ok := dataTableName(name)
+	if !ok {
+		return nil, ErrDataTableNotFound
+	}
+	access, ok := s.tables[qualified]
+	if !ok {
+		return nil, ErrDataTableNotFound
+	}
+
+	s.mu.Lock()
+	cached := s.schema[qualified]
+	s.mu.Unlock()
+	if cached != nil && time.Since(cached.loadedAt) < dataSchemaTTL {
+		return cached, nil
+	}
+
+	schema, table := splitTable(qualified)
+	t := &dataTable{schema: schema, name: table, types: make(map[string]string), readOnly: access.ReadOnly, loadedAt: time.Now()}
+	hidden := make(map[string]bool, len(access.HiddenColumns))
+	for _, column := range access.HiddenColumns {
+		hidden[column] = true
+	}
+
+	rows, err := s.pg.db.QueryContext(ctx, `
+		SELECT column_name, data_type FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2
+		ORDER BY ordinal_position`, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the columns of %s: %w", qualified, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var column, dataType string
+		if err := rows.Scan(&column, &dataType); err != nil {
+			return nil, err
+		}
+		if !hidden[column] {
+			t.columns = append(t.columns, column)
+			t.types[column] = dataType
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(t.columns) == 0 {
+		return nil, ErrDataTableNotFound
+	}
+
+	keys, err := s.pg.db.QueryContext(ctx, `
+		SELECT a.attname
+		FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = $1::regclass AND i.indisprimary
+		ORDER BY array_position(i.indkey, a.attnum)`, pq.QuoteIdentifier(schema)+"."+pq.QuoteIdentifier(table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the primary key of %s: %w", qualified, err)
+	}
+	defer keys.Close()
+	for keys.Next() {
+		var key string
+		if err := keys.Scan(&key); err != nil {
+			return nil, err
+		}
+		t.key = append(t.key, key)
+	}
+	if err := keys.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.schema[qualified] = t
+	s.mu.Unlock()
+	return t, nil
+}
+
+// List returns a page of rows matching q and the number of rows matching it in total
+func (s *DataService) List(ctx context.Context, table string, q DataQuery) ([]map[string]interface{}, int, error) {
+	t, err := s.table(ctx, table)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	columns := t.columns
+	if len(q.Fields) > 0 {
+		for _, field := range q.Fields {
+			if err := t.check(field); err != nil {
+				return nil, 0, err
+			}
+		}
+		columns = q.Fields
+	}
+
+	var where []string
+	var params []interface{}
+	for _, f := range q.Filters {
+		if err := t.check(f.Column); err != nil {
+			return nil, 0, err
+		}
+		column := pq.QuoteIdentifier(f.Column)
+		switch f.Op {
+		case "null":
+			isNull, err := strconv.ParseBool(f.Value)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%w: %s[null] must be true or false", ErrInvalidDataRequest, f.Column)
+			}
+			if isNull {
+				where = append(where, column+" IS NULL")
+			} else {
+				where = append(where, column+" IS NOT NULL")
+			}
+			continue
+		case "in":
+			params = append(params, pq.Array(splitList(f.Value)))
+			where = append(where, column+" = ANY($"+strconv.Itoa(len(params))+")")
+			continue
+		case "like", "ilike":
+			// Pattern matching works on the text form, so numeric codes can be searched by prefix too
+			column = "CAST(" + column + " AS TEXT)"
+		}
+		// The parameter is sent untyped; PostgreSQL reads it as the type of the column
+		params = append(params, f.Value)
+		where = append(where, column+" "+dataFilterOps[f.Op]+" $"+strconv.Itoa(len(params)))
+	}
+
+	var order []string
+	for _, o := range q.Sort {
+		if err := t.check(o.Column); err != nil {
+			return nil, 0, err
+		}
+		direction := "ASC"
+		if o.Desc {
+			direction = "DESC"
+		}
+		order = append(order, pq.QuoteIdentifier(o.Column)+" "+direction)
+	}
+	// The primary key breaks ties so pages do not overlap
+	for _, key := range t.key {
+		order = append(order, pq.QuoteIdentifier(key))
+	}
+
+	from := " FROM " + t.quoted()
+	if len(where) > 0 {
+		from += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.pg.db.QueryRowContext(ctx, "SELECT count(*)"+from, params...).Scan(&total); err != nil {
+		return nil, 0, dataQueryError(err)
+	}
+
+	query := "SELECT " + quoteColumns(columns) + from
+	if len(order) > 0 {
+		query += " ORDER BY " + strings.Join(order, ", ")
+	}
+	params = append(params, q.Limit, q.Offset)
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(params)-1, len(params))
+
+	rows := make([]map[string]interface{}, 0, q.Limit)
+	_, err = s.pg.StreamSelect(ctx, query, func(columns []string, values []interface{}) error {
+		rows = append(rows, dataRow(columns, values))
+		return nil
+	}, params...)
+	if err != nil {
+		return nil, 0, dataQueryError(err)
+	}
+	return rows, total, nil
+}
+
+// Get returns the row whose primary key is id
+func (s *DataService) Get(ctx context.Context, table, id string) (map[string]interface{}, error) {
+	t, err := s.table(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.singleKey()
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + quoteColumns(t.columns) + " FROM " + t.quoted() + " WHERE " + pq.QuoteIdentifier(key) + " = $1"
+	return s.one(ctx, query, id)
+}
+
+// Create inserts a row and returns it as stored, with defaults filled in
+func (s *DataService) Create(ctx context.Context, table string, values map[string]interface{}) (map[string]interface{}, error) {
+	t, err := s.writable(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	columns, params, err := t.values(values)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "INSERT INTO " + t.quoted()
+	if len(columns) == 0 {
+		query += " DEFAULT VALUES"
+	} else {
+		placeholders := make([]string, len(columns))
+		for i := range columns {
+			placeholders[i] = "$" + strconv.Itoa(i+1)
+		}
+		query += " (" + quoteColumns(columns) + ") VALUES (" + strings.Join(placeholders, ", ") + ")"
+	}
+	return s.one(ctx, query+" RETURNING "+quoteColumns(t.columns), params...)
+}
+
+// Update sets the given columns of the row whose primary key is id and returns the updated row
+func (s *DataService) Update(ctx context.Context, table, id string, values map[string]interface{}) (map[string]interface{}, error) {
+	t, err := s.writable(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.singleKey()
+	if err != nil {
+		return nil, err
+	}
+	columns, params, err := t.values(values)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: no columns to update", ErrInvalidDataRequest)
+	}
+
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = pq.QuoteIdentifier(column) + " = $" + strconv.Itoa(i+1)
+	}
+	params = append(params, id)
+	query := "UPDATE " + t.quoted() + " SET " + strings.Join(assignments, ", ") +
+		" WHERE " + pq.QuoteIdentifier(key) + " = $" + strconv.Itoa(len(params)) +
+		" RETURNING " + quoteColumns(t.columns)
+	return s.one(ctx, query, params...)
+}
+
+// Delete removes the row whose primary key is id and returns it
+func (s *DataService) Delete(ctx context.Context, table, id string) (map[string]interface{}, error) {
+	t, err := s.writable(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.singleKey()
+	if err != nil {
+		return nil, err
+	}
+	query := "DELETE FROM " + t.quoted() + " WHERE " + pq.QuoteIdentifier(key) + " = $1 RETURNING " + quoteColumns(t.columns)
+	return s.one(ctx, query, id)
+}
+
+func (s *DataService) writable(ctx context.Context, table string) (*dataTable, error) {
+	t, err := s.table(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	if t.readOnly {
+		return nil, ErrDataReadOnly
+	}
+	return t, nil
+}
+
+// one runs a statement returning at most one row
+func (s *DataService) one(ctx context.Context, query string, params ...interface{}) (map[string]interface{}, error) {
+	var row map[string]interface{}
+	_, err := s.pg.StreamSelect(ctx, query, func(columns []string, values []interface{}) error {
+		row = dataRow(columns, values)
+		return nil
+	}, params...)
+	if err != nil {
+		return nil, dataQueryError(err)
+	}
+	if row == nil {
+		return nil, ErrDataRowNotFound
+	}
+	return row, nil
+}
+
+// dataQueryError reports values PostgreSQL rejected, such as text in a numeric filter or a
+// duplicate key, as bad requests
+func dataQueryError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Class 22 is invalid data, class 23 a violated constraint
+		if class := pqErr.Code.Class(); class == "22" || class == "23" {
+			return fmt.Errorf("%w: %s", ErrInvalidDataRequest, pqErr.Message)
+		}
+	}
+	return err
+}
+
+func dataRow(columns []string, values []interface{}) map[string]interface{} {
+	row := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		row[column] = values[i]
+	}
+	return row
+}
+
+func (t *dataTable) quoted() string {
+	return pq.QuoteIdentifier(t.schema) + "." + pq.QuoteIdentifier(t.name)
+}
+
+// check rejects columns the table does not have or hides
+func (t *dataTable) check(column string) error {
+	if _, ok := t.types[column]; !ok {
+		return fmt.Errorf("%w: unknown column '%s'", ErrInvalidDataRequest, column)
+	}
+	return nil
+}
+
+// singleKey returns the primary key column that {id} addresses
+func (t *dataTable) singleKey() (string, error) {
+	switch {
+	case len(t.key) == 0:
+		return "", fmt.Errorf("%w: %s has no primary key; use filters on the list instead", ErrInvalidDataRequest, t.name)
+	case len(t.key) > 1:
+		return "", fmt.Errorf("%w: %s has a composite primary key (%s); use filters on the list instead",
+			ErrInvalidDataRequest, t.name, strings.Join(t.key, ", "))
+	}
+	if _, ok := t.types[t.key[0]]; !ok {
+		return "", fmt.Errorf("%w: the primary key of %s is hidden", ErrInvalidDataRequest, t.name)
+	}
+	return t.key[0], nil
+}
+
+// values turns a JSON object into sorted columns and parameters. Objects and arrays are accepted
+// for json, jsonb and array columns.
+func (t *dataTable) values(values map[string]interface{}) ([]string, []interface{}, error) {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		if err := t.check(column); err != nil {
+			return nil, nil, err
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	params := make([]interface{}, len(columns))
+	for i, column := range columns {
+		switch v := values[column].(type) {
+		case nil, bool, string:
+			params[i] = v
+		case float64:
+			params[i] = normalizeNumber(v)
+		case map[string]interface{}, []interface{}:
+			switch dataType := t.types[column]; {
+			case dataType == "json" || dataType == "jsonb":
+				encoded, err := json.Marshal(v)
+				if err != nil {
+					return nil, nil, err
+				}
+				params[i] = string(encoded)
+			case dataType == "ARRAY":
+				list, ok := v.([]interface{})
+				if !ok {
+					return nil, nil, fmt.Errorf("%w: %s takes an array", ErrInvalidDataRequest, column)
+				}
+				array, err := normalizeArray(list)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%w: %s: %v", ErrInvalidDataRequest, column, err)
+				}
+				params[i] = pq.Array(array)
+			default:
+				return nil, nil, fmt.Errorf("%w: %s is %s and cannot take %s", ErrInvalidDataRequest, column, dataType, jsonTypeName(v))
+			}
+		default:
+			return nil, nil, fmt.Errorf("%w: unsupported value for %s", ErrInvalidDataRequest, column)
+		}
+	}
+	return columns, params, nil
+}
+
+func quoteColumns(columns []string) string {
+	return joinQuoted(ServicePostgreSQL, columns)
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+)
+
+// configurePool applies the pool section of a database to its connection pool
+func configurePool(db *sql.DB, pool config.PoolConfig) {
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(pool.ConnMaxLifetimeSeconds) * time.Second)
+	db.SetConnMaxIdleTime(time.Duration(pool.ConnMaxIdleTimeSeconds) * time.Second)
+}
+
+// NewDBPoolStats reports a pool's live statistics next to its configuration
+func NewDBPoolStats(database string, pool config.PoolConfig, stats sql.DBStats) models.DBPoolStats {
+	return models.DBPoolStats{
+		Database:                database,
+		MaxOpenConns:            stats.MaxOpenConnections,
+		MaxIdleConns:            pool.MaxIdleConns,
+		ConnMaxLifetimeSeconds:  pool.ConnMaxLifetimeSeconds,
+		ConnMaxIdleTimeSeconds:  pool.ConnMaxIdleTimeSeconds,
+		StatementTimeoutSeconds: pool.StatementTimeoutSeconds,
+		OpenConnections:         stats.OpenConnections,
+		InUse:                   stats.InUse,
+		Idle:                    stats.Idle,
+		WaitCount:               stats.WaitCount,
+		WaitDurationMs:          float64(stats.WaitDuration.Microseconds()) / 1000,
+		MaxIdleClosed:           stats.MaxIdleClosed,
+		MaxIdleTimeClosed:       stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:       stats.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+
+	"smlgoapi/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxTracedStatementLength keeps generated search SQL from bloating spans
+const maxTracedStatementLength = 2000
+
+// tracingConnector wraps a database/sql connector so queries, executions and transactions get
+// a client span under the request's span. Rows are not wrapped: a query span ends when the first
+// result arrives, not when the rows have been read.
+type tracingConnector struct {
+	driver.Connector
+	system string // db.system attribute, e.g. "postgresql"
+}
+
+func newTracingConnector(connector driver.Connector, system string) driver.Connector {
+	return &tracingConnector{Connector: connector, system: system}
+}
+
+func (t *tracingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := t.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracingConn{conn: conn, system: t.system}, nil
+}
+
+// tracingConn forwards to the driver connection. Optional interfaces the driver lacks answer
+// driver.ErrSkip or fall back the way database/sql itself would.
+type tracingConn struct {
+	conn   driver.Conn
+	system string
+}
+
+func (c *tracingConn) start(ctx context.Context, operation, query string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", c.system),
+		attribute.String("db.operation.name", operation),
+	}
+	if query != "" {
+		if len(query) > maxTracedStatementLength {
+			query = query[:maxTracedStatementLength] + "…"
+		}
+		attrs = append(attrs, attribute.String("db.query.text", strings.TrimSpace(query)))
+	}
+	return tracing.Start(ctx, c.system+" "+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func (c *tracingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.start(ctx, "query", query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	tracing.End(span, skipIsNotAnError(err))
+	return rows, err
+}
+
+func (c *tracingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.start(ctx, "exec", query)
+	result, err := execer.ExecContext(ctx, query, args)
+	tracing.End(span, skipIsNotAnError(err))
+	return result, err
+}
+
+func (c *tracingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	ctx, span := c.start(ctx, "prepare", query)
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	tracing.End(span, err)
+	return stmt, err
+}
+
+func (c *tracingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	ctx, span := c.start(ctx, "begin", "")
+	var tx driver.Tx
+	var err error
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		// clickhouse-go only implements the old Begin
+		tx, err = c.conn.Begin()
+	}
+	tracing.End(span, err)
+	return tx, err
+}
+
+func (c *tracingConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(query)
+}
+
+func (c *tracingConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *tracingConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *tracingConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracingConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracingConn) IsValid() bool {
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue lets drivers such as clickhouse-go accept their own argument types
+func (c *tracingConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// skipIsNotAnError hides driver.ErrSkip, which only asks database/sql to prepare the statement
+func skipIsNotAnError(err error) error {
+	if err == driver.ErrSkip {
+		return nil
+	}
+	return err
+}
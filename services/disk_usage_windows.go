@@ -0,0 +1,18 @@
+//go:build windows
+
+package services
+
+import "golang.org/x/sys/windows"
+
+// DiskFreeBytes returns the free space available to the current user on the volume holding path
+func DiskFreeBytes(path string) (uint64, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var freeBytes, totalBytes, totalFreeBytes uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &freeBytes, &totalBytes, &totalFreeBytes); err != nil {
+		return 0, err
+	}
+	return freeBytes, nil
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+)
+
+// Export job states
+const (
+	ExportQueued    = "queued"
+	ExportRunning   = "running"
+	ExportDone      = "done"
+	ExportFailed    = "failed"
+	ExportCancelled = "cancelled"
+)
+
+// ErrExportNotFound is returned for unknown or expired export ids
+var ErrExportNotFound = errors.New("export not found")
+
+// ErrExportNotReady is returned when the file of an unfinished or failed export is requested
+var ErrExportNotReady = errors.New("export file is not ready")
+
+// exportFilePrefix marks the files the service owns in the export directory
+const exportFilePrefix = "export-"
+
+// ExportFunc writes an export to w and returns the number of rows written. progress may be
+// called with the rows written so far.
+type ExportFunc func(ctx context.Context, w *bufio.Writer, progress func(rows int)) (int, error)
+
+// ExportJobService runs exports in the background and keeps their files in a local directory,
+// so a large export is neither tied to an HTTP request nor cut off by its timeout. Jobs are kept
+// in memory: they are only known to the instance that runs them and are lost on restart.
+type ExportJobService struct {
+	cfg    config.ExportConfig
+	slots  chan struct{} // one per export allowed to run at the same time
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	jobs map[string]*exportJob
+}
+
+type exportJob struct {
+	models.ExportJob
+	path   string
+	cancel context.CancelFunc
+}
+
+// NewExportJobService prepares the export directory. Files left by an earlier run are removed,
+// since their jobs were lost with it.
+func NewExportJobService(cfg config.ExportConfig) (*ExportJobService, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(cfg.Dir, exportFilePrefix+"*"))
+	for _, path := range leftovers {
+		os.Remove(path)
+	}
+	if len(leftovers) > 0 {
+		log.Printf("🧹 [export] Removed %d files of a previous run from %s", len(leftovers), cfg.Dir)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &ExportJobService{
+		cfg:    cfg,
+		slots:  make(chan struct{}, cfg.MaxConcurrent),
+		ctx:    ctx,
+		cancel: cancel,
+		jobs:   make(map[string]*exportJob),
+	}
+	go s.pruneLoop()
+	return s, nil
+}
+
+// ExportDownloadURL is the path serving the file of a finished export
+func ExportDownloadURL(id string) string {
+	return "/v1/admin/export/" + id + "/download"
+}
+
+// Start queues an export described by job and returns its state. run writes the file once one of
+// the max_concurrent slots is free.
+func (s *ExportJobService) Start(job models.ExportJob, run ExportFunc) (models.ExportJob, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return models.ExportJob{}, fmt.Errorf("failed to generate export id: %w", err)
+	}
+	job.ID = hex.EncodeToString(buf)
+	job.Status = ExportQueued
+	job.CreatedAt = time.Now()
+	if job.Filename == "" {
+		job.Filename = exportFilePrefix + job.ID + "." + job.Format
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	j := &exportJob{
+		ExportJob: job,
+		path:      filepath.Join(s.cfg.Dir, exportFilePrefix+job.ID+"."+job.Format),
+		cancel:    cancel,
+	}
+	s.mu.Lock()
+	s.jobs[job.ID] = j
+	snapshot := j.snapshot()
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.run(ctx, j, run)
+	return snapshot, nil
+}
+
+func (s *ExportJobService) run(ctx context.Context, j *exportJob, run ExportFunc) {
+	defer s.wg.Done()
+	defer j.cancel()
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		s.finish(j, 0, ctx.Err())
+		return
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	j.Status = ExportRunning
+	j.StartedAt = &now
+	s.mu.Unlock()
+	log.Printf("📤 [export] %s started: %s from %s", j.ID, j.Format, j.Database)
+
+	rows, err := s.write(ctx, j, run)
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err() // drivers report a cancelled query in their own words
+	}
+	if err == nil {
+		err = os.Rename(j.path+".part", j.path)
+	}
+	if err != nil {
+		os.Remove(j.path + ".part")
+	}
+	s.finish(j, rows, err)
+}
+
+// write runs the export into a .part file, renamed by the caller once complete
+func (s *ExportJobService) write(ctx context.Context, j *exportJob, run ExportFunc) (int, error) {
+	file, err := os.Create(j.path + ".part")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriterSize(file, 64<<10)
+	rows, err := run(ctx, w, func(rows int) {
+		s.mu.Lock()
+		j.Rows = rows
+		s.mu.Unlock()
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = file.Close()
+	}
+	return rows, err
+}
+
+func (s *ExportJobService) finish(j *exportJob, rows int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	expires := now.Add(time.Duration(s.cfg.RetentionHours) * time.Hour)
+	j.Rows = rows
+	j.FinishedAt = &now
+	j.ExpiresAt = &expires
+	switch {
+	case err == nil:
+		j.Status = ExportDone
+		if info, statErr := os.Stat(j.path); statErr == nil {
+			j.SizeBytes = info.Size()
+		}
+		log.Printf("✅ [export] %s done: %d rows, %d bytes", j.ID, rows, j.SizeBytes)
+	case errors.Is(err, context.Canceled):
+		j.Status = ExportCancelled
+		log.Printf("🛑 [export] %s cancelled after %d rows", j.ID, rows)
+	default:
+		j.Status = ExportFailed
+		j.Error = err.Error()
+		log.Printf("❌ [export] %s failed after %d rows: %v", j.ID, rows, err)
+	}
+}
+
+// List returns the known exports, newest first
+func (s *ExportJobService) List() []models.ExportJob {
+	s.prune()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]models.ExportJob, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j.snapshot())
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].CreatedAt.After(jobs[k].CreatedAt) })
+	return jobs
+}
+
+// Get returns the state of an export
+func (s *ExportJobService) Get(id string) (models.ExportJob, error) {
+	s.prune()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return models.ExportJob{}, ErrExportNotFound
+	}
+	return j.snapshot(), nil
+}
+
+// File returns the path and download name of a finished export
+func (s *ExportJobService) File(id string) (path, filename string, err error) {
+	s.prune()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return "", "", ErrExportNotFound
+	}
+	if j.Status != ExportDone {
+		return "", "", fmt.Errorf("%w: export is %s", ErrExportNotReady, j.Status)
+	}
+	return j.path, j.Filename, nil
+}
+
+// Delete cancels a queued or running export, or removes a finished one and its file. It returns
+// the state of the export before it was removed.
+func (s *ExportJobService) Delete(id string) (models.ExportJob, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	if !ok {
+		s.mu.Unlock()
+		return models.ExportJob{}, ErrExportNotFound
+	}
+	if j.FinishedAt == nil {
+		j.cancel()
+		snapshot := j.snapshot()
+		s.mu.Unlock()
+		return snapshot, nil
+	}
+	delete(s.jobs, id)
+	s.mu.Unlock()
+
+	os.Remove(j.path)
+	return j.snapshot(), nil
+}
+
+// Close cancels the exports in progress and waits for them until ctx ends
+func (s *ExportJobService) Close(ctx context.Context) {
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+func (s *ExportJobService) pruneLoop() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.prune()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// prune removes exports finished longer than retention_hours ago, with their files
+func (s *ExportJobService) prune() {
+	now := time.Now()
+	var paths []string
+	s.mu.Lock()
+	for id, j := range s.jobs {
+		if j.ExpiresAt != nil && now.After(*j.ExpiresAt) {
+			paths = append(paths, j.path)
+			delete(s.jobs, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("⚠️ [export] Failed to remove %s: %v", path, err)
+		}
+	}
+	if len(paths) > 0 {
+		log.Printf("🧹 [export] Removed %d expired exports", len(paths))
+	}
+}
+
+// snapshot copies the public state; the caller holds s.mu
+func (j *exportJob) snapshot() models.ExportJob {
+	job := j.ExportJob
+	if job.Status == ExportDone {
+		job.DownloadURL = ExportDownloadURL(job.ID)
+	}
+	return job
+}
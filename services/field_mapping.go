@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Logical product fields; field_mapping.postgresql and field_mapping.clickhouse map them to columns
+const (
+	FieldCode             = "code"
+	FieldName             = "name"
+	FieldUnitStandardCode = "unit_standard_code"
+	FieldItemType         = "item_type"
+	FieldRowOrderRef      = "row_order_ref"
+	FieldBarcode          = "barcode"         // ic_inventory_barcode
+	FieldBarcodeICCode    = "barcode_ic_code" // ic_inventory_barcode column holding the product code
+	FieldImageURL         = "image_url"
+	FieldBalanceQty       = "balance_qty"
+	FieldSupplierCode     = "supplier_code"
+)
+
+// Standard column of each logical field in the SML schema
+var (
+	postgresFieldDefaults = map[string]string{
+		FieldCode:             "code",
+		FieldName:             "name",
+		FieldUnitStandardCode: "unit_standard_code",
+		FieldItemType:         "item_type",
+		FieldRowOrderRef:      "row_order_ref",
+		FieldBarcode:          "barcode",
+		FieldBarcodeICCode:    "ic_code",
+		FieldSupplierCode:     "supplier_code", // optional, used by the supplier facet
+	}
+	clickHouseFieldDefaults = map[string]string{
+		FieldCode:             "code",
+		FieldName:             "name",
+		FieldUnitStandardCode: "unit_standard",
+		FieldImageURL:         "image_url",
+		FieldBalanceQty:       "balance_qty",
+		FieldSupplierCode:     "supplier_code",
+	}
+)
+
+var (
+	columnNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// {field} or {alias.field}
+	fieldPlaceholderPattern = regexp.MustCompile(`\{(?:([a-z_][a-z0-9_]*)\.)?([a-z_]+)\}`)
+)
+
+// FieldMap resolves logical fields to the columns of one datasource
+type FieldMap struct {
+	columns map[string]string
+}
+
+// NewPostgreSQLFieldMap applies field_mapping.postgresql to the standard columns
+func NewPostgreSQLFieldMap(overrides map[string]string) (FieldMap, error) {
+	return newFieldMap("postgresql", postgresFieldDefaults, overrides)
+}
+
+// NewClickHouseFieldMap applies field_mapping.clickhouse to the standard columns
+func NewClickHouseFieldMap(overrides map[string]string) (FieldMap, error) {
+	return newFieldMap("clickhouse", clickHouseFieldDefaults, overrides)
+}
+
+func newFieldMap(datasource string, defaults, overrides map[string]string) (FieldMap, error) {
+	columns := make(map[string]string, len(defaults))
+	for field, column := range defaults {
+		columns[field] = column
+	}
+	for field, column := range overrides {
+		if _, ok := defaults[field]; !ok {
+			known := make([]string, 0, len(defaults))
+			for name := range defaults {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return FieldMap{}, fmt.Errorf("field_mapping.%s: unknown field '%s' (known: %s)", datasource, field, strings.Join(known, ", "))
+		}
+		// Column names are spliced into SQL, so only plain identifiers are accepted
+		if !columnNamePattern.MatchString(column) {
+			return FieldMap{}, fmt.Errorf("field_mapping.%s.%s: '%s' is not a valid column name", datasource, field, column)
+		}
+		columns[field] = column
+	}
+	return FieldMap{columns: columns}, nil
+}
+
+// Column returns the column of a logical field
+func (m FieldMap) Column(field string) string {
+	if column, ok := m.columns[field]; ok {
+		return column
+	}
+	return field
+}
+
+// FieldColumn returns the PostgreSQL column of a logical field under field_mapping.postgresql
+func (s *PostgreSQLService) FieldColumn(field string) string {
+	return s.fields.Column(field)
+}
+
+// Expand replaces {field} and {alias.field} placeholders in a query with the mapped columns.
+// Placeholders that are not logical fields are left as they are.
+func (m FieldMap) Expand(query string) string {
+	return fieldPlaceholderPattern.ReplaceAllStringFunc(query, func(placeholder string) string {
+		parts := fieldPlaceholderPattern.FindStringSubmatch(placeholder)
+		column, ok := m.columns[parts[2]]
+		if !ok {
+			return placeholder
+		}
+		if parts[1] != "" {
+			return parts[1] + "." + column
+		}
+		return column
+	})
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"smlgoapi/models"
+)
+
+// DependencyCheck describes a single health probe
+type DependencyCheck struct {
+	Name     string
+	Critical bool // a critical dependency being down makes the service unhealthy
+	BudgetMs int  // latency budget; 0 disables the "slow" state
+	Check    func(ctx context.Context) (details string, err error)
+}
+
+// RunHealthChecks runs all checks in parallel, each bounded by timeout, preserving the input order
+func RunHealthChecks(ctx context.Context, checks []DependencyCheck, timeout time.Duration) []models.DependencyHealth {
+	results := make([]models.DependencyHealth, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check DependencyCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := check.Check(checkCtx)
+			latency := float64(time.Since(start).Microseconds()) / 1000
+
+			result := models.DependencyHealth{
+				Name:      check.Name,
+				Status:    models.DependencyStatusUp,
+				Critical:  check.Critical,
+				LatencyMs: latency,
+				BudgetMs:  check.BudgetMs,
+				Details:   details,
+			}
+			if err != nil {
+				result.Status = models.DependencyStatusDown
+				result.Error = err.Error()
+			} else if check.BudgetMs > 0 && latency > float64(check.BudgetMs) {
+				result.Status = models.DependencyStatusSlow
+			}
+			results[i] = result
+		}(i, check)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// OverallHealthStatus derives the service status: a critical dependency down is unhealthy,
+// any other failure or budget overrun is degraded
+func OverallHealthStatus(dependencies []models.DependencyHealth) string {
+	status := models.HealthStatusHealthy
+	for _, dep := range dependencies {
+		if dep.Status == models.DependencyStatusDown && dep.Critical {
+			return models.HealthStatusUnhealthy
+		}
+		if dep.Status != models.DependencyStatusUp {
+			status = models.HealthStatusDegraded
+		}
+	}
+	return status
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hybrid search sources
+const (
+	HybridSourceBM25  = "bm25"  // Weaviate BM25
+	HybridSourceTFIDF = "tfidf" // in-memory TF-IDF index over ClickHouse products
+	HybridSourceSQL   = "sql"   // PostgreSQL ILIKE on code and name
+)
+
+// DefaultRRFK is the rank constant of reciprocal rank fusion. Larger values flatten the
+// difference between the top ranks of a source.
+const DefaultRRFK = 60
+
+// HybridSources lists the sources in the order they are reported
+var HybridSources = []string{HybridSourceBM25, HybridSourceTFIDF, HybridSourceSQL}
+
+// IsValidHybridSource reports whether name is a hybrid search source
+func IsValidHybridSource(name string) bool {
+	for _, source := range HybridSources {
+		if source == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RankedCode is one product code returned by a source, best first
+type RankedCode struct {
+	Code  string
+	Score float64 // the source's own score
+}
+
+// HybridRanker returns the best matching product codes of one source
+type HybridRanker func(ctx context.Context, query string, limit int) ([]RankedCode, error)
+
+// HybridSourceScore is how one source ranked a product
+type HybridSourceScore struct {
+	Rank  int     `json:"rank"`  // 1-based
+	Score float64 `json:"score"` // the source's own score
+	RRF   float64 `json:"rrf"`   // weight / (k + rank)
+}
+
+// HybridMatch is a product with its fused score
+type HybridMatch struct {
+	Code    string
+	Score   float64
+	Sources map[string]HybridSourceScore
+}
+
+// HybridSourceStatus reports how one source did for a request
+type HybridSourceStatus struct {
+	Source     string  `json:"source"`
+	Weight     float64 `json:"weight"`
+	Count      int     `json:"count"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// HybridSearchResult is a product with its fused score and the per-source ranks behind it
+type HybridSearchResult struct {
+	SearchResult
+	HybridScore float64                      `json:"hybrid_score"`
+	Sources     map[string]HybridSourceScore `json:"sources"`
+}
+
+// HybridSearchResponse is the response of /v1/search/hybrid
+type HybridSearchResponse struct {
+	Data     []HybridSearchResult `json:"data"`
+	Query    string               `json:"query"`
+	Duration float64              `json:"duration_ms"`
+	K        int                  `json:"k"`
+	Sources  []HybridSourceStatus `json:"sources"`
+	Partial  bool                 `json:"partial,omitempty"` // a requested source failed
+
+	SuggestedQuery string `json:"suggested_query,omitempty"` // corrected query offered when nothing matched
+}
+
+// RunHybridSources runs the rankers in parallel. A failing source is reported in its status and
+// contributes no results; the other sources are unaffected.
+func RunHybridSources(ctx context.Context, query string, depth int, rankers map[string]HybridRanker, weights map[string]float64) (map[string][]RankedCode, []HybridSourceStatus) {
+	lists := make(map[string][]RankedCode, len(rankers))
+	var statuses []HybridSourceStatus
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, source := range HybridSources {
+		ranker, ok := rankers[source]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(source string, ranker HybridRanker) {
+			defer wg.Done()
+			start := time.Now()
+			var codes []RankedCode
+			var err error
+			if ranker == nil {
+				err = fmt.Errorf("%s source is not available", source)
+			} else {
+				codes, err = ranker(ctx, query, depth)
+			}
+
+			status := HybridSourceStatus{
+				Source:     source,
+				Weight:     weights[source],
+				Count:      len(codes),
+				DurationMs: time.Since(start).Seconds() * 1000,
+			}
+			if err != nil {
+				status.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				lists[source] = codes
+			}
+			statuses = append(statuses, status)
+		}(source, ranker)
+	}
+	wg.Wait()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return hybridSourceIndex(statuses[i].Source) < hybridSourceIndex(statuses[j].Source)
+	})
+	return lists, statuses
+}
+
+func hybridSourceIndex(source string) int {
+	for i, s := range HybridSources {
+		if s == source {
+			return i
+		}
+	}
+	return len(HybridSources)
+}
+
+// FuseRRF merges ranked lists with reciprocal rank fusion: each product scores the sum of
+// weight/(k+rank) over the sources that returned it. A code listed twice by one source counts
+// at its best rank. Ties are broken by code so the order is stable.
+func FuseRRF(lists map[string][]RankedCode, weights map[string]float64, k int) []HybridMatch {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+
+	matches := make(map[string]*HybridMatch)
+	for source, list := range lists {
+		weight, ok := weights[source]
+		if !ok {
+			weight = 1
+		}
+		rank := 0
+		for _, item := range list {
+			code := strings.TrimSpace(item.Code)
+			if code == "" {
+				continue
+			}
+			match := matches[code]
+			if match == nil {
+				match = &HybridMatch{Code: code, Sources: make(map[string]HybridSourceScore)}
+				matches[code] = match
+			}
+			if _, seen := match.Sources[source]; seen {
+				continue
+			}
+			rank++
+			rrf := weight / float64(k+rank)
+			match.Sources[source] = HybridSourceScore{Rank: rank, Score: item.Score, RRF: rrf}
+			match.Score += rrf
+		}
+	}
+
+	fused := make([]HybridMatch, 0, len(matches))
+	for _, match := range matches {
+		fused = append(fused, *match)
+	}
+	sort.Slice(fused, func(i, j int) bool {
+		if fused[i].Score != fused[j].Score {
+			return fused[i].Score > fused[j].Score
+		}
+		return fused[i].Code < fused[j].Code
+	})
+	return fused
+}
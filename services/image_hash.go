@@ -0,0 +1,155 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math/bits"
+	"sort"
+
+	// Decoders of the formats accepted for product images
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// dHash compares neighbouring cells of a 9x8 grayscale thumbnail, one bit per comparison
+const (
+	dHashWidth  = 9
+	dHashHeight = 8
+)
+
+// dHashSamples bounds the pixels read per axis, so hashing a large photo costs the same as a small one
+const dHashSamples = 288
+
+// ImageHash returns the difference hash (dHash) of an encoded JPEG, PNG, GIF or WebP image.
+// Resizing, recompression and small color changes leave it within a few bits of the original.
+func ImageHash(data []byte) (uint64, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return 0, fmt.Errorf("image is empty")
+	}
+
+	// Average the luminance of every cell over a sampled grid of pixels
+	var sum [dHashHeight][dHashWidth]float64
+	var count [dHashHeight][dHashWidth]int
+	stepX, stepY := max(1, width/dHashSamples), max(1, height/dHashSamples)
+	for y := 0; y < height; y += stepY {
+		row := y * dHashHeight / height
+		for x := 0; x < width; x += stepX {
+			col := x * dHashWidth / width
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			sum[row][col] += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			count[row][col]++
+		}
+	}
+
+	var hash uint64
+	for row := 0; row < dHashHeight; row++ {
+		for col := 0; col < dHashWidth-1; col++ {
+			hash <<= 1
+			if cellMean(sum[row][col], count[row][col]) > cellMean(sum[row][col+1], count[row][col+1]) {
+				hash |= 1
+			}
+		}
+	}
+	return hash, nil
+}
+
+func cellMean(sum float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// HammingDistance counts the bits in which two image hashes differ
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// hashedImage is an image taking part in duplicate detection
+type hashedImage struct {
+	index int // position in the caller's slice
+	code  string
+	hash  uint64
+}
+
+// duplicateCluster is a set of images joined by pairs within the distance
+type duplicateCluster struct {
+	indexes  []int
+	distance int
+}
+
+// clusterDuplicates joins images whose hashes are at most maxDistance apart, transitively, and
+// returns the clusters with images of more than one product. Every pair is compared, which takes
+// about a second for 50,000 images.
+func clusterDuplicates(images []hashedImage, maxDistance int) []duplicateCluster {
+	parent := make([]int, len(images))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	distance := make(map[int]int)
+	for i := range images {
+		for j := i + 1; j < len(images); j++ {
+			d := HammingDistance(images[i].hash, images[j].hash)
+			if d > maxDistance {
+				continue
+			}
+			ri, rj := find(i), find(j)
+			if ri != rj {
+				parent[rj] = ri
+				distance[ri] = max(distance[ri], distance[rj])
+			}
+			distance[ri] = max(distance[ri], d)
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range images {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+
+	var clusters []duplicateCluster
+	for root, group := range members {
+		codes := make(map[string]bool)
+		for _, i := range group {
+			codes[images[i].code] = true
+		}
+		if len(codes) < 2 {
+			continue
+		}
+		cluster := duplicateCluster{distance: distance[root]}
+		for _, i := range group {
+			cluster.indexes = append(cluster.indexes, images[i].index)
+		}
+		clusters = append(clusters, cluster)
+	}
+	// Closest duplicates first; larger groups first among equals
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].distance != clusters[j].distance {
+			return clusters[i].distance < clusters[j].distance
+		}
+		if len(clusters[i].indexes) != len(clusters[j].indexes) {
+			return len(clusters[i].indexes) > len(clusters[j].indexes)
+		}
+		return clusters[i].indexes[0] < clusters[j].indexes[0]
+	})
+	return clusters
+}
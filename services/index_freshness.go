@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"smlgoapi/config"
+)
+
+// Search indexes reported by the freshness check
+const (
+	IndexWeaviate = "weaviate"
+	IndexTFIDF    = "tfidf"
+)
+
+// IndexFreshness reports how far a search index lags behind PostgreSQL
+type IndexFreshness struct {
+	Index     string     `json:"index"`
+	Available bool       `json:"available"`
+	LastSync  *time.Time `json:"last_sync,omitempty"` // last successful sync, or when the TF-IDF index was built
+	LastMode  string     `json:"last_mode,omitempty"`
+	// AgeSeconds is the time since LastSync
+	AgeSeconds float64 `json:"age_seconds"`
+	// PendingChanges counts ic_inventory rows changed since the last sync (Weaviate only)
+	PendingChanges *int `json:"pending_changes,omitempty"`
+	// OldestPending is the change time of the oldest pending row, known with weaviate.sync.updated_at_column
+	OldestPending *time.Time             `json:"oldest_pending,omitempty"`
+	LagSeconds    float64                `json:"lag_seconds"` // how long the oldest pending change has waited
+	Watermark     *WeaviateSyncWatermark `json:"watermark,omitempty"`
+	Documents     int                    `json:"documents,omitempty"` // TF-IDF documents
+	Stale         bool                   `json:"stale"`
+	Reasons       []string               `json:"reasons,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+}
+
+// IndexFreshnessReport is the response of /v1/admin/index-freshness
+type IndexFreshnessReport struct {
+	CheckedAt time.Time        `json:"checked_at"`
+	Stale     bool             `json:"stale"`
+	Indexes   []IndexFreshness `json:"indexes"`
+}
+
+// Freshness compares the recorded watermark of the Product class with ic_inventory
+func (s *WeaviateSyncService) Freshness(ctx context.Context, cfg config.IndexFreshnessConfig) IndexFreshness {
+	now := time.Now()
+	report := IndexFreshness{Index: IndexWeaviate, Available: true}
+
+	var watermark WeaviateSyncWatermark
+	var updatedAt sql.NullTime
+	var lastRun time.Time
+	err := s.pg.db.QueryRowContext(ctx,
+		`SELECT row_order_ref, updated_at, last_mode, last_run_at FROM sync_state WHERE name = $1`, productSyncStateName).
+		Scan(&watermark.RowOrderRef, &updatedAt, &report.LastMode, &lastRun)
+	if err == sql.ErrNoRows {
+		report.Stale = true
+		report.Reasons = append(report.Reasons, "no sync has completed; run a full sync")
+		return report
+	}
+	if err != nil {
+		report.Error = fmt.Sprintf("failed to read sync watermark: %v", err)
+		return report
+	}
+	if updatedAt.Valid {
+		watermark.UpdatedAt = &updatedAt.Time
+	}
+	report.Watermark = &watermark
+	report.LastSync = &lastRun
+	report.AgeSeconds = now.Sub(lastRun).Seconds()
+
+	pending, oldest, err := s.pg.inventoryChanges(ctx, &watermark, s.cfg.UpdatedAtColumn)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.PendingChanges = &pending
+	report.OldestPending = oldest
+
+	// Without a change time, a pending row has waited at most since the last sync
+	switch {
+	case oldest != nil:
+		report.LagSeconds = now.Sub(*oldest).Seconds()
+	case pending > 0:
+		report.LagSeconds = report.AgeSeconds
+	}
+
+	if pending > 0 && report.LagSeconds > float64(cfg.MaxLagSeconds) {
+		report.Stale = true
+		report.Reasons = append(report.Reasons, fmt.Sprintf("changes have waited %.0fs, over %ds", report.LagSeconds, cfg.MaxLagSeconds))
+	}
+	if cfg.MaxPendingChanges > 0 && pending > cfg.MaxPendingChanges {
+		report.Stale = true
+		report.Reasons = append(report.Reasons, fmt.Sprintf("%d changed rows pending, over %d", pending, cfg.MaxPendingChanges))
+	}
+	return report
+}
+
+// TFIDFFreshness reports the age of the in-memory TF-IDF index, which is built once from
+// ClickHouse and not refreshed while the process runs
+func TFIDFFreshness(vdb *TFIDFVectorDatabase, cfg config.IndexFreshnessConfig) IndexFreshness {
+	report := IndexFreshness{Index: IndexTFIDF, Available: vdb != nil}
+	if vdb == nil {
+		report.Error = "ClickHouse is not configured"
+		return report
+	}
+
+	documents, loadedAt := vdb.Stats()
+	if loadedAt == nil {
+		report.Reasons = append(report.Reasons, "not built yet; it is loaded on first use")
+		return report
+	}
+	report.Documents = documents
+	report.LastSync = loadedAt
+	report.AgeSeconds = time.Since(*loadedAt).Seconds()
+	report.LagSeconds = report.AgeSeconds
+	if report.AgeSeconds > float64(cfg.TFIDFMaxAgeSeconds) {
+		report.Stale = true
+		report.Reasons = append(report.Reasons, fmt.Sprintf("built %.0fs ago, over %ds; restart to rebuild", report.AgeSeconds, cfg.TFIDFMaxAgeSeconds))
+	}
+	return report
+}
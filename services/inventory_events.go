@@ -0,0 +1,448 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+
+	"github.com/lib/pq"
+)
+
+// Modes of inventory_events.mode
+const (
+	InventoryEventsPoll   = "poll"
+	InventoryEventsNotify = "notify"
+)
+
+// inventoryNotifyTrigger is installed on ic_balance and ic_inventory_price_formula in notify mode
+const inventoryNotifyTrigger = "smlgoapi_inventory_notify"
+
+// inventoryNotifyDebounce collects the notifications of one write burst into a single query
+const inventoryNotifyDebounce = 200 * time.Millisecond
+
+// inventorySubscriberBuffer is how many events a slow stream may fall behind before it is closed
+const inventorySubscriberBuffer = 256
+
+// inventoryState is the last known total balance and price tiers of one product
+type inventoryState struct {
+	balance    float64
+	hasBalance bool
+	prices     [5]float64
+	hasPrices  bool
+}
+
+// InventorySubscription receives the inventory events published after it subscribed. Events is
+// closed when the subscriber falls too far behind or the service closes.
+type InventorySubscription struct {
+	Events <-chan models.InventoryEvent
+	events chan models.InventoryEvent
+}
+
+// InventoryEventService detects stock balance and price changes, either by diffing ic_balance and
+// ic_inventory_price_formula every poll interval or by LISTEN/NOTIFY on triggers it installs, and
+// publishes them to the /v1/events streams. Recent events are kept so a reconnecting stream can
+// resume from its Last-Event-ID.
+type InventoryEventService struct {
+	pg  *PostgreSQLService
+	cfg config.InventoryEventsConfig
+
+	mu     sync.Mutex
+	state  map[string]inventoryState // nil until the first full load
+	nextID int64
+	recent []models.InventoryEvent // oldest first, at most cfg.Buffer
+	subs   map[*InventorySubscription]struct{}
+	hooks  []func(models.InventoryEvent)
+	closed bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func NewInventoryEventService(pg *PostgreSQLService, cfg config.InventoryEventsConfig) *InventoryEventService {
+	return &InventoryEventService{
+		pg:     pg,
+		cfg:    cfg,
+		nextID: 1,
+		subs:   map[*InventorySubscription]struct{}{},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// EnsureSchema installs the NOTIFY triggers in notify mode; poll mode changes nothing
+func (s *InventoryEventService) EnsureSchema(ctx context.Context) error {
+	if s.cfg.Mode != InventoryEventsNotify {
+		return nil
+	}
+
+	function := fmt.Sprintf(`CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		PERFORM pg_notify(TG_ARGV[0], CAST(OLD.ic_code AS TEXT));
+	ELSE
+		PERFORM pg_notify(TG_ARGV[0], CAST(NEW.ic_code AS TEXT));
+	END IF;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql`, inventoryNotifyTrigger)
+	if _, err := s.pg.db.ExecContext(ctx, function); err != nil {
+		return fmt.Errorf("failed to create trigger function: %w", err)
+	}
+
+	for _, table := range []string{"ic_balance", "ic_inventory_price_formula"} {
+		exists, err := s.tableExists(ctx, table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.Printf("⚠️ [inventory-events] Table '%s' not found - its changes are not notified", table)
+			continue
+		}
+		statements := []string{
+			fmt.Sprintf(`DROP TRIGGER IF EXISTS %s ON %s`, inventoryNotifyTrigger, table),
+			fmt.Sprintf(`CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE PROCEDURE %s(%s)`,
+				inventoryNotifyTrigger, table, inventoryNotifyTrigger, pq.QuoteLiteral(s.cfg.Channel)),
+		}
+		for _, statement := range statements {
+			if _, err := s.pg.db.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to install trigger on %s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Start loads the current balances and prices and begins watching them for changes
+func (s *InventoryEventService) Start() {
+	go s.run()
+}
+
+// Close stops watching and closes every subscription, so open streams end
+func (s *InventoryEventService) Close() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		close(s.stop)
+		s.mu.Lock()
+		s.closed = true
+		for sub := range s.subs {
+			close(sub.events)
+			delete(s.subs, sub)
+		}
+		s.mu.Unlock()
+	})
+	<-s.done
+}
+
+// Subscribe returns a subscription to new events. With lastID > 0 it also returns the kept events
+// after lastID; complete is false when some of them are no longer kept, so the caller has missed
+// changes and should reload what it shows.
+func (s *InventoryEventService) Subscribe(lastID int64) (sub *InventorySubscription, replay []models.InventoryEvent, complete bool) {
+	events := make(chan models.InventoryEvent, inventorySubscriberBuffer)
+	sub = &InventorySubscription{Events: events, events: events}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(events)
+		return sub, nil, true
+	}
+	s.subs[sub] = struct{}{}
+
+	complete = true
+	if lastID > 0 {
+		// Event ids are consecutive, so the kept events cover lastID+1 onwards only if the
+		// oldest one is at most lastID+1. An id not issued yet comes from before a restart.
+		if lastID+1 < s.oldestIDLocked() || lastID >= s.nextID {
+			complete = false
+		}
+		for _, event := range s.recent {
+			if event.ID > lastID {
+				replay = append(replay, event)
+			}
+		}
+	}
+	return sub, replay, complete
+}
+
+// OnEvent calls fn with every event as it is published, e.g. to trigger webhooks. fn runs while
+// events are being published and must not block.
+func (s *InventoryEventService) OnEvent(fn func(models.InventoryEvent)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, fn)
+}
+
+// Unsubscribe stops delivering events to sub
+func (s *InventoryEventService) Unsubscribe(sub *InventorySubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[sub]; ok {
+		close(sub.events)
+		delete(s.subs, sub)
+	}
+}
+
+func (s *InventoryEventService) oldestIDLocked() int64 {
+	if len(s.recent) == 0 {
+		return s.nextID
+	}
+	return s.recent[0].ID
+}
+
+func (s *InventoryEventService) run() {
+	defer close(s.done)
+	if s.cfg.Mode == InventoryEventsNotify {
+		s.listen()
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(s.cfg.PollIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		s.check(nil)
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// listen follows the trigger notifications, each carrying an ic_code. Notifications sent while
+// the connection was down are lost, so every (re)connect starts with a full check.
+func (s *InventoryEventService) listen() {
+	listener := pq.NewListener(s.pg.config.GetPostgreSQLDSN(), 10*time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Printf("⚠️ [inventory-events] Listener: %v", err)
+			}
+		})
+	defer listener.Close()
+	if err := listener.Listen(s.cfg.Channel); err != nil {
+		log.Printf("❌ [inventory-events] Failed to LISTEN on '%s': %v", s.cfg.Channel, err)
+		return
+	}
+	log.Printf("📣 [inventory-events] Listening on '%s'", s.cfg.Channel)
+	s.check(nil)
+
+	pending := map[string]struct{}{}
+	var debounce <-chan time.Time
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case notification := <-listener.Notify:
+			if notification == nil {
+				// The connection was re-established
+				s.check(nil)
+				continue
+			}
+			if code := strings.TrimSpace(notification.Extra); code != "" {
+				pending[code] = struct{}{}
+			}
+			if debounce == nil {
+				debounce = time.After(inventoryNotifyDebounce)
+			}
+		case <-debounce:
+			codes := make([]string, 0, len(pending))
+			for code := range pending {
+				codes = append(codes, code)
+			}
+			pending = map[string]struct{}{}
+			debounce = nil
+			s.check(codes)
+		case <-ping.C:
+			go listener.Ping()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// check loads the balances and prices of codes, or of every product when codes is nil, and
+// publishes an event for each one that changed
+func (s *InventoryEventService) check(codes []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Changes can only be told apart from a baseline of every product
+	s.mu.Lock()
+	if s.state == nil {
+		codes = nil
+	}
+	s.mu.Unlock()
+
+	current, err := s.load(ctx, codes)
+	if err != nil {
+		log.Printf("❌ [inventory-events] Check failed: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil {
+		// The first full load is the baseline; nothing has changed yet
+		s.state = current
+		log.Printf("📣 [inventory-events] Watching %d products", len(current))
+		return
+	}
+
+	now := time.Now()
+	checked := codes
+	if codes == nil {
+		checked = make([]string, 0, len(current)+len(s.state))
+		for code := range current {
+			checked = append(checked, code)
+		}
+		for code := range s.state {
+			if _, ok := current[code]; !ok {
+				checked = append(checked, code)
+			}
+		}
+	}
+	for _, code := range checked {
+		before, after := s.state[code], current[code]
+		if before.hasBalance != after.hasBalance || before.balance != after.balance {
+			previous := before.balance
+			s.publishLocked(models.InventoryEvent{
+				Type:               models.InventoryEventBalance,
+				ICCode:             code,
+				BalanceQty:         &after.balance,
+				PreviousBalanceQty: &previous,
+				ChangedAt:          now,
+			})
+		}
+		if before.hasPrices != after.hasPrices || before.prices != after.prices {
+			event := models.InventoryEvent{Type: models.InventoryEventPrice, ICCode: code, Prices: []float64{}, ChangedAt: now}
+			if after.hasPrices {
+				event.Prices = after.prices[:]
+			}
+			if before.hasPrices {
+				event.PreviousPrices = before.prices[:]
+			}
+			s.publishLocked(event)
+		}
+		if after.hasBalance || after.hasPrices {
+			s.state[code] = after
+		} else {
+			delete(s.state, code)
+		}
+	}
+}
+
+// publishLocked numbers the event, keeps it for resuming streams and hands it to every
+// subscriber. A subscriber whose buffer is full is closed rather than slowing down the others;
+// it resumes from the kept events when it reconnects.
+func (s *InventoryEventService) publishLocked(event models.InventoryEvent) {
+	event.ID = s.nextID
+	s.nextID++
+	s.recent = append(s.recent, event)
+	if len(s.recent) > s.cfg.Buffer {
+		s.recent = s.recent[len(s.recent)-s.cfg.Buffer:]
+	}
+
+	for _, hook := range s.hooks {
+		hook(event)
+	}
+	for sub := range s.subs {
+		select {
+		case sub.events <- event:
+		default:
+			close(sub.events)
+			delete(s.subs, sub)
+		}
+	}
+}
+
+// load reads the total balance and price tiers of codes, or of every product when codes is nil
+func (s *InventoryEventService) load(ctx context.Context, codes []string) (map[string]inventoryState, error) {
+	state := map[string]inventoryState{}
+	filter, params := "", []interface{}{}
+	if codes != nil {
+		if len(codes) == 0 {
+			return state, nil
+		}
+		filter, params = " AND ic_code = ANY($1)", []interface{}{pq.Array(codes)}
+	}
+
+	if exists, err := s.tableExists(ctx, "ic_balance"); err != nil {
+		return nil, err
+	} else if exists {
+		rows, err := s.pg.db.QueryContext(ctx, `
+			SELECT CAST(ic_code AS TEXT), COALESCE(SUM(balance_qty), 0)
+			FROM ic_balance
+			WHERE ic_code IS NOT NULL AND ic_code != ''`+filter+`
+			GROUP BY ic_code`, params...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load balances: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var code string
+			var qty float64
+			if err := rows.Scan(&code, &qty); err != nil {
+				return nil, fmt.Errorf("failed to scan balance: %w", err)
+			}
+			entry := state[code]
+			entry.balance, entry.hasBalance = qty, true
+			state[code] = entry
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("balance rows iteration error: %w", err)
+		}
+	}
+
+	if exists, err := s.tableExists(ctx, "ic_inventory_price_formula"); err != nil {
+		return nil, err
+	} else if exists {
+		rows, err := s.pg.db.QueryContext(ctx, `
+			SELECT CAST(ic_code AS TEXT),
+			       COALESCE(CAST(price_0 AS TEXT), '0'), COALESCE(CAST(price_1 AS TEXT), '0'),
+			       COALESCE(CAST(price_2 AS TEXT), '0'), COALESCE(CAST(price_3 AS TEXT), '0'),
+			       COALESCE(CAST(price_4 AS TEXT), '0')
+			FROM ic_inventory_price_formula
+			WHERE ic_code IS NOT NULL AND ic_code != ''`+filter, params...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load prices: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var code string
+			var tiers [5]string
+			if err := rows.Scan(&code, &tiers[0], &tiers[1], &tiers[2], &tiers[3], &tiers[4]); err != nil {
+				return nil, fmt.Errorf("failed to scan price: %w", err)
+			}
+			// Like LoadPriceFormula, the last row of a product wins and unparsable prices are 0
+			entry := state[code]
+			for i, tier := range tiers {
+				entry.prices[i], _ = strconv.ParseFloat(strings.TrimSpace(tier), 64)
+			}
+			entry.hasPrices = true
+			state[code] = entry
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("price rows iteration error: %w", err)
+		}
+	}
+	return state, nil
+}
+
+func (s *InventoryEventService) tableExists(ctx context.Context, table string) (bool, error) {
+	var exists bool
+	err := s.pg.db.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, "public."+table).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to check table %s: %w", table, err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"smlgoapi/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrNamedQueryNotFound is returned for names nobody registered
+var ErrNamedQueryNotFound = errors.New("named query not found")
+
+// ErrInvalidNamedQuery is returned when a template cannot be registered as given
+var ErrInvalidNamedQuery = errors.New("invalid named query")
+
+// ErrInvalidNamedQueryArgs is returned when the arguments of a call do not fit the parameters
+var ErrInvalidNamedQueryArgs = errors.New("invalid arguments")
+
+// Databases a named query can run on
+const (
+	NamedQueryPostgreSQL = "postgresql"
+	NamedQueryClickHouse = "clickhouse"
+)
+
+// namedQueryCacheTTL bounds how long another instance keeps running a template that was replaced
+const namedQueryCacheTTL = 30 * time.Second
+
+var namedQueryNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+type cachedNamedQuery struct {
+	query     *models.NamedQuery
+	expiresAt time.Time
+}
+
+// NamedQueryService stores the SQL templates of /v1/query/{name} in the PostgreSQL named_queries
+// table and turns call arguments into the positional parameters of a template
+type NamedQueryService struct {
+	postgreSQLService *PostgreSQLService
+
+	mu    sync.RWMutex
+	cache map[string]cachedNamedQuery
+}
+
+// NewNamedQueryService creates a new named query service
+func NewNamedQueryService(postgreSQLService *PostgreSQLService) *NamedQueryService {
+	return &NamedQueryService{
+		postgreSQLService: postgreSQLService,
+		cache:             make(map[string]cachedNamedQuery),
+	}
+}
+
+// EnsureSchema creates the named_queries table if it does not exist
+func (s *NamedQueryService) EnsureSchema(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS named_queries (
+			name        TEXT PRIMARY KEY,
+			description TEXT NOT NULL DEFAULT '',
+			database    TEXT NOT NULL,
+			query       TEXT NOT NULL,
+			params      JSONB NOT NULL DEFAULT '[]',
+			created_by  TEXT NOT NULL DEFAULT '',
+			created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`
+
+	if _, err := s.postgreSQLService.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create named_queries table: %w", err)
+	}
+	return nil
+}
+
+// Save registers req under name, replacing an earlier template of that name
+func (s *NamedQueryService) Save(ctx context.Context, name string, req models.NamedQueryRequest, createdBy string) (*models.NamedQuery, error) {
+	if err := CheckNamedQuery(name, req); err != nil {
+		return nil, err
+	}
+	if req.Params == nil {
+		req.Params = []models.NamedQueryParam{}
+	}
+	params, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, fmt.Errorf("%w: params: %v", ErrInvalidNamedQuery, err)
+	}
+
+	query := `
+		INSERT INTO named_queries (name, description, database, query, params, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (name) DO UPDATE SET
+			description = EXCLUDED.description,
+			database    = EXCLUDED.database,
+			query       = EXCLUDED.query,
+			params      = EXCLUDED.params,
+			updated_at  = now()
+		RETURNING name, description, database, query, params, created_by, created_at, updated_at`
+
+	saved, err := scanNamedQuery(s.postgreSQLService.db.QueryRowContext(ctx, query,
+		name, req.Description, req.Database, req.Query, string(params), createdBy))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save named query: %w", err)
+	}
+
+	s.forget(name)
+	return saved, nil
+}
+
+// Get returns the template registered under name, cached for namedQueryCacheTTL
+func (s *NamedQueryService) Get(ctx context.Context, name string) (*models.NamedQuery, error) {
+	s.mu.RLock()
+	cached, ok := s.cache[name]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.query, nil
+	}
+
+	query := `
+		SELECT name, description, database, query, params, created_by, created_at, updated_at
+		FROM named_queries
+		WHERE name = $1`
+
+	namedQuery, err := scanNamedQuery(s.postgreSQLService.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, ErrNamedQueryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load named query: %w", err)
+	}
+
+	s.mu.Lock()
+	s.cache[name] = cachedNamedQuery{query: namedQuery, expiresAt: time.Now().Add(namedQueryCacheTTL)}
+	s.mu.Unlock()
+	return namedQuery, nil
+}
+
+// List returns every template by name
+func (s *NamedQueryService) List(ctx context.Context) ([]models.NamedQuery, error) {
+	query := `
+		SELECT name, description, database, query, params, created_by, created_at, updated_at
+		FROM named_queries
+		ORDER BY name`
+
+	rows, err := s.postgreSQLService.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list named queries: %w", err)
+	}
+	defer rows.Close()
+
+	queries := []models.NamedQuery{}
+	for rows.Next() {
+		namedQuery, err := scanNamedQuery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan named query: %w", err)
+		}
+		queries = append(queries, *namedQuery)
+	}
+	return queries, rows.Err()
+}
+
+// Delete removes the template registered under name
+func (s *NamedQueryService) Delete(ctx context.Context, name string) error {
+	result, err := s.postgreSQLService.db.ExecContext(ctx, `DELETE FROM named_queries WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete named query: %w", err)
+	}
+	s.forget(name)
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNamedQueryNotFound
+	}
+	return nil
+}
+
+func (s *NamedQueryService) forget(name string) {
+	s.mu.Lock()
+	delete(s.cache, name)
+	s.mu.Unlock()
+}
+
+func scanNamedQuery(row rowScanner) (*models.NamedQuery, error) {
+	var namedQuery models.NamedQuery
+	var params []byte
+	if err := row.Scan(&namedQuery.Name, &namedQuery.Description, &namedQuery.Database, &namedQuery.Query, &params,
+		&namedQuery.CreatedBy, &namedQuery.CreatedAt, &namedQuery.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(params, &namedQuery.Params); err != nil {
+		return nil, fmt.Errorf("params of %s: %w", namedQuery.Name, err)
+	}
+	return &namedQuery, nil
+}
+
+// CheckNamedQuery validates a template before it is stored: one SELECT, known parameter types,
+// defaults of the declared type, and exactly one parameter per placeholder
+func CheckNamedQuery(name string, req models.NamedQueryRequest) error {
+	if !namedQueryNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: name must be 1-64 lower-case letters, digits, '_', '.' or '-'", ErrInvalidNamedQuery)
+	}
+	if req.Database != NamedQueryPostgreSQL && req.Database != NamedQueryClickHouse {
+		return fmt.Errorf("%w: database must be %s or %s", ErrInvalidNamedQuery, NamedQueryPostgreSQL, NamedQueryClickHouse)
+	}
+
+	statements := analyzeSQL(req.Database, req.Query)
+	switch {
+	case len(statements) != 1:
+		return fmt.Errorf("%w: query must hold exactly one statement, found %d", ErrInvalidNamedQuery, len(statements))
+	case statements[0].Type != "SELECT":
+		return fmt.Errorf("%w: query must be a SELECT, found %s", ErrInvalidNamedQuery, statements[0].Type)
+	case statements[0].modifiesData():
+		return fmt.Errorf("%w: query must not change data through a WITH query or SELECT ... INTO", ErrInvalidNamedQuery)
+	}
+
+	seen := make(map[string]bool, len(req.Params))
+	for _, param := range req.Params {
+		if param.Name == "" {
+			return fmt.Errorf("%w: every parameter needs a name", ErrInvalidNamedQuery)
+		}
+		if seen[param.Name] {
+			return fmt.Errorf("%w: parameter %s is declared twice", ErrInvalidNamedQuery, param.Name)
+		}
+		seen[param.Name] = true
+		if !namedQueryTypes[param.Type] {
+			return fmt.Errorf("%w: parameter %s has unknown type '%s'", ErrInvalidNamedQuery, param.Name, param.Type)
+		}
+		if param.Default != nil {
+			if _, err := namedQueryValue(param.Type, param.Default, false); err != nil {
+				return fmt.Errorf("%w: default of %s: %v", ErrInvalidNamedQuery, param.Name, err)
+			}
+		}
+	}
+
+	return checkNamedQueryPlaceholders(req.Database, req.Query, len(req.Params))
+}
+
+// checkNamedQueryPlaceholders makes sure every declared parameter is bound: PostgreSQL templates
+// must use each of $1..$n, ClickHouse templates must have n "?" placeholders
+func checkNamedQueryPlaceholders(database, query string, count int) error {
+	used := make(map[int]bool)
+	questionMarks := 0
+	for _, token := range tokenizeSQL(database, query) {
+		if token.kind != tokenPlaceholder {
+			continue
+		}
+		if token.text == "?" {
+			questionMarks++
+			continue
+		}
+		n, _ := strconv.Atoi(token.text[1:])
+		used[n] = true
+	}
+
+	if database == NamedQueryClickHouse {
+		if len(used) > 0 {
+			return fmt.Errorf("%w: ClickHouse templates use ? placeholders, not $n", ErrInvalidNamedQuery)
+		}
+		if questionMarks != count {
+			return fmt.Errorf("%w: query has %d placeholders but %d parameters are declared", ErrInvalidNamedQuery, questionMarks, count)
+		}
+		return nil
+	}
+
+	for n := range used {
+		if n < 1 || n > count {
+			return fmt.Errorf("%w: query uses $%d but %d parameters are declared", ErrInvalidNamedQuery, n, count)
+		}
+	}
+	for n := 1; n <= count; n++ {
+		if !used[n] {
+			return fmt.Errorf("%w: parameter %d is declared but $%d is not used", ErrInvalidNamedQuery, n, n)
+		}
+	}
+	return nil
+}
+
+// namedQueryTypes are the parameter types a template may declare
+var namedQueryTypes = map[string]bool{
+	models.NamedQueryString: true, models.NamedQueryInt: true, models.NamedQueryFloat: true,
+	models.NamedQueryBool: true, models.NamedQueryDate: true, models.NamedQueryTimestamp: true,
+	models.NamedQueryStringArray: true, models.NamedQueryIntArray: true, models.NamedQueryFloatArray: true,
+}
+
+// BindNamedQuery turns the arguments of a call into the positional parameters of q. Missing and
+// null arguments take the parameter's default, or bind NULL unless the parameter is required;
+// arguments the template does not declare are rejected so a misspelt name is not silently ignored.
+func BindNamedQuery(q *models.NamedQuery, args map[string]interface{}) ([]interface{}, error) {
+	declared := make(map[string]bool, len(q.Params))
+	for _, param := range q.Params {
+		declared[param.Name] = true
+	}
+	for name := range args {
+		if !declared[name] {
+			return nil, fmt.Errorf("%w: %s has no parameter %s", ErrInvalidNamedQueryArgs, q.Name, name)
+		}
+	}
+
+	forPostgres := q.Database == NamedQueryPostgreSQL
+	params := make([]interface{}, len(q.Params))
+	for i, param := range q.Params {
+		value := args[param.Name]
+		if value == nil {
+			value = param.Default
+		}
+		if value == nil {
+			if param.Required {
+				return nil, fmt.Errorf("%w: %s is required", ErrInvalidNamedQueryArgs, param.Name)
+			}
+			continue
+		}
+		bound, err := namedQueryValue(param.Type, value, forPostgres)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidNamedQueryArgs, param.Name, err)
+		}
+		params[i] = bound
+	}
+	return params, nil
+}
+
+// namedQueryValue converts a JSON-decoded value to the driver value of a parameter type
+func namedQueryValue(paramType string, value interface{}, forPostgres bool) (interface{}, error) {
+	if elemType, ok := strings.CutSuffix(paramType, "[]"); ok {
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an array, got %s", jsonTypeName(value))
+		}
+		var array interface{}
+		switch elemType {
+		case models.NamedQueryString:
+			array = make([]string, len(values))
+		case models.NamedQueryInt:
+			array = make([]int64, len(values))
+		default:
+			array = make([]float64, len(values))
+		}
+		for i, element := range values {
+			v, err := namedQueryValue(elemType, element, false)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			switch a := array.(type) {
+			case []string:
+				a[i] = v.(string)
+			case []int64:
+				a[i] = v.(int64)
+			case []float64:
+				a[i] = v.(float64)
+			}
+		}
+		if forPostgres {
+			return pq.Array(array), nil
+		}
+		return array, nil
+	}
+
+	switch paramType {
+	case models.NamedQueryString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case models.NamedQueryInt:
+		if f, ok := value.(float64); ok {
+			if f != math.Trunc(f) || math.Abs(f) > maxExactJSONInteger {
+				return nil, fmt.Errorf("%v is not an integer", f)
+			}
+			return int64(f), nil
+		}
+	case models.NamedQueryFloat:
+		if f, ok := value.(float64); ok {
+			return f, nil
+		}
+	case models.NamedQueryBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case models.NamedQueryDate, models.NamedQueryTimestamp:
+		s, ok := value.(string)
+		if !ok {
+			break
+		}
+		layout := time.RFC3339
+		if paramType == models.NamedQueryDate {
+			layout = "2006-01-02"
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a %s (%s)", s, paramType, layout)
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("expected %s, got %s", paramType, jsonTypeName(value))
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
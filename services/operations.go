@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrShuttingDown refuses new background work once the server drains, and is the cause of the
+// contexts cancelled when the drain period is over
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// OperationTracker tracks the long operations a shutdown waits for: imports, SQL statements,
+// Weaviate and table syncs, and job runs. Requests and background work run under Context, so
+// cancelling it after the drain period makes ClickHouse and PostgreSQL cancel their statements.
+type OperationTracker struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	mu       sync.Mutex
+	draining bool
+	ops      map[uint64]trackedOperation
+	lastID   uint64
+	idle     chan struct{} // closed when the last operation finished during Drain
+}
+
+type trackedOperation struct {
+	kind      string
+	name      string
+	startedAt time.Time
+}
+
+func NewOperationTracker() *OperationTracker {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	return &OperationTracker{ctx: ctx, cancel: cancel, ops: make(map[uint64]trackedOperation)}
+}
+
+// Context is the base context of requests and background operations. It ends when Cancel is
+// called. A nil tracker, as in the command-line tools, tracks nothing and never cancels.
+func (t *OperationTracker) Context() context.Context {
+	if t == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// Track registers an operation running as part of a request, which the server waits for anyway;
+// done must be called once it finished
+func (t *OperationTracker) Track(kind, name string) (done func()) {
+	if t == nil {
+		return func() {}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.add(kind, name)
+}
+
+// Begin registers an operation that is not part of a request, such as a job run. It returns
+// ErrShuttingDown once Drain was called; otherwise done must be called once the operation finished.
+func (t *OperationTracker) Begin(kind, name string) (done func(), err error) {
+	if t == nil {
+		return func() {}, nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.draining {
+		return nil, ErrShuttingDown
+	}
+	return t.add(kind, name), nil
+}
+
+// Go runs fn in the background as a tracked operation with Context. It returns ErrShuttingDown
+// without running fn once Drain was called.
+func (t *OperationTracker) Go(kind, name string, fn func(ctx context.Context)) error {
+	done, err := t.Begin(kind, name)
+	if err != nil {
+		return err
+	}
+	ctx := t.Context()
+	go func() {
+		defer done()
+		fn(ctx)
+	}()
+	return nil
+}
+
+// add registers an operation; t.mu must be held
+func (t *OperationTracker) add(kind, name string) func() {
+	t.lastID++
+	id := t.lastID
+	t.ops[id] = trackedOperation{kind: kind, name: name, startedAt: time.Now()}
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.ops, id)
+		if len(t.ops) == 0 && t.idle != nil {
+			close(t.idle)
+			t.idle = nil
+		}
+	}
+}
+
+// Drain refuses new background operations and waits until the running ones finished or ctx is
+// done. It returns the operations still running, the longest running first.
+func (t *OperationTracker) Drain(ctx context.Context) []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	t.draining = true
+	if len(t.ops) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return t.Running()
+	}
+}
+
+// Cancel ends Context, and with it every request and background operation still running
+func (t *OperationTracker) Cancel() {
+	if t != nil {
+		t.cancel(ErrShuttingDown)
+	}
+}
+
+// Running describes the operations in progress, e.g. "import products.csv (12s)"
+func (t *OperationTracker) Running() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	ops := make([]trackedOperation, 0, len(t.ops))
+	for _, op := range t.ops {
+		ops = append(ops, op)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(ops, func(i, k int) bool { return ops[i].startedAt.Before(ops[k].startedAt) })
+	running := make([]string, len(ops))
+	for i, op := range ops {
+		running[i] = fmt.Sprintf("%s %s (%s)", op.kind, op.name, time.Since(op.startedAt).Truncate(time.Second))
+	}
+	return running
+}
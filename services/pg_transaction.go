@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smlgoapi/models"
+)
+
+// MaxTransactionStatements caps the statements of one /v1/pgtransaction request
+const MaxTransactionStatements = 500
+
+// transactionControlKeywords would end or split the transaction the endpoint manages
+var transactionControlKeywords = map[string]bool{
+	"BEGIN": true, "START": true, "COMMIT": true, "END": true, "ROLLBACK": true, "ABORT": true,
+	"SAVEPOINT": true, "RELEASE": true, "PREPARE": true,
+}
+
+// rowReturningKeywords start statements that produce a result set
+var rowReturningKeywords = map[string]bool{"SELECT": true, "VALUES": true, "TABLE": true, "SHOW": true, "EXPLAIN": true}
+
+// TransactionStatement is one statement of a transaction with the values of its $1, $2, ... placeholders
+type TransactionStatement struct {
+	Query  string
+	Params []interface{}
+}
+
+// CheckTransactionStatement rejects entries that hold several statements or transaction control,
+// since the endpoint begins and ends the transaction itself
+func CheckTransactionStatement(query string) error {
+	statements := analyzeSQL(ServicePostgreSQL, query)
+	switch {
+	case len(statements) == 0:
+		return fmt.Errorf("statement is empty")
+	case len(statements) > 1:
+		return fmt.Errorf("holds %d statements; send each as its own entry", len(statements))
+	case transactionControlKeywords[statements[0].Type]:
+		return fmt.Errorf("%s is not allowed; the transaction is begun and committed by the endpoint", statements[0].Type)
+	}
+	return nil
+}
+
+// returnsRows reports whether a statement produces rows: queries and statements with RETURNING
+func returnsRows(query string) bool {
+	statements := analyzeSQL(ServicePostgreSQL, query)
+	if len(statements) == 1 && rowReturningKeywords[statements[0].Type] {
+		return true
+	}
+	for _, token := range tokenizeSQL(ServicePostgreSQL, query) {
+		if token.isKeyword("RETURNING") {
+			return true
+		}
+	}
+	return false
+}
+
+// ExecuteTransaction runs the statements in order in one transaction and commits only when every
+// one succeeds. The results cover the statements run so far; on failure the last one holds the
+// error, the transaction is rolled back and the error names the failed statement.
+func (s *PostgreSQLService) ExecuteTransaction(ctx context.Context, statements []TransactionStatement) ([]models.TransactionStatementResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	results := make([]models.TransactionStatementResult, 0, len(statements))
+	for i, statement := range statements {
+		start := time.Now()
+		result := models.TransactionStatementResult{Index: i}
+
+		if returnsRows(statement.Query) {
+			rows, err := tx.QueryContext(ctx, statement.Query, statement.Params...)
+			if err == nil {
+				result.Rows = []interface{}{}
+				var count int
+				count, err = scanRows(rows, collectRows(&result.Rows))
+				rows.Close()
+				affected := int64(count)
+				result.RowsAffected = &affected
+			}
+			if err != nil {
+				result.Error = err.Error()
+			}
+		} else if res, err := tx.ExecContext(ctx, statement.Query, statement.Params...); err != nil {
+			result.Error = err.Error()
+		} else if affected, err := res.RowsAffected(); err == nil {
+			result.RowsAffected = &affected
+		}
+
+		result.Duration = float64(time.Since(start).Nanoseconds()) / 1e6
+		results = append(results, result)
+		if result.Error != "" {
+			return results, fmt.Errorf("statement %d failed: %s", i, result.Error)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return results, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,450 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrUndoNotFound is returned for operations that do not exist or were pruned
+var ErrUndoNotFound = errors.New("undo operation not found")
+
+// ErrUndoRestored is returned when an operation has already been undone
+var ErrUndoRestored = errors.New("undo operation already restored")
+
+// ErrUndoUnsupported is returned for UPDATE and DELETE statements on an undo table whose rows
+// cannot be snapshotted; the statement is not run
+var ErrUndoUnsupported = errors.New("statement cannot be snapshotted for undo")
+
+// ErrUndoTooManyRows is returned when a statement would change more rows than undo.max_rows;
+// the statement is rolled back
+var ErrUndoTooManyRows = errors.New("statement affects too many rows to snapshot")
+
+// UndoService keeps the previous rows of UPDATE and DELETE statements sent to /v1/pgcommand on
+// the tables listed in undo.tables, so an admin can put them back. The rows are copied into
+// undo_rows in the same transaction as the statement, before it runs.
+type UndoService struct {
+	pg     *PostgreSQLService
+	cfg    config.UndoConfig
+	tables map[string]bool // by schema.table
+}
+
+// UndoPlan is an UPDATE or DELETE on an undo table, read by Plan and run by Execute
+type UndoPlan struct {
+	Statement string // UPDATE or DELETE
+	Table     string // schema.table
+	from      string // table reference as written, alias included
+	row       string // name of the row in the statement: the alias, else the table
+	where     string // condition as written, empty when every row is affected
+}
+
+// NewUndoService creates the undo service. Unqualified table names are in the public schema;
+// invalid names are logged and skipped.
+func NewUndoService(pg *PostgreSQLService, cfg config.UndoConfig) *UndoService {
+	tables := make(map[string]bool, len(cfg.Tables))
+	for _, name := range cfg.Tables {
+		qualified, ok := dataTableName(name)
+		if !ok {
+			log.Printf("⚠️ [undo] Ignoring invalid table name in undo.tables: %s", name)
+			continue
+		}
+		tables[qualified] = true
+	}
+	return &UndoService{pg: pg, cfg: cfg, tables: tables}
+}
+
+// EnsureSchema creates the undo_operations and undo_rows tables if they do not exist
+func (s *UndoService) EnsureSchema(ctx context.Context) error {
+	statements := []string{`
+		CREATE TABLE IF NOT EXISTS undo_operations (
+			id            TEXT PRIMARY KEY,
+			created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+			actor         TEXT NOT NULL DEFAULT '',
+			table_name    TEXT NOT NULL,
+			statement     TEXT NOT NULL,
+			sql_text      TEXT NOT NULL,
+			row_count     BIGINT NOT NULL DEFAULT 0,
+			restored_at   TIMESTAMPTZ,
+			restored_by   TEXT NOT NULL DEFAULT '',
+			restored_rows BIGINT
+		)`,
+		`CREATE INDEX IF NOT EXISTS undo_operations_created_at_idx ON undo_operations (created_at)`,
+		`CREATE TABLE IF NOT EXISTS undo_rows (
+			operation_id TEXT NOT NULL REFERENCES undo_operations (id) ON DELETE CASCADE,
+			row_data     JSONB NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS undo_rows_operation_idx ON undo_rows (operation_id)`,
+	}
+	for _, statement := range statements {
+		if _, err := s.pg.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to create undo tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// Plan returns the snapshot query needs, or nil when it changes no table listed in undo.tables.
+// It fails with ErrUndoUnsupported for statements on those tables it cannot snapshot: several
+// statements, WITH queries, MERGE, and UPDATE ... FROM or DELETE ... USING joins.
+func (s *UndoService) Plan(query string) (*UndoPlan, error) {
+	touched := ""
+	statements := analyzeSQL(ServicePostgreSQL, query)
+	for _, stmt := range statements {
+		// WITH d AS (DELETE FROM t ...) SELECT ... changes t whatever the main statement is
+		for _, cte := range stmt.CTEs {
+			if qualified, ok := dataTableName(cte.Table); ok && s.tables[qualified] && cte.Type != "INSERT" {
+				return nil, fmt.Errorf("%w: %s on %s inside a WITH query; send it as a single UPDATE or DELETE", ErrUndoUnsupported, cte.Type, qualified)
+			}
+		}
+		if stmt.Type == "MERGE" {
+			for _, table := range stmt.Tables {
+				if qualified, ok := dataTableName(table); ok && s.tables[qualified] {
+					return nil, fmt.Errorf("%w: MERGE on %s; send a single UPDATE or DELETE instead", ErrUndoUnsupported, qualified)
+				}
+			}
+		}
+		if stmt.Type != "UPDATE" && stmt.Type != "DELETE" {
+			continue
+		}
+		for _, table := range stmt.Tables {
+			if qualified, ok := dataTableName(table); ok && s.tables[qualified] {
+				touched = qualified
+			}
+		}
+	}
+	if touched == "" {
+		return nil, nil
+	}
+	if len(statements) > 1 {
+		return nil, fmt.Errorf("%w: send the UPDATE or DELETE on %s as a single statement", ErrUndoUnsupported, touched)
+	}
+
+	plan, err := parseUndoPlan(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUndoUnsupported, err)
+	}
+	if !s.tables[plan.Table] {
+		return nil, nil // the undo table is only read, e.g. in a subquery
+	}
+	return plan, nil
+}
+
+// Execute copies the rows plan is about to change into undo_rows and runs query, in one
+// transaction. The rows are locked while they are copied, so the statement changes exactly the
+// rows that were kept. The result has the shape of ExecuteCommand plus the undo operation id.
+func (s *UndoService) Execute(ctx context.Context, plan *UndoPlan, query, actor string) (map[string]interface{}, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate undo operation id: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+
+	tx, err := s.pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO undo_operations (id, actor, table_name, statement, sql_text)
+		VALUES ($1, $2, $3, $4, $5)`, id, actor, plan.Table, plan.Statement, query); err != nil {
+		return nil, fmt.Errorf("failed to store undo operation: %w", err)
+	}
+
+	snapshot := "INSERT INTO undo_rows (operation_id, row_data) SELECT $1, to_jsonb(" + plan.row + ".*) FROM " + plan.from
+	if plan.where != "" {
+		snapshot += " WHERE " + plan.where
+	}
+	snapshot += " FOR UPDATE OF " + plan.row
+	res, err := tx.ExecContext(ctx, snapshot, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot rows for undo: %w", err)
+	}
+	kept, _ := res.RowsAffected()
+	if kept > int64(s.cfg.MaxRows) {
+		return nil, fmt.Errorf("%w: %d rows of %s match, undo.max_rows is %d", ErrUndoTooManyRows, kept, plan.Table, s.cfg.MaxRows)
+	}
+
+	res, err = tx.ExecContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute command: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE undo_operations SET row_count = $2 WHERE id = $1`, id, kept); err != nil {
+		return nil, fmt.Errorf("failed to store undo operation: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	return map[string]interface{}{
+		"status":         "success",
+		"rows_affected":  affected,
+		"query":          query,
+		"undo_operation": id,
+	}, nil
+}
+
+// List returns a page of operations, newest first, and how many there are in total
+func (s *UndoService) List(ctx context.Context, limit, offset int) ([]models.UndoOperation, int, error) {
+	db := s.pg.db
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM undo_operations`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count undo operations: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, undoOperationColumns+`
+		ORDER BY created_at DESC, id
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query undo operations: %w", err)
+	}
+	defer rows.Close()
+
+	operations := []models.UndoOperation{}
+	for rows.Next() {
+		op, err := scanUndoOperation(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		operations = append(operations, *op)
+	}
+	return operations, total, rows.Err()
+}
+
+// Get returns an operation with up to limit of the rows it kept
+func (s *UndoService) Get(ctx context.Context, id string, limit int) (*models.UndoOperation, error) {
+	op, err := scanUndoOperation(s.pg.db.QueryRowContext(ctx, undoOperationColumns+` WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUndoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pg.db.QueryContext(ctx, `SELECT row_data FROM undo_rows WHERE operation_id = $1 LIMIT $2`, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query undo rows: %w", err)
+	}
+	defer rows.Close()
+	op.Snapshot = []json.RawMessage{}
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return nil, fmt.Errorf("failed to scan undo row: %w", err)
+		}
+		op.Snapshot = append(op.Snapshot, row)
+	}
+	return op, rows.Err()
+}
+
+// Restore puts back the rows an operation kept: deleted rows are inserted again and updated rows
+// get their previous values back, matched by primary key. An operation is restored at most once.
+func (s *UndoService) Restore(ctx context.Context, id, actor string) (*models.UndoOperation, error) {
+	tx, err := s.pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	op, err := scanUndoOperation(tx.QueryRowContext(ctx, undoOperationColumns+` WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUndoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if op.RestoredAt != nil {
+		return nil, fmt.Errorf("%w on %s by %q", ErrUndoRestored, op.RestoredAt.Format(time.RFC3339), op.RestoredBy)
+	}
+
+	schema, name, _ := strings.Cut(op.Table, ".")
+	table, err := s.pg.DescribeTable(ctx, schema, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", op.Table, err)
+	}
+	columns := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		columns[i] = column.Name
+	}
+	quotedTable := pq.QuoteIdentifier(schema) + "." + pq.QuoteIdentifier(name)
+	source := " FROM undo_rows u, jsonb_populate_record(NULL::" + quotedTable + ", u.row_data) r WHERE u.operation_id = $1"
+
+	var query string
+	switch op.Statement {
+	case "DELETE":
+		query = "INSERT INTO " + quotedTable + " (" + quoteColumns(columns) + ") SELECT " + prefixedColumns("r", columns) + source
+	default:
+		if len(table.PrimaryKey) == 0 {
+			return nil, fmt.Errorf("%s has no primary key to match the updated rows by", op.Table)
+		}
+		query = "UPDATE " + quotedTable + " t SET (" + quoteColumns(columns) + ") = ROW(" + prefixedColumns("r", columns) + ")" + source
+		for _, key := range table.PrimaryKey {
+			query += " AND t." + pq.QuoteIdentifier(key) + " = r." + pq.QuoteIdentifier(key)
+		}
+	}
+	res, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore rows of %s: %w", op.Table, err)
+	}
+	restored, _ := res.RowsAffected()
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE undo_operations SET restored_at = $2, restored_by = $3, restored_rows = $4 WHERE id = $1`,
+		id, now, actor, restored); err != nil {
+		return nil, fmt.Errorf("failed to store undo operation: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	op.RestoredAt, op.RestoredBy, op.RestoredRows = &now, actor, &restored
+	return op, nil
+}
+
+// Prune removes the operations older than undo.retention_days with the rows they kept
+func (s *UndoService) Prune(ctx context.Context) (string, error) {
+	cutoff := time.Now().AddDate(0, 0, -s.cfg.RetentionDays)
+	res, err := s.pg.db.ExecContext(ctx, `DELETE FROM undo_operations WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return "", fmt.Errorf("failed to prune undo_operations: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return fmt.Sprintf("removed %d undo operations older than %d days", n, s.cfg.RetentionDays), nil
+}
+
+const undoOperationColumns = `
+	SELECT id, created_at, actor, table_name, statement, sql_text, row_count, restored_at, restored_by, restored_rows
+	FROM undo_operations`
+
+func scanUndoOperation(row interface{ Scan(...interface{}) error }) (*models.UndoOperation, error) {
+	var op models.UndoOperation
+	var restoredAt sql.NullTime
+	var restoredRows sql.NullInt64
+	err := row.Scan(&op.ID, &op.Time, &op.Actor, &op.Table, &op.Statement, &op.SQL, &op.Rows,
+		&restoredAt, &op.RestoredBy, &restoredRows)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan undo operation: %w", err)
+	}
+	if restoredAt.Valid {
+		op.RestoredAt = &restoredAt.Time
+	}
+	if restoredRows.Valid {
+		op.RestoredRows = &restoredRows.Int64
+	}
+	return &op, nil
+}
+
+// prefixedColumns quotes columns and qualifies them with alias, e.g. r."code", r."name"
+func prefixedColumns(alias string, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = alias + "." + pq.QuoteIdentifier(column)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// parseUndoPlan reads the target table and condition of a single UPDATE or DELETE. The
+// condition is kept as written so the snapshot selects exactly the rows the statement changes.
+func parseUndoPlan(query string) (*UndoPlan, error) {
+	runes := []rune(query)
+	var tokens []sqlToken
+	for _, token := range tokenizeSQL(ServicePostgreSQL, query) {
+		if !token.isPunct(";") {
+			tokens = append(tokens, token)
+		}
+	}
+	text := func(from, to int) string { return strings.TrimSpace(string(runes[from:to])) }
+
+	plan := &UndoPlan{}
+	i := 1
+	switch {
+	case len(tokens) > 0 && tokens[0].isKeyword("UPDATE"):
+		plan.Statement = "UPDATE"
+	case len(tokens) > 1 && tokens[0].isKeyword("DELETE") && tokens[1].isKeyword("FROM"):
+		plan.Statement = "DELETE"
+		i = 2
+	default:
+		return nil, fmt.Errorf("only UPDATE and DELETE statements without WITH are snapshotted")
+	}
+
+	// [ONLY] name[.name] [[AS] alias]
+	if i >= len(tokens) {
+		return nil, fmt.Errorf("the statement names no table")
+	}
+	fromStart := tokens[i].start
+	if tokens[i].isKeyword("ONLY") {
+		i++
+	}
+	if i >= len(tokens) || tokens[i].kind != tokenWord {
+		return nil, fmt.Errorf("the statement names no table")
+	}
+	name := strings.ToLower(tokens[i].text)
+	plan.row = text(tokens[i].start, tokens[i].end)
+	for i+2 < len(tokens) && tokens[i+1].isPunct(".") && tokens[i+2].kind == tokenWord {
+		name += "." + strings.ToLower(tokens[i+2].text)
+		plan.row = text(tokens[i+2].start, tokens[i+2].end)
+		i += 2
+	}
+	qualified, ok := dataTableName(name)
+	if !ok {
+		return nil, fmt.Errorf("table name '%s' is not supported", name)
+	}
+	plan.Table = qualified
+	if i+1 < len(tokens) && tokens[i+1].isKeyword("AS") {
+		i++
+	}
+	if i+1 < len(tokens) && tokens[i+1].kind == tokenWord &&
+		(tokens[i+1].quoted || !nonAliasKeywords[strings.ToUpper(tokens[i+1].text)]) {
+		i++
+		plan.row = text(tokens[i].start, tokens[i].end)
+	}
+	plan.from = text(fromStart, tokens[i].end)
+
+	// The condition runs from WHERE to RETURNING or the end of the statement
+	whereStart, whereEnd := -1, tokens[len(tokens)-1].end
+	depth := 0
+	for i++; i < len(tokens); i++ {
+		token := tokens[i]
+		switch {
+		case token.isPunct("("):
+			depth++
+		case token.isPunct(")"):
+			depth--
+		case depth > 0:
+		case token.isKeyword("FROM") && plan.Statement == "UPDATE", token.isKeyword("USING"):
+			return nil, fmt.Errorf("joins through UPDATE ... FROM or DELETE ... USING are not snapshotted; use a subquery in WHERE")
+		case token.isKeyword("WHERE") && whereStart < 0:
+			if i+1 < len(tokens) && tokens[i+1].isKeyword("CURRENT") {
+				return nil, fmt.Errorf("WHERE CURRENT OF is not snapshotted")
+			}
+			if i+1 < len(tokens) {
+				whereStart = tokens[i+1].start
+			}
+		case token.isKeyword("RETURNING"):
+			whereEnd = token.start
+			i = len(tokens)
+		}
+	}
+	if whereStart >= 0 {
+		plan.where = text(whereStart, whereEnd)
+	}
+	return plan, nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"smlgoapi/config"
+)
+
+func TestUndoPlanWithQueries(t *testing.T) {
+	undo := NewUndoService(nil, config.UndoConfig{Tables: []string{"ic_inventory"}})
+	tests := []struct {
+		query       string
+		unsupported bool
+		planned     bool
+	}{
+		{"UPDATE ic_inventory SET name = 'x' WHERE code = 'A'", false, true},
+		{"WITH d AS (DELETE FROM ic_inventory WHERE code = 'A' RETURNING *) SELECT count(*) FROM d", true, false},
+		{"WITH u AS (UPDATE public.ic_inventory SET name = 'x' RETURNING *) SELECT * FROM u", true, false},
+		{"WITH d AS (DELETE FROM other RETURNING *) SELECT * FROM d JOIN ic_inventory USING (code)", false, false},
+		{"WITH i AS (INSERT INTO ic_inventory (code) VALUES ('B') RETURNING *) SELECT * FROM i", false, false},
+		{"MERGE INTO ic_inventory t USING other s ON t.code = s.code WHEN MATCHED THEN DELETE", true, false},
+	}
+	for _, tt := range tests {
+		plan, err := undo.Plan(tt.query)
+		if errors.Is(err, ErrUndoUnsupported) != tt.unsupported {
+			t.Errorf("Plan(%q) error = %v, want unsupported %t", tt.query, err, tt.unsupported)
+		}
+		if (plan != nil) != tt.planned {
+			t.Errorf("Plan(%q) = %+v, want planned %t", tt.query, plan, tt.planned)
+		}
+	}
+}
@@ -11,19 +11,37 @@ import (
 	"smlgoapi/config"
 	"smlgoapi/models"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type PostgreSQLService struct {
 	db     *sql.DB
 	config *config.Config
+	fields FieldMap // ic_inventory and ic_inventory_barcode columns, see field_mapping.postgresql
+
+	priceCache priceBalanceCache
 }
 
+// Product code and name as returned by the search queries. Ordering and cursor conditions use the
+// same expressions so they agree on rows with a NULL name or code.
+const (
+	productCodeExpr = "COALESCE(CAST({code} AS TEXT), 'N/A')"
+	productNameExpr = "COALESCE(CAST({name} AS TEXT), 'N/A')"
+)
+
 func NewPostgreSQLService(config *config.Config) (*PostgreSQLService, error) {
-	db, err := sql.Open("postgres", config.GetPostgreSQLDSN())
+	fields, err := NewPostgreSQLFieldMap(config.FieldMapping.PostgreSQL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Connections report the calling request in application_name for pg_stat_activity
+	connector, err := newTaggingConnector(config.GetPostgreSQLDSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open PostgreSQL connection: %w", err)
 	}
+	db := sql.OpenDB(newTracingConnector(connector, "postgresql"))
+	configurePool(db, config.PostgreSQL.Pool)
 
 	// Test connection
 	if err := db.Ping(); err != nil {
@@ -33,6 +51,7 @@ func NewPostgreSQLService(config *config.Config) (*PostgreSQLService, error) {
 	return &PostgreSQLService{
 		db:     db,
 		config: config,
+		fields: fields,
 	}, nil
 }
 
@@ -40,6 +59,11 @@ func (s *PostgreSQLService) Close() error {
 	return s.db.Close()
 }
 
+// Stats returns the connection pool statistics
+func (s *PostgreSQLService) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
 func (s *PostgreSQLService) GetVersion(ctx context.Context) (string, error) {
 	var version string
 	err := s.db.QueryRowContext(ctx, "SELECT version()").Scan(&version)
@@ -97,56 +121,26 @@ func (s *PostgreSQLService) ExecuteCommand(ctx context.Context, query string) (i
 	}, nil
 }
 
-// ExecuteSelect executes a SELECT query and returns the result data
-func (s *PostgreSQLService) ExecuteSelect(ctx context.Context, query string) ([]interface{}, error) {
-	rows, err := s.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute select query: %w", err)
+// ExecuteSelect executes a SELECT query and returns the result data.
+// Optional params are bound to $1, $2, ... placeholders.
+func (s *PostgreSQLService) ExecuteSelect(ctx context.Context, query string, params ...interface{}) ([]interface{}, error) {
+	var results []interface{}
+	if _, err := s.StreamSelect(ctx, query, collectRows(&results), params...); err != nil {
+		return nil, err
 	}
-	defer rows.Close()
+	return results, nil
+}
 
-	// Get column information
-	columns, err := rows.Columns()
+// StreamSelect executes a SELECT query and hands each row to fn as it is read,
+// so large results never have to fit in memory. It returns the number of rows streamed.
+func (s *PostgreSQLService) StreamSelect(ctx context.Context, query string, fn RowFunc, params ...interface{}) (int, error) {
+	rows, err := s.db.QueryContext(ctx, query, params...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get columns: %w", err)
-	}
-
-	var results []interface{}
-
-	for rows.Next() {
-		// Create a slice of interface{} to hold the values
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-
-		for i := range columns {
-			valuePtrs[i] = &values[i]
-		}
-
-		// Scan the row into the value pointers
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		// Create a map for this row
-		rowMap := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
-
-			// Convert []uint8 to string if needed
-			if b, ok := val.([]uint8); ok {
-				val = string(b)
-			}
-
-			rowMap[col] = val
-		}
-
-		results = append(results, rowMap)
+		return 0, fmt.Errorf("failed to execute select query: %w", err)
 	}
+	defer rows.Close()
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %w", err)
-	}
-	return results, nil
+	return scanRows(rows, fn)
 }
 
 // PriceInfo holds price information from ic_inventory_price_formula
@@ -262,6 +256,9 @@ func (s *PostgreSQLService) LoadPriceFormulaFiltered(ctx context.Context, icCode
 	if len(icCodes) == 0 {
 		return make(map[string]*PriceInfo), nil
 	}
+	if prices, ok := s.priceCache.cachedPrices(icCodes); ok {
+		return prices, nil
+	}
 
 	// Check if the price formula table exists
 	checkTableQuery := `
@@ -431,6 +428,9 @@ func (s *PostgreSQLService) LoadBalanceDataFiltered(ctx context.Context, icCodes
 	if len(icCodes) == 0 {
 		return make(map[string]*BalanceInfo), nil
 	}
+	if balances, ok := s.priceCache.cachedBalances(icCodes); ok {
+		return balances, nil
+	}
 
 	// Check if the balance table exists
 	checkTableQuery := `
@@ -502,6 +502,22 @@ func (s *PostgreSQLService) LoadBalanceDataFiltered(ctx context.Context, icCodes
 
 // SearchProducts performs a full text search on the ic_inventory table in PostgreSQL
 func (s *PostgreSQLService) SearchProducts(ctx context.Context, query string, limit, offset int) ([]map[string]interface{}, int, error) {
+	return s.searchProducts(ctx, query, limit, offset, nil, DefaultRankingWeights(), SearchFilters{})
+}
+
+// SearchProductsAfter returns the text search page following a SearchCursorText cursor.
+// The total count still covers every match.
+func (s *PostgreSQLService) SearchProductsAfter(ctx context.Context, query string, limit int, after *SearchCursor) ([]map[string]interface{}, int, error) {
+	return s.searchProducts(ctx, query, limit, 0, after, DefaultRankingWeights(), SearchFilters{})
+}
+
+// SearchProductsRanked is the text search ordered by the given ranking weights and restricted by
+// filters, by offset or after a cursor issued for the same weights and filters
+func (s *PostgreSQLService) SearchProductsRanked(ctx context.Context, query string, limit, offset int, after *SearchCursor, weights RankingWeights, filters SearchFilters) ([]map[string]interface{}, int, error) {
+	return s.searchProducts(ctx, query, limit, offset, after, weights, filters)
+}
+
+func (s *PostgreSQLService) searchProducts(ctx context.Context, query string, limit, offset int, after *SearchCursor, weights RankingWeights, filters SearchFilters) ([]map[string]interface{}, int, error) {
 	// First check if the ic_inventory table exists
 	checkTableQuery := `
 		SELECT COUNT(*) 
@@ -519,42 +535,57 @@ func (s *PostgreSQLService) SearchProducts(ctx context.Context, query string, li
 		return nil, 0, fmt.Errorf("table 'ic_inventory' not found in database - please create the table or contact system administrator")
 	}
 
-	// Split query into words for OR search
-	words := strings.Fields(strings.TrimSpace(query))
-	if len(words) == 0 {
-		words = []string{query} // If no spaces, use the whole query
-	}
-	// Build OR conditions for full text search - using ILIKE for better Unicode support
-	// Search only in 'code' and 'name' fields as requested
-	var orConditions []string
-	for range words {
-		orConditions = append(orConditions, "CAST(name AS TEXT) ILIKE ?")
-		orConditions = append(orConditions, "CAST(code AS TEXT) ILIKE ?")
+	// Build search query with priority scoring
+	priority := expr(`CASE
+		           WHEN CAST({code} AS TEXT) ILIKE ? THEN 5
+		           WHEN CAST({code} AS TEXT) ILIKE ? THEN 3
+		           WHEN CAST({name} AS TEXT) ILIKE ? THEN 2
+		           ELSE 1
+		       END`, query, "%"+query+"%", "%"+query+"%")
+	// Rank score: the priority tiers times ten, the exact code tier scaled by its weight, plus boosts
+	boosts, hasImage := s.rankingBoosts(ctx, weights)
+	rank := expr(`(CASE
+		           WHEN CAST({code} AS TEXT) ILIKE ? THEN 50 * ?::float8
+		           WHEN CAST({code} AS TEXT) ILIKE ? THEN 30
+		           WHEN CAST({name} AS TEXT) ILIKE ? THEN 20
+		           ELSE 10
+		       END + `+boosts.sql+`)`,
+		append([]interface{}{query, weights.ExactCode, "%" + query + "%", "%" + query + "%"}, boosts.args...)...)
+	builder := newSelect(
+		productCodeExpr+" as code",
+		productNameExpr+" as name",
+		"COALESCE(CAST({unit_standard_code} AS TEXT), 'N/A') as unit_standard_code",
+		"COALESCE({item_type}, 0) as item_type",
+		"COALESCE({row_order_ref}, 0) as row_order_ref").
+		Column(priority.sql+" as search_priority", priority.args...).
+		Column(rank.sql+" as rank_score", rank.args...).
+		Column(hasImage + " as has_image").
+		From("ic_inventory").
+		Where(productTextMatch(query)).
+		OrderBy("rank_score DESC").
+		OrderBy("LENGTH(" + productNameExpr + ") ASC").
+		OrderBy("{name} ASC").
+		OrderBy("{code} ASC").
+		Limit(limit).
+		Offset(offset)
+	for _, condition := range s.filterConditions(ctx, filters) {
+		builder.Where(condition)
 	}
 
-	// Convert PostgreSQL placeholder format
-	whereClause := strings.Join(orConditions, " OR ")
-	paramIndex := 1
-	for range orConditions {
-		whereClause = strings.Replace(whereClause, "?", fmt.Sprintf("$%d", paramIndex), 1)
-		paramIndex++
+	// Get count of matching records
+	countQuery, countParams, err := builder.CountSQL()
+	if err != nil {
+		return nil, 0, err
 	}
+	countQuery = s.fields.Expand(countQuery)
 
-	// Prepare parameters for count query
-	var countParams []interface{}
-	for _, word := range words {
-		if strings.TrimSpace(word) != "" {
-			countParams = append(countParams, "%"+word+"%") // name search
-			countParams = append(countParams, "%"+word+"%") // code search
-		}
+	// The keyset condition is added after counting so the count covers every page.
+	// Negating the rank turns the mixed DESC/ASC ordering into one row comparison.
+	if after != nil {
+		builder.Where(expr("(-"+rank.sql+", LENGTH("+productNameExpr+"), "+productNameExpr+", "+productCodeExpr+") > (?::float8, LENGTH(?::text), ?, ?)",
+			append(append([]interface{}{}, rank.args...), -after.RankScore(), after.Name, after.Name, after.Code)...))
 	}
 
-	// Get count of matching records
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) as total_count
-		FROM ic_inventory 
-		WHERE %s`, whereClause)
-
 	countRows, err := s.db.QueryContext(ctx, countQuery, countParams...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to execute count query: %w", err)
@@ -568,33 +599,11 @@ func (s *PostgreSQLService) SearchProducts(ctx context.Context, query string, li
 		}
 	}
 
-	// Build search query with priority scoring
-	searchQuery := fmt.Sprintf(`
-		SELECT COALESCE(CAST(code AS TEXT), 'N/A') as code, 
-		       COALESCE(CAST(name AS TEXT), 'N/A') as name,
-		       COALESCE(CAST(unit_standard_code AS TEXT), 'N/A') as unit_standard_code,
-		       COALESCE(item_type, 0) as item_type,
-		       COALESCE(row_order_ref, 0) as row_order_ref,
-		       CASE 
-		           WHEN CAST(code AS TEXT) ILIKE $%d THEN 5
-		           WHEN CAST(code AS TEXT) ILIKE $%d THEN 3
-		           WHEN CAST(name AS TEXT) ILIKE $%d THEN 2
-		           ELSE 1
-		       END as search_priority
-		FROM ic_inventory 
-		WHERE %s
-		ORDER BY search_priority DESC, LENGTH(name) ASC, name ASC
-		LIMIT $%d OFFSET $%d`,
-		len(countParams)+1, len(countParams)+2, len(countParams)+3, whereClause, len(countParams)+4, len(countParams)+5)
-
-	// Prepare parameters for search query
-	searchParams := make([]interface{}, 0)
-	searchParams = append(searchParams, countParams...) // word parameters
-	searchParams = append(searchParams, query)          // exact match for code
-	searchParams = append(searchParams, "%"+query+"%")  // like match for code
-	searchParams = append(searchParams, "%"+query+"%")  // like match for name
-	searchParams = append(searchParams, limit)          // limit
-	searchParams = append(searchParams, offset)         // offset
+	searchQuery, searchParams, err := builder.ToSQL()
+	if err != nil {
+		return nil, 0, err
+	}
+	searchQuery = s.fields.Expand(searchQuery)
 
 	// Log the actual SQL query for debugging
 	log.Printf("🔍 SQL Query: %s", searchQuery)
@@ -612,8 +621,10 @@ func (s *PostgreSQLService) SearchProducts(ctx context.Context, query string, li
 	for rows.Next() {
 		var code, name, unitStandardCode string
 		var itemType, rowOrderRef, searchPriority int
+		var rankScore float64
+		var hasImage bool
 
-		err := rows.Scan(&code, &name, &unitStandardCode, &itemType, &rowOrderRef, &searchPriority)
+		err := rows.Scan(&code, &name, &unitStandardCode, &itemType, &rowOrderRef, &searchPriority, &rankScore, &hasImage)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan search result: %w", err)
 		}
@@ -636,6 +647,8 @@ func (s *PostgreSQLService) SearchProducts(ctx context.Context, query string, li
 			"row_order_ref":      rowOrderRef,
 			"search_priority":    searchPriority,
 			"similarity_score":   float64(searchPriority), // Use search priority as similarity score
+			"rank_score":         rankScore,
+			"has_image":          hasImage,
 
 			// Pricing and inventory fields (will be updated below)
 			"sale_price":         salePrice,
@@ -715,6 +728,24 @@ func (s *PostgreSQLService) SearchProducts(ctx context.Context, query string, li
 	return results, totalCount, nil
 }
 
+// productTextMatch matches ic_inventory rows whose code or name contains any word of the query
+func productTextMatch(query string) sqlExpr {
+	// Split query into words for OR search
+	words := strings.Fields(strings.TrimSpace(query))
+	if len(words) == 0 {
+		words = []string{query} // If no spaces, use the whole query
+	}
+	// Build OR conditions for full text search - using ILIKE for better Unicode support
+	// Search only in 'code' and 'name' fields as requested
+	var orConditions []sqlExpr
+	for _, word := range words {
+		orConditions = append(orConditions,
+			expr("CAST({name} AS TEXT) ILIKE ?", "%"+word+"%"),
+			expr("CAST({code} AS TEXT) ILIKE ?", "%"+word+"%"))
+	}
+	return orExpr(orConditions...)
+}
+
 // SearchProductsByBarcodes performs search on the ic_inventory table using specific barcodes
 func (s *PostgreSQLService) SearchProductsByBarcodes(ctx context.Context, barcodes []string, limit, offset int) ([]map[string]interface{}, int, error) {
 	// For now, treat barcodes as search terms
@@ -788,14 +819,14 @@ func (s *PostgreSQLService) SearchProductsByExactBarcode(ctx context.Context, qu
 	}
 
 	// Search for exact barcode match
-	whereClause := "CAST(ib.barcode AS TEXT) = $1"
+	whereClause := "CAST({ib.barcode} AS TEXT) = $1"
 
 	// Get count of matching records
-	countQuery := fmt.Sprintf(`
+	countQuery := s.fields.Expand(fmt.Sprintf(`
 		SELECT COUNT(*) as total_count
 		FROM ic_inventory_barcode ib
-		INNER JOIN ic_inventory i ON CAST(ib.ic_code AS TEXT) = CAST(i.code AS TEXT)
-		WHERE %s`, whereClause)
+		INNER JOIN ic_inventory i ON CAST({ib.barcode_ic_code} AS TEXT) = CAST({i.code} AS TEXT)
+		WHERE %s`, whereClause))
 
 	var totalCount int
 	err = s.db.QueryRowContext(ctx, countQuery, query).Scan(&totalCount)
@@ -808,19 +839,19 @@ func (s *PostgreSQLService) SearchProductsByExactBarcode(ctx context.Context, qu
 	}
 
 	// Build search query
-	searchQuery := fmt.Sprintf(`
-		SELECT COALESCE(CAST(i.code AS TEXT), 'N/A') as code, 
-		       COALESCE(CAST(i.name AS TEXT), 'N/A') as name,
-		       COALESCE(CAST(i.unit_standard_code AS TEXT), 'N/A') as unit_standard_code,
-		       COALESCE(i.item_type, 0) as item_type,
-		       COALESCE(i.row_order_ref, 0) as row_order_ref,
-		       COALESCE(CAST(ib.barcode AS TEXT), 'N/A') as matched_barcode,
+	searchQuery := s.fields.Expand(fmt.Sprintf(`
+		SELECT COALESCE(CAST({i.code} AS TEXT), 'N/A') as code, 
+		       COALESCE(CAST({i.name} AS TEXT), 'N/A') as name,
+		       COALESCE(CAST({i.unit_standard_code} AS TEXT), 'N/A') as unit_standard_code,
+		       COALESCE({i.item_type}, 0) as item_type,
+		       COALESCE({i.row_order_ref}, 0) as row_order_ref,
+		       COALESCE(CAST({ib.barcode} AS TEXT), 'N/A') as matched_barcode,
 		       10 as search_priority
 		FROM ic_inventory_barcode ib
-		INNER JOIN ic_inventory i ON CAST(ib.ic_code AS TEXT) = CAST(i.code AS TEXT)
+		INNER JOIN ic_inventory i ON CAST({ib.barcode_ic_code} AS TEXT) = CAST({i.code} AS TEXT)
 		WHERE %s
-		ORDER BY i.name ASC
-		LIMIT $2 OFFSET $3`, whereClause)
+		ORDER BY {i.name} ASC
+		LIMIT $2 OFFSET $3`, whereClause))
 
 	log.Printf("🔍 [BARCODE-SEARCH] SQL Query: %s", searchQuery)
 	log.Printf("🔍 [BARCODE-SEARCH] Parameters: [%s, %d, %d]", query, limit, offset)
@@ -912,13 +943,13 @@ func (s *PostgreSQLService) SearchProductsByExactCode(ctx context.Context, query
 	}
 
 	// Search for exact code match
-	whereClause := "CAST(code AS TEXT) = $1"
+	whereClause := "CAST({code} AS TEXT) = $1"
 
 	// Get count of matching records
-	countQuery := fmt.Sprintf(`
+	countQuery := s.fields.Expand(fmt.Sprintf(`
 		SELECT COUNT(*) as total_count
 		FROM ic_inventory 
-		WHERE %s`, whereClause)
+		WHERE %s`, whereClause))
 
 	var totalCount int
 	err = s.db.QueryRowContext(ctx, countQuery, query).Scan(&totalCount)
@@ -931,17 +962,17 @@ func (s *PostgreSQLService) SearchProductsByExactCode(ctx context.Context, query
 	}
 
 	// Build search query
-	searchQuery := fmt.Sprintf(`
-		SELECT COALESCE(CAST(code AS TEXT), 'N/A') as code, 
-		       COALESCE(CAST(name AS TEXT), 'N/A') as name,
-		       COALESCE(CAST(unit_standard_code AS TEXT), 'N/A') as unit_standard_code,
-		       COALESCE(item_type, 0) as item_type,
-		       COALESCE(row_order_ref, 0) as row_order_ref,
+	searchQuery := s.fields.Expand(fmt.Sprintf(`
+		SELECT COALESCE(CAST({code} AS TEXT), 'N/A') as code, 
+		       COALESCE(CAST({name} AS TEXT), 'N/A') as name,
+		       COALESCE(CAST({unit_standard_code} AS TEXT), 'N/A') as unit_standard_code,
+		       COALESCE({item_type}, 0) as item_type,
+		       COALESCE({row_order_ref}, 0) as row_order_ref,
 		       8 as search_priority
 		FROM ic_inventory 
 		WHERE %s
-		ORDER BY name ASC
-		LIMIT $2 OFFSET $3`, whereClause)
+		ORDER BY {name} ASC
+		LIMIT $2 OFFSET $3`, whereClause))
 
 	log.Printf("🔍 [CODE-SEARCH] SQL Query: %s", searchQuery)
 	log.Printf("🔍 [CODE-SEARCH] Parameters: [%s, %d, %d]", query, limit, offset)
@@ -1019,6 +1050,22 @@ func (s *PostgreSQLService) SearchProductsByBarcodesWithRelevance(ctx context.Co
 
 // SearchProductsByBarcodesWithRelevanceAndBarcodeMap performs search with barcode mapping
 func (s *PostgreSQLService) SearchProductsByBarcodesWithRelevanceAndBarcodeMap(ctx context.Context, barcodes []string, relevanceMap map[string]float64, barcodeMap map[string]string, limit, offset int) ([]map[string]interface{}, int, error) {
+	return s.searchProductsByRelevance(ctx, barcodes, relevanceMap, barcodeMap, limit, offset, nil, DefaultRankingWeights(), SearchFilters{})
+}
+
+// SearchProductsByRelevanceAfter returns the page following a SearchCursorVector cursor for the
+// same codes and relevance scores. The total count still covers every match.
+func (s *PostgreSQLService) SearchProductsByRelevanceAfter(ctx context.Context, codes []string, relevanceMap map[string]float64, barcodeMap map[string]string, limit int, after *SearchCursor) ([]map[string]interface{}, int, error) {
+	return s.searchProductsByRelevance(ctx, codes, relevanceMap, barcodeMap, limit, 0, after, DefaultRankingWeights(), SearchFilters{})
+}
+
+// SearchProductsByRelevanceRanked returns Weaviate-ranked products ordered by the given ranking
+// weights and restricted by filters, by offset or after a cursor issued for the same weights and filters
+func (s *PostgreSQLService) SearchProductsByRelevanceRanked(ctx context.Context, codes []string, relevanceMap map[string]float64, barcodeMap map[string]string, limit, offset int, after *SearchCursor, weights RankingWeights, filters SearchFilters) ([]map[string]interface{}, int, error) {
+	return s.searchProductsByRelevance(ctx, codes, relevanceMap, barcodeMap, limit, offset, after, weights, filters)
+}
+
+func (s *PostgreSQLService) searchProductsByRelevance(ctx context.Context, barcodes []string, relevanceMap map[string]float64, barcodeMap map[string]string, limit, offset int, after *SearchCursor, weights RankingWeights, filters SearchFilters) ([]map[string]interface{}, int, error) {
 	if len(barcodes) == 0 {
 		return []map[string]interface{}{}, 0, nil
 	}
@@ -1040,69 +1087,58 @@ func (s *PostgreSQLService) SearchProductsByBarcodesWithRelevanceAndBarcodeMap(c
 		return nil, 0, fmt.Errorf("table 'ic_inventory' not found in database - please create the table or contact system administrator")
 	}
 
-	// Build IN clause for barcode filtering
-	placeholders := make([]string, len(barcodes))
-	params := make([]interface{}, len(barcodes))
-	for i, barcode := range barcodes {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
-		params[i] = barcode
+	// Join against the codes and their relevance passed as two parallel arrays. This keeps the
+	// statement the same size however many codes Weaviate returns and lets the planner hash-join.
+	codes, scores := relevanceArrays(barcodes, relevanceMap)
+	boosts, hasImage := s.rankingBoosts(ctx, weights)
+	rank := expr("(relevance_match.relevance * ?::float8 + "+boosts.sql+")", append([]interface{}{weights.Vector}, boosts.args...)...)
+	builder := newSelect(
+		productCodeExpr+" as code",
+		productNameExpr+" as name",
+		"COALESCE(CAST({unit_standard_code} AS TEXT), 'N/A') as unit_standard_code",
+		"COALESCE({item_type}, 0) as item_type",
+		"COALESCE({row_order_ref}, 0) as row_order_ref",
+		"6 as search_priority").
+		Column(rank.sql+" as rank_score", rank.args...).
+		Column(hasImage+" as has_image").
+		From("ic_inventory").
+		Join("JOIN unnest(?::text[], ?::float8[]) AS relevance_match(match_code, relevance) ON CAST({ic_inventory.code} AS TEXT) = relevance_match.match_code",
+			pq.Array(codes), pq.Array(scores))
+
+	// Order by rank, i.e. relevance (0 for codes without a score) scaled by the vector weight plus
+	// boosts, then by name
+	builder.OrderBy("rank_score DESC").OrderBy("{name} ASC").OrderBy("{code} ASC").Limit(limit).Offset(offset)
+	for _, condition := range s.filterConditions(ctx, filters) {
+		builder.Where(condition)
 	}
 
-	whereClause := fmt.Sprintf("CAST(code AS TEXT) IN (%s)", strings.Join(placeholders, ","))
-
 	// Get count of matching records
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) as total_count
-		FROM ic_inventory 
-		WHERE %s`, whereClause)
-
-	countRows, err := s.db.QueryContext(ctx, countQuery, params...)
+	countQuery, countParams, err := builder.CountSQL()
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to execute count query: %w", err)
+		return nil, 0, err
 	}
-	defer countRows.Close()
+	countQuery = s.fields.Expand(countQuery)
 
-	var totalCount int
-	if countRows.Next() {
-		if err := countRows.Scan(&totalCount); err != nil {
-			return nil, 0, fmt.Errorf("failed to scan count result: %w", err)
-		}
+	// Keyset condition for cursor pages, added after counting. Codes without a score have relevance 0,
+	// so the comparison also works when no relevance was given.
+	if after != nil {
+		builder.Where(expr("(-"+rank.sql+", "+productNameExpr+", "+productCodeExpr+") > (?::float8, ?, ?)",
+			append(append([]interface{}{}, rank.args...), -after.RankScore(), after.Name, after.Code)...))
 	}
 
-	// Build search query with barcode filtering and ordering by relevance (if available) then by name
-	var orderClause string
-	if relevanceMap != nil && len(relevanceMap) > 0 {
-		// Create CASE statement for relevance-based ordering
-		var caseClauses []string
-		for code, relevance := range relevanceMap {
-			caseClauses = append(caseClauses, fmt.Sprintf("WHEN CAST(code AS TEXT) = '%s' THEN %f",
-				strings.Replace(code, "'", "''", -1), relevance)) // Escape single quotes
-		}
-		orderClause = fmt.Sprintf(`ORDER BY 
-			CASE %s ELSE 0 END DESC, 
-			name ASC`, strings.Join(caseClauses, " "))
-	} else {
-		orderClause = "ORDER BY name ASC"
+	var totalCount int
+	if err := s.db.QueryRowContext(ctx, countQuery, countParams...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to execute count query: %w", err)
 	}
 
-	searchQuery := fmt.Sprintf(`
-		SELECT COALESCE(CAST(code AS TEXT), 'N/A') as code, 
-		       COALESCE(CAST(name AS TEXT), 'N/A') as name,
-		       COALESCE(CAST(unit_standard_code AS TEXT), 'N/A') as unit_standard_code,
-		       COALESCE(item_type, 0) as item_type,
-		       COALESCE(row_order_ref, 0) as row_order_ref,
-		       6 as search_priority
-		FROM ic_inventory 
-		WHERE %s
-		%s
-		LIMIT $%d OFFSET $%d`,
-		whereClause, orderClause, len(params)+1, len(params)+2)
-
-	// Add limit and offset to parameters
-	params = append(params, limit, offset)
+	searchQuery, params, err := builder.ToSQL()
+	if err != nil {
+		return nil, 0, err
+	}
+	searchQuery = s.fields.Expand(searchQuery)
 
 	log.Printf("🔍 [BARCODE-MAP-SEARCH] SQL Query: %s", searchQuery)
-	log.Printf("🔍 [BARCODE-MAP-SEARCH] Parameters: %v", params)
+	log.Printf("🔍 [BARCODE-MAP-SEARCH] Parameters: %d", len(params))
 
 	rows, err := s.db.QueryContext(ctx, searchQuery, params...)
 	if err != nil {
@@ -1116,8 +1152,10 @@ func (s *PostgreSQLService) SearchProductsByBarcodesWithRelevanceAndBarcodeMap(c
 	for rows.Next() {
 		var code, name, unitStandardCode string
 		var itemType, rowOrderRef, searchPriority int
+		var rankScore float64
+		var hasImage bool
 
-		err := rows.Scan(&code, &name, &unitStandardCode, &itemType, &rowOrderRef, &searchPriority)
+		err := rows.Scan(&code, &name, &unitStandardCode, &itemType, &rowOrderRef, &searchPriority, &rankScore, &hasImage)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan search result: %w", err)
 		}
@@ -1149,6 +1187,8 @@ func (s *PostgreSQLService) SearchProductsByBarcodesWithRelevanceAndBarcodeMap(c
 			"row_order_ref":      rowOrderRef,
 			"search_priority":    searchPriority,
 			"similarity_score":   relevanceScore,
+			"rank_score":         rankScore,
+			"has_image":          hasImage,
 			"barcode":            mappedBarcode,
 			"search_method":      "barcode_mapping",
 
@@ -1187,6 +1227,23 @@ func (s *PostgreSQLService) SearchProductsByBarcodesWithRelevanceAndBarcodeMap(c
 	return results, totalCount, nil
 }
 
+// relevanceArrays returns the distinct codes with their relevance scores (0 when unknown)
+// as parallel slices for binding to unnest
+func relevanceArrays(codes []string, relevanceMap map[string]float64) ([]string, []float64) {
+	seen := make(map[string]bool, len(codes))
+	distinct := make([]string, 0, len(codes))
+	scores := make([]float64, 0, len(codes))
+	for _, code := range codes {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		distinct = append(distinct, code)
+		scores = append(scores, relevanceMap[code])
+	}
+	return distinct, scores
+}
+
 // SearchProductsByLikeBarcode performs LIKE search in ic_inventory_barcode.barcode field
 func (s *PostgreSQLService) SearchProductsByLikeBarcode(ctx context.Context, query string, limit, offset int) ([]map[string]interface{}, int, error) {
 	// First check if the ic_inventory_barcode table exists
@@ -1208,14 +1265,14 @@ func (s *PostgreSQLService) SearchProductsByLikeBarcode(ctx context.Context, que
 	}
 
 	// Simple LIKE search in barcode field
-	whereClause := "CAST(ib.barcode AS TEXT) LIKE $1"
+	whereClause := "CAST({ib.barcode} AS TEXT) LIKE $1"
 
 	// Get count of matching records
-	countQuery := fmt.Sprintf(`
+	countQuery := s.fields.Expand(fmt.Sprintf(`
 		SELECT COUNT(*) as total_count
 		FROM ic_inventory_barcode ib
-		INNER JOIN ic_inventory i ON CAST(ib.ic_code AS TEXT) = CAST(i.code AS TEXT)
-		WHERE %s`, whereClause)
+		INNER JOIN ic_inventory i ON CAST({ib.barcode_ic_code} AS TEXT) = CAST({i.code} AS TEXT)
+		WHERE %s`, whereClause))
 
 	var totalCount int
 	queryWithWildcards := "%" + query + "%"
@@ -1229,19 +1286,19 @@ func (s *PostgreSQLService) SearchProductsByLikeBarcode(ctx context.Context, que
 	}
 
 	// Build search query
-	searchQuery := fmt.Sprintf(`
-		SELECT COALESCE(CAST(i.code AS TEXT), 'N/A') as code, 
-		       COALESCE(CAST(i.name AS TEXT), 'N/A') as name,
-		       COALESCE(CAST(i.unit_standard_code AS TEXT), 'N/A') as unit_standard_code,
-		       COALESCE(i.item_type, 0) as item_type,
-		       COALESCE(i.row_order_ref, 0) as row_order_ref,
-		       COALESCE(CAST(ib.barcode AS TEXT), 'N/A') as matched_barcode,
+	searchQuery := s.fields.Expand(fmt.Sprintf(`
+		SELECT COALESCE(CAST({i.code} AS TEXT), 'N/A') as code, 
+		       COALESCE(CAST({i.name} AS TEXT), 'N/A') as name,
+		       COALESCE(CAST({i.unit_standard_code} AS TEXT), 'N/A') as unit_standard_code,
+		       COALESCE({i.item_type}, 0) as item_type,
+		       COALESCE({i.row_order_ref}, 0) as row_order_ref,
+		       COALESCE(CAST({ib.barcode} AS TEXT), 'N/A') as matched_barcode,
 		       7 as search_priority
 		FROM ic_inventory_barcode ib
-		INNER JOIN ic_inventory i ON CAST(ib.ic_code AS TEXT) = CAST(i.code AS TEXT)
+		INNER JOIN ic_inventory i ON CAST({ib.barcode_ic_code} AS TEXT) = CAST({i.code} AS TEXT)
 		WHERE %s
-		ORDER BY i.name ASC
-		LIMIT $2 OFFSET $3`, whereClause)
+		ORDER BY {i.name} ASC
+		LIMIT $2 OFFSET $3`, whereClause))
 
 	log.Printf("🔍 [BARCODE-LIKE-SEARCH] SQL Query: %s", searchQuery)
 	log.Printf("🔍 [BARCODE-LIKE-SEARCH] Parameters: [%s, %d, %d]", queryWithWildcards, limit, offset)
@@ -1333,13 +1390,13 @@ func (s *PostgreSQLService) SearchProductsByLikeCode(ctx context.Context, query
 	}
 
 	// Simple LIKE search in code field
-	whereClause := "CAST(code AS TEXT) LIKE $1"
+	whereClause := "CAST({code} AS TEXT) LIKE $1"
 
 	// Get count of matching records
-	countQuery := fmt.Sprintf(`
+	countQuery := s.fields.Expand(fmt.Sprintf(`
 		SELECT COUNT(*) as total_count
 		FROM ic_inventory 
-		WHERE %s`, whereClause)
+		WHERE %s`, whereClause))
 
 	var totalCount int
 	queryWithWildcards := "%" + query + "%"
@@ -1353,17 +1410,17 @@ func (s *PostgreSQLService) SearchProductsByLikeCode(ctx context.Context, query
 	}
 
 	// Build search query
-	searchQuery := fmt.Sprintf(`
-		SELECT COALESCE(CAST(code AS TEXT), 'N/A') as code, 
-		       COALESCE(CAST(name AS TEXT), 'N/A') as name,
-		       COALESCE(CAST(unit_standard_code AS TEXT), 'N/A') as unit_standard_code,
-		       COALESCE(item_type, 0) as item_type,
-		       COALESCE(row_order_ref, 0) as row_order_ref,
+	searchQuery := s.fields.Expand(fmt.Sprintf(`
+		SELECT COALESCE(CAST({code} AS TEXT), 'N/A') as code, 
+		       COALESCE(CAST({name} AS TEXT), 'N/A') as name,
+		       COALESCE(CAST({unit_standard_code} AS TEXT), 'N/A') as unit_standard_code,
+		       COALESCE({item_type}, 0) as item_type,
+		       COALESCE({row_order_ref}, 0) as row_order_ref,
 		       5 as search_priority
 		FROM ic_inventory 
 		WHERE %s
-		ORDER BY name ASC
-		LIMIT $2 OFFSET $3`, whereClause)
+		ORDER BY {name} ASC
+		LIMIT $2 OFFSET $3`, whereClause))
 
 	log.Printf("🔍 [CODE-LIKE-SEARCH] SQL Query: %s", searchQuery)
 	log.Printf("🔍 [CODE-LIKE-SEARCH] Parameters: [%s, %d, %d]", queryWithWildcards, limit, offset)
@@ -1483,32 +1540,32 @@ func (s *PostgreSQLService) SearchProductsSimpleLike(ctx context.Context, query
 		SELECT * FROM (
 			-- Search in barcode table
 			SELECT DISTINCT
-				COALESCE(CAST(i.code AS TEXT), 'N/A') as code, 
-				COALESCE(CAST(i.name AS TEXT), 'N/A') as name,
-				COALESCE(CAST(i.unit_standard_code AS TEXT), 'N/A') as unit_standard_code,
-				COALESCE(i.item_type, 0) as item_type,
-				COALESCE(i.row_order_ref, 0) as row_order_ref,
-				COALESCE(CAST(ib.barcode AS TEXT), 'N/A') as matched_barcode,
+				COALESCE(CAST({i.code} AS TEXT), 'N/A') as code, 
+				COALESCE(CAST({i.name} AS TEXT), 'N/A') as name,
+				COALESCE(CAST({i.unit_standard_code} AS TEXT), 'N/A') as unit_standard_code,
+				COALESCE({i.item_type}, 0) as item_type,
+				COALESCE({i.row_order_ref}, 0) as row_order_ref,
+				COALESCE(CAST({ib.barcode} AS TEXT), 'N/A') as matched_barcode,
 				'barcode' as search_source,
 				9 as search_priority
 			FROM ic_inventory_barcode ib
-			INNER JOIN ic_inventory i ON CAST(ib.ic_code AS TEXT) = CAST(i.code AS TEXT)
-			WHERE CAST(ib.barcode AS TEXT) LIKE $1
+			INNER JOIN ic_inventory i ON CAST({ib.barcode_ic_code} AS TEXT) = CAST({i.code} AS TEXT)
+			WHERE CAST({ib.barcode} AS TEXT) LIKE $1
 			
 			UNION
 			
 			-- Search in code field
 			SELECT DISTINCT
-				COALESCE(CAST(code AS TEXT), 'N/A') as code, 
-				COALESCE(CAST(name AS TEXT), 'N/A') as name,
-				COALESCE(CAST(unit_standard_code AS TEXT), 'N/A') as unit_standard_code,
-				COALESCE(item_type, 0) as item_type,
-				COALESCE(row_order_ref, 0) as row_order_ref,
+				COALESCE(CAST({code} AS TEXT), 'N/A') as code, 
+				COALESCE(CAST({name} AS TEXT), 'N/A') as name,
+				COALESCE(CAST({unit_standard_code} AS TEXT), 'N/A') as unit_standard_code,
+				COALESCE({item_type}, 0) as item_type,
+				COALESCE({row_order_ref}, 0) as row_order_ref,
 				'N/A' as matched_barcode,
 				'code' as search_source,
 				7 as search_priority
 			FROM ic_inventory 
-			WHERE CAST(code AS TEXT) LIKE $2
+			WHERE CAST({code} AS TEXT) LIKE $2
 		) combined_results
 		ORDER BY search_priority DESC, name ASC
 		LIMIT $3 OFFSET $4`
@@ -1519,21 +1576,22 @@ func (s *PostgreSQLService) SearchProductsSimpleLike(ctx context.Context, query
 		log.Printf("⚠️ Table 'ic_inventory_barcode' not found, searching only in code field")
 		unionQuery = `
 		SELECT DISTINCT
-			COALESCE(CAST(code AS TEXT), 'N/A') as code, 
-			COALESCE(CAST(name AS TEXT), 'N/A') as name,
-			COALESCE(CAST(unit_standard_code AS TEXT), 'N/A') as unit_standard_code,
-			COALESCE(item_type, 0) as item_type,
-			COALESCE(row_order_ref, 0) as row_order_ref,
+			COALESCE(CAST({code} AS TEXT), 'N/A') as code, 
+			COALESCE(CAST({name} AS TEXT), 'N/A') as name,
+			COALESCE(CAST({unit_standard_code} AS TEXT), 'N/A') as unit_standard_code,
+			COALESCE({item_type}, 0) as item_type,
+			COALESCE({row_order_ref}, 0) as row_order_ref,
 			'N/A' as matched_barcode,
 			'code' as search_source,
 			7 as search_priority
 		FROM ic_inventory 
-		WHERE CAST(code AS TEXT) LIKE $1
-		ORDER BY name ASC
+		WHERE CAST({code} AS TEXT) LIKE $1
+		ORDER BY {name} ASC
 		LIMIT $2 OFFSET $3`
 
 		params = []interface{}{queryWithWildcards, limit, offset}
 	}
+	unionQuery = s.fields.Expand(unionQuery)
 
 	log.Printf("🔍 [SIMPLE-LIKE-SEARCH] SQL Query: %s", unionQuery)
 	log.Printf("🔍 [SIMPLE-LIKE-SEARCH] Parameters: %v", params)
@@ -1605,25 +1663,26 @@ func (s *PostgreSQLService) SearchProductsSimpleLike(ctx context.Context, query
 	if barcodeTableExists > 0 {
 		countQuery = `
 		SELECT COUNT(*) FROM (
-			SELECT DISTINCT i.code
+			SELECT DISTINCT {i.code}
 			FROM ic_inventory_barcode ib
-			INNER JOIN ic_inventory i ON CAST(ib.ic_code AS TEXT) = CAST(i.code AS TEXT)
-			WHERE CAST(ib.barcode AS TEXT) LIKE $1
+			INNER JOIN ic_inventory i ON CAST({ib.barcode_ic_code} AS TEXT) = CAST({i.code} AS TEXT)
+			WHERE CAST({ib.barcode} AS TEXT) LIKE $1
 			
 			UNION
 			
-			SELECT DISTINCT code
+			SELECT DISTINCT {code}
 			FROM ic_inventory 
-			WHERE CAST(code AS TEXT) LIKE $2
+			WHERE CAST({code} AS TEXT) LIKE $2
 		) combined_count`
 		countParams = []interface{}{queryWithWildcards, queryWithWildcards}
 	} else {
 		countQuery = `
-		SELECT COUNT(DISTINCT code)
+		SELECT COUNT(DISTINCT {code})
 		FROM ic_inventory 
-		WHERE CAST(code AS TEXT) LIKE $1`
+		WHERE CAST({code} AS TEXT) LIKE $1`
 		countParams = []interface{}{queryWithWildcards}
 	}
+	countQuery = s.fields.Expand(countQuery)
 
 	var totalCount int
 	err = s.db.QueryRowContext(ctx, countQuery, countParams...).Scan(&totalCount)
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// taggingConnector wraps the pq connector so every connection reports the calling request
+// in application_name. The name is only sent when it differs from the connection's current one.
+type taggingConnector struct {
+	driver.Connector
+}
+
+func newTaggingConnector(dsn string) (driver.Connector, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &taggingConnector{Connector: connector}, nil
+}
+
+func (t *taggingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := t.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &taggedConn{conn: conn}, nil
+}
+
+// taggedConn forwards to the pq connection, setting application_name before each statement
+type taggedConn struct {
+	conn    driver.Conn
+	appName string // application_name currently set on the server session
+	inTx    bool   // settings changed inside a transaction are lost on rollback, so leave them alone
+}
+
+func (c *taggedConn) applyTag(ctx context.Context) {
+	if c.inTx {
+		return
+	}
+
+	name := postgresApplicationName
+	if tag, ok := QueryTagFromContext(ctx); ok {
+		name = tag.ApplicationName()
+	}
+	if name == c.appName {
+		return
+	}
+
+	_, err := c.conn.(driver.ExecerContext).ExecContext(ctx,
+		"SELECT set_config('application_name', $1, false)",
+		[]driver.NamedValue{{Ordinal: 1, Value: name}})
+	if err != nil {
+		log.Printf("⚠️ Failed to set PostgreSQL application_name: %v", err)
+		return
+	}
+	c.appName = name
+}
+
+func (c *taggedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.applyTag(ctx)
+	return c.conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+}
+
+func (c *taggedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.applyTag(ctx)
+	return c.conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func (c *taggedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	c.applyTag(ctx)
+	return c.conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+}
+
+func (c *taggedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.applyTag(ctx)
+	tx, err := c.conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.inTx = true
+	return &taggedTx{Tx: tx, conn: c}, nil
+}
+
+func (c *taggedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(query)
+}
+
+func (c *taggedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *taggedConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *taggedConn) Ping(ctx context.Context) error {
+	return c.conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c *taggedConn) ResetSession(ctx context.Context) error {
+	return c.conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c *taggedConn) IsValid() bool {
+	return c.conn.(driver.Validator).IsValid()
+}
+
+type taggedTx struct {
+	driver.Tx
+	conn *taggedConn
+}
+
+func (t *taggedTx) Commit() error {
+	t.conn.inTx = false
+	return t.Tx.Commit()
+}
+
+func (t *taggedTx) Rollback() error {
+	t.conn.inTx = false
+	return t.Tx.Rollback()
+}
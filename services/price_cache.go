@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// priceBalanceCache holds every price and balance in memory, so search results can be enriched
+// without a query per page. It is filled by RefreshPriceBalanceCache; until then, and once the
+// data is older than maxAge, lookups fall through to PostgreSQL.
+type priceBalanceCache struct {
+	mu       sync.RWMutex
+	prices   map[string]*PriceInfo
+	balances map[string]*BalanceInfo
+	loadedAt time.Time
+	maxAge   time.Duration
+}
+
+// RefreshPriceBalanceCache reloads ic_inventory_price_formula and ic_balance into memory and
+// serves them to the filtered loaders for up to maxAge
+func (s *PostgreSQLService) RefreshPriceBalanceCache(ctx context.Context, maxAge time.Duration) (string, error) {
+	prices, err := s.LoadPriceFormula(ctx)
+	if err != nil {
+		return "", err
+	}
+	balances, err := s.LoadBalanceData(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.priceCache.mu.Lock()
+	s.priceCache.prices = prices
+	s.priceCache.balances = balances
+	s.priceCache.loadedAt = time.Now()
+	s.priceCache.maxAge = maxAge
+	s.priceCache.mu.Unlock()
+
+	return fmt.Sprintf("%d prices, %d balances cached", len(prices), len(balances)), nil
+}
+
+func (c *priceBalanceCache) freshLocked() bool {
+	return c.prices != nil && time.Since(c.loadedAt) <= c.maxAge
+}
+
+// cachedPrices returns copies of the cached prices of icCodes, or false when the cache is not fresh
+func (c *priceBalanceCache) cachedPrices(icCodes []string) (map[string]*PriceInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.freshLocked() {
+		return nil, false
+	}
+
+	result := make(map[string]*PriceInfo, len(icCodes))
+	for _, code := range icCodes {
+		if price, ok := c.prices[code]; ok {
+			copied := *price
+			result[code] = &copied
+		}
+	}
+	return result, true
+}
+
+// cachedBalances returns copies of the cached balances of icCodes, or false when the cache is not fresh
+func (c *priceBalanceCache) cachedBalances(icCodes []string) (map[string]*BalanceInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.freshLocked() {
+		return nil, false
+	}
+
+	result := make(map[string]*BalanceInfo, len(icCodes))
+	for _, code := range icCodes {
+		if balance, ok := c.balances[code]; ok {
+			copied := *balance
+			result[code] = &copied
+		}
+	}
+	return result, true
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"smlgoapi/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrProductNotFound is returned when neither an ic_code nor a barcode matches
+var ErrProductNotFound = errors.New("product not found")
+
+// priceTierCount is the number of price_N columns in ic_inventory_price_formula
+const priceTierCount = 5
+
+// GetProductDetail returns the merged record of a product identified by ic_code or barcode.
+// Optional tables that do not exist leave their part of the record empty.
+func (s *PostgreSQLService) GetProductDetail(ctx context.Context, codeOrBarcode string) (*models.ProductDetail, error) {
+	if !s.tableExists(ctx, "ic_inventory") {
+		return nil, fmt.Errorf("table 'ic_inventory' not found in database")
+	}
+
+	detail := &models.ProductDetail{MatchedBy: "code"}
+	inventory, err := s.loadInventoryRecord(ctx, codeOrBarcode)
+	if errors.Is(err, ErrProductNotFound) && s.tableExists(ctx, "ic_inventory_barcode") {
+		var icCode string
+		err = s.db.QueryRowContext(ctx, s.fields.Expand(
+			`SELECT CAST({barcode_ic_code} AS TEXT) FROM ic_inventory_barcode WHERE CAST({barcode} AS TEXT) = $1 LIMIT 1`),
+			codeOrBarcode).Scan(&icCode)
+		if err == sql.ErrNoRows {
+			return nil, ErrProductNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up barcode: %w", err)
+		}
+		detail.MatchedBy = "barcode"
+		inventory, err = s.loadInventoryRecord(ctx, icCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	detail.Inventory = inventory
+	detail.ICCode = jsonString(inventory["code"])
+	detail.Images = productImages(inventory)
+
+	codes := []string{detail.ICCode}
+
+	barcodes, err := s.loadProductBarcodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	detail.Barcodes = barcodes[detail.ICCode]
+
+	prices, err := s.loadProductPrices(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	detail.Prices = prices[detail.ICCode]
+
+	balances, err := s.loadWarehouseBalances(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	detail.Balances = balances[detail.ICCode]
+	for _, balance := range detail.Balances {
+		detail.TotalBalance += balance.Quantity
+	}
+
+	if detail.Barcodes == nil {
+		detail.Barcodes = []models.ProductBarcode{}
+	}
+	if detail.Prices == nil {
+		detail.Prices = []models.ProductPrice{}
+	}
+	if detail.Balances == nil {
+		detail.Balances = []models.WarehouseBalance{}
+	}
+	return detail, nil
+}
+
+// GetProductsBatch looks up products by ic_code or barcode and enriches them with price and stock.
+// Products are returned in the order of codes; codes matching nothing are listed in NotFound.
+func (s *PostgreSQLService) GetProductsBatch(ctx context.Context, codes []string) (*models.ProductBatchResponse, error) {
+	if !s.tableExists(ctx, "ic_inventory") {
+		return nil, fmt.Errorf("table 'ic_inventory' not found in database")
+	}
+
+	inventory, err := s.loadInventorySummaries(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Codes that are not ic_codes are tried as barcodes
+	var unmatched []string
+	for _, code := range codes {
+		if _, ok := inventory[code]; !ok {
+			unmatched = append(unmatched, code)
+		}
+	}
+	barcodeOwners := make(map[string]string)
+	if len(unmatched) > 0 && s.tableExists(ctx, "ic_inventory_barcode") {
+		barcodeOwners, err = s.loadBarcodeOwners(ctx, unmatched)
+		if err != nil {
+			return nil, err
+		}
+		var owners []string
+		for _, icCode := range barcodeOwners {
+			if _, ok := inventory[icCode]; !ok {
+				owners = append(owners, icCode)
+			}
+		}
+		if len(owners) > 0 {
+			more, err := s.loadInventorySummaries(ctx, owners)
+			if err != nil {
+				return nil, err
+			}
+			for icCode, product := range more {
+				inventory[icCode] = product
+			}
+		}
+	}
+
+	icCodes := make([]string, 0, len(inventory))
+	for icCode := range inventory {
+		icCodes = append(icCodes, icCode)
+	}
+
+	priceMap, err := s.LoadPriceFormulaFiltered(ctx, icCodes)
+	if err != nil {
+		return nil, err
+	}
+	balanceMap, err := s.LoadBalanceDataFiltered(ctx, icCodes)
+	if err != nil {
+		return nil, err
+	}
+	barcodes, err := s.loadProductBarcodes(ctx, icCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ProductBatchResponse{
+		Products: make([]models.BatchProduct, 0, len(codes)),
+		NotFound: []string{},
+	}
+	for _, code := range codes {
+		matchedBy := "code"
+		product, ok := inventory[code]
+		if !ok {
+			matchedBy = "barcode"
+			product, ok = inventory[barcodeOwners[code]]
+		}
+		if !ok {
+			response.NotFound = append(response.NotFound, code)
+			continue
+		}
+
+		product.Query = code
+		product.MatchedBy = matchedBy
+		product.Prices = make([]float64, priceTierCount)
+		if price, ok := priceMap[product.ICCode]; ok {
+			product.Prices = []float64{price.Price0, price.Price1, price.Price2, price.Price3, price.Price4}
+			product.SalePrice = price.Price0
+		}
+		if balance, ok := balanceMap[product.ICCode]; ok {
+			product.QtyAvailable = balance.TotalQty
+		}
+		product.Barcodes = []string{}
+		for _, barcode := range barcodes[product.ICCode] {
+			product.Barcodes = append(product.Barcodes, barcode.Barcode)
+		}
+		response.Products = append(response.Products, product)
+	}
+	return response, nil
+}
+
+// loadInventorySummaries returns the ic_inventory fields used by batch lookups, keyed by ic_code
+func (s *PostgreSQLService) loadInventorySummaries(ctx context.Context, icCodes []string) (map[string]models.BatchProduct, error) {
+	rows, err := s.db.QueryContext(ctx, s.fields.Expand(`
+		SELECT CAST({code} AS TEXT),
+		       `+productNameExpr+`,
+		       COALESCE(CAST({unit_standard_code} AS TEXT), 'N/A')
+		FROM ic_inventory
+		WHERE CAST({code} AS TEXT) = ANY($1)`), pq.Array(icCodes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load products: %w", err)
+	}
+	defer rows.Close()
+
+	products := make(map[string]models.BatchProduct)
+	for rows.Next() {
+		var product models.BatchProduct
+		if err := rows.Scan(&product.ICCode, &product.Name, &product.UnitStandardCode); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		products[product.ICCode] = product
+	}
+	return products, rows.Err()
+}
+
+// loadBarcodeOwners maps each barcode found in ic_inventory_barcode to its ic_code
+func (s *PostgreSQLService) loadBarcodeOwners(ctx context.Context, barcodes []string) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, s.fields.Expand(`
+		SELECT DISTINCT ON (CAST({barcode} AS TEXT)) CAST({barcode} AS TEXT), CAST({barcode_ic_code} AS TEXT)
+		FROM ic_inventory_barcode
+		WHERE CAST({barcode} AS TEXT) = ANY($1) AND {barcode_ic_code} IS NOT NULL`), pq.Array(barcodes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up barcodes: %w", err)
+	}
+	defer rows.Close()
+
+	owners := make(map[string]string)
+	for rows.Next() {
+		var barcode, icCode string
+		if err := rows.Scan(&barcode, &icCode); err != nil {
+			return nil, fmt.Errorf("failed to scan barcode: %w", err)
+		}
+		owners[barcode] = icCode
+	}
+	return owners, rows.Err()
+}
+
+// tableExists reports whether a table exists in the public schema; lookup errors count as missing
+func (s *PostgreSQLService) tableExists(ctx context.Context, table string) bool {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = 'public' AND table_name = $1)`,
+		table).Scan(&exists)
+	return err == nil && exists
+}
+
+// loadInventoryRecord returns every column of the ic_inventory row with the given code
+func (s *PostgreSQLService) loadInventoryRecord(ctx context.Context, code string) (map[string]interface{}, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, s.fields.Expand(
+		`SELECT to_jsonb(i) FROM ic_inventory i WHERE CAST({i.code} AS TEXT) = $1 LIMIT 1`), code).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ic_inventory record: %w", err)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode ic_inventory record: %w", err)
+	}
+	return record, nil
+}
+
+// queryJSONRows runs a query selecting (ic_code, to_jsonb(row)) and groups the decoded rows by ic_code
+func (s *PostgreSQLService) queryJSONRows(ctx context.Context, query string, args ...interface{}) (map[string][]map[string]interface{}, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grouped := make(map[string][]map[string]interface{})
+	for rows.Next() {
+		var icCode string
+		var data []byte
+		if err := rows.Scan(&icCode, &data); err != nil {
+			return nil, err
+		}
+		var row map[string]interface{}
+		if err := json.Unmarshal(data, &row); err != nil {
+			return nil, err
+		}
+		grouped[icCode] = append(grouped[icCode], row)
+	}
+	return grouped, rows.Err()
+}
+
+// loadProductBarcodes returns the ic_inventory_barcode rows of the given products
+func (s *PostgreSQLService) loadProductBarcodes(ctx context.Context, icCodes []string) (map[string][]models.ProductBarcode, error) {
+	result := make(map[string][]models.ProductBarcode)
+	if len(icCodes) == 0 || !s.tableExists(ctx, "ic_inventory_barcode") {
+		return result, nil
+	}
+
+	grouped, err := s.queryJSONRows(ctx, s.fields.Expand(`
+		SELECT CAST({b.barcode_ic_code} AS TEXT), to_jsonb(b)
+		FROM ic_inventory_barcode b
+		WHERE CAST({b.barcode_ic_code} AS TEXT) = ANY($1)
+		ORDER BY {b.barcode}`), pq.Array(icCodes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load barcodes: %w", err)
+	}
+
+	for icCode, rows := range grouped {
+		for _, row := range rows {
+			result[icCode] = append(result[icCode], models.ProductBarcode{
+				Barcode:  jsonString(row["barcode"]),
+				UnitCode: jsonString(row["unit_code"]),
+			})
+		}
+	}
+	return result, nil
+}
+
+// loadProductPrices returns the price formula rows of the given products
+func (s *PostgreSQLService) loadProductPrices(ctx context.Context, icCodes []string) (map[string][]models.ProductPrice, error) {
+	result := make(map[string][]models.ProductPrice)
+	if len(icCodes) == 0 || !s.tableExists(ctx, "ic_inventory_price_formula") {
+		return result, nil
+	}
+
+	grouped, err := s.queryJSONRows(ctx, `
+		SELECT CAST(p.ic_code AS TEXT), to_jsonb(p)
+		FROM ic_inventory_price_formula p
+		WHERE CAST(p.ic_code AS TEXT) = ANY($1)`, pq.Array(icCodes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load price formula: %w", err)
+	}
+
+	for icCode, rows := range grouped {
+		for _, row := range rows {
+			price := models.ProductPrice{
+				UnitCode: jsonString(row["unit_code"]),
+				Tiers:    make([]float64, priceTierCount),
+			}
+			for i := range price.Tiers {
+				// Prices are stored as text in some databases, the same as LoadPriceFormula expects
+				price.Tiers[i], _ = strconv.ParseFloat(strings.TrimSpace(jsonString(row[fmt.Sprintf("price_%d", i)])), 64)
+			}
+			result[icCode] = append(result[icCode], price)
+		}
+		sort.SliceStable(result[icCode], func(i, j int) bool {
+			return result[icCode][i].UnitCode < result[icCode][j].UnitCode
+		})
+	}
+	return result, nil
+}
+
+// loadWarehouseBalances returns the ic_balance quantity per warehouse of the given products
+func (s *PostgreSQLService) loadWarehouseBalances(ctx context.Context, icCodes []string) (map[string][]models.WarehouseBalance, error) {
+	result := make(map[string][]models.WarehouseBalance)
+	if len(icCodes) == 0 || !s.tableExists(ctx, "ic_balance") {
+		return result, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT CAST(ic_code AS TEXT), COALESCE(CAST(wh_code AS TEXT), ''), COALESCE(SUM(balance_qty), 0)
+		FROM ic_balance
+		WHERE CAST(ic_code AS TEXT) = ANY($1)
+		GROUP BY ic_code, wh_code
+		ORDER BY wh_code`, pq.Array(icCodes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load warehouse balances: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var icCode string
+		var balance models.WarehouseBalance
+		if err := rows.Scan(&icCode, &balance.WarehouseCode, &balance.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan warehouse balance: %w", err)
+		}
+		result[icCode] = append(result[icCode], balance)
+	}
+	return result, rows.Err()
+}
+
+// productImages extracts image URLs from the inventory record. image_url holds either a single
+// URL or a JSON array of URLs, depending on how the product was imported.
+func productImages(inventory map[string]interface{}) []string {
+	images := []string{}
+	for _, column := range []string{"image_url", "img_url"} {
+		switch value := inventory[column].(type) {
+		case []interface{}:
+			for _, item := range value {
+				if url := strings.TrimSpace(jsonString(item)); url != "" {
+					images = append(images, url)
+				}
+			}
+		case string:
+			value = strings.TrimSpace(value)
+			var urls []string
+			if strings.HasPrefix(value, "[") && json.Unmarshal([]byte(value), &urls) == nil {
+				for _, url := range urls {
+					if url = strings.TrimSpace(url); url != "" {
+						images = append(images, url)
+					}
+				}
+			} else if value != "" && value != "N/A" {
+				images = append(images, value)
+			}
+		}
+	}
+	return images
+}
+
+// jsonString formats a decoded JSON value as text; null becomes ""
+func jsonString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smlgoapi/models"
+)
+
+// ProductEventService records product view events in ClickHouse and computes
+// trending and recently viewed lists from them
+type ProductEventService struct {
+	clickHouseService *ClickHouseService
+}
+
+// NewProductEventService creates a new product event service backed by ClickHouse
+func NewProductEventService(clickHouseService *ClickHouseService) *ProductEventService {
+	return &ProductEventService{
+		clickHouseService: clickHouseService,
+	}
+}
+
+// EnsureSchema creates the product_view_events table if it does not exist
+func (s *ProductEventService) EnsureSchema(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS product_view_events (
+			event_time DateTime DEFAULT now(),
+			ic_code    String,
+			client_id  String,
+			source     String
+		) ENGINE = MergeTree()
+		ORDER BY (ic_code, event_time)
+		TTL event_time + INTERVAL 90 DAY`
+
+	if _, err := s.clickHouseService.db.ExecContext(tagContext(ctx), query); err != nil {
+		return fmt.Errorf("failed to create product_view_events table: %w", err)
+	}
+	return nil
+}
+
+// RecordView stores a single product view event
+func (s *ProductEventService) RecordView(ctx context.Context, event models.ProductViewRequest) error {
+	query := `INSERT INTO product_view_events (event_time, ic_code, client_id, source) VALUES (?, ?, ?, ?)`
+
+	if _, err := s.clickHouseService.db.ExecContext(tagContext(ctx), query, time.Now(), event.ICCode, event.ClientID, event.Source); err != nil {
+		return fmt.Errorf("failed to record product view: %w", err)
+	}
+	return nil
+}
+
+// GetTrending returns the most viewed products within the last `days` days
+func (s *ProductEventService) GetTrending(ctx context.Context, days, limit int) ([]models.TrendingProduct, error) {
+	query := `
+		SELECT ic_code,
+		       count() AS views,
+		       uniqExact(client_id) AS unique_clients,
+		       max(event_time) AS last_viewed
+		FROM product_view_events
+		WHERE event_time >= ?
+		GROUP BY ic_code
+		ORDER BY views DESC, last_viewed DESC
+		LIMIT ?`
+
+	since := time.Now().AddDate(0, 0, -days)
+	rows, err := s.clickHouseService.db.QueryContext(tagContext(ctx), query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trending products: %w", err)
+	}
+	defer rows.Close()
+
+	var trending []models.TrendingProduct
+	for rows.Next() {
+		var item models.TrendingProduct
+		var views, uniqueClients uint64
+		if err := rows.Scan(&item.ICCode, &views, &uniqueClients, &item.LastViewed); err != nil {
+			return nil, fmt.Errorf("failed to scan trending product: %w", err)
+		}
+		item.Views = int64(views)
+		item.UniqueClients = int64(uniqueClients)
+		trending = append(trending, item)
+	}
+
+	return trending, rows.Err()
+}
+
+// GetRecentlyViewed returns the products most recently viewed by a client, newest first
+func (s *ProductEventService) GetRecentlyViewed(ctx context.Context, clientID string, limit int) ([]models.TrendingProduct, error) {
+	query := `
+		SELECT ic_code,
+		       count() AS views,
+		       max(event_time) AS last_viewed
+		FROM product_view_events
+		WHERE client_id = ?
+		GROUP BY ic_code
+		ORDER BY last_viewed DESC
+		LIMIT ?`
+
+	rows, err := s.clickHouseService.db.QueryContext(tagContext(ctx), query, clientID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recently viewed products: %w", err)
+	}
+	defer rows.Close()
+
+	var recent []models.TrendingProduct
+	for rows.Next() {
+		var item models.TrendingProduct
+		var views uint64
+		if err := rows.Scan(&item.ICCode, &views, &item.LastViewed); err != nil {
+			return nil, fmt.Errorf("failed to scan recently viewed product: %w", err)
+		}
+		item.Views = int64(views)
+		recent = append(recent, item)
+	}
+
+	return recent, rows.Err()
+}
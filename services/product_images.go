@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"smlgoapi/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrProductImageNotFound is returned for image ids that do not exist or belong to another product
+var ErrProductImageNotFound = errors.New("product image not found")
+
+// ErrInvalidProductImage is returned for image URLs and uploads that cannot be stored
+var ErrInvalidProductImage = errors.New("invalid product image")
+
+// productImageTypes are the accepted uploads, by the content type sniffed from their first bytes
+var productImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ProductImageService links images to ic_inventory products in the PostgreSQL product_images
+// table. Uploaded files are stored in the table as well, so every instance serves them.
+type ProductImageService struct {
+	postgreSQLService *PostgreSQLService
+}
+
+// NewProductImageService creates a new product image service
+func NewProductImageService(postgreSQLService *PostgreSQLService) *ProductImageService {
+	return &ProductImageService{postgreSQLService: postgreSQLService}
+}
+
+// EnsureSchema creates the product_images table if it does not exist
+func (s *ProductImageService) EnsureSchema(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS product_images (
+			id           BIGSERIAL PRIMARY KEY,
+			ic_code      TEXT NOT NULL,
+			url          TEXT NOT NULL DEFAULT '',
+			content_type TEXT NOT NULL DEFAULT '',
+			data         BYTEA,
+			phash        BIGINT,
+			sort_order   INTEGER NOT NULL DEFAULT 0,
+			created_by   TEXT NOT NULL DEFAULT '',
+			created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		ALTER TABLE product_images ADD COLUMN IF NOT EXISTS phash BIGINT;
+		CREATE INDEX IF NOT EXISTS product_images_ic_code_idx ON product_images (ic_code, sort_order, id)`
+
+	if _, err := s.postgreSQLService.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create product_images table: %w", err)
+	}
+	return nil
+}
+
+// ProductImageURL is the path serving an uploaded image
+func ProductImageURL(id int64) string {
+	return fmt.Sprintf("/v1/product-images/%d", id)
+}
+
+// List returns the images of a product, first image first
+func (s *ProductImageService) List(ctx context.Context, icCode string) ([]models.ProductImage, error) {
+	rows, err := s.postgreSQLService.db.QueryContext(ctx, `
+		SELECT id, ic_code, url, content_type, COALESCE(length(data), 0), phash, sort_order, created_by, created_at
+		FROM product_images
+		WHERE ic_code = $1
+		ORDER BY sort_order, id`, icCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product images: %w", err)
+	}
+	defer rows.Close()
+
+	images := []models.ProductImage{}
+	for rows.Next() {
+		image, err := scanProductImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, *image)
+	}
+	return images, rows.Err()
+}
+
+// AddURL links an http(s) image URL to a product
+func (s *ProductImageService) AddURL(ctx context.Context, icCode string, req models.ProductImageRequest, createdBy string) (*models.ProductImage, error) {
+	link := strings.TrimSpace(req.URL)
+	if u, err := url.Parse(link); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidProductImage)
+	}
+	if err := s.postgreSQLService.checkProductExists(ctx, icCode); err != nil {
+		return nil, err
+	}
+	return s.insert(ctx, icCode, link, "", nil, nil, req.SortOrder, createdBy)
+}
+
+// Upload stores an image file for a product. The content type is sniffed from the data rather
+// than trusted from the client.
+func (s *ProductImageService) Upload(ctx context.Context, icCode string, data []byte, sortOrder int, createdBy string) (*models.ProductImage, error) {
+	contentType := http.DetectContentType(data)
+	if !productImageTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s is not a JPEG, PNG, GIF or WebP image", ErrInvalidProductImage, contentType)
+	}
+	hash, err := ImageHash(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProductImage, err)
+	}
+	if err := s.postgreSQLService.checkProductExists(ctx, icCode); err != nil {
+		return nil, err
+	}
+	return s.insert(ctx, icCode, "", contentType, data, int64(hash), sortOrder, createdBy)
+}
+
+// insert stores an image; phash is nil for linked URLs, which are never downloaded
+func (s *ProductImageService) insert(ctx context.Context, icCode, link, contentType string, data []byte, phash interface{}, sortOrder int, createdBy string) (*models.ProductImage, error) {
+	row := s.postgreSQLService.db.QueryRowContext(ctx, `
+		INSERT INTO product_images (ic_code, url, content_type, data, phash, sort_order, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, ic_code, url, content_type, COALESCE(length(data), 0), phash, sort_order, created_by, created_at`,
+		icCode, link, contentType, data, phash, sortOrder, createdBy)
+	image, err := scanProductImage(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save product image: %w", err)
+	}
+	return image, nil
+}
+
+// Delete removes an image of a product
+func (s *ProductImageService) Delete(ctx context.Context, icCode string, id int64) error {
+	result, err := s.postgreSQLService.db.ExecContext(ctx,
+		`DELETE FROM product_images WHERE ic_code = $1 AND id = $2`, icCode, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete product image: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrProductImageNotFound
+	}
+	return nil
+}
+
+// File returns the content type and bytes of an uploaded image
+func (s *ProductImageService) File(ctx context.Context, id int64) (string, []byte, error) {
+	var contentType string
+	var data []byte
+	err := s.postgreSQLService.db.QueryRowContext(ctx,
+		`SELECT content_type, data FROM product_images WHERE id = $1 AND data IS NOT NULL`, id).Scan(&contentType, &data)
+	if err == sql.ErrNoRows {
+		return "", nil, ErrProductImageNotFound
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load product image: %w", err)
+	}
+	return contentType, data, nil
+}
+
+// FirstImages returns the URL of the first image of each product that has one
+func (s *ProductImageService) FirstImages(ctx context.Context, icCodes []string) (map[string]string, error) {
+	result := make(map[string]string)
+	if len(icCodes) == 0 {
+		return result, nil
+	}
+	rows, err := s.postgreSQLService.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (ic_code) ic_code, id, url
+		FROM product_images
+		WHERE ic_code = ANY($1)
+		ORDER BY ic_code, sort_order, id`, pq.Array(icCodes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product images: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var icCode, link string
+		var id int64
+		if err := rows.Scan(&icCode, &id, &link); err != nil {
+			return nil, err
+		}
+		if link == "" {
+			link = ProductImageURL(id)
+		}
+		result[icCode] = link
+	}
+	return result, rows.Err()
+}
+
+func scanProductImage(row rowScanner) (*models.ProductImage, error) {
+	var image models.ProductImage
+	var phash sql.NullInt64
+	if err := row.Scan(&image.ID, &image.ICCode, &image.URL, &image.ContentType, &image.SizeBytes,
+		&phash, &image.SortOrder, &image.CreatedBy, &image.CreatedAt); err != nil {
+		return nil, err
+	}
+	if phash.Valid {
+		image.Hash = fmt.Sprintf("%016x", uint64(phash.Int64))
+	}
+	if image.URL == "" {
+		image.Uploaded = true
+		image.URL = ProductImageURL(image.ID)
+	}
+	return &image, nil
+}
+
+// Duplicates groups the uploaded images of different products whose hashes are at most
+// maxDistance bits apart. Images uploaded before hashing existed are hashed first.
+func (s *ProductImageService) Duplicates(ctx context.Context, maxDistance int) (*models.ImageDuplicatesResponse, error) {
+	if err := s.hashMissing(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.postgreSQLService.db.QueryContext(ctx, `
+		SELECT id, ic_code, url, content_type, COALESCE(length(data), 0), phash, sort_order, created_by, created_at
+		FROM product_images
+		WHERE phash IS NOT NULL
+		ORDER BY ic_code, sort_order, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image hashes: %w", err)
+	}
+	defer rows.Close()
+
+	var images []models.ProductImage
+	var hashed []hashedImage
+	for rows.Next() {
+		image, err := scanProductImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		hash, _ := strconv.ParseUint(image.Hash, 16, 64)
+		hashed = append(hashed, hashedImage{index: len(images), code: image.ICCode, hash: hash})
+		images = append(images, *image)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	response := &models.ImageDuplicatesResponse{
+		MaxDistance: maxDistance,
+		Compared:    len(images),
+		Groups:      []models.ImageDuplicateGroup{},
+	}
+	for _, cluster := range clusterDuplicates(hashed, maxDistance) {
+		group := models.ImageDuplicateGroup{Distance: cluster.distance}
+		seen := make(map[string]bool)
+		for _, i := range cluster.indexes {
+			group.Images = append(group.Images, images[i])
+			if !seen[images[i].ICCode] {
+				seen[images[i].ICCode] = true
+				group.ICCodes = append(group.ICCodes, images[i].ICCode)
+			}
+		}
+		response.Groups = append(response.Groups, group)
+	}
+	return response, nil
+}
+
+// hashMissing hashes uploaded images stored without a hash
+func (s *ProductImageService) hashMissing(ctx context.Context) error {
+	rows, err := s.postgreSQLService.db.QueryContext(ctx,
+		`SELECT id, data FROM product_images WHERE phash IS NULL AND data IS NOT NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to load unhashed images: %w", err)
+	}
+	hashes := make(map[int64]uint64)
+	for rows.Next() {
+		var id int64
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			rows.Close()
+			return err
+		}
+		hash, err := ImageHash(data)
+		if err != nil {
+			log.Printf("⚠️ [products] Image %d cannot be hashed: %v", id, err)
+			continue
+		}
+		hashes[id] = hash
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, hash := range hashes {
+		if _, err := s.postgreSQLService.db.ExecContext(ctx,
+			`UPDATE product_images SET phash = $1 WHERE id = $2`, int64(hash), id); err != nil {
+			return fmt.Errorf("failed to store hash of image %d: %w", id, err)
+		}
+	}
+	if len(hashes) > 0 {
+		log.Printf("🖼️ [products] Hashed %d images uploaded before duplicate detection", len(hashes))
+	}
+	return nil
+}
+
+// checkProductExists returns ErrProductNotFound unless ic_code is in ic_inventory
+func (s *PostgreSQLService) checkProductExists(ctx context.Context, icCode string) error {
+	if !s.tableExists(ctx, "ic_inventory") {
+		return fmt.Errorf("table 'ic_inventory' not found in database")
+	}
+	var exists bool
+	err := s.db.QueryRowContext(ctx, s.fields.Expand(
+		`SELECT EXISTS (SELECT 1 FROM ic_inventory i WHERE CAST({i.code} AS TEXT) = $1)`), icCode).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to look up product: %w", err)
+	}
+	if !exists {
+		return ErrProductNotFound
+	}
+	return nil
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"smlgoapi/models"
+
+	"github.com/lib/pq"
+	"github.com/xuri/excelize/v2"
+)
+
+// MaxImportRows caps the data rows of one product import
+const MaxImportRows = 100000
+
+// ErrInvalidImport is returned for import files that cannot be processed at all; problems with
+// single rows are reported per row instead
+var ErrInvalidImport = errors.New("invalid import file")
+
+// Import columns that are not ic_inventory columns
+const (
+	importBarcode  = "barcode"   // a row of ic_inventory_barcode
+	importUnitCode = "unit_code" // unit of the barcode and of the prices
+)
+
+// importInventoryFields are the logical fields an import header may name instead of the column
+var importInventoryFields = []string{FieldCode, FieldName, FieldUnitStandardCode, FieldItemType, FieldRowOrderRef, FieldSupplierCode}
+
+// ImportTable is a parsed CSV or XLSX file: the normalized header and the data rows. Blank rows
+// are dropped, so Lines holds the record number of each row in the file, the header being 1.
+type ImportTable struct {
+	Header []string
+	Rows   [][]string
+	Lines  []int
+}
+
+// ParseImportFile reads the header and rows of a CSV file or of the first worksheet of an XLSX
+// file. The format follows the file name, or the content when the name has no known extension.
+func ParseImportFile(name string, data []byte) (*ImportTable, error) {
+	var records [][]string
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(name)); {
+	case ext == ".xlsx" || (ext != ".csv" && bytes.HasPrefix(data, []byte("PK\x03\x04"))):
+		records, err = readXLSXRecords(data)
+	default:
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		records, err = reader.ReadAll()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImport)
+	}
+	if len(records)-1 > MaxImportRows {
+		return nil, fmt.Errorf("%w: %d rows, the limit is %d", ErrInvalidImport, len(records)-1, MaxImportRows)
+	}
+
+	table := &ImportTable{Header: make([]string, len(records[0]))}
+	for i, column := range records[0] {
+		table.Header[i] = strings.ToLower(strings.TrimSpace(column))
+	}
+	for i, record := range records[1:] {
+		if !blankRecord(record) {
+			table.Rows = append(table.Rows, record)
+			table.Lines = append(table.Lines, i+2)
+		}
+	}
+	return table, nil
+}
+
+func readXLSXRecords(data []byte) ([][]string, error) {
+	file, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("the workbook has no worksheet")
+	}
+	return file.GetRows(sheets[0])
+}
+
+func blankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// importPlan maps the columns of an import file to the tables they are written to
+type importPlan struct {
+	code      int            // index of the product code
+	inventory map[int]string // index -> ic_inventory column
+	barcode   int            // -1 without a barcode column
+	unitCode  int
+	prices    map[int]string // index -> price_N column of ic_inventory_price_formula
+
+	barcodeUnitColumn bool // ic_inventory_barcode has unit_code
+}
+
+// planImport checks the header against the tables before any row is written
+func (s *PostgreSQLService) planImport(ctx context.Context, header []string) (*importPlan, error) {
+	if !s.tableExists(ctx, "ic_inventory") {
+		return nil, fmt.Errorf("table 'ic_inventory' not found in database")
+	}
+	inventoryColumns, err := s.tableColumns(ctx, "ic_inventory")
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{code: -1, barcode: -1, unitCode: -1, inventory: make(map[int]string), prices: make(map[int]string)}
+	seen := make(map[string]bool)
+	var unknown []string
+	for i, name := range header {
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: column %q appears twice", ErrInvalidImport, name)
+		}
+		seen[name] = true
+
+		column := name
+		for _, field := range importInventoryFields {
+			if name == field {
+				column = s.fields.Column(field)
+			}
+		}
+		switch {
+		case column == s.fields.Column(FieldCode):
+			plan.code = i
+		case name == importBarcode:
+			plan.barcode = i
+		case name == importUnitCode:
+			plan.unitCode = i
+		case importPriceColumn(name):
+			plan.prices[i] = name
+		case inventoryColumns[column]:
+			plan.inventory[i] = column
+		default:
+			unknown = append(unknown, name)
+		}
+	}
+
+	switch {
+	case plan.code < 0:
+		return nil, fmt.Errorf("%w: a %q column is required", ErrInvalidImport, FieldCode)
+	case len(unknown) > 0:
+		return nil, fmt.Errorf("%w: unknown columns %s; use ic_inventory columns, %s, %s or price_0 to price_%d",
+			ErrInvalidImport, strings.Join(unknown, ", "), importBarcode, importUnitCode, priceTierCount-1)
+	case plan.barcode >= 0 && !s.tableExists(ctx, "ic_inventory_barcode"):
+		return nil, fmt.Errorf("%w: the barcode column needs table 'ic_inventory_barcode'", ErrInvalidImport)
+	case len(plan.prices) > 0 && !s.tableExists(ctx, "ic_inventory_price_formula"):
+		return nil, fmt.Errorf("%w: price columns need table 'ic_inventory_price_formula'", ErrInvalidImport)
+	}
+	if plan.barcode >= 0 {
+		barcodeColumns, err := s.tableColumns(ctx, "ic_inventory_barcode")
+		if err != nil {
+			return nil, err
+		}
+		plan.barcodeUnitColumn = barcodeColumns[importUnitCode]
+	}
+	return plan, nil
+}
+
+func importPriceColumn(name string) bool {
+	n, err := strconv.Atoi(strings.TrimPrefix(name, "price_"))
+	return strings.HasPrefix(name, "price_") && err == nil && n >= 0 && n < priceTierCount
+}
+
+// tableColumns returns the column names of a table in the public schema
+func (s *PostgreSQLService) tableColumns(ctx context.Context, table string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = 'public' AND table_name = $1`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns[column] = true
+	}
+	return columns, rows.Err()
+}
+
+// ImportStatus is the progress of the current or last product import
+type ImportStatus struct {
+	Running    bool       `json:"running"`
+	File       string     `json:"file"`
+	DryRun     bool       `json:"dry_run"`
+	Rows       int        `json:"rows"`
+	Processed  int        `json:"processed"`
+	Imported   int        `json:"imported"`
+	Rejected   int        `json:"rejected"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// ImportProgress follows the product import in progress, for clients watching it over /v1/ws.
+// A nil ImportProgress records nothing.
+type ImportProgress struct {
+	mu     sync.Mutex
+	status ImportStatus
+}
+
+// Status returns a copy of the current progress
+func (p *ImportProgress) Status() ImportStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+func (p *ImportProgress) begin(file string, rows int, dryRun bool) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status = ImportStatus{Running: true, File: file, DryRun: dryRun, Rows: rows, StartedAt: &now}
+}
+
+func (p *ImportProgress) row(imported bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status.Processed++
+	if imported {
+		p.status.Imported++
+	} else {
+		p.status.Rejected++
+	}
+}
+
+func (p *ImportProgress) finish(err error) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status.Running = false
+	p.status.FinishedAt = &now
+	if err != nil {
+		p.status.Error = err.Error()
+	}
+}
+
+// ImportProducts upserts the rows of an import file into ic_inventory, ic_inventory_barcode and
+// ic_inventory_price_formula in one transaction. Empty cells leave the stored value unchanged.
+// A row that fails is rolled back on its own and reported; the others are committed, unless
+// dryRun rolls everything back after the rows were checked against the database. Progress, when
+// not nil, is updated after every row.
+func (s *PostgreSQLService) ImportProducts(ctx context.Context, file string, table *ImportTable, dryRun bool, progress *ImportProgress) (result *models.ProductImportResult, err error) {
+	progress.begin(file, len(table.Rows), dryRun)
+	defer func() { progress.finish(err) }()
+
+	plan, err := s.planImport(ctx, table.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result = &models.ProductImportResult{DryRun: dryRun, Rows: len(table.Rows), Errors: []models.ProductImportError{}}
+	for i, record := range table.Rows {
+		code := importCell(record, plan.code)
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT import_row"); err != nil {
+			return nil, fmt.Errorf("failed to import row %d: %w", table.Lines[i], err)
+		}
+		counts, err := s.importRow(ctx, tx, plan, record)
+		if err != nil {
+			if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_row"); rollbackErr != nil {
+				return nil, fmt.Errorf("failed to roll back row %d: %w", table.Lines[i], rollbackErr)
+			}
+			result.Errors = append(result.Errors, models.ProductImportError{Row: table.Lines[i], Code: code, Error: err.Error()})
+			progress.row(false)
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		progress.row(true)
+		result.Imported++
+		result.ProductsInserted += counts.inserted
+		result.ProductsUpdated += counts.updated
+		result.BarcodesUpserted += counts.barcodes
+		result.PricesUpserted += counts.prices
+	}
+	result.Rejected = len(result.Errors)
+
+	if !dryRun {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit import: %w", err)
+		}
+	}
+	return result, nil
+}
+
+type importCounts struct {
+	inserted, updated, barcodes, prices int
+}
+
+func (s *PostgreSQLService) importRow(ctx context.Context, tx *sql.Tx, plan *importPlan, record []string) (importCounts, error) {
+	var counts importCounts
+	code := importCell(record, plan.code)
+	if code == "" {
+		return counts, fmt.Errorf("%s is empty", FieldCode)
+	}
+	unitCode := importCell(record, plan.unitCode)
+
+	// Validate the prices before writing anything
+	priceColumns := make([]string, 0, len(plan.prices))
+	priceValues := make([]interface{}, 0, len(plan.prices))
+	for _, i := range sortedKeys(plan.prices) {
+		value := importCell(record, i)
+		if value == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return counts, fmt.Errorf("%s %q is not a number", plan.prices[i], value)
+		}
+		priceColumns = append(priceColumns, plan.prices[i])
+		priceValues = append(priceValues, value)
+	}
+	if len(priceColumns) > 0 && unitCode == "" {
+		return counts, fmt.Errorf("%s is required with prices", importUnitCode)
+	}
+
+	// ic_inventory: update the given columns, or insert the product
+	columns := []string{}
+	values := []interface{}{code}
+	for _, i := range sortedKeys(plan.inventory) {
+		if value := importCell(record, i); value != "" {
+			columns = append(columns, plan.inventory[i])
+			values = append(values, value)
+		}
+	}
+	updated, err := importUpdate(ctx, tx, "ic_inventory", columns, values,
+		s.fields.Expand("CAST({code} AS TEXT) = $1"))
+	if err != nil {
+		return counts, err
+	}
+	if updated {
+		counts.updated++
+	} else {
+		if err := importInsert(ctx, tx, "ic_inventory", append([]string{s.fields.Column(FieldCode)}, columns...), values); err != nil {
+			return counts, err
+		}
+		counts.inserted++
+	}
+
+	// ic_inventory_barcode: the barcode moves to this product if another one had it
+	if barcode := importCell(record, plan.barcode); barcode != "" {
+		columns := []string{s.fields.Column(FieldBarcodeICCode)}
+		values := []interface{}{barcode, code}
+		if plan.barcodeUnitColumn && unitCode != "" {
+			columns = append(columns, importUnitCode)
+			values = append(values, unitCode)
+		}
+		updated, err := importUpdate(ctx, tx, "ic_inventory_barcode", columns, values,
+			s.fields.Expand("CAST({barcode} AS TEXT) = $1"))
+		if err == nil && !updated {
+			err = importInsert(ctx, tx, "ic_inventory_barcode", append([]string{s.fields.Column(FieldBarcode)}, columns...), values)
+		}
+		if err != nil {
+			return counts, err
+		}
+		counts.barcodes++
+	}
+
+	// ic_inventory_price_formula: one row per product and unit
+	if len(priceColumns) > 0 {
+		values := append([]interface{}{code, unitCode}, priceValues...)
+		updated, err := importUpdate(ctx, tx, "ic_inventory_price_formula", priceColumns, values,
+			"CAST(ic_code AS TEXT) = $1 AND CAST(unit_code AS TEXT) = $2")
+		if err == nil && !updated {
+			err = importInsert(ctx, tx, "ic_inventory_price_formula", append([]string{"ic_code", importUnitCode}, priceColumns...), values)
+		}
+		if err != nil {
+			return counts, err
+		}
+		counts.prices++
+	}
+	return counts, nil
+}
+
+// importUpdate sets columns to the values following the key values of where, and reports whether
+// a row matched. Without columns it only checks that a row exists.
+func importUpdate(ctx context.Context, tx *sql.Tx, table string, columns []string, values []interface{}, where string) (bool, error) {
+	keys := len(values) - len(columns)
+	if len(columns) == 0 {
+		var exists bool
+		err := tx.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE %s)", table, where), values...).Scan(&exists)
+		return exists, err
+	}
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = fmt.Sprintf("%s = $%d", pq.QuoteIdentifier(column), keys+i+1)
+	}
+	res, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(assignments, ", "), where), values...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func importInsert(ctx context.Context, tx *sql.Tx, table string, columns []string, values []interface{}) error {
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = pq.QuoteIdentifier(column)
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(quoted, ", "), strings.Join(placeholders, ", ")), values...)
+	return err
+}
+
+func importCell(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func sortedKeys(m map[int]string) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sqlExpr is a SQL fragment with "?" placeholders and the arguments bound to them
+type sqlExpr struct {
+	sql  string
+	args []interface{}
+}
+
+// expr creates a SQL fragment; each "?" outside string literals binds the next argument
+func expr(sql string, args ...interface{}) sqlExpr {
+	return sqlExpr{sql: sql, args: args}
+}
+
+// orExpr joins fragments with OR, parenthesised so it composes with other WHERE conditions
+func orExpr(exprs ...sqlExpr) sqlExpr {
+	parts := make([]string, len(exprs))
+	var args []interface{}
+	for i, e := range exprs {
+		parts[i] = e.sql
+		args = append(args, e.args...)
+	}
+	return sqlExpr{sql: "(" + strings.Join(parts, " OR ") + ")", args: args}
+}
+
+// selectBuilder builds PostgreSQL SELECT statements from fragments. Placeholders are numbered
+// $1, $2, ... in the order they appear in the final statement, so values are never spliced into SQL.
+type selectBuilder struct {
+	columns []sqlExpr
+	from    string
+	joins   []sqlExpr
+	where   []sqlExpr
+	orderBy []sqlExpr
+	limit   *int
+	offset  *int
+}
+
+func newSelect(columns ...string) *selectBuilder {
+	b := &selectBuilder{}
+	for _, column := range columns {
+		b.columns = append(b.columns, expr(column))
+	}
+	return b
+}
+
+// Column adds a computed column whose expression binds arguments
+func (b *selectBuilder) Column(sql string, args ...interface{}) *selectBuilder {
+	b.columns = append(b.columns, expr(sql, args...))
+	return b
+}
+
+func (b *selectBuilder) From(table string) *selectBuilder {
+	b.from = table
+	return b
+}
+
+// Join adds a join clause, e.g. "JOIN unnest(?::text[]) AS r(code) ON r.code = t.code"
+func (b *selectBuilder) Join(sql string, args ...interface{}) *selectBuilder {
+	b.joins = append(b.joins, expr(sql, args...))
+	return b
+}
+
+// Where adds a condition; multiple conditions are combined with AND
+func (b *selectBuilder) Where(condition sqlExpr) *selectBuilder {
+	b.where = append(b.where, condition)
+	return b
+}
+
+func (b *selectBuilder) OrderBy(sql string, args ...interface{}) *selectBuilder {
+	b.orderBy = append(b.orderBy, expr(sql, args...))
+	return b
+}
+
+func (b *selectBuilder) OrderByExpr(e sqlExpr) *selectBuilder {
+	b.orderBy = append(b.orderBy, e)
+	return b
+}
+
+func (b *selectBuilder) Limit(limit int) *selectBuilder {
+	b.limit = &limit
+	return b
+}
+
+func (b *selectBuilder) Offset(offset int) *selectBuilder {
+	b.offset = &offset
+	return b
+}
+
+// ToSQL renders the statement and its arguments
+func (b *selectBuilder) ToSQL() (string, []interface{}, error) {
+	r := &sqlRenderer{}
+
+	r.write("SELECT ")
+	for i, column := range b.columns {
+		if i > 0 {
+			r.write(", ")
+		}
+		if err := r.render(column); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := b.renderFromWhere(r); err != nil {
+		return "", nil, err
+	}
+
+	if len(b.orderBy) > 0 {
+		r.write(" ORDER BY ")
+		for i, order := range b.orderBy {
+			if i > 0 {
+				r.write(", ")
+			}
+			if err := r.render(order); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	if b.limit != nil {
+		if err := r.render(expr(" LIMIT ?", *b.limit)); err != nil {
+			return "", nil, err
+		}
+	}
+	if b.offset != nil {
+		if err := r.render(expr(" OFFSET ?", *b.offset)); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return r.sql.String(), r.args, nil
+}
+
+// CountSQL renders "SELECT COUNT(*)" over the same FROM and WHERE, ignoring columns, ordering and paging
+func (b *selectBuilder) CountSQL() (string, []interface{}, error) {
+	r := &sqlRenderer{}
+	r.write("SELECT COUNT(*)")
+	if err := b.renderFromWhere(r); err != nil {
+		return "", nil, err
+	}
+	return r.sql.String(), r.args, nil
+}
+
+func (b *selectBuilder) renderFromWhere(r *sqlRenderer) error {
+	if b.from == "" {
+		return fmt.Errorf("query builder: missing FROM table")
+	}
+	r.write(" FROM " + b.from)
+
+	for _, join := range b.joins {
+		r.write(" ")
+		if err := r.render(join); err != nil {
+			return err
+		}
+	}
+
+	for i, condition := range b.where {
+		if i == 0 {
+			r.write(" WHERE ")
+		} else {
+			r.write(" AND ")
+		}
+		if err := r.render(condition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqlRenderer accumulates SQL text and numbers placeholders as fragments are appended
+type sqlRenderer struct {
+	sql  strings.Builder
+	args []interface{}
+}
+
+func (r *sqlRenderer) write(s string) {
+	r.sql.WriteString(s)
+}
+
+// render appends a fragment, replacing each "?" outside single-quoted literals with the next $n
+func (r *sqlRenderer) render(e sqlExpr) error {
+	used := 0
+	inLiteral := false
+	for _, ch := range e.sql {
+		switch {
+		case ch == '\'':
+			inLiteral = !inLiteral
+			r.sql.WriteRune(ch)
+		case ch == '?' && !inLiteral:
+			if used >= len(e.args) {
+				return fmt.Errorf("query builder: fragment %q has more placeholders than arguments", e.sql)
+			}
+			r.args = append(r.args, e.args[used])
+			used++
+			fmt.Fprintf(&r.sql, "$%d", len(r.args))
+		default:
+			r.sql.WriteRune(ch)
+		}
+	}
+	if used != len(e.args) {
+		return fmt.Errorf("query builder: fragment %q has %d placeholders but %d arguments", e.sql, used, len(e.args))
+	}
+	return nil
+}
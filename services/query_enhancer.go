@@ -0,0 +1,279 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"smlgoapi/config"
+)
+
+// maxEnhancedWords caps the words an LLM may add to a query; longer answers are cut
+const maxEnhancedWords = 20
+
+// queryEnhancePrompt asks for the query plus its transliterations and related terms
+const queryEnhancePrompt = `You rewrite product search queries for an auto parts and retail catalogue in Thailand.
+Add the Thai transliteration of English words and the English spelling of Thai words, e.g.
+toyota = โตโยต้า, honda = ฮอนด้า, brake = เบรค, oil = น้ำมัน, filter = กรอง, shock = โช๊ค.
+For model names give both the English and the Thai spelling.
+Answer with the original query followed by the added words, separated by spaces, without duplicates.
+Return only the words, no explanation or quotes.`
+
+// QueryProvider sends a query to a language model and returns its answer
+type QueryProvider interface {
+	Complete(ctx context.Context, system, query string) (string, error)
+}
+
+// providerDefaults holds the endpoint, model and API key variable of each provider
+var providerDefaults = map[string]struct {
+	baseURL   string
+	model     string
+	apiKeyEnv string
+}{
+	"deepseek": {"https://api.deepseek.com/v1", "deepseek-chat", "DEEPSEEK_API_KEY"},
+	"openai":   {"https://api.openai.com/v1", "gpt-4o-mini", "OPENAI_API_KEY"},
+	"ollama":   {"http://localhost:11434", "qwen2.5", ""},
+}
+
+// QueryEnhancer rewrites search queries for the vector lookup with a language model. Answers are
+// cached per query, so repeated searches and later pages do not call the provider again.
+type QueryEnhancer struct {
+	provider QueryProvider
+	name     string
+	timeout  time.Duration
+	ttl      time.Duration
+	maxItems int
+
+	mu    sync.Mutex
+	cache map[string]cachedEnhancement
+}
+
+type cachedEnhancement struct {
+	query   string
+	expires time.Time
+}
+
+// NewQueryEnhancer creates the enhancer of search.ai_enhance. It returns nil when no provider is
+// configured, and an error for an unknown provider or a missing API key.
+func NewQueryEnhancer(cfg config.AIEnhanceConfig) (*QueryEnhancer, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if name == "" {
+		return nil, nil
+	}
+	defaults, ok := providerDefaults[name]
+	if !ok {
+		return nil, fmt.Errorf("search.ai_enhance.provider: unknown provider '%s' (use deepseek, openai or ollama)", cfg.Provider)
+	}
+
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaults.baseURL
+	}
+	model := cfg.Model
+	if model == "" {
+		model = defaults.model
+	}
+	keyEnv := cfg.APIKeyEnv
+	if keyEnv == "" {
+		keyEnv = defaults.apiKeyEnv
+	}
+	apiKey := cfg.APIKey // resolved from its reference when the configuration was loaded
+	if apiKey == "" && keyEnv != "" {
+		// The key is only read from the environment so it never ends up in smlgoapi.json
+		if apiKey = os.Getenv(keyEnv); apiKey == "" && name != "ollama" {
+			return nil, fmt.Errorf("search.ai_enhance: %s needs an API key in the %s environment variable", name, keyEnv)
+		}
+	}
+
+	client := &http.Client{Timeout: time.Duration(cfg.TimeoutMs) * time.Millisecond}
+	var provider QueryProvider
+	if name == "ollama" {
+		provider = &ollamaProvider{url: baseURL + "/api/chat", model: model, client: client}
+	} else {
+		provider = &chatCompletionProvider{url: baseURL + "/chat/completions", apiKey: apiKey, model: model, client: client}
+	}
+
+	log.Printf("🤖 Query enhancement enabled: %s (%s)", name, model)
+	return &QueryEnhancer{
+		provider: provider,
+		name:     name,
+		timeout:  time.Duration(cfg.TimeoutMs) * time.Millisecond,
+		ttl:      time.Duration(cfg.CacheTTLSeconds) * time.Second,
+		maxItems: cfg.CacheMaxEntries,
+		cache:    make(map[string]cachedEnhancement),
+	}, nil
+}
+
+// Provider returns the configured provider name
+func (e *QueryEnhancer) Provider() string {
+	return e.name
+}
+
+// Enhance returns query followed by the words the model added. The words of query are always
+// kept, so a poor answer can only add terms.
+func (e *QueryEnhancer) Enhance(ctx context.Context, query string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(query))
+	if enhanced, ok := e.cached(key); ok {
+		return enhanced, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	answer, err := e.provider.Complete(ctx, queryEnhancePrompt, query)
+	if err != nil {
+		return "", fmt.Errorf("%s query enhancement failed: %w", e.name, err)
+	}
+
+	enhanced := mergeQueryWords(query, answer)
+	e.store(key, enhanced)
+	return enhanced, nil
+}
+
+func (e *QueryEnhancer) cached(key string) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	entry, ok := e.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return "", false
+	}
+	return entry.query, true
+}
+
+func (e *QueryEnhancer) store(key, enhanced string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	if len(e.cache) >= e.maxItems {
+		// Drop expired answers first, then the one closest to expiring
+		oldestKey, oldest := "", time.Time{}
+		for k, entry := range e.cache {
+			if now.After(entry.expires) {
+				delete(e.cache, k)
+			} else if oldestKey == "" || entry.expires.Before(oldest) {
+				oldestKey, oldest = k, entry.expires
+			}
+		}
+		if len(e.cache) >= e.maxItems {
+			delete(e.cache, oldestKey)
+		}
+	}
+	e.cache[key] = cachedEnhancement{query: enhanced, expires: now.Add(e.ttl)}
+}
+
+// mergeQueryWords appends the words of answer that query does not already contain
+func mergeQueryWords(query, answer string) string {
+	words := strings.Fields(query)
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		seen[strings.ToLower(word)] = true
+	}
+	added := 0
+	for _, word := range strings.Fields(answer) {
+		word = strings.Trim(word, "\"'`,.;:")
+		lower := strings.ToLower(word)
+		if word == "" || seen[lower] {
+			continue
+		}
+		if added == maxEnhancedWords {
+			break
+		}
+		seen[lower] = true
+		words = append(words, word)
+		added++
+	}
+	return strings.Join(words, " ")
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatCompletionProvider calls an OpenAI-compatible /chat/completions API (OpenAI, DeepSeek)
+type chatCompletionProvider struct {
+	url    string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+func (p *chatCompletionProvider) Complete(ctx context.Context, system, query string) (string, error) {
+	body := map[string]interface{}{
+		"model":       p.model,
+		"temperature": 0,
+		"messages":    []chatMessage{{Role: "system", Content: system}, {Role: "user", Content: query}},
+	}
+	var resp struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := postJSON(ctx, p.client, p.url, p.apiKey, body, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("empty response")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// ollamaProvider calls the /api/chat endpoint of a local Ollama server
+type ollamaProvider struct {
+	url    string
+	model  string
+	client *http.Client
+}
+
+func (p *ollamaProvider) Complete(ctx context.Context, system, query string) (string, error) {
+	body := map[string]interface{}{
+		"model":    p.model,
+		"stream":   false,
+		"options":  map[string]interface{}{"temperature": 0},
+		"messages": []chatMessage{{Role: "system", Content: system}, {Role: "user", Content: query}},
+	}
+	var resp struct {
+		Message chatMessage `json:"message"`
+	}
+	if err := postJSON(ctx, p.client, p.url, "", body, &resp); err != nil {
+		return "", err
+	}
+	return resp.Message.Content, nil
+}
+
+// postJSON posts body and decodes the JSON answer into out
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/lib/pq"
+)
+
+// maxExactJSONInteger is the largest integer a JSON number (float64) represents exactly
+const maxExactJSONInteger = 1 << 53
+
+// NormalizeQueryParams converts JSON-decoded query parameters into driver values.
+// Integral numbers become int64 so they bind as integers rather than floats, and arrays become
+// typed slices (wrapped with pq.Array for PostgreSQL, e.g. "code = ANY($1)"). Objects are rejected.
+func NormalizeQueryParams(params []interface{}, forPostgres bool) ([]interface{}, error) {
+	normalized := make([]interface{}, len(params))
+	for i, param := range params {
+		switch v := param.(type) {
+		case nil, bool, string:
+			normalized[i] = v
+		case float64:
+			normalized[i] = normalizeNumber(v)
+		case []interface{}:
+			array, err := normalizeArray(v)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+			}
+			if forPostgres {
+				array = pq.Array(array)
+			}
+			normalized[i] = array
+		default:
+			return nil, fmt.Errorf("parameter %d: unsupported type %T (use string, number, boolean, null or array)", i+1, param)
+		}
+	}
+	return normalized, nil
+}
+
+func normalizeNumber(v float64) interface{} {
+	if v == math.Trunc(v) && math.Abs(v) <= maxExactJSONInteger {
+		return int64(v)
+	}
+	return v
+}
+
+// normalizeArray turns a JSON array into a []string, []int64, []float64 or []bool; elements must share one type
+func normalizeArray(values []interface{}) (interface{}, error) {
+	if len(values) == 0 {
+		return []string{}, nil
+	}
+
+	switch values[0].(type) {
+	case string:
+		out := make([]string, len(values))
+		for i, value := range values {
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("array elements must all be strings")
+			}
+			out[i] = s
+		}
+		return out, nil
+	case bool:
+		out := make([]bool, len(values))
+		for i, value := range values {
+			b, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("array elements must all be booleans")
+			}
+			out[i] = b
+		}
+		return out, nil
+	case float64:
+		ints := make([]int64, len(values))
+		floats := make([]float64, len(values))
+		integral := true
+		for i, value := range values {
+			f, ok := value.(float64)
+			if !ok {
+				return nil, fmt.Errorf("array elements must all be numbers")
+			}
+			floats[i] = f
+			if n, isInt := normalizeNumber(f).(int64); isInt {
+				ints[i] = n
+			} else {
+				integral = false
+			}
+		}
+		if integral {
+			return ints, nil
+		}
+		return floats, nil
+	default:
+		return nil, fmt.Errorf("unsupported array element type %T", values[0])
+	}
+}
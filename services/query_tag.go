@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// postgresApplicationName is the application_name used for queries that are not tied to a request
+const postgresApplicationName = "smlgoapi"
+
+// maxApplicationNameLength is PostgreSQL's NAMEDATALEN-1; longer names are truncated by the server
+const maxApplicationNameLength = 63
+
+type queryTagKey struct{}
+
+// QueryTag identifies the API request that issued a database query, so slow queries seen by
+// DBAs in pg_stat_activity or system.query_log can be traced back to the request
+type QueryTag struct {
+	RequestID string
+	Route     string
+}
+
+// WithQueryTag returns a context carrying the query tag
+func WithQueryTag(ctx context.Context, tag QueryTag) context.Context {
+	return context.WithValue(ctx, queryTagKey{}, tag)
+}
+
+// QueryTagFromContext returns the query tag attached to ctx, if any
+func QueryTagFromContext(ctx context.Context) (QueryTag, bool) {
+	tag, ok := ctx.Value(queryTagKey{}).(QueryTag)
+	return tag, ok
+}
+
+// String formats the tag as "smlgoapi:<request id>:<route>"
+func (t QueryTag) String() string {
+	return postgresApplicationName + ":" + t.RequestID + ":" + t.Route
+}
+
+// ApplicationName returns the tag trimmed to fit PostgreSQL's application_name
+func (t QueryTag) ApplicationName() string {
+	name := t.String()
+	if len(name) > maxApplicationNameLength {
+		name = name[:maxApplicationNameLength]
+	}
+	return name
+}
+
+// QueryID returns a ClickHouse query_id for one query of the request.
+// ClickHouse rejects a query_id that is still running, so each query gets a random suffix.
+func (t QueryTag) QueryID() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return t.RequestID
+	}
+	return t.RequestID + "-" + hex.EncodeToString(buf)
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// idleBucketTTL is how long an unused bucket is kept before it is swept
+const idleBucketTTL = 10 * time.Minute
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimitDecision is the outcome of a rate limit check
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int // requests per minute; 0 means the route is unlimited
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// redisTokenBucket refills and takes from a bucket stored as a Redis hash. It reads the clock of
+// the Redis server, so instances with skewed clocks still share one refill rate.
+var redisTokenBucket = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local per_second = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * per_second)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return {allowed, tostring(tokens)}
+`)
+
+// RateLimiter implements token bucket limits keyed by route rule and client. With Redis the
+// buckets are shared by every instance; while Redis fails, each instance limits on its own.
+type RateLimiter struct {
+	cfg   config.RateLimitConfig
+	redis *RedisStore // nil keeps the buckets in memory
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+
+	redisErrors atomic.Int64
+}
+
+// NewRateLimiter creates a rate limiter from the configuration; store may be nil
+func NewRateLimiter(cfg config.RateLimitConfig, store *RedisStore) *RateLimiter {
+	return &RateLimiter{
+		cfg:       cfg,
+		redis:     store,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes one token from the client's bucket for the rule matching path
+func (l *RateLimiter) Allow(path, clientKey string) RateLimitDecision {
+	route, rule := l.ruleFor(path)
+	if rule.RequestsPerMinute <= 0 {
+		return RateLimitDecision{Allowed: true}
+	}
+
+	burst := float64(rule.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	perSecond := float64(rule.RequestsPerMinute) / 60
+
+	key := route + "|" + clientKey
+	if l.redis != nil {
+		if decision, err := l.allowRedis(key, rule.RequestsPerMinute, burst, perSecond); err == nil {
+			return decision
+		} else if n := l.redisErrors.Add(1); n == 1 || n%1000 == 0 {
+			log.Printf("⚠️ [rate-limit] Redis unavailable, limiting per instance (%d failures): %v", n, err)
+		}
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, lastSeen: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.lastSeen).Seconds()*perSecond)
+	bucket.lastSeen = now
+
+	decision := RateLimitDecision{Limit: rule.RequestsPerMinute}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		decision.Allowed = true
+		decision.Remaining = int(bucket.tokens)
+		return decision
+	}
+
+	decision.RetryAfter = time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+	return decision
+}
+
+// allowRedis takes a token from the bucket shared in Redis
+func (l *RateLimiter) allowRedis(key string, limit int, burst, perSecond float64) (RateLimitDecision, error) {
+	ctx, cancel := l.redis.context()
+	defer cancel()
+	result, err := redisTokenBucket.Run(ctx, l.redis.client, []string{l.redis.key("ratelimit", key)},
+		burst, perSecond, idleBucketTTL.Milliseconds()).Slice()
+	if err != nil {
+		return RateLimitDecision{}, err
+	}
+	if len(result) != 2 {
+		return RateLimitDecision{}, fmt.Errorf("unexpected token bucket reply %v", result)
+	}
+	allowed, _ := result[0].(int64)
+	tokensText, _ := result[1].(string)
+	tokens, err := strconv.ParseFloat(tokensText, 64)
+	if err != nil {
+		return RateLimitDecision{}, fmt.Errorf("unexpected token count %q", tokensText)
+	}
+
+	decision := RateLimitDecision{Limit: limit, Allowed: allowed == 1}
+	if decision.Allowed {
+		decision.Remaining = int(tokens)
+	} else {
+		decision.RetryAfter = time.Duration((1 - tokens) / perSecond * float64(time.Second))
+	}
+	return decision, nil
+}
+
+// Info reports the configured rules and the number of tracked client buckets
+func (l *RateLimiter) Info() *models.RateLimitInfo {
+	info := &models.RateLimitInfo{
+		Enabled: l.cfg.Enabled,
+		Backend: "memory",
+		Rules: []models.RateLimitRuleInfo{{
+			Route:             "*",
+			RequestsPerMinute: l.cfg.RequestsPerMinute,
+			Burst:             l.cfg.Burst,
+		}},
+	}
+
+	routes := make([]string, 0, len(l.cfg.Routes))
+	for route := range l.cfg.Routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		rule := l.cfg.Routes[route]
+		info.Rules = append(info.Rules, models.RateLimitRuleInfo{
+			Route:             route,
+			RequestsPerMinute: rule.RequestsPerMinute,
+			Burst:             rule.Burst,
+		})
+	}
+
+	if l.redis != nil {
+		info.Backend = "redis"
+	}
+	l.mu.Lock()
+	info.TrackedClients = len(l.buckets)
+	l.mu.Unlock()
+
+	return info
+}
+
+// ruleFor returns the longest matching route prefix and its rule, or the default rule as "*"
+func (l *RateLimiter) ruleFor(path string) (string, config.RateLimitRule) {
+	bestRoute := ""
+	for route := range l.cfg.Routes {
+		if strings.HasPrefix(path, route) && len(route) > len(bestRoute) {
+			bestRoute = route
+		}
+	}
+	if bestRoute == "" {
+		return "*", config.RateLimitRule{RequestsPerMinute: l.cfg.RequestsPerMinute, Burst: l.cfg.Burst}
+	}
+	return bestRoute, l.cfg.Routes[bestRoute]
+}
+
+// sweep drops idle buckets at most once a minute; a full idle bucket is equivalent to a new one
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) > idleBucketTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
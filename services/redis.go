@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"smlgoapi/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is the Redis shared by every instance for the SELECT cache and the rate limits.
+// Every command runs under the configured timeout, so a slow Redis delays a request by at most
+// that much before the caller falls back to its in-memory state.
+type RedisStore struct {
+	client  *redis.Client
+	prefix  string
+	timeout time.Duration
+}
+
+// NewRedisStore connects to redis.url. It returns nil without a URL, and an error when the URL
+// is invalid; an unreachable server is only logged, since commands retry on every use.
+func NewRedisStore(cfg config.RedisConfig) (*RedisStore, error) {
+	if cfg.URL == "" {
+		return nil, nil
+	}
+	opts, err := redis.ParseURL(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("redis.url: %w", err)
+	}
+	timeout := time.Duration(cfg.TimeoutMs) * time.Millisecond
+	opts.DialTimeout = max(timeout, time.Second)
+	opts.ReadTimeout = timeout
+	opts.WriteTimeout = timeout
+
+	store := &RedisStore{client: redis.NewClient(opts), prefix: cfg.KeyPrefix, timeout: timeout}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := store.Ping(ctx); err != nil {
+		log.Printf("⚠️ Redis at %s is not reachable yet: %v", opts.Addr, err)
+	} else {
+		log.Printf("🧰 Redis connected: %s (db %d, prefix %q)", opts.Addr, opts.DB, cfg.KeyPrefix)
+	}
+	return store, nil
+}
+
+// Ping checks that Redis answers
+func (r *RedisStore) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close closes the connection pool
+func (r *RedisStore) Close() error {
+	return r.client.Close()
+}
+
+// key joins parts under the configured prefix
+func (r *RedisStore) key(parts ...string) string {
+	return r.prefix + strings.Join(parts, ":")
+}
+
+// context bounds one operation by the configured timeout
+func (r *RedisStore) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), r.timeout)
+}
+
+// scan hands the keys matching pattern to fn one SCAN page at a time
+func (r *RedisStore) scan(ctx context.Context, pattern string, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// RowFunc receives one result row. columns is shared across calls and values is only valid until
+// the function returns; returning an error stops the scan.
+type RowFunc func(columns []string, values []interface{}) error
+
+// scanRows walks the result set one row at a time so callers can stream rows without holding the
+// whole result in memory. It returns the number of rows passed to fn.
+func scanRows(rows *sql.Rows, fn RowFunc) (int, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range columns {
+		valuePtrs[i] = &values[i]
+	}
+
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return count, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		// Convert []uint8 to string if needed
+		for i, val := range values {
+			if b, ok := val.([]uint8); ok {
+				values[i] = string(b)
+			}
+		}
+
+		if err := fn(columns, values); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return count, nil
+}
+
+// collectRows is the RowFunc used by ExecuteSelect: it copies every row into a column map
+func collectRows(results *[]interface{}) RowFunc {
+	return func(columns []string, values []interface{}) error {
+		rowMap := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			rowMap[col] = values[i]
+		}
+		*results = append(*results, rowMap)
+		return nil
+	}
+}
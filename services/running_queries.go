@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"smlgoapi/models"
+)
+
+// ErrQueryNotRunning is returned for ids of statements that finished or never ran
+var ErrQueryNotRunning = errors.New("query not running")
+
+// ErrQueryCancelled is the cause of the context of a statement cancelled through the registry
+var ErrQueryCancelled = errors.New("query cancelled by an administrator")
+
+// RunningQueryRegistry tracks the SQL statements the SQL endpoints are running, so an operator
+// can list them and cancel a runaway one. Cancelling ends the statement's context, which makes
+// the driver cancel it on the database. Statements are only known to the instance that runs them.
+type RunningQueryRegistry struct {
+	mu      sync.Mutex
+	queries map[string]*runningQuery
+	lastID  uint64
+}
+
+type runningQuery struct {
+	models.RunningQuery
+	cancel context.CancelCauseFunc
+}
+
+func NewRunningQueryRegistry() *RunningQueryRegistry {
+	return &RunningQueryRegistry{queries: make(map[string]*runningQuery)}
+}
+
+// Track registers query and returns the context to run it with. done must be called once the
+// statement finished; it releases the context and removes the query from the registry.
+func (r *RunningQueryRegistry) Track(ctx context.Context, query models.RunningQuery) (context.Context, func()) {
+	query.StartedAt = time.Now()
+	if tag, ok := QueryTagFromContext(ctx); ok {
+		query.RequestID = tag.RequestID
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	r.mu.Lock()
+	r.lastID++
+	query.ID = strconv.FormatUint(r.lastID, 10)
+	r.queries[query.ID] = &runningQuery{RunningQuery: query, cancel: cancel}
+	r.mu.Unlock()
+
+	return ctx, func() {
+		cancel(nil)
+		r.mu.Lock()
+		delete(r.queries, query.ID)
+		r.mu.Unlock()
+	}
+}
+
+// List returns the running statements, the longest running first
+func (r *RunningQueryRegistry) List() []models.RunningQuery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queries := make([]models.RunningQuery, 0, len(r.queries))
+	for _, q := range r.queries {
+		queries = append(queries, q.snapshot())
+	}
+	sort.Slice(queries, func(i, k int) bool { return queries[i].StartedAt.Before(queries[k].StartedAt) })
+	return queries
+}
+
+// Cancel ends the context of a running statement. The statement is listed as cancelled until
+// the database has stopped it and its request answered.
+func (r *RunningQueryRegistry) Cancel(id string) (models.RunningQuery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	q, ok := r.queries[id]
+	if !ok {
+		return models.RunningQuery{}, ErrQueryNotRunning
+	}
+	q.cancel(ErrQueryCancelled)
+	q.Cancelled = true
+	return q.snapshot(), nil
+}
+
+func (q *runningQuery) snapshot() models.RunningQuery {
+	snapshot := q.RunningQuery
+	snapshot.DurationMs = float64(time.Since(q.StartedAt).Nanoseconds()) / 1e6
+	return snapshot
+}
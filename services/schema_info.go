@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"smlgoapi/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrTableNotFound is returned when a described table does not exist
+var ErrTableNotFound = errors.New("table not found")
+
+// postgresTablesQuery lists the relations of a schema that can be queried; $2 narrows it to one
+const postgresTablesQuery = `
+	SELECT c.oid, c.relname, n.nspname,
+		CASE c.relkind
+			WHEN 'r' THEN 'table' WHEN 'p' THEN 'partitioned table' WHEN 'v' THEN 'view'
+			WHEN 'm' THEN 'materialized view' ELSE 'foreign table'
+		END,
+		GREATEST(c.reltuples, 0)::bigint,
+		pg_total_relation_size(c.oid),
+		COALESCE(obj_description(c.oid, 'pg_class'), '')
+	FROM pg_class c
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	WHERE n.nspname = $1 AND c.relkind IN ('r', 'p', 'v', 'm', 'f') AND ($2::text = '' OR c.relname = $2::text)
+	ORDER BY c.relname`
+
+// DescribeTables lists the tables and views of a schema. Row counts are the planner's estimates
+// from the last ANALYZE, so they cost nothing on large tables.
+func (s *PostgreSQLService) DescribeTables(ctx context.Context, schema string) ([]models.TableInfo, error) {
+	tables, _, err := s.describeTables(ctx, schema, "")
+	return tables, err
+}
+
+func (s *PostgreSQLService) describeTables(ctx context.Context, schema, name string) ([]models.TableInfo, []int64, error) {
+	rows, err := s.db.QueryContext(ctx, postgresTablesQuery, schema, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query tables: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []models.TableInfo{}
+	var oids []int64
+	for rows.Next() {
+		var t models.TableInfo
+		var oid int64
+		if err := rows.Scan(&oid, &t.Name, &t.Schema, &t.Kind, &t.RowEstimate, &t.SizeBytes, &t.Comment); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		tables = append(tables, t)
+		oids = append(oids, oid)
+	}
+	return tables, oids, rows.Err()
+}
+
+// DescribeTable returns the columns, indexes and primary key of a table
+func (s *PostgreSQLService) DescribeTable(ctx context.Context, schema, name string) (*models.TableSchema, error) {
+	tables, oids, err := s.describeTables(ctx, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, ErrTableNotFound
+	}
+	table := &models.TableSchema{TableInfo: tables[0], Columns: []models.ColumnInfo{}, Indexes: []models.IndexInfo{}}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+			COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), COALESCE(col_description(a.attrelid, a.attnum), '')
+		FROM pg_attribute a
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, oids[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c models.ColumnInfo
+		if err := rows.Scan(&c.Name, &c.Type, &c.Nullable, &c.Default, &c.Comment); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		table.Columns = append(table.Columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// indkey counts from 0 while pg_get_indexdef numbers the index columns from 1
+	indexes, err := s.db.QueryContext(ctx, `
+		SELECT i.relname, am.amname, ix.indisunique, ix.indisprimary, pg_get_indexdef(ix.indexrelid),
+			ARRAY(SELECT pg_get_indexdef(ix.indexrelid, k + 1, true)
+				FROM generate_subscripts(ix.indkey, 1) AS k
+				WHERE k < ix.indnkeyatts ORDER BY k)
+		FROM pg_index ix
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_am am ON am.oid = i.relam
+		WHERE ix.indrelid = $1
+		ORDER BY ix.indisprimary DESC, i.relname`, oids[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	defer indexes.Close()
+	for indexes.Next() {
+		var index models.IndexInfo
+		if err := indexes.Scan(&index.Name, &index.Type, &index.Unique, &index.Primary, &index.Expression, pq.Array(&index.Columns)); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %w", err)
+		}
+		if index.Primary {
+			table.PrimaryKey = index.Columns
+		}
+		table.Indexes = append(table.Indexes, index)
+	}
+	return table, indexes.Err()
+}
+
+// clickHouseTablesQuery lists the tables of a database; the last two parameters narrow it to
+// one. total_rows and total_bytes are exact for MergeTree tables and NULL for views.
+const clickHouseTablesQuery = `
+	SELECT name, database, engine, toInt64(ifNull(total_rows, 0)), toInt64(ifNull(total_bytes, 0)), comment,
+		sorting_key, primary_key
+	FROM system.tables
+	WHERE database = ? AND NOT is_temporary AND (? = '' OR name = ?)
+	ORDER BY name`
+
+// DescribeTables lists the tables of a database
+func (s *ClickHouseService) DescribeTables(ctx context.Context, database string) ([]models.TableInfo, error) {
+	tables, err := s.describeTables(ctx, database, "")
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]models.TableInfo, len(tables))
+	for i, t := range tables {
+		infos[i] = t.TableInfo
+	}
+	return infos, nil
+}
+
+func (s *ClickHouseService) describeTables(ctx context.Context, database, name string) ([]models.TableSchema, error) {
+	rows, err := s.db.QueryContext(tagContext(ctx), clickHouseTablesQuery, database, name, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []models.TableSchema
+	for rows.Next() {
+		var t models.TableSchema
+		var primaryKey string
+		if err := rows.Scan(&t.Name, &t.Schema, &t.Kind, &t.RowEstimate, &t.SizeBytes, &t.Comment, &t.SortingKey, &primaryKey); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		// The key is an expression list such as "shop_id, toDate(created_at)"
+		t.PrimaryKey = splitKeyExpression(primaryKey)
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// DescribeTable returns the columns, data skipping indexes and keys of a table
+func (s *ClickHouseService) DescribeTable(ctx context.Context, database, name string) (*models.TableSchema, error) {
+	tables, err := s.describeTables(ctx, database, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, ErrTableNotFound
+	}
+	table := &tables[0]
+	table.Columns = []models.ColumnInfo{}
+	table.Indexes = []models.IndexInfo{}
+
+	rows, err := s.db.QueryContext(tagContext(ctx), `
+		SELECT name, type, default_expression, comment
+		FROM system.columns
+		WHERE database = ? AND table = ?
+		ORDER BY position`, database, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c models.ColumnInfo
+		if err := rows.Scan(&c.Name, &c.Type, &c.Default, &c.Comment); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		c.Nullable = strings.HasPrefix(c.Type, "Nullable(") || strings.HasPrefix(c.Type, "LowCardinality(Nullable(")
+		table.Columns = append(table.Columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	indexes, err := s.db.QueryContext(tagContext(ctx), `
+		SELECT name, type, expr
+		FROM system.data_skipping_indices
+		WHERE database = ? AND table = ?
+		ORDER BY name`, database, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	defer indexes.Close()
+	for indexes.Next() {
+		var index models.IndexInfo
+		if err := indexes.Scan(&index.Name, &index.Type, &index.Expression); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %w", err)
+		}
+		table.Indexes = append(table.Indexes, index)
+	}
+	return table, indexes.Err()
+}
+
+// splitKeyExpression splits a ClickHouse key expression on the commas between its elements,
+// keeping commas inside function calls
+func splitKeyExpression(expr string) []string {
+	var keys []string
+	depth, start := 0, 0
+	for i, r := range expr {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				keys = append(keys, strings.TrimSpace(expr[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(expr[start:]); last != "" {
+		keys = append(keys, last)
+	}
+	return keys
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+)
+
+// Search methods recorded in search_events
+const (
+	SearchMethodPriority = "priority" // exact barcode/code matches of the first page
+	SearchMethodVector   = "vector"   // Weaviate candidates ranked in PostgreSQL
+	SearchMethodText     = "text"     // PostgreSQL text search without Weaviate
+	SearchMethodHybrid   = "hybrid"   // /v1/search/hybrid
+)
+
+// SearchEvent is one search as stored in search_events
+type SearchEvent struct {
+	Time        time.Time
+	Endpoint    string
+	Query       string
+	Method      string
+	ResultCount int // all matches, not only the returned page
+	Returned    int
+	Offset      int
+	LatencyMs   float64
+	ClientID    string
+	Partial     bool
+}
+
+// SearchAnalyticsService records searches in the ClickHouse table search_events and reports on
+// them. Searches are queued and written in batches by a background writer, so recording never
+// adds a database round trip to a search; when the queue is full new events are dropped.
+type SearchAnalyticsService struct {
+	clickHouseService *ClickHouseService
+	cfg               config.SearchAnalyticsConfig
+
+	events  chan SearchEvent
+	stop    chan struct{}
+	done    chan struct{}
+	closing atomic.Bool
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+// NewSearchAnalyticsService creates the service; Start launches its writer
+func NewSearchAnalyticsService(clickHouseService *ClickHouseService, cfg config.SearchAnalyticsConfig) *SearchAnalyticsService {
+	return &SearchAnalyticsService{
+		clickHouseService: clickHouseService,
+		cfg:               cfg,
+		events:            make(chan SearchEvent, cfg.BufferSize),
+		stop:              make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+}
+
+// EnsureSchema creates the search_events table if it does not exist
+func (s *SearchAnalyticsService) EnsureSchema(ctx context.Context) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS search_events (
+			event_time   DateTime64(3) DEFAULT now64(3),
+			endpoint     LowCardinality(String),
+			query        String,
+			method       LowCardinality(String),
+			result_count UInt32,
+			returned     UInt32,
+			page_offset  UInt32,
+			latency_ms   Float32,
+			client_id    String,
+			partial      UInt8
+		) ENGINE = MergeTree()
+		ORDER BY (event_time, method)
+		TTL toDateTime(event_time) + INTERVAL %d DAY`, s.cfg.RetentionDays)
+
+	if _, err := s.clickHouseService.db.ExecContext(tagContext(ctx), query); err != nil {
+		return fmt.Errorf("failed to create search_events table: %w", err)
+	}
+	return nil
+}
+
+// Start runs the background writer until Close
+func (s *SearchAnalyticsService) Start() {
+	go s.run()
+}
+
+// Record queues a search for the writer without blocking
+func (s *SearchAnalyticsService) Record(event SearchEvent) {
+	if s == nil || s.closing.Load() {
+		return
+	}
+	select {
+	case s.events <- event:
+	default:
+		if n := s.dropped.Add(1); n == 1 || n%1000 == 0 {
+			log.Printf("⚠️ [search-analytics] Queue full, %d search events dropped so far", n)
+		}
+	}
+}
+
+// Dropped returns how many events were dropped because the queue was full
+func (s *SearchAnalyticsService) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close stops accepting events and writes the queued ones, waiting until ctx is done at most
+func (s *SearchAnalyticsService) Close(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		s.closing.Store(true)
+		close(s.stop)
+	})
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		log.Printf("⚠️ [search-analytics] Shutdown before %d queued search events were written", len(s.events))
+	}
+}
+
+func (s *SearchAnalyticsService) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(time.Duration(s.cfg.FlushIntervalMs) * time.Millisecond)
+	defer ticker.Stop()
+
+	batch := make([]SearchEvent, 0, s.cfg.BatchSize)
+	for {
+		select {
+		case event := <-s.events:
+			batch = append(batch, event)
+			if len(batch) >= s.cfg.BatchSize {
+				batch = s.flush(batch)
+			}
+		case <-ticker.C:
+			batch = s.flush(batch)
+		case <-s.stop:
+			for {
+				select {
+				case event := <-s.events:
+					batch = append(batch, event)
+					if len(batch) >= s.cfg.BatchSize {
+						batch = s.flush(batch)
+					}
+				default:
+					s.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes a batch and returns it emptied; a failed batch is logged and discarded
+func (s *SearchAnalyticsService) flush(batch []SearchEvent) []SearchEvent {
+	if len(batch) == 0 {
+		return batch
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.insert(ctx, batch); err != nil {
+		log.Printf("❌ [search-analytics] Failed to write %d search events: %v", len(batch), err)
+	}
+	return batch[:0]
+}
+
+func (s *SearchAnalyticsService) insert(ctx context.Context, batch []SearchEvent) error {
+	tx, err := s.clickHouseService.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO search_events (event_time, endpoint, query, method, result_count, returned, page_offset, latency_ms, client_id, partial)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range batch {
+		var partial uint8
+		if e.Partial {
+			partial = 1
+		}
+		if _, err := stmt.ExecContext(ctx, e.Time, e.Endpoint, e.Query, e.Method, uint32(max(e.ResultCount, 0)),
+			uint32(max(e.Returned, 0)), uint32(max(e.Offset, 0)), float32(e.LatencyMs), e.ClientID, partial); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// TopQueries returns the most searched queries between from and to, counting first pages only
+func (s *SearchAnalyticsService) TopQueries(ctx context.Context, from, to time.Time, limit int) ([]models.SearchQueryStats, error) {
+	return s.queryStats(ctx, `
+		SELECT lower(trim(query)) AS q,
+		       count() AS searches,
+		       uniqExact(client_id) AS unique_clients,
+		       countIf(result_count = 0) AS zero_results,
+		       avg(result_count) AS avg_results,
+		       avg(latency_ms) AS avg_latency_ms,
+		       min(event_time) AS first_searched,
+		       max(event_time) AS last_searched
+		FROM search_events
+		WHERE event_time >= ? AND event_time < ? AND page_offset = 0 AND q != ''
+		GROUP BY q
+		ORDER BY searches DESC, last_searched DESC
+		LIMIT ?`, from, to, limit)
+}
+
+// ZeroResultQueries returns the most frequent queries between from and to that found nothing
+func (s *SearchAnalyticsService) ZeroResultQueries(ctx context.Context, from, to time.Time, limit int) ([]models.SearchQueryStats, error) {
+	return s.queryStats(ctx, `
+		SELECT lower(trim(query)) AS q,
+		       count() AS searches,
+		       uniqExact(client_id) AS unique_clients,
+		       count() AS zero_results,
+		       toFloat64(0) AS avg_results,
+		       avg(latency_ms) AS avg_latency_ms,
+		       min(event_time) AS first_searched,
+		       max(event_time) AS last_searched
+		FROM search_events
+		WHERE event_time >= ? AND event_time < ? AND page_offset = 0 AND result_count = 0 AND q != ''
+		GROUP BY q
+		ORDER BY searches DESC, last_searched DESC
+		LIMIT ?`, from, to, limit)
+}
+
+func (s *SearchAnalyticsService) queryStats(ctx context.Context, query string, from, to time.Time, limit int) ([]models.SearchQueryStats, error) {
+	rows, err := s.clickHouseService.db.QueryContext(tagContext(ctx), query, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query search analytics: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.SearchQueryStats{}
+	for rows.Next() {
+		var item models.SearchQueryStats
+		var searches, uniqueClients, zeroResults uint64
+		var avgResults, avgLatency float64
+		if err := rows.Scan(&item.Query, &searches, &uniqueClients, &zeroResults, &avgResults, &avgLatency,
+			&item.FirstSearched, &item.LastSearched); err != nil {
+			return nil, fmt.Errorf("failed to scan search analytics: %w", err)
+		}
+		item.Searches = int64(searches)
+		item.UniqueClients = int64(uniqueClients)
+		item.ZeroResults = int64(zeroResults)
+		item.AvgResults = avgResults
+		item.AvgLatencyMs = avgLatency
+		stats = append(stats, item)
+	}
+	return stats, rows.Err()
+}
+
+// LatencyPercentiles returns search latency percentiles between from and to per method, with an
+// "all" row first
+func (s *SearchAnalyticsService) LatencyPercentiles(ctx context.Context, from, to time.Time) ([]models.SearchLatencyStats, error) {
+	query := `
+		SELECT if(method = '', 'all', method) AS m,
+		       count() AS searches,
+		       avg(latency_ms),
+		       quantiles(0.5, 0.9, 0.95, 0.99)(latency_ms),
+		       max(latency_ms)
+		FROM search_events
+		WHERE event_time >= ? AND event_time < ?
+		GROUP BY method WITH ROLLUP
+		ORDER BY m = 'all' DESC, searches DESC`
+
+	rows, err := s.clickHouseService.db.QueryContext(tagContext(ctx), query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query search latency: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.SearchLatencyStats{}
+	for rows.Next() {
+		var item models.SearchLatencyStats
+		var searches uint64
+		var avg float64
+		var quantiles []float32
+		var maxLatency float32
+		if err := rows.Scan(&item.Method, &searches, &avg, &quantiles, &maxLatency); err != nil {
+			return nil, fmt.Errorf("failed to scan search latency: %w", err)
+		}
+		item.Searches = int64(searches)
+		item.AvgMs = avg
+		item.MaxMs = float64(maxLatency)
+		if len(quantiles) == 4 {
+			item.P50Ms, item.P90Ms, item.P95Ms, item.P99Ms = float64(quantiles[0]), float64(quantiles[1]), float64(quantiles[2]), float64(quantiles[3])
+		}
+		stats = append(stats, item)
+	}
+	return stats, rows.Err()
+}
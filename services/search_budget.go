@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"smlgoapi/config"
+)
+
+// LatencyBudgetHeader carries the client's end-to-end budget for a search in milliseconds
+const LatencyBudgetHeader = "X-Latency-Budget-Ms"
+
+// Optional search stages, named as in search.latency_budget.reserve_ms. The exact-match steps
+// and the database page itself always run.
+const (
+	SearchStageVector     = "vector"     // Weaviate lookup; without it the page comes from the text search
+	SearchStageSupplement = "supplement" // text results filling a short vector page
+	SearchStageEstimate   = "estimate"   // estimated_total count
+	SearchStageFacets     = "facets"
+)
+
+// ParseLatencyBudget reads the X-Latency-Budget-Ms header. An empty header yields the configured
+// default; budgets above max_ms are lowered to it.
+func ParseLatencyBudget(header string, cfg config.LatencyBudgetConfig) (time.Duration, error) {
+	ms := cfg.DefaultMs
+	if header = strings.TrimSpace(header); header != "" {
+		n, err := strconv.Atoi(header)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("%s must be a positive number of milliseconds, got %q", LatencyBudgetHeader, header)
+		}
+		ms = n
+	}
+	if cfg.MaxMs > 0 && ms > cfg.MaxMs {
+		ms = cfg.MaxMs
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// LatencyBudget tracks the time left for one search and the optional stages it skipped.
+// A nil budget allows every stage.
+type LatencyBudget struct {
+	deadline time.Time
+	reserve  map[string]int
+	finish   time.Duration
+
+	mu      sync.Mutex
+	skipped []string
+}
+
+// NewLatencyBudget starts a budget at start; a zero budget returns nil
+func NewLatencyBudget(start time.Time, budget time.Duration, cfg config.LatencyBudgetConfig) *LatencyBudget {
+	if budget <= 0 {
+		return nil
+	}
+	return &LatencyBudget{
+		deadline: start.Add(budget),
+		reserve:  cfg.ReserveMs,
+		finish:   time.Duration(cfg.FinishMs) * time.Millisecond,
+	}
+}
+
+// Remaining returns the time left in the budget, which is negative once it is overrun
+func (b *LatencyBudget) Remaining() time.Duration {
+	return time.Until(b.deadline)
+}
+
+// Allow reports whether an optional stage may start. A stage refused for lack of budget is
+// recorded as skipped.
+func (b *LatencyBudget) Allow(stage string) bool {
+	if b == nil {
+		return true
+	}
+	remaining := b.Remaining()
+	if remaining >= time.Duration(b.reserve[stage])*time.Millisecond {
+		return true
+	}
+	log.Printf("⏱️ [BUDGET] Skipping %s stage, %dms of the budget left", stage, remaining.Milliseconds())
+	b.skip(stage)
+	return false
+}
+
+// Stage returns the context to run an optional stage with: it ends early enough to leave
+// finish_ms of the budget for building the page.
+func (b *LatencyBudget) Stage(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b == nil {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, b.deadline.Add(-b.finish))
+}
+
+// Overran reports whether err is an optional stage running out of budget rather than failing,
+// and records the stage as skipped when it is. stageCtx is the context returned by Stage; it may
+// already be cancelled.
+func (b *LatencyBudget) Overran(stage string, stageCtx, ctx context.Context, err error) bool {
+	if b == nil || err == nil || ctx.Err() != nil {
+		return false
+	}
+	if deadline, ok := stageCtx.Deadline(); !ok || time.Now().Before(deadline) {
+		return false
+	}
+	log.Printf("⏱️ [BUDGET] %s stage ran out of budget and was dropped: %v", stage, err)
+	b.skip(stage)
+	return true
+}
+
+func (b *LatencyBudget) skip(stage string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range b.skipped {
+		if s == stage {
+			return
+		}
+	}
+	b.skipped = append(b.skipped, stage)
+}
+
+// Skipped returns the optional stages left out to stay within the budget
+func (b *LatencyBudget) Skipped() []string {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.skipped...)
+}
+
+// MarkBudget flags a response that left out optional stages to stay within its latency budget
+func (r *VectorSearchResponse) MarkBudget(b *LatencyBudget) {
+	skipped := b.Skipped()
+	if len(skipped) == 0 {
+		return
+	}
+	r.Partial = true
+	r.SkippedStages = skipped
+}
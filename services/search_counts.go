@@ -0,0 +1,73 @@
+package services
+
+// Count strategies reported by the hybrid search
+const (
+	// CountStrategyExact means the merged set holds every known match, so estimated_total equals exact_count
+	CountStrategyExact = "exact"
+	// CountStrategyEstimated means PostgreSQL text matching reports more products than the merged set;
+	// estimated_total is that text match count and is only an upper bound for paging
+	CountStrategyEstimated = "estimated"
+)
+
+// SearchCounts describes how far a client can page through hybrid search results
+type SearchCounts struct {
+	ExactCount     int    // distinct products in the merged set (priority + vector + supplemental matches)
+	EstimatedTotal int    // best estimate of all matching products, never below ExactCount
+	Strategy       string // CountStrategyExact or CountStrategyEstimated
+	HasMore        bool   // another page exists within the merged set
+}
+
+// NewSearchCounts derives the counts for one page of hybrid search results.
+// merged is the deduplicated size of the candidate set the pages walk through, returned is the number
+// of rows on this page, and textTotal is the PostgreSQL text match count (negative when unknown).
+func NewSearchCounts(offset, returned, merged, textTotal int) SearchCounts {
+	exact := merged
+	if seen := offset + returned; seen > exact {
+		// The page itself proves at least this many matches exist
+		exact = seen
+	}
+
+	counts := SearchCounts{
+		ExactCount:     exact,
+		EstimatedTotal: exact,
+		Strategy:       CountStrategyExact,
+		HasMore:        offset+returned < exact,
+	}
+	if textTotal > exact {
+		counts.EstimatedTotal = textTotal
+		counts.Strategy = CountStrategyEstimated
+	}
+	return counts
+}
+
+// Apply copies the counts onto a search response; total_count keeps reporting the exact count
+func (c SearchCounts) Apply(resp *VectorSearchResponse) {
+	resp.TotalCount = c.ExactCount
+	resp.ExactCount = c.ExactCount
+	resp.EstimatedTotal = c.EstimatedTotal
+	resp.CountStrategy = c.Strategy
+	resp.HasMore = c.HasMore
+}
+
+// MergeResultsByCode appends secondary rows to primary, skipping rows whose product code is already present.
+// It returns the merged rows and the number of duplicates dropped.
+func MergeResultsByCode(primary, secondary []map[string]interface{}) ([]map[string]interface{}, int) {
+	merged := make([]map[string]interface{}, 0, len(primary)+len(secondary))
+	seen := make(map[string]bool, len(primary)+len(secondary))
+	dropped := 0
+
+	for _, rows := range [][]map[string]interface{}{primary, secondary} {
+		for _, row := range rows {
+			code, _ := row["code"].(string)
+			if code != "" {
+				if seen[code] {
+					dropped++
+					continue
+				}
+				seen[code] = true
+			}
+			merged = append(merged, row)
+		}
+	}
+	return merged, dropped
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewSearchCounts(t *testing.T) {
+	tests := []struct {
+		name                            string
+		offset, returned, merged, total int
+		want                            SearchCounts
+	}{
+		{
+			name: "exact, more pages", offset: 0, returned: 20, merged: 50, total: 50,
+			want: SearchCounts{ExactCount: 50, EstimatedTotal: 50, Strategy: CountStrategyExact, HasMore: true},
+		},
+		{
+			name: "exact, last page", offset: 40, returned: 10, merged: 50, total: 30,
+			want: SearchCounts{ExactCount: 50, EstimatedTotal: 50, Strategy: CountStrategyExact, HasMore: false},
+		},
+		{
+			name: "estimated from text matches", offset: 0, returned: 20, merged: 50, total: 800,
+			want: SearchCounts{ExactCount: 50, EstimatedTotal: 800, Strategy: CountStrategyEstimated, HasMore: true},
+		},
+		{
+			name: "offset past the merged set", offset: 100, returned: 0, merged: 50, total: 50,
+			want: SearchCounts{ExactCount: 100, EstimatedTotal: 100, Strategy: CountStrategyExact, HasMore: false},
+		},
+		{
+			name: "page beyond the merged set", offset: 45, returned: 10, merged: 50, total: -1,
+			want: SearchCounts{ExactCount: 55, EstimatedTotal: 55, Strategy: CountStrategyExact, HasMore: false},
+		},
+		{
+			name: "unknown text total", offset: 0, returned: 20, merged: 50, total: -1,
+			want: SearchCounts{ExactCount: 50, EstimatedTotal: 50, Strategy: CountStrategyExact, HasMore: true},
+		},
+		{
+			name: "no results", offset: 0, returned: 0, merged: 0, total: -1,
+			want: SearchCounts{ExactCount: 0, EstimatedTotal: 0, Strategy: CountStrategyExact, HasMore: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewSearchCounts(tt.offset, tt.returned, tt.merged, tt.total)
+			if got != tt.want {
+				t.Errorf("NewSearchCounts(%d, %d, %d, %d) = %+v, want %+v", tt.offset, tt.returned, tt.merged, tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchCountsApply(t *testing.T) {
+	var resp VectorSearchResponse
+	NewSearchCounts(0, 20, 50, 800).Apply(&resp)
+	if resp.TotalCount != 50 || resp.ExactCount != 50 || resp.EstimatedTotal != 800 ||
+		resp.CountStrategy != CountStrategyEstimated || !resp.HasMore {
+		t.Errorf("Apply set total_count %d, exact_count %d, estimated_total %d, count_strategy %q, has_more %t",
+			resp.TotalCount, resp.ExactCount, resp.EstimatedTotal, resp.CountStrategy, resp.HasMore)
+	}
+}
+
+func TestMergeResultsByCode(t *testing.T) {
+	row := func(code string) map[string]interface{} { return map[string]interface{}{"code": code} }
+	tests := []struct {
+		name               string
+		primary, secondary []map[string]interface{}
+		codes              []string
+		dropped            int
+	}{
+		{"no overlap", []map[string]interface{}{row("A")}, []map[string]interface{}{row("B")}, []string{"A", "B"}, 0},
+		{"duplicate across sets", []map[string]interface{}{row("A"), row("B")}, []map[string]interface{}{row("B"), row("C")}, []string{"A", "B", "C"}, 1},
+		{"duplicate within a set", []map[string]interface{}{row("A"), row("A")}, nil, []string{"A"}, 1},
+		{"rows without a code are kept", []map[string]interface{}{row("")}, []map[string]interface{}{row("")}, []string{"", ""}, 0},
+		{"empty", nil, nil, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, dropped := MergeResultsByCode(tt.primary, tt.secondary)
+			var codes []string
+			for _, r := range merged {
+				codes = append(codes, r["code"].(string))
+			}
+			if !reflect.DeepEqual(codes, tt.codes) || dropped != tt.dropped {
+				t.Errorf("MergeResultsByCode = %v with %d dropped, want %v with %d dropped", codes, dropped, tt.codes, tt.dropped)
+			}
+		})
+	}
+}
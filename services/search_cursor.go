@@ -0,0 +1,106 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Search cursor modes: which ordering the cursor position belongs to
+const (
+	// SearchCursorVector continues the Weaviate-ranked PostgreSQL rows (relevance DESC, name, code)
+	SearchCursorVector = "vector"
+	// SearchCursorText continues the PostgreSQL text match (search_priority DESC, LENGTH(name), name, code)
+	SearchCursorText = "text"
+)
+
+// ErrInvalidCursor is returned for cursors that cannot be decoded or belong to another query
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SearchCursor is the position after the last row of a search page. Pages continue with a keyset
+// condition instead of OFFSET, so deep pages stay fast and rows do not shift when data changes.
+type SearchCursor struct {
+	Mode      string  `json:"m"`
+	Query     string  `json:"q"` // fingerprint of the search the cursor was issued for
+	Seen      int     `json:"s"` // rows returned by earlier pages, used for counts
+	Priority  int     `json:"p,omitempty"`
+	Relevance float64 `json:"r,omitempty"`
+	Name      string  `json:"n,omitempty"`
+	Code      string  `json:"c,omitempty"`
+
+	// Score is the rank_score of the row; cursors issued before ranking weights carry only
+	// Priority or Relevance
+	Score *float64 `json:"w,omitempty"`
+}
+
+// RankScore returns the rank score the next page continues after
+func (c *SearchCursor) RankScore() float64 {
+	switch {
+	case c.Score != nil:
+		return *c.Score
+	case c.Mode == SearchCursorText:
+		return float64(c.Priority) * rankPriorityStep
+	default:
+		return c.Relevance
+	}
+}
+
+// SearchFingerprint identifies the search a cursor may be used with
+func SearchFingerprint(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Encode returns the opaque cursor string sent to clients
+func (c *SearchCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeSearchCursor parses a cursor and checks it was issued for the search with the given fingerprint
+func DecodeSearchCursor(value, fingerprint string) (*SearchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not base64url", ErrInvalidCursor)
+	}
+
+	var cursor SearchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidCursor)
+	}
+	if cursor.Mode != SearchCursorVector && cursor.Mode != SearchCursorText {
+		return nil, fmt.Errorf("%w: unknown mode '%s'", ErrInvalidCursor, cursor.Mode)
+	}
+	if cursor.Query != fingerprint {
+		return nil, fmt.Errorf("%w: issued for a different search", ErrInvalidCursor)
+	}
+	if cursor.Seen < 0 {
+		cursor.Seen = 0
+	}
+	return &cursor, nil
+}
+
+// NextSearchCursor builds the cursor following row, a search result row as returned by the
+// PostgreSQL search methods
+func NextSearchCursor(mode, fingerprint string, seen int, row map[string]interface{}) *SearchCursor {
+	cursor := &SearchCursor{Mode: mode, Query: fingerprint, Seen: seen}
+	cursor.Code, _ = row["code"].(string)
+	cursor.Name, _ = row["name"].(string)
+	switch mode {
+	case SearchCursorVector:
+		cursor.Relevance, _ = row["similarity_score"].(float64)
+	case SearchCursorText:
+		cursor.Priority, _ = row["search_priority"].(int)
+	}
+	if score, ok := row["rank_score"].(float64); ok {
+		cursor.Score = &score
+	}
+	return cursor
+}
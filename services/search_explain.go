@@ -0,0 +1,117 @@
+package services
+
+import (
+	"sort"
+	"strings"
+)
+
+// Stages reported by the search explanation, in the order /v1/search-by-vector runs them
+const (
+	ExplainStageExactBarcode = "exact_barcode"
+	ExplainStageExactCode    = "exact_code"
+	ExplainStageLike         = "like"
+	ExplainStageBM25         = "bm25"
+	ExplainStageTextSearch   = "text_search"
+	ExplainStageTFIDF        = "tfidf"
+)
+
+// SearchExplainStage reports whether one search stage returns the product for the query
+type SearchExplainStage struct {
+	Stage   string  `json:"stage"`
+	Runs    bool    `json:"runs"`            // whether /v1/search-by-vector runs this stage for the query
+	Matched bool    `json:"matched"`         // the product is among the stage's results
+	Rank    int     `json:"rank,omitempty"`  // 1-based position among distinct products
+	Score   float64 `json:"score,omitempty"` // the stage's own score
+	Total   int     `json:"total"`           // products the stage returned
+	Reason  string  `json:"reason"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// SearchExplainTokens compares the terms of the query and of the product name
+type SearchExplainTokens struct {
+	Query   []string `json:"query"`
+	Product []string `json:"product"`
+	Shared  []string `json:"shared"`
+}
+
+// SearchExplanation is the response of /v1/search/explain
+type SearchExplanation struct {
+	Query           string               `json:"query"`
+	Code            string               `json:"code"`
+	Exists          bool                 `json:"exists"` // the code is in ic_inventory
+	Name            string               `json:"name,omitempty"`
+	Barcodes        []string             `json:"barcodes"`
+	IndexedBarcodes []string             `json:"indexed_barcodes,omitempty"` // barcodes of the product's Weaviate objects
+	Tokens          *SearchExplainTokens `json:"tokens,omitempty"`
+	Stages          []SearchExplainStage `json:"stages"`
+	Verdict         string               `json:"verdict"`
+	Depth           int                  `json:"depth"` // results inspected per stage
+}
+
+// RankOfCode returns the 1-based rank of code among the distinct codes in order, or 0
+func RankOfCode(codes []string, code string) int {
+	rank := 0
+	seen := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		rank++
+		if c == code {
+			return rank
+		}
+	}
+	return 0
+}
+
+// CountDistinct returns the number of distinct non-empty codes
+func CountDistinct(codes []string) int {
+	seen := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		if c != "" {
+			seen[c] = true
+		}
+	}
+	return len(seen)
+}
+
+// SharedTokens returns the terms present in both lists, sorted
+func SharedTokens(a, b []string) []string {
+	inA := make(map[string]bool, len(a))
+	for _, t := range a {
+		inA[t] = true
+	}
+	shared := []string{}
+	seen := make(map[string]bool)
+	for _, t := range b {
+		if inA[t] && !seen[t] {
+			shared = append(shared, t)
+			seen[t] = true
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// ExplainVerdict summarises the stages into one sentence for a support reply
+func ExplainVerdict(e *SearchExplanation) string {
+	if !e.Exists {
+		return "The code is not in ic_inventory, so no stage can return it"
+	}
+	var matched []string
+	for _, stage := range e.Stages {
+		if stage.Runs && stage.Matched {
+			matched = append(matched, stage.Stage)
+		}
+	}
+	if len(matched) > 0 {
+		return "Returned on the first page by: " + strings.Join(matched, ", ")
+	}
+	for _, stage := range e.Stages {
+		if stage.Matched {
+			return "Only " + stage.Stage + " matches, but /v1/search-by-vector does not run it for this query: " + stage.Reason
+		}
+	}
+	return "No stage returns the product for this query; see the reason of each stage"
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Facets that can be requested with the "facets" search parameter
+const (
+	FacetItemType    = "item_type"
+	FacetUnit        = "unit_standard_code"
+	FacetSupplier    = "supplier"
+	FacetStockStatus = "stock_status"
+)
+
+// SearchFacetNames lists the facets in the order they are computed; "all" requests every one
+var SearchFacetNames = []string{FacetItemType, FacetUnit, FacetSupplier, FacetStockStatus}
+
+// Values of the stock_status facet
+const (
+	StockStatusInStock    = "in_stock"
+	StockStatusOutOfStock = "out_of_stock"
+)
+
+// maxFacetBuckets caps the values returned per facet; the most frequent ones are kept
+const maxFacetBuckets = 50
+
+// FacetBucket is one value of a facet and the number of matching products that have it
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// ParseFacets validates the "facets" search parameter. Entries may be comma separated and "all"
+// selects every facet; an empty result means no facets.
+func ParseFacets(facets []string) ([]string, error) {
+	requested := make(map[string]bool)
+	for _, entry := range facets {
+		for _, facet := range strings.Split(entry, ",") {
+			facet = strings.TrimSpace(facet)
+			switch {
+			case facet == "":
+			case facet == "all":
+				for _, name := range SearchFacetNames {
+					requested[name] = true
+				}
+			case isSearchFacet(facet):
+				requested[facet] = true
+			default:
+				return nil, fmt.Errorf("unknown facet '%s' (available: %s, all)", facet, strings.Join(SearchFacetNames, ", "))
+			}
+		}
+	}
+
+	var parsed []string
+	for _, name := range SearchFacetNames {
+		if requested[name] {
+			parsed = append(parsed, name)
+		}
+	}
+	return parsed, nil
+}
+
+func isSearchFacet(name string) bool {
+	for _, facet := range SearchFacetNames {
+		if facet == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchFacets counts the products matching query, plus the given codes (e.g. the Weaviate
+// matches), that pass filters per value of each facet. Facets whose column or table does not
+// exist are left out.
+func (s *PostgreSQLService) SearchFacets(ctx context.Context, query string, codes []string, filters SearchFilters, facets []string) (map[string][]FacetBucket, error) {
+	columns := make(map[string]string, len(facets))
+	for _, facet := range facets {
+		switch facet {
+		case FacetItemType:
+			columns[facet] = "CAST(COALESCE({item_type}, 0) AS TEXT)"
+		case FacetUnit:
+			columns[facet] = "COALESCE(CAST({unit_standard_code} AS TEXT), 'N/A')"
+		case FacetSupplier:
+			if !s.inventoryColumnExists(ctx, s.fields.Column(FieldSupplierCode)) {
+				log.Printf("⚠️ [FACETS] ic_inventory has no column '%s', skipping the supplier facet", s.fields.Column(FieldSupplierCode))
+				continue
+			}
+			columns[facet] = "COALESCE(CAST({supplier_code} AS TEXT), 'N/A')"
+		case FacetStockStatus:
+			if !s.tableExists(ctx, "ic_balance") {
+				log.Printf("⚠️ [FACETS] Table 'ic_balance' not found, skipping the stock_status facet")
+				continue
+			}
+			columns[facet] = `CASE WHEN COALESCE((SELECT SUM(b.balance_qty) FROM ic_balance b
+				WHERE CAST(b.ic_code AS TEXT) = CAST({ic_inventory.code} AS TEXT)), 0) > 0
+				THEN '` + StockStatusInStock + `' ELSE '` + StockStatusOutOfStock + `' END`
+		}
+	}
+	if len(columns) == 0 {
+		return map[string][]FacetBucket{}, nil
+	}
+
+	match := productTextMatch(query)
+	if len(codes) > 0 {
+		match = orExpr(match, expr("CAST({code} AS TEXT) = ANY(?::text[])", pq.Array(codes)))
+	}
+	builder := newSelect().From("ic_inventory").Where(match)
+	for _, condition := range s.filterConditions(ctx, filters) {
+		builder.Where(condition)
+	}
+	var counts []string
+	for _, facet := range SearchFacetNames {
+		column, ok := columns[facet]
+		if !ok {
+			continue
+		}
+		builder.Column(column + " AS " + facet)
+		counts = append(counts, fmt.Sprintf("SELECT '%s', %s, COUNT(*) FROM matched GROUP BY %s", facet, facet, facet))
+	}
+
+	matched, args, err := builder.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	query = s.fields.Expand("WITH matched AS (" + matched + ") " + strings.Join(counts, " UNION ALL "))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute facets: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string][]FacetBucket, len(columns))
+	for facet := range columns {
+		result[facet] = []FacetBucket{}
+	}
+	for rows.Next() {
+		var facet string
+		var bucket FacetBucket
+		if err := rows.Scan(&facet, &bucket.Value, &bucket.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan facet: %w", err)
+		}
+		result[facet] = append(result[facet], bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("facet rows iteration error: %w", err)
+	}
+
+	for facet, buckets := range result {
+		sort.Slice(buckets, func(i, j int) bool {
+			if buckets[i].Count != buckets[j].Count {
+				return buckets[i].Count > buckets[j].Count
+			}
+			return buckets[i].Value < buckets[j].Value
+		})
+		if len(buckets) > maxFacetBuckets {
+			result[facet] = buckets[:maxFacetBuckets]
+		}
+	}
+	return result, nil
+}
+
+// inventoryColumnExists reports whether ic_inventory has the given column
+func (s *PostgreSQLService) inventoryColumnExists(ctx context.Context, column string) bool {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM information_schema.columns
+		WHERE table_schema = 'public' AND table_name = 'ic_inventory' AND column_name = $1)`, column).Scan(&exists)
+	return err == nil && exists
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// SearchFilters restrict search results by price, stock and item type. Prices are compared with
+// final_price (price_0 of ic_inventory_price_formula) and stock with the ic_balance total, the
+// same values the results report.
+type SearchFilters struct {
+	MinPrice    *float64
+	MaxPrice    *float64
+	InStockOnly bool
+	ItemType    *int
+}
+
+// IsZero reports whether no filter is set
+func (f SearchFilters) IsZero() bool {
+	return f.MinPrice == nil && f.MaxPrice == nil && !f.InStockOnly && f.ItemType == nil
+}
+
+// Validate rejects negative prices and an empty price range
+func (f SearchFilters) Validate() error {
+	if f.MinPrice != nil && *f.MinPrice < 0 {
+		return fmt.Errorf("min_price must not be negative")
+	}
+	if f.MaxPrice != nil && *f.MaxPrice < 0 {
+		return fmt.Errorf("max_price must not be negative")
+	}
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return fmt.Errorf("min_price (%g) is greater than max_price (%g)", *f.MinPrice, *f.MaxPrice)
+	}
+	return nil
+}
+
+// Key identifies the filters in cursor fingerprints; it is empty when no filter is set
+func (f SearchFilters) Key() string {
+	if f.IsZero() {
+		return ""
+	}
+	var parts []string
+	if f.MinPrice != nil {
+		parts = append(parts, fmt.Sprintf("min=%g", *f.MinPrice))
+	}
+	if f.MaxPrice != nil {
+		parts = append(parts, fmt.Sprintf("max=%g", *f.MaxPrice))
+	}
+	if f.InStockOnly {
+		parts = append(parts, "in_stock")
+	}
+	if f.ItemType != nil {
+		parts = append(parts, fmt.Sprintf("item_type=%d", *f.ItemType))
+	}
+	return strings.Join(parts, "/")
+}
+
+// Matches reports whether an enriched search row passes the filters. It is used for the priority
+// steps, whose few rows are filtered after the lookup rather than in SQL.
+func (f SearchFilters) Matches(row map[string]interface{}) bool {
+	price, _ := row["final_price"].(float64)
+	if f.MinPrice != nil && price < *f.MinPrice {
+		return false
+	}
+	if f.MaxPrice != nil && price > *f.MaxPrice {
+		return false
+	}
+	if qty, _ := row["qty_available"].(float64); f.InStockOnly && qty <= 0 {
+		return false
+	}
+	if itemType, _ := row["item_type"].(int); f.ItemType != nil && itemType != *f.ItemType {
+		return false
+	}
+	return true
+}
+
+// Filter returns the rows that pass the filters and the number removed
+func (f SearchFilters) Filter(rows []map[string]interface{}) ([]map[string]interface{}, int) {
+	if f.IsZero() {
+		return rows, 0
+	}
+	kept := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		if f.Matches(row) {
+			kept = append(kept, row)
+		}
+	}
+	return kept, len(rows) - len(kept)
+}
+
+// filterConditions returns the WHERE conditions of the filters for ic_inventory rows. Without the
+// price or balance table every product counts as priced 0 or out of stock, as in the results.
+func (s *PostgreSQLService) filterConditions(ctx context.Context, f SearchFilters) []sqlExpr {
+	var conditions []sqlExpr
+
+	if f.MinPrice != nil || f.MaxPrice != nil {
+		price := "0"
+		if s.tableExists(ctx, "ic_inventory_price_formula") {
+			price = `COALESCE((SELECT MAX(CAST(p.price_0 AS float8)) FROM ic_inventory_price_formula p
+				WHERE CAST(p.ic_code AS TEXT) = CAST({ic_inventory.code} AS TEXT)), 0)`
+		} else {
+			log.Printf("⚠️ [FILTERS] Table 'ic_inventory_price_formula' not found, prices filter as 0")
+		}
+		if f.MinPrice != nil {
+			conditions = append(conditions, expr(price+" >= ?::float8", *f.MinPrice))
+		}
+		if f.MaxPrice != nil {
+			conditions = append(conditions, expr(price+" <= ?::float8", *f.MaxPrice))
+		}
+	}
+	if f.InStockOnly {
+		if s.tableExists(ctx, "ic_balance") {
+			conditions = append(conditions, expr(`COALESCE((SELECT SUM(b.balance_qty) FROM ic_balance b
+				WHERE CAST(b.ic_code AS TEXT) = CAST({ic_inventory.code} AS TEXT)), 0) > 0`))
+		} else {
+			log.Printf("⚠️ [FILTERS] Table 'ic_balance' not found, no product is in stock")
+			conditions = append(conditions, expr("false"))
+		}
+	}
+	if f.ItemType != nil {
+		conditions = append(conditions, expr("COALESCE({item_type}, 0) = ?", *f.ItemType))
+	}
+	return conditions
+}
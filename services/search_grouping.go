@@ -0,0 +1,76 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Supported values for the group_by search parameter
+const (
+	GroupByCodePrefix = "code_prefix"
+	GroupByName       = "name"
+)
+
+// codeVariantSeparators are the characters used to split a variant suffix off a product code (e.g. ABC-100-S)
+const codeVariantSeparators = "-_/. "
+
+// IsValidGroupBy reports whether groupBy is a supported grouping mode (empty means no grouping)
+func IsValidGroupBy(groupBy string) bool {
+	switch groupBy {
+	case "", GroupByCodePrefix, GroupByName:
+		return true
+	}
+	return false
+}
+
+// SearchGroupKey returns the family key used to collapse a product with its variants
+func SearchGroupKey(result SearchResult, groupBy string, prefixLength int) string {
+	switch groupBy {
+	case GroupByCodePrefix:
+		code := strings.TrimSpace(result.Code)
+		if prefixLength > 0 {
+			if utf8.RuneCountInString(code) <= prefixLength {
+				return code
+			}
+			return string([]rune(code)[:prefixLength])
+		}
+		// Strip the last separator-delimited segment (size/color/pack suffix)
+		if idx := strings.LastIndexAny(code, codeVariantSeparators); idx > 0 {
+			return code[:idx]
+		}
+		return code
+	case GroupByName:
+		return strings.ToLower(strings.Join(strings.Fields(result.Name), " "))
+	}
+	return result.Code
+}
+
+// GroupSearchResults collapses variants of the same product family into one result.
+// The first result of each family (the best ranked) becomes the representative card
+// and carries the variant count plus the full list of variants in ranking order.
+func GroupSearchResults(results []SearchResult, groupBy string, prefixLength int) []SearchResult {
+	if groupBy == "" || len(results) == 0 {
+		return results
+	}
+
+	grouped := make([]SearchResult, 0, len(results))
+	groupIndex := make(map[string]int)
+
+	for _, result := range results {
+		key := SearchGroupKey(result, groupBy, prefixLength)
+		if idx, exists := groupIndex[key]; exists {
+			grouped[idx].Variants = append(grouped[idx].Variants, result)
+			grouped[idx].VariantCount++
+			continue
+		}
+
+		representative := result
+		representative.GroupKey = key
+		representative.VariantCount = 1
+		representative.Variants = []SearchResult{result}
+		groupIndex[key] = len(grouped)
+		grouped = append(grouped, representative)
+	}
+
+	return grouped
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+// searchResultFields maps the JSON name of each SearchResult field to its struct index
+var searchResultFields = jsonFieldIndexes(reflect.TypeOf(SearchResult{}))
+
+func jsonFieldIndexes(t reflect.Type) map[string]int {
+	indexes := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			indexes[name] = i
+		}
+	}
+	return indexes
+}
+
+// ParseResultFields validates the "fields" search parameter. Entries may also be comma separated
+// ("code,name"); duplicates are dropped and an empty result means all fields.
+func ParseResultFields(fields []string) ([]string, error) {
+	var parsed []string
+	seen := make(map[string]bool)
+	for _, entry := range fields {
+		for _, field := range strings.Split(entry, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" || seen[field] {
+				continue
+			}
+			if _, ok := searchResultFields[field]; !ok {
+				return nil, fmt.Errorf("unknown field '%s' (available: %s)", field, strings.Join(SearchResultFieldNames(), ", "))
+			}
+			seen[field] = true
+			parsed = append(parsed, field)
+		}
+	}
+	return parsed, nil
+}
+
+// SearchResultFieldNames returns the fields that can be selected, sorted by name
+func SearchResultFieldNames() []string {
+	names := make([]string, 0, len(searchResultFields))
+	for name := range searchResultFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Project limits the serialized results to the given fields; nil restores the full results
+func (r *VectorSearchResponse) Project(fields []string) {
+	r.fields = fields
+}
+
+// vectorSearchResponseJSON has the fields of VectorSearchResponse without its MarshalJSON
+type vectorSearchResponseJSON VectorSearchResponse
+
+func (r VectorSearchResponse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.encodable())
+}
+
+// CodecEncodeSelf applies the projection to MessagePack responses as well
+func (r VectorSearchResponse) CodecEncodeSelf(e *codec.Encoder) {
+	e.MustEncode(r.encodable())
+}
+
+func (r *VectorSearchResponse) CodecDecodeSelf(d *codec.Decoder) {
+	d.MustDecode((*vectorSearchResponseJSON)(r))
+}
+
+// encodable returns the response as it is serialized, with the projection applied
+func (r VectorSearchResponse) encodable() interface{} {
+	if len(r.fields) == 0 {
+		return vectorSearchResponseJSON(r)
+	}
+	// The outer Data field hides the embedded one
+	return struct {
+		vectorSearchResponseJSON
+		Data []map[string]interface{} `json:"data"`
+	}{vectorSearchResponseJSON(r), projectSearchResults(r.Data, r.fields)}
+}
+
+func projectSearchResults(results []SearchResult, fields []string) []map[string]interface{} {
+	projected := make([]map[string]interface{}, len(results))
+	for i, result := range results {
+		value := reflect.ValueOf(result)
+		row := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			row[field] = value.Field(searchResultFields[field]).Interface()
+		}
+		// Grouped results keep their family and variants, projected the same way
+		if result.GroupKey != "" {
+			row["group_key"] = result.GroupKey
+			row["variant_count"] = result.VariantCount
+			row["variants"] = projectSearchResults(result.Variants, fields)
+		}
+		projected[i] = row
+	}
+	return projected
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"math"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Protobuf encoding of search responses, following proto/search.proto. The messages are written
+// by hand with protowire so the build does not need protoc; keep the field numbers in sync with
+// the .proto file. Zero values are left out, as proto3 does.
+
+type protoField struct {
+	name   string // JSON name, used by Project
+	number protowire.Number
+	value  func(r *SearchResult) interface{}
+}
+
+// searchResultProtoFields follows message SearchResult; variants (24) are written separately
+var searchResultProtoFields = []protoField{
+	{"id", 1, func(r *SearchResult) interface{} { return r.ID }},
+	{"name", 2, func(r *SearchResult) interface{} { return r.Name }},
+	{"similarity_score", 3, func(r *SearchResult) interface{} { return r.SimilarityScore }},
+	{"code", 4, func(r *SearchResult) interface{} { return r.Code }},
+	{"balance_qty", 5, func(r *SearchResult) interface{} { return r.BalanceQty }},
+	{"price", 6, func(r *SearchResult) interface{} { return r.Price }},
+	{"supplier_code", 7, func(r *SearchResult) interface{} { return r.SupplierCode }},
+	{"unit", 8, func(r *SearchResult) interface{} { return r.Unit }},
+	{"img_url", 9, func(r *SearchResult) interface{} { return r.ImgURL }},
+	{"search_priority", 10, func(r *SearchResult) interface{} { return r.SearchPriority }},
+	{"sale_price", 11, func(r *SearchResult) interface{} { return r.SalePrice }},
+	{"premium_word", 12, func(r *SearchResult) interface{} { return r.PremiumWord }},
+	{"discount_price", 13, func(r *SearchResult) interface{} { return r.DiscountPrice }},
+	{"discount_percent", 14, func(r *SearchResult) interface{} { return r.DiscountPercent }},
+	{"final_price", 15, func(r *SearchResult) interface{} { return r.FinalPrice }},
+	{"sold_qty", 16, func(r *SearchResult) interface{} { return r.SoldQty }},
+	{"multi_packing", 17, func(r *SearchResult) interface{} { return r.MultiPacking }},
+	{"multi_packing_name", 18, func(r *SearchResult) interface{} { return r.MultiPackingName }},
+	{"barcodes", 19, func(r *SearchResult) interface{} { return r.Barcodes }},
+	{"barcode", 20, func(r *SearchResult) interface{} { return r.Barcode }},
+	{"qty_available", 21, func(r *SearchResult) interface{} { return r.QtyAvailable }},
+	{"group_key", 22, func(r *SearchResult) interface{} { return r.GroupKey }},
+	{"variant_count", 23, func(r *SearchResult) interface{} { return r.VariantCount }},
+}
+
+// AppendProto appends the SearchResponse message. Results projected with Project only carry the
+// selected fields.
+func (r *VectorSearchResponse) AppendProto(b []byte) []byte {
+	selected := r.selectedFields()
+	for i := range r.Data {
+		b = appendProtoMessage(b, 1, appendSearchResultProto(nil, &r.Data[i], selected))
+	}
+	b = appendProtoInt(b, 2, r.TotalCount)
+	b = appendProtoString(b, 3, r.Query)
+	b = appendProtoDouble(b, 4, r.Duration)
+	b = appendProtoInt(b, 5, r.ExactCount)
+	b = appendProtoInt(b, 6, r.EstimatedTotal)
+	b = appendProtoString(b, 7, r.CountStrategy)
+	b = appendProtoBool(b, 8, r.HasMore)
+	b = appendProtoString(b, 9, r.NextCursor)
+	b = appendProtoBool(b, 10, r.Partial)
+	for _, failure := range r.FailedSteps {
+		var m []byte
+		m = appendProtoString(m, 1, failure.Step)
+		m = appendProtoInt(m, 2, failure.Attempts)
+		m = appendProtoString(m, 3, failure.Error)
+		b = appendProtoMessage(b, 11, m)
+	}
+
+	// Map entries in key order so equal responses encode identically
+	facets := make([]string, 0, len(r.Facets))
+	for facet := range r.Facets {
+		facets = append(facets, facet)
+	}
+	sort.Strings(facets)
+	for _, facet := range facets {
+		var buckets []byte
+		for _, bucket := range r.Facets[facet] {
+			var m []byte
+			m = appendProtoString(m, 1, bucket.Value)
+			m = appendProtoInt(m, 2, bucket.Count)
+			buckets = appendProtoMessage(buckets, 1, m)
+		}
+		var entry []byte
+		entry = appendProtoString(entry, 1, facet)
+		entry = appendProtoMessage(entry, 2, buckets)
+		b = appendProtoMessage(b, 12, entry)
+	}
+	for _, stage := range r.SkippedStages {
+		b = appendProtoString(b, 13, stage)
+	}
+	b = appendProtoString(b, 14, r.SuggestedQuery)
+	b = appendProtoString(b, 15, r.ExpandedQuery)
+	b = appendProtoString(b, 16, r.SearchMethod)
+	if r.Priority != nil {
+		var m []byte
+		m = appendProtoBool(m, 1, r.Priority.Applied)
+		for _, step := range r.Priority.Steps {
+			m = appendProtoString(m, 2, step)
+		}
+		m = appendProtoInt(m, 3, r.Priority.Count)
+		m = appendProtoBool(m, 4, r.Priority.Filled)
+		b = appendProtoMessage(b, 17, m)
+	}
+	return b
+}
+
+// selectedFields returns the projected fields as a set, or nil for all fields
+func (r *VectorSearchResponse) selectedFields() map[string]bool {
+	if len(r.fields) == 0 {
+		return nil
+	}
+	selected := make(map[string]bool, len(r.fields))
+	for _, field := range r.fields {
+		selected[field] = true
+	}
+	return selected
+}
+
+func appendSearchResultProto(b []byte, r *SearchResult, selected map[string]bool) []byte {
+	for _, field := range searchResultProtoFields {
+		// Grouping fields are kept by projections, see projectSearchResults
+		if selected == nil || selected[field.name] || (r.GroupKey != "" && field.number >= 22) {
+			b = appendProtoValue(b, field.number, field.value(r))
+		}
+	}
+	for i := range r.Variants {
+		b = appendProtoMessage(b, 24, appendSearchResultProto(nil, &r.Variants[i], selected))
+	}
+	return b
+}
+
+func appendProtoValue(b []byte, num protowire.Number, v interface{}) []byte {
+	switch v := v.(type) {
+	case string:
+		return appendProtoString(b, num, v)
+	case float64:
+		return appendProtoDouble(b, num, v)
+	case int:
+		return appendProtoInt(b, num, v)
+	}
+	return b
+}
+
+func appendProtoString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendProtoDouble(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+func appendProtoInt(b []byte, num protowire.Number, v int) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(int64(v)))
+}
+
+func appendProtoBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, 1)
+}
+
+// appendProtoMessage appends an embedded message; an empty one is still written so repeated
+// fields keep their length
+func appendProtoMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Base rank scores. Exact matches start at 100, Weaviate relevance is 0-100 and the text search
+// tiers are its search_priority times ten, so with default weights the ranking is unchanged.
+const (
+	rankExactMatch   = 100.0
+	rankPriorityStep = 10.0
+)
+
+// RankingWeights tune the order of search results. Weights scale the score of rows found by
+// a source; boosts are added to rows that have stock or an image.
+type RankingWeights struct {
+	ExactCode float64 `json:"weight_exact_code"`
+	Barcode   float64 `json:"weight_barcode"`
+	Vector    float64 `json:"weight_vector"`
+	InStock   float64 `json:"boost_in_stock"`
+	HasImage  float64 `json:"boost_has_image"`
+}
+
+// DefaultRankingWeights returns the weights that reproduce the standard ranking
+func DefaultRankingWeights() RankingWeights {
+	return RankingWeights{ExactCode: 1, Barcode: 1, Vector: 1}
+}
+
+// IsDefault reports whether the weights leave the standard ranking unchanged
+func (w RankingWeights) IsDefault() bool {
+	return w == DefaultRankingWeights()
+}
+
+// Validate rejects negative weights and boosts
+func (w RankingWeights) Validate() error {
+	for name, value := range map[string]float64{
+		"weight_exact_code": w.ExactCode, "weight_barcode": w.Barcode, "weight_vector": w.Vector,
+		"boost_in_stock": w.InStock, "boost_has_image": w.HasImage,
+	} {
+		if value < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	return nil
+}
+
+// Key identifies non-default weights in cursor fingerprints; it is empty for the defaults
+func (w RankingWeights) Key() string {
+	if w.IsDefault() {
+		return ""
+	}
+	return fmt.Sprintf("%g/%g/%g/%g/%g", w.ExactCode, w.Barcode, w.Vector, w.InStock, w.HasImage)
+}
+
+// RowScore returns the rank score of a search row. Rows from the ranked PostgreSQL searches
+// carry it in rank_score; the exact-match and LIKE steps are scored by their search_method.
+func (w RankingWeights) RowScore(row map[string]interface{}) float64 {
+	if score, ok := row["rank_score"].(float64); ok {
+		return score
+	}
+
+	method, _ := row["search_method"].(string)
+	priority, _ := row["search_priority"].(int)
+	var score float64
+	switch {
+	case method == "barcode_exact":
+		score = rankExactMatch * w.Barcode
+	case method == "code_exact":
+		score = rankExactMatch * w.ExactCode
+	default:
+		score = float64(priority) * rankPriorityStep
+	}
+
+	if qty, ok := row["qty_available"].(float64); ok && qty > 0 {
+		score += w.InStock
+	}
+	if hasImage, _ := row["has_image"].(bool); hasImage {
+		score += w.HasImage
+	}
+	return score
+}
+
+// SortByRank orders rows by their rank score, keeping the current order among equal scores
+func (w RankingWeights) SortByRank(rows []map[string]interface{}) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return w.RowScore(rows[i]) > w.RowScore(rows[j])
+	})
+}
+
+// rankingBoosts returns the SQL of the in-stock and has-image boosts for ic_inventory rows and
+// the condition for the has_image column. Tables or columns that do not exist add no boost, and
+// the image column is only looked up when its boost is set.
+func (s *PostgreSQLService) rankingBoosts(ctx context.Context, w RankingWeights) (sqlExpr, string) {
+	boosts := expr("0")
+	hasImage := "false"
+
+	if w.InStock != 0 && s.tableExists(ctx, "ic_balance") {
+		boosts = expr(boosts.sql+` + ?::float8 * (CASE WHEN COALESCE((SELECT SUM(b.balance_qty) FROM ic_balance b
+			WHERE CAST(b.ic_code AS TEXT) = CAST({ic_inventory.code} AS TEXT)), 0) > 0 THEN 1 ELSE 0 END)`,
+			append(boosts.args, w.InStock)...)
+	}
+	if w.HasImage != 0 {
+		if column := s.inventoryImageColumn(ctx); column != "" {
+			hasImage = "COALESCE(CAST(ic_inventory." + column + " AS TEXT), '') NOT IN ('', 'N/A', '[]')"
+			boosts = expr(boosts.sql+" + ?::float8 * (CASE WHEN "+hasImage+" THEN 1 ELSE 0 END)",
+				append(boosts.args, w.HasImage)...)
+		}
+	}
+	return boosts, hasImage
+}
+
+// inventoryImageColumn returns the ic_inventory column holding image URLs, or "" without one
+func (s *PostgreSQLService) inventoryImageColumn(ctx context.Context) string {
+	var columns []string
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = 'public' AND table_name = 'ic_inventory' AND column_name IN ('image_url', 'img_url')`)
+	if err != nil {
+		return ""
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var column string
+		if rows.Scan(&column) == nil {
+			columns = append(columns, column)
+		}
+	}
+	// Same preference as productImages
+	for _, preferred := range []string{"image_url", "img_url"} {
+		for _, column := range columns {
+			if strings.EqualFold(column, preferred) {
+				return column
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"time"
+
+	"smlgoapi/config"
+)
+
+// ShadowMirror runs a sample of searches a second time through an experimental pipeline. It
+// never affects the response: mirrors run detached from the request and are dropped when too
+// many are in flight.
+type ShadowMirror struct {
+	cfg   config.ShadowSearchConfig
+	slots chan struct{}
+}
+
+// NewShadowMirror returns nil when mirroring is disabled
+func NewShadowMirror(cfg config.ShadowSearchConfig) *ShadowMirror {
+	if !cfg.Enabled || cfg.SamplePercent <= 0 {
+		return nil
+	}
+	return &ShadowMirror{cfg: cfg, slots: make(chan struct{}, cfg.MaxConcurrent)}
+}
+
+// Sample reports whether this request should be mirrored
+func (m *ShadowMirror) Sample() bool {
+	return m != nil && rand.Float64()*100 < m.cfg.SamplePercent
+}
+
+// Go runs fn in the background with its own timeout. The request's query tag is kept so the
+// shadow queries can be told apart in pg_stat_activity and the ClickHouse query log.
+func (m *ShadowMirror) Go(ctx context.Context, fn func(ctx context.Context)) bool {
+	select {
+	case m.slots <- struct{}{}:
+	default:
+		return false
+	}
+
+	shadowCtx := context.Background()
+	if tag, ok := QueryTagFromContext(ctx); ok {
+		tag.Route += " (shadow)"
+		shadowCtx = WithQueryTag(shadowCtx, tag)
+	}
+	go func() {
+		defer func() { <-m.slots }()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("❌ [SHADOW] mirrored search panicked: %v", r)
+			}
+		}()
+		runCtx, cancel := context.WithTimeout(shadowCtx, time.Duration(m.cfg.TimeoutMs)*time.Millisecond)
+		defer cancel()
+		fn(runCtx)
+	}()
+	return true
+}
+
+// SearchDiff compares the product codes of two rankings of the same query
+type SearchDiff struct {
+	Compared    int      // codes compared from each side (the shorter list's length)
+	Overlap     int      // codes in both top lists
+	SameTop     bool     // both ranked the same product first
+	OnlyPrimary []string // in the primary top list only
+	OnlyShadow  []string // in the shadow top list only
+}
+
+// CompareSearchResults compares the first n codes of each ranking, n being the shorter length
+func CompareSearchResults(primary, shadow []string) SearchDiff {
+	n := min(len(primary), len(shadow))
+	diff := SearchDiff{Compared: n}
+	if n == 0 {
+		return diff
+	}
+	diff.SameTop = primary[0] == shadow[0]
+
+	inShadow := make(map[string]bool, n)
+	for _, code := range shadow[:n] {
+		inShadow[code] = true
+	}
+	inPrimary := make(map[string]bool, n)
+	for _, code := range primary[:n] {
+		inPrimary[code] = true
+		if inShadow[code] {
+			diff.Overlap++
+		} else {
+			diff.OnlyPrimary = append(diff.OnlyPrimary, code)
+		}
+	}
+	for _, code := range shadow[:n] {
+		if !inPrimary[code] {
+			diff.OnlyShadow = append(diff.OnlyShadow, code)
+		}
+	}
+	return diff
+}
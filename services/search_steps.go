@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"smlgoapi/config"
+)
+
+// Priority search steps, named as in search.priority_steps
+const (
+	SearchStepBarcode = "barcode"
+	SearchStepCode    = "code"
+	SearchStepLike    = "like"
+)
+
+// On-error actions of a priority search step
+const (
+	SearchStepSkip  = "skip"
+	SearchStepRetry = "retry"
+	SearchStepFail  = "fail"
+)
+
+// SearchStepFailure reports a priority search step that failed and was skipped
+type SearchStepFailure struct {
+	Step     string `json:"step"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+}
+
+// SearchStepFunc runs one search step and returns its rows and total count
+type SearchStepFunc func() ([]map[string]interface{}, int, error)
+
+// RunSearchStep runs a step under its policy. A step that still fails after its retries returns
+// a failure to report when it may be skipped, or an error when the policy is "fail".
+func RunSearchStep(ctx context.Context, step string, policy config.SearchStepPolicy, run SearchStepFunc) ([]map[string]interface{}, int, *SearchStepFailure, error) {
+	attempts := 1
+	if policy.OnError != SearchStepSkip {
+		attempts += policy.Retries
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var rows []map[string]interface{}
+		var count int
+		rows, count, err = run()
+		if err == nil {
+			return rows, count, nil, nil
+		}
+		// A cancelled request will not succeed on the next attempt
+		if ctx.Err() != nil {
+			attempts = attempt
+			break
+		}
+		if attempt < attempts {
+			log.Printf("🔁 [PRIORITY-SEARCH] %s step failed (attempt %d/%d), retrying: %v", step, attempt, attempts, err)
+			select {
+			case <-ctx.Done():
+				attempts = attempt
+			case <-time.After(time.Duration(policy.RetryDelayMs) * time.Millisecond):
+			}
+		}
+	}
+
+	if policy.OnError == SearchStepFail {
+		return nil, 0, nil, fmt.Errorf("%s search failed after %d attempt(s): %w", step, attempts, err)
+	}
+	log.Printf("⚠️ [PRIORITY-SEARCH] %s step failed after %d attempt(s), skipping: %v", step, attempts, err)
+	return nil, 0, &SearchStepFailure{Step: step, Attempts: attempts, Error: err.Error()}, nil
+}
+
+// MarkPartial flags a response whose priority search skipped failed steps
+func (r *VectorSearchResponse) MarkPartial(failures []SearchStepFailure) {
+	if len(failures) == 0 {
+		return
+	}
+	r.Partial = true
+	r.FailedSteps = failures
+}
+
+// PrioritySearch reports what the priority steps added to a page of /v1/search-by-vector. They
+// only run for the first page (offset 0 without a cursor): their exact barcode and code matches,
+// or LIKE matches when there are none, lead that page and the ranked results fill the rest.
+// Later pages hold ranked results only.
+type PrioritySearch struct {
+	Applied bool     `json:"applied"`          // false on later pages
+	Steps   []string `json:"steps,omitempty"`  // steps that ran, in order: barcode, code, like
+	Count   int      `json:"count"`            // rows of the page found by the steps
+	Filled  bool     `json:"filled,omitempty"` // the steps filled the page, so no ranked search ran
+}
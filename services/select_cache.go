@@ -0,0 +1,407 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SelectCache keeps /v1/select and /v1/pgselect results in memory for the cache_ttl the request
+// asked for, so dashboards polling the same query do not run it every time. Entries are keyed on
+// the database, the query with comments and whitespace normalized, and the parameters. A
+// successful command on a table drops the cached results that read it. With Redis the results are
+// shared by every instance and Redis evicts them under memory pressure instead of max_entries.
+type SelectCache struct {
+	cfg   config.SelectCacheConfig
+	redis *RedisStore // nil keeps the results in memory
+
+	mu      sync.Mutex
+	entries map[string]*selectCacheEntry
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+type selectCacheEntry struct {
+	database string
+	query    string
+	tables   []string
+	rows     []interface{}
+	cachedAt time.Time
+	expires  time.Time
+	hits     int64
+}
+
+// selectCacheMeta describes a result stored in Redis; the rows are stored under their own key so
+// inspecting and invalidating the cache does not read them
+type selectCacheMeta struct {
+	Database  string    `json:"database"`
+	Query     string    `json:"query"`
+	Tables    []string  `json:"tables"`
+	Rows      int       `json:"rows"`
+	CachedAt  time.Time `json:"cached_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// selectCacheScanTimeout bounds the SCAN of every cached result behind inspection and invalidation
+const selectCacheScanTimeout = 10 * time.Second
+
+// NewSelectCache creates the cache of select_cache; store may be nil
+func NewSelectCache(cfg config.SelectCacheConfig, store *RedisStore) *SelectCache {
+	return &SelectCache{cfg: cfg, redis: store, entries: make(map[string]*selectCacheEntry)}
+}
+
+// TTL returns how long a result requested with cacheTTL seconds is kept, lowered to
+// select_cache.max_ttl_seconds; 0 means the result is not cached
+func (c *SelectCache) TTL(cacheTTL int) time.Duration {
+	if c.cfg.Disabled || cacheTTL <= 0 {
+		return 0
+	}
+	return time.Duration(min(cacheTTL, c.cfg.MaxTTLSeconds)) * time.Second
+}
+
+// SelectCacheKey identifies a query: the same statement written with other whitespace or comments
+// and bound to the same parameters shares a key
+func SelectCacheKey(database, query string, params []interface{}) string {
+	var b strings.Builder
+	b.WriteString(database)
+	for _, token := range tokenizeSQL(database, query) {
+		b.WriteByte(' ')
+		if token.quoted {
+			b.WriteByte('"')
+		}
+		b.WriteString(token.text)
+	}
+	b.WriteByte(0)
+	encoded, _ := json.Marshal(params)
+	b.Write(encoded)
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Get returns the cached rows of key; they are shared and must not be modified
+func (c *SelectCache) Get(key string) ([]interface{}, bool) {
+	if c.redis != nil {
+		rows, err := c.redisGet(key)
+		if err != nil && err != redis.Nil {
+			log.Printf("⚠️ [select-cache] Redis read failed: %v", err)
+		}
+		if err != nil {
+			c.misses.Add(1)
+			return nil, false
+		}
+		c.hits.Add(1)
+		return rows, true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		c.misses.Add(1)
+		return nil, false
+	}
+	entry.hits++
+	c.hits.Add(1)
+	return entry.rows, true
+}
+
+// Put caches rows under key for ttl. Results over select_cache.max_rows are not cached, and
+// neither are those of queries whose tables cannot all be read or that change data, since no
+// write could be matched to them to drop the entry.
+func (c *SelectCache) Put(key, database, query string, rows []interface{}, ttl time.Duration) {
+	if ttl <= 0 || len(rows) > c.cfg.MaxRows {
+		return
+	}
+
+	var tables []string
+	for _, stmt := range analyzeSQL(database, query) {
+		if stmt.Incomplete || stmt.modifiesData() {
+			return
+		}
+		tables = append(tables, stmt.Tables...)
+	}
+
+	if c.redis != nil {
+		if err := c.redisPut(key, database, query, tables, rows, ttl); err != nil {
+			log.Printf("⚠️ [select-cache] Redis write failed: %v", err)
+		}
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.cfg.MaxEntries {
+		// Drop expired results first, then the one closest to expiring
+		oldestKey, oldest := "", time.Time{}
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			} else if oldestKey == "" || entry.expires.Before(oldest) {
+				oldestKey, oldest = k, entry.expires
+			}
+		}
+		if len(c.entries) >= c.cfg.MaxEntries {
+			delete(c.entries, oldestKey)
+		}
+	}
+	c.entries[key] = &selectCacheEntry{
+		database: database,
+		query:    query,
+		tables:   tables,
+		rows:     rows,
+		cachedAt: now,
+		expires:  now.Add(ttl),
+	}
+}
+
+// Invalidate removes the results of database that read table, or all of its results when table is
+// empty. An empty database matches both. Tables match with or without their schema.
+func (c *SelectCache) Invalidate(database, table string) int {
+	table = strings.ToLower(strings.Trim(table, `"`))
+	if c.redis != nil {
+		removed, err := c.redisInvalidate(func(meta selectCacheMeta) bool {
+			return (database == "" || meta.Database == database) && (table == "" || readsTable(meta.Tables, table))
+		})
+		if err != nil {
+			log.Printf("⚠️ [select-cache] Redis invalidation failed after %d results: %v", removed, err)
+		}
+		return removed
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for key, entry := range c.entries {
+		if database != "" && entry.database != database {
+			continue
+		}
+		if table != "" && !readsTable(entry.tables, table) {
+			continue
+		}
+		delete(c.entries, key)
+		removed++
+	}
+	return removed
+}
+
+// Remove drops the result cached under key
+func (c *SelectCache) Remove(key string) int {
+	if c.redis != nil {
+		ctx, cancel := c.redis.context()
+		defer cancel()
+		removed, err := c.redis.client.Del(ctx, c.redis.key("select", "meta", key), c.redis.key("select", "rows", key)).Result()
+		if err != nil {
+			log.Printf("⚠️ [select-cache] Redis delete failed: %v", err)
+		}
+		return min(int(removed), 1)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		return 0
+	}
+	delete(c.entries, key)
+	return 1
+}
+
+// InvalidateQuery removes the results of database that read a table written by query
+func (c *SelectCache) InvalidateQuery(database, query string) int {
+	removed := 0
+	for _, stmt := range analyzeSQL(database, query) {
+		for _, table := range stmt.Tables {
+			removed += c.Invalidate(database, table)
+		}
+	}
+	return removed
+}
+
+func readsTable(tables []string, table string) bool {
+	for _, t := range tables {
+		if t == table || unqualifiedTable(t) == unqualifiedTable(table) {
+			return true
+		}
+	}
+	return false
+}
+
+func unqualifiedTable(table string) string {
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		return table[i+1:]
+	}
+	return table
+}
+
+// Stats reports the cache and its live entries, the most used first
+func (c *SelectCache) Stats() models.SelectCacheStats {
+	if c.redis != nil {
+		return c.redisStats()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	items := []models.SelectCacheEntryStats{}
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			continue
+		}
+		items = append(items, models.SelectCacheEntryStats{
+			Key:       key,
+			Database:  entry.database,
+			Query:     entry.query,
+			Tables:    entry.tables,
+			Rows:      len(entry.rows),
+			Hits:      entry.hits,
+			CachedAt:  entry.cachedAt,
+			ExpiresAt: entry.expires,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Hits != items[j].Hits {
+			return items[i].Hits > items[j].Hits
+		}
+		return items[i].Key < items[j].Key
+	})
+
+	return models.SelectCacheStats{
+		Enabled:    !c.cfg.Disabled,
+		Backend:    "memory",
+		Entries:    len(items),
+		MaxEntries: c.cfg.MaxEntries,
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		Items:      items,
+	}
+}
+
+func (c *SelectCache) redisGet(key string) ([]interface{}, error) {
+	ctx, cancel := c.redis.context()
+	defer cancel()
+	data, err := c.redis.client.Get(ctx, c.redis.key("select", "rows", key)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	// Numbers stay json.Number so integers beyond 2^53 come back exactly as they were cached
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var rows []interface{}
+	if err := dec.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("cached rows of %s: %w", key, err)
+	}
+	return rows, nil
+}
+
+func (c *SelectCache) redisPut(key, database, query string, tables []string, rows []interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	meta, err := json.Marshal(selectCacheMeta{
+		Database: database, Query: query, Tables: tables, Rows: len(rows), CachedAt: now, ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.redis.context()
+	defer cancel()
+	pipe := c.redis.client.TxPipeline()
+	pipe.Set(ctx, c.redis.key("select", "rows", key), data, ttl)
+	pipe.Set(ctx, c.redis.key("select", "meta", key), meta, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// redisEach hands every cached result's key and description to fn
+func (c *SelectCache) redisEach(ctx context.Context, fn func(key string, meta selectCacheMeta)) error {
+	metaPrefix := c.redis.key("select", "meta", "")
+	return c.redis.scan(ctx, metaPrefix+"*", func(keys []string) error {
+		values, err := c.redis.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return err
+		}
+		for i, value := range values {
+			text, ok := value.(string)
+			if !ok {
+				continue // expired between SCAN and MGET
+			}
+			var meta selectCacheMeta
+			if json.Unmarshal([]byte(text), &meta) == nil {
+				fn(strings.TrimPrefix(keys[i], metaPrefix), meta)
+			}
+		}
+		return nil
+	})
+}
+
+func (c *SelectCache) redisInvalidate(match func(meta selectCacheMeta) bool) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), selectCacheScanTimeout)
+	defer cancel()
+	var doomed []string
+	err := c.redisEach(ctx, func(key string, meta selectCacheMeta) {
+		if match(meta) {
+			doomed = append(doomed, key)
+		}
+	})
+	removed := 0
+	for start := 0; start < len(doomed); start += 500 {
+		keys := make([]string, 0, 1000)
+		for _, key := range doomed[start:min(start+500, len(doomed))] {
+			keys = append(keys, c.redis.key("select", "meta", key), c.redis.key("select", "rows", key))
+		}
+		n, delErr := c.redis.client.Del(ctx, keys...).Result()
+		if delErr != nil {
+			return removed, delErr
+		}
+		removed += int(n) / 2
+	}
+	return removed, err
+}
+
+func (c *SelectCache) redisStats() models.SelectCacheStats {
+	ctx, cancel := context.WithTimeout(context.Background(), selectCacheScanTimeout)
+	defer cancel()
+	items := []models.SelectCacheEntryStats{}
+	err := c.redisEach(ctx, func(key string, meta selectCacheMeta) {
+		items = append(items, models.SelectCacheEntryStats{
+			Key:       key,
+			Database:  meta.Database,
+			Query:     meta.Query,
+			Tables:    meta.Tables,
+			Rows:      meta.Rows,
+			CachedAt:  meta.CachedAt,
+			ExpiresAt: meta.ExpiresAt,
+		})
+	})
+	sort.Slice(items, func(i, j int) bool { return items[i].CachedAt.After(items[j].CachedAt) })
+	stats := models.SelectCacheStats{
+		Enabled: !c.cfg.Disabled,
+		Backend: "redis",
+		Entries: len(items),
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Items:   items,
+	}
+	if err != nil {
+		stats.Error = err.Error()
+	}
+	return stats
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"smlgoapi/config"
+)
+
+func TestSelectCachePut(t *testing.T) {
+	tests := []struct {
+		query  string
+		cached bool
+	}{
+		{"SELECT * FROM ic_inventory", true},
+		{"SELECT * FROM a, $1", false},
+		{"WITH d AS (DELETE FROM ic_inventory RETURNING *) SELECT * FROM d", false},
+		{"SELECT * INTO copy FROM ic_inventory", false},
+	}
+	for _, tt := range tests {
+		cache := NewSelectCache(config.SelectCacheConfig{MaxRows: 10, MaxEntries: 10, MaxTTLSeconds: 60}, nil)
+		key := SelectCacheKey(ServicePostgreSQL, tt.query, nil)
+		cache.Put(key, ServicePostgreSQL, tt.query, []interface{}{map[string]interface{}{"code": "A"}}, time.Minute)
+		if _, ok := cache.Get(key); ok != tt.cached {
+			t.Errorf("Put(%q) cached %t, want %t", tt.query, ok, tt.cached)
+		}
+	}
+}
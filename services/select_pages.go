@@ -0,0 +1,92 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrResultFull stops the scan of a LimitedRows once it holds as much as it may
+var ErrResultFull = errors.New("result limit reached")
+
+// LimitedRows collects the rows of a SELECT until it holds MaxRows rows or MaxBytes of JSON, so a
+// huge result is cut off while it is read instead of after it was buffered. The first row is always
+// kept, however large, so a result can be paged through.
+type LimitedRows struct {
+	MaxRows  int
+	MaxBytes int64
+
+	Rows      []interface{}
+	Bytes     int64 // JSON size of Rows
+	Truncated bool  // more rows were left unread
+}
+
+// Collect is the RowFunc of the scan; it returns ErrResultFull on the first row that does not fit
+func (l *LimitedRows) Collect(columns []string, values []interface{}) error {
+	if len(l.Rows) >= l.MaxRows {
+		l.Truncated = true
+		return ErrResultFull
+	}
+	row := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		row[column] = values[i]
+	}
+	encoded, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Errorf("failed to encode row: %w", err)
+	}
+	size := int64(len(encoded)) + 1 // the comma between rows
+	if len(l.Rows) > 0 && l.Bytes+size > l.MaxBytes {
+		l.Truncated = true
+		return ErrResultFull
+	}
+	l.Rows = append(l.Rows, row)
+	l.Bytes += size
+	return nil
+}
+
+// SelectCursor is the position after the last row of a truncated /select or /pgselect result.
+// The next page runs the query again from that offset, so it only continues where the first page
+// stopped when the query has an ORDER BY.
+type SelectCursor struct {
+	Query  string `json:"q"` // fingerprint of the query and params the cursor was issued for
+	Offset int    `json:"o"` // rows returned by earlier pages
+}
+
+// SelectFingerprint identifies the query a cursor may be used with
+func SelectFingerprint(database, query string, params []interface{}) string {
+	return SelectCacheKey(database, query, params)[:16]
+}
+
+// Encode returns the opaque cursor string sent to clients
+func (c *SelectCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeSelectCursor parses a cursor and checks it was issued for the query with the given fingerprint
+func DecodeSelectCursor(value, fingerprint string) (*SelectCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not base64url", ErrInvalidCursor)
+	}
+
+	var cursor SelectCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidCursor)
+	}
+	if cursor.Query != fingerprint {
+		return nil, fmt.Errorf("%w: issued for a different query", ErrInvalidCursor)
+	}
+	if cursor.Offset < 0 {
+		return nil, fmt.Errorf("%w: negative offset", ErrInvalidCursor)
+	}
+	return &cursor, nil
+}
+
+// SelectPageQuery wraps query to read at most limit rows after the first offset. ClickHouse and
+// PostgreSQL both accept LIMIT ... OFFSET over a subquery, and placeholders keep their numbers.
+func SelectPageQuery(query string, offset, limit int) string {
+	return fmt.Sprintf("SELECT * FROM (\n%s\n) AS page LIMIT %d OFFSET %d", trimStatement(query), limit, offset)
+}
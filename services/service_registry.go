@@ -0,0 +1,80 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"sync"
+
+	"smlgoapi/models"
+)
+
+// Dependency names shared by the health checks and the service registry
+const (
+	ServicePostgreSQL = "postgresql"
+	ServiceClickHouse = "clickhouse"
+	ServiceWeaviate   = "weaviate"
+	ServiceThaiAdmin  = "thai_admin"
+	ServiceImageCache = "image_cache"
+	ServiceRedis      = "redis"
+)
+
+// ServiceRegistry tracks which dependencies are currently unavailable. It is fed by startup,
+// the health checks and failed calls, and read by every request so responses can say when they
+// were answered without a dependency (e.g. the PostgreSQL text search instead of Weaviate).
+type ServiceRegistry struct {
+	mu   sync.RWMutex
+	down map[string]string // service -> reason
+}
+
+// NewServiceRegistry creates a registry in which every service is available
+func NewServiceRegistry() *ServiceRegistry {
+	return &ServiceRegistry{down: make(map[string]string)}
+}
+
+// MarkDown records that a service is unavailable; the change is logged once
+func (r *ServiceRegistry) MarkDown(name, reason string) {
+	r.mu.Lock()
+	_, wasDown := r.down[name]
+	r.down[name] = reason
+	r.mu.Unlock()
+	if !wasDown {
+		log.Printf("⚠️ [services] %s unavailable, responses fall back without it: %s", name, reason)
+	}
+}
+
+// MarkUp records that a service answers again
+func (r *ServiceRegistry) MarkUp(name string) {
+	r.mu.Lock()
+	_, wasDown := r.down[name]
+	delete(r.down, name)
+	r.mu.Unlock()
+	if wasDown {
+		log.Printf("✅ [services] %s available again", name)
+	}
+}
+
+// Update applies the result of a health check run; slow services still count as available
+func (r *ServiceRegistry) Update(dependencies []models.DependencyHealth) {
+	for _, dep := range dependencies {
+		if dep.Status == models.DependencyStatusDown {
+			r.MarkDown(dep.Name, dep.Error)
+		} else {
+			r.MarkUp(dep.Name)
+		}
+	}
+}
+
+// Degraded returns the unavailable services in name order, or nil when all are available
+func (r *ServiceRegistry) Degraded() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.down) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(r.down))
+	for name := range r.down {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
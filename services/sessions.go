@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned for malformed, expired or wrongly signed tokens
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+	tokenIssuer      = "smlgoapi"
+)
+
+// sessionClaims is the JWT payload for both access and refresh tokens
+type sessionClaims struct {
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	TokenType string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// SessionService issues and validates JWT sessions for API users.
+// Access and refresh tokens are signed with separate secrets so one can never stand in for the other.
+type SessionService struct {
+	users         *UserService
+	secret        []byte
+	refreshSecret []byte
+	accessTTL     time.Duration
+	refreshTTL    time.Duration
+}
+
+// NewSessionService creates a session service from the JWT configuration
+func NewSessionService(cfg config.JWTConfig, users *UserService) (*SessionService, error) {
+	if cfg.Secret == "" || cfg.RefreshSecret == "" {
+		return nil, errors.New("jwt.secret and jwt.refresh_secret must be set")
+	}
+	if cfg.Secret == cfg.RefreshSecret {
+		return nil, errors.New("jwt.secret and jwt.refresh_secret must differ")
+	}
+
+	return &SessionService{
+		users:         users,
+		secret:        []byte(cfg.Secret),
+		refreshSecret: []byte(cfg.RefreshSecret),
+		accessTTL:     time.Duration(cfg.AccessTTLMinutes) * time.Minute,
+		refreshTTL:    time.Duration(cfg.RefreshTTLHours) * time.Hour,
+	}, nil
+}
+
+// Users returns the user store backing this service
+func (s *SessionService) Users() *UserService {
+	return s.users
+}
+
+// Login verifies credentials and issues a new token pair
+func (s *SessionService) Login(ctx context.Context, username, password string) (*models.TokenResponse, error) {
+	user, err := s.users.Authenticate(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user)
+}
+
+// Refresh exchanges a valid refresh token for a new token pair.
+// The user is reloaded so role changes and deactivation take effect on the next refresh.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
+	claims, err := s.parse(refreshToken, s.refreshSecret, tokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.users.GetActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user)
+}
+
+// ParseAccessToken validates an access token and returns its identity
+func (s *SessionService) ParseAccessToken(token string) (*models.SessionClaims, error) {
+	claims, err := s.parse(token, s.secret, tokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil || !models.IsValidRole(claims.Role) {
+		return nil, ErrInvalidToken
+	}
+
+	return &models.SessionClaims{
+		UserID:   userID,
+		Username: claims.Username,
+		Role:     claims.Role,
+	}, nil
+}
+
+func (s *SessionService) issueTokens(user *models.User) (*models.TokenResponse, error) {
+	accessToken, err := s.sign(user, tokenTypeAccess, s.secret, s.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := s.sign(user, tokenTypeRefresh, s.refreshSecret, s.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+		User:         *user,
+	}, nil
+}
+
+func (s *SessionService) sign(user *models.User, tokenType string, secret []byte, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := sessionClaims{
+		Username:  user.Username,
+		Role:      user.Role,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign %s token: %w", tokenType, err)
+	}
+	return signed, nil
+}
+
+func (s *SessionService) parse(token string, secret []byte, tokenType string) (*sessionClaims, error) {
+	var claims sessionClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(tokenIssuer), jwt.WithExpirationRequired())
+	if err != nil || claims.TokenType != tokenType {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+)
+
+// ErrShareNotFound is returned for unknown share tokens
+var ErrShareNotFound = errors.New("share link not found")
+
+// ErrShareExpired is returned for share links past their expiry
+var ErrShareExpired = errors.New("share link has expired")
+
+// ErrInvalidShare is returned when a share request cannot be stored as given
+var ErrInvalidShare = errors.New("invalid share request")
+
+// ShareService stores shared search results in the PostgreSQL shared_results table, so a link
+// opened on another device shows the same rows without running the search again
+type ShareService struct {
+	postgreSQLService *PostgreSQLService
+	cfg               config.ShareConfig
+}
+
+// NewShareService creates a new share service
+func NewShareService(postgreSQLService *PostgreSQLService, cfg config.ShareConfig) *ShareService {
+	return &ShareService{
+		postgreSQLService: postgreSQLService,
+		cfg:               cfg,
+	}
+}
+
+// EnsureSchema creates the shared_results table if it does not exist
+func (s *ShareService) EnsureSchema(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS shared_results (
+			token      TEXT PRIMARY KEY,
+			endpoint   TEXT NOT NULL DEFAULT '',
+			params     JSONB,
+			result     JSONB,
+			title      TEXT NOT NULL DEFAULT '',
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			expires_at TIMESTAMPTZ NOT NULL
+		)`
+
+	if _, err := s.postgreSQLService.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create shared_results table: %w", err)
+	}
+	return nil
+}
+
+// Create stores req under a new token. The lifetime is capped at max_ttl_minutes and the stored
+// JSON at max_bytes.
+func (s *ShareService) Create(ctx context.Context, req models.ShareRequest, createdBy string) (string, time.Time, error) {
+	if req.Params == nil && req.Result == nil {
+		return "", time.Time{}, fmt.Errorf("%w: params or result is required", ErrInvalidShare)
+	}
+	params, err := shareJSON(req.Params)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%w: params: %v", ErrInvalidShare, err)
+	}
+	result, err := shareJSON(req.Result)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%w: result: %v", ErrInvalidShare, err)
+	}
+	if size := len(params) + len(result); size > s.cfg.MaxBytes {
+		return "", time.Time{}, fmt.Errorf("%w: shared content is %d bytes, the limit is %d", ErrInvalidShare, size, s.cfg.MaxBytes)
+	}
+
+	ttl := req.TTLMinutes
+	if ttl <= 0 {
+		ttl = s.cfg.DefaultTTLMinutes
+	}
+	if ttl > s.cfg.MaxTTLMinutes {
+		ttl = s.cfg.MaxTTLMinutes
+	}
+	expiresAt := time.Now().Add(time.Duration(ttl) * time.Minute).UTC().Truncate(time.Second)
+
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate share token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	query := `
+		INSERT INTO shared_results (token, endpoint, params, result, title, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	if _, err := s.postgreSQLService.db.ExecContext(ctx, query,
+		token, req.Endpoint, jsonbParam(params), jsonbParam(result), req.Title, createdBy, expiresAt); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store shared result: %w", err)
+	}
+
+	// Expired links are removed as new ones are created; the table stays small without a job
+	if res, err := s.postgreSQLService.db.ExecContext(ctx, `DELETE FROM shared_results WHERE expires_at < now()`); err != nil {
+		log.Printf("⚠️ [share] Failed to remove expired links: %v", err)
+	} else if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("🧹 [share] Removed %d expired links", n)
+	}
+
+	return token, expiresAt, nil
+}
+
+// Get returns the content behind a token
+func (s *ShareService) Get(ctx context.Context, token string) (*models.SharedResult, error) {
+	query := `
+		SELECT token, endpoint, params, result, title, created_by, created_at, expires_at
+		FROM shared_results
+		WHERE token = $1`
+
+	var shared models.SharedResult
+	var params, result []byte
+	err := s.postgreSQLService.db.QueryRowContext(ctx, query, token).Scan(
+		&shared.Token, &shared.Endpoint, &params, &result, &shared.Title, &shared.CreatedBy, &shared.CreatedAt, &shared.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shared result: %w", err)
+	}
+	if time.Now().After(shared.ExpiresAt) {
+		return nil, ErrShareExpired
+	}
+
+	// The stored JSON is passed through without decoding it
+	if params != nil {
+		shared.Params = json.RawMessage(params)
+	}
+	if result != nil {
+		shared.Result = json.RawMessage(result)
+	}
+	return &shared, nil
+}
+
+// shareJSON encodes a shared value for a JSONB column; nil stays NULL
+func shareJSON(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// jsonbParam passes encoded JSON as text, which PostgreSQL casts to JSONB; nil becomes NULL
+func jsonbParam(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
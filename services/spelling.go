@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// spellingIndexMaxAge is how long the word list is used before it is reloaded from ic_inventory
+const spellingIndexMaxAge = time.Hour
+
+// maxSpellingCandidates caps the words compared with Levenshtein for one query word
+const maxSpellingCandidates = 200
+
+// SpellingIndex suggests corrections for search queries that match nothing. It holds the words
+// of product names and the product codes of ic_inventory with a trigram index over them; the
+// words sharing most trigrams with a query word are ranked by edit distance.
+type SpellingIndex struct {
+	pg *PostgreSQLService
+
+	mu       sync.RWMutex
+	words    []spellingWord
+	known    map[string]int   // word -> index in words
+	trigrams map[string][]int // trigram -> indexes of the words containing it
+	loadedAt time.Time
+	loading  bool
+}
+
+type spellingWord struct {
+	text  string
+	runes []rune
+	count int // products using the word; breaks ties between equally close words
+}
+
+// NewSpellingIndex returns an index that loads its words from PostgreSQL on first use
+func NewSpellingIndex(pg *PostgreSQLService) *SpellingIndex {
+	return &SpellingIndex{pg: pg}
+}
+
+// Suggest returns the query with its unknown words replaced by the closest product words, or ""
+// when every word is known or nothing is close enough. While the word list is being loaded no
+// suggestion is made, so a search never waits for it.
+func (s *SpellingIndex) Suggest(query string) string {
+	if s == nil || s.pg == nil {
+		return ""
+	}
+	s.refreshIfStale()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.words) == 0 {
+		return ""
+	}
+
+	words := strings.Fields(strings.ToLower(query))
+	corrected := false
+	for i, word := range words {
+		word = trimSpellingWord(word)
+		if _, ok := s.known[word]; ok || len([]rune(word)) < 3 {
+			continue
+		}
+		if closest := s.closestLocked(word); closest != "" {
+			words[i] = closest
+			corrected = true
+		}
+	}
+	if !corrected {
+		return ""
+	}
+	return strings.Join(words, " ")
+}
+
+// closestLocked returns the known word nearest to word within its edit distance limit
+func (s *SpellingIndex) closestLocked(word string) string {
+	runes := []rune(word)
+	limit := maxSpellingDistance(len(runes))
+
+	shared := make(map[int]int)
+	for _, trigram := range spellingTrigrams(runes) {
+		for _, i := range s.trigrams[trigram] {
+			shared[i]++
+		}
+	}
+	candidates := make([]int, 0, len(shared))
+	for i := range shared {
+		if diff := len(s.words[i].runes) - len(runes); diff <= limit && diff >= -limit {
+			candidates = append(candidates, i)
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		return shared[candidates[a]] > shared[candidates[b]]
+	})
+	if len(candidates) > maxSpellingCandidates {
+		candidates = candidates[:maxSpellingCandidates]
+	}
+
+	best, bestDistance := -1, limit+1
+	for _, i := range candidates {
+		distance := levenshtein(runes, s.words[i].runes, bestDistance+1)
+		if distance < bestDistance || (distance == bestDistance && best >= 0 && s.words[i].count > s.words[best].count) {
+			best, bestDistance = i, distance
+		}
+	}
+	if best < 0 || bestDistance > limit {
+		return ""
+	}
+	return s.words[best].text
+}
+
+// refreshIfStale starts loading the word list in the background when it is missing or old
+func (s *SpellingIndex) refreshIfStale() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loading || (len(s.words) > 0 && time.Since(s.loadedAt) < spellingIndexMaxAge) {
+		return
+	}
+	s.loading = true
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		err := s.load(ctx)
+
+		s.mu.Lock()
+		s.loading = false
+		if err != nil {
+			// Retry on a later search rather than immediately
+			s.loadedAt = time.Now()
+		}
+		s.mu.Unlock()
+		if err != nil {
+			log.Printf("⚠️ [SPELLING] Failed to load product words: %v", err)
+		}
+	}()
+}
+
+// load rebuilds the word list from the names and codes in ic_inventory
+func (s *SpellingIndex) load(ctx context.Context) error {
+	rows, err := s.pg.db.QueryContext(ctx, s.pg.fields.Expand(`
+		SELECT COALESCE(CAST({code} AS TEXT), ''), COALESCE(CAST({name} AS TEXT), '')
+		FROM ic_inventory`))
+	if err != nil {
+		return fmt.Errorf("failed to query product names: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var code, name string
+		if err := rows.Scan(&code, &name); err != nil {
+			return fmt.Errorf("failed to read product names: %w", err)
+		}
+		if code = trimSpellingWord(strings.ToLower(code)); code != "" {
+			counts[code]++
+		}
+		for _, word := range strings.Fields(strings.ToLower(name)) {
+			if word = trimSpellingWord(word); len([]rune(word)) >= 2 {
+				counts[word]++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read product names: %w", err)
+	}
+
+	words := make([]spellingWord, 0, len(counts))
+	known := make(map[string]int, len(counts))
+	trigrams := make(map[string][]int)
+	for text, count := range counts {
+		i := len(words)
+		words = append(words, spellingWord{text: text, runes: []rune(text), count: count})
+		known[text] = i
+		for _, trigram := range spellingTrigrams(words[i].runes) {
+			trigrams[trigram] = append(trigrams[trigram], i)
+		}
+	}
+
+	s.mu.Lock()
+	s.words, s.known, s.trigrams = words, known, trigrams
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	log.Printf("🔤 [SPELLING] Loaded %d product words", len(words))
+	return nil
+}
+
+// trimSpellingWord strips punctuation around a word; Thai vowel and tone marks are kept
+func trimSpellingWord(word string) string {
+	return strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	})
+}
+
+// spellingTrigrams returns the distinct trigrams of a word padded with a boundary marker
+func spellingTrigrams(runes []rune) []string {
+	padded := make([]rune, 0, len(runes)+2)
+	padded = append(padded, '\x00')
+	padded = append(padded, runes...)
+	padded = append(padded, '\x00')
+
+	seen := make(map[string]bool, len(padded))
+	trigrams := make([]string, 0, len(padded))
+	for i := 0; i+3 <= len(padded); i++ {
+		trigram := string(padded[i : i+3])
+		if !seen[trigram] {
+			seen[trigram] = true
+			trigrams = append(trigrams, trigram)
+		}
+	}
+	return trigrams
+}
+
+// maxSpellingDistance is the largest edit distance accepted for a word of n runes
+func maxSpellingDistance(n int) int {
+	switch {
+	case n <= 4:
+		return 1
+	case n <= 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// levenshtein returns the edit distance between a and b, or any value >= limit once the
+// distance is known to reach limit
+func levenshtein(a, b []rune, limit int) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin >= limit {
+			return limit
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
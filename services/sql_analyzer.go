@@ -0,0 +1,517 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// sqlToken kinds
+const (
+	tokenWord        = iota // identifier or keyword (quoted identifiers included)
+	tokenNumber             // numeric literal
+	tokenLiteral            // string literal
+	tokenPlaceholder        // ?, $1
+	tokenPunct              // single punctuation character
+)
+
+type sqlToken struct {
+	kind       int
+	text       string
+	quoted     bool // identifier was quoted, so it is never a keyword
+	start, end int  // rune offsets of the token in the query, quotes included
+}
+
+func (t sqlToken) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && !t.quoted && strings.EqualFold(t.text, keyword)
+}
+
+func (t sqlToken) isPunct(p string) bool {
+	return t.kind == tokenPunct && t.text == p
+}
+
+// sqlStatement is what the policy engine needs to know about one statement
+type sqlStatement struct {
+	Type         string   // leading statement keyword, upper case (the main statement for WITH queries)
+	Tables       []string // referenced tables, lower case, optionally schema-qualified; table functions end in "()"
+	Limit        int      // top-level LIMIT value when HasLimit
+	HasLimit     bool
+	LimitUnknown bool // LIMIT present but not a literal (placeholder, ALL or expression)
+	Incomplete   bool // a FROM list holds something that is not a table or subquery, so Tables may miss some
+	CTEs         []sqlCTE
+	Into         bool // SELECT ... INTO, which creates a table (PostgreSQL) or writes a file (ClickHouse)
+}
+
+// sqlCTE is the statement of one WITH query body: WITH d AS (DELETE FROM t RETURNING *) ...
+type sqlCTE struct {
+	Type  string // leading statement keyword, upper case
+	Table string // table written by INSERT, UPDATE, DELETE or MERGE, as in Tables
+}
+
+// modifiesData reports whether a statement changes data other than by its Type: through a WITH
+// query that inserts, updates or deletes, or with SELECT ... INTO
+func (s sqlStatement) modifiesData() bool {
+	for _, cte := range s.CTEs {
+		if dataModifyingKeywords[cte.Type] {
+			return true
+		}
+	}
+	return s.Into
+}
+
+// dataModifyingKeywords start statements that change rows, also inside a WITH query
+var dataModifyingKeywords = map[string]bool{"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true}
+
+// mainStatementKeywords can follow a WITH clause
+var mainStatementKeywords = map[string]bool{"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true}
+
+// tableContextKeywords are followed by a table reference
+var tableContextKeywords = map[string]bool{"FROM": true, "JOIN": true, "INTO": true, "UPDATE": true, "TABLE": true, "VIEW": true, "DICTIONARY": true}
+
+// tableModifierKeywords may appear between a table context keyword and the table name
+var tableModifierKeywords = map[string]bool{"ONLY": true, "LATERAL": true, "IF": true, "NOT": true, "EXISTS": true, "TEMPORARY": true, "TEMP": true}
+
+// nonAliasKeywords end a table reference instead of naming its alias
+var nonAliasKeywords = map[string]bool{
+	"WHERE": true, "JOIN": true, "ON": true, "USING": true, "GROUP": true, "ORDER": true, "LIMIT": true, "OFFSET": true,
+	"LEFT": true, "RIGHT": true, "INNER": true, "FULL": true, "OUTER": true, "CROSS": true, "NATURAL": true,
+	"UNION": true, "EXCEPT": true, "INTERSECT": true, "HAVING": true, "WINDOW": true, "FETCH": true, "FOR": true,
+	"SET": true, "VALUES": true, "SELECT": true, "RETURNING": true, "DEFAULT": true, "FINAL": true, "SAMPLE": true,
+	"PREWHERE": true, "ARRAY": true, "GLOBAL": true, "ANY": true, "ALL": true, "SETTINGS": true, "FORMAT": true,
+	"ASOF": true, "SEMI": true, "ANTI": true, "PASTE": true, "AS": true, "FROM": true, "INTO": true,
+	"QUALIFY": true, "TABLESAMPLE": true, "OVERRIDING": true,
+}
+
+// fromEndKeywords end a FROM list, so later commas no longer separate table references
+var fromEndKeywords = map[string]bool{
+	"WHERE": true, "GROUP": true, "ORDER": true, "LIMIT": true, "OFFSET": true, "HAVING": true, "WINDOW": true,
+	"UNION": true, "EXCEPT": true, "INTERSECT": true, "RETURNING": true, "FETCH": true, "FOR": true,
+	"PREWHERE": true, "QUALIFY": true, "SETTINGS": true, "FORMAT": true, "SET": true, "VALUES": true,
+	"SELECT": true, "INTO": true,
+}
+
+// softClauseKeywords start a clause in one dialect but may alias a table in the other:
+// FROM t SETTINGS max_threads = 1 in ClickHouse, FROM t settings, u in PostgreSQL
+var softClauseKeywords = map[string]bool{"SET": true, "VALUES": true, "SETTINGS": true, "FORMAT": true, "PREWHERE": true, "QUALIFY": true}
+
+// operatorKeywords are followed by an operand, so a keyword after them is a column name
+var operatorKeywords = map[string]bool{
+	"ON": true, "AND": true, "OR": true, "NOT": true, "IS": true, "IN": true, "LIKE": true, "ILIKE": true,
+	"BETWEEN": true, "SIMILAR": true, "CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "ANY": true,
+	"ALL": true, "SOME": true, "DISTINCT": true, "AS": true, "USING": true, "JOIN": true, "FROM": true,
+	"EXISTS": true, "INTERVAL": true,
+}
+
+// subqueryKeywords start a parenthesised query in a FROM list; anything else in the parentheses is
+// a parenthesised join, e.g. FROM (a JOIN b ON ...)
+var subqueryKeywords = map[string]bool{"SELECT": true, "WITH": true, "VALUES": true, "TABLE": true}
+
+// nonFunctionKeywords open a parenthesis that is not a function call
+var nonFunctionKeywords = map[string]bool{
+	"IN": true, "EXISTS": true, "FROM": true, "JOIN": true, "AS": true, "ANY": true, "ALL": true, "SOME": true,
+	"LATERAL": true, "VALUES": true, "ON": true, "WHERE": true, "AND": true, "OR": true, "NOT": true, "SELECT": true,
+	"UNION": true, "USING": true, "MATERIALIZED": true,
+}
+
+// analyzeSQL splits a query for database (ServicePostgreSQL or ServiceClickHouse) into statements
+// and extracts their type, tables and LIMIT. It is a tokenizer-level analysis covering PostgreSQL
+// and ClickHouse syntax, not a full parser.
+func analyzeSQL(database, query string) []sqlStatement {
+	var statements []sqlStatement
+	var current []sqlToken
+	for _, token := range tokenizeSQL(database, query) {
+		if token.isPunct(";") {
+			if len(current) > 0 {
+				statements = append(statements, analyzeStatement(current))
+			}
+			current = nil
+			continue
+		}
+		current = append(current, token)
+	}
+	if len(current) > 0 {
+		statements = append(statements, analyzeStatement(current))
+	}
+	return statements
+}
+
+func analyzeStatement(tokens []sqlToken) sqlStatement {
+	stmt := sqlStatement{}
+	if tokens[0].kind == tokenWord {
+		stmt.Type = strings.ToUpper(tokens[0].text)
+	}
+
+	cteNames := make(map[string]bool)
+	isWith := stmt.Type == "WITH"
+
+	depth := 0
+	var functionParens []bool
+	// fromList marks the depths at which a FROM list is being read: after FROM or JOIN, until a
+	// clause keyword or the closing parenthesis. Commas there separate table references, also
+	// after a join condition: FROM a JOIN b ON ..., c
+	fromList := make(map[int]bool)
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		switch {
+		case token.isPunct(",") && fromList[depth]:
+			i = readTableList(tokens, i+1, true, true, &stmt)
+			continue
+		case token.isPunct("("):
+			// WITH name AS [NOT MATERIALIZED] (statement), at any depth
+			if i > 0 && (tokens[i-1].isKeyword("AS") || tokens[i-1].isKeyword("MATERIALIZED")) &&
+				i+1 < len(tokens) && tokens[i+1].kind == tokenWord && !tokens[i+1].quoted {
+				stmt.CTEs = append(stmt.CTEs, readCTE(tokens, i+1))
+			}
+			isFunction := i > 0 && tokens[i-1].kind == tokenWord &&
+				(tokens[i-1].quoted || !nonFunctionKeywords[strings.ToUpper(tokens[i-1].text)])
+			functionParens = append(functionParens, isFunction)
+			depth++
+			continue
+		case token.isPunct(")"):
+			if depth > 0 {
+				delete(fromList, depth)
+				depth--
+				functionParens = functionParens[:len(functionParens)-1]
+			}
+			continue
+		case token.kind != tokenWord || token.quoted:
+			continue
+		}
+
+		keyword := strings.ToUpper(token.text)
+		if i > 0 && tokens[i-1].isPunct(".") {
+			continue // a qualified name such as b.order is never a keyword
+		}
+
+		// WITH name [(columns)] AS (...), ... main statement
+		if isWith && depth == 0 {
+			if mainStatementKeywords[keyword] {
+				stmt.Type = keyword
+				isWith = false
+			} else if i+1 < len(tokens) && (tokens[i+1].isKeyword("AS") || tokens[i+1].isPunct("(")) && keyword != "AS" && keyword != "WITH" {
+				cteNames[strings.ToLower(token.text)] = true
+			}
+		}
+
+		// FROM inside a function call, e.g. EXTRACT(YEAR FROM col), is not a table reference,
+		// nor is the one of a IS [NOT] DISTINCT FROM b
+		inFunction := len(functionParens) > 0 && functionParens[len(functionParens)-1]
+		distinctFrom := keyword == "FROM" && i > 0 && tokens[i-1].isKeyword("DISTINCT")
+		// The UPDATE of FOR [NO KEY] UPDATE or of MERGE ... THEN UPDATE SET names no table either
+		lockOrMerge := keyword == "UPDATE" && ((i > 0 && (tokens[i-1].isKeyword("FOR") || tokens[i-1].isKeyword("KEY"))) ||
+			(i+1 < len(tokens) && tokens[i+1].isKeyword("SET")))
+		if keyword == "INTO" && depth == 0 && stmt.Type == "SELECT" {
+			stmt.Into = true
+		}
+		if fromEndKeywords[keyword] && i > 0 && endsOperand(tokens[i-1]) {
+			fromList[depth] = false
+		}
+
+		switch {
+		case tableContextKeywords[keyword] && !inFunction && !distinctFrom && !lockOrMerge:
+			fromClause := keyword == "FROM" || keyword == "JOIN"
+			// MERGE INTO t USING s reads s like a FROM list
+			fromList[depth] = fromClause || (keyword == "INTO" && i > 0 && tokens[i-1].isKeyword("MERGE"))
+			i = readTableList(tokens, i+1, keyword == "FROM", fromClause, &stmt)
+		case keyword == "USING" && fromList[depth] && i+1 < len(tokens) && !tokens[i+1].isPunct("("):
+			// DELETE FROM t USING a, b; JOIN ... USING (columns) names no table
+			i = readTableList(tokens, i+1, true, true, &stmt)
+		case keyword == "TRUNCATE" && i == 0 && i+1 < len(tokens) && !tokens[i+1].isKeyword("TABLE"):
+			i = readTableList(tokens, i+1, true, false, &stmt)
+		case keyword == "LIMIT" && depth == 0:
+			readLimit(tokens, i+1, &stmt)
+		case keyword == "FETCH" && depth == 0 && i+2 < len(tokens) && tokens[i+2].kind == tokenNumber:
+			setLimit(tokens[i+2].text, &stmt)
+		}
+	}
+
+	// CTE references are not real tables
+	tables := stmt.Tables[:0]
+	for _, table := range stmt.Tables {
+		if !cteNames[table] {
+			tables = append(tables, table)
+		}
+	}
+	stmt.Tables = tables
+
+	return stmt
+}
+
+// readTableList reads one table reference (or a comma-separated list when list is true) starting at i
+// and returns the index of the last consumed token. Table functions are only recognised in FROM/JOIN,
+// elsewhere a parenthesis after the name is a column list, e.g. INSERT INTO t (a, b). In FROM/JOIN
+// a parenthesised subquery or join is read with its alias, and anything else that is not a table
+// marks stmt Incomplete.
+func readTableList(tokens []sqlToken, i int, list, fromClause bool, stmt *sqlStatement) int {
+	for {
+		for i < len(tokens) && tokens[i].kind == tokenWord && !tokens[i].quoted && tableModifierKeywords[strings.ToUpper(tokens[i].text)] {
+			i++
+		}
+		switch {
+		case fromClause && i < len(tokens) && tokens[i].isPunct("("):
+			i = readParenthesisedFrom(tokens, i, stmt)
+		case i >= len(tokens) || tokens[i].kind != tokenWord:
+			if fromClause {
+				stmt.Incomplete = true
+			}
+			return i - 1 // something that is not a table
+		default:
+			name := strings.ToLower(tokens[i].text)
+			for i+2 < len(tokens) && tokens[i+1].isPunct(".") && tokens[i+2].kind == tokenWord {
+				name += "." + strings.ToLower(tokens[i+2].text)
+				i += 2
+			}
+
+			// Table function, e.g. unnest(...), numbers(10), url(...)
+			if fromClause && i+1 < len(tokens) && tokens[i+1].isPunct("(") {
+				name += "()"
+				i = skipParens(tokens, i+1)
+			}
+			stmt.Tables = append(stmt.Tables, name)
+		}
+
+		// Optional alias, in FROM/JOIN with column names: AS s (a, b)
+		if i+1 < len(tokens) && tokens[i+1].isKeyword("AS") {
+			i++
+		}
+		if i+1 < len(tokens) && tokens[i+1].kind == tokenWord && (tokens[i+1].quoted || isTableAlias(tokens, i+1, fromClause)) {
+			i++
+			if fromClause && i+1 < len(tokens) && tokens[i+1].isPunct("(") {
+				i = skipParens(tokens, i+1)
+			}
+		}
+
+		if !list || i+1 >= len(tokens) || !tokens[i+1].isPunct(",") {
+			return i
+		}
+		i += 2
+	}
+}
+
+// isTableAlias tells whether the unquoted word at i is the alias of the table reference before it.
+// In FROM/JOIN a soft clause keyword is an alias when no clause follows it: FROM a settings, b
+func isTableAlias(tokens []sqlToken, i int, fromClause bool) bool {
+	keyword := strings.ToUpper(tokens[i].text)
+	if !nonAliasKeywords[keyword] {
+		return true
+	}
+	if !fromClause || !softClauseKeywords[keyword] {
+		return false
+	}
+	next := i + 1
+	return next >= len(tokens) || tokens[next].kind != tokenWord ||
+		(!tokens[next].quoted && nonAliasKeywords[strings.ToUpper(tokens[next].text)])
+}
+
+// endsOperand tells whether a token ends an expression or table reference, so a clause keyword
+// after it starts the clause instead of naming a column: ON a = b WHERE, but ON where = 1
+func endsOperand(t sqlToken) bool {
+	switch t.kind {
+	case tokenNumber, tokenLiteral, tokenPlaceholder:
+		return true
+	case tokenPunct:
+		return t.text == ")"
+	}
+	return t.quoted || !operatorKeywords[strings.ToUpper(t.text)]
+}
+
+// readParenthesisedFrom reads the tables of the subquery or join in the parenthesis at i and
+// returns the index of the closing one
+func readParenthesisedFrom(tokens []sqlToken, i int, stmt *sqlStatement) int {
+	end := skipParens(tokens, i)
+	inner := tokens[i+1 : end]
+	if len(inner) == 0 || !tokens[end].isPunct(")") {
+		stmt.Incomplete = true
+		return end
+	}
+	if inner[0].quoted || !subqueryKeywords[strings.ToUpper(inner[0].text)] {
+		// (a JOIN b ON ...) reads like FROM a JOIN b ON ...
+		inner = append([]sqlToken{{kind: tokenWord, text: "FROM"}}, inner...)
+	}
+	sub := analyzeStatement(inner)
+	stmt.Tables = append(stmt.Tables, sub.Tables...)
+	stmt.Incomplete = stmt.Incomplete || sub.Incomplete
+	stmt.CTEs = append(stmt.CTEs, sub.CTEs...)
+	stmt.Into = stmt.Into || sub.Into
+	return end
+}
+
+// readCTE reads the statement of the WITH query body starting at i and the table it writes
+func readCTE(tokens []sqlToken, i int) sqlCTE {
+	cte := sqlCTE{Type: strings.ToUpper(tokens[i].text)}
+	if !dataModifyingKeywords[cte.Type] {
+		return cte
+	}
+	target := i + 1
+	if target < len(tokens) && (tokens[target].isKeyword("FROM") || tokens[target].isKeyword("INTO")) {
+		target++
+	}
+	var written sqlStatement
+	readTableList(tokens, target, false, false, &written)
+	if len(written.Tables) > 0 {
+		cte.Table = written.Tables[0]
+	}
+	return cte
+}
+
+// skipParens returns the index of the parenthesis closing the one at i
+func skipParens(tokens []sqlToken, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		if tokens[i].isPunct("(") {
+			depth++
+		} else if tokens[i].isPunct(")") {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+// readLimit handles "LIMIT n", "LIMIT offset, n" (ClickHouse) and ignores "LIMIT n BY" (per-group limit)
+func readLimit(tokens []sqlToken, i int, stmt *sqlStatement) {
+	if i >= len(tokens) || tokens[i].kind != tokenNumber {
+		stmt.LimitUnknown = true
+		return
+	}
+	value := tokens[i].text
+	if i+2 < len(tokens) && tokens[i+1].isPunct(",") {
+		if tokens[i+2].kind != tokenNumber {
+			stmt.LimitUnknown = true
+			return
+		}
+		value = tokens[i+2].text
+		i += 2
+	}
+	if i+1 < len(tokens) && tokens[i+1].isKeyword("BY") {
+		return
+	}
+	setLimit(value, stmt)
+}
+
+func setLimit(value string, stmt *sqlStatement) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		stmt.LimitUnknown = true
+		return
+	}
+	stmt.Limit = n
+	stmt.HasLimit = true
+}
+
+// tokenizeSQL splits SQL into tokens, dropping comments and whitespace.
+// It understands single-quoted strings with doubled-quote escapes, "..." and `...` identifiers and
+// $tag$...$tag$ strings. A backslash escapes the next character in ClickHouse strings and in
+// PostgreSQL E'...' strings only: with standard_conforming_strings, '\' is a whole string there.
+func tokenizeSQL(database, query string) []sqlToken {
+	runes := []rune(query)
+	var tokens []sqlToken
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		begin, count := i, len(tokens)
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case r == '\'' || ((r == 'E' || r == 'e') && i+1 < len(runes) && runes[i+1] == '\''):
+			backslashEscapes := database == ServiceClickHouse || r != '\''
+			start := i
+			if r != '\'' {
+				i++
+			}
+			i++
+			for i < len(runes) {
+				if backslashEscapes && runes[i] == '\\' {
+					i += 2
+					continue
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			tokens = append(tokens, sqlToken{kind: tokenLiteral, text: string(runes[start:min(i, len(runes))])})
+		case r == '"' || r == '`':
+			quote := r
+			start := i + 1
+			i++
+			for i < len(runes) && runes[i] != quote {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenWord, text: string(runes[start:min(i, len(runes))]), quoted: true})
+			i++
+		case r == '$' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			start := i
+			i++
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenPlaceholder, text: string(runes[start:i])})
+		case r == '$':
+			// Dollar-quoted string: $$...$$ or $tag$...$tag$
+			end := i + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			if end >= len(runes) || runes[end] != '$' {
+				tokens = append(tokens, sqlToken{kind: tokenPunct, text: "$"})
+				i++
+				break
+			}
+			tag := runes[i : end+1]
+			i = indexRunes(runes, end+1, tag) + len(tag)
+			tokens = append(tokens, sqlToken{kind: tokenLiteral, text: string(tag)})
+		case r == '?':
+			tokens = append(tokens, sqlToken{kind: tokenPlaceholder, text: "?"})
+			i++
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenWord, text: string(runes[start:i])})
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenNumber, text: string(runes[start:i])})
+		default:
+			tokens = append(tokens, sqlToken{kind: tokenPunct, text: string(r)})
+			i++
+		}
+		if len(tokens) > count {
+			tokens[count].start, tokens[count].end = begin, min(i, len(runes))
+		}
+	}
+
+	return tokens
+}
+
+// indexRunes returns the index of needle in haystack at or after start, or len(haystack) if absent
+func indexRunes(haystack []rune, start int, needle []rune) int {
+	for i := start; i+len(needle) <= len(haystack); i++ {
+		if string(haystack[i:i+len(needle)]) == string(needle) {
+			return i
+		}
+	}
+	return len(haystack)
+}
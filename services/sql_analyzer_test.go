@@ -0,0 +1,184 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"smlgoapi/config"
+)
+
+func TestAnalyzeSQLTables(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		tables     []string
+		incomplete bool
+	}{
+		{"single table", "SELECT * FROM ic_inventory WHERE code = $1", []string{"ic_inventory"}, false},
+		{"comma list", "SELECT * FROM a, public.b AS x", []string{"a", "public.b"}, false},
+		{"subquery then table", "SELECT * FROM (SELECT 1) s, secret", []string{"secret"}, false},
+		{"subquery with tables", "SELECT * FROM (SELECT * FROM a) AS s (x), b", []string{"a", "b"}, false},
+		{"join subquery then comma", "SELECT * FROM a JOIN (SELECT 1) s ON true, secret", []string{"a", "secret"}, false},
+		{"join condition then comma", "SELECT * FROM a JOIN b ON a.id = b.id, secret", []string{"a", "b", "secret"}, false},
+		{"parenthesised join", "SELECT * FROM (a JOIN b ON true), secret", []string{"a", "b", "secret"}, false},
+		{"lateral subquery", "SELECT * FROM a, LATERAL (SELECT * FROM b) l, secret", []string{"a", "b", "secret"}, false},
+		{"select into", "SELECT * INTO x FROM secret", []string{"x", "secret"}, false},
+		{"qualified keyword column", "SELECT * FROM a JOIN b ON a.x = b.order, secret", []string{"a", "b", "secret"}, false},
+		{"keyword column in condition", "SELECT * FROM a JOIN b ON settings = 1, secret", []string{"a", "b", "secret"}, false},
+		{"soft keyword alias", "SELECT * FROM a settings, secret", []string{"a", "secret"}, false},
+		{"is distinct from", "SELECT a IS DISTINCT FROM b, c FROM t", []string{"t"}, false},
+		{"delete using", "DELETE FROM a USING b, secret WHERE a.id = b.id", []string{"a", "b", "secret"}, false},
+		{"merge using", "MERGE INTO t USING s, secret ON t.id = s.id WHEN MATCHED THEN UPDATE SET a = 1, b = 2", []string{"t", "s", "secret"}, false},
+		{"row locks", "SELECT * FROM t FOR UPDATE OF t", []string{"t"}, false},
+		{"group by list", "SELECT a, b FROM t GROUP BY a, b ORDER BY a, b", []string{"t"}, false},
+		{"clickhouse settings", "SELECT * FROM t SETTINGS max_threads = 1, max_memory_usage = 1000", []string{"t"}, false},
+		{"extract from", "SELECT EXTRACT(YEAR FROM created_at), b FROM t", []string{"t"}, false},
+		{"table function", "SELECT * FROM numbers(10)", []string{"numbers()"}, false},
+		{"cte reference", "WITH x AS (SELECT * FROM a) SELECT * FROM x, b", []string{"a", "b"}, false},
+		{"placeholder table", "SELECT * FROM a, $1", []string{"a"}, true},
+		{"from at end", "SELECT * FROM", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements := analyzeSQL(ServicePostgreSQL, tt.query)
+			if len(statements) != 1 {
+				t.Fatalf("analyzeSQL(%q) returned %d statements, want 1", tt.query, len(statements))
+			}
+			stmt := statements[0]
+			if len(stmt.Tables) == 0 {
+				stmt.Tables = nil
+			}
+			if !reflect.DeepEqual(stmt.Tables, tt.tables) {
+				t.Errorf("tables of %q = %v, want %v", tt.query, stmt.Tables, tt.tables)
+			}
+			if stmt.Incomplete != tt.incomplete {
+				t.Errorf("incomplete of %q = %t, want %t", tt.query, stmt.Incomplete, tt.incomplete)
+			}
+		})
+	}
+}
+
+func TestSQLPolicyCheckTables(t *testing.T) {
+	policy := NewSQLPolicyService(config.SQLPolicyConfig{
+		Enabled:       true,
+		AllowedTables: []string{"public.*"},
+		DeniedTables:  []string{"secret"},
+	})
+	tests := []struct {
+		query   string
+		allowed bool
+	}{
+		{"SELECT * FROM ic_inventory", true},
+		{"SELECT * FROM (SELECT 1) s, secret", false},
+		{"SELECT * FROM a JOIN (SELECT 1) s ON true, secret", false},
+		{"SELECT * INTO x FROM secret", false},
+		{"SELECT * FROM a, $1", false},
+	}
+	for _, tt := range tests {
+		err := policy.Check(ServicePostgreSQL, tt.query, "public")
+		if (err == nil) != tt.allowed {
+			t.Errorf("Check(%q) = %v, want allowed %t", tt.query, err, tt.allowed)
+		}
+	}
+}
+
+func TestAnalyzeSQLModifiesData(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		typ      string
+		ctes     []sqlCTE
+		modifies bool
+	}{
+		{"plain select", "SELECT * FROM a", "SELECT", nil, false},
+		{"select cte", "WITH x AS (SELECT * FROM a) SELECT * FROM x", "SELECT", []sqlCTE{{Type: "SELECT"}}, false},
+		{"delete cte", "WITH d AS (DELETE FROM ic_inventory RETURNING *) SELECT count(*) FROM d", "SELECT",
+			[]sqlCTE{{Type: "DELETE", Table: "ic_inventory"}}, true},
+		{"update cte", "WITH u AS MATERIALIZED (UPDATE ONLY public.t SET a = 1 RETURNING *) SELECT * FROM u", "SELECT",
+			[]sqlCTE{{Type: "UPDATE", Table: "public.t"}}, true},
+		{"insert cte in subquery", "SELECT * FROM (WITH i AS (INSERT INTO t VALUES (1) RETURNING *) SELECT * FROM i) s", "SELECT",
+			[]sqlCTE{{Type: "INSERT", Table: "t"}}, true},
+		{"select into", "SELECT * INTO x FROM secret", "SELECT", nil, true},
+		{"insert select", "INSERT INTO t SELECT * FROM u", "INSERT", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := analyzeSQL(ServicePostgreSQL, tt.query)[0]
+			if stmt.Type != tt.typ {
+				t.Errorf("type of %q = %s, want %s", tt.query, stmt.Type, tt.typ)
+			}
+			if !reflect.DeepEqual(stmt.CTEs, tt.ctes) {
+				t.Errorf("WITH queries of %q = %v, want %v", tt.query, stmt.CTEs, tt.ctes)
+			}
+			if stmt.modifiesData() != tt.modifies {
+				t.Errorf("modifiesData of %q = %t, want %t", tt.query, stmt.modifiesData(), tt.modifies)
+			}
+		})
+	}
+}
+
+func TestSQLPolicyCheckStatements(t *testing.T) {
+	policy := NewSQLPolicyService(config.SQLPolicyConfig{Enabled: true, DeniedStatements: []string{"DELETE", "DROP"}})
+	tests := []struct {
+		query   string
+		allowed bool
+	}{
+		{"SELECT * FROM ic_inventory", true},
+		{"DELETE FROM ic_inventory", false},
+		{"WITH d AS (DELETE FROM ic_inventory RETURNING *) SELECT count(*) FROM d", false},
+		{"WITH u AS (UPDATE ic_inventory SET a = 1 RETURNING *) SELECT * FROM u", true},
+	}
+	for _, tt := range tests {
+		err := policy.Check(ServicePostgreSQL, tt.query, "public")
+		if (err == nil) != tt.allowed {
+			t.Errorf("Check(%q) = %v, want allowed %t", tt.query, err, tt.allowed)
+		}
+	}
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	tests := []struct {
+		query   string
+		allowed bool
+	}{
+		{"SELECT * FROM ic_inventory LIMIT 10", true},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", true},
+		{"WITH d AS (DELETE FROM ic_inventory RETURNING *) SELECT count(*) FROM d", false},
+		{"SELECT * INTO copy FROM ic_inventory", false},
+	}
+	for _, tt := range tests {
+		err := CheckReadOnly(ServicePostgreSQL, tt.query)
+		if (err == nil) != tt.allowed {
+			t.Errorf("CheckReadOnly(%q) = %v, want allowed %t", tt.query, err, tt.allowed)
+		}
+	}
+}
+
+func TestSQLPolicyCheckBackslashes(t *testing.T) {
+	policy := NewSQLPolicyService(config.SQLPolicyConfig{
+		Enabled:          true,
+		DeniedStatements: []string{"DROP"},
+		DeniedTables:     []string{"secret"},
+	})
+	tests := []struct {
+		database string
+		query    string
+		allowed  bool
+	}{
+		// A backslash does not escape the quote under standard_conforming_strings
+		{ServicePostgreSQL, `SELECT '\' FROM secret -- '`, false},
+		{ServicePostgreSQL, `SELECT '\', * FROM secret; DROP TABLE x; -- '`, false},
+		{ServicePostgreSQL, `SELECT '\' FROM ic_inventory`, true},
+		// It does in E'...' strings and in ClickHouse
+		{ServicePostgreSQL, `SELECT E'\' FROM secret -- '`, true},
+		{ServicePostgreSQL, `SELECT e'\'', * FROM secret`, false},
+		{ServiceClickHouse, `SELECT '\' FROM secret -- '`, true},
+		{ServiceClickHouse, `SELECT '\'', * FROM secret`, false},
+	}
+	for _, tt := range tests {
+		err := policy.Check(tt.database, tt.query, "public")
+		if (err == nil) != tt.allowed {
+			t.Errorf("Check(%s, %q) = %v, want allowed %t", tt.database, tt.query, err, tt.allowed)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// trimStatement strips the trailing semicolons a statement cannot be wrapped with
+func trimStatement(query string) string {
+	return strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+}
+
+// Explain returns the plan ClickHouse would run query with, including the indexes it would use,
+// one line per step. The query itself is not run.
+func (s *ClickHouseService) Explain(ctx context.Context, query string, params ...interface{}) ([]string, error) {
+	rows, err := s.db.QueryContext(tagContext(ctx), "EXPLAIN indexes = 1 "+trimStatement(query), params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+	defer rows.Close()
+	return scanPlan(rows)
+}
+
+// Explain returns the plan PostgreSQL would run query with, with its estimated costs. With analyze
+// the query is run in a read-only transaction that is rolled back, and the plan reports the actual
+// times and rows of each step.
+func (s *PostgreSQLService) Explain(ctx context.Context, query string, analyze bool, params ...interface{}) ([]string, error) {
+	if !analyze {
+		rows, err := s.db.QueryContext(ctx, "EXPLAIN "+trimStatement(query), params...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to explain query: %w", err)
+		}
+		defer rows.Close()
+		return scanPlan(rows)
+	}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "EXPLAIN (ANALYZE, BUFFERS) "+trimStatement(query), params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+	defer rows.Close()
+	return scanPlan(rows)
+}
+
+// scanPlan reads the one text column EXPLAIN returns per line of the plan
+func scanPlan(rows *sql.Rows) ([]string, error) {
+	plan := []string{}
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("failed to read query plan: %w", err)
+		}
+		plan = append(plan, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read query plan: %w", err)
+	}
+	return plan, nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"smlgoapi/config"
+)
+
+// SQLPolicyViolation is returned when a query is rejected by the SQL policy
+type SQLPolicyViolation struct {
+	Reason string
+}
+
+func (v *SQLPolicyViolation) Error() string {
+	return "SQL policy violation: " + v.Reason
+}
+
+// SQLPolicyService validates raw SQL against the configured policy and reloads the policy
+// whenever smlgoapi.json changes
+type SQLPolicyService struct {
+	mu      sync.RWMutex
+	policy  config.SQLPolicyConfig
+	denied  map[string]bool
+	modTime time.Time
+}
+
+// NewSQLPolicyService creates a policy service from the initial configuration
+func NewSQLPolicyService(policy config.SQLPolicyConfig) *SQLPolicyService {
+	s := &SQLPolicyService{}
+	s.setPolicy(policy)
+	if path := config.FindConfigFile(); path != "" {
+		if info, err := os.Stat(path); err == nil {
+			s.modTime = info.ModTime()
+		}
+	}
+	return s
+}
+
+// Policy returns the active policy
+func (s *SQLPolicyService) Policy() config.SQLPolicyConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.policy
+}
+
+// Check returns a *SQLPolicyViolation if query, run on database (ServicePostgreSQL or
+// ServiceClickHouse), is not allowed. Unqualified table names are resolved against defaultSchema
+// ("public" for PostgreSQL, the configured database for ClickHouse).
+func (s *SQLPolicyService) Check(database, query, defaultSchema string) error {
+	s.mu.RLock()
+	policy, denied := s.policy, s.denied
+	s.mu.RUnlock()
+
+	if !policy.Enabled {
+		return nil
+	}
+
+	statements := analyzeSQL(database, query)
+	if len(statements) == 0 {
+		return &SQLPolicyViolation{Reason: "empty query"}
+	}
+	if len(statements) > 1 && !policy.AllowMultipleStatements {
+		return &SQLPolicyViolation{Reason: fmt.Sprintf("multiple statements are not allowed (found %d)", len(statements))}
+	}
+
+	for _, stmt := range statements {
+		if denied[stmt.Type] {
+			return &SQLPolicyViolation{Reason: stmt.Type + " statements are not allowed"}
+		}
+		for _, cte := range stmt.CTEs {
+			if denied[cte.Type] {
+				return &SQLPolicyViolation{Reason: cte.Type + " statements are not allowed (found in a WITH query)"}
+			}
+		}
+
+		// A table the analyzer could not read may be any table, so table rules fail closed
+		if stmt.Incomplete && (len(policy.AllowedTables) > 0 || len(policy.DeniedTables) > 0) {
+			return &SQLPolicyViolation{Reason: "the tables of the FROM list could not be determined"}
+		}
+		for _, table := range stmt.Tables {
+			if matchesAnyTable(policy.DeniedTables, table, defaultSchema) {
+				return &SQLPolicyViolation{Reason: "access to table '" + table + "' is denied"}
+			}
+			if len(policy.AllowedTables) > 0 && !matchesAnyTable(policy.AllowedTables, table, defaultSchema) {
+				return &SQLPolicyViolation{Reason: "table '" + table + "' is not in the allowed list"}
+			}
+		}
+
+		if policy.MaxLimit > 0 && stmt.Type == "SELECT" {
+			switch {
+			case stmt.LimitUnknown:
+				return &SQLPolicyViolation{Reason: fmt.Sprintf("LIMIT must be a literal number <= %d", policy.MaxLimit)}
+			case !stmt.HasLimit:
+				return &SQLPolicyViolation{Reason: fmt.Sprintf("SELECT must include LIMIT <= %d", policy.MaxLimit)}
+			case stmt.Limit > policy.MaxLimit:
+				return &SQLPolicyViolation{Reason: fmt.Sprintf("LIMIT %d exceeds the maximum of %d", stmt.Limit, policy.MaxLimit)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckReadOnly returns a *SQLPolicyViolation for a query that changes data although it reads
+// like a SELECT: a WITH query that inserts, updates or deletes, or SELECT ... INTO. The endpoints
+// for SELECT apply it whether or not the policy is enabled.
+func CheckReadOnly(database, query string) error {
+	for _, stmt := range analyzeSQL(database, query) {
+		for _, cte := range stmt.CTEs {
+			if dataModifyingKeywords[cte.Type] {
+				return &SQLPolicyViolation{Reason: cte.Type + " in a WITH query is not allowed on a read-only endpoint"}
+			}
+		}
+		if stmt.Into {
+			return &SQLPolicyViolation{Reason: "SELECT ... INTO is not allowed on a read-only endpoint"}
+		}
+	}
+	return nil
+}
+
+// AllowsTable reports whether queries may read table under the table lists of the policy. It is
+// used to hide denied tables from schema listings.
+func (s *SQLPolicyService) AllowsTable(table, defaultSchema string) bool {
+	s.mu.RLock()
+	policy := s.policy
+	s.mu.RUnlock()
+
+	if !policy.Enabled {
+		return true
+	}
+	table = strings.ToLower(table)
+	if matchesAnyTable(policy.DeniedTables, table, defaultSchema) {
+		return false
+	}
+	return len(policy.AllowedTables) == 0 || matchesAnyTable(policy.AllowedTables, table, defaultSchema)
+}
+
+// Reload re-reads the policy from smlgoapi.json
+func (s *SQLPolicyService) Reload() error {
+	path := config.FindConfigFile()
+	if path == "" {
+		return fmt.Errorf("smlgoapi.json not found; the SQL policy can only be reloaded from the config file")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	policy, err := config.LoadSQLPolicy(path)
+	if err != nil {
+		return err
+	}
+
+	s.setPolicy(policy)
+	s.mu.Lock()
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+
+	log.Printf("🛡️ [sql-policy] Reloaded from %s (enabled=%t, denied=%v, allowed tables=%d, max_limit=%d)",
+		path, policy.Enabled, policy.DeniedStatements, len(policy.AllowedTables), policy.MaxLimit)
+	return nil
+}
+
+// Watch polls smlgoapi.json and reloads the policy when the file changes, until ctx is done
+func (s *SQLPolicyService) Watch(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(s.Policy().ReloadIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			path := config.FindConfigFile()
+			if path == "" {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+
+			s.mu.RLock()
+			changed := info.ModTime().After(s.modTime)
+			s.mu.RUnlock()
+
+			if changed {
+				if err := s.Reload(); err != nil {
+					// Keep the previous policy rather than running without one
+					log.Printf("⚠️ [sql-policy] Reload failed, keeping previous policy: %v", err)
+					s.mu.Lock()
+					s.modTime = info.ModTime()
+					s.mu.Unlock()
+				}
+			}
+		}
+	}
+}
+
+func (s *SQLPolicyService) setPolicy(policy config.SQLPolicyConfig) {
+	denied := make(map[string]bool, len(policy.DeniedStatements))
+	for _, statement := range policy.DeniedStatements {
+		denied[strings.ToUpper(strings.TrimSpace(statement))] = true
+	}
+
+	s.mu.Lock()
+	s.policy = policy
+	s.denied = denied
+	s.mu.Unlock()
+}
+
+// matchesAnyTable matches "table" (any schema), "schema.table", "schema.*" or "*" patterns
+func matchesAnyTable(patterns []string, table, defaultSchema string) bool {
+	qualified := table
+	if !strings.Contains(table, ".") {
+		qualified = strings.ToLower(defaultSchema) + "." + table
+	}
+	name := qualified[strings.LastIndex(qualified, ".")+1:]
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "*":
+			return true
+		case strings.HasSuffix(pattern, ".*"):
+			if strings.HasPrefix(qualified, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		case strings.Contains(pattern, "."):
+			if qualified == pattern {
+				return true
+			}
+		case name == pattern:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"smlgoapi/config"
+
+	"github.com/lib/pq"
+)
+
+// ErrStockAgingUnavailable is returned when the balance or goods-receipt table does not exist
+var ErrStockAgingUnavailable = errors.New("stock aging needs the ic_balance and goods receipt tables")
+
+// StockAgingOptions selects the rows of a stock aging report
+type StockAgingOptions struct {
+	Warehouse string // only this wh_code; empty = every warehouse
+	ByItem    bool   // one row per product and warehouse instead of per warehouse
+}
+
+// StockAgingQuery builds the stock aging report for StreamSelect. On-hand quantities come from
+// ic_balance and are aged first-in first-out: the stock still on hand is taken to be the newest
+// receipts of the product in that warehouse, and each receipt's share is bucketed by its doc_date.
+// Stock not covered by any receipt is reported as qty_no_receipt.
+func (s *PostgreSQLService) StockAgingQuery(ctx context.Context, cfg config.StockAgingConfig, opts StockAgingOptions) (string, []interface{}, error) {
+	if !columnNamePattern.MatchString(cfg.ReceiptTable) {
+		return "", nil, fmt.Errorf("reports.stock_aging.receipt_table: '%s' is not a valid table name", cfg.ReceiptTable)
+	}
+	for i, days := range cfg.BucketDays {
+		if days <= 0 || (i > 0 && days <= cfg.BucketDays[i-1]) {
+			return "", nil, fmt.Errorf("reports.stock_aging.bucket_days must be increasing positive numbers, got %v", cfg.BucketDays)
+		}
+	}
+	if !s.tableExists(ctx, "ic_balance") || !s.tableExists(ctx, cfg.ReceiptTable) {
+		return "", nil, ErrStockAgingUnavailable
+	}
+
+	flags := make([]int64, len(cfg.ReceiptTransFlags))
+	for i, flag := range cfg.ReceiptTransFlags {
+		flags[i] = int64(flag)
+	}
+	params := []interface{}{pq.Array(flags)}
+	warehouseFilter := ""
+	if opts.Warehouse != "" {
+		params = append(params, opts.Warehouse)
+		warehouseFilter = "WHERE CAST(wh_code AS TEXT) = $2"
+	}
+
+	// One column per age bucket, e.g. qty_0_30, qty_31_90, qty_91_180 and qty_over_180
+	buckets := make([]string, 0, len(cfg.BucketDays)+1)
+	from, last := 0, 0
+	for _, days := range cfg.BucketDays {
+		condition := fmt.Sprintf("a.age_days <= %d", days)
+		if from > 0 {
+			condition = fmt.Sprintf("a.age_days > %d AND %s", last, condition)
+		}
+		buckets = append(buckets, fmt.Sprintf("COALESCE(SUM(a.qty) FILTER (WHERE %s), 0) AS qty_%d_%d", condition, from, days))
+		from, last = days+1, days
+	}
+	buckets = append(buckets, fmt.Sprintf("COALESCE(SUM(a.qty) FILTER (WHERE a.age_days > %d), 0) AS qty_over_%d", last, last))
+
+	groupBy, columns, join := "a.wh_code", "a.wh_code", ""
+	if opts.ByItem {
+		groupBy = "a.wh_code, a.ic_code"
+		columns = "a.wh_code, a.ic_code, COALESCE(MAX(CAST(i.{i.name} AS TEXT)), '') AS ic_name"
+		join = "LEFT JOIN ic_inventory i ON CAST(i.{i.code} AS TEXT) = a.ic_code"
+	}
+
+	// Receipt lines are converted to the stock unit of ic_balance with stand_value/divide_value;
+	// cancelled documents (last_status 1) are left out
+	query := fmt.Sprintf(`
+		WITH balances AS (
+			SELECT CAST(ic_code AS TEXT) AS ic_code, CAST(wh_code AS TEXT) AS wh_code, SUM(balance_qty) AS on_hand
+			FROM ic_balance
+			%[1]s
+			GROUP BY 1, 2
+			HAVING SUM(balance_qty) > 0
+		),
+		receipts AS (
+			SELECT CAST(item_code AS TEXT) AS ic_code, CAST(wh_code AS TEXT) AS wh_code, CAST(doc_date AS DATE) AS doc_date,
+				qty * COALESCE(NULLIF(stand_value, 0), 1) / COALESCE(NULLIF(divide_value, 0), 1) AS qty
+			FROM %[2]s
+			WHERE trans_flag = ANY($1) AND COALESCE(last_status, 0) = 0 AND qty > 0
+		),
+		layers AS (
+			SELECT r.ic_code, r.wh_code, r.doc_date, r.qty,
+				SUM(r.qty) OVER (PARTITION BY r.ic_code, r.wh_code ORDER BY r.doc_date DESC, r.qty
+					ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) - r.qty AS newer_qty
+			FROM receipts r
+			JOIN balances b ON b.ic_code = r.ic_code AND b.wh_code = r.wh_code
+		),
+		aged AS (
+			SELECT b.ic_code, b.wh_code, CURRENT_DATE - l.doc_date AS age_days, LEAST(l.qty, b.on_hand - l.newer_qty) AS qty
+			FROM balances b
+			JOIN layers l ON l.ic_code = b.ic_code AND l.wh_code = b.wh_code
+			WHERE l.newer_qty < b.on_hand
+			UNION ALL
+			SELECT b.ic_code, b.wh_code, NULL, b.on_hand - COALESCE(SUM(l.qty), 0)
+			FROM balances b
+			LEFT JOIN layers l ON l.ic_code = b.ic_code AND l.wh_code = b.wh_code
+			GROUP BY b.ic_code, b.wh_code, b.on_hand
+			HAVING b.on_hand > COALESCE(SUM(l.qty), 0)
+		)
+		SELECT %[3]s,
+			SUM(a.qty) AS on_hand_qty,
+			%[4]s,
+			COALESCE(SUM(a.qty) FILTER (WHERE a.age_days IS NULL), 0) AS qty_no_receipt
+		FROM aged a
+		%[5]s
+		GROUP BY %[6]s
+		ORDER BY %[6]s`,
+		warehouseFilter, cfg.ReceiptTable, columns, strings.Join(buckets, ",\n\t\t\t"), join, groupBy)
+
+	return s.fields.Expand(query), params, nil
+}
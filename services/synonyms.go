@@ -0,0 +1,190 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"smlgoapi/config"
+)
+
+// defaultSynonyms is the dictionary used when search.synonyms.file is not set
+//
+//go:embed synonyms.txt
+var defaultSynonyms string
+
+// SynonymService expands search queries with Thai/English synonyms from a local dictionary, so
+// "toyota brake" also finds products named "โตโยต้า เบรค". It replaces the DeepSeek query
+// enhancement without the external call.
+type SynonymService struct {
+	path string // dictionary file; empty uses the built-in list
+
+	mu      sync.RWMutex
+	groups  map[string][]string // term -> every term of its group
+	modTime time.Time
+}
+
+// NewSynonymService loads the configured dictionary, falling back to the built-in one when the
+// file cannot be read
+func NewSynonymService(cfg config.SynonymsConfig) *SynonymService {
+	s := &SynonymService{path: cfg.File}
+	if err := s.Reload(); err != nil {
+		log.Printf("⚠️ [synonyms] %v; using the built-in dictionary", err)
+		groups, _ := parseSynonyms(strings.NewReader(defaultSynonyms))
+		s.groups = groups
+	}
+	return s
+}
+
+// Reload reads the dictionary again
+func (s *SynonymService) Reload() error {
+	if s.path == "" {
+		groups, err := parseSynonyms(strings.NewReader(defaultSynonyms))
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.groups = groups
+		s.mu.Unlock()
+		return nil
+	}
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open synonym dictionary: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read synonym dictionary: %w", err)
+	}
+	groups, err := parseSynonyms(file)
+	if err != nil {
+		return fmt.Errorf("failed to read synonym dictionary %s: %w", s.path, err)
+	}
+
+	s.mu.Lock()
+	s.groups = groups
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+	log.Printf("📖 [synonyms] Loaded %d terms from %s", len(groups), s.path)
+	return nil
+}
+
+// Watch reloads the dictionary file when it changes, until ctx is done
+func (s *SynonymService) Watch(ctx context.Context, interval time.Duration) {
+	if s.path == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(s.path)
+			if err != nil {
+				continue
+			}
+			s.mu.RLock()
+			changed := info.ModTime().After(s.modTime)
+			s.mu.RUnlock()
+			if !changed {
+				continue
+			}
+			if err := s.Reload(); err != nil {
+				// Keep the previous dictionary rather than searching without one
+				log.Printf("⚠️ [synonyms] Reload failed, keeping previous dictionary: %v", err)
+				s.mu.Lock()
+				s.modTime = info.ModTime()
+				s.mu.Unlock()
+			}
+		}
+	}
+}
+
+// Expand returns the query followed by the synonyms of the terms it contains, without duplicates.
+// Latin terms match whole words; Thai terms match anywhere, since Thai is written without spaces.
+func (s *SynonymService) Expand(query string) string {
+	if s == nil {
+		return query
+	}
+	lower := strings.ToLower(query)
+	words := strings.Fields(lower)
+	padded := " " + strings.Join(words, " ") + " "
+
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		seen[word] = true
+	}
+	var added []string
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for term, group := range s.groups {
+		if !containsSynonymTerm(padded, lower, term) {
+			continue
+		}
+		for _, synonym := range group {
+			if synonym != term && !seen[synonym] && !strings.Contains(lower, synonym) {
+				seen[synonym] = true
+				added = append(added, synonym)
+			}
+		}
+	}
+	if len(added) == 0 {
+		return query
+	}
+	// Map order is random; sorted additions keep the expanded query (and cursors) stable
+	sort.Strings(added)
+	return query + " " + strings.Join(added, " ")
+}
+
+// containsSynonymTerm reports whether the query (lowercased, and padded with spaces around its
+// normalized words) contains term
+func containsSynonymTerm(padded, lower, term string) bool {
+	for _, r := range term {
+		if r >= 0x0E00 && r <= 0x0E7F {
+			return strings.Contains(lower, term)
+		}
+	}
+	return strings.Contains(padded, " "+term+" ")
+}
+
+// parseSynonyms reads comma-separated groups, one per line; # starts a comment line
+func parseSynonyms(r io.Reader) (map[string][]string, error) {
+	groups := make(map[string][]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var group []string
+		for _, term := range strings.Split(line, ",") {
+			term = strings.Join(strings.FieldsFunc(strings.ToLower(term), unicode.IsSpace), " ")
+			if term != "" {
+				group = append(group, term)
+			}
+		}
+		if len(group) < 2 {
+			continue
+		}
+		for _, term := range group {
+			// A term listed in several groups expands to all of them
+			groups[term] = append(groups[term], group...)
+		}
+	}
+	return groups, scanner.Err()
+}
@@ -0,0 +1,895 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// ErrTableSyncRunning is returned when a table sync is started while another one is still running
+var ErrTableSyncRunning = errors.New("a table sync is already running")
+
+// ErrInvalidTableSync is returned for sync options that cannot be run
+var ErrInvalidTableSync = errors.New("invalid table sync")
+
+// Table sync modes
+const (
+	TableSyncFull        = "full"        // the target is emptied, then every row is copied
+	TableSyncAppend      = "append"      // every row is copied next to the rows already in the target
+	TableSyncIncremental = "incremental" // rows whose key is above the largest key in the target
+)
+
+// Phases of a table sync besides SyncPhaseIdle, SyncPhaseDone and SyncPhaseFailed
+const (
+	SyncPhasePrepare = "preparing"
+	SyncPhaseCopy    = "copying"
+)
+
+const (
+	defaultTableSyncBatch = 1000
+	maxTableSyncBatch     = 10000
+	// PostgreSQL binds at most 65535 parameters per statement
+	maxPostgresParams = 65535
+)
+
+// Column types a table sync maps between the databases
+const (
+	syncText = iota
+	syncInt16
+	syncInt32
+	syncInt64
+	syncFloat32
+	syncFloat64
+	syncDecimal
+	syncBool
+	syncDate
+	syncTimestamp
+)
+
+// TableSyncOptions controls a table sync
+type TableSyncOptions struct {
+	Source      string `json:"source"`                 // postgresql or clickhouse
+	Target      string `json:"target"`                 // the other database
+	Table       string `json:"table"`                  // source table, optionally schema- or database-qualified
+	TargetTable string `json:"target_table,omitempty"` // default: the source table name
+	Mode        string `json:"mode"`                   // full (default), append or incremental
+	Key         string `json:"key,omitempty"`          // column whose values only grow; required by incremental
+	BatchSize   int    `json:"batch_size"`             // rows written per insert
+}
+
+// TableSyncColumn is a copied column and the type it has on each side
+type TableSyncColumn struct {
+	Name       string `json:"name"`
+	SourceType string `json:"source_type"`
+	TargetType string `json:"target_type"`
+}
+
+// TableSyncStatus is the progress of the current or last table sync
+type TableSyncStatus struct {
+	Running       bool              `json:"running"`
+	Phase         string            `json:"phase"`
+	Options       TableSyncOptions  `json:"options"`
+	StartedAt     *time.Time        `json:"started_at,omitempty"`
+	FinishedAt    *time.Time        `json:"finished_at,omitempty"`
+	Created       bool              `json:"created"`         // the target table did not exist and was created
+	Since         interface{}       `json:"since,omitempty"` // incremental: largest key found in the target
+	TotalRows     int64             `json:"total_rows"`      // rows to copy, counted before copying
+	RowsCopied    int64             `json:"rows_copied"`
+	Batches       int               `json:"batches"`
+	Columns       []TableSyncColumn `json:"columns,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	RowsPerSecond float64           `json:"rows_per_second,omitempty"`
+}
+
+// TableSyncService copies tables between PostgreSQL and ClickHouse, typically to mirror
+// ic_inventory into ClickHouse for analytics. Missing target tables are created with the mapped
+// column types. One sync runs at a time.
+type TableSyncService struct {
+	pg    *PostgreSQLService
+	ch    *ClickHouseService
+	cache *SelectCache
+
+	mu     sync.Mutex
+	status TableSyncStatus
+}
+
+// syncColumn is a column of the source or target table
+type syncColumn struct {
+	name      string
+	dbType    string // as reported by the database
+	kind      int
+	nullable  bool
+	precision int // syncDecimal only
+	scale     int
+}
+
+// syncTable is one side of a sync
+type syncTable struct {
+	database string
+	name     string // possibly qualified
+	columns  []syncColumn
+	key      []string // PostgreSQL primary key, used as the ClickHouse sorting key
+}
+
+// NewTableSyncService creates a table sync service; both databases must be available. Cached
+// SELECT results of the target table are dropped after a sync.
+func NewTableSyncService(pg *PostgreSQLService, ch *ClickHouseService, cache *SelectCache) *TableSyncService {
+	return &TableSyncService{pg: pg, ch: ch, cache: cache, status: TableSyncStatus{Phase: SyncPhaseIdle}}
+}
+
+// Status returns a copy of the current progress
+func (s *TableSyncService) Status() TableSyncStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	status.Columns = append([]TableSyncColumn(nil), s.status.Columns...)
+	return status
+}
+
+// Start validates the options and runs the sync in the background, tracked by operations so a
+// shutdown waits for it
+func (s *TableSyncService) Start(operations *OperationTracker, opts TableSyncOptions) (TableSyncStatus, error) {
+	opts, err := normalizeTableSync(opts)
+	if err != nil {
+		return s.Status(), err
+	}
+
+	s.mu.Lock()
+	if s.status.Running {
+		s.mu.Unlock()
+		return s.Status(), ErrTableSyncRunning
+	}
+	now := time.Now()
+	s.status = TableSyncStatus{Running: true, Phase: SyncPhasePrepare, Options: opts, StartedAt: &now}
+	s.mu.Unlock()
+
+	err = operations.Go("table-sync", opts.Source+" "+opts.Table, func(ctx context.Context) {
+		s.run(ctx, opts)
+	})
+	if err != nil {
+		s.mu.Lock()
+		s.status.Running = false
+		s.status.Phase = SyncPhaseFailed
+		s.status.Error = err.Error()
+		s.mu.Unlock()
+		return s.Status(), err
+	}
+	return s.Status(), nil
+}
+
+func normalizeTableSync(opts TableSyncOptions) (TableSyncOptions, error) {
+	opts.Source = strings.ToLower(strings.TrimSpace(opts.Source))
+	opts.Target = strings.ToLower(strings.TrimSpace(opts.Target))
+	opts.Table = strings.TrimSpace(opts.Table)
+	opts.TargetTable = strings.TrimSpace(opts.TargetTable)
+	opts.Key = strings.TrimSpace(opts.Key)
+	if opts.Mode == "" {
+		opts.Mode = TableSyncFull
+	}
+	if opts.TargetTable == "" {
+		opts.TargetTable = opts.Table
+		if i := strings.LastIndexByte(opts.Table, '.'); i >= 0 {
+			opts.TargetTable = opts.Table[i+1:]
+		}
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultTableSyncBatch
+	}
+	opts.BatchSize = min(opts.BatchSize, maxTableSyncBatch)
+
+	valid := map[string]bool{ServicePostgreSQL: true, ServiceClickHouse: true}
+	switch {
+	case !valid[opts.Source] || !valid[opts.Target] || opts.Source == opts.Target:
+		return opts, fmt.Errorf("%w: source and target must be %s and %s, one each", ErrInvalidTableSync, ServicePostgreSQL, ServiceClickHouse)
+	case !validTableName(opts.Table):
+		return opts, fmt.Errorf("%w: invalid table name '%s'", ErrInvalidTableSync, opts.Table)
+	case !validTableName(opts.TargetTable):
+		return opts, fmt.Errorf("%w: invalid target table name '%s'", ErrInvalidTableSync, opts.TargetTable)
+	case opts.Mode != TableSyncFull && opts.Mode != TableSyncAppend && opts.Mode != TableSyncIncremental:
+		return opts, fmt.Errorf("%w: unknown mode '%s': use full, append or incremental", ErrInvalidTableSync, opts.Mode)
+	case opts.Mode == TableSyncIncremental && opts.Key == "":
+		return opts, fmt.Errorf("%w: incremental mode needs a key column", ErrInvalidTableSync)
+	case opts.Key != "" && !columnNamePattern.MatchString(opts.Key):
+		return opts, fmt.Errorf("%w: invalid key column '%s'", ErrInvalidTableSync, opts.Key)
+	}
+	return opts, nil
+}
+
+// validTableName accepts table or schema.table
+func validTableName(name string) bool {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return false
+	}
+	for _, part := range parts {
+		if !columnNamePattern.MatchString(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *TableSyncService) run(ctx context.Context, opts TableSyncOptions) {
+	log.Printf("🔁 [table-sync] %s sync of %s from %s to %s.%s started", opts.Mode, opts.Table, opts.Source, opts.Target, opts.TargetTable)
+
+	err := s.sync(ctx, opts)
+	if s.cache != nil {
+		s.cache.Invalidate(opts.Target, opts.TargetTable)
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	s.status.Running = false
+	s.status.FinishedAt = &now
+	s.status.Phase = SyncPhaseDone
+	if err != nil {
+		s.status.Phase = SyncPhaseFailed
+		s.status.Error = err.Error()
+	}
+	if elapsed := now.Sub(*s.status.StartedAt).Seconds(); elapsed > 0 {
+		s.status.RowsPerSecond = float64(s.status.RowsCopied) / elapsed
+	}
+	status := s.status
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Printf("❌ [table-sync] Sync of %s failed after %d rows: %v", opts.Table, status.RowsCopied, err)
+		return
+	}
+	log.Printf("✅ [table-sync] Copied %d rows of %s to %s.%s in %s", status.RowsCopied, opts.Table,
+		opts.Target, opts.TargetTable, now.Sub(*status.StartedAt).Round(time.Millisecond))
+}
+
+func (s *TableSyncService) sync(ctx context.Context, opts TableSyncOptions) error {
+	source, err := s.describe(ctx, opts.Source, opts.Table)
+	if err != nil {
+		return err
+	}
+	if len(source.columns) == 0 {
+		return fmt.Errorf("table '%s' not found in %s", opts.Table, opts.Source)
+	}
+	if opts.Key != "" && source.column(opts.Key) == nil {
+		return fmt.Errorf("key column '%s' not found in %s", opts.Key, opts.Table)
+	}
+
+	target, err := s.describe(ctx, opts.Target, opts.TargetTable)
+	if err != nil {
+		return err
+	}
+	created := len(target.columns) == 0
+	if created {
+		if target, err = s.create(ctx, source, opts); err != nil {
+			return err
+		}
+	}
+
+	// Columns present on both sides are copied; their values are converted to the target type
+	var columns []syncColumn // target columns
+	var sourceIndex []int
+	var report []TableSyncColumn
+	for i, sc := range source.columns {
+		if tc := target.column(sc.name); tc != nil {
+			columns = append(columns, *tc)
+			sourceIndex = append(sourceIndex, i)
+			report = append(report, TableSyncColumn{Name: sc.name, SourceType: sc.dbType, TargetType: tc.dbType})
+		}
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("%s and %s have no column in common", opts.Table, opts.TargetTable)
+	}
+	if opts.Key != "" && target.column(opts.Key) == nil {
+		return fmt.Errorf("key column '%s' not found in %s", opts.Key, opts.TargetTable)
+	}
+
+	query := "SELECT " + joinQuoted(opts.Source, columnNames(source.columns, sourceIndex)) + " FROM " + quoteTable(opts.Source, opts.Table)
+	var params []interface{}
+	var since interface{}
+	if opts.Mode == TableSyncIncremental && !created {
+		if since, err = s.maxKey(ctx, opts.Target, opts.TargetTable, opts.Key); err != nil {
+			return err
+		}
+		if since != nil {
+			if since, err = syncValue(*source.column(opts.Key), since); err != nil {
+				return fmt.Errorf("largest %s in %s: %w", opts.Key, opts.TargetTable, err)
+			}
+			query += " WHERE " + quoteIdent(opts.Source, opts.Key) + " > " + placeholder(opts.Source, 1)
+			params = append(params, since)
+		}
+	}
+	if opts.Key != "" {
+		query += " ORDER BY " + quoteIdent(opts.Source, opts.Key)
+	}
+
+	var total int64
+	if err := s.queryRow(ctx, opts.Source, "SELECT count(*) FROM ("+query+") AS sync_source", params...).Scan(&total); err != nil {
+		return fmt.Errorf("failed to count source rows: %w", err)
+	}
+	s.mu.Lock()
+	s.status.Created = created
+	s.status.Since = since
+	s.status.TotalRows = total
+	s.status.Columns = report
+	s.status.Phase = SyncPhaseCopy
+	s.mu.Unlock()
+
+	writer, err := s.newWriter(ctx, opts, columns)
+	if err != nil {
+		return err
+	}
+	defer writer.close()
+
+	batchSize := opts.BatchSize
+	if opts.Target == ServicePostgreSQL {
+		batchSize = min(batchSize, maxPostgresParams/len(columns))
+	}
+	batch := make([][]interface{}, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := writer.write(ctx, batch); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.status.RowsCopied += int64(len(batch))
+		s.status.Batches++
+		s.mu.Unlock()
+		batch = batch[:0]
+		return nil
+	}
+
+	_, err = s.stream(ctx, opts.Source, query, func(_ []string, values []interface{}) error {
+		row := make([]interface{}, len(columns))
+		for i, column := range columns {
+			value, err := syncValue(column, values[i])
+			if err != nil {
+				return fmt.Errorf("column %s: %w", column.name, err)
+			}
+			row[i] = value
+		}
+		batch = append(batch, row)
+		if len(batch) < batchSize {
+			return nil
+		}
+		return flush()
+	}, params...)
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return err
+	}
+	return writer.commit()
+}
+
+// describe returns the columns of a table, none when it does not exist
+func (s *TableSyncService) describe(ctx context.Context, database, table string) (*syncTable, error) {
+	schema, name := splitTable(table)
+	t := &syncTable{database: database, name: table}
+
+	var rows *sql.Rows
+	var err error
+	if database == ServicePostgreSQL {
+		if schema == "" {
+			schema = postgresDefaultSchema
+		}
+		rows, err = s.pg.db.QueryContext(ctx, `
+			SELECT column_name, data_type, is_nullable = 'YES', COALESCE(numeric_precision, 0), COALESCE(numeric_scale, 0)
+			FROM information_schema.columns
+			WHERE table_schema = $1 AND table_name = $2
+			ORDER BY ordinal_position`, schema, name)
+	} else {
+		rows, err = s.ch.db.QueryContext(tagContext(ctx), `
+			SELECT name, type, 0, 0, 0
+			FROM system.columns
+			WHERE database = if(? = '', currentDatabase(), ?) AND table = ?
+			ORDER BY position`, schema, schema, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c syncColumn
+		var precision, scale int64
+		if err := rows.Scan(&c.name, &c.dbType, &c.nullable, &precision, &scale); err != nil {
+			return nil, err
+		}
+		if database == ServicePostgreSQL {
+			c.kind, c.precision, c.scale = postgresSyncKind(c.dbType, int(precision), int(scale))
+		} else {
+			c.kind, c.nullable, c.precision, c.scale = clickHouseSyncKind(c.dbType)
+		}
+		t.columns = append(t.columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if database == ServicePostgreSQL && len(t.columns) > 0 {
+		keys, err := s.pg.db.QueryContext(ctx, `
+			SELECT a.attname
+			FROM pg_index i
+			JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+			WHERE i.indrelid = $1::regclass AND i.indisprimary
+			ORDER BY array_position(i.indkey, a.attnum)`, pq.QuoteIdentifier(schema)+"."+pq.QuoteIdentifier(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the primary key of %s: %w", table, err)
+		}
+		defer keys.Close()
+		for keys.Next() {
+			var key string
+			if err := keys.Scan(&key); err != nil {
+				return nil, err
+			}
+			t.key = append(t.key, key)
+		}
+	}
+	return t, nil
+}
+
+// create makes the target table with the source columns in target types
+func (s *TableSyncService) create(ctx context.Context, source *syncTable, opts TableSyncOptions) (*syncTable, error) {
+	target := &syncTable{database: opts.Target, name: opts.TargetTable}
+	definitions := make([]string, len(source.columns))
+	for i, sc := range source.columns {
+		c := sc
+		c.dbType = syncTypeName(opts.Target, c)
+		target.columns = append(target.columns, c)
+		definitions[i] = quoteIdent(opts.Target, c.name) + " " + c.dbType
+		if opts.Target == ServicePostgreSQL && !c.nullable {
+			definitions[i] += " NOT NULL"
+		}
+	}
+
+	ddl := "CREATE TABLE " + quoteTable(opts.Target, opts.TargetTable) + " (\n\t" + strings.Join(definitions, ",\n\t") + "\n)"
+	if opts.Target == ServiceClickHouse {
+		// ClickHouse needs a sorting key: the PostgreSQL primary key, else the sync key
+		orderBy := "tuple()"
+		if len(source.key) > 0 {
+			orderBy = "(" + joinQuoted(ServiceClickHouse, source.key) + ")"
+		} else if opts.Key != "" && !source.column(opts.Key).nullable {
+			orderBy = quoteIdent(ServiceClickHouse, opts.Key)
+		}
+		ddl += " ENGINE = MergeTree ORDER BY " + orderBy
+	}
+	if err := s.exec(ctx, opts.Target, ddl); err != nil {
+		return nil, fmt.Errorf("failed to create %s in %s: %w", opts.TargetTable, opts.Target, err)
+	}
+	log.Printf("🆕 [table-sync] Created %s in %s with %d columns", opts.TargetTable, opts.Target, len(target.columns))
+	return target, nil
+}
+
+func (s *TableSyncService) maxKey(ctx context.Context, database, table, key string) (interface{}, error) {
+	var value interface{}
+	query := "SELECT max(" + quoteIdent(database, key) + ") FROM " + quoteTable(database, table)
+	if database == ServiceClickHouse {
+		// An empty table returns the type's default instead of NULL
+		query = "SELECT if(count() = 0, NULL, max(" + quoteIdent(database, key) + ")) FROM " + quoteTable(database, table)
+	}
+	if err := s.queryRow(ctx, database, query).Scan(&value); err != nil {
+		return nil, fmt.Errorf("failed to read the largest %s in %s: %w", key, table, err)
+	}
+	value = syncUnwrap(value)
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	return value, nil
+}
+
+func (s *TableSyncService) stream(ctx context.Context, database, query string, fn RowFunc, params ...interface{}) (int, error) {
+	if database == ServicePostgreSQL {
+		return s.pg.StreamSelect(ctx, query, fn, params...)
+	}
+	return s.ch.StreamSelect(ctx, query, fn, params...)
+}
+
+func (s *TableSyncService) queryRow(ctx context.Context, database, query string, params ...interface{}) *sql.Row {
+	if database == ServicePostgreSQL {
+		return s.pg.db.QueryRowContext(ctx, query, params...)
+	}
+	return s.ch.db.QueryRowContext(tagContext(ctx), query, params...)
+}
+
+func (s *TableSyncService) exec(ctx context.Context, database, query string) error {
+	var err error
+	if database == ServicePostgreSQL {
+		_, err = s.pg.db.ExecContext(ctx, query)
+	} else {
+		_, err = s.ch.db.ExecContext(tagContext(ctx), query)
+	}
+	return err
+}
+
+// syncWriter inserts batches into the target table
+type syncWriter interface {
+	write(ctx context.Context, rows [][]interface{}) error
+	commit() error
+	close()
+}
+
+// newWriter empties the target in full mode and returns its writer. PostgreSQL writes the whole
+// sync in one transaction, so readers keep the old rows until it commits. ClickHouse has no
+// transactions: a failed full sync leaves the target partly filled until it is run again.
+func (s *TableSyncService) newWriter(ctx context.Context, opts TableSyncOptions, columns []syncColumn) (syncWriter, error) {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	table := quoteTable(opts.Target, opts.TargetTable)
+
+	if opts.Target == ServicePostgreSQL {
+		tx, err := s.pg.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		if opts.Mode == TableSyncFull {
+			if _, err := tx.ExecContext(ctx, "TRUNCATE TABLE "+table); err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("failed to empty %s: %w", opts.TargetTable, err)
+			}
+		}
+		return &postgresSyncWriter{tx: tx, insert: "INSERT INTO " + table + " (" + joinQuoted(ServicePostgreSQL, names) + ") VALUES "}, nil
+	}
+
+	if opts.Mode == TableSyncFull {
+		if err := s.exec(ctx, ServiceClickHouse, "TRUNCATE TABLE "+table); err != nil {
+			return nil, fmt.Errorf("failed to empty %s: %w", opts.TargetTable, err)
+		}
+	}
+	return &clickHouseSyncWriter{db: s.ch.db, insert: "INSERT INTO " + table + " (" + joinQuoted(ServiceClickHouse, names) + ")"}, nil
+}
+
+type postgresSyncWriter struct {
+	tx     *sql.Tx
+	insert string
+}
+
+func (w *postgresSyncWriter) write(ctx context.Context, rows [][]interface{}) error {
+	values := make([]string, len(rows))
+	params := make([]interface{}, 0, len(rows)*len(rows[0]))
+	for i, row := range rows {
+		placeholders := make([]string, len(row))
+		for j := range row {
+			placeholders[j] = "$" + strconv.Itoa(len(params)+j+1)
+		}
+		values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+		params = append(params, row...)
+	}
+	if _, err := w.tx.ExecContext(ctx, w.insert+strings.Join(values, ", "), params...); err != nil {
+		return fmt.Errorf("failed to insert batch: %w", err)
+	}
+	return nil
+}
+
+func (w *postgresSyncWriter) commit() error {
+	return w.tx.Commit()
+}
+
+func (w *postgresSyncWriter) close() {
+	w.tx.Rollback()
+}
+
+// clickHouseSyncWriter sends each batch as one native insert block
+type clickHouseSyncWriter struct {
+	db     *sql.DB
+	insert string
+}
+
+func (w *clickHouseSyncWriter) write(ctx context.Context, rows [][]interface{}) error {
+	tx, err := w.db.BeginTx(tagContext(ctx), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(tagContext(ctx), w.insert)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return fmt.Errorf("failed to insert batch: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (w *clickHouseSyncWriter) commit() error { return nil }
+
+func (w *clickHouseSyncWriter) close() {}
+
+func (t *syncTable) column(name string) *syncColumn {
+	for i := range t.columns {
+		if t.columns[i].name == name {
+			return &t.columns[i]
+		}
+	}
+	return nil
+}
+
+// postgresDefaultSchema holds unqualified PostgreSQL tables
+const postgresDefaultSchema = "public"
+
+var (
+	clickHouseWrapperPattern = regexp.MustCompile(`^(Nullable|LowCardinality)\((.*)\)$`)
+	clickHouseDecimalPattern = regexp.MustCompile(`^Decimal(32|64|128|256)?\((\d+)(?:,\s*(\d+))?\)$`)
+)
+
+// postgresSyncKind maps an information_schema data_type
+func postgresSyncKind(dataType string, precision, scale int) (kind, p, s int) {
+	switch dataType {
+	case "smallint":
+		return syncInt16, 0, 0
+	case "integer":
+		return syncInt32, 0, 0
+	case "bigint":
+		return syncInt64, 0, 0
+	case "real":
+		return syncFloat32, 0, 0
+	case "double precision":
+		return syncFloat64, 0, 0
+	case "numeric":
+		// Unbounded numeric has no ClickHouse Decimal to match; Float64 keeps it usable for analytics
+		if precision == 0 || precision > 76 {
+			return syncFloat64, 0, 0
+		}
+		return syncDecimal, precision, scale
+	case "boolean":
+		return syncBool, 0, 0
+	case "date":
+		return syncDate, 0, 0
+	case "timestamp without time zone", "timestamp with time zone":
+		return syncTimestamp, 0, 0
+	}
+	return syncText, 0, 0
+}
+
+// clickHouseSyncKind maps a system.columns type, unwrapping Nullable and LowCardinality
+func clickHouseSyncKind(dbType string) (kind int, nullable bool, precision, scale int) {
+	t := dbType
+	for {
+		m := clickHouseWrapperPattern.FindStringSubmatch(t)
+		if m == nil {
+			break
+		}
+		nullable = nullable || m[1] == "Nullable"
+		t = m[2]
+	}
+	if m := clickHouseDecimalPattern.FindStringSubmatch(t); m != nil {
+		// Decimal(P, S), or DecimalN(S) whose precision follows from N
+		precision, _ = strconv.Atoi(m[2])
+		if m[3] != "" {
+			scale, _ = strconv.Atoi(m[3])
+		} else {
+			scale = precision
+			precision = map[string]int{"32": 9, "64": 18, "128": 38, "256": 76}[m[1]]
+		}
+		return syncDecimal, nullable, precision, scale
+	}
+	switch {
+	case t == "Int8" || t == "Int16" || t == "UInt8":
+		return syncInt16, nullable, 0, 0
+	case t == "Int32" || t == "UInt16":
+		return syncInt32, nullable, 0, 0
+	case t == "Int64" || t == "UInt32":
+		return syncInt64, nullable, 0, 0
+	case t == "UInt64":
+		return syncDecimal, nullable, 20, 0
+	case t == "Float32":
+		return syncFloat32, nullable, 0, 0
+	case t == "Float64":
+		return syncFloat64, nullable, 0, 0
+	case t == "Bool":
+		return syncBool, nullable, 0, 0
+	case t == "Date" || t == "Date32":
+		return syncDate, nullable, 0, 0
+	case strings.HasPrefix(t, "DateTime"):
+		return syncTimestamp, nullable, 0, 0
+	}
+	return syncText, nullable, 0, 0
+}
+
+// syncTypeName is the column type created in database for a column
+func syncTypeName(database string, c syncColumn) string {
+	if database == ServicePostgreSQL {
+		return map[int]string{
+			syncText:      "text",
+			syncInt16:     "smallint",
+			syncInt32:     "integer",
+			syncInt64:     "bigint",
+			syncFloat32:   "real",
+			syncFloat64:   "double precision",
+			syncDecimal:   fmt.Sprintf("numeric(%d,%d)", c.precision, c.scale),
+			syncBool:      "boolean",
+			syncDate:      "date",
+			syncTimestamp: "timestamp with time zone",
+		}[c.kind]
+	}
+	name := map[int]string{
+		syncText:      "String",
+		syncInt16:     "Int16",
+		syncInt32:     "Int32",
+		syncInt64:     "Int64",
+		syncFloat32:   "Float32",
+		syncFloat64:   "Float64",
+		syncDecimal:   fmt.Sprintf("Decimal(%d, %d)", c.precision, c.scale),
+		syncBool:      "Bool",
+		syncDate:      "Date32",
+		syncTimestamp: "DateTime64(6)",
+	}[c.kind]
+	if c.nullable {
+		name = "Nullable(" + name + ")"
+	}
+	return name
+}
+
+// syncValue converts a source value to the Go type the target column takes. The ClickHouse
+// driver only accepts exact types, such as int16 for Int16 and decimal.Decimal for Decimal.
+func syncValue(c syncColumn, v interface{}) (interface{}, error) {
+	v = syncUnwrap(v)
+	if v == nil {
+		return nil, nil
+	}
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+
+	switch c.kind {
+	case syncInt16, syncInt32, syncInt64:
+		n, err := syncInt(v)
+		if err != nil {
+			return nil, err
+		}
+		switch c.kind {
+		case syncInt16:
+			return int16(n), nil
+		case syncInt32:
+			return int32(n), nil
+		}
+		return n, nil
+	case syncFloat32, syncFloat64:
+		f, err := syncFloat(v)
+		if err != nil {
+			return nil, err
+		}
+		if c.kind == syncFloat32 {
+			return float32(f), nil
+		}
+		return f, nil
+	case syncDecimal:
+		d, err := decimal.NewFromString(fmt.Sprint(v))
+		if err != nil {
+			return nil, fmt.Errorf("%v is not a decimal", v)
+		}
+		return d, nil
+	case syncBool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		n, err := syncInt(v)
+		return n != 0, err
+	case syncDate, syncTimestamp:
+		if t, ok := v.(time.Time); ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("%T is not a time", v)
+	}
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+func syncInt(v interface{}) (int64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return int64(rv.Float()), nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	n, ok := new(big.Int).SetString(strings.TrimSpace(fmt.Sprint(v)), 10)
+	if !ok || !n.IsInt64() {
+		return 0, fmt.Errorf("%v is not an integer", v)
+	}
+	return n.Int64(), nil
+}
+
+func syncFloat(v interface{}) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(v)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%v is not a number", v)
+	}
+	return f, nil
+}
+
+// syncUnwrap dereferences the pointers ClickHouse returns for Nullable columns
+func syncUnwrap(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+func splitTable(table string) (schema, name string) {
+	if i := strings.IndexByte(table, '.'); i >= 0 {
+		return table[:i], table[i+1:]
+	}
+	return "", table
+}
+
+func quoteIdent(database, name string) string {
+	if database == ServicePostgreSQL {
+		return pq.QuoteIdentifier(name)
+	}
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+func quoteTable(database, table string) string {
+	schema, name := splitTable(table)
+	if schema == "" {
+		return quoteIdent(database, name)
+	}
+	return quoteIdent(database, schema) + "." + quoteIdent(database, name)
+}
+
+func joinQuoted(database string, names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(database, name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func columnNames(columns []syncColumn, indexes []int) []string {
+	names := make([]string, len(indexes))
+	for i, index := range indexes {
+		names[i] = columns[index].name
+	}
+	return names
+}
+
+func placeholder(database string, n int) string {
+	if database == ServicePostgreSQL {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"smlgoapi/config"
 	"smlgoapi/models"
+	"sync"
+	"time"
 )
 
 // ThaiAdminService handles Thai administrative data operations
 type ThaiAdminService struct {
+	mu                     sync.RWMutex // guards the data below; UpdateData replaces it while serving
 	provincesData          []models.Province
 	amphuresData           []models.Amphure
 	tambonsData            []models.Tambon
@@ -18,15 +22,48 @@ type ThaiAdminService struct {
 	tambonsLoaded          bool
 	completeLocationData   []models.CompleteLocationData
 	completeLocationLoaded bool
+	source                 string          // ThaiAdminSource* the data being served came from
+	replacedAt             time.Time       // when an upload or refresh swapped the data in
+	index                  *thaiAdminIndex // lookups of the data above, replaced with it
+	exports                thaiAdminExports
+	boundaries             tambonBoundaries
+	upstream               config.ThaiAdminUpstreamConfig
+	refreshMu              sync.Mutex // one refresh from upstream at a time
 }
 
+// Where the data being served came from
+const (
+	ThaiAdminSourceFiles    = "files"
+	ThaiAdminSourceUpload   = "upload"
+	ThaiAdminSourceUpstream = "upstream"
+)
+
 // NewThaiAdminService creates a new Thai administrative service
-func NewThaiAdminService() *ThaiAdminService {
-	return &ThaiAdminService{}
+func NewThaiAdminService(cfg config.ThaiAdminConfig) *ThaiAdminService {
+	return &ThaiAdminService{
+		source:     ThaiAdminSourceFiles,
+		index:      &thaiAdminIndex{},
+		boundaries: tambonBoundaries{file: cfg.BoundariesFile, idProperty: cfg.BoundariesIDProperty},
+		upstream:   cfg.Upstream,
+	}
+}
+
+// Check loads the data files if they are not loaded yet and reports how many records they hold
+func (s *ThaiAdminService) Check() (string, error) {
+	for _, load := range []func() error{s.loadProvinces, s.loadAmphures, s.loadTambons, s.loadCompleteLocationData} {
+		if err := load(); err != nil {
+			return "", err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fmt.Sprintf("%d provinces, %d amphures, %d tambons", len(s.provincesData), len(s.amphuresData), len(s.tambonsData)), nil
 }
 
 // loadProvinces loads province data from JSON file
 func (s *ThaiAdminService) loadProvinces() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.provincesLoaded {
 		return nil
 	}
@@ -48,6 +85,8 @@ func (s *ThaiAdminService) loadProvinces() error {
 
 // loadAmphures loads amphure data from JSON file
 func (s *ThaiAdminService) loadAmphures() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.amphuresLoaded {
 		return nil
 	}
@@ -69,6 +108,8 @@ func (s *ThaiAdminService) loadAmphures() error {
 
 // loadTambons loads tambon data from JSON file
 func (s *ThaiAdminService) loadTambons() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.tambonsLoaded {
 		return nil
 	}
@@ -90,86 +131,40 @@ func (s *ThaiAdminService) loadTambons() error {
 
 // GetProvinces returns all provinces
 func (s *ThaiAdminService) GetProvinces() ([]models.Province, error) {
-	err := s.loadProvinces()
+	index, err := s.lookups()
 	if err != nil {
 		return nil, err
 	}
-
-	// Return only essential fields as specified in the docs
-	var result []models.Province
-	for _, province := range s.provincesData {
-		result = append(result, models.Province{
-			ID:     province.ID,
-			NameTh: province.NameTh,
-			NameEn: province.NameEn,
-		})
-	}
-
-	return result, nil
+	return append([]models.Province(nil), index.provinces...), nil
 }
 
 // GetAmphuresByProvinceID returns all amphures for a given province
 func (s *ThaiAdminService) GetAmphuresByProvinceID(provinceID int) ([]models.Amphure, error) {
-	err := s.loadAmphures()
+	index, err := s.lookups()
 	if err != nil {
 		return nil, err
 	}
-
-	var result []models.Amphure
-	for _, amphure := range s.amphuresData {
-		if amphure.ProvinceID == provinceID {
-			result = append(result, models.Amphure{
-				ID:     amphure.ID,
-				NameTh: amphure.NameTh,
-				NameEn: amphure.NameEn,
-			})
-		}
-	}
-
-	return result, nil
+	return append([]models.Amphure(nil), index.amphuresByProvince[provinceID]...), nil
 }
 
 // GetTambonsByAmphureAndProvince returns all tambons for a given amphure and province
 func (s *ThaiAdminService) GetTambonsByAmphureAndProvince(amphureID, provinceID int) ([]models.Tambon, error) {
-	err := s.loadTambons()
+	index, err := s.lookups()
 	if err != nil {
 		return nil, err
 	}
 
 	// First verify the amphure belongs to the province
-	err = s.loadAmphures()
-	if err != nil {
-		return nil, err
-	}
-
-	var amphureFound bool
-	for _, amphure := range s.amphuresData {
-		if amphure.ID == amphureID && amphure.ProvinceID == provinceID {
-			amphureFound = true
-			break
-		}
-	}
-
-	if !amphureFound {
+	if amphure, ok := index.amphureByID[amphureID]; !ok || amphure.ProvinceID != provinceID {
 		return nil, fmt.Errorf("amphure_id %d not found in province_id %d", amphureID, provinceID)
 	}
-
-	var result []models.Tambon
-	for _, tambon := range s.tambonsData {
-		if tambon.AmphureID == amphureID {
-			result = append(result, models.Tambon{
-				ID:     tambon.ID,
-				NameTh: tambon.NameTh,
-				NameEn: tambon.NameEn,
-			})
-		}
-	}
-
-	return result, nil
+	return append([]models.Tambon(nil), index.tambonsByAmphure[amphureID]...), nil
 }
 
 // loadCompleteLocationData loads complete location data from JSON file
 func (s *ThaiAdminService) loadCompleteLocationData() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.completeLocationLoaded {
 		return nil
 	}
@@ -215,34 +210,9 @@ func (s *ThaiAdminService) loadCompleteLocationData() error {
 
 // FindByZipCode finds all locations with the given zip code
 func (s *ThaiAdminService) FindByZipCode(zipCode int) ([]models.CompleteLocationData, error) {
-	err := s.loadCompleteLocationData()
+	index, err := s.lookups()
 	if err != nil {
 		return nil, err
 	}
-
-	var result []models.CompleteLocationData
-	for _, location := range s.completeLocationData {
-		if location.Tambon.ZipCode == zipCode {
-			result = append(result, models.CompleteLocationData{
-				Province: models.Province{
-					ID:     location.Province.ID,
-					NameTh: location.Province.NameTh,
-					NameEn: location.Province.NameEn,
-				},
-				Amphure: models.Amphure{
-					ID:     location.Amphure.ID,
-					NameTh: location.Amphure.NameTh,
-					NameEn: location.Amphure.NameEn,
-				},
-				Tambon: models.Tambon{
-					ID:      location.Tambon.ID,
-					NameTh:  location.Tambon.NameTh,
-					NameEn:  location.Tambon.NameEn,
-					ZipCode: location.Tambon.ZipCode,
-				},
-			})
-		}
-	}
-
-	return result, nil
+	return append([]models.CompleteLocationData(nil), index.locationsByZip[zipCode]...), nil
 }
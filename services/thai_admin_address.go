@@ -0,0 +1,300 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"smlgoapi/models"
+)
+
+// ErrAddressIncomplete is returned when an address has no location to format
+var ErrAddressIncomplete = errors.New("address needs tambon_id, or tambon, amphure and province names, or a zip code")
+
+// bangkokProvinceID is the province whose divisions are แขวง/เขต instead of ตำบล/อำเภอ
+const bangkokProvinceID = 1
+
+const bangkokNameTh = "กรุงเทพมหานคร"
+
+// adminNamePrefixes are division labels people type in front of names; they are removed before
+// names are compared. Longer forms come first so "ตำบล" is not cut as "ต".
+var adminNamePrefixes = []string{
+	"จังหวัด", "อำเภอ", "ตำบล", "แขวง", "เขต", "จ.", "อ.", "ต.",
+	"changwat ", "amphoe ", "tambon ", "khwaeng ", "khet ",
+}
+
+var adminNameSuffixes = []string{" province", " subdistrict", " sub-district", " district"}
+
+// bangkokAliases are the short names of Bangkok in common use
+var bangkokAliases = map[string]bool{"กทม": true, "กทม.": true, "กรุงเทพ": true, "กรุงเทพฯ": true}
+
+// normalizeAdminName reduces a province, amphure or tambon name to a comparable form
+func normalizeAdminName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if bangkokAliases[name] {
+		return bangkokNameTh
+	}
+	for _, prefix := range adminNamePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			name = strings.TrimPrefix(name, prefix)
+			break
+		}
+	}
+	for _, suffix := range adminNameSuffixes {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return strings.Join(strings.Fields(name), "")
+}
+
+// FormatAddress resolves the location of an address against the administrative data and
+// renders it in Thai postal order, with an English block for international labels.
+// An address whose location cannot be matched is still formatted from the names given,
+// with Verified false and a warning.
+func (s *ThaiAdminService) FormatAddress(req models.AddressFormatRequest) (*models.AddressFormatResult, error) {
+	if req.TambonID == 0 && req.ZipCode == 0 && (req.Tambon == "" || req.Amphure == "" || req.Province == "") {
+		return nil, ErrAddressIncomplete
+	}
+	index, err := s.lookups()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.AddressFormatResult{}
+	location, warnings := s.resolveAddressLocation(index, req)
+	result.Warnings = warnings
+
+	if location != nil {
+		result.Verified = true
+		result.Location = location
+		if req.ZipCode != 0 && req.ZipCode != location.Tambon.ZipCode {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("zip code %05d differs from %05d on record for tambon %s; printed as given",
+				req.ZipCode, location.Tambon.ZipCode, location.Tambon.NameTh))
+		}
+	} else {
+		if req.Tambon == "" || req.Amphure == "" || req.Province == "" {
+			return nil, fmt.Errorf("%w: %s", ErrAddressIncomplete, strings.Join(warnings, "; "))
+		}
+		location = &models.CompleteLocationData{
+			Province: models.Province{NameTh: req.Province, NameEn: req.Province},
+			Amphure:  models.Amphure{NameTh: req.Amphure, NameEn: req.Amphure},
+			Tambon:   models.Tambon{NameTh: req.Tambon, NameEn: req.Tambon, ZipCode: req.ZipCode},
+		}
+		if normalizeAdminName(req.Province) == bangkokNameTh {
+			location.Province.ID = bangkokProvinceID
+		}
+	}
+
+	zipCode := location.Tambon.ZipCode
+	if req.ZipCode != 0 {
+		zipCode = req.ZipCode
+	}
+
+	result.Lines = thaiAddressLines(req, location, zipCode)
+	result.LinesEn = englishAddressLines(req, location, zipCode)
+	result.Text = strings.Join(result.Lines, "\n")
+	result.TextEn = strings.Join(result.LinesEn, "\n")
+	return result, nil
+}
+
+// resolveAddressLocation finds the single tambon the request describes. Names are matched
+// after normalizeAdminName; the zip code narrows the match and is ignored when nothing fits it.
+func (s *ThaiAdminService) resolveAddressLocation(index *thaiAdminIndex, req models.AddressFormatRequest) (*models.CompleteLocationData, []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	provinceByID, amphureByID := index.provinceByID, index.amphureByID
+
+	locate := func(t models.Tambon) *models.CompleteLocationData {
+		a := amphureByID[t.AmphureID]
+		p := provinceByID[a.ProvinceID]
+		return &models.CompleteLocationData{
+			Province: models.Province{ID: p.ID, NameTh: p.NameTh, NameEn: p.NameEn},
+			Amphure:  models.Amphure{ID: a.ID, NameTh: a.NameTh, NameEn: a.NameEn},
+			Tambon:   models.Tambon{ID: t.ID, NameTh: t.NameTh, NameEn: t.NameEn, ZipCode: t.ZipCode},
+		}
+	}
+
+	if req.TambonID != 0 {
+		if t, ok := index.tambonByID[req.TambonID]; ok {
+			return locate(t), nil
+		}
+		return nil, []string{fmt.Sprintf("tambon_id %d does not exist; formatted from the names given", req.TambonID)}
+	}
+
+	tambonName := normalizeAdminName(req.Tambon)
+	amphureName := normalizeAdminName(req.Amphure)
+	provinceName := normalizeAdminName(req.Province)
+
+	matches := func(t models.Tambon, useZip bool) bool {
+		if useZip && t.ZipCode != req.ZipCode {
+			return false
+		}
+		if tambonName != "" && tambonName != normalizeAdminName(t.NameTh) && tambonName != normalizeAdminName(t.NameEn) {
+			return false
+		}
+		a := amphureByID[t.AmphureID]
+		if req.AmphureID != 0 && a.ID != req.AmphureID {
+			return false
+		}
+		if amphureName != "" && amphureName != normalizeAdminName(a.NameTh) && amphureName != normalizeAdminName(a.NameEn) {
+			return false
+		}
+		p := provinceByID[a.ProvinceID]
+		if req.ProvinceID != 0 && p.ID != req.ProvinceID {
+			return false
+		}
+		return provinceName == "" || provinceName == normalizeAdminName(p.NameTh) || provinceName == normalizeAdminName(p.NameEn)
+	}
+
+	find := func(useZip bool) []models.Tambon {
+		candidates := s.tambonsData
+		if useZip {
+			candidates = index.tambonsByZip[req.ZipCode]
+		}
+		var found []models.Tambon
+		for _, t := range candidates {
+			if matches(t, useZip) {
+				found = append(found, t)
+			}
+		}
+		return found
+	}
+
+	found := find(req.ZipCode != 0)
+	if len(found) == 0 && req.ZipCode != 0 && tambonName != "" {
+		found = find(false)
+	}
+
+	switch len(found) {
+	case 1:
+		return locate(found[0]), nil
+	case 0:
+		return nil, []string{"location not found in the Thai administrative data; formatted from the names given"}
+	default:
+		return nil, []string{fmt.Sprintf("location matches %d tambons; give tambon_id or more of tambon, amphure, province and zip_code", len(found))}
+	}
+}
+
+// thaiAddressLines follows the Thailand Post order: recipient, house details, tambon and
+// amphure, then province and zip code. Bangkok uses แขวง/เขต and no จังหวัด label.
+func thaiAddressLines(req models.AddressFormatRequest, location *models.CompleteLocationData, zipCode int) []string {
+	labels := map[string]string{"moo": "หมู่ที่", "soi": "ซอย", "road": "ถนน", "tambon": "ตำบล", "amphure": "อำเภอ", "province": "จังหวัด"}
+	if req.Abbreviate {
+		labels = map[string]string{"moo": "ม.", "soi": "ซ.", "road": "ถ.", "tambon": "ต.", "amphure": "อ.", "province": "จ."}
+	}
+
+	var lines []string
+	lines = appendNonEmpty(lines, req.Name, req.Company)
+
+	lines = appendNonEmpty(lines, joinParts(" ",
+		req.HouseNo,
+		labelled("ห้อง ", req.Room),
+		labelled("ชั้น ", req.Floor),
+		req.Building,
+		req.Village,
+		labelled(labels["moo"]+" ", stripLabel(req.Moo, "หมู่ที่", "หมู่", "ม.")),
+		labelled(labels["soi"], stripLabel(req.Soi, "ซอย", "ซ.")),
+		labelled(labels["road"], stripLabel(req.Road, "ถนน", "ถ.")),
+	))
+
+	tambon := normalizeDisplayName(location.Tambon.NameTh)
+	amphure := normalizeDisplayName(location.Amphure.NameTh)
+	if location.Province.ID == bangkokProvinceID {
+		lines = appendNonEmpty(lines, joinParts(" ", "แขวง"+tambon, "เขต"+amphure))
+		lines = appendNonEmpty(lines, joinParts(" ", bangkokNameTh, zipString(zipCode)))
+	} else {
+		lines = appendNonEmpty(lines, joinParts(" ", labels["tambon"]+tambon, labels["amphure"]+amphure))
+		lines = appendNonEmpty(lines, joinParts(" ", labels["province"]+normalizeDisplayName(location.Province.NameTh), zipString(zipCode)))
+	}
+
+	lines = appendNonEmpty(lines, labelled("โทร. ", req.Phone))
+	return lines
+}
+
+// englishAddressLines renders the address for international labels, ending with the country
+func englishAddressLines(req models.AddressFormatRequest, location *models.CompleteLocationData, zipCode int) []string {
+	var lines []string
+	lines = appendNonEmpty(lines, firstNonEmpty(req.NameEn, req.Name), firstNonEmpty(req.CompanyEn, req.Company))
+
+	lines = appendNonEmpty(lines, joinParts(", ",
+		joinParts(" ", labelled("Room ", req.Room), labelled("Floor ", req.Floor)),
+		firstNonEmpty(req.BuildingEn, req.Building),
+		joinParts(" ", req.HouseNo, labelled("Moo ", stripLabel(req.Moo, "หมู่ที่", "หมู่", "ม.", "Moo"))),
+		firstNonEmpty(req.VillageEn, req.Village),
+		labelled("Soi ", stripLabel(firstNonEmpty(req.SoiEn, req.Soi), "ซอย", "ซ.", "Soi")),
+		suffixed(stripLabel(firstNonEmpty(req.RoadEn, req.Road), "ถนน", "ถ."), " Road"),
+	))
+
+	tambon := stripLabel(location.Tambon.NameEn, "Khwaeng", "Tambon")
+	amphure := stripLabel(location.Amphure.NameEn, "Khet", "Amphoe")
+	lines = appendNonEmpty(lines, joinParts(", ", suffixed(tambon, " Subdistrict"), suffixed(amphure, " District")))
+	lines = appendNonEmpty(lines, joinParts(" ", location.Province.NameEn, zipString(zipCode)))
+	lines = append(lines, "THAILAND")
+	lines = appendNonEmpty(lines, labelled("Tel. ", req.Phone))
+	return lines
+}
+
+// normalizeDisplayName removes a division label already part of a stored or typed name,
+// so the label is not printed twice
+func normalizeDisplayName(name string) string {
+	return stripLabel(name, "จังหวัด", "อำเภอ", "ตำบล", "แขวง", "เขต", "จ.", "อ.", "ต.")
+}
+
+// stripLabel removes the first matching label from the start of value
+func stripLabel(value string, labels ...string) string {
+	value = strings.TrimSpace(value)
+	for _, label := range labels {
+		if strings.HasPrefix(value, label) {
+			return strings.TrimSpace(strings.TrimPrefix(value, label))
+		}
+	}
+	return value
+}
+
+func labelled(label, value string) string {
+	if value = strings.TrimSpace(value); value == "" {
+		return ""
+	}
+	return label + value
+}
+
+func suffixed(value, suffix string) string {
+	if value = strings.TrimSpace(value); value == "" || strings.HasSuffix(strings.ToLower(value), strings.ToLower(suffix)) {
+		return value
+	}
+	return value + suffix
+}
+
+func joinParts(sep string, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+func appendNonEmpty(lines []string, values ...string) []string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			lines = append(lines, value)
+		}
+	}
+	return lines
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func zipString(zipCode int) string {
+	if zipCode == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%05d", zipCode)
+}
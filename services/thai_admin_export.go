@@ -0,0 +1,186 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"smlgoapi/models"
+)
+
+// Formats of /v1/thai-admin/export
+const (
+	ThaiAdminExportJSON = "json"
+	ThaiAdminExportCSV  = "csv"
+)
+
+// ThaiAdminExport is a rendered dataset file together with its identifiers
+type ThaiAdminExport struct {
+	Format    string
+	Version   string // dataset version, the same for every format
+	UpdatedAt string
+	Checksum  string // hex SHA-256 of Body
+	Body      []byte
+}
+
+// thaiAdminExports caches rendered exports; UpdateData clears it
+type thaiAdminExports struct {
+	mu      sync.Mutex
+	formats map[string]*ThaiAdminExport
+}
+
+// Export renders the complete province, amphure and tambon hierarchy in the given format.
+// The result is cached until the data changes.
+func (s *ThaiAdminService) Export(format string) (*ThaiAdminExport, error) {
+	if format != ThaiAdminExportJSON && format != ThaiAdminExportCSV {
+		return nil, fmt.Errorf("unsupported format '%s': use json or csv", format)
+	}
+
+	s.exports.mu.Lock()
+	defer s.exports.mu.Unlock()
+
+	if export, ok := s.exports.formats[format]; ok {
+		return export, nil
+	}
+
+	dataset, err := s.buildDataset()
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if format == ThaiAdminExportCSV {
+		body, err = thaiAdminCSV(dataset)
+	} else {
+		body, err = json.Marshal(dataset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s export: %w", format, err)
+	}
+
+	sum := sha256.Sum256(body)
+	export := &ThaiAdminExport{
+		Format:    format,
+		Version:   dataset.Version,
+		UpdatedAt: dataset.UpdatedAt,
+		Checksum:  hex.EncodeToString(sum[:]),
+		Body:      body,
+	}
+	if s.exports.formats == nil {
+		s.exports.formats = make(map[string]*ThaiAdminExport)
+	}
+	s.exports.formats[format] = export
+	return export, nil
+}
+
+// buildDataset nests tambons under amphures under provinces, each level sorted by id
+func (s *ThaiAdminService) buildDataset() (*models.ThaiAdminDataset, error) {
+	if err := s.loadProvinces(); err != nil {
+		return nil, err
+	}
+	if err := s.loadAmphures(); err != nil {
+		return nil, err
+	}
+	if err := s.loadTambons(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dataset := &models.ThaiAdminDataset{
+		Counts: models.ThaiAdminCounts{
+			Provinces: len(s.provincesData),
+			Amphures:  len(s.amphuresData),
+			Tambons:   len(s.tambonsData),
+		},
+	}
+
+	tambonsByAmphure := make(map[int][]models.TambonLeaf)
+	for _, t := range s.tambonsData {
+		tambonsByAmphure[t.AmphureID] = append(tambonsByAmphure[t.AmphureID], models.TambonLeaf{
+			ID: t.ID, NameTh: t.NameTh, NameEn: t.NameEn, ZipCode: t.ZipCode,
+		})
+		dataset.UpdatedAt = maxString(dataset.UpdatedAt, t.UpdatedAt)
+	}
+
+	amphuresByProvince := make(map[int][]models.AmphureTree)
+	for _, a := range s.amphuresData {
+		tambons := tambonsByAmphure[a.ID]
+		sort.Slice(tambons, func(i, j int) bool { return tambons[i].ID < tambons[j].ID })
+		if tambons == nil {
+			tambons = []models.TambonLeaf{}
+		}
+		amphuresByProvince[a.ProvinceID] = append(amphuresByProvince[a.ProvinceID], models.AmphureTree{
+			ID: a.ID, NameTh: a.NameTh, NameEn: a.NameEn, Tambons: tambons,
+		})
+		dataset.UpdatedAt = maxString(dataset.UpdatedAt, a.UpdatedAt)
+	}
+
+	dataset.Provinces = make([]models.ProvinceTree, 0, len(s.provincesData))
+	for _, p := range s.provincesData {
+		amphures := amphuresByProvince[p.ID]
+		sort.Slice(amphures, func(i, j int) bool { return amphures[i].ID < amphures[j].ID })
+		if amphures == nil {
+			amphures = []models.AmphureTree{}
+		}
+		dataset.Provinces = append(dataset.Provinces, models.ProvinceTree{
+			ID: p.ID, NameTh: p.NameTh, NameEn: p.NameEn, GeographyID: p.GeographyID, Amphures: amphures,
+		})
+		dataset.UpdatedAt = maxString(dataset.UpdatedAt, p.UpdatedAt)
+	}
+	sort.Slice(dataset.Provinces, func(i, j int) bool { return dataset.Provinces[i].ID < dataset.Provinces[j].ID })
+
+	// The version is derived from the content, so it only changes when a record does
+	content, err := json.Marshal(dataset.Provinces)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	dataset.Version = hex.EncodeToString(sum[:])[:12]
+	return dataset, nil
+}
+
+// thaiAdminCSV writes one row per tambon with its amphure and province. Amphures without
+// tambons get a row with empty tambon columns so every amphure is present.
+func thaiAdminCSV(dataset *models.ThaiAdminDataset) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{
+		"province_id", "province_name_th", "province_name_en",
+		"amphure_id", "amphure_name_th", "amphure_name_en",
+		"tambon_id", "tambon_name_th", "tambon_name_en", "zip_code",
+	})
+
+	for _, p := range dataset.Provinces {
+		for _, a := range p.Amphures {
+			prefix := []string{
+				strconv.Itoa(p.ID), p.NameTh, p.NameEn,
+				strconv.Itoa(a.ID), a.NameTh, a.NameEn,
+			}
+			if len(a.Tambons) == 0 {
+				w.Write(append(prefix, "", "", "", ""))
+				continue
+			}
+			for _, t := range a.Tambons {
+				w.Write(append(prefix, strconv.Itoa(t.ID), t.NameTh, t.NameEn, strconv.Itoa(t.ZipCode)))
+			}
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func maxString(a, b string) string {
+	if b > a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"smlgoapi/models"
+)
+
+// ErrBoundariesUnavailable is returned for reverse geocoding without a tambon boundaries file
+var ErrBoundariesUnavailable = errors.New("tambon boundaries are not available")
+
+// tambonBoundaries are the tambon polygons of the boundaries file, read on first use
+type tambonBoundaries struct {
+	mu         sync.Mutex
+	file       string
+	idProperty string
+	loaded     bool
+	shapes     []tambonShape
+}
+
+// tambonShape is one feature of the boundaries file. Each polygon is a list of rings of
+// [lng, lat] points; the first ring is the outline and the others are holes.
+type tambonShape struct {
+	tambonID                       int
+	minLng, minLat, maxLng, maxLat float64
+	polygons                       [][][][2]float64
+}
+
+// ReverseGeocode finds the tambon containing the point, with its amphure and province. It
+// returns nil when no tambon of the boundaries file contains it, e.g. for a point at sea.
+func (s *ThaiAdminService) ReverseGeocode(lat, lng float64) (*models.CompleteLocationData, error) {
+	shapes, err := s.boundaries.load()
+	if err != nil {
+		return nil, err
+	}
+	tambonID := 0
+	for i := range shapes {
+		if shapes[i].contains(lng, lat) {
+			tambonID = shapes[i].tambonID
+			break
+		}
+	}
+	if tambonID == 0 {
+		return nil, nil
+	}
+
+	index, err := s.lookups()
+	if err != nil {
+		return nil, err
+	}
+	location, ok := index.locationByTambon[tambonID]
+	if !ok {
+		// The boundaries name a tambon that an upload removed
+		return nil, nil
+	}
+	return &location, nil
+}
+
+func (b *tambonBoundaries) load() ([]tambonShape, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.loaded {
+		return b.shapes, nil
+	}
+
+	data, err := os.ReadFile(b.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s does not exist; set thai_admin.boundaries_file", ErrBoundariesUnavailable, b.file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tambon boundaries: %v", err)
+	}
+
+	var collection struct {
+		Features []struct {
+			Properties map[string]interface{} `json:"properties"`
+			Geometry   *struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse tambon boundaries GeoJSON: %v", err)
+	}
+
+	var shapes []tambonShape
+	skipped := 0
+	for _, feature := range collection.Features {
+		tambonID := boundaryTambonID(feature.Properties[b.idProperty])
+		if tambonID == 0 || feature.Geometry == nil {
+			skipped++
+			continue
+		}
+		var polygons [][][][]float64
+		switch feature.Geometry.Type {
+		case "Polygon":
+			var polygon [][][]float64
+			err = json.Unmarshal(feature.Geometry.Coordinates, &polygon)
+			polygons = [][][][]float64{polygon}
+		case "MultiPolygon":
+			err = json.Unmarshal(feature.Geometry.Coordinates, &polygons)
+		default:
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the boundary of tambon %d: %v", tambonID, err)
+		}
+		shapes = append(shapes, newTambonShape(tambonID, polygons))
+	}
+	if len(shapes) == 0 {
+		return nil, fmt.Errorf("%w: %s has no polygon with a %s property", ErrBoundariesUnavailable, b.file, b.idProperty)
+	}
+	if skipped > 0 {
+		log.Printf("⚠️ Tambon boundaries: skipped %d features without a %s or a polygon", skipped, b.idProperty)
+	}
+	log.Printf("🗺️ Loaded %d tambon boundaries from %s", len(shapes), b.file)
+
+	b.shapes, b.loaded = shapes, true
+	return shapes, nil
+}
+
+// boundaryTambonID reads a tambon ID property, either a number or a code whose digits are the ID
+func boundaryTambonID(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case string:
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, v)
+		id, _ := strconv.Atoi(digits)
+		return id
+	}
+	return 0
+}
+
+func newTambonShape(tambonID int, polygons [][][][]float64) tambonShape {
+	shape := tambonShape{tambonID: tambonID, minLng: 180, minLat: 90, maxLng: -180, maxLat: -90}
+	for _, polygon := range polygons {
+		rings := make([][][2]float64, 0, len(polygon))
+		for _, ring := range polygon {
+			points := make([][2]float64, 0, len(ring))
+			for _, point := range ring {
+				// Positions may carry an altitude after lng and lat
+				if len(point) < 2 {
+					continue
+				}
+				lng, lat := point[0], point[1]
+				points = append(points, [2]float64{lng, lat})
+				shape.minLng, shape.maxLng = min(shape.minLng, lng), max(shape.maxLng, lng)
+				shape.minLat, shape.maxLat = min(shape.minLat, lat), max(shape.maxLat, lat)
+			}
+			rings = append(rings, points)
+		}
+		if len(rings) > 0 {
+			shape.polygons = append(shape.polygons, rings)
+		}
+	}
+	return shape
+}
+
+// contains tells whether the point is inside the outline of one of the polygons and outside
+// its holes
+func (t *tambonShape) contains(lng, lat float64) bool {
+	if lng < t.minLng || lng > t.maxLng || lat < t.minLat || lat > t.maxLat {
+		return false
+	}
+	for _, rings := range t.polygons {
+		if !ringContains(rings[0], lng, lat) {
+			continue
+		}
+		inHole := false
+		for _, hole := range rings[1:] {
+			if ringContains(hole, lng, lat) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// ringContains casts a ray from the point towards higher longitudes and counts the edges it crosses
+func ringContains(ring [][2]float64, lng, lat float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a[1] > lat) != (b[1] > lat) && lng < (b[0]-a[0])*(lat-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"sync"
+
+	"smlgoapi/models"
+)
+
+// thaiAdminIndex holds the lookups by ID and zip code of one generation of the data, so address
+// forms do not scan every amphure or tambon on each request. It is built once, on first use;
+// UpdateData replaces it along with the data. Slices in it are shared and must be copied before
+// they are handed to callers, which fill in fields such as province_id.
+type thaiAdminIndex struct {
+	once               sync.Once
+	provinces          []models.Province // as listed by GetProvinces
+	provinceByID       map[int]models.Province
+	amphureByID        map[int]models.Amphure
+	amphuresByProvince map[int][]models.Amphure // as listed by GetAmphuresByProvinceID
+	tambonByID         map[int]models.Tambon
+	tambonsByAmphure   map[int][]models.Tambon // as listed by GetTambonsByAmphureAndProvince
+	tambonsByZip       map[int][]models.Tambon
+	locationsByZip     map[int][]models.CompleteLocationData
+	locationByTambon   map[int]models.CompleteLocationData
+}
+
+// lookups loads the data files if needed and returns the index of the data being served
+func (s *ThaiAdminService) lookups() (*thaiAdminIndex, error) {
+	for _, load := range []func() error{s.loadProvinces, s.loadAmphures, s.loadTambons, s.loadCompleteLocationData} {
+		if err := load(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	index := s.index
+	index.once.Do(func() {
+		index.build(s.provincesData, s.amphuresData, s.tambonsData, s.completeLocationData)
+	})
+	return index, nil
+}
+
+func (x *thaiAdminIndex) build(provinces []models.Province, amphures []models.Amphure, tambons []models.Tambon, locations []models.CompleteLocationData) {
+	x.provinces = make([]models.Province, 0, len(provinces))
+	x.provinceByID = make(map[int]models.Province, len(provinces))
+	for _, p := range provinces {
+		// Return only essential fields as specified in the docs
+		x.provinces = append(x.provinces, models.Province{ID: p.ID, NameTh: p.NameTh, NameEn: p.NameEn})
+		x.provinceByID[p.ID] = p
+	}
+
+	x.amphureByID = make(map[int]models.Amphure, len(amphures))
+	x.amphuresByProvince = make(map[int][]models.Amphure, len(provinces))
+	for _, a := range amphures {
+		x.amphureByID[a.ID] = a
+		x.amphuresByProvince[a.ProvinceID] = append(x.amphuresByProvince[a.ProvinceID],
+			models.Amphure{ID: a.ID, NameTh: a.NameTh, NameEn: a.NameEn})
+	}
+
+	x.tambonByID = make(map[int]models.Tambon, len(tambons))
+	x.tambonsByAmphure = make(map[int][]models.Tambon, len(amphures))
+	x.tambonsByZip = make(map[int][]models.Tambon)
+	for _, t := range tambons {
+		x.tambonByID[t.ID] = t
+		x.tambonsByAmphure[t.AmphureID] = append(x.tambonsByAmphure[t.AmphureID],
+			models.Tambon{ID: t.ID, NameTh: t.NameTh, NameEn: t.NameEn})
+		x.tambonsByZip[t.ZipCode] = append(x.tambonsByZip[t.ZipCode], t)
+	}
+
+	x.locationsByZip = make(map[int][]models.CompleteLocationData)
+	x.locationByTambon = make(map[int]models.CompleteLocationData, len(locations))
+	for _, l := range locations {
+		location := models.CompleteLocationData{
+			Province: models.Province{ID: l.Province.ID, NameTh: l.Province.NameTh, NameEn: l.Province.NameEn},
+			Amphure:  models.Amphure{ID: l.Amphure.ID, NameTh: l.Amphure.NameTh, NameEn: l.Amphure.NameEn},
+			Tambon:   models.Tambon{ID: l.Tambon.ID, NameTh: l.Tambon.NameTh, NameEn: l.Tambon.NameEn, ZipCode: l.Tambon.ZipCode},
+		}
+		x.locationsByZip[l.Tambon.ZipCode] = append(x.locationsByZip[l.Tambon.ZipCode], location)
+		x.locationByTambon[l.Tambon.ID] = location
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+)
+
+// newTestThaiAdminService serves the bundled files in provinces/, which the service reads from
+// the working directory
+func newTestThaiAdminService(tb testing.TB) *ThaiAdminService {
+	tb.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.Chdir(wd) })
+
+	s := NewThaiAdminService(config.ThaiAdminConfig{})
+	if _, err := s.lookups(); err != nil {
+		tb.Fatalf("failed to load the Thai admin data: %v", err)
+	}
+	return s
+}
+
+// scanAmphuresByProvinceID is the linear scan GetAmphuresByProvinceID did before the index
+func scanAmphuresByProvinceID(s *ThaiAdminService, provinceID int) []models.Amphure {
+	var result []models.Amphure
+	for _, amphure := range s.amphuresData {
+		if amphure.ProvinceID == provinceID {
+			result = append(result, models.Amphure{ID: amphure.ID, NameTh: amphure.NameTh, NameEn: amphure.NameEn})
+		}
+	}
+	return result
+}
+
+// scanByZipCode is the linear scan FindByZipCode did before the index
+func scanByZipCode(s *ThaiAdminService, zipCode int) []models.CompleteLocationData {
+	var result []models.CompleteLocationData
+	for _, location := range s.completeLocationData {
+		if location.Tambon.ZipCode == zipCode {
+			result = append(result, models.CompleteLocationData{
+				Province: models.Province{ID: location.Province.ID, NameTh: location.Province.NameTh, NameEn: location.Province.NameEn},
+				Amphure:  models.Amphure{ID: location.Amphure.ID, NameTh: location.Amphure.NameTh, NameEn: location.Amphure.NameEn},
+				Tambon: models.Tambon{ID: location.Tambon.ID, NameTh: location.Tambon.NameTh, NameEn: location.Tambon.NameEn,
+					ZipCode: location.Tambon.ZipCode},
+			})
+		}
+	}
+	return result
+}
+
+func TestThaiAdminIndexMatchesScan(t *testing.T) {
+	s := newTestThaiAdminService(t)
+
+	// Every province, plus one that does not exist
+	provinceIDs := []int{0}
+	for _, p := range s.provincesData {
+		provinceIDs = append(provinceIDs, p.ID)
+	}
+	for _, id := range provinceIDs {
+		got, err := s.GetAmphuresByProvinceID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := scanAmphuresByProvinceID(s, id); !reflect.DeepEqual(got, want) {
+			t.Errorf("GetAmphuresByProvinceID(%d) = %d amphures, the scan finds %d", id, len(got), len(want))
+		}
+	}
+
+	zipCodes := map[int]bool{99999: true}
+	for _, l := range s.completeLocationData {
+		zipCodes[l.Tambon.ZipCode] = true
+	}
+	for zipCode := range zipCodes {
+		got, err := s.FindByZipCode(zipCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := scanByZipCode(s, zipCode); !reflect.DeepEqual(got, want) {
+			t.Errorf("FindByZipCode(%d) = %d locations, the scan finds %d", zipCode, len(got), len(want))
+		}
+	}
+}
+
+func BenchmarkFindByZipCode(b *testing.B) {
+	s := newTestThaiAdminService(b)
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s.FindByZipCode(10200)
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scanByZipCode(s, 10200)
+		}
+	})
+}
+
+func BenchmarkGetAmphuresByProvinceID(b *testing.B) {
+	s := newTestThaiAdminService(b)
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s.GetAmphuresByProvinceID(1)
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scanAmphuresByProvinceID(s, 1)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"smlgoapi/models"
+)
+
+var (
+	// ErrThaiAdminUpstreamMissing is returned for a refresh without thai_admin.upstream.url
+	ErrThaiAdminUpstreamMissing = errors.New("thai_admin.upstream.url is not set")
+	// ErrThaiAdminUpstream wraps the failures to download or decode the upstream files
+	ErrThaiAdminUpstream = errors.New("failed to read upstream Thai administrative data")
+)
+
+// maxUpstreamFileBytes bounds one downloaded file; api_tambon.json is about 2 MB
+const maxUpstreamFileBytes = 64 << 20
+
+// RefreshFromUpstream downloads the data files from thai_admin.upstream and swaps them in like an
+// upload with persist, so the files in provinces/ are rewritten too. Data equal to what is being
+// served is reported as UpToDate and changes nothing. A refresh that removes more than
+// max_removed_percent of any level is refused, as that is more likely a broken upstream.
+func (s *ThaiAdminService) RefreshFromUpstream(ctx context.Context, dryRun bool) (*models.ThaiAdminUploadResult, error) {
+	if s.upstream.URL == "" {
+		return nil, ErrThaiAdminUpstreamMissing
+	}
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	var req models.ThaiAdminUploadRequest
+	files := []struct {
+		name string
+		into interface{}
+	}{
+		{"api_province.json", &req.Provinces},
+		{"api_amphure.json", &req.Amphures},
+		{"api_tambon.json", &req.Tambons},
+	}
+	for _, file := range files {
+		if err := s.fetchUpstreamFile(ctx, file.name, file.into); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate and diff first; only a change that passes is applied
+	result, err := s.updateData(req, true, false, ThaiAdminSourceUpstream)
+	if err != nil || len(result.Errors) > 0 {
+		return result, err
+	}
+	for _, level := range []struct {
+		name string
+		diff models.RecordDiff
+	}{
+		{"provinces", result.Diff.Provinces},
+		{"amphures", result.Diff.Amphures},
+		{"tambons", result.Diff.Tambons},
+	} {
+		if level.diff.Before > 0 && len(level.diff.Removed)*100 > level.diff.Before*s.upstream.MaxRemovedPercent {
+			result.Errors = append(result.Errors, fmt.Sprintf("upstream removes %d of %d %s, more than max_removed_percent %d%%",
+				len(level.diff.Removed), level.diff.Before, level.name, s.upstream.MaxRemovedPercent))
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+	if diffEmpty(result.Diff) {
+		result.UpToDate = true
+		if export, err := s.Export(ThaiAdminExportJSON); err == nil {
+			result.Version = export.Version
+		}
+		return result, nil
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	result, err = s.updateData(req, false, true, ThaiAdminSourceUpstream)
+	if err == nil && result.Applied {
+		log.Printf("🗺️ Thai administrative data refreshed from %s to version %s", s.upstream.URL, result.Version)
+	}
+	return result, err
+}
+
+// fetchUpstreamFile decodes the JSON array of one upstream file into v
+func (s *ThaiAdminService) fetchUpstreamFile(ctx context.Context, name string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.upstream.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	url := strings.TrimRight(s.upstream.URL, "/") + "/" + name
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrThaiAdminUpstream, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrThaiAdminUpstream, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s answered %s", ErrThaiAdminUpstream, url, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxUpstreamFileBytes+1))
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrThaiAdminUpstream, url, err)
+	}
+	if len(body) > maxUpstreamFileBytes {
+		return fmt.Errorf("%w: %s is larger than %d MB", ErrThaiAdminUpstream, url, maxUpstreamFileBytes>>20)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: %s is not a JSON array of records: %v", ErrThaiAdminUpstream, url, err)
+	}
+	return nil
+}
+
+func diffEmpty(diff models.ThaiAdminDiff) bool {
+	for _, d := range []models.RecordDiff{diff.Provinces, diff.Amphures, diff.Tambons} {
+		if len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// DatasetInfo identifies the data being served, for /health
+func (s *ThaiAdminService) DatasetInfo() (*models.ThaiAdminDatasetInfo, error) {
+	export, err := s.Export(ThaiAdminExportJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	info := &models.ThaiAdminDatasetInfo{
+		Version:   export.Version,
+		UpdatedAt: export.UpdatedAt,
+		Counts: models.ThaiAdminCounts{
+			Provinces: len(s.provincesData),
+			Amphures:  len(s.amphuresData),
+			Tambons:   len(s.tambonsData),
+		},
+		Source: s.source,
+	}
+	if !s.replacedAt.IsZero() {
+		replacedAt := s.replacedAt
+		info.ReplacedAt = &replacedAt
+	}
+	return info, nil
+}
+
+// LastModified is when the data being served last changed: the last upload or refresh, or else
+// when the data files were written
+func (s *ThaiAdminService) LastModified() time.Time {
+	s.mu.RLock()
+	replacedAt := s.replacedAt
+	s.mu.RUnlock()
+	if !replacedAt.IsZero() {
+		return replacedAt
+	}
+
+	var modified time.Time
+	for _, name := range []string{"api_province.json", "api_amphure.json", "api_tambon.json"} {
+		if info, err := os.Stat(filepath.Join(thaiAdminDir, name)); err == nil && info.ModTime().After(modified) {
+			modified = info.ModTime()
+		}
+	}
+	return modified
+}
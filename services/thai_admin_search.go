@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"smlgoapi/models"
+)
+
+// Levels of the Thai administrative data
+const (
+	ThaiAdminLevelProvince = "province"
+	ThaiAdminLevelAmphure  = "amphure"
+	ThaiAdminLevelTambon   = "tambon"
+)
+
+var thaiAdminLevelRank = map[string]int{ThaiAdminLevelProvince: 0, ThaiAdminLevelAmphure: 1, ThaiAdminLevelTambon: 2}
+
+// ErrInvalidAdminSearch is returned for a search the request itself makes impossible
+var ErrInvalidAdminSearch = errors.New("invalid search")
+
+const (
+	defaultAdminSearchLimit = 20
+	maxAdminSearchLimit     = 100
+	// minAdminSearchScore keeps names that only share a few letters with the query out of the results
+	minAdminSearchScore = 0.6
+)
+
+// thaiMarkFolder drops tone marks, the thanthakhat and the mai taikhu, which are often mistyped
+// or left out, and the punctuation of romanized names ("Ko Samui", "Ko-Samui")
+var thaiMarkFolder = strings.NewReplacer(
+	"่", "", "้", "", "๊", "", "๋", "", "์", "", "็", "",
+	"-", "", ".", "", "'", "",
+)
+
+// SearchAdminAreas finds provinces, amphures and tambons whose Thai or English name resembles the
+// query. Names are compared after normalizeAdminName, so labels such as "อ." or "Khet" and spaces
+// do not matter; an exact match scores 1, a match without tone marks 0.95, a prefix at least 0.85,
+// a part of the name at least 0.7, and a misspelling by how many letters are off.
+func (s *ThaiAdminService) SearchAdminAreas(req models.ThaiAdminSearchRequest) ([]models.ThaiAdminSearchResult, error) {
+	query := normalizeAdminName(req.Query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: query is empty", ErrInvalidAdminSearch)
+	}
+	levels := map[string]bool{}
+	for _, level := range req.Levels {
+		if _, ok := thaiAdminLevelRank[level]; !ok {
+			return nil, fmt.Errorf("%w: unknown level %q; use province, amphure or tambon", ErrInvalidAdminSearch, level)
+		}
+		levels[level] = true
+	}
+	if len(levels) == 0 {
+		levels = map[string]bool{ThaiAdminLevelProvince: true, ThaiAdminLevelAmphure: true, ThaiAdminLevelTambon: true}
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultAdminSearchLimit
+	}
+	if limit > maxAdminSearchLimit {
+		limit = maxAdminSearchLimit
+	}
+
+	index, err := s.lookups()
+	if err != nil {
+		return nil, err
+	}
+	provinceByID, amphureByID := index.provinceByID, index.amphureByID
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []models.ThaiAdminSearchResult
+	add := func(level string, id int, nameTh, nameEn string, zipCode int, province *models.Province, amphure *models.Amphure) {
+		score := math.Max(adminNameScore(query, nameTh), adminNameScore(query, nameEn))
+		if score < minAdminSearchScore {
+			return
+		}
+		results = append(results, models.ThaiAdminSearchResult{
+			Level:    level,
+			ID:       id,
+			NameTh:   nameTh,
+			NameEn:   nameEn,
+			ZipCode:  zipCode,
+			Score:    math.Round(score*1000) / 1000,
+			Province: province,
+			Amphure:  amphure,
+		})
+	}
+	parentProvince := func(id int) *models.Province {
+		p := provinceByID[id]
+		return &models.Province{ID: p.ID, NameTh: p.NameTh, NameEn: p.NameEn}
+	}
+
+	if levels[ThaiAdminLevelProvince] {
+		for _, p := range s.provincesData {
+			if req.ProvinceID == 0 || p.ID == req.ProvinceID {
+				add(ThaiAdminLevelProvince, p.ID, p.NameTh, p.NameEn, 0, nil, nil)
+			}
+		}
+	}
+	if levels[ThaiAdminLevelAmphure] {
+		for _, a := range s.amphuresData {
+			if req.ProvinceID == 0 || a.ProvinceID == req.ProvinceID {
+				add(ThaiAdminLevelAmphure, a.ID, a.NameTh, a.NameEn, 0, parentProvince(a.ProvinceID), nil)
+			}
+		}
+	}
+	if levels[ThaiAdminLevelTambon] {
+		for _, t := range s.tambonsData {
+			a := amphureByID[t.AmphureID]
+			if req.ProvinceID == 0 || a.ProvinceID == req.ProvinceID {
+				add(ThaiAdminLevelTambon, t.ID, t.NameTh, t.NameEn, t.ZipCode, parentProvince(a.ProvinceID),
+					&models.Amphure{ID: a.ID, NameTh: a.NameTh, NameEn: a.NameEn, ProvinceID: a.ProvinceID})
+			}
+		}
+	}
+
+	// Best first; on a tie the larger area, then the lower ID
+	sort.Slice(results, func(i, k int) bool {
+		if results[i].Score != results[k].Score {
+			return results[i].Score > results[k].Score
+		}
+		if results[i].Level != results[k].Level {
+			return thaiAdminLevelRank[results[i].Level] < thaiAdminLevelRank[results[k].Level]
+		}
+		return results[i].ID < results[k].ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// adminNameScore rates how well a normalized query matches a name, from 0 to 1
+func adminNameScore(query, name string) float64 {
+	name = normalizeAdminName(name)
+	if name == "" {
+		return 0
+	}
+	if query == name {
+		return 1
+	}
+
+	query, name = thaiMarkFolder.Replace(query), thaiMarkFolder.Replace(name)
+	queryLen, nameLen := utf8.RuneCountInString(query), utf8.RuneCountInString(name)
+	if queryLen == 0 || nameLen == 0 {
+		return 0
+	}
+	coverage := float64(queryLen) / float64(nameLen)
+	switch {
+	case query == name:
+		return 0.95
+	case strings.HasPrefix(name, query):
+		return 0.85 + 0.1*coverage
+	case strings.Contains(name, query):
+		return 0.7 + 0.1*coverage
+	}
+	// Beyond a third of the letters the score is below minAdminSearchScore anyway
+	longest := max(queryLen, nameLen)
+	distance := levenshtein([]rune(query), []rune(name), longest/3+1)
+	return 0.9 * (1 - float64(distance)/float64(longest))
+}
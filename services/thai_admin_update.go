@@ -0,0 +1,315 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"smlgoapi/models"
+)
+
+// maxUploadErrors caps the validation errors reported for one upload
+const maxUploadErrors = 50
+
+// thaiAdminDir holds the JSON files the service loads at startup
+const thaiAdminDir = "provinces"
+
+// UpdateData validates replacement data, compares it with the data being served and, unless
+// dryRun is set or validation fails, swaps it in. With persist the data files are rewritten so
+// the update survives a restart.
+func (s *ThaiAdminService) UpdateData(req models.ThaiAdminUploadRequest, dryRun, persist bool) (*models.ThaiAdminUploadResult, error) {
+	return s.updateData(req, dryRun, persist, ThaiAdminSourceUpload)
+}
+
+// updateData is UpdateData recording source as the origin of the data once it is swapped in
+func (s *ThaiAdminService) updateData(req models.ThaiAdminUploadRequest, dryRun, persist bool, source string) (*models.ThaiAdminUploadResult, error) {
+	if err := s.loadProvinces(); err != nil {
+		return nil, err
+	}
+	if err := s.loadAmphures(); err != nil {
+		return nil, err
+	}
+	if err := s.loadTambons(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	provinces, amphures, tambons := s.provincesData, s.amphuresData, s.tambonsData
+	if req.Provinces != nil {
+		provinces = req.Provinces
+	}
+	if req.Amphures != nil {
+		amphures = req.Amphures
+	}
+	if req.Tambons != nil {
+		tambons = req.Tambons
+	}
+
+	// Problems the served data already has (the bundled files contain tambons of a missing amphure)
+	// do not block an upload that leaves them untouched; they are reported as warnings
+	existing := make(map[string]bool)
+	for _, msg := range validateThaiAdminData(s.provincesData, s.amphuresData, s.tambonsData) {
+		existing[msg] = true
+	}
+
+	result := &models.ThaiAdminUploadResult{
+		Diff: models.ThaiAdminDiff{
+			Provinces: diffRecords(provinceKeys(s.provincesData), provinceKeys(provinces)),
+			Amphures:  diffRecords(amphureKeys(s.amphuresData), amphureKeys(amphures)),
+			Tambons:   diffRecords(tambonKeys(s.tambonsData), tambonKeys(tambons)),
+		},
+	}
+	for _, msg := range validateThaiAdminData(provinces, amphures, tambons) {
+		if existing[msg] {
+			result.Warnings = append(result.Warnings, msg)
+		} else {
+			result.Errors = append(result.Errors, msg)
+		}
+	}
+	if dryRun || len(result.Errors) > 0 {
+		s.mu.Unlock()
+		return result, nil
+	}
+
+	s.provincesData, s.amphuresData, s.tambonsData = provinces, amphures, tambons
+	s.completeLocationData = buildCompleteLocations(provinces, amphures, tambons)
+	s.completeLocationLoaded = true
+	s.source, s.replacedAt = source, time.Now()
+	s.index = &thaiAdminIndex{}
+	s.mu.Unlock()
+	result.Applied = true
+
+	// Cleared after the swap, so an export rendered from the old data cannot stay cached
+	s.exports.mu.Lock()
+	s.exports.formats = nil
+	s.exports.mu.Unlock()
+
+	log.Printf("🗺️ Thai administrative data replaced: %d provinces, %d amphures, %d tambons",
+		len(provinces), len(amphures), len(tambons))
+
+	if persist {
+		if err := persistThaiAdminData(provinces, amphures, tambons); err != nil {
+			// The new data is already being served; report that it will not survive a restart
+			log.Printf("⚠️ Failed to write Thai administrative data files: %v", err)
+			result.Errors = append(result.Errors, "data applied but not saved to disk: "+err.Error())
+		} else {
+			result.Persisted = true
+		}
+	}
+
+	if export, err := s.Export(ThaiAdminExportJSON); err == nil {
+		result.Version = export.Version
+	}
+	return result, nil
+}
+
+// validateThaiAdminData checks ids, names, zip codes and parent references
+func validateThaiAdminData(provinces []models.Province, amphures []models.Amphure, tambons []models.Tambon) []string {
+	var errs []string
+	add := func(format string, args ...interface{}) {
+		if len(errs) < maxUploadErrors {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	provinceIDs := make(map[int]bool, len(provinces))
+	for i, p := range provinces {
+		switch {
+		case p.ID <= 0:
+			add("provinces[%d]: id must be positive", i)
+		case provinceIDs[p.ID]:
+			add("provinces[%d]: duplicate id %d", i, p.ID)
+		}
+		if p.NameTh == "" {
+			add("provinces[%d] (id %d): name_th is required", i, p.ID)
+		}
+		provinceIDs[p.ID] = true
+	}
+
+	amphureIDs := make(map[int]bool, len(amphures))
+	for i, a := range amphures {
+		switch {
+		case a.ID <= 0:
+			add("amphures[%d]: id must be positive", i)
+		case amphureIDs[a.ID]:
+			add("amphures[%d]: duplicate id %d", i, a.ID)
+		}
+		if a.NameTh == "" {
+			add("amphures[%d] (id %d): name_th is required", i, a.ID)
+		}
+		if !provinceIDs[a.ProvinceID] {
+			add("amphures[%d] (id %d): province_id %d does not exist", i, a.ID, a.ProvinceID)
+		}
+		amphureIDs[a.ID] = true
+	}
+
+	tambonIDs := make(map[int]bool, len(tambons))
+	for i, t := range tambons {
+		switch {
+		case t.ID <= 0:
+			add("tambons[%d]: id must be positive", i)
+		case tambonIDs[t.ID]:
+			add("tambons[%d]: duplicate id %d", i, t.ID)
+		}
+		if t.NameTh == "" {
+			add("tambons[%d] (id %d): name_th is required", i, t.ID)
+		}
+		if t.ZipCode < 10000 || t.ZipCode > 99999 {
+			add("tambons[%d] (id %d): zip_code %d is not a 5-digit postal code", i, t.ID, t.ZipCode)
+		}
+		if !amphureIDs[t.AmphureID] {
+			add("tambons[%d] (id %d): amphure_id %d does not exist", i, t.ID, t.AmphureID)
+		}
+		tambonIDs[t.ID] = true
+	}
+
+	if len(provinces) == 0 || len(amphures) == 0 || len(tambons) == 0 {
+		add("provinces, amphures and tambons must not be empty")
+	}
+	return errs
+}
+
+// The *Keys functions reduce records to the fields that count as a change in the diff
+
+func provinceKeys(provinces []models.Province) map[int]string {
+	keys := make(map[int]string, len(provinces))
+	for _, p := range provinces {
+		keys[p.ID] = fmt.Sprintf("%s|%s|%d", p.NameTh, p.NameEn, p.GeographyID)
+	}
+	return keys
+}
+
+func amphureKeys(amphures []models.Amphure) map[int]string {
+	keys := make(map[int]string, len(amphures))
+	for _, a := range amphures {
+		keys[a.ID] = fmt.Sprintf("%s|%s|%d", a.NameTh, a.NameEn, a.ProvinceID)
+	}
+	return keys
+}
+
+func tambonKeys(tambons []models.Tambon) map[int]string {
+	keys := make(map[int]string, len(tambons))
+	for _, t := range tambons {
+		keys[t.ID] = fmt.Sprintf("%s|%s|%d|%d", t.NameTh, t.NameEn, t.ZipCode, t.AmphureID)
+	}
+	return keys
+}
+
+// diffRecords compares two id -> key maps
+func diffRecords(before, after map[int]string) models.RecordDiff {
+	diff := models.RecordDiff{
+		Before:  len(before),
+		After:   len(after),
+		Added:   []int{},
+		Removed: []int{},
+		Changed: []int{},
+	}
+	for id, key := range after {
+		old, ok := before[id]
+		if !ok {
+			diff.Added = append(diff.Added, id)
+		} else if old != key {
+			diff.Changed = append(diff.Changed, id)
+		}
+	}
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+	sort.Ints(diff.Added)
+	sort.Ints(diff.Removed)
+	sort.Ints(diff.Changed)
+	return diff
+}
+
+// buildCompleteLocations joins tambons with their amphure and province for zip code lookups
+func buildCompleteLocations(provinces []models.Province, amphures []models.Amphure, tambons []models.Tambon) []models.CompleteLocationData {
+	provinceByID := make(map[int]models.Province, len(provinces))
+	for _, p := range provinces {
+		provinceByID[p.ID] = p
+	}
+	amphureByID := make(map[int]models.Amphure, len(amphures))
+	for _, a := range amphures {
+		amphureByID[a.ID] = a
+	}
+
+	locations := make([]models.CompleteLocationData, 0, len(tambons))
+	for _, t := range tambons {
+		a := amphureByID[t.AmphureID]
+		p := provinceByID[a.ProvinceID]
+		locations = append(locations, models.CompleteLocationData{
+			Province: models.Province{ID: p.ID, NameTh: p.NameTh, NameEn: p.NameEn},
+			Amphure:  models.Amphure{ID: a.ID, NameTh: a.NameTh, NameEn: a.NameEn},
+			Tambon:   models.Tambon{ID: t.ID, NameTh: t.NameTh, NameEn: t.NameEn, ZipCode: t.ZipCode},
+		})
+	}
+	return locations
+}
+
+// persistThaiAdminData rewrites the data files read at startup, including the denormalized
+// tambon file used for zip code lookups
+func persistThaiAdminData(provinces []models.Province, amphures []models.Amphure, tambons []models.Tambon) error {
+	provinceByID := make(map[int]models.Province, len(provinces))
+	for _, p := range provinces {
+		provinceByID[p.ID] = p
+	}
+	amphureByID := make(map[int]models.Amphure, len(amphures))
+	for _, a := range amphures {
+		amphureByID[a.ID] = a
+	}
+
+	nested := make([]models.TambonWithNested, 0, len(tambons))
+	for _, t := range tambons {
+		a := amphureByID[t.AmphureID]
+		p := provinceByID[a.ProvinceID]
+		nested = append(nested, models.TambonWithNested{
+			ID: t.ID, ZipCode: t.ZipCode, NameTh: t.NameTh, NameEn: t.NameEn, AmphureID: t.AmphureID,
+			CreatedAt: t.CreatedAt, UpdatedAt: t.UpdatedAt, DeletedAt: t.DeletedAt,
+			Amphure: models.AmphureWithNested{
+				ID: a.ID, NameTh: a.NameTh, NameEn: a.NameEn, ProvinceID: a.ProvinceID,
+				CreatedAt: a.CreatedAt, UpdatedAt: a.UpdatedAt, DeletedAt: a.DeletedAt,
+				Province: models.ProvinceNested{
+					ID: p.ID, NameTh: p.NameTh, NameEn: p.NameEn, GeographyID: p.GeographyID,
+					CreatedAt: p.CreatedAt, UpdatedAt: p.UpdatedAt, DeletedAt: p.DeletedAt,
+				},
+			},
+		})
+	}
+
+	files := map[string]interface{}{
+		"api_province.json": provinces,
+		"api_amphure.json":  amphures,
+		"api_tambon.json":   tambons,
+		"api_revert_tambon_with_amphure_province.json": nested,
+	}
+	for name, data := range files {
+		if err := writeJSONFileAtomic(filepath.Join(thaiAdminDir, name), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSONFileAtomic writes through a temporary file and rename, so a crash never leaves a
+// half-written file behind
+func writeJSONFileAtomic(path string, data interface{}) error {
+	content, err := json.MarshalIndent(data, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
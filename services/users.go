@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"smlgoapi/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials is returned when a username/password pair does not match an active user
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// UserService manages API user accounts stored in the PostgreSQL api_users table
+type UserService struct {
+	postgreSQLService *PostgreSQLService
+}
+
+// NewUserService creates a new user service
+func NewUserService(postgreSQLService *PostgreSQLService) *UserService {
+	return &UserService{postgreSQLService: postgreSQLService}
+}
+
+// EnsureSchema creates the api_users table if it does not exist.
+// Password hashes are bcrypt, so pgcrypto's crypt(password, gen_salt('bf')) can seed the first admin.
+func (s *UserService) EnsureSchema(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS api_users (
+			id            SERIAL PRIMARY KEY,
+			username      TEXT NOT NULL UNIQUE,
+			password_hash TEXT NOT NULL,
+			role          TEXT NOT NULL DEFAULT 'viewer',
+			active        BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`
+
+	if _, err := s.postgreSQLService.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create api_users table: %w", err)
+	}
+	return nil
+}
+
+// Authenticate verifies a username and password and returns the active user
+func (s *UserService) Authenticate(ctx context.Context, username, password string) (*models.User, error) {
+	query := `
+		SELECT id, username, role, active, created_at, password_hash
+		FROM api_users
+		WHERE username = $1 AND active = TRUE`
+
+	var user models.User
+	var passwordHash string
+	err := s.postgreSQLService.db.QueryRowContext(ctx, query, strings.TrimSpace(username)).
+		Scan(&user.ID, &user.Username, &user.Role, &user.Active, &user.CreatedAt, &passwordHash)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &user, nil
+}
+
+// GetActiveUser loads an active user by id, so refreshed sessions pick up role changes and deactivation
+func (s *UserService) GetActiveUser(ctx context.Context, id int) (*models.User, error) {
+	query := `
+		SELECT id, username, role, active, created_at
+		FROM api_users
+		WHERE id = $1 AND active = TRUE`
+
+	user, err := scanUser(s.postgreSQLService.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	return user, nil
+}
+
+// CreateUser stores a new user with a bcrypt password hash
+func (s *UserService) CreateUser(ctx context.Context, username, password, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, fmt.Errorf("unknown role '%s'", role)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	query := `
+		INSERT INTO api_users (username, password_hash, role)
+		VALUES ($1, $2, $3)
+		RETURNING id, username, role, active, created_at`
+
+	user, err := scanUser(s.postgreSQLService.db.QueryRowContext(ctx, query, strings.TrimSpace(username), string(hash), role))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	return user, nil
+}
+
+// ListUsers returns all users ordered by username
+func (s *UserService) ListUsers(ctx context.Context) ([]models.User, error) {
+	rows, err := s.postgreSQLService.db.QueryContext(ctx, `
+		SELECT id, username, role, active, created_at
+		FROM api_users
+		ORDER BY username`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	if err := row.Scan(&user.ID, &user.Username, &user.Role, &user.Active, &user.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
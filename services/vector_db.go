@@ -6,6 +6,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -16,6 +17,8 @@ import (
 type TFIDFVectorDatabase struct {
 	clickHouseService *ClickHouseService
 	seg               gse.Segmenter
+	loadMu            sync.Mutex // serializes the first load; the index is read-only afterwards
+	loadedAt          time.Time
 	documents         map[string]*Document
 	idf               map[string]float64
 	totalDocs         int
@@ -54,13 +57,47 @@ type SearchResult struct {
 	Barcodes         string  `json:"barcodes"`
 	Barcode          string  `json:"barcode"` // Individual barcode from Weaviate
 	QtyAvailable     float64 `json:"qty_available"`
+
+	// Variant grouping fields (only populated when group_by is requested)
+	GroupKey     string         `json:"group_key,omitempty"`
+	VariantCount int            `json:"variant_count,omitempty"`
+	Variants     []SearchResult `json:"variants,omitempty"`
 }
 
 type VectorSearchResponse struct {
 	Data       []SearchResult `json:"data"`
-	TotalCount int            `json:"total_count"`
+	TotalCount int            `json:"total_count"` // same as exact_count, kept for existing clients
 	Query      string         `json:"query"`
 	Duration   float64        `json:"duration_ms"`
+
+	// Pagination counts, see SearchCounts
+	ExactCount     int    `json:"exact_count"`
+	EstimatedTotal int    `json:"estimated_total"`
+	CountStrategy  string `json:"count_strategy,omitempty"`
+	HasMore        bool   `json:"has_more"`
+	NextCursor     string `json:"next_cursor,omitempty"` // pass as "cursor" to fetch the next page
+
+	// Set when a priority search step failed and was skipped (exact matches may be missing) or
+	// when optional stages were left out to stay within X-Latency-Budget-Ms
+	Partial       bool                `json:"partial,omitempty"`
+	FailedSteps   []SearchStepFailure `json:"failed_steps,omitempty"`
+	SkippedStages []string            `json:"skipped_stages,omitempty"`
+
+	// Counts per facet value over all matching products, when facets were requested
+	Facets map[string][]FacetBucket `json:"facets,omitempty"`
+
+	// A corrected query offered when nothing matched, e.g. "coca cola" for "coca colla"
+	SuggestedQuery string `json:"suggested_query,omitempty"`
+
+	// The query sent to Weaviate when ai_enhance or expand_synonyms added terms to it
+	ExpandedQuery string `json:"expanded_query,omitempty"`
+
+	// How /v1/search-by-vector found the page: priority, vector or text (see SearchMethodPriority),
+	// and what its priority steps contributed
+	SearchMethod string          `json:"search_method,omitempty"`
+	Priority     *PrioritySearch `json:"priority,omitempty"`
+
+	fields []string // set by Project
 }
 
 func NewTFIDFVectorDatabase(clickHouseService *ClickHouseService) *TFIDFVectorDatabase {
@@ -84,13 +121,13 @@ func NewTFIDFVectorDatabase(clickHouseService *ClickHouseService) *TFIDFVectorDa
 }
 
 func (vdb *TFIDFVectorDatabase) LoadDocuments(ctx context.Context) error { // Query all products from ClickHouse
-	query := `
-		SELECT code, name
+	query := vdb.clickHouseService.fields.Expand(`
+		SELECT {code}, {name}
 		FROM ic_inventory
-		WHERE name != '' AND name IS NOT NULL
-	`
+		WHERE {name} != '' AND {name} IS NOT NULL
+	`)
 
-	rows, err := vdb.clickHouseService.db.QueryContext(ctx, query)
+	rows, err := vdb.clickHouseService.db.QueryContext(tagContext(ctx), query)
 	if err != nil {
 		return fmt.Errorf("failed to query products: %w", err)
 	}
@@ -146,6 +183,7 @@ func (vdb *TFIDFVectorDatabase) LoadDocuments(ctx context.Context) error { // Qu
 	}
 
 	vdb.totalDocs = len(vdb.documents)
+	vdb.loadedAt = time.Now()
 
 	// Calculate IDF
 	for term := range docCount {
@@ -155,6 +193,11 @@ func (vdb *TFIDFVectorDatabase) LoadDocuments(ctx context.Context) error { // Qu
 	return nil
 }
 
+// Tokenize splits text into the index terms used for TF-IDF scoring
+func (vdb *TFIDFVectorDatabase) Tokenize(text string) []string {
+	return vdb.tokenize(text)
+}
+
 func (vdb *TFIDFVectorDatabase) tokenize(text string) []string {
 	text = strings.ToLower(text)
 
@@ -256,13 +299,13 @@ func (vdb *TFIDFVectorDatabase) fetchAdditionalData(ctx context.Context, product
 		args[i] = code
 	}
 
-	query := fmt.Sprintf(`
-		SELECT code, image_url, unit_standard, balance_qty, supplier_code, 100 as price
+	query := vdb.clickHouseService.fields.Expand(fmt.Sprintf(`
+		SELECT {code}, {image_url}, {unit_standard_code}, {balance_qty}, {supplier_code}, 100 as price
 		FROM ic_inventory 
-		WHERE code IN (%s)
-	`, strings.Join(placeholders, ","))
+		WHERE {code} IN (%s)
+	`, strings.Join(placeholders, ",")))
 
-	rows, err := vdb.clickHouseService.db.QueryContext(ctx, query, args...)
+	rows, err := vdb.clickHouseService.db.QueryContext(tagContext(ctx), query, args...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to fetch additional data: %w", err)
 	}
@@ -310,11 +353,8 @@ func (vdb *TFIDFVectorDatabase) fetchAdditionalData(ctx context.Context, product
 func (vdb *TFIDFVectorDatabase) SearchProducts(ctx context.Context, query string, limit, offset int) (*VectorSearchResponse, error) {
 	startTime := time.Now()
 
-	// Ensure documents are loaded
-	if len(vdb.documents) == 0 {
-		if err := vdb.LoadDocuments(ctx); err != nil {
-			return nil, fmt.Errorf("failed to load documents: %w", err)
-		}
+	if err := vdb.ensureLoaded(ctx); err != nil {
+		return nil, err
 	}
 
 	// Step 1: Full text search by code (highest priority)
@@ -388,12 +428,55 @@ func (vdb *TFIDFVectorDatabase) SearchProducts(ctx context.Context, query string
 
 	duration := float64(time.Since(startTime).Nanoseconds()) / 1e6
 
-	return &VectorSearchResponse{
-		Data:       combinedResults,
-		TotalCount: totalCount,
-		Query:      query,
-		Duration:   duration,
-	}, nil
+	resp := &VectorSearchResponse{
+		Data:     combinedResults,
+		Query:    query,
+		Duration: duration,
+	}
+	NewSearchCounts(offset, len(combinedResults), totalCount, -1).Apply(resp)
+	return resp, nil
+}
+
+// RankProducts returns the product codes closest to the query by TF-IDF cosine similarity
+func (vdb *TFIDFVectorDatabase) RankProducts(ctx context.Context, query string, limit int) ([]RankedCode, error) {
+	if err := vdb.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	results, err := vdb.performVectorSearch(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	ranked := make([]RankedCode, len(results))
+	for i, result := range results {
+		ranked[i] = RankedCode{Code: result.ID, Score: result.SimilarityScore}
+	}
+	return ranked, nil
+}
+
+// Stats returns the number of indexed documents and when the index was built; loadedAt is nil
+// before the first search loads it
+func (vdb *TFIDFVectorDatabase) Stats() (documents int, loadedAt *time.Time) {
+	vdb.loadMu.Lock()
+	defer vdb.loadMu.Unlock()
+	if vdb.loadedAt.IsZero() {
+		return 0, nil
+	}
+	loaded := vdb.loadedAt
+	return vdb.totalDocs, &loaded
+}
+
+// ensureLoaded builds the index from ClickHouse on first use
+func (vdb *TFIDFVectorDatabase) ensureLoaded(ctx context.Context) error {
+	vdb.loadMu.Lock()
+	defer vdb.loadMu.Unlock()
+	if len(vdb.documents) > 0 {
+		return nil
+	}
+	if err := vdb.LoadDocuments(ctx); err != nil {
+		return fmt.Errorf("failed to load documents: %w", err)
+	}
+	return nil
 }
 
 // searchByCode performs full text search on product codes
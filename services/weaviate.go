@@ -5,12 +5,18 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"smlgoapi/config"
+	"smlgoapi/tracing"
 
 	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/filters"
 	"github.com/weaviate/weaviate-go-client/v4/weaviate/graphql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Product represents a product in the vector search results
@@ -24,6 +30,10 @@ type Product struct {
 // WeaviateService handles vector database operations
 type WeaviateService struct {
 	client *weaviate.Client
+	schema config.WeaviateSchemaConfig
+
+	mu     sync.RWMutex
+	fields weaviateFields
 }
 
 // NewWeaviateService creates a new Weaviate service
@@ -72,29 +82,67 @@ func NewWeaviateService(config *config.Config) (*WeaviateService, error) {
 
 	log.Printf("🔗 Connected to Weaviate at: %s://%s", cfg.Scheme, cfg.Host)
 
-	return &WeaviateService{
+	service := &WeaviateService{
 		client: client,
-	}, nil
+		schema: config.Weaviate.Schema,
+	}
+
+	// Check the class has the properties the search reads before accepting traffic, so a schema
+	// change shows up as a clear startup error instead of failed searches
+	schemaCtx, schemaCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer schemaCancel()
+	if err := service.validateSchemaWithRetry(schemaCtx); err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// SearchProducts performs vector search using Weaviate BM25. When the query fails because the
+// schema changed since startup, the mapping is re-resolved and the search retried once.
+func (w *WeaviateService) SearchProducts(ctx context.Context, query string, limit int) (products []Product, err error) {
+	ctx, span := tracing.Start(ctx, "weaviate bm25", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "weaviate"),
+		attribute.String("db.query.text", query),
+		attribute.Int("smlgoapi.search.limit", limit)))
+	defer func() {
+		span.SetAttributes(attribute.Int("smlgoapi.search.results", len(products)))
+		tracing.End(span, err)
+	}()
+
+	products, err = w.searchProducts(ctx, query, limit, w.currentFields())
+	if err == nil || !isSchemaDriftError(err.Error()) {
+		return products, err
+	}
+
+	log.Printf("⚠️ Weaviate search failed on schema mismatch, re-reading schema: %v", err)
+	if schemaErr := w.ValidateSchema(ctx); schemaErr != nil {
+		return nil, schemaErr
+	}
+	return w.searchProducts(ctx, query, limit, w.currentFields())
 }
 
-// SearchProducts performs vector search using Weaviate BM25
-func (w *WeaviateService) SearchProducts(ctx context.Context, query string, limit int) ([]Product, error) {
-	className := "Product"
+func (w *WeaviateService) searchProducts(ctx context.Context, query string, limit int, fields weaviateFields) ([]Product, error) {
+	className := fields.class
 
 	// Use BM25 search since vectorizer is "none"
 	bm25 := w.client.GraphQL().Bm25ArgBuilder().
 		WithQuery(query)
 
+	queryFields := []graphql.Field{
+		{Name: fields.barcode},
+		{Name: fields.icCode},
+		{Name: "_additional", Fields: []graphql.Field{
+			{Name: "score"},
+		}},
+	}
+	if fields.name != "" {
+		queryFields = append(queryFields, graphql.Field{Name: fields.name})
+	}
+
 	result, err := w.client.GraphQL().Get().
 		WithClassName(className).
-		WithFields(
-			graphql.Field{Name: "barcode"},
-			graphql.Field{Name: "name"},
-			graphql.Field{Name: "icCode"}, // แก้จาก ic_code เป็น icCode ตาม Weaviate schema
-			graphql.Field{Name: "_additional", Fields: []graphql.Field{
-				{Name: "score"},
-			}},
-		).
+		WithFields(queryFields...).
 		WithBM25(bm25).
 		WithLimit(limit).
 		Do(ctx)
@@ -103,6 +151,14 @@ func (w *WeaviateService) SearchProducts(ctx context.Context, query string, limi
 		log.Printf("Weaviate search error: %v", err)
 		return nil, err
 	}
+	if len(result.Errors) > 0 {
+		messages := make([]string, len(result.Errors))
+		for i, gqlErr := range result.Errors {
+			messages[i] = gqlErr.Message
+		}
+		log.Printf("Weaviate GraphQL errors: %v", messages)
+		return nil, fmt.Errorf("Weaviate query on class '%s' failed: %s", className, strings.Join(messages, "; "))
+	}
 
 	log.Printf("Weaviate GraphQL result received")
 
@@ -115,15 +171,17 @@ func (w *WeaviateService) SearchProducts(ctx context.Context, query string, limi
 					if product, ok := item.(map[string]interface{}); ok {
 						p := Product{}
 
-						if barcode, ok := product["barcode"].(string); ok {
+						if barcode, ok := product[fields.barcode].(string); ok {
 							p.Barcode = barcode
 						}
 
-						if name, ok := product["name"].(string); ok {
-							p.Name = name
+						if fields.name != "" {
+							if name, ok := product[fields.name].(string); ok {
+								p.Name = name
+							}
 						}
 
-						if icCode, ok := product["icCode"].(string); ok { // แก้จาก ic_code เป็น icCode
+						if icCode, ok := product[fields.icCode].(string); ok {
 							p.ICCode = icCode
 						}
 
@@ -246,3 +304,51 @@ func (w *WeaviateService) GetBarcodeToBarcodeMap(products []Product) map[string]
 
 	return barcodeToBarcodeMap
 }
+
+// IndexedBarcodes returns the barcodes under which a product is stored in the class. An empty
+// result means the product is not indexed.
+func (w *WeaviateService) IndexedBarcodes(ctx context.Context, icCode string) (_ []string, err error) {
+	ctx, span := tracing.Start(ctx, "weaviate get", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "weaviate"),
+		attribute.String("smlgoapi.ic_code", icCode)))
+	defer func() { tracing.End(span, err) }()
+
+	fields := w.currentFields()
+	where := filters.Where().WithPath([]string{fields.icCode}).WithOperator(filters.Equal).WithValueText(icCode)
+	result, err := w.client.GraphQL().Get().
+		WithClassName(fields.class).
+		WithFields(graphql.Field{Name: fields.barcode}).
+		WithWhere(where).
+		WithLimit(100).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("Weaviate query on class '%s' failed: %s", fields.class, result.Errors[0].Message)
+	}
+
+	barcodes := []string{}
+	if data, ok := result.Data["Get"].(map[string]interface{}); ok {
+		objects, _ := data[fields.class].([]interface{})
+		for _, item := range objects {
+			obj, _ := item.(map[string]interface{})
+			if barcode, ok := obj[fields.barcode].(string); ok {
+				barcodes = append(barcodes, barcode)
+			}
+		}
+	}
+	return barcodes, nil
+}
+
+// Ping checks whether the Weaviate server is ready to serve requests
+func (w *WeaviateService) Ping(ctx context.Context) error {
+	ready, err := w.client.Misc().ReadyChecker().Do(ctx)
+	if err != nil {
+		return err
+	}
+	if !ready {
+		return fmt.Errorf("Weaviate reported not ready")
+	}
+	return nil
+}
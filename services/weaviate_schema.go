@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"smlgoapi/config"
+)
+
+// Logical fields read from the Weaviate class; weaviate.schema.fields maps them to property names
+const (
+	WeaviateFieldBarcode = "barcode"
+	WeaviateFieldName    = "name"
+	WeaviateFieldICCode  = "ic_code"
+)
+
+// requiredWeaviateFields must resolve to a property; without them search results cannot be joined
+// with PostgreSQL. A missing name only leaves Product.Name empty.
+var requiredWeaviateFields = []string{WeaviateFieldBarcode, WeaviateFieldICCode}
+
+// WeaviateSchemaError reports fields of the search that have no matching property in the class
+type WeaviateSchemaError struct {
+	Class     string
+	Missing   map[string][]string // logical field -> candidate property names that were tried
+	Available []string            // properties the class actually has
+}
+
+func (e *WeaviateSchemaError) Error() string {
+	fields := make([]string, 0, len(e.Missing))
+	for field := range e.Missing {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = fmt.Sprintf("%s (tried %s)", field, strings.Join(e.Missing[field], ", "))
+	}
+	return fmt.Sprintf("Weaviate class '%s' is missing properties for %s; available properties: %s. Set weaviate.schema.fields to map them",
+		e.Class, strings.Join(parts, "; "), strings.Join(e.Available, ", "))
+}
+
+// weaviateFields is the resolved mapping used to build queries
+type weaviateFields struct {
+	class   string
+	barcode string
+	name    string // empty when the class has no name property
+	icCode  string
+}
+
+// resolveWeaviateFields picks the first candidate of each logical field present in properties
+func resolveWeaviateFields(schema config.WeaviateSchemaConfig, properties []string) (weaviateFields, error) {
+	present := make(map[string]bool, len(properties))
+	for _, prop := range properties {
+		present[prop] = true
+	}
+
+	resolved := map[string]string{}
+	for field, candidates := range schema.Fields {
+		for _, candidate := range candidates {
+			if present[candidate] {
+				resolved[field] = candidate
+				break
+			}
+		}
+	}
+
+	missing := map[string][]string{}
+	for _, field := range requiredWeaviateFields {
+		if resolved[field] == "" {
+			missing[field] = schema.Fields[field]
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(properties)
+		return weaviateFields{}, &WeaviateSchemaError{Class: schema.Class, Missing: missing, Available: properties}
+	}
+	if resolved[WeaviateFieldName] == "" {
+		log.Printf("⚠️ Weaviate class '%s' has no name property (tried %s); product names come from PostgreSQL only",
+			schema.Class, strings.Join(schema.Fields[WeaviateFieldName], ", "))
+	}
+
+	return weaviateFields{
+		class:   schema.Class,
+		barcode: resolved[WeaviateFieldBarcode],
+		name:    resolved[WeaviateFieldName],
+		icCode:  resolved[WeaviateFieldICCode],
+	}, nil
+}
+
+// ValidateSchema reads the class schema from Weaviate and resolves the configured field mapping.
+// The new mapping is used by subsequent searches.
+func (w *WeaviateService) ValidateSchema(ctx context.Context) error {
+	class, err := w.client.Schema().ClassGetter().WithClassName(w.schema.Class).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("reading schema of Weaviate class '%s': %w", w.schema.Class, err)
+	}
+
+	properties := make([]string, 0, len(class.Properties))
+	for _, prop := range class.Properties {
+		properties = append(properties, prop.Name)
+	}
+
+	fields, err := resolveWeaviateFields(w.schema, properties)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	changed := w.fields != fields
+	w.fields = fields
+	w.mu.Unlock()
+
+	if changed {
+		log.Printf("🧩 Weaviate schema mapping for '%s': barcode=%s name=%s ic_code=%s",
+			fields.class, fields.barcode, fields.name, fields.icCode)
+	}
+	return nil
+}
+
+// validateSchemaWithRetry retries ValidateSchema while the schema cannot be read. A schema that
+// was read but does not match is not retried: waiting will not add the missing properties.
+func (w *WeaviateService) validateSchemaWithRetry(ctx context.Context) error {
+	delay := time.Duration(w.schema.RetryDelayMs) * time.Millisecond
+
+	var err error
+	for attempt := 1; attempt <= w.schema.ValidateRetries; attempt++ {
+		err = w.ValidateSchema(ctx)
+		if err == nil {
+			return nil
+		}
+		if _, mismatch := err.(*WeaviateSchemaError); mismatch {
+			return err
+		}
+		if attempt < w.schema.ValidateRetries {
+			log.Printf("⚠️ Weaviate schema check failed (attempt %d/%d): %v", attempt, w.schema.ValidateRetries, err)
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(delay):
+			}
+		}
+	}
+	return err
+}
+
+// currentFields returns the field mapping resolved by the last schema validation
+func (w *WeaviateService) currentFields() weaviateFields {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.fields
+}
+
+// isSchemaDriftError reports whether a GraphQL error means the query names a property or class
+// the schema no longer has
+func isSchemaDriftError(message string) bool {
+	return strings.Contains(message, "Cannot query field") ||
+		strings.Contains(message, "no such prop") ||
+		strings.Contains(message, "class not found")
+}
@@ -0,0 +1,635 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+	"smlgoapi/tracing"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/lib/pq"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/filters"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/graphql"
+	wvmodels "github.com/weaviate/weaviate/entities/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrSyncRunning is returned when a sync is started while another one is still running
+var ErrSyncRunning = errors.New("a Weaviate sync is already running")
+
+// ErrInvalidSyncMode is returned for a mode other than SyncModeFull or SyncModeIncremental
+var ErrInvalidSyncMode = errors.New("unknown sync mode: use full or incremental")
+
+// ErrNoSyncWatermark is returned by an incremental sync before any full sync has completed
+var ErrNoSyncWatermark = errors.New("no sync watermark recorded; run a full sync first")
+
+// Sync modes
+const (
+	SyncModeFull        = "full"        // every product, then stale objects are deleted
+	SyncModeIncremental = "incremental" // only rows changed since the last recorded watermark
+)
+
+// Sync phases reported in WeaviateSyncStatus.Phase
+const (
+	SyncPhaseIdle   = "idle"
+	SyncPhaseUpsert = "upserting"
+	SyncPhaseDelete = "deleting"
+	SyncPhaseDone   = "done"
+	SyncPhaseFailed = "failed"
+)
+
+const (
+	defaultSyncBatch  = 200
+	maxSyncBatch      = 1000
+	maxSyncErrorsKept = 20
+
+	// productSyncStateName is the sync_state row of the Product class sync
+	productSyncStateName = "weaviate_product"
+)
+
+// WeaviateSyncOptions controls a sync run
+type WeaviateSyncOptions struct {
+	Mode      string `json:"mode"`       // SyncModeFull (default) or SyncModeIncremental
+	DryRun    bool   `json:"dry_run"`    // count what would change without writing to Weaviate
+	BatchSize int    `json:"batch_size"` // products read and objects written per batch
+}
+
+// WeaviateSyncWatermark marks how far ic_inventory has been synced. Rows with a higher
+// row_order_ref, or a later updated_at when that column is configured, are picked up next.
+type WeaviateSyncWatermark struct {
+	RowOrderRef int64      `json:"row_order_ref"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// WeaviateSyncStatus is the progress of the current or last sync run
+type WeaviateSyncStatus struct {
+	Running         bool                   `json:"running"`
+	Phase           string                 `json:"phase"`
+	Options         WeaviateSyncOptions    `json:"options"`
+	StartedAt       *time.Time             `json:"started_at,omitempty"`
+	FinishedAt      *time.Time             `json:"finished_at,omitempty"`
+	ProductsRead    int                    `json:"products_read"`
+	ObjectsUpserted int                    `json:"objects_upserted"` // in a dry run: objects that would be written
+	ObjectsFailed   int                    `json:"objects_failed"`
+	ObjectsScanned  int                    `json:"objects_scanned"` // existing Weaviate objects checked for deletion
+	ObjectsDeleted  int                    `json:"objects_deleted"` // in a dry run: stale objects that would be deleted
+	Batches         int                    `json:"batches"`
+	Since           *WeaviateSyncWatermark `json:"since,omitempty"`     // starting point of an incremental sync
+	Watermark       *WeaviateSyncWatermark `json:"watermark,omitempty"` // recorded when the run succeeded
+	Errors          []string               `json:"errors,omitempty"`
+}
+
+// WeaviateSyncService copies products from PostgreSQL into the Weaviate Product class.
+// Every barcode of a product becomes one object with a deterministic id, so re-running a sync
+// overwrites objects in place; objects whose id is no longer produced are deleted.
+type WeaviateSyncService struct {
+	weaviate *WeaviateService
+	pg       *PostgreSQLService
+	cfg      config.WeaviateSyncConfig
+
+	mu     sync.Mutex
+	status WeaviateSyncStatus
+}
+
+// NewWeaviateSyncService creates a sync service; both stores must be available
+func NewWeaviateSyncService(weaviate *WeaviateService, pg *PostgreSQLService, cfg config.WeaviateSyncConfig) *WeaviateSyncService {
+	return &WeaviateSyncService{
+		weaviate: weaviate,
+		pg:       pg,
+		cfg:      cfg,
+		status:   WeaviateSyncStatus{Phase: SyncPhaseIdle},
+	}
+}
+
+// EnsureSchema creates the sync_state table holding sync watermarks if it does not exist
+func (s *WeaviateSyncService) EnsureSchema(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS sync_state (
+			name          TEXT PRIMARY KEY,
+			row_order_ref BIGINT NOT NULL DEFAULT 0,
+			updated_at    TIMESTAMPTZ,
+			last_mode     TEXT NOT NULL,
+			last_run_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`
+
+	if _, err := s.pg.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create sync_state table: %w", err)
+	}
+	return nil
+}
+
+// Status returns a copy of the current progress
+func (s *WeaviateSyncService) Status() WeaviateSyncStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	status.Errors = append([]string(nil), s.status.Errors...)
+	return status
+}
+
+// Start begins a sync in the background, tracked by operations so a shutdown waits for it, and
+// returns immediately
+func (s *WeaviateSyncService) Start(operations *OperationTracker, opts WeaviateSyncOptions) (WeaviateSyncStatus, error) {
+	opts, since, err := s.begin(operations.Context(), opts)
+	if err != nil {
+		return s.Status(), err
+	}
+	err = operations.Go("weaviate-sync", opts.Mode, func(ctx context.Context) {
+		s.run(ctx, opts, since)
+	})
+	if err != nil {
+		s.mu.Lock()
+		s.status.Running = false
+		s.status.Phase = SyncPhaseFailed
+		s.addErrorLocked(err.Error())
+		s.mu.Unlock()
+		return s.Status(), err
+	}
+	return s.Status(), nil
+}
+
+// Run performs a sync and returns when it has finished
+func (s *WeaviateSyncService) Run(ctx context.Context, opts WeaviateSyncOptions) (WeaviateSyncStatus, error) {
+	opts, since, err := s.begin(ctx, opts)
+	if err != nil {
+		return s.Status(), err
+	}
+	err = s.run(ctx, opts, since)
+	return s.Status(), err
+}
+
+// begin validates the options and marks a sync as running
+func (s *WeaviateSyncService) begin(ctx context.Context, opts WeaviateSyncOptions) (WeaviateSyncOptions, *WeaviateSyncWatermark, error) {
+	if opts.Mode == "" {
+		opts.Mode = SyncModeFull
+	}
+	if opts.Mode != SyncModeFull && opts.Mode != SyncModeIncremental {
+		return opts, nil, ErrInvalidSyncMode
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultSyncBatch
+	}
+	if opts.BatchSize > maxSyncBatch {
+		opts.BatchSize = maxSyncBatch
+	}
+
+	// Checked up front so a missing watermark is reported to the caller, not only in the status
+	var since *WeaviateSyncWatermark
+	if opts.Mode == SyncModeIncremental {
+		loadCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		var err error
+		since, err = s.loadWatermark(loadCtx)
+		cancel()
+		if err != nil {
+			return opts, nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status.Running {
+		return opts, nil, ErrSyncRunning
+	}
+	now := time.Now()
+	s.status = WeaviateSyncStatus{Running: true, Phase: SyncPhaseUpsert, Options: opts, StartedAt: &now, Since: since}
+	return opts, since, nil
+}
+
+func (s *WeaviateSyncService) run(ctx context.Context, opts WeaviateSyncOptions, since *WeaviateSyncWatermark) error {
+	mode := ""
+	if opts.DryRun {
+		mode = " (dry run)"
+	}
+	log.Printf("🔄 [weaviate-sync] %s sync started%s, batch size %d", opts.Mode, mode, opts.BatchSize)
+
+	err := s.sync(ctx, opts, since)
+
+	s.mu.Lock()
+	now := time.Now()
+	s.status.Running = false
+	s.status.FinishedAt = &now
+	s.status.Phase = SyncPhaseDone
+	if err != nil {
+		s.status.Phase = SyncPhaseFailed
+		s.addErrorLocked(err.Error())
+	}
+	status := s.status
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Printf("❌ [weaviate-sync] %s sync failed%s after %d products: %v", opts.Mode, mode, status.ProductsRead, err)
+		return err
+	}
+	log.Printf("✅ [weaviate-sync] %s sync finished%s: %d products, %d objects upserted, %d failed, %d deleted in %s",
+		opts.Mode, mode, status.ProductsRead, status.ObjectsUpserted, status.ObjectsFailed, status.ObjectsDeleted,
+		now.Sub(*status.StartedAt).Round(time.Millisecond))
+	return nil
+}
+
+// sync reads ic_inventory, or with since only the rows changed after it, and writes the objects
+func (s *WeaviateSyncService) sync(ctx context.Context, opts WeaviateSyncOptions, since *WeaviateSyncWatermark) error {
+	fields := s.weaviate.currentFields()
+	incremental := since != nil
+
+	// Taken before reading, so rows changed while the sync runs are picked up by the next one
+	watermark, err := s.pg.inventoryWatermark(ctx, s.cfg.UpdatedAtColumn)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[strfmt.UUID]bool)
+	after := ""
+	for {
+		items, err := s.pg.inventoryPage(ctx, after, opts.BatchSize, since, s.cfg.UpdatedAtColumn)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			break
+		}
+		after = items[len(items)-1].Code
+
+		codes := make([]string, len(items))
+		for i, item := range items {
+			codes[i] = item.Code
+		}
+		barcodes, err := s.pg.loadProductBarcodes(ctx, codes)
+		if err != nil {
+			return err
+		}
+
+		batchWanted := make(map[strfmt.UUID]bool)
+		objects := make([]*wvmodels.Object, 0, len(items))
+		for _, item := range items {
+			for _, barcode := range syncBarcodes(barcodes[item.Code]) {
+				obj := productObject(fields, item, barcode)
+				batchWanted[obj.ID] = true
+				objects = append(objects, obj)
+			}
+		}
+
+		written, failed := len(objects), 0
+		if !opts.DryRun {
+			written, failed = s.upsert(ctx, objects)
+		}
+
+		// A full sync finds removed barcodes in its delete phase; an incremental one has to look
+		// at the objects of the products it touched
+		deleted := 0
+		if incremental {
+			if deleted, err = s.deleteRemovedBarcodes(ctx, fields, codes, batchWanted, opts.DryRun); err != nil {
+				return err
+			}
+		} else {
+			for id := range batchWanted {
+				wanted[id] = true
+			}
+		}
+
+		s.mu.Lock()
+		s.status.Batches++
+		s.status.ProductsRead += len(items)
+		s.status.ObjectsUpserted += written
+		s.status.ObjectsFailed += failed
+		s.status.ObjectsDeleted += deleted
+		s.mu.Unlock()
+	}
+
+	if !incremental {
+		// An empty read is far more likely a wrong database than an empty catalogue; never let it
+		// wipe the index
+		if len(wanted) == 0 {
+			return fmt.Errorf("ic_inventory returned no products; stale objects were not deleted")
+		}
+
+		s.mu.Lock()
+		s.status.Phase = SyncPhaseDelete
+		s.mu.Unlock()
+
+		if err := s.deleteStale(ctx, fields.class, wanted, opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+	// Failed objects would be skipped by the next incremental sync if the watermark moved past them
+	if failed := s.Status().ObjectsFailed; failed > 0 {
+		return fmt.Errorf("%d objects failed; watermark not advanced", failed)
+	}
+	if err := s.saveWatermark(ctx, watermark, opts.Mode); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.status.Watermark = watermark
+	s.mu.Unlock()
+	return nil
+}
+
+// upsert writes a batch and returns how many objects were written and how many failed
+func (s *WeaviateSyncService) upsert(ctx context.Context, objects []*wvmodels.Object) (int, int) {
+	if len(objects) == 0 {
+		return 0, 0
+	}
+
+	ctx, span := tracing.Start(ctx, "weaviate batch", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "weaviate"),
+		attribute.Int("smlgoapi.batch.objects", len(objects))))
+	responses, err := s.weaviate.client.Batch().ObjectsBatcher().WithObjects(objects...).Do(ctx)
+	tracing.End(span, err)
+	if err != nil {
+		s.addError(fmt.Sprintf("batch of %d objects failed: %v", len(objects), err))
+		return 0, len(objects)
+	}
+
+	written, failed := 0, 0
+	for _, resp := range responses {
+		if resp.Result != nil && resp.Result.Errors != nil && len(resp.Result.Errors.Error) > 0 {
+			failed++
+			s.addError(fmt.Sprintf("object %s: %s", resp.ID, resp.Result.Errors.Error[0].Message))
+			continue
+		}
+		written++
+	}
+	return written, failed
+}
+
+// deleteStale pages through the class by id and deletes objects the sync did not produce
+func (s *WeaviateSyncService) deleteStale(ctx context.Context, class string, wanted map[strfmt.UUID]bool, opts WeaviateSyncOptions) error {
+	after := ""
+	for {
+		getter := s.weaviate.client.Data().ObjectsGetter().WithClassName(class).WithLimit(opts.BatchSize)
+		if after != "" {
+			getter = getter.WithAfter(after)
+		}
+		objects, err := getter.Do(ctx)
+		if err != nil {
+			return fmt.Errorf("listing Weaviate objects: %w", err)
+		}
+		if len(objects) == 0 {
+			return nil
+		}
+		after = string(objects[len(objects)-1].ID)
+
+		var stale []string
+		for _, obj := range objects {
+			if !wanted[obj.ID] {
+				stale = append(stale, string(obj.ID))
+			}
+		}
+
+		deleted := len(stale)
+		if !opts.DryRun {
+			if deleted, err = s.deleteObjects(ctx, class, stale); err != nil {
+				return err
+			}
+		}
+
+		s.mu.Lock()
+		s.status.ObjectsScanned += len(objects)
+		s.status.ObjectsDeleted += deleted
+		s.mu.Unlock()
+	}
+}
+
+// deleteRemovedBarcodes deletes objects of the given products that the sync no longer produces,
+// i.e. barcodes removed from a product since its objects were written
+func (s *WeaviateSyncService) deleteRemovedBarcodes(ctx context.Context, fields weaviateFields, codes []string, wanted map[strfmt.UUID]bool, dryRun bool) (int, error) {
+	where := filters.Where().WithPath([]string{fields.icCode}).WithOperator(filters.ContainsAny).WithValueText(codes...)
+	result, err := s.weaviate.client.GraphQL().Get().
+		WithClassName(fields.class).
+		WithFields(graphql.Field{Name: "_additional", Fields: []graphql.Field{{Name: "id"}}}).
+		WithWhere(where).
+		WithLimit(maxSyncBatch * 10).
+		Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("listing objects of changed products: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return 0, fmt.Errorf("listing objects of changed products: %s", result.Errors[0].Message)
+	}
+
+	var stale []string
+	if data, ok := result.Data["Get"].(map[string]interface{}); ok {
+		objects, _ := data[fields.class].([]interface{})
+		for _, item := range objects {
+			obj, _ := item.(map[string]interface{})
+			additional, _ := obj["_additional"].(map[string]interface{})
+			if id, ok := additional["id"].(string); ok && !wanted[strfmt.UUID(id)] {
+				stale = append(stale, id)
+			}
+		}
+	}
+
+	if dryRun {
+		return len(stale), nil
+	}
+	return s.deleteObjects(ctx, fields.class, stale)
+}
+
+// deleteObjects deletes objects by id and returns how many were deleted
+func (s *WeaviateSyncService) deleteObjects(ctx context.Context, class string, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	where := filters.Where().WithPath([]string{"id"}).WithOperator(filters.ContainsAny).WithValueText(ids...)
+	resp, err := s.weaviate.client.Batch().ObjectsBatchDeleter().WithClassName(class).WithWhere(where).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("deleting %d stale objects: %w", len(ids), err)
+	}
+	if resp.Results == nil {
+		return 0, nil
+	}
+	return int(resp.Results.Successful), nil
+}
+
+// loadWatermark reads the watermark recorded by the last successful sync
+func (s *WeaviateSyncService) loadWatermark(ctx context.Context) (*WeaviateSyncWatermark, error) {
+	var watermark WeaviateSyncWatermark
+	var updatedAt sql.NullTime
+	err := s.pg.db.QueryRowContext(ctx,
+		`SELECT row_order_ref, updated_at FROM sync_state WHERE name = $1`, productSyncStateName).
+		Scan(&watermark.RowOrderRef, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoSyncWatermark
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync watermark: %w", err)
+	}
+	if updatedAt.Valid {
+		watermark.UpdatedAt = &updatedAt.Time
+	}
+	return &watermark, nil
+}
+
+func (s *WeaviateSyncService) saveWatermark(ctx context.Context, watermark *WeaviateSyncWatermark, mode string) error {
+	_, err := s.pg.db.ExecContext(ctx, `
+		INSERT INTO sync_state (name, row_order_ref, updated_at, last_mode, last_run_at)
+		VALUES ($1, $2, $3, $4, now())
+		ON CONFLICT (name) DO UPDATE
+		SET row_order_ref = EXCLUDED.row_order_ref, updated_at = EXCLUDED.updated_at,
+		    last_mode = EXCLUDED.last_mode, last_run_at = EXCLUDED.last_run_at`,
+		productSyncStateName, watermark.RowOrderRef, watermark.UpdatedAt, mode)
+	if err != nil {
+		return fmt.Errorf("failed to save sync watermark: %w", err)
+	}
+	return nil
+}
+
+func (s *WeaviateSyncService) addError(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addErrorLocked(msg)
+}
+
+// addErrorLocked keeps the first errors of a run; the counters show how many objects failed
+func (s *WeaviateSyncService) addErrorLocked(msg string) {
+	if len(s.status.Errors) < maxSyncErrorsKept {
+		s.status.Errors = append(s.status.Errors, msg)
+	}
+}
+
+// syncBarcodes returns the barcodes to index for a product. Products without a barcode are
+// indexed once under an empty barcode so they can still be found by name.
+func syncBarcodes(barcodes []models.ProductBarcode) []string {
+	if len(barcodes) == 0 {
+		return []string{""}
+	}
+	seen := make(map[string]bool, len(barcodes))
+	result := make([]string, 0, len(barcodes))
+	for _, b := range barcodes {
+		if !seen[b.Barcode] {
+			seen[b.Barcode] = true
+			result = append(result, b.Barcode)
+		}
+	}
+	return result
+}
+
+// productObject builds the Weaviate object for one barcode of a product
+func productObject(fields weaviateFields, item inventoryItem, barcode string) *wvmodels.Object {
+	props := map[string]interface{}{
+		fields.icCode:  item.Code,
+		fields.barcode: barcode,
+	}
+	if fields.name != "" {
+		props[fields.name] = item.Name
+	}
+	return &wvmodels.Object{
+		Class:      fields.class,
+		ID:         productObjectID(item.Code, barcode),
+		Properties: props,
+	}
+}
+
+// productObjectID derives a stable UUID (version 5 layout) from the ic_code and barcode
+func productObjectID(code, barcode string) strfmt.UUID {
+	sum := sha1.Sum([]byte("smlgoapi/product\x00" + code + "\x00" + barcode))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return strfmt.UUID(fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16]))
+}
+
+// inventoryItem is the part of an ic_inventory row indexed in Weaviate
+type inventoryItem struct {
+	Code string
+	Name string
+}
+
+// inventoryWatermark returns the highest row_order_ref and, when updatedColumn is set, the
+// latest change time in ic_inventory
+func (s *PostgreSQLService) inventoryWatermark(ctx context.Context, updatedColumn string) (*WeaviateSyncWatermark, error) {
+	updatedExpr := "NULL::timestamptz"
+	if updatedColumn != "" {
+		updatedExpr = "MAX(" + pq.QuoteIdentifier(updatedColumn) + ")"
+	}
+
+	var watermark WeaviateSyncWatermark
+	var updatedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, s.fields.Expand(`SELECT COALESCE(MAX({row_order_ref}), 0), `+updatedExpr+` FROM ic_inventory`)).
+		Scan(&watermark.RowOrderRef, &updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ic_inventory watermark: %w", err)
+	}
+	if updatedAt.Valid {
+		watermark.UpdatedAt = &updatedAt.Time
+	}
+	return &watermark, nil
+}
+
+// inventoryPage returns up to limit products ordered by code, starting after the given code.
+// With since, only rows added or changed after that watermark are returned.
+func (s *PostgreSQLService) inventoryPage(ctx context.Context, after string, limit int, since *WeaviateSyncWatermark, updatedColumn string) ([]inventoryItem, error) {
+	where := "{code} IS NOT NULL AND CAST({code} AS TEXT) > $1"
+	args := []interface{}{after, limit}
+	if since != nil {
+		var changed string
+		changed, args = inventoryChangedSince(since, updatedColumn, args)
+		where += " AND " + changed
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.fields.Expand(`
+		SELECT CAST({code} AS TEXT), `+productNameExpr+`
+		FROM ic_inventory
+		WHERE `+where+`
+		ORDER BY CAST({code} AS TEXT)
+		LIMIT $2`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ic_inventory: %w", err)
+	}
+	defer rows.Close()
+
+	var items []inventoryItem
+	for rows.Next() {
+		var item inventoryItem
+		if err := rows.Scan(&item.Code, &item.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan ic_inventory row: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// inventoryChanges counts the ic_inventory rows changed after since and, when updatedColumn is
+// set, returns the change time of the oldest of them
+func (s *PostgreSQLService) inventoryChanges(ctx context.Context, since *WeaviateSyncWatermark, updatedColumn string) (int, *time.Time, error) {
+	oldestExpr := "NULL::timestamptz"
+	if updatedColumn != "" {
+		oldestExpr = "MIN(" + pq.QuoteIdentifier(updatedColumn) + ")"
+	}
+	changed, args := inventoryChangedSince(since, updatedColumn, nil)
+
+	var count int
+	var oldest sql.NullTime
+	err := s.db.QueryRowContext(ctx, s.fields.Expand(`SELECT COUNT(*), `+oldestExpr+` FROM ic_inventory WHERE {code} IS NOT NULL AND `+changed), args...).
+		Scan(&count, &oldest)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to count changed ic_inventory rows: %w", err)
+	}
+	if !oldest.Valid {
+		return count, nil, nil
+	}
+	return count, &oldest.Time, nil
+}
+
+// inventoryChangedSince returns the condition matching rows added or changed after since,
+// numbering its placeholders after args
+func inventoryChangedSince(since *WeaviateSyncWatermark, updatedColumn string, args []interface{}) (string, []interface{}) {
+	args = append(args, since.RowOrderRef)
+	changed := fmt.Sprintf("COALESCE({row_order_ref}, 0) > $%d", len(args))
+	if updatedColumn != "" && since.UpdatedAt != nil {
+		args = append(args, *since.UpdatedAt)
+		changed += fmt.Sprintf(" OR %s > $%d", pq.QuoteIdentifier(updatedColumn), len(args))
+	}
+	return "(" + changed + ")", args
+}
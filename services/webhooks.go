@@ -0,0 +1,631 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"smlgoapi/config"
+	"smlgoapi/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrWebhookNotFound is returned for webhook and delivery ids that do not exist
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// ErrInvalidWebhook is returned when a webhook cannot be registered as given
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// Headers of every webhook call
+const (
+	WebhookEventHeader     = "X-SMLGOAPI-Event"
+	WebhookDeliveryHeader  = "X-SMLGOAPI-Delivery"
+	WebhookTimestampHeader = "X-SMLGOAPI-Timestamp"
+	WebhookSignatureHeader = "X-SMLGOAPI-Signature" // sha256=<hex HMAC of "<timestamp>.<body>">
+)
+
+const (
+	webhookQueueSize     = 1000
+	webhookBatchSize     = 20               // deliveries attempted at once
+	webhookPollInterval  = 5 * time.Second  // due retries are picked up at least this often
+	webhookLease         = 5 * time.Minute  // a claimed delivery is retried after this if the instance dies
+	webhookMaxErrorBytes = 512              // of the response body kept with a failed attempt
+	webhookIdleTimeout   = 10 * time.Second // idle connections to webhook hosts are closed after this
+)
+
+// WebhookService stores the webhooks of /v1/webhooks in PostgreSQL and delivers the events they
+// subscribed to. Emitted events are queued in memory and written as one webhook_deliveries row per
+// subscribed webhook; a worker POSTs due deliveries, signed with the webhook's secret, and retries
+// failures with exponential backoff. Deliveries are claimed with SKIP LOCKED, so several instances
+// can share the queue.
+type WebhookService struct {
+	postgreSQLService *PostgreSQLService
+	cfg               config.WebhooksConfig
+	client            *http.Client
+
+	events  chan models.WebhookPayload
+	wake    chan struct{}
+	dropped atomic.Int64
+	closing atomic.Bool
+	stop    chan struct{}
+	done    sync.WaitGroup
+	once    sync.Once
+}
+
+// NewWebhookService creates a new webhook service; call Start to begin delivering
+func NewWebhookService(postgreSQLService *PostgreSQLService, cfg config.WebhooksConfig) *WebhookService {
+	return &WebhookService{
+		postgreSQLService: postgreSQLService,
+		cfg:               cfg,
+		client: &http.Client{
+			Timeout:   time.Duration(cfg.TimeoutSeconds) * time.Second,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, IdleConnTimeout: webhookIdleTimeout},
+			// A redirect would resend the signed body somewhere the admin did not register
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		events: make(chan models.WebhookPayload, webhookQueueSize),
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+	}
+}
+
+// EnsureSchema creates the webhooks and webhook_deliveries tables if they do not exist
+func (s *WebhookService) EnsureSchema(ctx context.Context) error {
+	statements := []string{`
+		CREATE TABLE IF NOT EXISTS webhooks (
+			id          BIGSERIAL PRIMARY KEY,
+			url         TEXT NOT NULL,
+			events      TEXT[] NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			active      BOOLEAN NOT NULL DEFAULT true,
+			secret      TEXT NOT NULL,
+			created_by  TEXT NOT NULL DEFAULT '',
+			created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`, `
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id               BIGSERIAL PRIMARY KEY,
+			webhook_id       BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+			event            TEXT NOT NULL,
+			payload          JSONB NOT NULL,
+			status           TEXT NOT NULL DEFAULT 'pending',
+			attempts         INT NOT NULL DEFAULT 0,
+			next_attempt_at  TIMESTAMPTZ,
+			last_status_code INT NOT NULL DEFAULT 0,
+			last_error       TEXT NOT NULL DEFAULT '',
+			created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+			delivered_at     TIMESTAMPTZ
+		)`,
+		`CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id DESC)`,
+	}
+	for _, statement := range statements {
+		if _, err := s.postgreSQLService.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to create webhook tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// Start begins recording emitted events and delivering them
+func (s *WebhookService) Start() {
+	s.done.Add(2)
+	go s.record()
+	go s.deliver()
+}
+
+// Close stops the worker, writing the events still queued and waiting for running calls until
+// ctx is done at most. Undelivered deliveries stay in the table for the next start.
+func (s *WebhookService) Close(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		s.closing.Store(true)
+		close(s.stop)
+	})
+	stopped := make(chan struct{})
+	go func() {
+		s.done.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Printf("⚠️ [webhooks] Shutdown before %d queued events were recorded", len(s.events))
+	}
+}
+
+// Emit queues event for every active webhook subscribed to it, without blocking. Nothing is sent
+// when s is nil, so callers need not check whether webhooks are enabled.
+func (s *WebhookService) Emit(event string, data interface{}) {
+	if s == nil || s.closing.Load() {
+		return
+	}
+	id, err := randomWebhookID("evt_", 12)
+	if err != nil {
+		log.Printf("❌ [webhooks] Dropped %s event: %v", event, err)
+		return
+	}
+	payload := models.WebhookPayload{ID: id, Event: event, CreatedAt: time.Now(), Data: data}
+	select {
+	case s.events <- payload:
+	default:
+		if n := s.dropped.Add(1); n == 1 || n%1000 == 0 {
+			log.Printf("⚠️ [webhooks] Queue full, %d events dropped so far", n)
+		}
+	}
+}
+
+// Create registers a webhook; its secret is generated unless req sets one
+func (s *WebhookService) Create(ctx context.Context, req models.WebhookRequest, createdBy string) (*models.Webhook, error) {
+	if err := checkWebhook(req); err != nil {
+		return nil, err
+	}
+	secret := req.Secret
+	if secret == "" {
+		generated, err := randomWebhookID("whsec_", 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = generated
+	}
+	active := req.Active == nil || *req.Active
+
+	webhook, err := scanWebhook(s.postgreSQLService.db.QueryRowContext(ctx, `
+		INSERT INTO webhooks (url, events, description, active, secret, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+webhookColumns,
+		req.URL, pq.Array(req.Events), req.Description, active, secret, createdBy))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+	webhook.Secret = secret
+	return webhook, nil
+}
+
+// Update replaces the URL, events, description and active flag of webhook id, and its secret when
+// req sets one; the new secret is returned only then
+func (s *WebhookService) Update(ctx context.Context, id int64, req models.WebhookRequest) (*models.Webhook, error) {
+	if err := checkWebhook(req); err != nil {
+		return nil, err
+	}
+
+	webhook, err := scanWebhook(s.postgreSQLService.db.QueryRowContext(ctx, `
+		UPDATE webhooks SET
+			url         = $2,
+			events      = $3,
+			description = $4,
+			active      = COALESCE($5, active),
+			secret      = COALESCE(NULLIF($6, ''), secret),
+			updated_at  = now()
+		WHERE id = $1
+		RETURNING `+webhookColumns,
+		id, req.URL, pq.Array(req.Events), req.Description, req.Active, req.Secret))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	webhook.Secret = req.Secret
+	return webhook, nil
+}
+
+// Get returns webhook id without its secret
+func (s *WebhookService) Get(ctx context.Context, id int64) (*models.Webhook, error) {
+	webhook, err := scanWebhook(s.postgreSQLService.db.QueryRowContext(ctx,
+		`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// List returns every webhook without its secret
+func (s *WebhookService) List(ctx context.Context) ([]models.Webhook, error) {
+	rows, err := s.postgreSQLService.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// Delete removes webhook id and its deliveries
+func (s *WebhookService) Delete(ctx context.Context, id int64) error {
+	result, err := s.postgreSQLService.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// Test queues a ping for webhook id, whether or not it is active, and returns the delivery
+func (s *WebhookService) Test(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	eventID, err := randomWebhookID("evt_", 12)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate event id: %w", err)
+	}
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:        eventID,
+		Event:     models.WebhookPing,
+		CreatedAt: time.Now(),
+		Data:      map[string]interface{}{"webhook_id": id},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := scanWebhookDelivery(s.postgreSQLService.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at)
+		SELECT id, $2, $3, now() FROM webhooks WHERE id = $1
+		RETURNING `+webhookDeliveryColumns,
+		id, models.WebhookPing, string(payload)))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue ping: %w", err)
+	}
+	s.signal()
+	return delivery, nil
+}
+
+// Deliveries returns the latest deliveries of webhook id, newest first, optionally of one status
+func (s *WebhookService) Deliveries(ctx context.Context, id int64, status string, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	rows, err := s.postgreSQLService.db.QueryContext(ctx, `
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3`, id, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// Retry queues delivery deliveryID of webhook id again with a fresh set of attempts
+func (s *WebhookService) Retry(ctx context.Context, id, deliveryID int64) (*models.WebhookDelivery, error) {
+	delivery, err := scanWebhookDelivery(s.postgreSQLService.db.QueryRowContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = now(), last_error = '', delivered_at = NULL
+		WHERE id = $1 AND webhook_id = $2
+		RETURNING `+webhookDeliveryColumns, deliveryID, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry delivery: %w", err)
+	}
+	s.signal()
+	return delivery, nil
+}
+
+// Prune removes finished deliveries older than webhooks.retention_days
+func (s *WebhookService) Prune(ctx context.Context) (string, error) {
+	cutoff := time.Now().AddDate(0, 0, -s.cfg.RetentionDays)
+	res, err := s.postgreSQLService.db.ExecContext(ctx,
+		`DELETE FROM webhook_deliveries WHERE status != 'pending' AND created_at < $1`, cutoff)
+	if err != nil {
+		return "", fmt.Errorf("failed to prune webhook_deliveries: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return fmt.Sprintf("removed %d deliveries older than %d days", n, s.cfg.RetentionDays), nil
+}
+
+// record writes every emitted event as deliveries of the webhooks subscribed to it
+func (s *WebhookService) record() {
+	defer s.done.Done()
+	for {
+		select {
+		case payload := <-s.events:
+			s.recordEvent(payload)
+		case <-s.stop:
+			for {
+				select {
+				case payload := <-s.events:
+					s.recordEvent(payload)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *WebhookService) recordEvent(payload models.WebhookPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("❌ [webhooks] Failed to encode %s event: %v", payload.Event, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := s.postgreSQLService.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at)
+		SELECT id, $1, $2, now() FROM webhooks WHERE active AND $1 = ANY(events)`,
+		payload.Event, string(body))
+	if err != nil {
+		log.Printf("❌ [webhooks] Failed to queue %s event: %v", payload.Event, err)
+		return
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		s.signal()
+	}
+}
+
+// signal wakes the delivery worker without waiting for its next poll
+func (s *WebhookService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver attempts due deliveries until stopped
+func (s *WebhookService) deliver() {
+	defer s.done.Done()
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	for {
+		// Keep going while full batches are due
+		for s.deliverBatch() == webhookBatchSize {
+			if s.closing.Load() {
+				return
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// webhookAttempt is a claimed delivery with what is needed to send it
+type webhookAttempt struct {
+	id        int64
+	event     string
+	payload   []byte
+	attempts  int
+	webhookID int64
+	url       string
+	secret    string
+}
+
+// deliverBatch claims up to webhookBatchSize due deliveries, sends them concurrently and records
+// the outcomes. It returns how many were claimed.
+func (s *WebhookService) deliverBatch() int {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The lease pushes next_attempt_at out so no other instance claims a delivery being sent
+	rows, err := s.postgreSQLService.db.QueryContext(ctx, `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = now() + make_interval(secs => $2)
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.event, d.payload, d.attempts, w.id, w.url, w.secret`,
+		webhookBatchSize, webhookLease.Seconds())
+	if err != nil {
+		log.Printf("❌ [webhooks] Failed to claim deliveries: %v", err)
+		return 0
+	}
+	var attempts []webhookAttempt
+	for rows.Next() {
+		var a webhookAttempt
+		if err := rows.Scan(&a.id, &a.event, &a.payload, &a.attempts, &a.webhookID, &a.url, &a.secret); err != nil {
+			log.Printf("❌ [webhooks] Failed to scan delivery: %v", err)
+			continue
+		}
+		attempts = append(attempts, a)
+	}
+	rows.Close()
+
+	var wg sync.WaitGroup
+	for _, attempt := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.attempt(attempt)
+		}()
+	}
+	wg.Wait()
+	return len(attempts)
+}
+
+// attempt sends one delivery and records the outcome, scheduling a retry after a failure
+func (s *WebhookService) attempt(a webhookAttempt) {
+	statusCode, err := s.send(a)
+	attempts := a.attempts + 1
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err == nil {
+		_, err = s.postgreSQLService.db.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET status = 'delivered', attempts = $2, last_status_code = $3, last_error = '',
+			    next_attempt_at = NULL, delivered_at = now()
+			WHERE id = $1`, a.id, attempts, statusCode)
+		if err != nil {
+			log.Printf("❌ [webhooks] Failed to record delivery %d: %v", a.id, err)
+		}
+		return
+	}
+
+	status, next := models.WebhookDeliveryPending, sql.NullTime{Time: time.Now().Add(s.backoff(attempts)), Valid: true}
+	if attempts >= s.cfg.MaxAttempts {
+		status, next = models.WebhookDeliveryFailed, sql.NullTime{}
+		log.Printf("❌ [webhooks] Gave up on %s delivery %d to webhook %d after %d attempts: %v", a.event, a.id, a.webhookID, attempts, err)
+	} else {
+		log.Printf("⚠️ [webhooks] %s delivery %d to webhook %d failed (attempt %d, retry at %s): %v",
+			a.event, a.id, a.webhookID, attempts, next.Time.Format(time.RFC3339), err)
+	}
+	_, dbErr := s.postgreSQLService.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, last_status_code = $4, last_error = $5, next_attempt_at = $6
+		WHERE id = $1`, a.id, status, attempts, statusCode, err.Error(), next)
+	if dbErr != nil {
+		log.Printf("❌ [webhooks] Failed to record delivery %d: %v", a.id, dbErr)
+	}
+}
+
+// send POSTs the payload with its signature; any status outside 2xx is a failure
+func (s *WebhookService) send(a webhookAttempt) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequest(http.MethodPost, a.url, bytes.NewReader(a.payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "smlgoapi-webhooks")
+	req.Header.Set(WebhookEventHeader, a.event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(a.id, 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(a.secret, timestamp, a.payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxErrorBytes))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff is the wait before the attempt after attempts failed ones
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	wait := time.Duration(s.cfg.RetryBaseSeconds) * time.Second
+	limit := time.Duration(s.cfg.RetryMaxSeconds) * time.Second
+	for i := 1; i < attempts && wait < limit; i++ {
+		wait *= 2
+	}
+	return min(wait, limit)
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>" under secret, as sent in
+// X-SMLGOAPI-Signature; receivers compute the same to check a call
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func checkWebhook(req models.WebhookRequest) error {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if len(req.Events) == 0 {
+		return fmt.Errorf("%w: subscribe to at least one of %v", ErrInvalidWebhook, models.WebhookEvents)
+	}
+	for _, event := range req.Events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			return fmt.Errorf("%w: unknown event '%s'; use %v", ErrInvalidWebhook, event, models.WebhookEvents)
+		}
+	}
+	if req.Secret != "" && len(req.Secret) < 16 {
+		return fmt.Errorf("%w: secret must be at least 16 characters", ErrInvalidWebhook)
+	}
+	return nil
+}
+
+// randomWebhookID returns prefix followed by n random bytes in hex, for secrets and event ids
+func randomWebhookID(prefix string, n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
+
+const webhookColumns = `id, url, events, description, active, created_by, created_at, updated_at`
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := row.Scan(&webhook.ID, &webhook.URL, pq.Array(&webhook.Events), &webhook.Description, &webhook.Active,
+		&webhook.CreatedBy, &webhook.CreatedAt, &webhook.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+const webhookDeliveryColumns = `id, webhook_id, event, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at`
+
+func scanWebhookDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var payload []byte
+	var next, delivered sql.NullTime
+	if err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &payload, &delivery.Status, &delivery.Attempts,
+		&next, &delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt, &delivered); err != nil {
+		return nil, err
+	}
+	delivery.Payload = payload
+	if next.Valid {
+		delivery.NextAttemptAt = &next.Time
+	}
+	if delivered.Valid {
+		delivery.DeliveredAt = &delivered.Time
+	}
+	return &delivery, nil
+}